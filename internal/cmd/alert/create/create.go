@@ -10,17 +10,22 @@ import (
 
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
 	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/alerting"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/notifier"
 	"github.com/tj-smith47/shelly-cli/internal/utils"
 )
 
 // Options holds the command options.
 type Options struct {
-	Factory     *cmdutil.Factory
-	Name        string
-	Device      string
-	Condition   string
-	Action      string
-	Description string
+	Factory        *cmdutil.Factory
+	Name           string
+	Device         string
+	Condition      string
+	Action         string
+	Description    string
+	NotifyResolved bool
+	Repeat         time.Duration
 }
 
 // NewCommand creates the alert create command.
@@ -33,25 +38,50 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 		Short:   "Create a monitoring alert",
 		Long: `Create a new monitoring alert for device conditions.
 
-Conditions can be:
-  - offline: Device becomes unreachable
-  - online: Device becomes reachable
-  - power>N: Power consumption exceeds N watts
-  - temperature>N: Temperature exceeds N degrees
+Conditions are expressions over the device's status and are validated when
+the alert is created:
+  - offline / online: Device reachability
+  - <component>.<field> <op> <value>: Compare a status value, e.g.
+    switch:0.apower > 1500, sys.ram_free < 20000, cover:0.state == "open"
+    (operators: > >= < <= == !=; a bare path tests truthiness)
+  - power>N, temperature>N, voltage<N, current>N: Shorthand that reads the
+    first matching component
+  - and / or / not (or && || !) with parentheses combine clauses
+  - <clause> for 5m: Only match once the clause has held for the duration
+  - <comparison> clear < N: Hysteresis; once matched, stay active until the
+    clear threshold is crossed
+
+A comparison whose value is missing, for example while the device is offline,
+is unknown rather than false, so "not switch:0.output" does not match an
+unreachable device; use offline / online to match reachability.
 
 Actions can be:
   - notify: Desktop notification (default)
   - webhook:URL: Send HTTP POST to URL
-  - command:CMD: Execute shell command`,
+  - command:CMD: Execute shell command
+  - notifier:NAME: Send through a notifier from the "notifiers:" config
+    section (smtp, ntfy, gotify, slack, discord, mattermost, mqtt)
+
+Actions run once when the alert starts firing. Use --repeat to send reminders
+while it stays firing and --notify-resolved to also run the action when it
+clears.`,
 		Example: `  # Alert when device goes offline
   shelly alert create kitchen-offline --device kitchen --condition offline
 
   # Alert on high power consumption
   shelly alert create high-power --device heater --condition "power>2000"
 
+  # Sustained overload with hysteresis, combined with low memory
+  shelly alert create overload --device heater \
+    --condition "switch:0.apower > 1500 clear < 1200 for 5m and sys.ram_free < 20000"
+
   # Alert with webhook action
   shelly alert create temp-alert --device sensor --condition "temperature>30" \
-    --action "webhook:http://example.com/alert"`,
+    --action "webhook:http://example.com/alert"
+
+  # Alert through a configured notifier
+  shelly alert create freezer-warm --device freezer --condition "temperature>-10 for 10m" \
+    --action notifier:phone`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.Name = args[0]
@@ -63,6 +93,8 @@ Actions can be:
 	cmd.Flags().StringVarP(&opts.Condition, "condition", "c", "", "Alert condition (required)")
 	cmd.Flags().StringVarP(&opts.Action, "action", "a", "notify", "Action when alert triggers")
 	cmd.Flags().StringVar(&opts.Description, "description", "", "Alert description")
+	cmd.Flags().DurationVar(&opts.Repeat, "repeat", 0, "Repeat the action at this interval while firing (0 = once)")
+	cmd.Flags().BoolVar(&opts.NotifyResolved, "notify-resolved", false, "Also run the action when the alert resolves")
 
 	utils.Must(cmd.MarkFlagRequired("device"))
 	utils.Must(cmd.MarkFlagRequired("condition"))
@@ -82,15 +114,33 @@ func run(_ context.Context, opts *Options) error {
 		return fmt.Errorf("alert %q already exists", opts.Name)
 	}
 
+	if err := alerting.Validate(opts.Condition); err != nil {
+		return fmt.Errorf("invalid condition %q: %w", opts.Condition, err)
+	}
+
+	if name, ok := shelly.AlertNotifierName(opts.Action); ok {
+		n, exists := cfg.Notifiers[name]
+		if !exists {
+			return fmt.Errorf("notifier %q not configured", name)
+		}
+		if err := notifier.Validate(n); err != nil {
+			return fmt.Errorf("notifier %q: %w", name, err)
+		}
+	}
+
 	// Create alert
 	alert := config.Alert{
-		Name:        opts.Name,
-		Description: opts.Description,
-		Device:      opts.Device,
-		Condition:   opts.Condition,
-		Action:      opts.Action,
-		Enabled:     true,
-		CreatedAt:   time.Now().Format(time.RFC3339),
+		Name:           opts.Name,
+		Description:    opts.Description,
+		Device:         opts.Device,
+		Condition:      opts.Condition,
+		Action:         opts.Action,
+		Enabled:        true,
+		NotifyResolved: opts.NotifyResolved,
+		CreatedAt:      time.Now().Format(time.RFC3339),
+	}
+	if opts.Repeat > 0 {
+		alert.RepeatInterval = opts.Repeat.String()
 	}
 
 	cfg.Alerts[opts.Name] = alert
@@ -103,6 +153,9 @@ func run(_ context.Context, opts *Options) error {
 	ios.Printf("  Device: %s\n", opts.Device)
 	ios.Printf("  Condition: %s\n", opts.Condition)
 	ios.Printf("  Action: %s\n", opts.Action)
+	if alert.RepeatInterval != "" {
+		ios.Printf("  Repeat: every %s\n", alert.RepeatInterval)
+	}
 
 	return nil
 }
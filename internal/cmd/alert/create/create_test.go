@@ -792,3 +792,88 @@ func TestNewCommand_Structure(t *testing.T) {
 		})
 	}
 }
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestExecute_InvalidCondition(t *testing.T) {
+	setupTest(t)
+	tf := factory.NewTestFactory(t)
+
+	cmd := NewCommand(tf.Factory)
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{
+		"bad-alert",
+		"--device", "heater",
+		"--condition", "switch:0.apower >> 10",
+	})
+	cmd.SetOut(tf.TestIO.Out)
+	cmd.SetErr(tf.TestIO.ErrOut)
+
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("Expected error for invalid condition")
+	}
+	if !strings.Contains(err.Error(), "invalid condition") {
+		t.Errorf("Error = %q, want invalid condition", err)
+	}
+	if _, exists := tf.Config.Alerts["bad-alert"]; exists {
+		t.Error("Alert should not be created with an invalid condition")
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestExecute_RepeatAndNotifyResolved(t *testing.T) {
+	setupTest(t)
+	tf := factory.NewTestFactory(t)
+
+	cmd := NewCommand(tf.Factory)
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{
+		"stuck-heater",
+		"--device", "heater",
+		"--condition", "power>2000 for 5m",
+		"--repeat", "1h",
+		"--notify-resolved",
+	})
+	cmd.SetOut(tf.TestIO.Out)
+	cmd.SetErr(tf.TestIO.ErrOut)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	alert, exists := tf.Config.Alerts["stuck-heater"]
+	if !exists {
+		t.Fatal("Alert was not created in config")
+	}
+	if alert.RepeatInterval != "1h0m0s" {
+		t.Errorf("RepeatInterval = %q, want %q", alert.RepeatInterval, "1h0m0s")
+	}
+	if !alert.NotifyResolved {
+		t.Error("NotifyResolved should be set")
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestExecute_UnknownNotifier(t *testing.T) {
+	setupTest(t)
+	tf := factory.NewTestFactory(t)
+
+	cmd := NewCommand(tf.Factory)
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{
+		"chat-alert",
+		"--device", "kitchen",
+		"--condition", conditionOffline,
+		"--action", "notifier:missing",
+	})
+	cmd.SetOut(tf.TestIO.Out)
+	cmd.SetErr(tf.TestIO.ErrOut)
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("Execute() error = %v, want notifier not configured", err)
+	}
+	if _, exists := tf.Config.Alerts["chat-alert"]; exists {
+		t.Error("Alert should not be created with an unknown notifier")
+	}
+}
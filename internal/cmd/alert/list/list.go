@@ -9,11 +9,14 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/term"
 )
 
 // Options holds the command options.
 type Options struct {
 	Factory *cmdutil.Factory
+	History bool
+	Limit   int
 }
 
 // NewCommand creates the alert list command.
@@ -24,15 +27,25 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 		Use:     "list",
 		Aliases: []string{"ls", "show"},
 		Short:   "List configured alerts",
-		Long:    `List all configured monitoring alerts.`,
+		Long: `List all configured monitoring alerts.
+
+Alerts that "shelly alert watch" has seen fire show their current lifecycle
+state. Use --history to show recorded transitions (pending, firing, repeat,
+resolved) instead.`,
 		Example: `  # List all alerts
-  shelly alert list`,
+  shelly alert list
+
+  # Show the last 20 alert transitions
+  shelly alert list --history --limit 20`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return run(cmd.Context(), opts)
 		},
 	}
 
+	cmd.Flags().BoolVar(&opts.History, "history", false, "Show alert transition history")
+	cmd.Flags().IntVar(&opts.Limit, "limit", 50, "Maximum history entries to show (0 = all)")
+
 	return cmd
 }
 
@@ -43,6 +56,24 @@ func run(_ context.Context, opts *Options) error {
 		return fmt.Errorf("load config: %w", err)
 	}
 
+	mgr, err := opts.Factory.ConfigManager()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	if opts.History {
+		entries, err := mgr.AlertHistory(opts.Limit)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			ios.Info("No alert history")
+			return nil
+		}
+		term.DisplayAlertHistory(ios, entries)
+		return nil
+	}
+
 	if len(cfg.Alerts) == 0 {
 		ios.Info("No alerts configured")
 		ios.Println("")
@@ -50,6 +81,11 @@ func run(_ context.Context, opts *Options) error {
 		return nil
 	}
 
+	records, err := mgr.LoadAlertStates()
+	if err != nil {
+		ios.DebugErr("load alert state", err)
+	}
+
 	ios.Success("Configured Alerts (%d)", len(cfg.Alerts))
 	ios.Println("")
 
@@ -70,6 +106,9 @@ func run(_ context.Context, opts *Options) error {
 		ios.Printf("    Device: %s\n", alert.Device)
 		ios.Printf("    Condition: %s\n", alert.Condition)
 		ios.Printf("    Action: %s\n", alert.Action)
+		if rec, ok := records[name]; ok && rec.State != "" {
+			ios.Printf("    State: %s since %s\n", rec.State, rec.Since.Local().Format(time.DateTime))
+		}
 		if alert.Description != "" {
 			ios.Printf("    Description: %s\n", alert.Description)
 		}
@@ -410,3 +410,26 @@ func TestRun_InvalidSnoozedUntil(t *testing.T) {
 		t.Errorf("expected 'enabled' status for invalid snooze, got: %s", output)
 	}
 }
+
+func TestRun_HistoryEmpty(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	ios := iostreams.Test(nil, out, errOut)
+
+	mgr := config.NewTestManager(&config.Config{})
+	f := cmdutil.NewFactory().SetIOStreams(ios).SetConfigManager(mgr)
+
+	cmd := NewCommand(f)
+	cmd.SetArgs([]string{"--history"})
+	cmd.SetOut(out)
+	cmd.SetErr(errOut)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "No alert history") {
+		t.Errorf("expected 'No alert history' message, got: %s", out.String())
+	}
+}
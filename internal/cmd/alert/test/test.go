@@ -8,14 +8,19 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/alerting"
+	"github.com/tj-smith47/shelly-cli/internal/term"
 )
 
 const actionNotify = "notify"
 
 // Options holds the command options.
 type Options struct {
-	Factory *cmdutil.Factory
-	Name    string
+	Factory  *cmdutil.Factory
+	Live     bool
+	Name     string
+	Snapshot string
 }
 
 // NewCommand creates the alert test command.
@@ -28,9 +33,29 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 		Short:   "Test an alert by triggering it",
 		Long: `Test an alert by manually triggering its action.
 
-This simulates the alert condition being met and executes the configured action.`,
+This simulates the alert condition being met and executes the configured action.
+
+With --live the condition is first evaluated against the device's current
+status; with --snapshot it is evaluated against a recorded Shelly.GetStatus
+response (or the JSON written by "shelly device status -o json"). Each
+referenced value is shown with whether its comparison matched. Sustained
+"for" windows are treated as satisfied and "clear" thresholds are ignored.
+
+Webhook and command actions are only described. Notifier actions
+(notifier:NAME) send a real message with state "test", so delivery settings
+can be checked end to end.`,
 		Example: `  # Test an alert
-  shelly alert test kitchen-offline`,
+  shelly alert test kitchen-offline
+
+  # Evaluate the condition against the device right now
+  shelly alert test high-power --live
+
+  # Evaluate against a recorded status snapshot
+  shelly device status heater -o json > heater.json
+  shelly alert test high-power --snapshot heater.json
+
+  # Send a test message through the alert's notifier
+  shelly alert test freezer-warm`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.Name = args[0]
@@ -38,10 +63,14 @@ This simulates the alert condition being met and executes the configured action.
 		},
 	}
 
+	cmd.Flags().BoolVar(&opts.Live, "live", false, "Evaluate the condition against the device's current status")
+	cmd.Flags().StringVar(&opts.Snapshot, "snapshot", "", "Evaluate the condition against a recorded status JSON file")
+	cmd.MarkFlagsMutuallyExclusive("live", "snapshot")
+
 	return cmd
 }
 
-func run(_ context.Context, opts *Options) error {
+func run(ctx context.Context, opts *Options) error {
 	ios := opts.Factory.IOStreams()
 	cfg, err := opts.Factory.Config()
 	if err != nil {
@@ -59,6 +88,39 @@ func run(_ context.Context, opts *Options) error {
 	ios.Printf("  Action: %s\n", alert.Action)
 	ios.Println("")
 
+	cond, err := alerting.Parse(alert.Condition)
+	if err != nil {
+		return fmt.Errorf("invalid condition %q: %w", alert.Condition, err)
+	}
+
+	if opts.Live || opts.Snapshot != "" {
+		var snap alerting.Snapshot
+		if opts.Snapshot != "" {
+			snap, err = shelly.LoadAlertSnapshot(opts.Snapshot)
+			if err != nil {
+				return err
+			}
+		} else {
+			snap = opts.Factory.ShellyService().AlertSnapshot(ctx, alert.Device, cond.NeedsStatus())
+		}
+		term.DisplayAlertEvaluation(ios, cond, cond.EvaluateInstant(snap))
+		ios.Println("")
+	}
+
+	if name, ok := shelly.AlertNotifierName(alert.Action); ok {
+		n, exists := cfg.Notifiers[name]
+		if !exists {
+			return fmt.Errorf("notifier %q not configured", name)
+		}
+		if err := shelly.NotifyAlert(ctx, n, alert, "test", "n/a"); err != nil {
+			return fmt.Errorf("notifier %q (%s): %w", name, n.Type, err)
+		}
+		ios.Success("[TEST] Sent test notification via %s (%s)", name, n.Type)
+		ios.Println("")
+		ios.Success("Alert test completed")
+		return nil
+	}
+
 	// Execute the action
 	switch {
 	case alert.Action == actionNotify:
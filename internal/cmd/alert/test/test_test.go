@@ -2,6 +2,12 @@ package test
 
 import (
 	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -437,3 +443,144 @@ func TestRun_ShortCommandAction(t *testing.T) {
 		t.Errorf("expected unknown action warning for 'command:' (8 chars), got stderr: %s", errOutput)
 	}
 }
+
+func TestRun_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	ios := iostreams.Test(nil, out, errOut)
+
+	snapshot := filepath.Join(t.TempDir(), "status.json")
+	data := `{"switch:0":{"apower":1620.5,"output":true},"sys":{"ram_free":18000}}`
+	if err := os.WriteFile(snapshot, []byte(data), 0o600); err != nil {
+		t.Fatalf("write snapshot: %v", err)
+	}
+
+	cfg := &config.Config{
+		Alerts: map[string]config.Alert{
+			"overload": {
+				Name:      "overload",
+				Device:    "heater",
+				Condition: "switch:0.apower > 1500 for 5m and sys.ram_free < 20000",
+				Action:    actionNotify,
+				Enabled:   true,
+			},
+		},
+	}
+	f := cmdutil.NewFactory().SetIOStreams(ios).SetConfigManager(config.NewTestManager(cfg))
+
+	cmd := NewCommand(f)
+	cmd.SetArgs([]string{"overload", "--snapshot", snapshot})
+	cmd.SetOut(out)
+	cmd.SetErr(errOut)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := out.String() + errOut.String()
+	for _, want := range []string{"switch:0.apower = 1620.5", "sys.ram_free = 18000", "Condition is met"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got: %s", want, output)
+		}
+	}
+}
+
+func TestRun_InvalidCondition(t *testing.T) {
+	t.Parallel()
+
+	ios := iostreams.Test(nil, &bytes.Buffer{}, &bytes.Buffer{})
+	cfg := &config.Config{
+		Alerts: map[string]config.Alert{
+			"broken": {Name: "broken", Device: "kitchen", Condition: "power=>1", Action: actionNotify},
+		},
+	}
+	f := cmdutil.NewFactory().SetIOStreams(ios).SetConfigManager(config.NewTestManager(cfg))
+
+	cmd := NewCommand(f)
+	cmd.SetArgs([]string{"broken"})
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for invalid condition")
+	}
+}
+
+func TestRun_NotifierAction(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan map[string]string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read body: %v", err)
+		}
+		var payload map[string]string
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("unmarshal: %v", err)
+		}
+		received <- payload
+	}))
+	t.Cleanup(srv.Close)
+
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	ios := iostreams.Test(nil, out, errOut)
+
+	cfg := &config.Config{
+		Alerts: map[string]config.Alert{
+			"chat-alert": {
+				Name:      "chat-alert",
+				Device:    "living-room",
+				Condition: "offline",
+				Action:    "notifier:team",
+				Enabled:   true,
+			},
+		},
+		Notifiers: map[string]config.Notifier{
+			"team": {Type: "slack", URL: srv.URL},
+		},
+	}
+	f := cmdutil.NewFactory().SetIOStreams(ios).SetConfigManager(config.NewTestManager(cfg))
+
+	cmd := NewCommand(f)
+	cmd.SetArgs([]string{"chat-alert"})
+	cmd.SetOut(out)
+	cmd.SetErr(errOut)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	payload := <-received
+	if !strings.Contains(payload["text"], "chat-alert test on living-room") {
+		t.Errorf("payload text = %q", payload["text"])
+	}
+	if !strings.Contains(out.String(), "Sent test notification via team") {
+		t.Errorf("expected sent message in output, got: %s", out.String())
+	}
+}
+
+func TestRun_NotifierMissing(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	ios := iostreams.Test(nil, out, errOut)
+
+	cfg := &config.Config{
+		Alerts: map[string]config.Alert{
+			"chat-alert": {Name: "chat-alert", Device: "d", Condition: "offline", Action: "notifier:nope", Enabled: true},
+		},
+	}
+	f := cmdutil.NewFactory().SetIOStreams(ios).SetConfigManager(config.NewTestManager(cfg))
+
+	cmd := NewCommand(f)
+	cmd.SetArgs([]string{"chat-alert"})
+	cmd.SetOut(out)
+	cmd.SetErr(errOut)
+
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("expected not configured error, got: %v", err)
+	}
+}
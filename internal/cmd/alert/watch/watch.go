@@ -17,6 +17,7 @@ import (
 type Options struct {
 	Factory  *cmdutil.Factory
 	Interval time.Duration
+	NoEvents bool
 	Once     bool
 }
 
@@ -30,21 +31,32 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 		Short:   "Monitor alerts in real-time",
 		Long: `Monitor configured alerts and trigger actions when conditions are met.
 
-This command runs continuously, polling device status at the specified interval
-and executing alert actions when conditions are triggered.
+This command runs continuously and executes alert actions when conditions are
+triggered. Registered Gen2+ devices are watched over WebSocket, so status
+changes, input events and errors are evaluated within a second. Devices that
+cannot stream (Gen1, unregistered or unreachable at start-up) are polled every
+--interval; streamed devices are also re-checked then so "for" windows elapse.
 
-Conditions supported:
-  - offline: Device becomes unreachable
-  - online: Device becomes reachable
-  - power>N: Power consumption exceeds N watts
-  - power<N: Power consumption below N watts
-  - temperature>N: Temperature exceeds N degrees
-  - temperature<N: Temperature below N degrees
+Each alert moves through pending (condition matched, "for" window running),
+firing and resolved. Actions run once when an alert starts firing, again every
+repeat_interval while it keeps firing, and on resolution when notify_resolved
+is set. Lifecycle state survives restarts and every transition is recorded in
+the history shown by "shelly alert list --history".
+
+Conditions are the expressions accepted by "shelly alert create", e.g.
+  - offline / online: Device reachability, optionally "offline for 2m"
+  - power>N, temperature>N: Shorthand thresholds on the first matching component
+  - switch:0.apower > 1500 clear < 1200 for 5m and sys.ram_free < 20000
+  - event input:0.long_push: A device event (streamed devices only)
+  - switch:0.errors == overpower: Lists compare by membership
+
+Sustained "for" windows and "clear" hysteresis are tracked across checks.
 
 Actions supported:
   - notify: Print to console (default)
   - webhook:URL: Send HTTP POST to URL with alert JSON
-  - command:CMD: Execute shell command`,
+  - command:CMD: Execute shell command
+  - notifier:NAME: Send through a configured notifier`,
 		Example: `  # Monitor alerts every 30 seconds
   shelly alert watch
 
@@ -52,7 +64,10 @@ Actions supported:
   shelly alert watch --interval 1m
 
   # Run once and exit (for cron)
-  shelly alert watch --once`,
+  shelly alert watch --once
+
+  # Poll only, without WebSocket connections
+  shelly alert watch --no-events`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return run(cmd.Context(), opts)
 		},
@@ -60,6 +75,7 @@ Actions supported:
 
 	cmd.Flags().DurationVarP(&opts.Interval, "interval", "i", 30*time.Second, "Check interval")
 	cmd.Flags().BoolVar(&opts.Once, "once", false, "Run once and exit (for cron/scheduled tasks)")
+	cmd.Flags().BoolVar(&opts.NoEvents, "no-events", false, "Poll every device instead of streaming events")
 
 	return cmd
 }
@@ -90,45 +106,64 @@ func run(ctx context.Context, opts *Options) error {
 		return nil
 	}
 
+	mgr, err := opts.Factory.ConfigManager()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	states, err := shelly.LoadAlertStates(mgr, cfg.Alerts)
+	if err != nil {
+		ios.Warning("Could not restore alert state: %v", err)
+	}
+
 	ios.Success("Alert monitor started")
 	ios.Printf("  Monitoring %d alert(s) every %s\n", enabledCount, opts.Interval)
 	ios.Printf("  Press Ctrl+C to stop\n")
 	ios.Println("")
 
-	states := make(map[string]*shelly.AlertState)
-	for name := range cfg.Alerts {
-		states[name] = &shelly.AlertState{}
+	check := func(results []shelly.AlertCheckResult) {
+		for _, result := range results {
+			alert := cfg.Alerts[result.Name]
+			term.DisplayAlertResult(ctx, ios, alert, result)
+		}
+		if err := shelly.RecordAlertResults(mgr, results, states); err != nil {
+			ios.DebugErr("record alert state", err)
+		}
 	}
 
-	ticker := time.NewTicker(opts.Interval)
-	defer ticker.Stop()
-
 	// Run immediately on start
-	for _, result := range svc.CheckAlerts(ctx, cfg.Alerts, states) {
-		alert := cfg.Alerts[result.Name]
-		term.DisplayAlertResult(ctx, ios, alert, result)
-	}
+	check(svc.CheckAlerts(ctx, cfg.Alerts, states))
 
 	if opts.Once {
 		return nil
 	}
 
+	stream := shelly.NewAlertStream()
+	if !opts.NoEvents {
+		var stop func()
+		stream, stop = svc.WatchAlertEvents(cfg.Alerts)
+		defer stop()
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			ios.Println("")
 			ios.Info("Alert monitor stopped")
 			return nil
+		case device := <-stream.Updates():
+			check(svc.CheckStreamedAlerts(ctx, cfg.Alerts, states, stream, device))
 		case <-ticker.C:
 			cfg, err = opts.Factory.Config()
 			if err != nil {
 				ios.DebugErr("reload config", err)
 				continue
 			}
-			for _, result := range svc.CheckAlerts(ctx, cfg.Alerts, states) {
-				alert := cfg.Alerts[result.Name]
-				term.DisplayAlertResult(ctx, ios, alert, result)
-			}
+			// Stream the devices of alerts added while running.
+			stream.WatchAlerts(cfg.Alerts)
+			check(svc.CheckStreamedAlerts(ctx, cfg.Alerts, states, stream, ""))
 		}
 	}
 }
@@ -91,6 +91,12 @@ func TestNewCommand_Flags(t *testing.T) {
 			shorthand:    "",
 			defaultValue: "false",
 		},
+		{
+			name:         "no-events flag",
+			flagName:     "no-events",
+			shorthand:    "",
+			defaultValue: "false",
+		},
 	}
 
 	for _, tt := range tests {
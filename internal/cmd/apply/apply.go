@@ -0,0 +1,124 @@
+// Package apply provides the apply command for converging devices to declarative state.
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil/flags"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/gitops"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory    *cmdutil.Factory
+	Concurrent int
+	Devices    []string
+	DryRun     bool
+	Path       string
+	Prune      bool
+	Yes        bool
+}
+
+// NewCommand creates the apply command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "apply <path>",
+		Aliases: []string{"converge"},
+		Short:   "Converge devices to a desired-state manifest",
+		Long: `Make devices match a declarative manifest.
+
+The changes are planned exactly as "shelly plan" shows them, displayed, and
+applied after confirmation. Applying is idempotent: once devices match the
+manifest, running apply again makes no changes.
+
+Config keys are set per component; scripts, schedules, webhooks, KVS entries
+and virtual components are created or updated to match. With --prune, items
+on a device that a managed section of the manifest does not list are deleted.
+Sections absent from the manifest are never touched.
+
+See "shelly plan --help" for the manifest format.`,
+		Example: `  # Review and apply a manifest
+  shelly apply shelly.yaml
+
+  # Apply without prompting and delete unmanaged items (e.g. in CI)
+  shelly apply ./fleet --prune --yes
+
+  # Apply to a single device only
+  shelly apply shelly.yaml --device kitchen`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.FileThenNoComplete(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Path = args[0]
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Prune, "prune", false, "Delete items the manifest does not list")
+	cmd.Flags().StringSliceVar(&opts.Devices, "device", nil, "Limit to specific devices (default: all in manifest)")
+	flags.AddConcurrencyFlag(cmd, &opts.Concurrent)
+	flags.AddDryRunFlag(cmd, &opts.DryRun)
+	flags.AddYesFlag(cmd, &opts.Yes)
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	desired, err := shelly.ResolveManifest(opts.Path, opts.Devices)
+	if err != nil {
+		return err
+	}
+	if len(desired) == 0 {
+		ios.Warning("No devices in manifest")
+		return nil
+	}
+
+	concurrent := cmdutil.CapConcurrency(ios, opts.Concurrent)
+	client := svc.GitOpsClient(opts.Factory.AutomationService(), opts.Factory.KVSService())
+
+	plan, err := cmdutil.RunWithSpinnerResult(ctx, ios, "Reading device state...", func(ctx context.Context) (*gitops.Plan, error) {
+		return gitops.BuildPlan(ctx, client, desired, opts.Prune, concurrent), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	term.DisplayGitOpsPlan(ios, plan)
+	if !plan.HasChanges() || opts.DryRun {
+		return plan.Err()
+	}
+
+	ios.Println()
+	confirmed, err := opts.Factory.ConfirmAction("Apply these changes?", opts.Yes)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		ios.Warning("Apply cancelled")
+		return nil
+	}
+
+	ios.Println()
+	applied, failed := gitops.Apply(ctx, client, plan, func(r gitops.ApplyResult) {
+		term.DisplayGitOpsApplyResult(ios, r)
+	})
+
+	ios.Println()
+	if failed > 0 {
+		ios.Warning("Applied %d change(s), %d failed", applied, failed)
+		return fmt.Errorf("%d change(s) failed", failed)
+	}
+	ios.Success("Applied %d change(s)", applied)
+	return plan.Err()
+}
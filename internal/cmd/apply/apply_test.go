@@ -0,0 +1,83 @@
+package apply
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "apply <path>" {
+		t.Errorf("Use = %q, want \"apply <path>\"", cmd.Use)
+	}
+	if cmd.Short == "" || cmd.Long == "" || cmd.Example == "" {
+		t.Error("help text is incomplete")
+	}
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("expected error with no args")
+	}
+	for _, name := range []string{"prune", "device", "concurrent", "dry-run", "yes"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("flag %q not found", name)
+		}
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestRun_ManifestErrors(t *testing.T) {
+	fs := factory.SetupTestFs(t)
+
+	files := map[string]string{
+		"/invalid.yaml": "devices:\n  kitchen:\n    scripts:\n      - name: a\n",
+		"/group.yaml":   "groups:\n  office:\n    kvs: {site: office}\n",
+		"/devices.yaml": "devices:\n  kitchen:\n    kvs: {a: 1}\n",
+	}
+	for name, data := range files {
+		if err := afero.WriteFile(fs, name, []byte(data), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		path    string
+		devices []string
+		want    string
+	}{
+		{"/missing.yaml", nil, "read manifest"},
+		{"/invalid.yaml", nil, "file or code"},
+		{"/group.yaml", nil, "group not found"},
+		{"/devices.yaml", []string{"garage"}, "not in the manifest"},
+	}
+	for _, tt := range tests {
+		tf := factory.NewTestFactory(t)
+		err := run(context.Background(), &Options{Factory: tf.Factory, Path: tt.path, Devices: tt.devices})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("run(%s) error = %v, want %q", tt.path, err, tt.want)
+		}
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestRun_Empty(t *testing.T) {
+	fs := factory.SetupTestFs(t)
+	if err := afero.WriteFile(fs, "/empty.yaml", []byte("devices: {}\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tf := factory.NewTestFactory(t)
+	if err := run(context.Background(), &Options{Factory: tf.Factory, Path: "/empty.yaml"}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(tf.ErrString()+tf.OutString(), "No devices in manifest") {
+		t.Errorf("expected empty notice, got:\n%s", tf.ErrString())
+	}
+}
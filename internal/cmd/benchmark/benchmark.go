@@ -3,6 +3,7 @@ package benchmark
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -12,16 +13,31 @@ import (
 	"github.com/tj-smith47/shelly-cli/internal/model"
 	"github.com/tj-smith47/shelly-cli/internal/output"
 	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/benchmark"
 	"github.com/tj-smith47/shelly-cli/internal/term"
 	"github.com/tj-smith47/shelly-cli/internal/utils"
 )
 
+// gen2ConnectionLimit is the most concurrent connections a Gen2+ device
+// accepts, and the ceiling for rate_limit.gen2.max_concurrent.
+const gen2ConnectionLimit = 5
+
 // Options holds the command options.
 type Options struct {
-	Factory    *cmdutil.Factory
-	Device     string
-	Iterations int
-	Warmup     int
+	Factory     *cmdutil.Factory
+	Compare     string
+	Concurrency int
+	Device      string
+	Iterations  int
+	Method      string
+	Params      string
+	RPS         float64
+	Requests    int
+	Save        string
+	Sweep       []int
+	Threshold   float64
+	Transport   string
+	Warmup      int
 }
 
 // NewCommand creates the benchmark command.
@@ -40,15 +56,41 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 
 The benchmark runs multiple iterations to collect statistics on:
   - Ping latency (basic connectivity)
-  - RPC latency (API call response time)
+  - RPC latency (API call response time, using --method)
+
+Results include min, max, average, and percentile statistics (P50, P95, P99).
+
+Load profiles run --requests calls across N parallel clients, each on its
+own connection, optionally paced to a target rate with --rps. Use
+--concurrency for one profile or --sweep for several, and --transport to
+compare HTTP with WebSocket. Load connections bypass the CLI's rate limiter,
+so a sweep shows how many concurrent requests the device itself handles and
+suggests a value for rate_limit.gen2.max_concurrent.
 
-Results include min, max, average, and percentile statistics (P50, P95, P99).`,
+Save a run with --save and check a later run (e.g. after a firmware update)
+with --compare. P50/P95 latency and throughput changes beyond --threshold
+percent, or error rates more than one point higher, are reported as
+regressions and make the command exit non-zero.`,
 		Example: `  # Basic benchmark (10 iterations)
   shelly benchmark kitchen-light
 
   # Extended benchmark
   shelly benchmark kitchen-light --iterations 50
 
+  # Benchmark a specific method
+  shelly benchmark kitchen-light --method Switch.GetStatus --params '{"id":0}'
+  shelly benchmark kitchen-light --method Script.Eval --params '{"id":1,"code":"1+1"}'
+
+  # 4 parallel clients at 20 requests/second
+  shelly benchmark kitchen-light --concurrency 4 --rps 20 --requests 200
+
+  # Concurrency sweep over HTTP and WebSocket
+  shelly benchmark kitchen-light --sweep 1,2,3,4,5 --transport both
+
+  # Save a baseline, then check for regressions after a firmware update
+  shelly benchmark kitchen-light --sweep 1,3,5 --save baseline.json
+  shelly benchmark kitchen-light --sweep 1,3,5 --compare baseline.json
+
   # JSON output for logging
   shelly benchmark kitchen-light --json`,
 		Args: cobra.ExactArgs(1),
@@ -60,18 +102,100 @@ Results include min, max, average, and percentile statistics (P50, P95, P99).`,
 
 	cmd.Flags().IntVarP(&opts.Iterations, "iterations", "n", 10, "Number of iterations")
 	cmd.Flags().IntVar(&opts.Warmup, "warmup", 2, "Number of warmup iterations (not counted)")
+	cmd.Flags().StringVar(&opts.Method, "method", benchmark.DefaultMethod, "RPC method to benchmark (e.g., Shelly.GetStatus, Switch.GetStatus, Script.Eval)")
+	cmd.Flags().StringVar(&opts.Params, "params", "", "RPC parameters as a JSON object")
+	cmd.Flags().IntVarP(&opts.Concurrency, "concurrency", "c", 0, "Parallel clients for a load profile (0 = no load profile)")
+	cmd.Flags().IntSliceVar(&opts.Sweep, "sweep", nil, "Run a load profile at each concurrency level (e.g., 1,2,4)")
+	cmd.Flags().IntVar(&opts.Requests, "requests", 100, "Total requests per load profile")
+	cmd.Flags().Float64Var(&opts.RPS, "rps", 0, "Target requests per second for load profiles (0 = unpaced)")
+	cmd.Flags().StringVar(&opts.Transport, "transport", benchmark.TransportHTTP, "Load profile transport: http, ws or both")
+	cmd.Flags().StringVar(&opts.Save, "save", "", "Save results as a baseline file")
+	cmd.Flags().StringVar(&opts.Compare, "compare", "", "Compare results with a baseline file")
+	cmd.Flags().Float64Var(&opts.Threshold, "threshold", benchmark.DefaultThreshold, "Regression threshold in percent for --compare")
 
 	return cmd
 }
 
 func run(ctx context.Context, opts *Options) error {
 	ios := opts.Factory.IOStreams()
-	svc := opts.Factory.ShellyService()
+
+	if opts.Method == "" {
+		opts.Method = benchmark.DefaultMethod
+	}
+	if opts.Transport == "" {
+		opts.Transport = benchmark.TransportHTTP
+	}
+
+	var params map[string]any
+	if opts.Params != "" {
+		if err := json.Unmarshal([]byte(opts.Params), &params); err != nil {
+			return fmt.Errorf("--params must be a JSON object: %w", err)
+		}
+	}
+	transports, err := loadTransports(opts.Transport)
+	if err != nil {
+		return err
+	}
+	levels := opts.loadLevels()
+	for _, n := range levels {
+		if n < 1 {
+			return fmt.Errorf("concurrency levels must be at least 1, got %d", n)
+		}
+	}
+
+	var baseline *model.BenchmarkResult
+	if opts.Compare != "" {
+		b, err := benchmark.LoadBaseline(opts.Compare)
+		if err != nil {
+			return err
+		}
+		baseline = &b
+	}
 
 	ios.Info("Benchmarking %s (%d iterations + %d warmup)...",
 		opts.Device, opts.Iterations, opts.Warmup)
 	ios.Println("")
 
+	result, err := runSequential(ctx, opts, params)
+	if err != nil {
+		return err
+	}
+	if len(levels) > 0 {
+		result.Load, err = runLoad(ctx, opts, params, transports, levels)
+		if err != nil {
+			return err
+		}
+	}
+
+	if baseline != nil {
+		result.Regressions = benchmark.Compare(*baseline, result, opts.Threshold)
+	}
+	if opts.Save != "" {
+		if err := benchmark.SaveBaseline(opts.Save, result); err != nil {
+			return err
+		}
+	}
+
+	if output.WantsStructured() {
+		if err := output.FormatOutput(ios.Out, result); err != nil {
+			return err
+		}
+	} else {
+		display(opts, result, transports, len(levels) > 1)
+	}
+
+	if len(result.Regressions) > 0 {
+		return fmt.Errorf("%d regression(s) against baseline %s", len(result.Regressions), opts.Compare)
+	}
+	return nil
+}
+
+// runSequential measures one-at-a-time latency of opts.Method and of a
+// lightweight ping call over the CLI's regular connection.
+func runSequential(ctx context.Context, opts *Options, params map[string]any) (model.BenchmarkResult, error) {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
 	var result model.BenchmarkResult
 
 	err := svc.WithDevice(ctx, opts.Device, func(dev *shelly.DeviceClient) error {
@@ -85,20 +209,20 @@ func run(ctx context.Context, opts *Options) error {
 		if opts.Warmup > 0 {
 			ios.Info("Warming up...")
 			for range opts.Warmup {
-				if _, err := conn.Call(ctx, "Shelly.GetDeviceInfo", nil); err != nil {
+				if _, err := conn.Call(ctx, opts.Method, params); err != nil {
 					ios.DebugErr("warmup call", err)
 				}
 			}
 		}
 
 		// Benchmark RPC calls
-		ios.Info("Running RPC benchmark...")
+		ios.Info("Running RPC benchmark (%s)...", opts.Method)
 		rpcLatencies := make([]time.Duration, 0, opts.Iterations)
 		rpcErrors := 0
 
 		for i := range opts.Iterations {
 			start := time.Now()
-			_, err := conn.Call(ctx, "Shelly.GetDeviceInfo", nil)
+			_, err := conn.Call(ctx, opts.Method, params)
 			elapsed := time.Since(start)
 
 			if err != nil {
@@ -139,33 +263,68 @@ func run(ctx context.Context, opts *Options) error {
 		// Build result
 		result = model.BenchmarkResult{
 			Device:      opts.Device,
+			Method:      opts.Method,
 			Iterations:  opts.Iterations,
 			PingLatency: pingStats,
 			RPCLatency:  rpcStats,
 			Summary:     output.FormatBenchmarkSummary(rpcStats),
 			Timestamp:   time.Now(),
 		}
+		if info := dev.Info(); info != nil {
+			result.Model, result.Firmware = info.Model, info.Firmware
+		}
 
 		return nil
 	})
-	if err != nil {
-		return err
-	}
+	return result, err
+}
 
-	if output.WantsStructured() {
-		return output.FormatOutput(ios.Out, result)
+// runLoad runs a load profile for every transport and concurrency level.
+func runLoad(ctx context.Context, opts *Options, params map[string]any, transports []string, levels []int) ([]model.LoadResult, error) {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	var results []model.LoadResult
+	for _, tr := range transports {
+		dial, err := svc.BenchmarkDialer(ctx, opts.Device, tr)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range levels {
+			ios.Info("Running load profile: %s, %d client(s), %d requests...", tr, n, opts.Requests)
+			load, err := benchmark.Run(ctx, dial, benchmark.Profile{
+				Transport:   tr,
+				Method:      opts.Method,
+				Params:      params,
+				Concurrency: n,
+				Requests:    opts.Requests,
+				RPS:         opts.RPS,
+				Warmup:      opts.Warmup,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%s load profile with %d client(s): %w", tr, n, err)
+			}
+			results = append(results, load)
+		}
 	}
+	return results, nil
+}
+
+func display(opts *Options, result model.BenchmarkResult, transports []string, sweep bool) {
+	ios := opts.Factory.IOStreams()
 
-	// Display results
 	ios.Println("")
 	ios.Success("Benchmark Complete")
 	ios.Println("")
 
 	ios.Printf("Device: %s\n", opts.Device)
+	if result.Firmware != "" {
+		ios.Printf("Firmware: %s\n", result.Firmware)
+	}
 	ios.Printf("Iterations: %d\n", opts.Iterations)
 	ios.Println("")
 
-	ios.Printf("RPC Latency:\n")
+	ios.Printf("RPC Latency (%s):\n", opts.Method)
 	term.DisplayLatencyStats(ios, result.RPCLatency)
 
 	ios.Printf("\nPing Latency:\n")
@@ -173,5 +332,64 @@ func run(ctx context.Context, opts *Options) error {
 
 	ios.Printf("\nSummary: %s\n", result.Summary)
 
+	if len(result.Load) > 0 {
+		ios.Printf("\nLoad Profiles:\n")
+		term.DisplayLoadResults(ios, result.Load)
+	}
+	if sweep {
+		for _, tr := range transports {
+			if n := benchmark.SuggestMaxConcurrent(loadFor(result.Load, tr), gen2ConnectionLimit); n > 0 {
+				ios.Printf("Suggested rate_limit.gen2.max_concurrent (%s): %d\n", tr, n)
+			}
+		}
+	}
+
+	if opts.Save != "" {
+		ios.Println("")
+		ios.Success("Baseline saved to %s", opts.Save)
+	}
+	if opts.Compare != "" {
+		ios.Println("")
+		if len(result.Regressions) == 0 {
+			ios.Success("No regressions against %s (threshold %.0f%%)", opts.Compare, opts.Threshold)
+		} else {
+			ios.Warning("Regressions against %s (threshold %.0f%%):", opts.Compare, opts.Threshold)
+			term.DisplayBenchmarkRegressions(ios, result.Regressions)
+		}
+	}
+}
+
+// loadLevels returns the concurrency levels to run load profiles at. A
+// non-default --transport without --concurrency or --sweep runs a single
+// client so transports can be compared.
+func (opts *Options) loadLevels() []int {
+	switch {
+	case len(opts.Sweep) > 0:
+		return opts.Sweep
+	case opts.Concurrency > 0:
+		return []int{opts.Concurrency}
+	case opts.Transport != benchmark.TransportHTTP:
+		return []int{1}
+	}
 	return nil
 }
+
+func loadTransports(name string) ([]string, error) {
+	switch name {
+	case benchmark.TransportHTTP, benchmark.TransportWS:
+		return []string{name}, nil
+	case "both":
+		return []string{benchmark.TransportHTTP, benchmark.TransportWS}, nil
+	}
+	return nil, fmt.Errorf("invalid transport %q (want http, ws or both)", name)
+}
+
+func loadFor(results []model.LoadResult, transport string) []model.LoadResult {
+	var out []model.LoadResult
+	for _, r := range results {
+		if r.Transport == transport {
+			out = append(out, r)
+		}
+	}
+	return out
+}
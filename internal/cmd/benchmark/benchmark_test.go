@@ -73,6 +73,16 @@ func TestNewCommand_Flags(t *testing.T) {
 	}{
 		{name: "iterations", shorthand: "n", defValue: "10"},
 		{name: "warmup", shorthand: "", defValue: "2"},
+		{name: "method", shorthand: "", defValue: "Shelly.GetDeviceInfo"},
+		{name: "params", shorthand: "", defValue: ""},
+		{name: "concurrency", shorthand: "c", defValue: "0"},
+		{name: "sweep", shorthand: "", defValue: "[]"},
+		{name: "requests", shorthand: "", defValue: "100"},
+		{name: "rps", shorthand: "", defValue: "0"},
+		{name: "transport", shorthand: "", defValue: "http"},
+		{name: "save", shorthand: "", defValue: ""},
+		{name: "compare", shorthand: "", defValue: ""},
+		{name: "threshold", shorthand: "", defValue: "20"},
 	}
 
 	for _, tt := range tests {
@@ -1208,3 +1218,119 @@ func TestExecute_DefaultIterations(t *testing.T) {
 		t.Errorf("Expected '2 warmup' in output (default), got: %q", output)
 	}
 }
+
+func loadFixtures(name string) *mock.Fixtures {
+	return &mock.Fixtures{
+		Version: "1",
+		Config: mock.ConfigFixture{
+			Devices: []mock.DeviceFixture{
+				{
+					Name:       name,
+					Address:    "192.168.1.100",
+					MAC:        "AA:BB:CC:DD:EE:FF",
+					Type:       "SNSW-001P16EU",
+					Model:      "Shelly Plus 1PM",
+					Generation: 2,
+				},
+			},
+		},
+		DeviceStates: map[string]mock.DeviceState{
+			name: {"switch:0": map[string]any{"output": false}},
+		},
+	}
+}
+
+func TestExecute_InvalidLoadOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"transport", []string{"dev", "--transport", "coap"}, "invalid transport"},
+		{"params", []string{"dev", "--params", "[1]"}, "--params"},
+		{"sweep", []string{"dev", "--sweep", "1,0"}, "at least 1"},
+		{"baseline", []string{"dev", "--compare", "/missing/baseline.json"}, "read baseline"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tf := factory.NewTestFactory(t)
+
+			cmd := NewCommand(tf.Factory)
+			cmd.SetContext(context.Background())
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Execute() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecute_LoadProfile(t *testing.T) {
+	t.Parallel()
+
+	demo, err := mock.StartWithFixtures(loadFixtures("load-device"))
+	if err != nil {
+		t.Fatalf("StartWithFixtures: %v", err)
+	}
+	defer demo.Cleanup()
+
+	tf := factory.NewTestFactory(t)
+	demo.InjectIntoFactory(tf.Factory)
+
+	cmd := NewCommand(tf.Factory)
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{"load-device", "-n", "2", "--warmup", "0",
+		"--method", "Switch.GetStatus", "--params", `{"id":0}`, "--sweep", "1,2", "--requests", "6"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	output := tf.OutString()
+	if !strings.Contains(output, "Load Profiles") || !strings.Contains(output, "Switch.GetStatus") {
+		t.Errorf("Expected load profile table, got: %q", output)
+	}
+	if !strings.Contains(output, "RPC Latency (Switch.GetStatus)") {
+		t.Errorf("Expected method in RPC latency header, got: %q", output)
+	}
+}
+
+//nolint:paralleltest // Reads the baseline through the test factory's global filesystem
+func TestExecute_SaveAndCompare(t *testing.T) {
+	demo, err := mock.StartWithFixtures(loadFixtures("baseline-device"))
+	if err != nil {
+		t.Fatalf("StartWithFixtures: %v", err)
+	}
+	defer demo.Cleanup()
+
+	tf := factory.NewTestFactory(t)
+	demo.InjectIntoFactory(tf.Factory)
+
+	save := NewCommand(tf.Factory)
+	save.SetContext(context.Background())
+	save.SetArgs([]string{"baseline-device", "-n", "2", "--warmup", "0", "--concurrency", "1", "--requests", "2",
+		"--save", "/baseline.json"})
+	if err := save.Execute(); err != nil {
+		t.Fatalf("save Execute() error = %v", err)
+	}
+	if !strings.Contains(tf.OutString(), "Baseline saved") {
+		t.Errorf("Expected save confirmation, got: %q", tf.OutString())
+	}
+
+	// A huge threshold tolerates the latency jitter of two runs.
+	compare := NewCommand(tf.Factory)
+	compare.SetContext(context.Background())
+	compare.SetArgs([]string{"baseline-device", "-n", "2", "--warmup", "0", "--concurrency", "1", "--requests", "2",
+		"--compare", "/baseline.json", "--threshold", "100000"})
+	if err := compare.Execute(); err != nil {
+		t.Fatalf("compare Execute() error = %v", err)
+	}
+	if !strings.Contains(tf.OutString(), "No regressions") {
+		t.Errorf("Expected no regressions, got: %q", tf.OutString())
+	}
+}
@@ -0,0 +1,106 @@
+// Package assign provides the drift assign subcommand.
+package assign
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/backup"
+	"github.com/tj-smith47/shelly-cli/internal/utils"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory  *cmdutil.Factory
+	Device   string
+	Backup   string
+	Template string
+	Manifest string
+}
+
+// NewCommand creates the drift assign command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "assign <device>",
+		Aliases: []string{"set"},
+		Short:   "Assign a device's drift baseline",
+		Long: `Assign the baseline a device is compared against by "shelly drift watch".
+
+The baseline is one of:
+  --backup     A backup file ("shelly backup create"); compared in full,
+               including scripts, schedules and webhooks
+  --template   A device template; only the components it defines are compared
+  --manifest   A manifest file or directory ("shelly apply"); the sections it
+               manages are compared, and unlisted items in them count as drift
+
+Assigning a new baseline replaces the previous one. File paths are stored as
+absolute paths.`,
+		Example: `  # Compare against a backup
+  shelly drift assign kitchen --backup backups/kitchen.json
+
+  # Compare against a template
+  shelly drift assign porch --template outdoor-plug
+
+  # Compare against a manifest repository
+  shelly drift assign office-desk --manifest ./infra`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.DeviceNames(),
+		RunE: func(_ *cobra.Command, args []string) error {
+			opts.Device = args[0]
+			return run(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Backup, "backup", "", "Backup file to compare against")
+	cmd.Flags().StringVar(&opts.Template, "template", "", "Device template to compare against")
+	cmd.Flags().StringVar(&opts.Manifest, "manifest", "", "Manifest file or directory to compare against")
+	cmd.MarkFlagsMutuallyExclusive("backup", "template", "manifest")
+	cmd.MarkFlagsOneRequired("backup", "template", "manifest")
+	utils.Must(cmd.RegisterFlagCompletionFunc("template", completion.TemplateNames()))
+
+	return cmd
+}
+
+func run(opts *Options) error {
+	ios := opts.Factory.IOStreams()
+
+	baseline := config.DriftBaseline{Template: opts.Template}
+	switch {
+	case opts.Backup != "":
+		if _, err := backup.LoadAndValidate(opts.Backup); err != nil {
+			return err
+		}
+		path, err := filepath.Abs(opts.Backup)
+		if err != nil {
+			return err
+		}
+		baseline.Backup = path
+	case opts.Manifest != "":
+		if _, err := shelly.ResolveManifest(opts.Manifest, []string{opts.Device}); err != nil {
+			return err
+		}
+		path, err := filepath.Abs(opts.Manifest)
+		if err != nil {
+			return err
+		}
+		baseline.Manifest = path
+	default:
+		if _, ok := config.GetDeviceTemplate(opts.Template); !ok {
+			return fmt.Errorf("template %q not found", opts.Template)
+		}
+	}
+
+	if err := config.SetDriftBaseline(opts.Device, baseline); err != nil {
+		return err
+	}
+	ios.Success("Drift baseline for %s set to %s", opts.Device, baseline)
+	return nil
+}
@@ -0,0 +1,74 @@
+package assign
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "assign <device>" {
+		t.Errorf("Use = %q, want \"assign <device>\"", cmd.Use)
+	}
+	if cmd.ValidArgsFunction == nil {
+		t.Error("ValidArgsFunction is nil")
+	}
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("expected error with no args")
+	}
+	for _, name := range []string{"backup", "template", "manifest"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("flag %q not found", name)
+		}
+	}
+}
+
+func TestNewCommand_RequiresOneBaseline(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	for _, args := range [][]string{
+		{"kitchen"},
+		{"kitchen", "--template", "plug", "--backup", "kitchen.json"},
+	} {
+		cmd := NewCommand(tf.Factory)
+		cmd.SetArgs(args)
+		cmd.SetOut(&strings.Builder{})
+		cmd.SetErr(&strings.Builder{})
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("Execute(%v) expected error", args)
+		}
+	}
+}
+
+//nolint:paralleltest // Test modifies global config state
+func TestRun_Template(t *testing.T) {
+	config.ResetDefaultManagerForTesting()
+	t.Cleanup(config.ResetDefaultManagerForTesting)
+
+	config.SetDefaultManager(config.NewTestManager(&config.Config{
+		Templates: config.TemplatesConfig{Device: map[string]config.DeviceTemplate{
+			"plug": {Name: "plug", Config: map[string]any{"sys": map[string]any{}}},
+		}},
+	}))
+
+	tf := factory.NewTestFactory(t)
+	if err := run(&Options{Factory: tf.Factory, Device: "kitchen", Template: "plug"}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if got, ok := config.GetDriftBaseline("kitchen"); !ok || got.Template != "plug" {
+		t.Errorf("baseline = %+v, %v", got, ok)
+	}
+
+	err := run(&Options{Factory: tf.Factory, Device: "kitchen", Template: "missing"})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("run() error = %v, want template not found", err)
+	}
+}
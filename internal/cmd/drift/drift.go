@@ -0,0 +1,43 @@
+// Package drift provides configuration drift detection commands.
+package drift
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmd/drift/assign"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/drift/list"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/drift/unassign"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/drift/watch"
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+)
+
+// NewCommand creates the drift command and its subcommands.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Detect devices drifting from their baseline",
+		Long: `Detect devices whose configuration has drifted from a known-good baseline.
+
+Each device is assigned a baseline: a backup file, a device template or a
+declarative manifest (see "shelly apply"). "shelly drift watch" compares
+devices against their baselines periodically, records when drift appears,
+changes or resolves, and can notify through alert actions and expose drift
+as Prometheus metrics. This catches settings silently lost or changed, for
+example schedules removed by a firmware update.`,
+		Example: `  # Watch the kitchen plug against a backup taken when it was set up
+  shelly drift assign kitchen --backup backups/kitchen.json
+
+  # Show baselines and their last known state
+  shelly drift list
+
+  # Check every hour and post drift to a webhook
+  shelly drift watch --interval 1h --action webhook:http://hub/drift`,
+	}
+
+	cmd.AddCommand(assign.NewCommand(f))
+	cmd.AddCommand(unassign.NewCommand(f))
+	cmd.AddCommand(list.NewCommand(f))
+	cmd.AddCommand(watch.NewCommand(f))
+
+	return cmd
+}
@@ -0,0 +1,25 @@
+package drift
+
+import (
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "drift" {
+		t.Errorf("Use = %q, want drift", cmd.Use)
+	}
+	if cmd.Short == "" {
+		t.Error("Short description is empty")
+	}
+	for _, name := range []string{"assign", "unassign", "list", "watch"} {
+		if sub, _, err := cmd.Find([]string{name}); err != nil || sub.Name() != name {
+			t.Errorf("subcommand %q not found", name)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Package list provides the drift list subcommand.
+package list
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory *cmdutil.Factory
+	History bool
+	Limit   int
+}
+
+// NewCommand creates the drift list command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls", "status"},
+		Short:   "List drift baselines and state",
+		Long: `List devices with an assigned drift baseline and the state recorded by the
+last "shelly drift watch" check. Use --history to show recorded drift events
+(detected, changed, resolved) instead.`,
+		Example: `  # Show baselines and drift state
+  shelly drift list
+
+  # Show the last 20 drift events
+  shelly drift list --history --limit 20`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return run(opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.History, "history", false, "Show drift event history")
+	cmd.Flags().IntVar(&opts.Limit, "limit", 50, "Maximum history entries to show (0 = all)")
+
+	return cmd
+}
+
+func run(opts *Options) error {
+	ios := opts.Factory.IOStreams()
+	cfg, err := opts.Factory.Config()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	mgr, err := opts.Factory.ConfigManager()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	if opts.History {
+		events, err := mgr.DriftHistory(opts.Limit)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			ios.Info("No drift history")
+			return nil
+		}
+		return cmdutil.PrintResult(ios, events, term.DisplayDriftHistory)
+	}
+
+	if len(cfg.Drift) == 0 {
+		ios.Info("No drift baselines assigned")
+		ios.Info("Assign one with: shelly drift assign <device> --backup <file>")
+		return nil
+	}
+
+	states, err := mgr.LoadDriftStates()
+	if err != nil {
+		ios.DebugErr("load drift state", err)
+	}
+	return cmdutil.PrintResult(ios, shelly.DriftStatuses(cfg.Drift, states), term.DisplayDriftStatuses)
+}
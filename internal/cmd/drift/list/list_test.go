@@ -0,0 +1,54 @@
+package list
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "list" {
+		t.Errorf("Use = %q, want list", cmd.Use)
+	}
+	if cmd.Flags().Lookup("history") == nil || cmd.Flags().Lookup("limit") == nil {
+		t.Error("history/limit flags not found")
+	}
+}
+
+func TestRun_NoBaselines(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	if err := run(&Options{Factory: tf.Factory}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if out := tf.OutString() + tf.ErrString(); !strings.Contains(out, "No drift baselines") {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestRun_Baselines(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	tf.Config.Drift = map[string]config.DriftBaseline{
+		"kitchen": {Template: "plug"},
+		"porch":   {Backup: "/backups/porch.json"},
+	}
+	if err := run(&Options{Factory: tf.Factory}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	out := tf.OutString()
+	for _, want := range []string{"kitchen", "template:plug", "backup:/backups/porch.json", "unchecked"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Package unassign provides the drift unassign subcommand.
+package unassign
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// NewCommand creates the drift unassign command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "unassign <device>",
+		Aliases:           []string{"rm", "remove", "unset"},
+		Short:             "Stop watching a device for drift",
+		Long:              `Remove a device's drift baseline so "shelly drift watch" no longer checks it.`,
+		Example:           `  shelly drift unassign kitchen`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.DeviceNames(),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := config.DeleteDriftBaseline(args[0]); err != nil {
+				return err
+			}
+			f.IOStreams().Success("Drift baseline for %s removed", args[0])
+			return nil
+		},
+	}
+
+	return cmd
+}
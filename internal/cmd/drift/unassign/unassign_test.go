@@ -0,0 +1,34 @@
+package unassign
+
+import (
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+//nolint:paralleltest // Test modifies global config state
+func TestRun_Unassign(t *testing.T) {
+	config.ResetDefaultManagerForTesting()
+	t.Cleanup(config.ResetDefaultManagerForTesting)
+
+	config.SetDefaultManager(config.NewTestManager(&config.Config{
+		Drift: map[string]config.DriftBaseline{"kitchen": {Template: "plug"}},
+	}))
+
+	tf := factory.NewTestFactory(t)
+	cmd := NewCommand(tf.Factory)
+	cmd.SetArgs([]string{"kitchen"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if _, ok := config.GetDriftBaseline("kitchen"); ok {
+		t.Error("baseline still assigned")
+	}
+
+	cmd = NewCommand(tf.Factory)
+	cmd.SetArgs([]string{"kitchen"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for device without baseline")
+	}
+}
@@ -0,0 +1,223 @@
+// Package watch provides the drift watch subcommand.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+	"github.com/tj-smith47/shelly-cli/internal/utils"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory     *cmdutil.Factory
+	Action      string
+	Devices     []string
+	Interval    time.Duration
+	MetricsPort int
+	Once        bool
+}
+
+// NewCommand creates the drift watch command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "watch",
+		Aliases: []string{"daemon", "run"},
+		Short:   "Periodically check devices for drift",
+		Long: `Compare each device with an assigned baseline every --interval and report
+when it drifts.
+
+A device drifts when its live state no longer matches its baseline. Each
+transition is recorded with a timestamp and shown by "shelly drift list
+--history":
+  - detected: The device started to differ from its baseline
+  - changed:  A drifted device now differs in another way
+  - resolved: The device matches its baseline again
+
+Unchanged drift is not reported again, including across restarts. Failed
+checks (e.g. an unreachable device) are noted but do not change the state.
+
+--action runs on every event, using the alert action syntax:
+  - notify: Print to console (default)
+  - webhook:URL: Send HTTP POST to URL with the drift as alert JSON
+  - command:CMD: Execute shell command (SHELLY_ALERT_* environment)
+  - notifier:NAME: Send through a configured notifier
+
+--metrics-port serves the drift state for Prometheus at /metrics
+(shelly_drift_detected, shelly_drift_changes, shelly_drift_check_failed,
+shelly_drift_last_check_timestamp_seconds, shelly_drift_events_total).`,
+		Example: `  # Check hourly
+  shelly drift watch
+
+  # Check once and exit (for cron)
+  shelly drift watch --once
+
+  # Notify through a configured notifier and export metrics
+  shelly drift watch --interval 15m --action notifier:phone --metrics-port 9102`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().DurationVarP(&opts.Interval, "interval", "i", time.Hour, "Check interval")
+	cmd.Flags().BoolVar(&opts.Once, "once", false, "Check once and exit (for cron/scheduled tasks)")
+	cmd.Flags().StringVarP(&opts.Action, "action", "a", shelly.ActionTypeNotify, "Action to run on drift events")
+	cmd.Flags().StringSliceVar(&opts.Devices, "device", nil, "Only check these devices")
+	cmd.Flags().IntVar(&opts.MetricsPort, "metrics-port", 0, "Serve Prometheus metrics on this port (0 = disabled)")
+	utils.Must(cmd.RegisterFlagCompletionFunc("device", completion.DeviceNames()))
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	if opts.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if name, ok := shelly.AlertNotifierName(opts.Action); ok {
+		if _, exists := config.GetNotifier(name); !exists {
+			return fmt.Errorf("notifier %q not configured", name)
+		}
+	}
+
+	baselines, err := loadBaselines(opts)
+	if err != nil {
+		return err
+	}
+	if len(baselines) == 0 {
+		ios.Warning("No drift baselines assigned")
+		ios.Info("Assign one with: shelly drift assign <device> --backup <file>")
+		return nil
+	}
+
+	mgr, err := opts.Factory.ConfigManager()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	monitor, err := svc.NewDriftMonitor(mgr, opts.Factory.AutomationService(), opts.Factory.KVSService())
+	if err != nil {
+		ios.Warning("Could not restore drift state: %v", err)
+	}
+
+	check := func() []shelly.DriftResult {
+		results, events, err := monitor.Check(ctx, baselines)
+		if err != nil {
+			ios.DebugErr("record drift state", err)
+		}
+		for _, ev := range events {
+			term.DisplayDriftEvent(ios, ev)
+			if opts.Action == shelly.ActionTypeNotify {
+				continue
+			}
+			if res := shelly.NotifyDrift(ctx, opts.Action, ev); res.Error != nil {
+				ios.Error("[%s] Drift action failed for %s: %v", time.Now().Format("15:04:05"), ev.Device, res.Error)
+			}
+		}
+		return results
+	}
+
+	if opts.Once {
+		term.DisplayDriftResults(ios, check())
+		return nil
+	}
+
+	if opts.MetricsPort > 0 {
+		go serveMetrics(ctx, ios, opts.MetricsPort, monitor)
+	}
+
+	ios.Success("Drift monitor started")
+	ios.Printf("  Checking %d device(s) every %s\n", len(baselines), opts.Interval)
+	if opts.MetricsPort > 0 {
+		ios.Printf("  Metrics at http://localhost:%d/metrics\n", opts.MetricsPort)
+	}
+	ios.Printf("  Press Ctrl+C to stop\n")
+	ios.Println("")
+
+	term.DisplayDriftResults(ios, check())
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			ios.Println("")
+			ios.Info("Drift monitor stopped")
+			return nil
+		case <-ticker.C:
+			// Pick up baselines assigned or removed while running.
+			if reloaded, err := loadBaselines(opts); err != nil {
+				ios.DebugErr("reload config", err)
+			} else {
+				baselines = reloaded
+			}
+			check()
+		}
+	}
+}
+
+// loadBaselines returns the assigned baselines, limited to --device when set.
+func loadBaselines(opts *Options) (map[string]config.DriftBaseline, error) {
+	cfg, err := opts.Factory.Config()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	baselines := make(map[string]config.DriftBaseline, len(cfg.Drift))
+	for device, baseline := range cfg.Drift {
+		if len(opts.Devices) == 0 || slices.Contains(opts.Devices, device) {
+			baselines[device] = baseline
+		}
+	}
+	for _, device := range opts.Devices {
+		if _, ok := baselines[device]; !ok {
+			return nil, fmt.Errorf("no drift baseline for device %q", device)
+		}
+	}
+	return baselines, nil
+}
+
+// serveMetrics serves the monitor's drift metrics until ctx is cancelled.
+func serveMetrics(ctx context.Context, ios *iostreams.IOStreams, port int, monitor *shelly.DriftMonitor) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if _, err := w.Write([]byte(monitor.FormatMetrics())); err != nil {
+			ios.DebugErr("writing metrics response", err)
+		}
+	})
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			ios.DebugErr("metrics server shutdown", err)
+		}
+	}()
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		ios.Error("Metrics server: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package watch
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand_Flags(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	tests := []struct {
+		name     string
+		defValue string
+	}{
+		{"interval", "1h0m0s"},
+		{"once", "false"},
+		{"action", "notify"},
+		{"device", "[]"},
+		{"metrics-port", "0"},
+	}
+	for _, tt := range tests {
+		flag := cmd.Flags().Lookup(tt.name)
+		if flag == nil {
+			t.Errorf("flag %q not found", tt.name)
+			continue
+		}
+		if flag.DefValue != tt.defValue {
+			t.Errorf("%s default = %q, want %q", tt.name, flag.DefValue, tt.defValue)
+		}
+	}
+}
+
+func TestRun_NoBaselines(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	err := run(context.Background(), &Options{Factory: tf.Factory, Interval: time.Hour, Action: "notify", Once: true})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if out := tf.ErrString() + tf.OutString(); !strings.Contains(out, "No drift baselines") {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestRun_UnknownDevice(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	tf.Config.Drift = map[string]config.DriftBaseline{"kitchen": {Template: "plug"}}
+	err := run(context.Background(), &Options{Factory: tf.Factory, Interval: time.Hour, Action: "notify", Devices: []string{"porch"}})
+	if err == nil || !strings.Contains(err.Error(), "porch") {
+		t.Errorf("run() error = %v, want unknown device error", err)
+	}
+}
+
+func TestRun_InvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	err := run(context.Background(), &Options{Factory: tf.Factory, Action: "notify"})
+	if err == nil || !strings.Contains(err.Error(), "interval") {
+		t.Errorf("run() error = %v, want interval error", err)
+	}
+}
@@ -0,0 +1,179 @@
+// Package collect provides the energy collect command.
+package collect
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/energystore"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+	"github.com/tj-smith47/shelly-cli/internal/utils"
+)
+
+// compactInterval is how often the daemon aggregates and prunes the store.
+const compactInterval = time.Hour
+
+// Options holds command options.
+type Options struct {
+	Factory  *cmdutil.Factory
+	Devices  []string
+	Interval time.Duration
+	Once     bool
+}
+
+// NewCommand creates the energy collect command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "collect",
+		Aliases: []string{"record", "daemon"},
+		Short:   "Record energy readings into the local store",
+		Long: `Poll metering devices every --interval and record their readings in a local
+time-series store under the config directory (energy/).
+
+Every component that reports power is recorded: switches, covers, lights,
+PM1, EM and EM1 meters on Gen2+ devices and meters/emeters on Gen1 devices.
+Energy is taken from the device's counters, or integrated from power for
+components without one. Devices without metering components are skipped
+after the first successful read.
+
+Samples are kept at three resolutions, each with its own retention
+(energy.retention in the config file):
+  raw   Individual samples     (raw_days, default 30)
+  1h    Hourly aggregates      (hourly_days, default 730)
+  1d    Daily aggregates       (daily_days, default forever)
+
+Aggregation and retention run at start and hourly. Read the store with
+--local on "shelly energy history", "compare" and "export"; the TUI energy
+history panel shows it for longer ranges.`,
+		Example: `  # Record all registered devices every minute
+  shelly energy collect
+
+  # Record specific devices every 10 seconds
+  shelly energy collect --device kitchen --device porch --interval 10s
+
+  # Record once and exit (for cron)
+  shelly energy collect --once`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().DurationVarP(&opts.Interval, "interval", "i", time.Minute, "Collection interval")
+	cmd.Flags().StringSliceVar(&opts.Devices, "device", nil, "Only collect these devices (default: all registered)")
+	cmd.Flags().BoolVar(&opts.Once, "once", false, "Collect once and exit (for cron/scheduled tasks)")
+	utils.Must(cmd.RegisterFlagCompletionFunc("device", completion.DeviceNames()))
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	if opts.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	cfg, err := opts.Factory.Config()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	mgr, err := opts.Factory.ConfigManager()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	devices := opts.Devices
+	if len(devices) == 0 {
+		for name := range cfg.Devices {
+			devices = append(devices, name)
+		}
+		sort.Strings(devices)
+	}
+	if len(devices) == 0 {
+		ios.Warning("No devices found. Register devices using 'shelly device add' or specify --device")
+		return nil
+	}
+
+	store := shelly.OpenEnergyStore(mgr)
+	retention := shelly.EnergyStoreRetention(cfg.GetEnergyConfig())
+	compact := func() {
+		if err := store.Compact(time.Now(), retention); err != nil {
+			ios.Warning("Energy store compaction failed: %v", err)
+		}
+	}
+
+	if opts.Once {
+		results, err := svc.CollectEnergy(ctx, store, devices, time.Now())
+		if err != nil {
+			return err
+		}
+		compact()
+		return cmdutil.PrintResult(ios, results, term.DisplayEnergyCollectResults)
+	}
+
+	ios.Success("Energy collector started")
+	ios.Printf("  Recording %d device(s) every %s\n", len(devices), opts.Interval)
+	ios.Printf("  Store: %s\n", store.Dir())
+	ios.Printf("  Press Ctrl+C to stop\n")
+	ios.Println("")
+
+	compact()
+	lastCompact := time.Now()
+	devices = collect(ctx, opts, store, devices)
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			ios.Println("")
+			ios.Info("Energy collector stopped")
+			return nil
+		case <-ticker.C:
+			devices = collect(ctx, opts, store, devices)
+			if time.Since(lastCompact) >= compactInterval {
+				compact()
+				lastCompact = time.Now()
+			}
+		}
+	}
+}
+
+// collect records one round of readings and returns the devices to keep
+// polling: those without metering components are dropped.
+func collect(ctx context.Context, opts *Options, store *energystore.Store, devices []string) []string {
+	ios := opts.Factory.IOStreams()
+	results, err := opts.Factory.ShellyService().CollectEnergy(ctx, store, devices, time.Now())
+	if err != nil {
+		ios.DebugErr("collect energy", err)
+		return devices
+	}
+
+	var unmetered []string
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			ios.DebugErr("collect "+r.Device, fmt.Errorf("%s", r.Error))
+		case r.Samples == 0:
+			unmetered = append(unmetered, r.Device)
+		}
+	}
+	if len(unmetered) == 0 {
+		return devices
+	}
+	ios.Info("Skipping devices without metering components: %v", unmetered)
+	return slices.DeleteFunc(slices.Clone(devices), func(d string) bool { return slices.Contains(unmetered, d) })
+}
@@ -0,0 +1,91 @@
+package collect
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/mock"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand_Flags(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	tests := []struct {
+		name     string
+		defValue string
+	}{
+		{"interval", "1m0s"},
+		{"device", "[]"},
+		{"once", "false"},
+	}
+	for _, tt := range tests {
+		flag := cmd.Flags().Lookup(tt.name)
+		if flag == nil {
+			t.Errorf("flag %q not found", tt.name)
+			continue
+		}
+		if flag.DefValue != tt.defValue {
+			t.Errorf("%s default = %q, want %q", tt.name, flag.DefValue, tt.defValue)
+		}
+	}
+}
+
+func TestRun_NoDevices(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	if err := run(context.Background(), &Options{Factory: tf.Factory, Interval: time.Minute, Once: true}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if out := tf.ErrString() + tf.OutString(); !strings.Contains(out, "No devices found") {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestRun_InvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	if err := run(context.Background(), &Options{Factory: tf.Factory}); err == nil {
+		t.Error("expected error for zero interval")
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetDefaultManager via demo.InjectIntoFactory
+func TestRun_Once(t *testing.T) {
+	fixtures := &mock.Fixtures{
+		Version: "1",
+		Config: mock.ConfigFixture{
+			Devices: []mock.DeviceFixture{
+				{Name: "plug", Address: "192.168.1.100", MAC: "AA:BB:CC:DD:EE:01", Type: "SNPL-00112EU", Model: "Shelly Plus Plug S", Generation: 2},
+				{Name: "button", Address: "192.168.1.101", MAC: "AA:BB:CC:DD:EE:02", Type: "SNSN-0024X", Model: "Shelly Plus i4", Generation: 2},
+			},
+		},
+		DeviceStates: map[string]mock.DeviceState{
+			"plug":   {"switch:0": map[string]any{"output": true, "apower": 42.5, "aenergy": map[string]any{"total": 1200.0}}},
+			"button": {"input:0": map[string]any{"state": false}},
+		},
+	}
+	demo, err := mock.StartWithFixtures(fixtures)
+	if err != nil {
+		t.Fatalf("StartWithFixtures: %v", err)
+	}
+	defer demo.Cleanup()
+
+	tf := factory.NewTestFactory(t)
+	demo.InjectIntoFactory(tf.Factory)
+
+	if err := run(context.Background(), &Options{Factory: tf.Factory, Interval: time.Minute, Once: true}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	out := tf.OutString()
+	if !strings.Contains(out, "plug") || !strings.Contains(out, "no metering components") {
+		t.Errorf("output = %q", out)
+	}
+}
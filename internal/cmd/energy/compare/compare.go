@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/model"
 	"github.com/tj-smith47/shelly-cli/internal/shelly"
 	"github.com/tj-smith47/shelly-cli/internal/term"
 )
@@ -23,6 +24,7 @@ type Options struct {
 	Period  string
 	From    string
 	To      string
+	Local   bool
 }
 
 // NewCommand creates the energy compare command.
@@ -40,7 +42,16 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 Shows each device's total energy consumption, average power, and percentage
 of the total consumption. Useful for identifying high-energy consumers.
 
-By default, compares all registered devices. Use --devices to specify a subset.`,
+By default, compares all registered devices. Use --devices to specify a subset.
+
+Each device's consumption is priced per interval under the tariff configured
+in energy.tariff (time-of-use periods, tiers, feed-in credit for exported
+energy), falling back to energy.cost_rate. The total includes the standing
+charge over the range.
+
+With --local, consumption is read from the local store filled by
+"shelly energy collect", which covers every metering device and ranges beyond
+the device's own history.`,
 		Example: `  # Compare all devices for the last day
   shelly energy compare
 
@@ -50,6 +61,9 @@ By default, compares all registered devices. Use --devices to specify a subset.`
   # Compare for a specific date range
   shelly energy compare --from "2025-01-01" --to "2025-01-07"
 
+  # Compare the last year from the local store
+  shelly energy compare --local --period year
+
   # Output as JSON
   shelly energy compare -o json`,
 		Aliases: []string{"cmp", "diff"},
@@ -59,9 +73,10 @@ By default, compares all registered devices. Use --devices to specify a subset.`
 	}
 
 	cmd.Flags().StringSliceVar(&opts.Devices, "devices", nil, "Devices to compare (default: all registered)")
-	cmd.Flags().StringVarP(&opts.Period, "period", "p", periodDay, "Time period (hour, day, week, month)")
+	cmd.Flags().StringVarP(&opts.Period, "period", "p", periodDay, "Time period (hour, day, week, month, year)")
 	cmd.Flags().StringVar(&opts.From, "from", "", "Start time (RFC3339 or YYYY-MM-DD)")
 	cmd.Flags().StringVar(&opts.To, "to", "", "End time (RFC3339 or YYYY-MM-DD)")
+	cmd.Flags().BoolVar(&opts.Local, "local", false, "Read from the local store filled by 'shelly energy collect'")
 
 	return cmd
 }
@@ -98,8 +113,22 @@ func run(ctx context.Context, opts *Options) error {
 		return fmt.Errorf("invalid time range: %w", err)
 	}
 
+	tariff, err := shelly.EnergyTariff(cfg.GetEnergyConfig())
+	if err != nil {
+		return fmt.Errorf("invalid energy tariff: %w", err)
+	}
+
 	// Collect comparison data using service layer
-	comparison := svc.CollectComparisonData(ctx, ios, devices, opts.Period, startTS, endTS)
+	var comparison model.ComparisonData
+	if opts.Local {
+		mgr, err := opts.Factory.ConfigManager()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		comparison = shelly.LocalComparisonData(shelly.OpenEnergyStore(mgr), devices, opts.Period, startTS, endTS, tariff)
+	} else {
+		comparison = svc.CollectComparisonData(ctx, ios, devices, opts.Period, startTS, endTS, tariff)
+	}
 
 	// Calculate percentages
 	if comparison.TotalEnergy > 0 {
@@ -76,6 +76,7 @@ func TestNewCommand_Flags(t *testing.T) {
 		{"period", "p", periodDay},
 		{"from", "", ""},
 		{"to", "", ""},
+		{"local", "", "false"},
 	}
 
 	for _, tt := range tests {
@@ -910,3 +911,16 @@ func TestRun_SingleDeviceOnly(t *testing.T) {
 		t.Logf("Output: %s", output)
 	}
 }
+
+func TestRun_LocalNoData(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	opts := &Options{Factory: tf.Factory, Devices: []string{"kitchen", "porch"}, Period: "year", Local: true}
+	if err := run(context.Background(), opts); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if out := tf.OutString(); !strings.Contains(out, "kitchen") {
+		t.Errorf("output = %q", out)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/tariff"
 	"github.com/tj-smith47/shelly-cli/internal/term"
 )
 
@@ -35,7 +37,9 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 		Short: "Show energy dashboard for all devices",
 		Long: `Display an aggregated energy dashboard showing power consumption across all devices.
 
-Shows total power consumption, per-device breakdown, and optional cost estimation.
+Shows total power consumption, per-device breakdown, and today's cost for
+devices with energy meters (EM/EM1), priced per interval under the tariff
+configured in energy.tariff. --cost replaces the tariff with a flat rate.
 By default, queries all registered devices. Use --devices to specify a subset.
 
 Examples:
@@ -94,8 +98,17 @@ func run(ctx context.Context, opts *Options) error {
 
 	sort.Strings(devices)
 
+	// Price today's usage with --cost as a flat rate, or the configured tariff
+	energyTariff := tariff.Flat(opts.CostPerKwh, opts.CostCurrency)
+	if opts.CostPerKwh <= 0 {
+		energyCfg := cfg.GetEnergyConfig()
+		if energyTariff, err = shelly.EnergyTariff(energyCfg); err != nil {
+			return fmt.Errorf("invalid energy tariff: %w", err)
+		}
+	}
+
 	// Collect data from all devices concurrently using service layer
-	dashboard := svc.CollectDashboardData(ctx, ios, devices)
+	dashboard := svc.CollectDashboardData(ctx, ios, devices, energyTariff)
 
 	// Add cost estimation if configured
 	if opts.CostPerKwh > 0 {
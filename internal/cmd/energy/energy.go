@@ -4,12 +4,14 @@ package energy
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/tj-smith47/shelly-cli/internal/cmd/energy/collect"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/energy/compare"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/energy/dashboard"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/energy/export"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/energy/history"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/energy/list"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/energy/reset"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/energy/solar"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/energy/status"
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
 )
@@ -33,7 +35,14 @@ These components provide real-time measurements including:
   - Per-phase data for 3-phase monitors
   - Total power and neutral current
 
-For power meters with energy totals (PM/PM1 components), use 'shelly power'.`,
+For power meters with energy totals (PM/PM1 components), use 'shelly power'.
+
+'shelly energy collect' records every metering device into a local store,
+which history, compare and export read with --local, for ranges beyond the
+device's own history.
+
+'shelly energy solar' analyzes PV self-consumption, autarky and peak demand
+from a grid meter and an optional PV meter.`,
 		Aliases: []string{"em"},
 		Example: `  # List energy monitor components
   shelly energy list kitchen
@@ -52,6 +61,8 @@ For power meters with energy totals (PM/PM1 components), use 'shelly power'.`,
 	cmd.AddCommand(reset.NewCommand(f))
 	cmd.AddCommand(dashboard.NewCommand(f))
 	cmd.AddCommand(compare.NewCommand(f))
+	cmd.AddCommand(collect.NewCommand(f))
+	cmd.AddCommand(solar.NewCommand(f))
 
 	return cmd
 }
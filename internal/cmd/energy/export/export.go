@@ -4,11 +4,13 @@ package export
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
 	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/energystore"
 	shellyexport "github.com/tj-smith47/shelly-cli/internal/shelly/export"
 )
 
@@ -18,11 +20,14 @@ type Options struct {
 	Device        string
 	ComponentID   int
 	ComponentType string
+	HasID         bool
 	Format        string
 	OutputFile    string
 	Period        string
 	From          string
 	To            string
+	Local         bool
+	Resolution    string
 }
 
 // NewCommand creates the energy export command.
@@ -44,7 +49,11 @@ Supports multiple output formats:
   - YAML: Human-readable YAML format
 
 The exported data includes timestamp, voltage, current, power, and energy
-measurements for the specified time range.`,
+measurements for the specified time range.
+
+With --local, data is read from the local store filled by
+"shelly energy collect": every metering component of the device (or only
+[id]) at the --resolution (raw, 1h, 1d; default: by range).`,
 		Example: `  # Export last 24 hours as CSV
   shelly energy export shelly-3em-pro > data.csv
 
@@ -52,7 +61,10 @@ measurements for the specified time range.`,
   shelly energy export shelly-em --format json --from "2025-01-01" --to "2025-01-07" --output energy.json
 
   # Export last week as YAML
-  shelly energy export shelly-3em-pro 0 --format yaml --period week --output weekly.yaml`,
+  shelly energy export shelly-3em-pro 0 --format yaml --period week --output weekly.yaml
+
+  # Export a year of hourly data from the local store
+  shelly energy export kitchen --local --period year --resolution 1h --output kitchen.csv`,
 		Aliases: []string{"exp", "dump"},
 		Args:    cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -61,17 +73,20 @@ measurements for the specified time range.`,
 				if _, err := fmt.Sscanf(args[1], "%d", &opts.ComponentID); err != nil {
 					return fmt.Errorf("invalid component ID: %w", err)
 				}
+				opts.HasID = true
 			}
 			return run(cmd.Context(), opts)
 		},
 	}
 
-	cmd.Flags().StringVar(&opts.ComponentType, "type", shelly.ComponentTypeAuto, "Component type (auto, em, em1)")
+	cmd.Flags().StringVar(&opts.ComponentType, "type", shelly.ComponentTypeAuto, "Component type (auto, em, em1; any type with --local, e.g. switch)")
 	cmd.Flags().StringVarP(&opts.Format, "format", "f", shellyexport.FormatCSV, "Output format (csv, json, yaml)")
 	cmd.Flags().StringVarP(&opts.OutputFile, "output", "o", "", "Output file (default: stdout)")
-	cmd.Flags().StringVarP(&opts.Period, "period", "p", "", "Time period (hour, day, week, month)")
+	cmd.Flags().StringVarP(&opts.Period, "period", "p", "", "Time period (hour, day, week, month, year)")
 	cmd.Flags().StringVar(&opts.From, "from", "", "Start time (RFC3339 or YYYY-MM-DD)")
 	cmd.Flags().StringVar(&opts.To, "to", "", "End time (RFC3339 or YYYY-MM-DD)")
+	cmd.Flags().BoolVar(&opts.Local, "local", false, "Read from the local store filled by 'shelly energy collect'")
+	cmd.Flags().StringVar(&opts.Resolution, "resolution", "", "Local store resolution (raw, 1h, 1d; default: by range)")
 
 	return cmd
 }
@@ -85,12 +100,20 @@ func run(ctx context.Context, opts *Options) error {
 		return fmt.Errorf("invalid format: %s (use: csv, json, yaml)", opts.Format)
 	}
 
+	if opts.Resolution != "" && !opts.Local {
+		return fmt.Errorf("--resolution requires --local")
+	}
+
 	// Calculate time range
 	startTS, endTS, err := shelly.CalculateTimeRange(opts.Period, opts.From, opts.To)
 	if err != nil {
 		return fmt.Errorf("invalid time range: %w", err)
 	}
 
+	if opts.Local {
+		return runLocal(opts, startTS, endTS)
+	}
+
 	// Auto-detect type if not specified
 	componentType := opts.ComponentType
 	if componentType == shelly.ComponentTypeAuto {
@@ -110,3 +133,28 @@ func run(ctx context.Context, opts *Options) error {
 		return fmt.Errorf("no energy data components found")
 	}
 }
+
+// runLocal exports data from the local energy store.
+func runLocal(opts *Options, startTS, endTS *int64) error {
+	mgr, err := opts.Factory.ConfigManager()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	q := energystore.Query{Resolution: opts.Resolution}
+	if startTS != nil {
+		q.From = time.Unix(*startTS, 0)
+	}
+	if endTS != nil {
+		q.To = time.Unix(*endTS, 0)
+	}
+	match := shelly.EnergyComponentMatcher(opts.ComponentType, opts.ComponentID, opts.HasID)
+	series, err := shelly.LocalEnergyHistory(shelly.OpenEnergyStore(mgr), opts.Device, q, match)
+	if err != nil {
+		return err
+	}
+	if len(series) == 0 {
+		return fmt.Errorf("no local energy data for %s (collect it with: shelly energy collect)", opts.Device)
+	}
+	return shellyexport.LocalEnergy(opts.Factory.IOStreams(), series, opts.Format, opts.OutputFile)
+}
@@ -61,6 +61,8 @@ func TestNewCommand_Flags(t *testing.T) {
 		{name: "period", shorthand: "p", defValue: ""},
 		{name: "from", shorthand: "", defValue: ""},
 		{name: "to", shorthand: "", defValue: ""},
+		{name: "local", shorthand: "", defValue: "false"},
+		{name: "resolution", shorthand: "", defValue: ""},
 	}
 
 	for _, tt := range tests {
@@ -1322,3 +1324,20 @@ func TestRun_YAMLFormat(t *testing.T) {
 		t.Logf("run() error = %v (expected - mock doesn't support EM1Data)", err)
 	}
 }
+
+func TestRun_LocalNoData(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	opts := &Options{
+		Factory:       tf.Factory,
+		Device:        "kitchen",
+		ComponentType: shelly.ComponentTypeAuto,
+		Format:        shellyexport.FormatCSV,
+		Local:         true,
+	}
+	err := run(context.Background(), opts)
+	if err == nil || !strings.Contains(err.Error(), "no local energy data") {
+		t.Errorf("run() error = %v, want no local data error", err)
+	}
+}
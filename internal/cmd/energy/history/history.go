@@ -4,11 +4,13 @@ package history
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
 	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/energystore"
 	"github.com/tj-smith47/shelly-cli/internal/term"
 )
 
@@ -18,10 +20,13 @@ type Options struct {
 	Device        string
 	ComponentID   int
 	ComponentType string
+	HasID         bool
 	Period        string
 	From          string
 	To            string
 	Limit         int
+	Local         bool
+	Resolution    string
 }
 
 // NewCommand creates the energy history command.
@@ -44,7 +49,14 @@ Works with:
   - EM1 components (single-phase energy monitors)
 
 The device must have EMData or EM1Data components that store historical
-measurements. Not all Shelly devices support historical data storage.`,
+measurements. Not all Shelly devices support historical data storage.
+
+With --local, history is read from the local store filled by
+"shelly energy collect" instead. It covers every metering component (switch,
+cover, light, PM1, EM, EM1 and Gen1 meters) for as long as the retention
+policy keeps it. Without an [id] or --type, all components of the device are
+shown. --resolution picks raw samples, hourly (1h) or daily (1d) aggregates;
+by default it follows the length of the range.`,
 		Example: `  # Show last 24 hours of energy data
   shelly energy history shelly-3em-pro
 
@@ -55,7 +67,10 @@ measurements. Not all Shelly devices support historical data storage.`,
   shelly energy history shelly-3em-pro 0 --period week
 
   # Limit number of records shown
-  shelly energy history shelly-em --limit 100`,
+  shelly energy history shelly-em --limit 100
+
+  # Daily totals for the last year from the local store
+  shelly energy history kitchen --local --period year --resolution 1d`,
 		Aliases: []string{"hist", "events"},
 		Args:    cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -64,16 +79,19 @@ measurements. Not all Shelly devices support historical data storage.`,
 				if _, err := fmt.Sscanf(args[1], "%d", &opts.ComponentID); err != nil {
 					return fmt.Errorf("invalid component ID: %w", err)
 				}
+				opts.HasID = true
 			}
 			return run(cmd.Context(), opts)
 		},
 	}
 
-	cmd.Flags().StringVar(&opts.ComponentType, "type", shelly.ComponentTypeAuto, "Component type (auto, em, em1)")
-	cmd.Flags().StringVarP(&opts.Period, "period", "p", "", "Time period (hour, day, week, month)")
+	cmd.Flags().StringVar(&opts.ComponentType, "type", shelly.ComponentTypeAuto, "Component type (auto, em, em1; any type with --local, e.g. switch)")
+	cmd.Flags().StringVarP(&opts.Period, "period", "p", "", "Time period (hour, day, week, month, year)")
 	cmd.Flags().StringVar(&opts.From, "from", "", "Start time (RFC3339 or YYYY-MM-DD)")
 	cmd.Flags().StringVar(&opts.To, "to", "", "End time (RFC3339 or YYYY-MM-DD)")
 	cmd.Flags().IntVar(&opts.Limit, "limit", 0, "Limit number of data points (0 = no limit)")
+	cmd.Flags().BoolVar(&opts.Local, "local", false, "Read from the local store filled by 'shelly energy collect'")
+	cmd.Flags().StringVar(&opts.Resolution, "resolution", "", "Local store resolution (raw, 1h, 1d; default: by range)")
 
 	return cmd
 }
@@ -82,12 +100,20 @@ func run(ctx context.Context, opts *Options) error {
 	ios := opts.Factory.IOStreams()
 	svc := opts.Factory.ShellyService()
 
+	if opts.Resolution != "" && !opts.Local {
+		return fmt.Errorf("--resolution requires --local")
+	}
+
 	// Calculate time range
 	startTS, endTS, err := shelly.CalculateTimeRange(opts.Period, opts.From, opts.To)
 	if err != nil {
 		return fmt.Errorf("invalid time range: %w", err)
 	}
 
+	if opts.Local {
+		return runLocal(opts, startTS, endTS)
+	}
+
 	// Auto-detect type if not specified
 	componentType := opts.ComponentType
 	if componentType == shelly.ComponentTypeAuto {
@@ -116,3 +142,33 @@ func run(ctx context.Context, opts *Options) error {
 		return fmt.Errorf("no energy data components found (device may not support historical data storage)")
 	}
 }
+
+// runLocal shows history from the local energy store.
+func runLocal(opts *Options, startTS, endTS *int64) error {
+	ios := opts.Factory.IOStreams()
+	mgr, err := opts.Factory.ConfigManager()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	q := energystore.Query{Resolution: opts.Resolution}
+	if startTS != nil {
+		q.From = time.Unix(*startTS, 0)
+	}
+	if endTS != nil {
+		q.To = time.Unix(*endTS, 0)
+	}
+	match := shelly.EnergyComponentMatcher(opts.ComponentType, opts.ComponentID, opts.HasID)
+	series, err := shelly.LocalEnergyHistory(shelly.OpenEnergyStore(mgr), opts.Device, q, match)
+	if err != nil {
+		return err
+	}
+	if len(series) == 0 {
+		ios.Warning("No local energy data for %s", opts.Device)
+		ios.Info("Collect it with: shelly energy collect --device %s", opts.Device)
+		return nil
+	}
+
+	term.DisplayLocalEnergyHistory(ios, series, startTS, endTS, opts.Limit)
+	return nil
+}
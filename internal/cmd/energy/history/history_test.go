@@ -59,6 +59,8 @@ func TestNewCommand_Flags(t *testing.T) {
 		{name: "from", shorthand: "", defValue: ""},
 		{name: "to", shorthand: "", defValue: ""},
 		{name: "limit", shorthand: "", defValue: "0"},
+		{name: "local", shorthand: "", defValue: "false"},
+		{name: "resolution", shorthand: "", defValue: ""},
 	}
 
 	for _, tt := range tests {
@@ -957,3 +959,27 @@ func TestRun_AutoDetectFails(t *testing.T) {
 // Note: Tests for CalculateTimeRange, ParseTime, CalculateEMMetrics, and CalculateEM1Metrics
 // are now in internal/shelly/energy_test.go since these functions were extracted to the
 // service layer for DRY compliance.
+
+func TestRun_LocalNoData(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	opts := &Options{Factory: tf.Factory, Device: "kitchen", ComponentType: shelly.ComponentTypeAuto, Period: "year", Local: true}
+	if err := run(context.Background(), opts); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if out := tf.ErrString() + tf.OutString(); !strings.Contains(out, "No local energy data") {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestRun_ResolutionRequiresLocal(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	opts := &Options{Factory: tf.Factory, Device: "kitchen", ComponentType: shelly.ComponentTypeAuto, Resolution: "1h"}
+	err := run(context.Background(), opts)
+	if err == nil || !strings.Contains(err.Error(), "--local") {
+		t.Errorf("run() error = %v, want --local error", err)
+	}
+}
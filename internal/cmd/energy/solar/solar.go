@@ -0,0 +1,136 @@
+// Package solar provides the energy solar command.
+package solar
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+)
+
+// periodWeek is the default analysis period.
+const periodWeek = "week"
+
+// Options holds command options.
+type Options struct {
+	Factory *cmdutil.Factory
+	Grid    string
+	GridID  int
+	PV      string
+	PVID    int
+	Period  string
+	From    string
+	To      string
+}
+
+// NewCommand creates the energy solar command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{
+		Factory: f,
+		Period:  periodWeek,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "solar [grid-device]",
+		Short: "Analyze PV self-consumption and net metering",
+		Long: `Analyze solar self-consumption from the history of the grid meter and,
+optionally, a PV meter (EM or EM1 components).
+
+For each day and over the whole range it reports:
+  - Import from and export to the grid
+  - PV production and how much of it was used on site (self-consumption)
+  - The share of consumption covered by PV (autarky)
+  - Peak demand: the highest 15-minute average import
+  - Phase imbalance for 3-phase grid meters: the mean deviation of the
+    busiest phase from the average load
+
+Self-consumption and autarky need a PV meter (--pv). Production is read from
+whichever direction the PV meter recorded most energy, so a reversed clamp
+works as is.
+
+Meters not given on the command line, with their component IDs, come from
+energy.solar in the config file.`,
+		Example: `  # Analyze the last week with a grid and a PV meter
+  shelly energy solar house-3em --pv inverter
+
+  # Use the meters configured in energy.solar
+  shelly energy solar
+
+  # Grid only, second EM1 channel, for the last month
+  shelly energy solar pro-em --grid-id 1 --period month
+
+  # A specific date range
+  shelly energy solar house-3em --pv inverter --from 2025-06-01 --to 2025-06-30
+
+  # Output as JSON
+  shelly energy solar -o json`,
+		Aliases:           []string{"pv", "self-consumption"},
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completion.DeviceNames(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.Grid = args[0]
+			}
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.GridID, "grid-id", 0, "Component ID on the grid meter")
+	cmd.Flags().StringVar(&opts.PV, "pv", "", "Device metering PV production")
+	cmd.Flags().IntVar(&opts.PVID, "pv-id", 0, "Component ID on the PV meter")
+	cmd.Flags().StringVarP(&opts.Period, "period", "p", periodWeek, "Time period (hour, day, week, month, year)")
+	cmd.Flags().StringVar(&opts.From, "from", "", "Start time (RFC3339 or YYYY-MM-DD)")
+	cmd.Flags().StringVar(&opts.To, "to", "", "End time (RFC3339 or YYYY-MM-DD)")
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+	cfg, err := opts.Factory.Config()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Meters not given on the command line come from energy.solar
+	solarCfg := cfg.GetEnergyConfig().Solar
+	if opts.Grid == "" {
+		opts.Grid, opts.GridID = solarCfg.Grid, solarCfg.GridID
+	}
+	if opts.PV == "" {
+		opts.PV, opts.PVID = solarCfg.PV, solarCfg.PVID
+	}
+	if opts.Grid == "" {
+		return fmt.Errorf("no grid meter given; pass a device or set energy.solar.grid")
+	}
+
+	startTS, endTS, err := shelly.CalculateTimeRange(opts.Period, opts.From, opts.To)
+	if err != nil {
+		return fmt.Errorf("invalid time range: %w", err)
+	}
+	to := time.Now()
+	if endTS != nil {
+		to = time.Unix(*endTS, 0)
+	}
+	from := to.AddDate(0, 0, -7)
+	if startTS != nil {
+		from = time.Unix(*startTS, 0)
+	}
+
+	report, err := cmdutil.RunWithSpinnerResult(ctx, ios, "Analyzing meter history...", func(ctx context.Context) (model.SolarReport, error) {
+		return svc.SolarAnalysis(ctx, opts.Grid, opts.GridID, opts.PV, opts.PVID, from, to)
+	})
+	if err != nil {
+		return err
+	}
+
+	return cmdutil.PrintResult(ios, report, term.DisplaySolarReport)
+}
@@ -0,0 +1,129 @@
+package solar
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/mock"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand_Structure(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "solar [grid-device]" {
+		t.Errorf("Use = %q, want %q", cmd.Use, "solar [grid-device]")
+	}
+	if cmd.Short == "" || cmd.Long == "" || cmd.Example == "" {
+		t.Error("Short, Long and Example must be set")
+	}
+	if err := cmd.Args(cmd, []string{"a", "b"}); err == nil {
+		t.Error("expected error with two arguments")
+	}
+}
+
+func TestNewCommand_Flags(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	tests := []struct {
+		name      string
+		shorthand string
+		defValue  string
+	}{
+		{"grid-id", "", "0"},
+		{"pv", "", ""},
+		{"pv-id", "", "0"},
+		{"period", "p", periodWeek},
+		{"from", "", ""},
+		{"to", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			flag := cmd.Flags().Lookup(tt.name)
+			if flag == nil {
+				t.Fatalf("flag %q not found", tt.name)
+			}
+			if flag.Shorthand != tt.shorthand {
+				t.Errorf("flag %q shorthand = %q, want %q", tt.name, flag.Shorthand, tt.shorthand)
+			}
+			if flag.DefValue != tt.defValue {
+				t.Errorf("flag %q default = %q, want %q", tt.name, flag.DefValue, tt.defValue)
+			}
+		})
+	}
+}
+
+func TestExecute_NoGridMeter(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	cmd := NewCommand(tf.Factory)
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "energy.solar.grid") {
+		t.Errorf("Execute() error = %v, want missing grid meter", err)
+	}
+}
+
+//nolint:paralleltest // uses global mock config manager
+func TestExecute_GridMeter(t *testing.T) {
+	fixtures := &mock.Fixtures{
+		Version: "1",
+		Config: mock.ConfigFixture{
+			Devices: []mock.DeviceFixture{
+				{
+					Name:       "house",
+					Address:    "192.168.1.100",
+					MAC:        "AA:BB:CC:DD:EE:FF",
+					Type:       "SPEM-003CEBEU",
+					Model:      "Shelly Pro 3EM",
+					Generation: 2,
+				},
+			},
+		},
+		DeviceStates: map[string]mock.DeviceState{
+			"house": {
+				"em:0": map[string]any{"id": 0, "total_act_power": 1500.0},
+			},
+		},
+	}
+
+	demo, err := mock.StartWithFixtures(fixtures)
+	if err != nil {
+		t.Fatalf("StartWithFixtures: %v", err)
+	}
+	defer demo.Cleanup()
+
+	tf := factory.NewTestFactory(t)
+	demo.InjectIntoFactory(tf.Factory)
+
+	cmd := NewCommand(tf.Factory)
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{"house"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	// Mock EMData has one minute at 1500 W: 25 Wh, averaging 100 W over its demand window
+	out := tf.OutString()
+	for _, want := range []string{"Solar Analysis", "Daily Breakdown", "25.0 Wh", "100.0 W"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -4,10 +4,12 @@ package status
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/output"
 	"github.com/tj-smith47/shelly-cli/internal/shelly"
 	"github.com/tj-smith47/shelly-cli/internal/term"
 )
@@ -34,7 +36,11 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 
 Displays real-time measurements including voltage, current, power,
 power factor, and frequency. For 3-phase EM components, shows
-per-phase data and totals.`,
+per-phase data and totals.
+
+Also shows the rate in effect and today's cost, priced per interval of the
+component's stored EMData/EM1Data under the tariff configured in
+energy.tariff, with exported energy credited at the feed-in rate.`,
 		Example: `  # Show energy monitor status
   shelly energy status shelly-3em-pro
 
@@ -82,6 +88,7 @@ func run(ctx context.Context, opts *Options) error {
 			return fmt.Errorf("failed to get EM status: %w", err)
 		}
 		term.DisplayEMStatus(ios, status)
+		displayCostToday(ctx, opts, componentType)
 		return nil
 	case shelly.ComponentTypeEM1:
 		status, err := svc.GetEM1Status(ctx, opts.Device, opts.ComponentID)
@@ -89,8 +96,37 @@ func run(ctx context.Context, opts *Options) error {
 			return fmt.Errorf("failed to get EM1 status: %w", err)
 		}
 		term.DisplayEM1Status(ios, status)
+		displayCostToday(ctx, opts, componentType)
 		return nil
 	default:
 		return fmt.Errorf("no energy monitoring components found")
 	}
 }
+
+// displayCostToday prints today's cost of the component under the configured
+// tariff. Components without stored history are skipped.
+func displayCostToday(ctx context.Context, opts *Options, componentType string) {
+	if output.WantsStructured() {
+		return
+	}
+	ios := opts.Factory.IOStreams()
+
+	cfg, err := opts.Factory.Config()
+	if err != nil {
+		ios.DebugErr("load config", err)
+		return
+	}
+	tariff, err := shelly.EnergyTariff(cfg.GetEnergyConfig())
+	if err != nil {
+		ios.Warning("Invalid energy tariff: %v", err)
+		return
+	}
+
+	cost, err := opts.Factory.ShellyService().EnergyCostToday(ctx, opts.Device, componentType, opts.ComponentID, tariff)
+	if err != nil {
+		ios.DebugErr("energy cost", err)
+		return
+	}
+	rate := tariff.RateAt(time.Now(), 0)
+	term.DisplayEnergyCost(ios, "Cost Today", cost, &rate)
+}
@@ -223,6 +223,10 @@ func TestRun_EMComponent(t *testing.T) {
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
+	// Mock EMData has one minute at 1500 W: 25 Wh at the default flat rate
+	if out := tf.OutString(); !strings.Contains(out, "Cost Today") || !strings.Contains(out, "0.025 kWh") {
+		t.Errorf("output missing cost section:\n%s", out)
+	}
 }
 
 //nolint:paralleltest // uses global mock config manager
@@ -3,7 +3,9 @@ package ansible
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
@@ -24,6 +26,8 @@ type Options struct {
 	Devices   []string
 	File      string
 	GroupName string
+	List      bool
+	Host      string
 	Factory   *cmdutil.Factory
 }
 
@@ -38,7 +42,30 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 		Long: `Export devices as an Ansible inventory YAML file.
 
 Creates an Ansible-compatible inventory with device groups based on
-model type. Use @all to export all registered devices.`,
+model type. Use @all to export all registered devices.
+
+With --list or --host the command follows the dynamic inventory script
+contract and prints JSON built from live device state. Without device
+arguments every registered device is included. --list groups hosts by:
+
+  group_<name>   Config device groups
+  model_<model>  Device model, e.g. model_shelly_plus_plug_s
+  gen<N>         Device generation, e.g. gen2
+  fw_<x_y_z>     Firmware version, e.g. fw_1_4_2
+  offline        Devices that could not be reached
+
+Host vars come from Shelly.GetDeviceInfo and the device status:
+ansible_host, shelly_model, shelly_type, shelly_generation, shelly_app,
+shelly_id, shelly_mac, shelly_fw_id, shelly_fw_version, shelly_groups,
+shelly_online, shelly_auth_enabled, shelly_uptime, shelly_ip,
+shelly_wifi_ssid, shelly_wifi_rssi, shelly_update_stable,
+shelly_update_beta and the full status as shelly_status. Compare firmware
+with Ansible's version test, e.g.
+  when: shelly_fw_version is version('1.4.0', '<')
+
+To use it as an inventory script, wrap it in an executable file:
+  #!/bin/sh
+  exec shelly export ansible "$@"`,
 		Example: `  # Export to stdout
   shelly export ansible @all
 
@@ -49,16 +76,36 @@ model type. Use @all to export all registered devices.`,
   shelly export ansible living-room bedroom inventory.yaml
 
   # Specify group name
-  shelly export ansible @all --group-name shelly_devices`,
-		Args:              cobra.MinimumNArgs(1),
+  shelly export ansible @all --group-name shelly_devices
+
+  # Dynamic inventory JSON for all registered devices
+  shelly export ansible --list
+
+  # Host vars for one device
+  shelly export ansible --host kitchen
+
+  # Target Gen2 plugs on old firmware from a playbook
+  ansible-playbook -i shelly-inventory.sh -l 'gen2:&model_shelly_plus_plug_s' update.yml`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.List || opts.Host != "" {
+				return nil
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		ValidArgsFunction: completion.DevicesWithGroups(),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.Devices, opts.File = shelly.SplitDevicesAndFile(args, yamlExtensions)
+			if opts.List || opts.Host != "" {
+				return runDynamic(cmd.Context(), opts)
+			}
 			return run(cmd.Context(), opts)
 		},
 	}
 
 	cmd.Flags().StringVar(&opts.GroupName, "group-name", "shelly", "Ansible group name for devices")
+	cmd.Flags().BoolVar(&opts.List, "list", false, "Print dynamic inventory JSON (inventory script contract)")
+	cmd.Flags().StringVar(&opts.Host, "host", "", "Print dynamic inventory host vars for one device")
+	cmd.MarkFlagsMutuallyExclusive("list", "host")
 
 	return cmd
 }
@@ -105,3 +152,42 @@ func run(ctx context.Context, opts *Options) error {
 	ios.Success("Exported %d devices to %s", len(deviceData), opts.File)
 	return nil
 }
+
+// runDynamic prints dynamic inventory JSON: the full inventory for --list,
+// or one host's vars for --host ({} for unknown hosts, as Ansible expects).
+func runDynamic(ctx context.Context, opts *Options) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*shelly.DefaultTimeout)
+	defer cancel()
+
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+	cfg, err := opts.Factory.Config()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var output any
+	if opts.Host != "" {
+		output = map[string]any{}
+		if hosts := svc.CollectAnsibleHostStates(ctx, []string{opts.Host}); len(hosts) == 1 {
+			output = export.AnsibleHostVars(hosts[0], cfg.Groups)
+		}
+	} else {
+		devices := completion.ExpandDeviceArgs(opts.Devices)
+		if len(devices) == 0 {
+			for name := range cfg.Devices {
+				devices = append(devices, name)
+			}
+			sort.Strings(devices)
+		}
+		hosts := svc.CollectAnsibleHostStates(ctx, devices)
+		output = export.BuildAnsibleDynamicInventory(hosts, cfg.Groups, opts.GroupName)
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode inventory: %w", err)
+	}
+	ios.Printf("%s\n", data)
+	return nil
+}
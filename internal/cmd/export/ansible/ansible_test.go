@@ -3,6 +3,7 @@ package ansible
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"os"
 	"strings"
 	"testing"
@@ -952,3 +953,97 @@ func TestExecute_DeviceWithFileArg(t *testing.T) {
 		t.Errorf("Expected file to contain 'home_automation:', got: %s", string(content))
 	}
 }
+
+func TestNewCommand_DynamicFlags(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+	for _, name := range []string{"list", "host"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("flag %q not found", name)
+		}
+	}
+
+	cmd.SetArgs([]string{"--list", "--host", "kitchen"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for --list with --host")
+	}
+}
+
+//nolint:paralleltest // Test uses global config via demo.InjectIntoFactory
+func TestExecute_DynamicList(t *testing.T) {
+	fixtures := &mock.Fixtures{
+		Version: "1",
+		Config: mock.ConfigFixture{
+			Devices: []mock.DeviceFixture{
+				{
+					Name:       "test-device",
+					Address:    "192.168.1.100",
+					MAC:        "AA:BB:CC:DD:EE:FF",
+					Type:       "SNSW-001P16EU",
+					Model:      "Shelly Plus 1PM",
+					Generation: 2,
+				},
+			},
+		},
+		DeviceStates: map[string]mock.DeviceState{
+			"test-device": {"switch:0": map[string]any{"output": false}},
+		},
+	}
+
+	demo, err := mock.StartWithFixtures(fixtures)
+	if err != nil {
+		t.Fatalf("StartWithFixtures: %v", err)
+	}
+	defer demo.Cleanup()
+
+	tf := factory.NewTestFactory(t)
+	demo.InjectIntoFactory(tf.Factory)
+
+	cmd := NewCommand(tf.Factory)
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{"--list"}) // No devices - all registered
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	var inventory map[string]any
+	if err := json.Unmarshal([]byte(tf.OutString()), &inventory); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, tf.OutString())
+	}
+	meta, ok := inventory["_meta"].(map[string]any)
+	if !ok {
+		t.Fatalf("missing _meta: %v", inventory)
+	}
+	vars, ok := meta["hostvars"].(map[string]any)["test-device"].(map[string]any)
+	if !ok || vars["ansible_host"] == "" || vars["shelly_online"] != true {
+		t.Errorf("hostvars = %v", meta["hostvars"])
+	}
+	if _, ok := inventory["shelly"]; !ok {
+		t.Errorf("missing top-level group: %v", inventory)
+	}
+}
+
+//nolint:paralleltest // Test uses global config via demo.InjectIntoFactory
+func TestExecute_DynamicUnknownHost(t *testing.T) {
+	demo, err := mock.StartWithFixtures(&mock.Fixtures{Version: "1", Config: mock.ConfigFixture{}})
+	if err != nil {
+		t.Fatalf("StartWithFixtures: %v", err)
+	}
+	defer demo.Cleanup()
+
+	tf := factory.NewTestFactory(t)
+	demo.InjectIntoFactory(tf.Factory)
+
+	if err := runDynamic(context.Background(), &Options{Factory: tf.Factory, Host: "missing", GroupName: "shelly"}); err != nil {
+		t.Fatalf("runDynamic() error = %v", err)
+	}
+	if got := strings.TrimSpace(tf.OutString()); got != "{}" {
+		t.Errorf("output = %q, want {}", got)
+	}
+}
@@ -4,6 +4,7 @@ package terraform
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -21,10 +22,13 @@ var tfExtensions = []string{".tf"}
 
 // Options holds command options.
 type Options struct {
-	Devices      []string
-	File         string
-	ResourceName string
-	Factory      *cmdutil.Factory
+	Devices        []string
+	File           string
+	ResourceName   string
+	Resources      bool
+	ProviderSource string
+	ImportScript   string
+	Factory        *cmdutil.Factory
 }
 
 // NewCommand creates the export terraform command.
@@ -38,7 +42,32 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 		Long: `Export devices as Terraform local values configuration.
 
 Creates a Terraform locals block with device information that can be
-used as data source for infrastructure as code workflows.`,
+used as data source for infrastructure as code workflows.
+
+With --resources, the live configuration of each online Gen2+ device is
+exported as resource blocks for a Shelly provider, so existing installations
+can be brought under Terraform without retyping them. No provider is
+assumed: --provider-source names the one to use, and it must implement
+these resources:
+
+  shelly_switch, shelly_cover    Component config (switch:N, cover:N)
+  shelly_light, shelly_rgb,      Light component config
+  shelly_rgbw, shelly_cct
+  shelly_schedule                Schedule jobs (timespec and calls)
+  shelly_webhook                 Webhooks
+  shelly_script                  Scripts, with their code
+  shelly_kvs                     KVS entries
+
+Every resource has a device attribute, the device address, which
+references the locals block. Component resources add component_id and the
+fields of the component's GetConfig result except id; schedules and
+webhooks carry the fields of Schedule.List jobs and Webhook.List hooks
+except id; scripts have name, enable and code; KVS entries have key and
+value.
+
+--import-script writes a shell script of terraform import commands mapping
+each resource to the existing object. Import IDs have the form
+<address>/<object>, e.g. 192.168.1.10/switch:0 or 192.168.1.10/schedule:3.`,
 		Example: `  # Export to stdout
   shelly export terraform @all
 
@@ -46,7 +75,11 @@ used as data source for infrastructure as code workflows.`,
   shelly export terraform @all shelly.tf
 
   # Export with custom resource name
-  shelly export terraform @all --resource-name my_shelly_devices`,
+  shelly export terraform @all --resource-name my_shelly_devices
+
+  # Export provider resources and the commands to import them
+  shelly export terraform @all shelly.tf --resources \
+    --provider-source example/shelly --import-script import.sh`,
 		Args:              cobra.MinimumNArgs(1),
 		ValidArgsFunction: completion.DevicesWithGroups(),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -56,55 +89,110 @@ used as data source for infrastructure as code workflows.`,
 	}
 
 	cmd.Flags().StringVar(&opts.ResourceName, "resource-name", "shelly_devices", "Terraform local variable name")
+	cmd.Flags().BoolVar(&opts.Resources, "resources", false, "Export provider resources from live device configuration")
+	cmd.Flags().StringVar(&opts.ProviderSource, "provider-source", "", "Provider source address for required_providers (required with --resources)")
+	cmd.Flags().StringVar(&opts.ImportScript, "import-script", "", "Write terraform import commands to this file (requires --resources)")
 
 	return cmd
 }
 
 func run(ctx context.Context, opts *Options) error {
-	ctx, cancel := context.WithTimeout(ctx, 2*shelly.DefaultTimeout)
-	defer cancel()
-
 	ios := opts.Factory.IOStreams()
 	svc := opts.Factory.ShellyService()
 
+	if opts.ImportScript != "" && !opts.Resources {
+		return fmt.Errorf("--import-script requires --resources")
+	}
+	if opts.Resources && opts.ProviderSource == "" {
+		return fmt.Errorf("--resources requires --provider-source")
+	}
+
 	// Expand @all to all registered devices
 	devices := completion.ExpandDeviceArgs(opts.Devices)
 	if len(devices) == 0 {
 		return fmt.Errorf("no devices specified")
 	}
 
+	// Reading a device's config for --resources takes several requests, so
+	// allow time for every device rather than a fixed limit.
+	timeout := 2 * shelly.DefaultTimeout
+	if opts.Resources {
+		timeout = shelly.DefaultTimeout * time.Duration(len(devices)+1)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	// Collect device data using shared helper
 	var deviceData []model.DeviceData
+	var resources []export.TerraformResource
+	var failed map[string]error
 	err := cmdutil.RunWithSpinner(ctx, ios, "Fetching device data...", func(ctx context.Context) error {
 		deviceData = svc.CollectDeviceData(ctx, devices)
+		if opts.Resources {
+			backups, errs := svc.CollectDeviceBackups(ctx, deviceData)
+			failed = errs
+			resources = export.BuildTerraformResources(deviceData, backups, opts.ResourceName)
+		}
 		return nil
 	})
 	if err != nil {
 		return err
 	}
+	for _, d := range deviceData {
+		if devErr, ok := failed[d.Name]; ok {
+			ios.Warning("No resources for %s: %v", d.Name, devErr)
+		}
+	}
 
 	// Build Terraform config using export builder
-	tfConfig, err := export.BuildTerraformConfig(deviceData, opts.ResourceName)
+	var tfConfig string
+	if opts.Resources {
+		tfConfig, err = export.BuildTerraformResourceConfig(deviceData, resources, export.TerraformResourceOptions{
+			ResourceName:   opts.ResourceName,
+			ProviderSource: opts.ProviderSource,
+		})
+	} else {
+		tfConfig, err = export.BuildTerraformConfig(deviceData, opts.ResourceName)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to build terraform config: %w", err)
 	}
 
+	if opts.ImportScript != "" {
+		if err := writeFile(opts.ImportScript, export.FormatTerraformImports(resources)); err != nil {
+			return err
+		}
+		ios.Success("Wrote %d import commands to %s", len(resources), opts.ImportScript)
+	}
+
 	// Output
 	if opts.File == "" {
 		ios.Printf("%s", tfConfig)
 		return nil
 	}
 
-	file, err := config.Fs().Create(opts.File)
+	if err := writeFile(opts.File, tfConfig); err != nil {
+		return err
+	}
+
+	if opts.Resources {
+		ios.Success("Exported %d devices and %d resources to %s", len(deviceData), len(resources), opts.File)
+		return nil
+	}
+	ios.Success("Exported %d devices to %s", len(deviceData), opts.File)
+	return nil
+}
+
+// writeFile creates path and writes content to it.
+func writeFile(path, content string) error {
+	file, err := config.Fs().Create(path)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer iostreams.CloseWithDebug("closing terraform export file", file)
 
-	if _, err := file.WriteString(tfConfig); err != nil {
+	if _, err := file.WriteString(content); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
-
-	ios.Success("Exported %d devices to %s", len(deviceData), opts.File)
 	return nil
 }
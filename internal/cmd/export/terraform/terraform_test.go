@@ -643,3 +643,64 @@ func TestRun_EmptyResourceName(t *testing.T) {
 		t.Log("Expected error (connection or validation)")
 	}
 }
+
+func TestNewCommand_ResourceFlags(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	tests := []struct {
+		name     string
+		defValue string
+	}{
+		{"resources", "false"},
+		{"provider-source", ""},
+		{"import-script", ""},
+	}
+	for _, tt := range tests {
+		flag := cmd.Flags().Lookup(tt.name)
+		if flag == nil {
+			t.Errorf("flag %q not found", tt.name)
+			continue
+		}
+		if flag.DefValue != tt.defValue {
+			t.Errorf("%s default = %q, want %q", tt.name, flag.DefValue, tt.defValue)
+		}
+	}
+}
+
+func TestRun_ImportScriptRequiresResources(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+
+	opts := &Options{
+		Factory:      tf.Factory,
+		Devices:      []string{"kitchen"},
+		ResourceName: "shelly_devices",
+		ImportScript: "import.sh",
+	}
+
+	err := run(context.Background(), opts)
+	if err == nil || !strings.Contains(err.Error(), "requires --resources") {
+		t.Errorf("run() error = %v, want requires --resources", err)
+	}
+}
+
+func TestRun_ResourcesRequireProviderSource(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+
+	opts := &Options{
+		Factory:      tf.Factory,
+		Devices:      []string{"kitchen"},
+		ResourceName: "shelly_devices",
+		Resources:    true,
+	}
+
+	err := run(context.Background(), opts)
+	if err == nil || !strings.Contains(err.Error(), "requires --provider-source") {
+		t.Errorf("run() error = %v, want requires --provider-source", err)
+	}
+}
@@ -6,7 +6,10 @@ import (
 
 	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/check"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/download"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/mirror"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/rollback"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/rollout"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/serve"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/status"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/update"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/updates"
@@ -43,11 +46,18 @@ Check for updates, update to the latest version, or rollback to a previous versi
   # Update all devices (non-interactive)
   shelly firmware updates --all --yes
 
+  # Health-checked rollout: canary group first, then waves by model
+  shelly firmware rollout start --all --canary-group test
+
   # Rollback to previous firmware
   shelly firmware rollback living-room
 
   # Download firmware file
-  shelly firmware download ShellyPlus1PM 1.0.0 --output firmware.zip`,
+  shelly firmware download ShellyPlus1PM 1.0.0 --output firmware.zip
+
+  # Mirror images for the fleet and serve them on the LAN
+  shelly firmware mirror --all
+  shelly firmware serve`,
 	}
 
 	cmd.AddCommand(check.NewCommand(f))
@@ -55,7 +65,10 @@ Check for updates, update to the latest version, or rollback to a previous versi
 	cmd.AddCommand(update.NewCommand(f))
 	cmd.AddCommand(updates.NewCommand(f))
 	cmd.AddCommand(rollback.NewCommand(f))
+	cmd.AddCommand(rollout.NewCommand(f))
 	cmd.AddCommand(download.NewCommand(f))
+	cmd.AddCommand(mirror.NewCommand(f))
+	cmd.AddCommand(serve.NewCommand(f))
 
 	return cmd
 }
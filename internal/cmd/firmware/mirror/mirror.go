@@ -0,0 +1,216 @@
+// Package mirror provides the firmware mirror subcommand.
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/tj-smith47/shelly-go/firmware"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/fwmirror"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+)
+
+// Options holds command options.
+type Options struct {
+	Factory *cmdutil.Factory
+	Devices []string
+	All     bool
+	Dir     string
+	Beta    bool
+	Prune   bool
+	Verify  bool
+}
+
+// NewCommand creates the firmware mirror command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:   "mirror [device...]",
+		Short: "Sync firmware images to a local mirror",
+		Long: `Download the firmware images needed by your devices into a local mirror.
+
+Each device is asked which firmware it would update to; devices of the
+same model and version share one download. Images are stored as
+<dir>/<model>/<version>/<file> with a SHA-256 checksum in <dir>/index.json.
+
+Running mirror again only downloads what is missing: images already in the
+mirror are verified against their checksum and downloaded again when they
+no longer match. Zip images must also open as a valid archive.
+
+The checksum is computed from the downloaded image, as Shelly publishes
+none to check it against. It detects later corruption of the mirror, not a
+bad or tampered download.
+
+Serve the mirror on your LAN with 'shelly firmware serve' and point devices
+at it with 'shelly firmware update --url'.`,
+		Example: `  # Mirror firmware for all registered devices
+  shelly firmware mirror --all
+
+  # Mirror beta firmware into a specific directory
+  shelly firmware mirror --all --beta --dir /srv/shelly-firmware
+
+  # Mirror and remove images no device needs any more
+  shelly firmware mirror --all --prune
+
+  # Verify the mirror without downloading anything
+  shelly firmware mirror --verify`,
+		ValidArgsFunction: completion.DeviceNames(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Devices = args
+			if !opts.Verify && !opts.All && len(opts.Devices) == 0 {
+				return fmt.Errorf("specify device(s) or use --all")
+			}
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Mirror firmware for all registered devices")
+	cmd.Flags().StringVar(&opts.Dir, "dir", "", "Mirror directory (default: firmware/mirror in the config directory)")
+	cmd.Flags().BoolVar(&opts.Beta, "beta", false, "Mirror beta firmware")
+	cmd.Flags().BoolVar(&opts.Prune, "prune", false, "Remove images not needed by the mirrored devices")
+	cmd.Flags().BoolVar(&opts.Verify, "verify", false, "Only verify the checksums of mirrored images")
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+
+	dir := opts.Dir
+	if dir == "" {
+		var err error
+		if dir, err = config.FirmwareMirrorDir(); err != nil {
+			return err
+		}
+	}
+
+	if opts.Verify {
+		return verify(opts, dir)
+	}
+
+	names := opts.Devices
+	if opts.All {
+		devices := config.ListDevices()
+		if len(devices) == 0 {
+			ios.Warning("No devices registered. Run 'shelly discover mdns --register' first.")
+			return nil
+		}
+		names = make([]string, 0, len(devices))
+		for name := range devices {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	stage := firmware.StageStable
+	if opts.Beta {
+		stage = firmware.StageBeta
+	}
+
+	var (
+		sources []fwmirror.Source
+		failed  map[string]error
+	)
+	err := cmdutil.RunWithSpinner(ctx, ios, "Checking devices for firmware...", func(ctx context.Context) error {
+		sources, failed = opts.Factory.ShellyService().FirmwareMirrorSources(ctx, names, stage)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(failed) {
+		ios.Warning("Skipping %s: %v", name, failed[name])
+	}
+	if len(sources) == 0 {
+		ios.Info("No firmware to mirror: all devices are up to date")
+		return nil
+	}
+
+	var results []fwmirror.Result
+	err = cmdutil.RunWithSpinner(ctx, ios, fmt.Sprintf("Syncing %d image(s) to %s...", len(fwmirror.Merge(sources)), dir), func(ctx context.Context) error {
+		var syncErr error
+		results, syncErr = fwmirror.Sync(ctx, dir, sources, firmware.NewDownloader().DownloadToWriter)
+		return syncErr
+	})
+	if err != nil {
+		return err
+	}
+	term.DisplayMirrorResults(ios, results)
+
+	if opts.Prune {
+		if err := prune(opts, dir, sources); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range results {
+		if r.Err != nil {
+			return fmt.Errorf("some images could not be mirrored")
+		}
+	}
+	return nil
+}
+
+func verify(opts *Options, dir string) error {
+	ios := opts.Factory.IOStreams()
+	idx, err := fwmirror.LoadIndex(dir)
+	if err != nil {
+		return err
+	}
+	if len(idx.Images) == 0 {
+		ios.Info("The mirror in %s is empty", dir)
+		return nil
+	}
+
+	bad := 0
+	for _, img := range idx.Images {
+		if err := fwmirror.Verify(dir, img); err != nil {
+			ios.Error("%s: %v", img.File, err)
+			bad++
+			continue
+		}
+		ios.Success("%s", img.File)
+	}
+	if bad > 0 {
+		return fmt.Errorf("%d of %d image(s) failed verification; run 'shelly firmware mirror --all' to repair", bad, len(idx.Images))
+	}
+	return nil
+}
+
+func prune(opts *Options, dir string, keep []fwmirror.Source) error {
+	ios := opts.Factory.IOStreams()
+	idx, err := fwmirror.LoadIndex(dir)
+	if err != nil {
+		return err
+	}
+	removed, err := fwmirror.Prune(dir, idx, keep)
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	if err := fwmirror.SaveIndex(dir, idx); err != nil {
+		return err
+	}
+	for _, img := range removed {
+		ios.Info("Pruned %s", img.File)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]error) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,99 @@
+package mirror
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/fwmirror"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "mirror [device...]" {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+	if cmd.Short == "" || cmd.Long == "" || cmd.Example == "" {
+		t.Error("help text is incomplete")
+	}
+	for _, name := range []string{"all", "dir", "beta", "prune", "verify"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("flag --%s not defined", name)
+		}
+	}
+}
+
+func TestNewCommand_RequiresDevices(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	err := cmd.RunE(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "specify device(s) or use --all") {
+		t.Errorf("RunE() error = %v", err)
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestRun_AllWithoutDevices(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	factory.SetupTestFs(t)
+
+	if err := run(context.Background(), &Options{Factory: tf.Factory, All: true}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(tf.ErrString()+tf.OutString(), "No devices registered") {
+		t.Errorf("output = %q", tf.ErrString())
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestRun_Verify(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	factory.SetupTestFs(t)
+	dir, err := config.FirmwareMirrorDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := &fwmirror.Index{Images: []fwmirror.Image{
+		{Model: "SHSW-1", Version: "1.14.0", File: "SHSW-1/1.14.0/fw.bin", Size: 2,
+			SHA256: "8f434346648f6b96df89dda901c5176b10a6d83961dd3c1ac88b59b2dc327aa4"}, // sha256("hi")
+		{Model: "SNSW-001P16EU", Version: "1.4.4", File: "SNSW-001P16EU/1.4.4/fw.bin", Size: 2, SHA256: "0"},
+	}}
+	for _, img := range idx.Images {
+		if err := afero.WriteFile(config.Fs(), filepath.Join(dir, img.File), []byte("hi"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fwmirror.SaveIndex(dir, idx); err != nil {
+		t.Fatal(err)
+	}
+
+	err = run(context.Background(), &Options{Factory: tf.Factory, Verify: true})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 image(s) failed verification") {
+		t.Errorf("run() error = %v", err)
+	}
+	if !strings.Contains(tf.ErrString()+tf.OutString(), "checksum mismatch") {
+		t.Errorf("output = %q", tf.ErrString()+tf.OutString())
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestRun_VerifyEmpty(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	factory.SetupTestFs(t)
+
+	if err := run(context.Background(), &Options{Factory: tf.Factory, Verify: true, Dir: "/mirror"}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(tf.ErrString()+tf.OutString(), "is empty") {
+		t.Errorf("output = %q", tf.ErrString()+tf.OutString())
+	}
+}
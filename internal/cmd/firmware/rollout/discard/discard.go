@@ -0,0 +1,75 @@
+// Package discard provides the firmware rollout discard subcommand.
+package discard
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil/flags"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// Options holds command options.
+type Options struct {
+	flags.ConfirmFlags
+	Factory *cmdutil.Factory
+}
+
+// NewCommand creates the firmware rollout discard command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "discard",
+		Aliases: []string{"clear"},
+		Short:   "Forget the recorded firmware rollout",
+		Long: `Delete the recorded firmware rollout so a new one can be started.
+
+Devices are not changed: updates already applied stay applied.`,
+		Example: `  # Discard the current rollout
+  shelly firmware rollout discard
+
+  # Discard without confirmation
+  shelly firmware rollout discard --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return run(opts)
+		},
+	}
+
+	flags.AddYesOnlyFlag(cmd, &opts.ConfirmFlags)
+
+	return cmd
+}
+
+func run(opts *Options) error {
+	ios := opts.Factory.IOStreams()
+
+	state, err := config.LoadRolloutState()
+	if errors.Is(err, config.ErrNoRollout) {
+		ios.Info("No firmware rollout recorded")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !state.Completed {
+		confirmed, err := opts.Factory.ConfirmAction("Discard the unfinished firmware rollout?", opts.Yes)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			ios.Warning("Discard cancelled")
+			return nil
+		}
+	}
+
+	if err := config.ClearRolloutState(); err != nil {
+		return err
+	}
+	ios.Success("Firmware rollout discarded")
+	return nil
+}
@@ -0,0 +1,56 @@
+package discard
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil/flags"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "discard" {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+	if cmd.Flags().Lookup("yes") == nil {
+		t.Error("flag --yes not found")
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestRun_NoRollout(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	factory.SetupTestFs(t)
+
+	if err := run(&Options{Factory: tf.Factory}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(tf.OutString(), "No firmware rollout recorded") {
+		t.Errorf("output = %q", tf.OutString())
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestRun_Discard(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	factory.SetupTestFs(t)
+	if err := config.SaveRolloutState(&config.RolloutState{}); err != nil {
+		t.Fatalf("SaveRolloutState() error = %v", err)
+	}
+
+	if err := run(&Options{Factory: tf.Factory, ConfirmFlags: flags.ConfirmFlags{Yes: true}}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if _, err := config.LoadRolloutState(); !errors.Is(err, config.ErrNoRollout) {
+		t.Errorf("LoadRolloutState() error = %v, want ErrNoRollout", err)
+	}
+	if !strings.Contains(tf.OutString(), "discarded") {
+		t.Errorf("output = %q", tf.OutString())
+	}
+}
@@ -0,0 +1,90 @@
+// Package resume provides the firmware rollout resume subcommand.
+package resume
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// Options holds command options.
+type Options struct {
+	Factory     *cmdutil.Factory
+	RetryFailed bool
+}
+
+// NewCommand creates the firmware rollout resume command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Resume an interrupted or halted rollout",
+		Long: `Resume the recorded firmware rollout from where it stopped.
+
+Devices whose update was started but not yet verified are health-checked
+without updating them again when their firmware already changed.
+
+A halted rollout only resumes with --retry-failed, which returns failed,
+rolled back and skipped devices to pending and clears the halt.`,
+		Example: `  # Continue after an interruption
+  shelly firmware rollout resume
+
+  # Retry failed devices after fixing them
+  shelly firmware rollout resume --retry-failed`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.RetryFailed, "retry-failed", false, "Retry failed, rolled back and skipped devices and clear a halt")
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+
+	state, err := config.LoadRolloutState()
+	if errors.Is(err, config.ErrNoRollout) {
+		ios.Info("No firmware rollout to resume. Start one with 'shelly firmware rollout start'.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if state.Completed {
+		ios.Info("The firmware rollout has already completed")
+		return nil
+	}
+
+	if opts.RetryFailed {
+		retried := 0
+		for name, d := range state.Devices {
+			switch d.Status {
+			case config.RolloutFailed, config.RolloutRolledBack, config.RolloutSkipped:
+				d.Status, d.Problem = config.RolloutPending, ""
+				state.Devices[name] = d
+				retried++
+			default:
+			}
+		}
+		state.Halted, state.HaltReason = false, ""
+		if err := config.SaveRolloutState(state); err != nil {
+			return err
+		}
+		ios.Info("Retrying %d device(s)", retried)
+	}
+
+	if state.Halted {
+		return fmt.Errorf("rollout halted: %s (use --retry-failed to retry failed devices)", state.HaltReason)
+	}
+
+	return cmdutil.RunRollout(ctx, opts.Factory, state)
+}
@@ -0,0 +1,113 @@
+package resume
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rollout"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+// unreachable is a device address that refuses connections immediately.
+const unreachable = "127.0.0.1:1"
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "resume" {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+	if cmd.Short == "" || cmd.Long == "" || cmd.Example == "" {
+		t.Error("help text is incomplete")
+	}
+	if cmd.Flags().Lookup("retry-failed") == nil {
+		t.Error("flag --retry-failed not found")
+	}
+}
+
+func saveState(t *testing.T, status config.RolloutDeviceStatus, halted bool) *config.RolloutState {
+	t.Helper()
+	factory.SetupTestFs(t)
+	state := rollout.NewState([]config.RolloutWave{{Name: "all", Devices: []string{unreachable}}})
+	state.HealthTimeout = time.Second
+	state.MaxFailureRatio = 1
+	state.Devices[unreachable] = config.RolloutDevice{Status: status, Problem: "timeout"}
+	if halted {
+		state.Halted, state.HaltReason = true, "1 of 1 canary devices failed"
+	}
+	if err := config.SaveRolloutState(state); err != nil {
+		t.Fatalf("SaveRolloutState() error = %v", err)
+	}
+	return state
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestRun_NoRollout(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	factory.SetupTestFs(t)
+
+	if err := run(context.Background(), &Options{Factory: tf.Factory}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(tf.OutString(), "No firmware rollout") {
+		t.Errorf("output = %q", tf.OutString())
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestRun_Halted(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	saveState(t, config.RolloutFailed, true)
+
+	err := run(context.Background(), &Options{Factory: tf.Factory})
+	if err == nil || !strings.Contains(err.Error(), "--retry-failed") {
+		t.Errorf("run() error = %v, want halted error", err)
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestRun_RetryFailed(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	saveState(t, config.RolloutFailed, true)
+
+	if err := run(context.Background(), &Options{Factory: tf.Factory, RetryFailed: true}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	state, err := config.LoadRolloutState()
+	if err != nil {
+		t.Fatalf("LoadRolloutState() error = %v", err)
+	}
+	if state.Halted || !state.Completed {
+		t.Errorf("state halted = %v, completed = %v", state.Halted, state.Completed)
+	}
+	// The retried device is unreachable, so it is skipped this time.
+	if d := state.Devices[unreachable]; d.Status != config.RolloutSkipped {
+		t.Errorf("device = %+v, want skipped", d)
+	}
+	if !strings.Contains(tf.OutString(), "Retrying 1 device(s)") {
+		t.Errorf("output = %q", tf.OutString())
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestRun_Completed(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	state := saveState(t, config.RolloutHealthy, false)
+	state.Completed = true
+	if err := config.SaveRolloutState(state); err != nil {
+		t.Fatalf("SaveRolloutState() error = %v", err)
+	}
+
+	if err := run(context.Background(), &Options{Factory: tf.Factory}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(tf.OutString(), "already completed") {
+		t.Errorf("output = %q", tf.OutString())
+	}
+}
@@ -0,0 +1,53 @@
+// Package rollout provides the firmware rollout commands.
+package rollout
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/rollout/discard"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/rollout/resume"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/rollout/start"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/firmware/rollout/status"
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+)
+
+// NewCommand creates the firmware rollout command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rollout",
+		Aliases: []string{"ro"},
+		Short:   "Roll out firmware in health-checked waves",
+		Long: `Roll out firmware updates progressively across a fleet.
+
+A rollout updates a canary wave first, then the remaining devices in waves
+grouped by model or device group. Every updated device is health-checked:
+it must come back online on new firmware with the same components, without
+new component errors, and answering the RPCs given with --check-rpc.
+
+A wave whose failure ratio exceeds --max-failure-ratio halts the rollout;
+any canary failure halts it too. Failed devices can be rolled back
+automatically. Progress is saved after every device, so an interrupted or
+halted rollout can be resumed.`,
+		Example: `  # Canary on the "test" group, then waves by model
+  shelly firmware rollout start --all --canary-group test
+
+  # Preview the waves
+  shelly firmware rollout start --all --wave-size 10 --dry-run
+
+  # Show progress of the current rollout
+  shelly firmware rollout status
+
+  # Continue after an interruption, retrying failed devices
+  shelly firmware rollout resume --retry-failed
+
+  # Forget the current rollout
+  shelly firmware rollout discard`,
+	}
+
+	cmd.AddCommand(start.NewCommand(f))
+	cmd.AddCommand(resume.NewCommand(f))
+	cmd.AddCommand(status.NewCommand(f))
+	cmd.AddCommand(discard.NewCommand(f))
+
+	return cmd
+}
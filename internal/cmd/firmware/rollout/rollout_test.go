@@ -0,0 +1,28 @@
+package rollout
+
+import (
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "rollout" {
+		t.Errorf("Use = %q, want rollout", cmd.Use)
+	}
+
+	want := map[string]bool{"start": false, "resume": false, "status": false, "discard": false}
+	for _, sub := range cmd.Commands() {
+		if _, ok := want[sub.Name()]; ok {
+			want[sub.Name()] = true
+		}
+	}
+	for name, found := range want {
+		if !found {
+			t.Errorf("subcommand %q not registered", name)
+		}
+	}
+}
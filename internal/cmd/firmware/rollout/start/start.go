@@ -0,0 +1,240 @@
+// Package start provides the firmware rollout start subcommand.
+package start
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil/flags"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rollout"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+)
+
+// Defaults for rollout options.
+const (
+	defaultCanary          = 1
+	defaultMaxFailureRatio = 0.2
+	defaultHealthTimeout   = 10 * time.Minute
+	defaultParallelism     = 3
+)
+
+// Options holds command options.
+type Options struct {
+	flags.ConfirmFlags
+	Factory         *cmdutil.Factory
+	Devices         []string
+	All             bool
+	Beta            bool
+	URL             string
+	CanaryGroup     string
+	Canary          int
+	WaveBy          string
+	WaveSize        int
+	MaxFailureRatio float64
+	RollbackFailed  bool
+	HealthTimeout   time.Duration
+	CheckRPCs       []string
+	Parallelism     int
+	DryRun          bool
+}
+
+// NewCommand creates the firmware rollout start command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:   "start [device...]",
+		Short: "Plan and start a firmware rollout",
+		Long: `Plan a firmware rollout and run it wave by wave.
+
+Only devices with an available update are included, unless --url is given.
+The first wave is the canary: the members of --canary-group, or the first
+--canary devices by name. The remaining devices are grouped by model
+(--wave-by model), by their first device group (--wave-by group) or not at
+all (--wave-by none), and each group is split into waves of --wave-size.
+
+After its update each device is polled until it reports new firmware and
+passes its health checks, or --health-timeout passes. Health checks compare
+the device with its pre-update state:
+  - the device is back online
+  - every component it had is still present
+  - no component reports an error it did not report before
+  - every --check-rpc method answers (HTTP paths such as /status on Gen1)
+
+Any canary failure, or a wave failure ratio above --max-failure-ratio,
+halts the rollout. With --rollback-failed, failed devices are rolled back
+to their previous firmware.
+
+Progress is saved after every device. Use 'shelly firmware rollout resume'
+to continue an interrupted or halted rollout.`,
+		Example: `  # Canary on the "test" group, then waves by model
+  shelly firmware rollout start --all --canary-group test
+
+  # Two canaries, waves of at most 10 devices per device group
+  shelly firmware rollout start --all --canary 2 --wave-by group --wave-size 10
+
+  # Halt when more than 10% of a wave fails and roll failed devices back
+  shelly firmware rollout start --all --max-failure-ratio 0.1 --rollback-failed
+
+  # Also require these RPCs to answer after the update
+  shelly firmware rollout start --all --check-rpc Switch.GetStatus --check-rpc Sys.GetStatus
+
+  # Preview the plan only
+  shelly firmware rollout start --all --dry-run`,
+		ValidArgsFunction: completion.DeviceNames(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Devices = args
+			if !opts.All && len(opts.Devices) == 0 {
+				return fmt.Errorf("specify device(s) or use --all")
+			}
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Roll out to all registered devices")
+	cmd.Flags().BoolVar(&opts.Beta, "beta", false, "Update to beta firmware")
+	cmd.Flags().StringVar(&opts.URL, "url", "", "Custom firmware URL")
+	cmd.Flags().StringVar(&opts.CanaryGroup, "canary-group", "", "Device group that forms the canary wave")
+	cmd.Flags().IntVar(&opts.Canary, "canary", defaultCanary, "Number of canary devices when no --canary-group is given (0 to disable)")
+	cmd.Flags().StringVar(&opts.WaveBy, "wave-by", rollout.WaveByModel, "Group waves by: model, group, none")
+	cmd.Flags().IntVar(&opts.WaveSize, "wave-size", 0, "Maximum devices per wave (0 for no limit)")
+	cmd.Flags().Float64Var(&opts.MaxFailureRatio, "max-failure-ratio", defaultMaxFailureRatio, "Halt when more than this share of a wave fails (0-1)")
+	cmd.Flags().BoolVar(&opts.RollbackFailed, "rollback-failed", false, "Roll back devices that fail their update or health checks")
+	cmd.Flags().DurationVar(&opts.HealthTimeout, "health-timeout", defaultHealthTimeout, "How long to wait for a device to come back healthy")
+	cmd.Flags().StringArrayVar(&opts.CheckRPCs, "check-rpc", nil, "RPC method (or Gen1 HTTP path) that must answer after the update (repeatable)")
+	cmd.Flags().IntVar(&opts.Parallelism, "parallel", defaultParallelism, "Number of devices to update in parallel within a wave")
+	flags.AddDryRunFlag(cmd, &opts.DryRun)
+	flags.AddYesOnlyFlag(cmd, &opts.ConfirmFlags)
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	f := opts.Factory
+	ios := f.IOStreams()
+
+	if opts.MaxFailureRatio < 0 || opts.MaxFailureRatio > 1 {
+		return fmt.Errorf("--max-failure-ratio must be between 0 and 1")
+	}
+	if opts.HealthTimeout <= 0 {
+		opts.HealthTimeout = defaultHealthTimeout
+	}
+
+	existing, err := config.LoadRolloutState()
+	switch {
+	case errors.Is(err, config.ErrNoRollout):
+	case err != nil:
+		return err
+	case !existing.Completed:
+		return fmt.Errorf("a firmware rollout is already in progress: use 'shelly firmware rollout resume' or 'shelly firmware rollout discard'")
+	}
+
+	names := opts.Devices
+	if opts.All {
+		devices := config.ListDevices()
+		if len(devices) == 0 {
+			ios.Warning("No devices registered. Run 'shelly discover mdns --register' first.")
+			return nil
+		}
+		names = make([]string, 0, len(devices))
+		for name := range devices {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	targets := findTargets(ctx, opts, names)
+	if len(targets) == 0 {
+		if opts.Beta {
+			ios.Info("No devices have a beta update")
+			return nil
+		}
+		ios.Info("All devices are up to date")
+		return nil
+	}
+
+	waves, err := rollout.BuildPlan(targets, rollout.PlanOpts{
+		CanaryGroup: opts.CanaryGroup,
+		CanaryCount: opts.Canary,
+		WaveBy:      opts.WaveBy,
+		WaveSize:    opts.WaveSize,
+	})
+	if err != nil {
+		return err
+	}
+	term.DisplayRolloutPlan(ios, waves)
+
+	if opts.DryRun {
+		ios.Info("Dry run: no devices were updated")
+		return nil
+	}
+
+	confirmed, err := f.ConfirmAction(fmt.Sprintf("Roll out firmware to %d device(s) in %d wave(s)?", len(targets), len(waves)), opts.Yes)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		ios.Warning("Rollout cancelled")
+		return nil
+	}
+
+	state := rollout.NewState(waves)
+	state.StartedAt = time.Now()
+	state.Beta = opts.Beta
+	state.URL = opts.URL
+	state.Parallelism = opts.Parallelism
+	state.MaxFailureRatio = opts.MaxFailureRatio
+	state.RollbackFailed = opts.RollbackFailed
+	state.HealthTimeout = opts.HealthTimeout
+	state.CheckRPCs = opts.CheckRPCs
+	if err := config.SaveRolloutState(state); err != nil {
+		return err
+	}
+
+	return cmdutil.RunRollout(ctx, f, state)
+}
+
+// findTargets returns the devices to update with their model and groups.
+// Without --url, only devices with an available update are returned.
+func findTargets(ctx context.Context, opts *Options, names []string) []rollout.Target {
+	f := opts.Factory
+	devices := config.ListDevices()
+
+	groups := make(map[string][]string)
+	for groupName, g := range config.ListGroups() {
+		for _, d := range g.Devices {
+			groups[d] = append(groups[d], groupName)
+		}
+	}
+
+	newTarget := func(name, deviceModel string) rollout.Target {
+		if deviceModel == "" {
+			deviceModel = devices[name].Type
+		}
+		gs := groups[name]
+		slices.Sort(gs)
+		return rollout.Target{Name: name, Model: deviceModel, Groups: gs}
+	}
+
+	var targets []rollout.Target
+	if opts.URL != "" {
+		for _, name := range names {
+			targets = append(targets, newTarget(name, ""))
+		}
+		return targets
+	}
+
+	for _, s := range f.ShellyService().CheckDevicesForStage(ctx, f.IOStreams(), names, 100, opts.Beta) {
+		targets = append(targets, newTarget(s.Name, s.Info.DeviceModel))
+	}
+	return targets
+}
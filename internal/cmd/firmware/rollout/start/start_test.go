@@ -0,0 +1,130 @@
+package start
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "start [device...]" {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+	if cmd.Short == "" || cmd.Long == "" || cmd.Example == "" {
+		t.Error("help text is incomplete")
+	}
+
+	flagDefaults := map[string]string{
+		"all":               "false",
+		"canary":            "1",
+		"canary-group":      "",
+		"wave-by":           "model",
+		"wave-size":         "0",
+		"max-failure-ratio": "0.2",
+		"rollback-failed":   "false",
+		"health-timeout":    "10m0s",
+		"check-rpc":         "[]",
+		"parallel":          "3",
+		"dry-run":           "false",
+		"yes":               "false",
+	}
+	for name, want := range flagDefaults {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			t.Errorf("flag --%s not found", name)
+			continue
+		}
+		if flag.DefValue != want {
+			t.Errorf("--%s default = %q, want %q", name, flag.DefValue, want)
+		}
+	}
+}
+
+func TestNewCommand_RequiresDevices(t *testing.T) {
+	t.Parallel()
+	tf := factory.NewTestFactory(t)
+	cmd := NewCommand(tf.Factory)
+	cmd.SetArgs([]string{})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "specify device(s) or use --all") {
+		t.Errorf("Execute() error = %v, want device error", err)
+	}
+}
+
+func TestRun_InvalidFailureRatio(t *testing.T) {
+	t.Parallel()
+	tf := factory.NewTestFactory(t)
+
+	err := run(context.Background(), &Options{Factory: tf.Factory, Devices: []string{"a"}, MaxFailureRatio: 1.5})
+	if err == nil || !strings.Contains(err.Error(), "--max-failure-ratio") {
+		t.Errorf("run() error = %v, want ratio error", err)
+	}
+}
+
+//nolint:paralleltest // Sets the global default config manager
+func TestRun_DryRunPlan(t *testing.T) {
+	tf := factory.NewTestFactoryWithDevices(t, map[string]model.Device{
+		"kitchen": {Name: "kitchen", Address: "127.0.0.1:1", Type: "SNSW-001P16EU"},
+		"garage":  {Name: "garage", Address: "127.0.0.1:1", Type: "SHSW-1"},
+		"office":  {Name: "office", Address: "127.0.0.1:1", Type: "SNSW-001P16EU"},
+	})
+	tf.Config.Groups["test"] = config.Group{Devices: []string{"office"}}
+	config.SetDefaultManager(tf.Manager)
+	t.Cleanup(config.ResetDefaultManagerForTesting)
+
+	err := run(context.Background(), &Options{
+		Factory:     tf.Factory,
+		All:         true,
+		URL:         "http://example.com/fw.zip",
+		CanaryGroup: "test",
+		WaveBy:      "model",
+		DryRun:      true,
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	out := tf.OutString()
+	for _, want := range []string{"canary", "office", "model SHSW-1", "garage", "model SNSW-001P16EU", "kitchen", "Dry run"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+//nolint:paralleltest // Sets the global default config manager
+func TestRun_NoDevices(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	config.SetDefaultManager(tf.Manager)
+	t.Cleanup(config.ResetDefaultManagerForTesting)
+
+	if err := run(context.Background(), &Options{Factory: tf.Factory, All: true}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(tf.ErrString()+tf.OutString(), "No devices registered") {
+		t.Error("expected no devices warning")
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestRun_RolloutInProgress(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	factory.SetupTestFs(t)
+	if err := config.SaveRolloutState(&config.RolloutState{}); err != nil {
+		t.Fatalf("SaveRolloutState() error = %v", err)
+	}
+
+	err := run(context.Background(), &Options{Factory: tf.Factory, Devices: []string{"a"}})
+	if err == nil || !strings.Contains(err.Error(), "already in progress") {
+		t.Errorf("run() error = %v, want in-progress error", err)
+	}
+}
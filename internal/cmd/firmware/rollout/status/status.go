@@ -0,0 +1,55 @@
+// Package status provides the firmware rollout status subcommand.
+package status
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+)
+
+// Options holds command options.
+type Options struct {
+	Factory *cmdutil.Factory
+}
+
+// NewCommand creates the firmware rollout status command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "status",
+		Aliases: []string{"st"},
+		Short:   "Show firmware rollout progress",
+		Long: `Show the recorded firmware rollout: every device with its wave, status,
+firmware versions and the problem that failed it.`,
+		Example: `  # Show rollout progress
+  shelly firmware rollout status
+
+  # Output as JSON
+  shelly firmware rollout status -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return run(opts)
+		},
+	}
+
+	return cmd
+}
+
+func run(opts *Options) error {
+	ios := opts.Factory.IOStreams()
+
+	state, err := config.LoadRolloutState()
+	if errors.Is(err, config.ErrNoRollout) {
+		ios.Info("No firmware rollout recorded")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return cmdutil.PrintResult(ios, state, term.DisplayRolloutState)
+}
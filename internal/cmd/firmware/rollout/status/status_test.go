@@ -0,0 +1,92 @@
+package status
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "status" {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+	if cmd.Short == "" || cmd.Long == "" || cmd.Example == "" {
+		t.Error("help text is incomplete")
+	}
+}
+
+func saveState(t *testing.T) {
+	t.Helper()
+	factory.SetupTestFs(t)
+	state := &config.RolloutState{
+		Waves: []config.RolloutWave{{Name: "canary", Devices: []string{"kitchen", "garage"}}},
+		Devices: map[string]config.RolloutDevice{
+			"kitchen": {Status: config.RolloutHealthy, FromVersion: "1.4.2", ToVersion: "1.4.4"},
+			"garage":  {Status: config.RolloutRolledBack, FromVersion: "1.4.2", Problem: "missing components: switch:0"},
+		},
+		Halted:     true,
+		HaltReason: "1 of 2 canary devices failed",
+	}
+	if err := config.SaveRolloutState(state); err != nil {
+		t.Fatalf("SaveRolloutState() error = %v", err)
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestRun_NoRollout(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	factory.SetupTestFs(t)
+
+	if err := run(&Options{Factory: tf.Factory}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(tf.OutString(), "No firmware rollout recorded") {
+		t.Errorf("output = %q", tf.OutString())
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem and viper
+func TestRun_Table(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	saveState(t)
+	viper.Set("output", "table")
+	defer viper.Reset()
+
+	if err := run(&Options{Factory: tf.Factory}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	out := tf.OutString() + tf.ErrString()
+	for _, want := range []string{"halted", "kitchen", "1.4.4", "garage", "missing components: switch:0", "1 healthy"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem and viper
+func TestRun_JSON(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	saveState(t)
+	viper.Set("output", "json")
+	defer viper.Reset()
+
+	if err := run(&Options{Factory: tf.Factory}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	var state config.RolloutState
+	if err := json.Unmarshal([]byte(tf.OutString()), &state); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, tf.OutString())
+	}
+	if !state.Halted || state.Devices["garage"].Status != config.RolloutRolledBack {
+		t.Errorf("state = %+v", state)
+	}
+}
@@ -0,0 +1,120 @@
+// Package serve provides the firmware serve subcommand.
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/fwmirror"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+	"github.com/tj-smith47/shelly-cli/internal/webhook"
+)
+
+// Options holds command options.
+type Options struct {
+	Factory *cmdutil.Factory
+	Dir     string
+	Port    int
+	Host    string
+}
+
+// NewCommand creates the firmware serve command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f, Port: 8080}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the firmware mirror over HTTP",
+		Long: `Serve the local firmware mirror over HTTP so devices on the LAN can
+update from it instead of the internet.
+
+The mirror is served read-only: images under <model>/<version>/<file> and
+the index at /index.json. On start, the URL of every mirrored image is
+printed with the devices that need it; pass it to
+'shelly firmware update --url'.
+
+Populate the mirror first with 'shelly firmware mirror'.`,
+		Example: `  # Serve the default mirror on port 8080
+  shelly firmware serve
+
+  # Serve a specific directory, advertising a fixed address
+  shelly firmware serve --dir /srv/shelly-firmware --port 8000 --host 192.168.1.10
+
+  # Then update a device from the mirror
+  shelly firmware update kitchen --url http://192.168.1.10:8080/SNSW-001P16EU/1.4.4/SNSW-001P16EU.zip`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Dir, "dir", "", "Mirror directory (default: firmware/mirror in the config directory)")
+	cmd.Flags().IntVar(&opts.Port, "port", opts.Port, "HTTP port")
+	cmd.Flags().StringVar(&opts.Host, "host", "", "Address devices use to reach this host (default: detected LAN IP)")
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+
+	dir := opts.Dir
+	if dir == "" {
+		var err error
+		if dir, err = config.FirmwareMirrorDir(); err != nil {
+			return err
+		}
+	}
+	idx, err := fwmirror.LoadIndex(dir)
+	if err != nil {
+		return err
+	}
+
+	host := opts.Host
+	if host == "" {
+		host = webhook.GetLocalIP()
+	}
+
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", fmt.Sprintf(":%d", opts.Port))
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	baseURL := "http://" + net.JoinHostPort(host, strconv.Itoa(port))
+
+	server := &http.Server{
+		Handler:           fwmirror.Handler(dir),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ios.Printf("Serving firmware mirror %s on %s\n", dir, baseURL)
+	if len(idx.Images) == 0 {
+		ios.Warning("The mirror is empty. Run 'shelly firmware mirror --all' to populate it.")
+	} else {
+		term.DisplayMirrorImages(ios, idx.Images, baseURL)
+	}
+	ios.Printf("Press Ctrl+C to stop\n")
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if shutdownErr := server.Shutdown(shutdownCtx); shutdownErr != nil {
+			ios.DebugErr("server shutdown", shutdownErr)
+		}
+	}()
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package serve
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/fwmirror"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "serve" {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+	if cmd.Short == "" || cmd.Long == "" || cmd.Example == "" {
+		t.Error("help text is incomplete")
+	}
+	port := cmd.Flags().Lookup("port")
+	if port == nil || port.DefValue != "8080" {
+		t.Errorf("port flag = %+v", port)
+	}
+	if cmd.Args == nil || cmd.Args(cmd, []string{"extra"}) == nil {
+		t.Error("expected serve to reject arguments")
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestRun(t *testing.T) {
+	tf := factory.NewTestFactory(t)
+	factory.SetupTestFs(t)
+	if err := fwmirror.SaveIndex("/mirror", &fwmirror.Index{Images: []fwmirror.Image{
+		{Model: "SHSW-1", Version: "1.14.0", Stage: "stable", File: "SHSW-1/1.14.0/fw.zip", Devices: []string{"garage"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := run(ctx, &Options{Factory: tf.Factory, Dir: "/mirror", Host: "10.0.0.2"}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	out := tf.OutString()
+	for _, want := range []string{"Serving firmware mirror /mirror on http://10.0.0.2:", "/SHSW-1/1.14.0/fw.zip", "garage"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -44,7 +44,8 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 		Long: `Update device firmware to the latest version.
 
 By default, updates to the latest stable version. Use --beta for beta firmware
-or --url for a custom firmware file.
+or --url for a custom firmware file, such as an image on a LAN mirror started
+with 'shelly firmware serve'.
 
 Use --list to show available updates before prompting for confirmation.
 This is useful for reviewing what version will be installed.
@@ -53,7 +54,8 @@ Supports both native Shelly devices and plugin-managed devices (Tasmota, etc.).
 Plugin devices are automatically detected and updated using the appropriate plugin.
 
 Use --all to update all registered devices. The --staged flag allows percentage-based
-rollouts (e.g., --staged 25 updates 25% of devices).`,
+rollouts (e.g., --staged 25 updates 25% of devices). For canary waves with
+post-update health checks and automatic halting, use 'shelly firmware rollout'.`,
 		Example: `  # Update to latest stable
   shelly firmware update living-room
 
@@ -48,7 +48,8 @@ for piping to InfluxDB or Telegraf.
 
 Format: measurement,tags field=value,field=value timestamp
 
-Use --continuous to stream metrics at regular intervals.`,
+Use --continuous to stream metrics at regular intervals, or
+"shelly metrics push" to write to the InfluxDB API directly.`,
 		Example: `  # Output metrics once to stdout
   shelly metrics influxdb
 
@@ -7,6 +7,7 @@ import (
 	"github.com/tj-smith47/shelly-cli/internal/cmd/metrics/influxdb"
 	jsonmetrics "github.com/tj-smith47/shelly-cli/internal/cmd/metrics/json"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/metrics/prometheus"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/metrics/push"
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
 )
 
@@ -21,6 +22,7 @@ Supports multiple output formats for integration with monitoring systems:
   - Prometheus: Start an HTTP exporter for Prometheus scraping
   - JSON: Output metrics in JSON format for custom integrations
   - InfluxDB: Output in InfluxDB line protocol for time-series databases
+  - Push: Write directly to InfluxDB and OTLP collectors from one process
 
 All formats export: power, voltage, current, energy, temperature, and device status.`,
 		Aliases: []string{"metric", "export-metrics"},
@@ -31,12 +33,16 @@ All formats export: power, voltage, current, energy, temperature, and device sta
   shelly metric json kitchen
 
   # Export in InfluxDB line protocol
-  shelly metrics influxdb kitchen`,
+  shelly metrics influxdb kitchen
+
+  # Push to InfluxDB continuously
+  shelly metrics push --influxdb-url http://localhost:8086 --influxdb-bucket shelly`,
 	}
 
 	cmd.AddCommand(prometheus.NewCommand(f))
 	cmd.AddCommand(jsonmetrics.NewCommand(f))
 	cmd.AddCommand(influxdb.NewCommand(f))
+	cmd.AddCommand(push.NewCommand(f))
 
 	return cmd
 }
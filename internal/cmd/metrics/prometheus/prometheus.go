@@ -6,20 +6,29 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
 	"github.com/tj-smith47/shelly-cli/internal/shelly"
 )
 
+// defaultProbeTimeout bounds a probe when Prometheus sends no scrape timeout.
+const defaultProbeTimeout = 10 * time.Second
+
+// contentType is the Prometheus text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
 // Options holds command options.
 type Options struct {
 	Factory  *cmdutil.Factory
 	Port     int
 	Devices  []string
 	Interval time.Duration
+	Probe    bool
 }
 
 // NewCommand creates the prometheus metrics command.
@@ -36,7 +45,14 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 		Long: `Start an HTTP server that exports metrics in Prometheus format.
 
 The exporter collects metrics from all registered devices (or a specified
-subset) and exposes them at /metrics for Prometheus scraping.
+subset) every --interval and exposes them at /metrics for Prometheus scraping.
+
+It also serves /probe?target=<device>, which scrapes one device on demand in
+the style of the blackbox exporter, so Prometheus drives timing and labels
+through its scrape config. The target is a device name or address; only
+registered devices are counted in the exporter metrics. With --probe the
+exporter only serves probes: nothing is polled and /metrics reports the
+exporter's own metrics.
 
 Metrics exported:
   Power metering (PM/PM1/EM/EM1 components):
@@ -53,8 +69,27 @@ Metrics exported:
   - shelly_ram_free_bytes: Free RAM
   - shelly_ram_total_bytes: Total RAM
 
+  - shelly_firmware_update_available: Update available per channel
+
   Component state:
   - shelly_switch_on: Switch state (1=on, 0=off)
+  - shelly_cover_position_percent, shelly_cover_state: Cover position and state
+  - shelly_light_on, shelly_light_brightness_percent: Lights and RGB(W)/CCT
+  - shelly_thermostat_target_celsius, shelly_thermostat_current_celsius,
+    shelly_thermostat_output: Thermostats
+  - shelly_sensor_temperature_celsius, shelly_sensor_humidity_percent,
+    shelly_sensor_illuminance_lux, shelly_flood_alarm, shelly_smoke_alarm,
+    shelly_battery_percent: Sensors
+  - shelly_input_state, shelly_input_percent, shelly_input_count_total: Inputs
+  - shelly_bthome_rssi, shelly_bthome_battery_percent,
+    shelly_bthome_sensor_value: BTHome devices and sensors
+  - shelly_virtual_value: Virtual boolean and number components
+
+  Exporter metrics:
+  - shelly_exporter_scrape_duration_seconds: Duration of the last scrape
+  - shelly_exporter_scrapes_total, shelly_exporter_scrape_errors_total
+  - shelly_exporter_circuit_state: Circuit breaker state per device address
+  - probe_success, probe_duration_seconds: On /probe only
 
 Labels include: device, component, component_id, phase`,
 		Example: `  # Start exporter on default port 9090
@@ -64,7 +99,10 @@ Labels include: device, component, component_id, phase`,
   shelly metrics prometheus --port 8080 --devices kitchen,living-room
 
   # Collect metrics every 30 seconds
-  shelly metrics prometheus --interval 30s`,
+  shelly metrics prometheus --interval 30s
+
+  # Serve probes only; scrape /probe?target=kitchen from Prometheus
+  shelly metrics prometheus --probe`,
 		Aliases: []string{"prom"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return run(cmd.Context(), opts)
@@ -74,6 +112,7 @@ Labels include: device, component, component_id, phase`,
 	cmd.Flags().IntVar(&opts.Port, "port", opts.Port, "HTTP port for the exporter")
 	cmd.Flags().StringSliceVar(&opts.Devices, "devices", nil, "Devices to include (default: all registered)")
 	cmd.Flags().DurationVar(&opts.Interval, "interval", opts.Interval, "Metrics collection interval")
+	cmd.Flags().BoolVar(&opts.Probe, "probe", false, "Only serve /probe; do not poll devices")
 
 	return cmd
 }
@@ -81,72 +120,64 @@ Labels include: device, component, component_id, phase`,
 func run(ctx context.Context, opts *Options) error {
 	ios := opts.Factory.IOStreams()
 	svc := opts.Factory.ShellyService()
-	cfg, err := opts.Factory.Config()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
 
-	// Get device list
-	devices := opts.Devices
-	if len(devices) == 0 {
-		for name := range cfg.Devices {
-			devices = append(devices, name)
+	var devices []string
+	if !opts.Probe {
+		cfg, err := opts.Factory.Config()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
 		}
-	}
 
-	if len(devices) == 0 {
-		ios.Warning("No devices found. Register devices using 'shelly device add' or specify --devices")
-		return nil
-	}
+		// Get device list
+		devices = opts.Devices
+		if len(devices) == 0 {
+			for name := range cfg.Devices {
+				devices = append(devices, name)
+			}
+		}
 
-	sort.Strings(devices)
+		if len(devices) == 0 {
+			ios.Warning("No devices found. Register devices using 'shelly device add' or specify --devices")
+			return nil
+		}
+
+		sort.Strings(devices)
+	}
 
 	collector := shelly.NewPrometheusCollector(svc, devices)
 
-	// Initial collection
-	collector.Collect(ctx)
+	if !opts.Probe {
+		// Initial collection
+		collector.Collect(ctx)
 
-	// Start background collection
-	go func() {
-		ticker := time.NewTicker(opts.Interval)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				collector.Collect(ctx)
+		// Start background collection
+		go func() {
+			ticker := time.NewTicker(opts.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					collector.Collect(ctx)
+				}
 			}
-		}
-	}()
-
-	// HTTP server
-	mux := http.NewServeMux()
-	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
-		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
-		if _, writeErr := w.Write([]byte(collector.FormatMetrics())); writeErr != nil {
-			ios.DebugErr("writing metrics response", writeErr)
-		}
-	})
-	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
-		w.Header().Set("Content-Type", "text/html")
-		if _, writeErr := fmt.Fprintf(w, `<html><body>
-<h1>Shelly Metrics Exporter</h1>
-<p>Devices: %d</p>
-<p><a href="/metrics">Metrics</a></p>
-</body></html>`, len(devices)); writeErr != nil {
-			ios.DebugErr("writing index page", writeErr)
-		}
-	})
+		}()
+	}
 
 	server := &http.Server{
 		Addr:              fmt.Sprintf(":%d", opts.Port),
-		Handler:           mux,
+		Handler:           newHandler(ios, collector, opts.Probe, len(devices)),
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
-	ios.Printf("Starting Prometheus exporter on http://localhost:%d/metrics\n", opts.Port)
-	ios.Printf("Monitoring %d devices with %s collection interval\n", len(devices), opts.Interval)
+	if opts.Probe {
+		ios.Printf("Starting Prometheus exporter on http://localhost:%d/probe\n", opts.Port)
+		ios.Printf("Probe mode: devices are scraped on request via /probe?target=<device>\n")
+	} else {
+		ios.Printf("Starting Prometheus exporter on http://localhost:%d/metrics\n", opts.Port)
+		ios.Printf("Monitoring %d devices with %s collection interval\n", len(devices), opts.Interval)
+	}
 	ios.Printf("Press Ctrl+C to stop\n")
 
 	// Handle shutdown
@@ -167,3 +198,65 @@ func run(ctx context.Context, opts *Options) error {
 
 	return nil
 }
+
+// newHandler builds the exporter's HTTP routes. In probe mode /metrics
+// serves only the exporter self-metrics.
+func newHandler(ios *iostreams.IOStreams, collector *shelly.PrometheusCollector, probeOnly bool, deviceCount int) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		body := collector.FormatMetrics()
+		if probeOnly {
+			body = collector.FormatSelfMetrics()
+		}
+		w.Header().Set("Content-Type", contentType)
+		if _, writeErr := w.Write([]byte(body)); writeErr != nil {
+			ios.DebugErr("writing metrics response", writeErr)
+		}
+	})
+	mux.HandleFunc("/probe", func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("target")
+		if target == "" {
+			http.Error(w, "target parameter is missing", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), probeTimeout(r))
+		defer cancel()
+
+		w.Header().Set("Content-Type", contentType)
+		if _, writeErr := w.Write([]byte(collector.Probe(ctx, target))); writeErr != nil {
+			ios.DebugErr("writing probe response", writeErr)
+		}
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		devices := strconv.Itoa(deviceCount)
+		if probeOnly {
+			devices = "probe mode"
+		}
+		w.Header().Set("Content-Type", "text/html")
+		if _, writeErr := fmt.Fprintf(w, `<html><body>
+<h1>Shelly Metrics Exporter</h1>
+<p>Devices: %s</p>
+<p><a href="/metrics">Metrics</a></p>
+<form action="/probe"><input name="target" placeholder="device"> <input type="submit" value="Probe"></form>
+</body></html>`, devices); writeErr != nil {
+			ios.DebugErr("writing index page", writeErr)
+		}
+	})
+	return mux
+}
+
+// probeTimeout honors the scrape timeout Prometheus sends with each request,
+// leaving a little headroom to write the response.
+func probeTimeout(r *http.Request) time.Duration {
+	header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil || seconds <= 0 {
+		return defaultProbeTimeout
+	}
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout > time.Second {
+		timeout -= 500 * time.Millisecond
+	}
+	return timeout
+}
@@ -3,19 +3,26 @@ package prometheus
 import (
 	"bytes"
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
 	"github.com/tj-smith47/shelly-cli/internal/config"
 	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/mock"
 	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
 )
 
 const (
 	testAddrKitchen = "192.168.1.100"
 	testAddrLiving  = "192.168.1.101"
 	testDevKitchen  = "kitchen"
+	testDevProbe    = "probe-target"
 )
 
 func TestNewCommand(t *testing.T) {
@@ -67,6 +74,7 @@ func TestNewCommand_Flags(t *testing.T) {
 		{name: "port", shorthand: "", defValue: "9090"},
 		{name: "devices", shorthand: "", defValue: "[]"},
 		{name: "interval", shorthand: "", defValue: "15s"},
+		{name: "probe", shorthand: "", defValue: "false"},
 	}
 
 	for _, tt := range tests {
@@ -98,6 +106,7 @@ func TestNewCommand_FlagUsage(t *testing.T) {
 		{name: "port", expectedUsage: "HTTP port for the exporter"},
 		{name: "devices", expectedUsage: "Devices to include (default: all registered)"},
 		{name: "interval", expectedUsage: "Metrics collection interval"},
+		{name: "probe", expectedUsage: "Only serve /probe; do not poll devices"},
 	}
 
 	for _, tt := range tests {
@@ -444,3 +453,127 @@ func TestRun_DevicesParsing(t *testing.T) {
 		t.Errorf("devices = %q, want %q", flag.Value.String(), expected)
 	}
 }
+
+func TestRun_ProbeModeNeedsNoDevices(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	ios := iostreams.Test(&bytes.Buffer{}, &stdout, &stderr)
+	f := cmdutil.NewFactory().
+		SetIOStreams(ios).
+		SetConfigManager(config.NewTestManager(&config.Config{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := run(ctx, &Options{Factory: f, Port: 0, Probe: true, Interval: time.Second}); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if strings.Contains(stderr.String(), "No devices found") {
+		t.Errorf("probe mode should not require devices, got stderr: %q", stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Probe mode") {
+		t.Errorf("Expected probe mode message, got: %q", stdout.String())
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", defaultProbeTimeout},
+		{"invalid", defaultProbeTimeout},
+		{"10", 9500 * time.Millisecond},
+		{"0.5", 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/probe?target=x", http.NoBody)
+		if tt.header != "" {
+			r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", tt.header)
+		}
+		if got := probeTimeout(r); got != tt.want {
+			t.Errorf("probeTimeout(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+//nolint:paralleltest // uses global mock config manager
+func TestHandler_Probe(t *testing.T) {
+	fixtures := &mock.Fixtures{
+		Version: "1",
+		Config: mock.ConfigFixture{
+			Devices: []mock.DeviceFixture{
+				{
+					Name:       testDevProbe,
+					Address:    "192.168.1.150",
+					MAC:        "AA:BB:CC:DD:EE:FF",
+					Type:       "SNSW-001P16EU",
+					Model:      "Shelly Plus 1PM",
+					Generation: 2,
+				},
+			},
+		},
+		DeviceStates: map[string]mock.DeviceState{
+			testDevProbe: {"switch:0": map[string]any{"output": true}},
+		},
+	}
+
+	demo, err := mock.StartWithFixtures(fixtures)
+	if err != nil {
+		t.Fatalf("StartWithFixtures: %v", err)
+	}
+	defer demo.Cleanup()
+
+	tf := factory.NewTestFactory(t)
+	demo.InjectIntoFactory(tf.Factory)
+
+	collector := shelly.NewPrometheusCollector(tf.Factory.ShellyService(), nil)
+	handler := newHandler(tf.Factory.IOStreams(), collector, true, 0)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/probe?target="+testDevProbe, http.NoBody))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/probe status = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"probe_success 1", "probe_duration_seconds", `shelly_switch_on{component="switch:0",device="probe-target"} 1`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/probe missing %q:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/probe", http.NoBody))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("/probe without target status = %d, want 400", rec.Code)
+	}
+
+	// In probe mode /metrics only reports the exporter's own metrics
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+	body = rec.Body.String()
+	if !strings.Contains(body, `shelly_exporter_scrapes_total{device="probe-target"} 1`) {
+		t.Errorf("/metrics missing scrape count:\n%s", body)
+	}
+	if strings.Contains(body, "shelly_switch_on") {
+		t.Errorf("/metrics in probe mode should not include device metrics:\n%s", body)
+	}
+
+	// Ad-hoc targets are probed but not tracked in the self-metrics
+	req := httptest.NewRequest(http.MethodGet, "/probe?target=127.0.0.1:1", http.NoBody)
+	req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", "0.5")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), "probe_success 0") {
+		t.Errorf("/probe of unreachable target missing probe_success 0:\n%s", rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+	if strings.Contains(rec.Body.String(), "127.0.0.1:1") {
+		t.Errorf("/metrics should not track unregistered probe targets:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,245 @@
+// Package push provides the metrics push command.
+package push
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/export"
+)
+
+// tokenEnv is read when --influxdb-token is not given, as the influx CLI does.
+const tokenEnv = "INFLUX_TOKEN"
+
+// Options holds command options.
+type Options struct {
+	Factory  *cmdutil.Factory
+	Devices  []string
+	Interval time.Duration
+	Once     bool
+
+	InfluxURL    string
+	InfluxOrg    string
+	InfluxBucket string
+	InfluxToken  string
+	Measurement  string
+	Tags         []string
+
+	OTLPEndpoint string
+	OTLPHeaders  []string
+
+	BatchSize  int
+	MaxRetries int
+}
+
+// NewCommand creates the metrics push command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{
+		Factory:     f,
+		Interval:    15 * time.Second,
+		Measurement: "shelly",
+		BatchSize:   export.DefaultPushBatchSize,
+		MaxRetries:  export.DefaultPushMaxRetries,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "push",
+		Aliases: []string{"write", "ship"},
+		Short:   "Push metrics to InfluxDB or an OTLP collector",
+		Long: `Collect device metrics every --interval and write them directly to
+InfluxDB, an OpenTelemetry collector, or both.
+
+Devices are collected once per interval and the same samples feed every
+destination, with the metrics of "shelly metrics prometheus", "json" and
+"influxdb".
+
+InfluxDB: points are written to the v2 write API (/api/v2/write) with
+--influxdb-org, --influxdb-bucket and a token from --influxdb-token or the
+INFLUX_TOKEN environment variable. InfluxDB 3 serves the same API; use the
+database name as the bucket. Points are sent in batches of --batch-size.
+Each device yields one point per meter reading in --measurement and one
+<measurement>_device point with online state, WiFi RSSI, uptime, RAM and
+temperature.
+
+OTLP: metrics are exported over OTLP/HTTP using the JSON encoding to
+<endpoint>/v1/metrics. Counters become cumulative sums, everything else a
+gauge, with the Prometheus labels as attributes. Use --otlp-header for
+authentication headers.
+
+Requests failing with a network error, 429 or 5xx are retried up to
+--max-retries times with exponential backoff. A destination that still
+fails is reported and retried on the next interval.`,
+		Example: `  # Push to InfluxDB 2 every 15 seconds
+  export INFLUX_TOKEN=my-token
+  shelly metrics push --influxdb-url http://localhost:8086 \
+    --influxdb-org home --influxdb-bucket shelly
+
+  # Push to an OpenTelemetry collector
+  shelly metrics push --otlp-endpoint http://localhost:4318
+
+  # Both, for specific devices, every minute
+  shelly metrics push --devices kitchen,garage --interval 1m \
+    --influxdb-url http://influx:8086 --influxdb-bucket shelly \
+    --otlp-endpoint https://otlp.example.com --otlp-header "Authorization=Bearer abc"
+
+  # Push once and exit (for cron)
+  shelly metrics push --once --influxdb-url http://localhost:8086 --influxdb-bucket shelly`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&opts.Devices, "devices", nil, "Devices to include (default: all registered)")
+	cmd.Flags().DurationVarP(&opts.Interval, "interval", "i", opts.Interval, "Collection interval")
+	cmd.Flags().BoolVar(&opts.Once, "once", false, "Push once and exit (for cron/scheduled tasks)")
+	cmd.Flags().StringVar(&opts.InfluxURL, "influxdb-url", "", "InfluxDB server URL")
+	cmd.Flags().StringVar(&opts.InfluxOrg, "influxdb-org", "", "InfluxDB organization")
+	cmd.Flags().StringVar(&opts.InfluxBucket, "influxdb-bucket", "", "InfluxDB bucket (database on InfluxDB 3)")
+	cmd.Flags().StringVar(&opts.InfluxToken, "influxdb-token", "", "InfluxDB API token (default: $INFLUX_TOKEN)")
+	cmd.Flags().StringVarP(&opts.Measurement, "measurement", "m", opts.Measurement, "InfluxDB measurement name")
+	cmd.Flags().StringSliceVarP(&opts.Tags, "tags", "t", nil, "Additional InfluxDB tags (key=value)")
+	cmd.Flags().StringVar(&opts.OTLPEndpoint, "otlp-endpoint", "", "OTLP/HTTP collector URL")
+	cmd.Flags().StringSliceVar(&opts.OTLPHeaders, "otlp-header", nil, "OTLP request header (key=value)")
+	cmd.Flags().IntVar(&opts.BatchSize, "batch-size", opts.BatchSize, "Maximum InfluxDB points per request")
+	cmd.Flags().IntVar(&opts.MaxRetries, "max-retries", opts.MaxRetries, "Retries per request on network errors, 429 and 5xx")
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	if opts.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	writers, err := buildWriters(opts)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := opts.Factory.Config()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	devices := opts.Devices
+	if len(devices) == 0 {
+		for name := range cfg.Devices {
+			devices = append(devices, name)
+		}
+	}
+	if len(devices) == 0 {
+		ios.Warning("No devices found. Register devices using 'shelly device add' or specify --devices")
+		return nil
+	}
+	sort.Strings(devices)
+
+	push := func() error {
+		samples := svc.CollectSamples(ctx, devices)
+		var firstErr error
+		for _, w := range writers {
+			if err := w.WriteSamples(ctx, samples); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				ios.Warning("Push to %s failed: %v", w.Name(), err)
+				if firstErr == nil {
+					firstErr = fmt.Errorf("push to %s: %w", w.Name(), err)
+				}
+			}
+		}
+		return firstErr
+	}
+
+	if opts.Once {
+		if err := push(); err != nil {
+			return err
+		}
+		ios.Success("Pushed metrics for %d device(s)", len(devices))
+		return nil
+	}
+
+	ios.Success("Metrics push started")
+	ios.Printf("  Pushing %d device(s) every %s to %s\n", len(devices), opts.Interval, writerNames(writers))
+	ios.Printf("  Press Ctrl+C to stop\n")
+	ios.Println("")
+
+	//nolint:errcheck // failures are reported as warnings; the next interval retries
+	push()
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			ios.Println("")
+			ios.Info("Metrics push stopped")
+			return nil
+		case <-ticker.C:
+			//nolint:errcheck // failures are reported as warnings; the next interval retries
+			push()
+		}
+	}
+}
+
+// buildWriters creates a writer per configured destination.
+func buildWriters(opts *Options) ([]export.SampleWriter, error) {
+	retry := export.DefaultRetryPolicy
+	retry.MaxRetries = opts.MaxRetries
+
+	var writers []export.SampleWriter
+	if opts.InfluxURL != "" {
+		token := opts.InfluxToken
+		if token == "" {
+			token = os.Getenv(tokenEnv)
+		}
+		w, err := export.NewInfluxDBWriter(export.InfluxDBWriterConfig{
+			URL:         opts.InfluxURL,
+			Org:         opts.InfluxOrg,
+			Bucket:      opts.InfluxBucket,
+			Token:       token,
+			Measurement: opts.Measurement,
+			Tags:        export.ParseTags(opts.Tags),
+			BatchSize:   opts.BatchSize,
+			Retry:       retry,
+		})
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, w)
+	}
+	if opts.OTLPEndpoint != "" {
+		w, err := export.NewOTLPWriter(export.OTLPWriterConfig{
+			Endpoint: opts.OTLPEndpoint,
+			Headers:  export.ParseTags(opts.OTLPHeaders),
+			Retry:    retry,
+		})
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, w)
+	}
+	if len(writers) == 0 {
+		return nil, fmt.Errorf("no destination: set --influxdb-url or --otlp-endpoint")
+	}
+	return writers, nil
+}
+
+// writerNames lists the writer names for the startup banner.
+func writerNames(writers []export.SampleWriter) string {
+	names := make([]string, len(writers))
+	for i, w := range writers {
+		names[i] = w.Name()
+	}
+	return strings.Join(names, ", ")
+}
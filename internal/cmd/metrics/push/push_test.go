@@ -0,0 +1,155 @@
+package push
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/mock"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand_Flags(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	tests := []struct {
+		name     string
+		defValue string
+	}{
+		{"devices", "[]"},
+		{"interval", "15s"},
+		{"once", "false"},
+		{"influxdb-url", ""},
+		{"influxdb-org", ""},
+		{"influxdb-bucket", ""},
+		{"influxdb-token", ""},
+		{"measurement", "shelly"},
+		{"tags", "[]"},
+		{"otlp-endpoint", ""},
+		{"otlp-header", "[]"},
+		{"batch-size", "5000"},
+		{"max-retries", "3"},
+	}
+	for _, tt := range tests {
+		flag := cmd.Flags().Lookup(tt.name)
+		if flag == nil {
+			t.Errorf("flag %q not found", tt.name)
+			continue
+		}
+		if flag.DefValue != tt.defValue {
+			t.Errorf("%s default = %q, want %q", tt.name, flag.DefValue, tt.defValue)
+		}
+	}
+}
+
+func TestRun_NoDestination(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	err := run(context.Background(), &Options{Factory: tf.Factory, Interval: time.Second})
+	if err == nil || !strings.Contains(err.Error(), "no destination") {
+		t.Errorf("run() error = %v, want no destination", err)
+	}
+}
+
+func TestRun_InfluxDBMissingBucket(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	err := run(context.Background(), &Options{Factory: tf.Factory, Interval: time.Second, InfluxURL: "http://localhost:8086"})
+	if err == nil || !strings.Contains(err.Error(), "bucket") {
+		t.Errorf("run() error = %v, want bucket error", err)
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetDefaultManager via demo.InjectIntoFactory
+func TestRun_OnceWritesBothDestinations(t *testing.T) {
+	fixtures := &mock.Fixtures{
+		Version: "1",
+		Config: mock.ConfigFixture{
+			Devices: []mock.DeviceFixture{
+				{Name: "plug", Address: "192.168.1.100", MAC: "AA:BB:CC:DD:EE:01", Type: "SNPL-00112EU", Model: "Shelly Plus Plug S", Generation: 2},
+			},
+		},
+		DeviceStates: map[string]mock.DeviceState{
+			"plug": {"switch:0": map[string]any{"output": true, "apower": 42.5}},
+		},
+	}
+	demo, err := mock.StartWithFixtures(fixtures)
+	if err != nil {
+		t.Fatalf("StartWithFixtures: %v", err)
+	}
+	defer demo.Cleanup()
+
+	var mu sync.Mutex
+	received := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body) //nolint:errcheck // test server
+		mu.Lock()
+		received[r.URL.Path] = string(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	tf := factory.NewTestFactory(t)
+	demo.InjectIntoFactory(tf.Factory)
+
+	opts := &Options{
+		Factory:      tf.Factory,
+		Interval:     time.Minute,
+		Once:         true,
+		InfluxURL:    srv.URL,
+		InfluxBucket: "shelly",
+		Measurement:  "shelly",
+		OTLPEndpoint: srv.URL,
+	}
+	if err := run(context.Background(), opts); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if got := received["/api/v2/write"]; !strings.Contains(got, "shelly_device,device=plug") {
+		t.Errorf("influxdb body = %q", got)
+	}
+	if got := received["/v1/metrics"]; !strings.Contains(got, "shelly_device_online") {
+		t.Errorf("otlp body = %q", got)
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetDefaultManager via demo.InjectIntoFactory
+func TestRun_OnceReportsFailure(t *testing.T) {
+	fixtures := &mock.Fixtures{
+		Version: "1",
+		Config: mock.ConfigFixture{
+			Devices: []mock.DeviceFixture{
+				{Name: "plug", Address: "192.168.1.100", MAC: "AA:BB:CC:DD:EE:01", Type: "SNPL-00112EU", Model: "Shelly Plus Plug S", Generation: 2},
+			},
+		},
+	}
+	demo, err := mock.StartWithFixtures(fixtures)
+	if err != nil {
+		t.Fatalf("StartWithFixtures: %v", err)
+	}
+	defer demo.Cleanup()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	tf := factory.NewTestFactory(t)
+	demo.InjectIntoFactory(tf.Factory)
+
+	opts := &Options{Factory: tf.Factory, Interval: time.Minute, Once: true, InfluxURL: srv.URL, InfluxBucket: "shelly"}
+	if err := run(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("run() error = %v, want 401", err)
+	}
+}
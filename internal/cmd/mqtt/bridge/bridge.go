@@ -0,0 +1,170 @@
+// Package bridge provides the mqtt bridge subcommand.
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/mqttbridge"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/mqttclient"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory   *cmdutil.Factory
+	All       bool
+	BaseTopic string
+	Broker    string
+	ClientID  string
+	Devices   []string
+	Password  string
+	QoS       int
+	ReadOnly  bool
+	Username  string
+}
+
+// NewCommand creates the mqtt bridge command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "bridge [device...]",
+		Aliases: []string{"br"},
+		Short:   "Bridge devices to an MQTT broker",
+		Long: `Bridge devices to an MQTT broker without enabling MQTT on them.
+
+Device state is streamed over WebSocket (Gen2+) and CoIoT (Gen1, with HTTP
+polling as the fallback) and published under one topic scheme:
+
+  <base>/bridge/state                      online | offline (retained)
+  <base>/<device>/online                   true | false (retained)
+  <base>/<device>/<type>/<id>              component status JSON (retained)
+  <base>/<device>/<type>/<id>/event        input events, e.g. single_push
+
+Gen1 relays, rollers, lights and inputs appear as switch, cover, light and
+input components. Components are only republished when their status changes.
+
+Commands are accepted on:
+
+  <base>/<device>/<type>/<id>/command      on | off | toggle (switch, light, rgb, rgbw)
+                                           open | close | stop (cover)
+  <base>/<device>/<type>/<id>/set          {"on":true,"brightness":50,"rgb":[255,0,0],"white":0}
+  <base>/<device>/cover/<id>/position      0-100
+
+Use --read-only to publish state without accepting commands. The bridge runs
+until interrupted.`,
+		Example: `  # Bridge two devices
+  shelly mqtt bridge kitchen porch --broker tcp://broker:1883
+
+  # Bridge all registered devices under home/shelly
+  shelly mqtt bridge --all --broker tcp://broker:1883 --base-topic home/shelly
+
+  # Publish state only
+  shelly mqtt bridge --all --broker tcp://broker:1883 --read-only
+
+  # Turn a bridged switch on
+  mosquitto_pub -t shelly/kitchen/switch/0/command -m on`,
+		ValidArgsFunction: completion.DeviceNames(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Devices = args
+			if opts.All {
+				registered := config.ListDevices()
+				if len(registered) == 0 {
+					opts.Factory.IOStreams().Warning("No devices registered. Run 'shelly discover mdns --register' first.")
+					return nil
+				}
+				opts.Devices = make([]string, 0, len(registered))
+				for name := range registered {
+					opts.Devices = append(opts.Devices, name)
+				}
+				sort.Strings(opts.Devices)
+			} else if len(args) == 0 {
+				return fmt.Errorf("specify device(s) or use --all")
+			}
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Bridge all registered devices")
+	cmd.Flags().StringVar(&opts.Broker, "broker", "", "MQTT broker URL (e.g., tcp://broker:1883)")
+	cmd.Flags().StringVar(&opts.Username, "username", "", "MQTT username")
+	cmd.Flags().StringVar(&opts.Password, "password", "", "MQTT password")
+	cmd.Flags().StringVar(&opts.ClientID, "client-id", "", "MQTT client ID (default: generated)")
+	cmd.Flags().IntVar(&opts.QoS, "qos", 0, "MQTT QoS for published and subscribed topics (0-2)")
+	cmd.Flags().StringVar(&opts.BaseTopic, "base-topic", mqttbridge.DefaultBaseTopic, "Topic prefix")
+	cmd.Flags().BoolVar(&opts.ReadOnly, "read-only", false, "Publish state without accepting commands")
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	if opts.Broker == "" {
+		return fmt.Errorf("--broker is required")
+	}
+	if opts.QoS < 0 || opts.QoS > 2 {
+		return fmt.Errorf("--qos must be 0, 1 or 2")
+	}
+
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	for _, name := range opts.Devices {
+		if _, ok := config.GetDevice(name); !ok {
+			return fmt.Errorf("device %q is not registered", name)
+		}
+	}
+
+	var client *mqttbridge.Client
+	err := cmdutil.RunWithSpinner(ctx, ios, "Connecting to broker...", func(ctx context.Context) error {
+		var connErr error
+		client, connErr = mqttbridge.Connect(ctx, mqttclient.BrokerConfig{
+			Broker:   opts.Broker,
+			Username: opts.Username,
+			Password: opts.Password,
+			ClientID: opts.ClientID,
+			QoS:      byte(opts.QoS),
+		}, mqttbridge.StateTopic(opts.BaseTopic))
+		return connErr
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	bridge := mqttbridge.New(opts.BaseTopic, opts.Devices, client)
+
+	if !opts.ReadOnly {
+		report := func(cmd mqttbridge.Command, err error) {
+			switch {
+			case err != nil && cmd.Device == "":
+				ios.Warning("Ignored command: %v", err)
+			case err != nil:
+				ios.Error("%s %s:%d %s failed: %v", cmd.Device, cmd.Component, cmd.ID, cmd.Action, err)
+			default:
+				ios.Printf("%s %s:%d %s\n", cmd.Device, cmd.Component, cmd.ID, cmd.Action)
+			}
+		}
+		if err := bridge.ServeCommands(ctx, client, svc.BridgeExecutor(), report); err != nil {
+			return fmt.Errorf("subscribe to command topics: %w", err)
+		}
+	}
+
+	ios.Success("MQTT bridge started")
+	ios.Printf("  Bridging %d device(s) to %s under %s/\n", len(opts.Devices), opts.Broker, opts.BaseTopic)
+	if opts.ReadOnly {
+		ios.Printf("  Read-only: commands are not accepted\n")
+	}
+	ios.Printf("  Press Ctrl+C to stop\n")
+	ios.Println("")
+
+	svc.RunMQTTBridge(ctx, opts.Devices, bridge)
+
+	ios.Println("")
+	ios.Info("MQTT bridge stopped")
+	return nil
+}
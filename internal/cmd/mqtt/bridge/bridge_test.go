@@ -0,0 +1,89 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd == nil {
+		t.Fatal("NewCommand returned nil")
+	}
+
+	if cmd.Use != "bridge [device...]" {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+
+	if cmd.Short == "" {
+		t.Error("Short description is empty")
+	}
+}
+
+func TestNewCommand_Flags(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	tests := []struct {
+		name     string
+		defValue string
+	}{
+		{"all", "false"},
+		{"broker", ""},
+		{"username", ""},
+		{"password", ""},
+		{"client-id", ""},
+		{"qos", "0"},
+		{"base-topic", "shelly"},
+		{"read-only", "false"},
+	}
+	for _, tt := range tests {
+		flag := cmd.Flags().Lookup(tt.name)
+		if flag == nil {
+			t.Errorf("flag --%s not found", tt.name)
+			continue
+		}
+		if flag.DefValue != tt.defValue {
+			t.Errorf("--%s default = %q, want %q", tt.name, flag.DefValue, tt.defValue)
+		}
+	}
+}
+
+func TestExecute_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"no devices", []string{"--broker", "tcp://localhost:1883"}, "specify device"},
+		{"no broker", []string{"kitchen"}, "--broker"},
+		{"bad qos", []string{"kitchen", "--broker", "tcp://localhost:1883", "--qos", "3"}, "--qos"},
+		{"unregistered", []string{"nowhere", "--broker", "tcp://localhost:1883"}, "not registered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tf := factory.NewTestFactory(t)
+
+			cmd := NewCommand(tf.Factory)
+			cmd.SetContext(context.Background())
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Execute() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// Package hadiscovery provides the mqtt ha-discovery subcommand.
+package hadiscovery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/hadiscovery"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/mqttclient"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory  *cmdutil.Factory
+	All      bool
+	Broker   string
+	ClientID string
+	Devices  []string
+	DryRun   bool
+	Password string
+	Prefix   string
+	QoS      int
+	Remove   bool
+	Username string
+}
+
+// NewCommand creates the mqtt ha-discovery command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "ha-discovery [device...]",
+		Aliases: []string{"ha", "homeassistant"},
+		Short:   "Publish Home Assistant MQTT discovery configs",
+		Long: `Publish Home Assistant MQTT discovery configs for devices.
+
+Each device's components are inspected and a retained config message is
+published to <prefix>/<type>/<id>/config for every entity found:
+  - switch, cover, light, rgb and rgbw outputs
+  - inputs (binary sensors), temperature, humidity, illuminance, battery
+  - power, voltage, current and energy from switches, PM, EM and EM1 meters
+  - virtual components (boolean, number, text, enum, button) on Gen2+
+
+Entities use the device's native MQTT topics, so the device must publish
+to the same broker (see 'shelly mqtt set'). Gen2+ devices also need status
+notifications (status_ntf) turned on for entities to receive state. Gen1
+devices use the shellies/<id>/... topics.
+
+Use --remove to publish empty retained payloads that delete the entities,
+and --dry-run to print the messages without connecting to a broker.`,
+		Example: `  # Publish discovery for a device
+  shelly mqtt ha-discovery kitchen --broker tcp://broker:1883
+
+  # All registered devices, with broker credentials
+  shelly mqtt ha-discovery --all --broker tcp://broker:1883 --username ha --password secret
+
+  # Preview the messages
+  shelly mqtt ha-discovery kitchen --dry-run
+
+  # Remove the entities again
+  shelly mqtt ha-discovery kitchen --broker tcp://broker:1883 --remove`,
+		ValidArgsFunction: completion.DeviceNames(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Devices = args
+			if opts.All {
+				registered := config.ListDevices()
+				if len(registered) == 0 {
+					opts.Factory.IOStreams().Warning("No devices registered. Run 'shelly discover mdns --register' first.")
+					return nil
+				}
+				opts.Devices = make([]string, 0, len(registered))
+				for name := range registered {
+					opts.Devices = append(opts.Devices, name)
+				}
+				sort.Strings(opts.Devices)
+			} else if len(args) == 0 {
+				return fmt.Errorf("specify device(s) or use --all")
+			}
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Publish for all registered devices")
+	cmd.Flags().StringVar(&opts.Broker, "broker", "", "MQTT broker URL (e.g., tcp://broker:1883)")
+	cmd.Flags().StringVar(&opts.Username, "username", "", "MQTT username")
+	cmd.Flags().StringVar(&opts.Password, "password", "", "MQTT password")
+	cmd.Flags().StringVar(&opts.ClientID, "client-id", "", "MQTT client ID (default: generated)")
+	cmd.Flags().IntVar(&opts.QoS, "qos", 1, "MQTT QoS for discovery messages (0-2)")
+	cmd.Flags().StringVar(&opts.Prefix, "discovery-prefix", hadiscovery.DefaultPrefix, "Home Assistant discovery prefix")
+	cmd.Flags().BoolVar(&opts.Remove, "remove", false, "Remove the entities instead of publishing them")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Print messages instead of publishing")
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	if !opts.DryRun && opts.Broker == "" {
+		return fmt.Errorf("--broker is required (or use --dry-run)")
+	}
+	if opts.QoS < 0 || opts.QoS > 2 {
+		return fmt.Errorf("--qos must be 0, 1 or 2")
+	}
+
+	ctx, cancel := opts.Factory.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	var devices []hadiscovery.Device
+	var errs map[string]error
+	err := cmdutil.RunWithSpinner(ctx, ios, "Inspecting devices...", func(ctx context.Context) error {
+		devices, errs = svc.CollectHADiscoveryDevices(ctx, opts.Devices)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range opts.Devices {
+		if devErr, ok := errs[name]; ok {
+			ios.Warning("Skipping %s: %v", name, devErr)
+		}
+	}
+	if len(devices) == 0 {
+		return fmt.Errorf("no devices could be inspected")
+	}
+
+	var msgs []hadiscovery.Message
+	for _, dev := range devices {
+		devMsgs, err := hadiscovery.Messages(dev, opts.Prefix, opts.Remove)
+		if err != nil {
+			return fmt.Errorf("%s: %w", dev.Name, err)
+		}
+		if len(devMsgs) == 0 {
+			ios.Warning("No supported components found on %s", dev.Name)
+			continue
+		}
+		if !opts.Remove {
+			for _, w := range dev.Warnings() {
+				ios.Warning("%s: %s", dev.Name, w)
+			}
+		}
+		msgs = append(msgs, devMsgs...)
+	}
+
+	if opts.DryRun {
+		for _, msg := range msgs {
+			ios.Printf("%s %s\n", msg.Topic, msg.Payload)
+		}
+		return nil
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	broker := mqttclient.BrokerConfig{
+		Broker:   opts.Broker,
+		Username: opts.Username,
+		Password: opts.Password,
+		ClientID: opts.ClientID,
+		QoS:      byte(opts.QoS),
+	}
+	err = cmdutil.RunWithSpinner(ctx, ios, "Publishing discovery configs...", func(ctx context.Context) error {
+		return hadiscovery.Publish(ctx, broker, msgs)
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.Remove {
+		ios.Success("Removed %d entity config(s) for %d device(s)", len(msgs), len(devices))
+	} else {
+		ios.Success("Published %d entity config(s) for %d device(s)", len(msgs), len(devices))
+	}
+	return nil
+}
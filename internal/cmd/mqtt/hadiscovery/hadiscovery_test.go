@@ -0,0 +1,144 @@
+package hadiscovery
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/mock"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd == nil {
+		t.Fatal("NewCommand returned nil")
+	}
+
+	if cmd.Use != "ha-discovery [device...]" {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+
+	if cmd.Short == "" {
+		t.Error("Short description is empty")
+	}
+}
+
+func TestNewCommand_Flags(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	tests := []struct {
+		name     string
+		defValue string
+	}{
+		{"all", "false"},
+		{"broker", ""},
+		{"username", ""},
+		{"password", ""},
+		{"client-id", ""},
+		{"qos", "1"},
+		{"discovery-prefix", "homeassistant"},
+		{"remove", "false"},
+		{"dry-run", "false"},
+	}
+	for _, tt := range tests {
+		flag := cmd.Flags().Lookup(tt.name)
+		if flag == nil {
+			t.Errorf("flag --%s not found", tt.name)
+			continue
+		}
+		if flag.DefValue != tt.defValue {
+			t.Errorf("--%s default = %q, want %q", tt.name, flag.DefValue, tt.defValue)
+		}
+	}
+}
+
+func TestExecute_NoDevices(t *testing.T) {
+	t.Parallel()
+	tf := factory.NewTestFactory(t)
+
+	cmd := NewCommand(tf.Factory)
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{"--dry-run"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error without devices")
+	}
+}
+
+func TestExecute_RequiresBroker(t *testing.T) {
+	t.Parallel()
+	tf := factory.NewTestFactory(t)
+
+	cmd := NewCommand(tf.Factory)
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{"kitchen"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--broker") {
+		t.Errorf("Execute() error = %v, want --broker error", err)
+	}
+}
+
+//nolint:paralleltest // Uses global mock state
+func TestExecute_DryRun(t *testing.T) {
+	fixtures := &mock.Fixtures{
+		Version: "1",
+		Config: mock.ConfigFixture{
+			Devices: []mock.DeviceFixture{
+				{
+					Name:       "kitchen",
+					Address:    "192.168.1.100",
+					MAC:        "AA:BB:CC:DD:EE:FF",
+					Type:       "SNSW-001P16EU",
+					Model:      "Shelly Plus 1PM",
+					Generation: 2,
+				},
+			},
+		},
+		DeviceStates: map[string]mock.DeviceState{
+			"kitchen": {
+				"switch:0": map[string]any{"id": 0, "output": true, "apower": 12.5},
+			},
+		},
+	}
+
+	demo, err := mock.StartWithFixtures(fixtures)
+	if err != nil {
+		t.Fatalf("StartWithFixtures: %v", err)
+	}
+	defer demo.Cleanup()
+
+	tf := factory.NewTestFactory(t)
+	demo.InjectIntoFactory(tf.Factory)
+
+	cmd := NewCommand(tf.Factory)
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{"kitchen", "--dry-run"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	out := tf.OutString()
+	if !strings.Contains(out, "homeassistant/switch/") || !strings.Contains(out, "_switch_0/config ") {
+		t.Errorf("output missing switch config:\n%s", out)
+	}
+	if !strings.Contains(out, "_switch_0_power/config ") {
+		t.Errorf("output missing power sensor:\n%s", out)
+	}
+	if !strings.Contains(out, `"command_topic":"`) {
+		t.Errorf("output missing payload:\n%s", out)
+	}
+}
@@ -4,7 +4,9 @@ package mqtt
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/tj-smith47/shelly-cli/internal/cmd/mqtt/bridge"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/mqtt/disable"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/mqtt/hadiscovery"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/mqtt/set"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/mqtt/status"
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
@@ -27,12 +29,20 @@ like Home Assistant, OpenHAB, or custom MQTT brokers.`,
   shelly mqtt set living-room --server "mqtt://broker:1883" --user user --password pass
 
   # Disable MQTT
-  shelly mqtt disable living-room`,
+  shelly mqtt disable living-room
+
+  # Publish Home Assistant discovery configs
+  shelly mqtt ha-discovery living-room --broker tcp://broker:1883
+
+  # Bridge devices without MQTT onto a broker
+  shelly mqtt bridge --all --broker tcp://broker:1883`,
 	}
 
 	cmd.AddCommand(status.NewCommand(f))
 	cmd.AddCommand(set.NewCommand(f))
 	cmd.AddCommand(disable.NewCommand(f))
+	cmd.AddCommand(hadiscovery.NewCommand(f))
+	cmd.AddCommand(bridge.NewCommand(f))
 
 	return cmd
 }
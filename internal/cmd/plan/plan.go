@@ -0,0 +1,124 @@
+// Package plan provides the plan command for previewing declarative device state.
+package plan
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil/flags"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/gitops"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory    *cmdutil.Factory
+	Concurrent int
+	Devices    []string
+	Path       string
+	Prune      bool
+}
+
+// NewCommand creates the plan command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "plan <path>",
+		Aliases: []string{"preview"},
+		Short:   "Show changes needed to match a desired-state manifest",
+		Long: `Compare devices with a declarative manifest and show every change
+"shelly apply" would make, without changing anything.
+
+The manifest is a YAML file, or a directory of YAML files, describing per
+device or group the desired config, scripts, schedules, webhooks, KVS entries
+and virtual components. Config is partial: only the keys listed are compared.
+Other sections are managed only when present in the manifest.
+
+Schedules are matched by id when one is given, and otherwise by timespec
+and calls. A schedule whose timespec changed is updated; one whose calls
+changed without an id is created anew, and the old schedule is listed in a
+warning unless --prune deletes it.
+
+With --prune, items on a device that a managed section does not list are
+shown for deletion.
+
+Example manifest:
+
+  devices:
+    kitchen:
+      config:
+        switch:0: {name: Kitchen, auto_off: true, auto_off_delay: 600}
+      scripts:
+        - name: night-light
+          file: scripts/night-light.js
+      schedules:
+        - id: 1
+          timespec: "0 0 22 * * *"
+          calls: [{method: Switch.Set, params: {id: 0, on: false}}]
+      kvs:
+        mode: eco
+  groups:
+    office:
+      webhooks:
+        - name: door-open
+          event: input.toggle_on
+          urls: ["http://hub.local/door"]`,
+		Example: `  # Preview changes for every device in the manifest
+  shelly plan shelly.yaml
+
+  # Include deletion of unmanaged scripts, schedules, webhooks and keys
+  shelly plan ./fleet --prune
+
+  # Limit to specific devices
+  shelly plan shelly.yaml --device kitchen --device office-plug
+
+  # Machine-readable plan for CI
+  shelly plan shelly.yaml -o json`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.FileThenNoComplete(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Path = args[0]
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Prune, "prune", false, "Show deletion of items the manifest does not list")
+	cmd.Flags().StringSliceVar(&opts.Devices, "device", nil, "Limit to specific devices (default: all in manifest)")
+	flags.AddConcurrencyFlag(cmd, &opts.Concurrent)
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	desired, err := shelly.ResolveManifest(opts.Path, opts.Devices)
+	if err != nil {
+		return err
+	}
+	if len(desired) == 0 {
+		ios.Warning("No devices in manifest")
+		return nil
+	}
+
+	concurrent := cmdutil.CapConcurrency(ios, opts.Concurrent)
+	client := svc.GitOpsClient(opts.Factory.AutomationService(), opts.Factory.KVSService())
+
+	plan, err := cmdutil.RunWithSpinnerResult(ctx, ios, "Reading device state...", func(ctx context.Context) (*gitops.Plan, error) {
+		return gitops.BuildPlan(ctx, client, desired, opts.Prune, concurrent), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := cmdutil.PrintResult(ios, plan, term.DisplayGitOpsPlan); err != nil {
+		return err
+	}
+	return plan.Err()
+}
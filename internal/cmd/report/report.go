@@ -10,6 +10,7 @@ import (
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil/flags"
 	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
 	"github.com/tj-smith47/shelly-cli/internal/term"
 )
 
@@ -42,7 +43,7 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 
 Report types:
   devices  - Device inventory and status
-  energy   - Energy consumption summary
+  energy   - Energy consumption summary and today's cost (energy.tariff)
   audit    - Security audit report
 
 Output formats:
@@ -85,6 +86,11 @@ func run(ctx context.Context, opts *Options) error {
 		return nil
 	}
 
+	tariff, err := shelly.EnergyTariff(cfg.GetEnergyConfig())
+	if err != nil {
+		return fmt.Errorf("invalid energy tariff: %w", err)
+	}
+
 	var report model.DeviceReport
 	var spinnerMsg string
 
@@ -104,7 +110,7 @@ func run(ctx context.Context, opts *Options) error {
 		case reportTypeDevices:
 			report = svc.GenerateDevicesReport(ctx, cfg.Devices)
 		case "energy":
-			report = svc.GenerateEnergyReport(ctx, cfg.Devices)
+			report = svc.GenerateEnergyReport(ctx, cfg.Devices, tariff)
 		case reportTypeAudit:
 			report = svc.GenerateAuditReport(ctx, cfg.Devices)
 		}
@@ -24,6 +24,7 @@ import (
 	"github.com/tj-smith47/shelly-cli/internal/cmd/alert"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/alias"
 	apicmd "github.com/tj-smith47/shelly-cli/internal/cmd/api"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/apply"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/audit"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/auth"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/backup"
@@ -42,6 +43,7 @@ import (
 	diagramcmd "github.com/tj-smith47/shelly-cli/internal/cmd/diagram"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/discover"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/doctor"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/drift"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/energy"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/ethernet"
 	exportcmd "github.com/tj-smith47/shelly-cli/internal/cmd/export"
@@ -67,6 +69,7 @@ import (
 	"github.com/tj-smith47/shelly-cli/internal/cmd/off"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/on"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/party"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/plan"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/plugin"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/power"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/profile"
@@ -76,6 +79,7 @@ import (
 	"github.com/tj-smith47/shelly-cli/internal/cmd/report"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/rgb"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/rgbw"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/rules"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/scene"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/schedule"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/script"
@@ -364,6 +368,7 @@ func init() {
 		thermostat.NewCommand(factory),
 		batch.NewCommand(factory),
 		scene.NewCommand(factory),
+		rules.NewCommand(factory),
 	)
 
 	// Management commands - device and group management
@@ -377,6 +382,8 @@ func init() {
 		backup.NewCommand(factory),
 		migrate.NewCommand(factory),
 		sync.NewCommand(factory),
+		plan.NewCommand(factory),
+		apply.NewCommand(factory),
 		fleet.NewCommand(factory),
 	)
 
@@ -408,6 +415,7 @@ func init() {
 	cmdutil.AddCommandsToGroup(rootCmd, groupMonitoring,
 		monitor.NewCommand(factory),
 		alert.NewCommand(factory),
+		drift.NewCommand(factory),
 		energy.NewCommand(factory),
 		power.NewCommand(factory),
 		sensor.NewCommand(factory),
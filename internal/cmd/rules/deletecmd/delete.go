@@ -0,0 +1,23 @@
+// Package deletecmd provides the rules delete subcommand.
+package deletecmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil/factories"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// NewCommand creates the rules delete command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	return factories.NewConfigDeleteCommand(f, factories.ConfigDeleteOpts{
+		Resource:      "rule",
+		ValidArgsFunc: completion.RuleNames(),
+		ExistsFunc: func(name string) (any, bool) {
+			return config.GetRule(name)
+		},
+		DeleteFunc: config.DeleteRule,
+	})
+}
@@ -0,0 +1,56 @@
+package deletecmd
+
+import (
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "delete <rule>" {
+		t.Errorf("Use = %q, want \"delete <rule>\"", cmd.Use)
+	}
+	if cmd.Short == "" {
+		t.Error("Short description is empty")
+	}
+	if cmd.ValidArgsFunction == nil {
+		t.Error("ValidArgsFunction is nil")
+	}
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("expected error with no args")
+	}
+}
+
+//nolint:paralleltest // Test modifies global config state
+func TestRun_Delete(t *testing.T) {
+	config.ResetDefaultManagerForTesting()
+	t.Cleanup(config.ResetDefaultManagerForTesting)
+
+	config.SetDefaultManager(config.NewTestManager(&config.Config{
+		Rules: map[string]config.Rule{
+			"night": {Name: "night", Trigger: config.RuleTrigger{Device: "hall", Condition: "offline"}},
+		},
+	}))
+
+	tf := factory.NewTestFactory(t)
+	cmd := NewCommand(tf.Factory)
+	cmd.SetArgs([]string{"night", "--yes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if _, ok := config.GetRule("night"); ok {
+		t.Error("rule still exists after delete")
+	}
+
+	cmd = NewCommand(tf.Factory)
+	cmd.SetArgs([]string{"missing", "--yes"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for missing rule")
+	}
+}
@@ -0,0 +1,33 @@
+// Package importcmd provides the rules import subcommand.
+package importcmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil/factories"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rules"
+)
+
+// NewCommand creates the rules import command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	return factories.NewConfigImportCommand(f, factories.ConfigImportOpts{
+		Component: "rule",
+		Aliases:   []string{"load", "add"},
+		Short:     "Import rules from a YAML file",
+		Long: `Import rule definitions from a YAML file into the config.
+
+The file holds a top-level "rules" list (or a bare list of rules). Every rule
+is validated before any is saved. Use --overwrite to replace rules that
+already exist.`,
+		Example: `  # Import rules
+  shelly rules import rules.yaml
+
+  # Replace existing rules with the same names
+  shelly rules import rules.yaml --overwrite`,
+		ForceFlagName: "overwrite",
+		Importer: func(file, _ string, overwrite bool) (string, error) {
+			return rules.ImportFile(file, overwrite)
+		},
+	})
+}
@@ -0,0 +1,105 @@
+package importcmd
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "import <file>" {
+		t.Errorf("Use = %q, want \"import <file>\"", cmd.Use)
+	}
+	if cmd.Short == "" {
+		t.Error("Short description is empty")
+	}
+	if cmd.Example == "" {
+		t.Error("Example is empty")
+	}
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("expected error with no args")
+	}
+	if err := cmd.Args(cmd, []string{"rules.yaml"}); err != nil {
+		t.Errorf("unexpected error with 1 arg: %v", err)
+	}
+	if cmd.Flags().Lookup("overwrite") == nil {
+		t.Error("overwrite flag not found")
+	}
+	if cmd.Flags().Lookup("name") != nil {
+		t.Error("name flag should not be registered for multi-rule files")
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestRun_Import(t *testing.T) {
+	fs := factory.SetupTestFs(t)
+
+	data := `rules:
+  - name: hallway-button
+    trigger: {device: hallway, event: input:0.single_push}
+    actions: [{device: kitchen, action: toggle}]
+  - name: router-down
+    trigger: {device: router-plug, condition: offline}
+    actions: [{scene: alarm}]
+`
+	if err := afero.WriteFile(fs, "/rules.yaml", []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tf := factory.NewTestFactory(t)
+	cmd := NewCommand(tf.Factory)
+	cmd.SetArgs([]string{"/rules.yaml"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if n := len(config.ListRules()); n != 2 {
+		t.Errorf("len(ListRules()) = %d, want 2", n)
+	}
+
+	// Importing again without --overwrite fails, with it succeeds.
+	cmd = NewCommand(tf.Factory)
+	cmd.SetArgs([]string{"/rules.yaml"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error when rules already exist")
+	}
+	cmd = NewCommand(tf.Factory)
+	cmd.SetArgs([]string{"/rules.yaml", "--overwrite"})
+	if err := cmd.Execute(); err != nil {
+		t.Errorf("Execute(--overwrite) error = %v", err)
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestRun_ImportInvalid(t *testing.T) {
+	fs := factory.SetupTestFs(t)
+
+	data := `rules:
+  - name: good
+    trigger: {device: hallway, event: single_push}
+    actions: [{scene: evening}]
+  - name: bad
+    trigger: {device: hallway}
+    actions: [{scene: evening}]
+`
+	if err := afero.WriteFile(fs, "/rules.yaml", []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tf := factory.NewTestFactory(t)
+	cmd := NewCommand(tf.Factory)
+	cmd.SetArgs([]string{"/rules.yaml"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if n := len(config.ListRules()); n != 0 {
+		t.Errorf("len(ListRules()) = %d, want 0 (nothing saved on error)", n)
+	}
+}
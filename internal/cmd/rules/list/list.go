@@ -0,0 +1,33 @@
+// Package list provides the rules list subcommand.
+package list
+
+import (
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil/factories"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+)
+
+// NewCommand creates the rules list command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	return factories.NewConfigListCommand(f, factories.ConfigListOpts[config.Rule]{
+		Resource: "rule",
+		FetchFunc: func() []config.Rule {
+			rules := config.ListRules()
+			result := make([]config.Rule, 0, len(rules))
+			for _, rule := range rules {
+				result = append(result, rule)
+			}
+			sort.Slice(result, func(i, j int) bool {
+				return result[i].Name < result[j].Name
+			})
+			return result
+		},
+		DisplayFunc: term.DisplayRuleList,
+		HintMsg:     "Use 'shelly rules import <file>' to add rules",
+	})
+}
@@ -0,0 +1,66 @@
+package list
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "list" {
+		t.Errorf("Use = %q, want \"list\"", cmd.Use)
+	}
+	if cmd.Short == "" {
+		t.Error("Short description is empty")
+	}
+	if cmd.Example == "" {
+		t.Error("Example is empty")
+	}
+	if err := cmd.Args(cmd, []string{"extra"}); err == nil {
+		t.Error("expected error for positional argument")
+	}
+}
+
+//nolint:paralleltest // Test modifies global config state
+func TestNewCommand_ExecuteWithRules(t *testing.T) {
+	config.ResetDefaultManagerForTesting()
+	t.Cleanup(config.ResetDefaultManagerForTesting)
+
+	config.SetDefaultManager(config.NewTestManager(&config.Config{
+		Rules: map[string]config.Rule{
+			"hallway-button": {
+				Name:    "hallway-button",
+				Trigger: config.RuleTrigger{Device: "hallway", Event: "input:0.single_push"},
+				Actions: []config.RuleAction{{Device: "kitchen", Action: "toggle"}},
+			},
+			"router-down": {
+				Name:     "router-down",
+				Disabled: true,
+				Trigger:  config.RuleTrigger{Device: "router-plug", Condition: "offline"},
+				Actions:  []config.RuleAction{{Scene: "alarm"}},
+			},
+		},
+	}))
+
+	tf := factory.NewTestFactory(t)
+	cmd := NewCommand(tf.Factory)
+	cmd.SetArgs([]string{})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	out := tf.OutString()
+	for _, want := range []string{"hallway-button", "toggle kitchen", "router-plug when offline", "scene alarm"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Package rules provides local automation rule commands.
+package rules
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmd/rules/deletecmd"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/rules/importcmd"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/rules/list"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/rules/run"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/rules/validate"
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+)
+
+// NewCommand creates the rules command and its subcommands.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rules",
+		Aliases: []string{"rule"},
+		Short:   "Manage local cross-device automation rules",
+		Long: `Manage rules that react to events on one device by acting on others.
+
+Rules run on this machine (see "shelly rules run") rather than in on-device
+scripts, so Gen1 and Gen2 devices can drive each other. A rule has a trigger
+(a device event such as input:0.single_push, or a condition becoming true),
+optional conditions on current device state, and actions that control
+devices, call RPC methods or activate scenes.
+
+Rules are written in YAML and imported into the config file:
+
+  rules:
+    - name: hallway-button
+      trigger:
+        device: hallway-i4
+        event: input:0.single_push
+      conditions:
+        - device: kitchen
+          condition: "switch:0.output == false"
+      actions:
+        - device: kitchen
+          action: toggle
+        - scene: evening`,
+		Example: `  # Import rules from a file
+  shelly rules import rules.yaml
+
+  # Check a rules file without importing it
+  shelly rules validate rules.yaml
+
+  # List configured rules
+  shelly rules list
+
+  # Run the rules daemon
+  shelly rules run`,
+	}
+
+	cmd.AddCommand(list.NewCommand(f))
+	cmd.AddCommand(importcmd.NewCommand(f))
+	cmd.AddCommand(deletecmd.NewCommand(f))
+	cmd.AddCommand(validate.NewCommand(f))
+	cmd.AddCommand(run.NewCommand(f))
+
+	return cmd
+}
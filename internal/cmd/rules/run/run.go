@@ -0,0 +1,137 @@
+// Package run provides the rules run subcommand.
+package run
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rules"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+	"github.com/tj-smith47/shelly-cli/internal/utils"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory  *cmdutil.Factory
+	DryRun   bool
+	Files    []string
+	Interval time.Duration
+	Rules    []string
+}
+
+// NewCommand creates the rules run command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "run",
+		Aliases: []string{"daemon", "start", "watch"},
+		Short:   "Run rules until interrupted",
+		Long: `Run automation rules until interrupted.
+
+Gen2+ trigger devices are streamed over WebSocket, so device events such as
+input:0.single_push fire rules immediately. Gen1 devices (and Gen2+ devices
+that cannot be streamed) are polled every --interval; their relays, inputs,
+rollers and lights appear as switch:N, input:N, cover:N and light:N, and
+input events are derived from the input event counters.
+
+Condition triggers fire when the condition becomes true, not while it stays
+true, and never for a condition that already holds at start-up.
+
+Rules are read from the config file unless --file is given. Use --dry-run to
+see which rules would fire without performing their actions.`,
+		Example: `  # Run all enabled rules
+  shelly rules run
+
+  # Run rules from a file without importing them
+  shelly rules run --file rules.yaml
+
+  # Run selected rules and only report what would happen
+  shelly rules run --rule hallway-button --dry-run
+
+  # Poll Gen1 devices every second
+  shelly rules run --interval 1s`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Report fired rules without performing actions")
+	cmd.Flags().StringArrayVarP(&opts.Files, "file", "f", nil, "Read rules from a YAML file instead of the config (repeatable)")
+	cmd.Flags().DurationVarP(&opts.Interval, "interval", "i", 2*time.Second, "Polling interval for devices that cannot be streamed")
+	cmd.Flags().StringSliceVarP(&opts.Rules, "rule", "r", nil, "Only run the named rules (repeatable)")
+	utils.Must(cmd.RegisterFlagCompletionFunc("rule", completion.RuleNames()))
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	if opts.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	var list []config.Rule
+	if len(opts.Files) > 0 {
+		for _, file := range opts.Files {
+			parsed, err := config.ParseRulesFile(file)
+			if err != nil {
+				return err
+			}
+			list = append(list, parsed...)
+		}
+	} else {
+		cfg, err := opts.Factory.Config()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		for _, rule := range cfg.Rules {
+			list = append(list, rule)
+		}
+	}
+
+	if len(opts.Rules) > 0 {
+		list = slices.DeleteFunc(list, func(r config.Rule) bool {
+			return !slices.Contains(opts.Rules, r.Name)
+		})
+	}
+
+	engine, err := rules.New(list)
+	if err != nil {
+		return err
+	}
+	if engine.Rules() == 0 {
+		ios.Warning("No enabled rules")
+		ios.Info("Import rules with: shelly rules import <file>")
+		return nil
+	}
+
+	exec := svc.RuleExecutor()
+	if opts.DryRun {
+		exec = rules.DryRun(exec)
+		ios.Info("Dry run - actions will not be performed")
+	}
+
+	ios.Success("Rules engine started")
+	ios.Printf("  Running %d rule(s) on %d trigger device(s)\n", engine.Rules(), len(engine.Devices()))
+	ios.Printf("  Press Ctrl+C to stop\n")
+	ios.Println("")
+
+	svc.RunRules(ctx, engine, exec, opts.Interval, func(out rules.Outcome) {
+		term.DisplayRuleOutcome(ios, out)
+	})
+
+	ios.Println("")
+	ios.Info("Rules engine stopped")
+	return nil
+}
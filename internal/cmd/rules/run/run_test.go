@@ -0,0 +1,122 @@
+package run
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "run" {
+		t.Errorf("Use = %q, want run", cmd.Use)
+	}
+	if cmd.Short == "" {
+		t.Error("Short description is empty")
+	}
+	if cmd.Example == "" {
+		t.Error("Example is empty")
+	}
+	if err := cmd.Args(cmd, []string{"extra"}); err == nil {
+		t.Error("expected error for positional argument")
+	}
+}
+
+func TestNewCommand_Flags(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	tests := []struct {
+		name      string
+		shorthand string
+		defValue  string
+	}{
+		{"dry-run", "", "false"},
+		{"file", "f", "[]"},
+		{"interval", "i", "2s"},
+		{"rule", "r", "[]"},
+	}
+	for _, tt := range tests {
+		flag := cmd.Flags().Lookup(tt.name)
+		if flag == nil {
+			t.Errorf("flag %q not found", tt.name)
+			continue
+		}
+		if flag.Shorthand != tt.shorthand {
+			t.Errorf("%s shorthand = %q, want %q", tt.name, flag.Shorthand, tt.shorthand)
+		}
+		if flag.DefValue != tt.defValue {
+			t.Errorf("%s default = %q, want %q", tt.name, flag.DefValue, tt.defValue)
+		}
+	}
+}
+
+func TestRun_InvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	err := run(context.Background(), &Options{Factory: tf.Factory})
+	if err == nil || !strings.Contains(err.Error(), "interval") {
+		t.Errorf("run() error = %v, want interval error", err)
+	}
+}
+
+func TestRun_NoEnabledRules(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	tf.Config.Rules = map[string]config.Rule{
+		"off": {
+			Name:     "off",
+			Disabled: true,
+			Trigger:  config.RuleTrigger{Device: "hall", Condition: "offline"},
+			Actions:  []config.RuleAction{{Scene: "alarm"}},
+		},
+		"on": {
+			Name:    "on",
+			Trigger: config.RuleTrigger{Device: "hall", Event: "single_push"},
+			Actions: []config.RuleAction{{Scene: "evening"}},
+		},
+	}
+
+	// --rule selects only the disabled rule, so nothing is left to run.
+	err := run(context.Background(), &Options{Factory: tf.Factory, Interval: time.Second, Rules: []string{"off"}})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(tf.ErrString()+tf.OutString(), "No enabled rules") {
+		t.Errorf("expected warning, got:\n%s%s", tf.OutString(), tf.ErrString())
+	}
+}
+
+func TestRun_InvalidRule(t *testing.T) {
+	t.Parallel()
+
+	tf := factory.NewTestFactory(t)
+	tf.Config.Rules = map[string]config.Rule{
+		"broken": {Name: "broken", Trigger: config.RuleTrigger{Device: "hall"}},
+	}
+	if err := run(context.Background(), &Options{Factory: tf.Factory, Interval: time.Second}); err == nil {
+		t.Error("expected validation error")
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestRun_MissingFile(t *testing.T) {
+	factory.SetupTestFs(t)
+
+	tf := factory.NewTestFactory(t)
+	err := run(context.Background(), &Options{Factory: tf.Factory, Interval: time.Second, Files: []string{"/missing.yaml"}})
+	if err == nil {
+		t.Error("expected error for missing file")
+	}
+}
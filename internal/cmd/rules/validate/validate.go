@@ -0,0 +1,94 @@
+// Package validate provides the rules validate subcommand.
+package validate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rules"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory  *cmdutil.Factory
+	FilePath string
+}
+
+// NewCommand creates the rules validate command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "validate [file]",
+		Aliases: []string{"check", "lint"},
+		Short:   "Validate rules",
+		Long: `Validate rule definitions without running them.
+
+Checks a rules file, or the configured rules when no file is given. Syntax
+errors in triggers, conditions and actions fail validation; references to
+unregistered devices or missing scenes are reported as warnings.`,
+		Example: `  # Validate a rules file before importing it
+  shelly rules validate rules.yaml
+
+  # Validate the configured rules
+  shelly rules validate`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.FilePath = args[0]
+			}
+			return run(opts)
+		},
+	}
+
+	return cmd
+}
+
+func run(opts *Options) error {
+	ios := opts.Factory.IOStreams()
+
+	var list []config.Rule
+	if opts.FilePath != "" {
+		parsed, err := config.ParseRulesFile(opts.FilePath)
+		if err != nil {
+			return err
+		}
+		list = parsed
+	} else {
+		cfg, err := opts.Factory.Config()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		for _, rule := range cfg.Rules {
+			list = append(list, rule)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+
+	if len(list) == 0 {
+		ios.Info("No rules configured")
+		return nil
+	}
+
+	failed := 0
+	for _, rule := range list {
+		if err := rules.Validate(rule); err != nil {
+			ios.Error("%v", err)
+			failed++
+			continue
+		}
+		for _, w := range rules.Warnings(rule) {
+			ios.Warning("%s", w)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d/%d rule(s) invalid", failed, len(list))
+	}
+	ios.Success("%d rule(s) valid", len(list))
+	return nil
+}
@@ -0,0 +1,100 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "validate [file]" {
+		t.Errorf("Use = %q, want \"validate [file]\"", cmd.Use)
+	}
+	if cmd.Short == "" {
+		t.Error("Short description is empty")
+	}
+	if cmd.Example == "" {
+		t.Error("Example is empty")
+	}
+	if err := cmd.Args(cmd, []string{"a", "b"}); err == nil {
+		t.Error("expected error with 2 args")
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestRun_File(t *testing.T) {
+	fs := factory.SetupTestFs(t)
+
+	data := `rules:
+  - name: good
+    trigger: {device: hallway, event: single_push}
+    actions: [{scene: evening}]
+  - name: bad
+    trigger: {device: hallway, event: single_push, condition: offline}
+    actions: [{scene: evening}]
+`
+	if err := afero.WriteFile(fs, "/rules.yaml", []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tf := factory.NewTestFactory(t)
+	err := run(&Options{Factory: tf.Factory, FilePath: "/rules.yaml"})
+	if err == nil || !strings.Contains(err.Error(), "1/2") {
+		t.Errorf("run() error = %v, want 1/2 rule(s) invalid", err)
+	}
+	errOut := tf.ErrString()
+	if !strings.Contains(errOut, "bad") {
+		t.Errorf("expected error for rule bad, got:\n%s", errOut)
+	}
+	// Unregistered devices and scenes are warnings, not failures.
+	if !strings.Contains(errOut, "hallway") || !strings.Contains(errOut, "evening") {
+		t.Errorf("expected warnings for device and scene, got:\n%s", errOut)
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestRun_Configured(t *testing.T) {
+	factory.SetupTestFs(t)
+
+	tf := factory.NewTestFactory(t)
+	if err := run(&Options{Factory: tf.Factory}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(tf.OutString()+tf.ErrString(), "No rules configured") {
+		t.Errorf("expected empty notice, got:\n%s%s", tf.OutString(), tf.ErrString())
+	}
+
+	tf.Config.Rules = map[string]config.Rule{
+		"night": {
+			Name:    "night",
+			Trigger: config.RuleTrigger{Device: "hall", Condition: "offline"},
+			Actions: []config.RuleAction{{Device: "lamp", Action: "on"}},
+		},
+	}
+	tf.Reset()
+	if err := run(&Options{Factory: tf.Factory}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.Contains(tf.OutString()+tf.ErrString(), "1 rule(s) valid") {
+		t.Errorf("expected success, got:\n%s%s", tf.OutString(), tf.ErrString())
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestRun_MissingFile(t *testing.T) {
+	factory.SetupTestFs(t)
+
+	tf := factory.NewTestFactory(t)
+	if err := run(&Options{Factory: tf.Factory, FilePath: "/missing.yaml"}); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
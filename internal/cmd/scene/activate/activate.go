@@ -4,26 +4,29 @@ package activate
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/spf13/cobra"
-	"golang.org/x/sync/errgroup"
 
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
 	"github.com/tj-smith47/shelly-cli/internal/completion"
 	"github.com/tj-smith47/shelly-cli/internal/config"
-	"github.com/tj-smith47/shelly-cli/internal/iostreams"
-	"github.com/tj-smith47/shelly-cli/internal/output"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/term"
 	"github.com/tj-smith47/shelly-cli/internal/theme"
 )
 
 // Options holds the command options.
 type Options struct {
-	Factory    *cmdutil.Factory
-	Concurrent int
-	DryRun     bool
-	Name       string
-	Timeout    time.Duration
+	Factory     *cmdutil.Factory
+	Concurrent  int
+	DryRun      bool
+	Name        string
+	NoSnapshot  bool
+	RevertAfter time.Duration
+	Timeout     time.Duration
 }
 
 // NewCommand creates the scene activate command.
@@ -40,14 +43,28 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 		Short:   "Activate a scene",
 		Long: `Execute all actions defined in a scene.
 
-Actions are executed concurrently for faster execution.
-Use --dry-run to preview actions without executing them.`,
+Actions in the same group are executed concurrently. Groups run one after
+another in ascending order; a group starts once every action in the previous
+group has finished, including its delay and transition, so covers can close
+after lights have dimmed. Use --dry-run to preview actions without executing
+them.
+
+Before running, the current state of every switch, light and cover the scene
+touches is saved as a snapshot; "shelly scene revert" restores it. With
+--revert-after the command waits and restores the snapshot itself.
+Activating the scene again before reverting keeps the first snapshot, so
+revert still returns devices to their state before the scene. Devices whose
+state cannot be read, such as Gen1 devices, are named in a warning and are
+not restored by revert.`,
 		Example: `  # Activate a scene
   shelly scene activate movie-night
 
   # Preview without executing
   shelly scene activate movie-night --dry-run
 
+  # Return the room to its previous state after 30 minutes
+  shelly scene activate presentation --revert-after 30m
+
   # Using aliases
   shelly scene run bedtime
   shelly scene play morning-routine
@@ -65,6 +82,8 @@ Use --dry-run to preview actions without executing them.`,
 	cmd.Flags().DurationVarP(&opts.Timeout, "timeout", "t", 10*time.Second, "Timeout per device")
 	cmd.Flags().IntVarP(&opts.Concurrent, "concurrent", "c", 5, "Max concurrent operations")
 	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Preview actions without executing")
+	cmd.Flags().BoolVar(&opts.NoSnapshot, "no-snapshot", false, "Do not save the previous device state for revert")
+	cmd.Flags().DurationVar(&opts.RevertAfter, "revert-after", 0, "Restore the previous device state after this duration")
 
 	return cmd
 }
@@ -84,78 +103,58 @@ func run(ctx context.Context, opts *Options) error {
 		ios.Warning("Scene %q has no actions", opts.Name)
 		return nil
 	}
+	if opts.NoSnapshot && opts.RevertAfter > 0 {
+		return fmt.Errorf("--revert-after requires a snapshot; remove --no-snapshot")
+	}
 
 	if opts.DryRun {
 		ios.Info("Dry run - would execute %d action(s):", len(scene.Actions))
-		for i, action := range scene.Actions {
-			params := output.FormatParamsInline(action.Params)
-			if params != "" {
-				params = theme.Dim().Render("{" + params + "}")
-			}
-			ios.Info("  %d. %s %s %s",
-				i+1,
-				theme.Bold().Render(action.Device),
-				theme.Highlight().Render(action.Method),
-				params,
-			)
-		}
+		term.DisplayScenePlan(ios, scene)
 		return nil
 	}
 
-	ios.Info("Activating scene %q (%d actions)...", theme.Bold().Render(opts.Name), len(scene.Actions))
-
 	svc := opts.Factory.ShellyService()
 
-	// Create MultiWriter for progress tracking
-	mw := iostreams.NewMultiWriter(ios.Out, ios.IsStdoutTTY())
-
-	// Add all actions upfront (use device:method as identifier for clarity)
-	for _, action := range scene.Actions {
-		lineID := fmt.Sprintf("%s:%s", action.Device, action.Method)
-		mw.AddLine(lineID, "pending")
-	}
-
-	// Create parent context with overall timeout
-	ctx, cancel := context.WithTimeout(ctx, opts.Timeout*time.Duration(len(scene.Actions)))
-	defer cancel()
-
-	g, ctx := errgroup.WithContext(ctx)
-	g.SetLimit(concurrent)
-
-	for _, action := range scene.Actions {
-		act := action // Capture for closure
-		lineID := fmt.Sprintf("%s:%s", act.Device, act.Method)
-		g.Go(func() error {
-			params := output.FormatParamsInline(act.Params)
-			if params != "" {
-				params = theme.Dim().Render("{" + params + "}")
-			}
-			mw.UpdateLine(lineID, iostreams.StatusRunning, params)
-
-			// Per-action timeout
-			actionCtx, actionCancel := context.WithTimeout(ctx, opts.Timeout)
-			defer actionCancel()
-
-			_, err := svc.RawRPC(actionCtx, act.Device, act.Method, act.Params)
-			if err != nil {
-				mw.UpdateLine(lineID, iostreams.StatusError, err.Error())
+	snapshot := config.SceneSnapshot{}
+	if !opts.NoSnapshot {
+		// Keep the state from before an earlier activation that was not
+		// reverted, rather than replacing it with this scene's own state.
+		pending, _, err := config.GetSceneSnapshot(opts.Name)
+		if err != nil {
+			ios.DebugErr("load scene snapshot", err)
+		}
+		snapCtx, cancel := context.WithTimeout(ctx, opts.Timeout*time.Duration(len(scene.Actions)))
+		snap, skipped := svc.SnapshotScene(snapCtx, scene, pending)
+		cancel()
+		for _, device := range slices.Sorted(maps.Keys(skipped)) {
+			// The error names the device, e.g. "porch: scene capture requires a Gen2+ device".
+			ios.Warning("Revert will not restore %v", skipped[device])
+		}
+		if len(snap.Actions) > 0 {
+			if err := config.SaveSceneSnapshot(snap); err != nil {
+				ios.Warning("Failed to save snapshot: %v", err)
 			} else {
-				mw.UpdateLine(lineID, iostreams.StatusSuccess, "done")
+				snapshot = snap
 			}
-
-			return nil // Don't fail the whole batch on individual errors
-		})
+		}
 	}
 
-	// Wait for all operations to complete
-	if err := g.Wait(); err != nil {
-		return fmt.Errorf("scene activation failed: %w", err)
-	}
+	ios.Info("Activating scene %q (%d actions)...", theme.Bold().Render(opts.Name), len(scene.Actions))
 
-	mw.Finalize()
+	// Create parent context with overall timeout, allowing for delays and transitions
+	total := opts.Timeout * time.Duration(len(scene.Actions))
+	for _, stage := range shelly.PlanScene(scene.Actions) {
+		total += stage.Duration()
+	}
+	runCtx, cancel := context.WithTimeout(ctx, total)
+	defer cancel()
 
-	// Get summary from MultiWriter
-	succeeded, failed, _ := mw.Summary()
+	// Individual failures are reported per line and counted below
+	progress := term.NewSceneProgress(ios, scene.Actions)
+	if err := svc.RunScene(runCtx, scene, progress.Options(concurrent, opts.Timeout)); err != nil {
+		ios.DebugErr("scene activation", err)
+	}
+	succeeded, failed := progress.Finish()
 
 	// Print summary
 	if failed > 0 {
@@ -164,5 +163,36 @@ func run(ctx context.Context, opts *Options) error {
 	}
 
 	ios.Success("Scene %q activated (%d actions)", opts.Name, succeeded)
+
+	switch {
+	case len(snapshot.Actions) == 0:
+		if opts.RevertAfter > 0 {
+			ios.Warning("No previous state was saved; nothing to revert")
+		}
+		return nil
+	case opts.RevertAfter <= 0:
+		ios.Info("Undo with: shelly scene revert %s", opts.Name)
+		return nil
+	}
+
+	ios.Info("Reverting in %s (Ctrl+C to keep the scene; revert later with: shelly scene revert %s)", opts.RevertAfter, opts.Name)
+	select {
+	case <-ctx.Done():
+		ios.Info("Revert skipped; the snapshot is kept")
+		return nil
+	case <-time.After(opts.RevertAfter):
+	}
+
+	ios.Info("Reverting scene %q...", opts.Name)
+	revertCtx, revertCancel := context.WithTimeout(ctx, opts.Timeout*time.Duration(len(snapshot.Actions)))
+	defer revertCancel()
+	revert := term.NewSceneProgress(ios, snapshot.Actions)
+	if err := svc.RevertScene(revertCtx, snapshot, revert.Options(concurrent, opts.Timeout)); err != nil {
+		ios.DebugErr("scene revert", err)
+	}
+	if _, failed := revert.Finish(); failed > 0 {
+		return fmt.Errorf("revert: %d/%d actions failed", failed, len(snapshot.Actions))
+	}
+	ios.Success("Scene %q reverted", opts.Name)
 	return nil
 }
@@ -619,3 +619,42 @@ func TestNewCommand_ExecuteWithContext(t *testing.T) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+//nolint:paralleltest // Test modifies global config state
+func TestRun_RevertAfterRequiresSnapshot(t *testing.T) {
+	config.ResetDefaultManagerForTesting()
+	t.Cleanup(config.ResetDefaultManagerForTesting)
+
+	config.SetDefaultManager(config.NewTestManager(&config.Config{
+		Scenes: map[string]config.Scene{
+			"presentation": {
+				Name:    "presentation",
+				Actions: []config.SceneAction{{Device: "room-a", Method: "Light.Set"}},
+			},
+		},
+	}))
+
+	tf := factory.NewTestFactory(t)
+	opts := &Options{
+		Factory:     tf.Factory,
+		Name:        "presentation",
+		NoSnapshot:  true,
+		RevertAfter: time.Minute,
+		Timeout:     time.Second,
+	}
+	if err := run(t.Context(), opts); err == nil || !strings.Contains(err.Error(), "revert-after") {
+		t.Errorf("run() error = %v, want --revert-after error", err)
+	}
+}
+
+func TestNewCommand_SnapshotFlags(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+	if f := cmd.Flags().Lookup("revert-after"); f == nil || f.DefValue != "0s" {
+		t.Errorf("revert-after flag = %v", f)
+	}
+	if f := cmd.Flags().Lookup("no-snapshot"); f == nil || f.DefValue != "false" {
+		t.Errorf("no-snapshot flag = %v", f)
+	}
+}
@@ -0,0 +1,111 @@
+// Package capture provides the scene capture subcommand.
+package capture
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory     *cmdutil.Factory
+	Description string
+	Devices     []string
+	Name        string
+	Overwrite   bool
+	Transition  time.Duration
+}
+
+// NewCommand creates the scene capture command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "capture <name> <device>...",
+		Aliases: []string{"snapshot", "record"},
+		Short:   "Create a scene from current device state",
+		Long: `Create a scene that restores the current state of one or more devices.
+
+Switches, lights, RGB/RGBW/CCT outputs and covers are captured from each
+device's status: outputs with their on state, brightness and colour, and
+covers with their position (or open/closed when not calibrated). Capture
+requires Gen2+ devices.
+
+Use --transition to fade captured lights into their state when the scene is
+activated.`,
+		Example: `  # Capture the living room as it is now
+  shelly scene capture movie-night living-room-lights tv-plug blinds
+
+  # Fade lights over 3 seconds when activated
+  shelly scene capture evening living-room-lights --transition 3s
+
+  # Replace an existing scene
+  shelly scene capture evening living-room-lights --overwrite`,
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completion.NameThenDevices(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Name = args[0]
+			opts.Devices = args[1:]
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Description, "description", "d", "", "Scene description")
+	cmd.Flags().BoolVar(&opts.Overwrite, "overwrite", false, "Replace an existing scene")
+	cmd.Flags().DurationVar(&opts.Transition, "transition", 0, "Transition for captured light actions (e.g., 3s)")
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ctx, cancel := opts.Factory.WithDefaultTimeout(ctx)
+	defer cancel()
+
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	if err := config.ValidateSceneName(opts.Name); err != nil {
+		return err
+	}
+	if opts.Transition < 0 {
+		return fmt.Errorf("transition must not be negative")
+	}
+	if _, exists := config.GetScene(opts.Name); exists && !opts.Overwrite {
+		return fmt.Errorf("scene %q already exists (use --overwrite to replace)", opts.Name)
+	}
+
+	var actions []config.SceneAction
+	err := cmdutil.RunWithSpinner(ctx, ios, "Capturing device state...", func(ctx context.Context) error {
+		var captureErr error
+		actions, captureErr = svc.CaptureScene(ctx, opts.Devices)
+		return captureErr
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.Transition > 0 {
+		for i := range actions {
+			if slices.Contains(config.SceneTransitionMethods, actions[i].Method) {
+				actions[i].Transition = opts.Transition.String()
+			}
+		}
+	}
+
+	scene := &config.Scene{Name: opts.Name, Description: opts.Description, Actions: actions}
+	if err := config.ImportScene(scene, opts.Overwrite); err != nil {
+		return err
+	}
+
+	ios.Success("Scene %q captured with %d action(s) from %d device(s)", opts.Name, len(actions), len(opts.Devices))
+	ios.Info("Activate with: shelly scene activate %s", opts.Name)
+	return nil
+}
@@ -0,0 +1,71 @@
+package capture
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "capture <name> <device>..." {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+	if cmd.Short == "" {
+		t.Error("Short description is empty")
+	}
+	if cmd.Example == "" {
+		t.Error("Example is empty")
+	}
+	if cmd.ValidArgsFunction == nil {
+		t.Error("ValidArgsFunction is nil")
+	}
+	if err := cmd.Args(cmd, []string{"scene"}); err == nil {
+		t.Error("expected error without devices")
+	}
+	if err := cmd.Args(cmd, []string{"scene", "a", "b"}); err != nil {
+		t.Errorf("unexpected error with two devices: %v", err)
+	}
+	for _, name := range []string{"description", "overwrite", "transition"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("flag %q not found", name)
+		}
+	}
+}
+
+//nolint:paralleltest // Test modifies global config state
+func TestRun_Validation(t *testing.T) {
+	config.ResetDefaultManagerForTesting()
+	t.Cleanup(config.ResetDefaultManagerForTesting)
+	config.SetDefaultManager(config.NewTestManager(&config.Config{
+		Scenes: map[string]config.Scene{"existing": {Name: "existing"}},
+	}))
+
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{"invalid name", Options{Name: "bad name", Devices: []string{"a"}}, "invalid"},
+		{"negative transition", Options{Name: "ok", Devices: []string{"a"}, Transition: -time.Second}, "transition"},
+		{"existing scene", Options{Name: "existing", Devices: []string{"a"}}, "already exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := factory.NewTestFactory(t)
+			tt.opts.Factory = tf.Factory
+			err := run(t.Context(), &tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("run() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Package revert provides the scene revert subcommand.
+package revert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+	"github.com/tj-smith47/shelly-cli/internal/theme"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory    *cmdutil.Factory
+	Concurrent int
+	DryRun     bool
+	Name       string
+	Timeout    time.Duration
+}
+
+// NewCommand creates the scene revert command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "revert [name]",
+		Aliases: []string{"undo", "restore"},
+		Short:   "Restore device state from before a scene was activated",
+		Long: `Restore the devices a scene changed to the state they were in before it
+was last activated.
+
+"shelly scene activate" saves a snapshot of every switch, light and cover a
+scene touches before running it. Without a name, the most recently saved
+snapshot is restored. A snapshot is removed once it has been fully restored.`,
+		Example: `  # Undo the most recent scene
+  shelly scene revert
+
+  # Undo a specific scene
+  shelly scene revert presentation
+
+  # Show what would be restored
+  shelly scene revert presentation --dry-run`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completion.SceneNames(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.Name = args[0]
+			}
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().DurationVarP(&opts.Timeout, "timeout", "t", 10*time.Second, "Timeout per device")
+	cmd.Flags().IntVarP(&opts.Concurrent, "concurrent", "c", 5, "Max concurrent operations")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Preview actions without executing")
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+
+	concurrent := cmdutil.CapConcurrency(ios, opts.Concurrent)
+
+	snap, ok, err := config.GetSceneSnapshot(opts.Name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if opts.Name == "" {
+			return fmt.Errorf("no scene snapshots to revert")
+		}
+		return fmt.Errorf("no snapshot for scene %q", opts.Name)
+	}
+
+	if opts.DryRun {
+		ios.Info("Dry run - would restore %d action(s) saved %s:", len(snap.Actions), snap.Time.Format(time.DateTime))
+		term.DisplayScenePlan(ios, config.Scene{Name: snap.Scene, Actions: snap.Actions})
+		return nil
+	}
+
+	ios.Info("Reverting scene %q (saved %s)...", theme.Bold().Render(snap.Scene), snap.Time.Format(time.DateTime))
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout*time.Duration(max(len(snap.Actions), 1)))
+	defer cancel()
+
+	svc := opts.Factory.ShellyService()
+	progress := term.NewSceneProgress(ios, snap.Actions)
+	if err := svc.RevertScene(ctx, snap, progress.Options(concurrent, opts.Timeout)); err != nil {
+		ios.DebugErr("scene revert", err)
+	}
+	succeeded, failed := progress.Finish()
+
+	if failed > 0 {
+		ios.Warning("Scene %q: %d/%d actions failed; the snapshot is kept", snap.Scene, failed, len(snap.Actions))
+		return fmt.Errorf("%d/%d actions failed", failed, len(snap.Actions))
+	}
+
+	ios.Success("Scene %q reverted (%d actions)", snap.Scene, succeeded)
+	return nil
+}
@@ -0,0 +1,86 @@
+package revert
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "revert [name]" {
+		t.Errorf("Use = %q, want \"revert [name]\"", cmd.Use)
+	}
+	if cmd.Short == "" {
+		t.Error("Short description is empty")
+	}
+	if cmd.Example == "" {
+		t.Error("Example is empty")
+	}
+	if cmd.ValidArgsFunction == nil {
+		t.Error("ValidArgsFunction is nil")
+	}
+	if err := cmd.Args(cmd, []string{"a", "b"}); err == nil {
+		t.Error("expected error with 2 args")
+	}
+	for _, name := range []string{"timeout", "concurrent", "dry-run"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("flag %q not found", name)
+		}
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestRun_NoSnapshot(t *testing.T) {
+	factory.SetupTestFs(t)
+
+	tf := factory.NewTestFactory(t)
+	err := run(t.Context(), &Options{Factory: tf.Factory, Timeout: time.Second})
+	if err == nil || !strings.Contains(err.Error(), "no scene snapshots") {
+		t.Errorf("run() error = %v, want no snapshots", err)
+	}
+
+	err = run(t.Context(), &Options{Factory: tf.Factory, Name: "presentation", Timeout: time.Second})
+	if err == nil || !strings.Contains(err.Error(), "presentation") {
+		t.Errorf("run() error = %v, want missing snapshot for presentation", err)
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestRun_DryRun(t *testing.T) {
+	factory.SetupTestFs(t)
+
+	snap := config.SceneSnapshot{
+		Scene: "presentation",
+		Time:  time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+		Actions: []config.SceneAction{
+			{Device: "room-a-lights", Method: "Light.Set", Params: map[string]any{"id": 0, "on": true, "brightness": 80}},
+			{Device: "room-a-blinds", Method: "Cover.GoToPosition", Params: map[string]any{"id": 0, "pos": 100}},
+		},
+	}
+	if err := config.SaveSceneSnapshot(snap); err != nil {
+		t.Fatalf("SaveSceneSnapshot() error = %v", err)
+	}
+
+	tf := factory.NewTestFactory(t)
+	if err := run(t.Context(), &Options{Factory: tf.Factory, DryRun: true, Timeout: time.Second}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	out := tf.OutString()
+	for _, want := range []string{"Dry run", "room-a-lights", "Cover.GoToPosition"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if _, ok, _ := config.GetSceneSnapshot("presentation"); !ok {
+		t.Error("dry run removed the snapshot")
+	}
+}
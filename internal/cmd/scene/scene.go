@@ -5,11 +5,13 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/tj-smith47/shelly-cli/internal/cmd/scene/activate"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/scene/capture"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/scene/create"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/scene/deletecmd"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/scene/export"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/scene/importcmd"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/scene/list"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/scene/revert"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/scene/show"
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
 )
@@ -30,12 +32,18 @@ Each scene contains one or more actions that are executed when the scene is acti
   # Create a new scene
   shelly scene create movie-night
 
+  # Create a scene from current device state
+  shelly scene capture movie-night living-room tv-plug
+
   # Show scene details
   shelly scene show movie-night
 
   # Activate a scene
   shelly scene activate movie-night
 
+  # Undo the last activated scene
+  shelly scene revert
+
   # Export a scene to file
   shelly scene export movie-night scene.yaml
 
@@ -50,6 +58,8 @@ Each scene contains one or more actions that are executed when the scene is acti
 	cmd.AddCommand(create.NewCommand(f))
 	cmd.AddCommand(deletecmd.NewCommand(f))
 	cmd.AddCommand(activate.NewCommand(f))
+	cmd.AddCommand(capture.NewCommand(f))
+	cmd.AddCommand(revert.NewCommand(f))
 	cmd.AddCommand(show.NewCommand(f))
 	cmd.AddCommand(export.NewCommand(f))
 	cmd.AddCommand(importcmd.NewCommand(f))
@@ -0,0 +1,117 @@
+// Package bundle provides the script bundle subcommand.
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/output"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptbundle"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory *cmdutil.Factory
+	Entry   string
+	Output  string
+	Minify  bool
+	MaxSize int
+	Allow   []string
+}
+
+// NewCommand creates the script bundle command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:   "bundle <entry.js>",
+		Short: "Bundle a script and its local modules into one file",
+		Long: `Bundle a script and the local modules it imports into a single file
+that can be uploaded to a device.
+
+Local modules are loaded with import statements or require() calls using
+relative paths (./util or ../lib/util.js). Each module is included once,
+dependencies first, in its own function scope. Comments are stripped, and
+--minify also removes unneeded whitespace and shortens declared names.
+
+Constructs the device script engine does not support (classes, async/await,
+Promise, generators, dynamic import(), destructuring, and getters and
+setters) are rejected with their file and line. Use --allow with a rule name
+to skip a check: class, async, promise, generator, module, destructuring or
+accessor.
+
+The bundle is checked against --max-size, the script size limit of the
+device, and is not written if it is larger.`,
+		Example: `  # Print the bundle of a script
+  shelly script bundle main.js
+
+  # Write a minified bundle to a file
+  shelly script bundle main.js --minify -o dist/main.js
+
+  # Use a larger size limit
+  shelly script bundle main.js --max-size 32768
+
+  # Bundle and upload in one step
+  shelly script upload living-room 1 main.js --bundle --minify`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Entry = args[0]
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "Write the bundle to a file instead of stdout")
+	cmd.Flags().BoolVar(&opts.Minify, "minify", false, "Remove unneeded whitespace and shorten names")
+	cmd.Flags().IntVar(&opts.MaxSize, "max-size", scriptbundle.DefaultMaxSize, "Script size limit in bytes (0 for no limit)")
+	cmd.Flags().StringArrayVar(&opts.Allow, "allow", nil, "Skip a check rule (class, async, promise, generator, module, destructuring, accessor)")
+
+	return cmd
+}
+
+func run(_ context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+
+	res, err := scriptbundle.Bundle(opts.Entry, scriptbundle.Options{Minify: opts.Minify, Allow: opts.Allow})
+	if err != nil {
+		return err
+	}
+	if err := scriptbundle.CheckSize(res.Size, opts.MaxSize); err != nil {
+		return err
+	}
+
+	if opts.Output == "" {
+		ios.Printf("%s", res.Code)
+		return nil
+	}
+
+	fs := config.Fs()
+	if dir := filepath.Dir(opts.Output); dir != "." && dir != "" {
+		if err := fs.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+	if err := afero.WriteFile(fs, opts.Output, []byte(res.Code), 0o600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	ios.Success("Bundled %d module(s) into %s", len(res.Modules), opts.Output)
+	source := 0
+	for _, m := range res.Modules {
+		source += m.Size
+		ios.Info("  %s (%s)", m.File, output.FormatSize(int64(m.Size)))
+	}
+	if opts.MaxSize > 0 {
+		ios.Info("Size: %s from %s of source, %d%% of the %s limit",
+			output.FormatSize(int64(res.Size)), output.FormatSize(int64(source)),
+			res.Size*100/opts.MaxSize, output.FormatSize(int64(opts.MaxSize)))
+	} else {
+		ios.Info("Size: %s from %s of source", output.FormatSize(int64(res.Size)), output.FormatSize(int64(source)))
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package bundle
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "bundle <entry.js>" {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+	if cmd.Short == "" || cmd.Long == "" || cmd.Example == "" {
+		t.Error("help text is incomplete")
+	}
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("expected error with no args")
+	}
+	for name, want := range map[string]string{"output": "", "minify": "false", "max-size": "16384", "allow": "[]"} {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			t.Errorf("--%s flag not found", name)
+			continue
+		}
+		if flag.DefValue != want {
+			t.Errorf("--%s default = %q, want %q", name, flag.DefValue, want)
+		}
+	}
+	if cmd.Flags().ShorthandLookup("o") == nil {
+		t.Error("-o shorthand not found")
+	}
+}
+
+func setupFiles(t *testing.T) afero.Fs {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	config.SetFs(fs)
+	t.Cleanup(func() { config.SetFs(nil) })
+
+	files := map[string]string{
+		"/app/main.js": "// Toggle on push\nimport { toggle } from \"./util\";\n\nShelly.addEventHandler(function (event) {\n  toggle(0);\n});\n",
+		"/app/util.js": "export function toggle(switchId) {\n  Shelly.call(\"Switch.Toggle\", { id: switchId });\n}\n",
+	}
+	for name, content := range files {
+		if err := afero.WriteFile(fs, name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fs
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestRun_Stdout(t *testing.T) {
+	setupFiles(t)
+	tf := factory.NewTestFactory(t)
+
+	err := run(context.Background(), &Options{Factory: tf.Factory, Entry: "/app/main.js", MaxSize: 16384})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	out := tf.OutString()
+	if !strings.Contains(out, "let toggle = __util.toggle;") || strings.Contains(out, "Toggle on push") {
+		t.Errorf("unexpected bundle:\n%s", out)
+	}
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestRun_OutputFile(t *testing.T) {
+	fs := setupFiles(t)
+	tf := factory.NewTestFactory(t)
+
+	err := run(context.Background(), &Options{Factory: tf.Factory, Entry: "/app/main.js", Output: "/app/dist/main.js", Minify: true, MaxSize: 16384})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	data, err := afero.ReadFile(fs, "/app/dist/main.js")
+	if err != nil {
+		t.Fatalf("bundle not written: %v", err)
+	}
+	if strings.Contains(string(data), "switchId") || !strings.Contains(string(data), `Shelly.call("Switch.Toggle"`) {
+		t.Errorf("bundle not minified:\n%s", data)
+	}
+
+	out := tf.OutString()
+	for _, want := range []string{"Bundled 2 module(s) into /app/dist/main.js", "/app/util.js", "of the 16.0 KB limit"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestRun_Errors(t *testing.T) {
+	fs := setupFiles(t)
+	if err := afero.WriteFile(fs, "/app/async.js", []byte("async function f() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tf := factory.NewTestFactory(t)
+
+	err := run(context.Background(), &Options{Factory: tf.Factory, Entry: "/app/main.js", Output: "/app/out.js", MaxSize: 50})
+	if err == nil || !strings.Contains(err.Error(), "over the 50 byte limit") {
+		t.Errorf("run() error = %v, want size error", err)
+	}
+	if _, statErr := fs.Stat("/app/out.js"); statErr == nil {
+		t.Error("bundle over the limit should not be written")
+	}
+
+	err = run(context.Background(), &Options{Factory: tf.Factory, Entry: "/app/async.js"})
+	if err == nil || !strings.Contains(err.Error(), "/app/async.js:1:1: async functions are not supported") {
+		t.Errorf("run() error = %v, want check error", err)
+	}
+
+	if err := run(context.Background(), &Options{Factory: tf.Factory, Entry: "/app/async.js", Allow: []string{"async"}}); err != nil {
+		t.Errorf("run() with --allow error = %v", err)
+	}
+}
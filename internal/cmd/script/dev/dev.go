@@ -0,0 +1,246 @@
+// Package dev provides the script dev subcommand.
+package dev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/completion"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptdev"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+)
+
+// Timing of the dev loop.
+const (
+	rpcTimeout     = 30 * time.Second
+	statusDelay    = time.Second
+	reconnectDelay = 2 * time.Second
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory   *cmdutil.Factory
+	Device    string
+	ID        int
+	File      string
+	ChunkSize int
+	Interval  time.Duration
+	AllLogs   bool
+	NoLogs    bool
+}
+
+// NewCommand creates the script dev command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:     "dev <device> <id> <file>",
+		Aliases: []string{"watch"},
+		Short:   "Develop a script with live reload and log streaming",
+		Long: `Watch a local script file and keep the device script in sync with it.
+
+On start and on every change to the file, the script is stopped, the code is
+uploaded in chunks of --chunk-size bytes and the script is started again.
+Errors reported by the device after a restart are shown.
+
+Meanwhile the device debug log is streamed over WebSocket, showing the
+script's print() output and interpreter errors. Errors that point at a
+line or quote code are mapped back to the source file. Firmware log lines
+are hidden unless --all-logs is given. The debug log WebSocket is enabled
+on the device while dev runs and restored on exit. A device with a password
+is logged in with its configured credentials; if the login is refused, the
+log is not followed.
+
+Press Ctrl+C to stop.`,
+		Example: `  # Develop script 1 from main.js
+  shelly script dev living-room 1 main.js
+
+  # Include firmware log lines
+  shelly script dev living-room 1 main.js --all-logs
+
+  # Only upload and restart on change, without log streaming
+  shelly script dev living-room 1 main.js --no-logs`,
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: completion.DeviceThenScriptID(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid script ID: %s", args[1])
+			}
+			opts.Device = args[0]
+			opts.ID = id
+			opts.File = args[2]
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.ChunkSize, "chunk-size", scriptdev.DefaultChunkSize, "Bytes uploaded per request")
+	cmd.Flags().DurationVar(&opts.Interval, "interval", scriptdev.DefaultWatchInterval, "How often to check the file for changes")
+	cmd.Flags().BoolVar(&opts.AllLogs, "all-logs", false, "Show firmware log lines too")
+	cmd.Flags().BoolVar(&opts.NoLogs, "no-logs", false, "Do not stream the device debug log")
+
+	return cmd
+}
+
+func run(ctx context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	resolveCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	resolved, err := svc.ResolveWithGeneration(resolveCtx, opts.Device)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to resolve device: %w", err)
+	}
+	if resolved.Generation < 2 {
+		return fmt.Errorf("scripts are only supported on Gen2+ devices (this is Gen%d)", resolved.Generation)
+	}
+
+	var source atomic.Pointer[scriptdev.Source]
+	if !opts.NoLogs {
+		restore, err := enableDebugLog(ctx, opts)
+		if err != nil {
+			return err
+		}
+		defer restore()
+		go followLog(ctx, opts, scriptdev.LogURL(resolved.Address), resolved.Auth, &source)
+	}
+
+	ios.Info("Watching %s (press Ctrl+C to stop)...", opts.File)
+	scriptdev.Watch(ctx, opts.File, opts.Interval, func(data []byte) {
+		code := string(data)
+		source.Store(scriptdev.NewSource(opts.File, code))
+		deploy(ctx, opts, code)
+	}, func(err error) {
+		ios.Warning("Cannot read %s: %v", opts.File, err)
+	})
+
+	ios.Println()
+	ios.Info("Stopped")
+	return nil
+}
+
+// deploy stops the script, uploads code and starts it again, then reports
+// any errors the device records for it.
+func deploy(ctx context.Context, opts *Options, code string) {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.AutomationService()
+
+	ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+
+	if err := svc.StopScript(ctx, opts.Device, opts.ID); err != nil {
+		ios.DebugErr("stop script", err)
+	}
+	chunks, err := svc.UploadScriptCode(ctx, opts.Device, opts.ID, code, opts.ChunkSize)
+	if err != nil {
+		ios.Error("Upload failed: %v", err)
+		return
+	}
+	if err := svc.StartScript(ctx, opts.Device, opts.ID); err != nil {
+		ios.Error("Uploaded %d bytes, but the script did not start: %v", len(code), err)
+		return
+	}
+	ios.Success("Uploaded %d bytes in %d chunk(s) and restarted script %d", len(code), chunks, opts.ID)
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(statusDelay):
+	}
+	status, err := svc.GetScriptStatus(ctx, opts.Device, opts.ID)
+	if err != nil {
+		ios.DebugErr("get script status", err)
+		return
+	}
+	for _, e := range status.Errors {
+		ios.Error("Script error: %s", e)
+	}
+	if !status.Running && len(status.Errors) == 0 {
+		ios.Warning("Script %d is not running", opts.ID)
+	}
+}
+
+// enableDebugLog turns on the device debug log WebSocket and returns a
+// function that restores the previous setting.
+func enableDebugLog(ctx context.Context, opts *Options) (func(), error) {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	cfgCtx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
+	enabled, err := svc.GetSysDebugWebSocket(cfgCtx, opts.Device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read debug log config: %w", err)
+	}
+	if enabled {
+		return func() {}, nil
+	}
+	if err := svc.SetSysDebugWebSocket(cfgCtx, opts.Device, true); err != nil {
+		return nil, fmt.Errorf("failed to enable debug log: %w", err)
+	}
+	ios.Info("Enabled the debug log WebSocket on %s", opts.Device)
+
+	return func() {
+		restoreCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rpcTimeout)
+		defer cancel()
+		if err := svc.SetSysDebugWebSocket(restoreCtx, opts.Device, false); err != nil {
+			ios.Warning("Could not disable the debug log WebSocket: %v", err)
+		}
+	}, nil
+}
+
+// followLog streams the debug log until ctx is done, reconnecting when the
+// connection drops. A failure is shown once until the log connects again;
+// a rejected login ends the stream.
+func followLog(ctx context.Context, opts *Options, wsURL string, auth *model.Auth, source *atomic.Pointer[scriptdev.Source]) {
+	ios := opts.Factory.IOStreams()
+	connected := false
+	handle := func(e scriptdev.LogEntry) {
+		connected = true
+		if !opts.AllLogs && e.IsFirmware() {
+			return
+		}
+		var loc *scriptdev.Location
+		if src := source.Load(); src != nil {
+			if l, ok := src.Locate(e.Text); ok {
+				loc = &l
+			}
+		}
+		term.DisplayScriptLogEntry(ios, e, loc)
+	}
+
+	warned := false
+	for {
+		err := scriptdev.StreamLog(ctx, wsURL, auth, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, scriptdev.ErrLogAuth) {
+			ios.Warning("Not following the device log: %v", err)
+			return
+		}
+		if connected {
+			connected, warned = false, false
+		}
+		if warned {
+			ios.DebugErr("debug log stream", err)
+		} else {
+			ios.Warning("Device log unavailable, retrying: %v", err)
+			warned = true
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package dev
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/mock"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "dev <device> <id> <file>" {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+	if cmd.Short == "" || cmd.Long == "" || cmd.Example == "" {
+		t.Error("help text is incomplete")
+	}
+	if cmd.ValidArgsFunction == nil {
+		t.Error("ValidArgsFunction should be set for completion")
+	}
+	for name, want := range map[string]string{"chunk-size": "1024", "interval": "500ms", "all-logs": "false", "no-logs": "false"} {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			t.Errorf("flag --%s not defined", name)
+			continue
+		}
+		if flag.DefValue != want {
+			t.Errorf("--%s default = %q, want %q", name, flag.DefValue, want)
+		}
+	}
+}
+
+func TestNewCommand_Args(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if err := cmd.Args(cmd, []string{"device", "1"}); err == nil {
+		t.Error("expected error with two args")
+	}
+	if err := cmd.Args(cmd, []string{"device", "1", "main.js"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := cmd.RunE(cmd, []string{"device", "one", "main.js"})
+	if err == nil || !strings.Contains(err.Error(), "invalid script ID") {
+		t.Errorf("RunE() error = %v, want invalid script ID", err)
+	}
+}
+
+func startDemo(t *testing.T, generation int) *factory.TestFactory {
+	t.Helper()
+	demo, err := mock.StartWithFixtures(&mock.Fixtures{
+		Config: mock.ConfigFixture{
+			Devices: []mock.DeviceFixture{{
+				Name:       "test-device",
+				Address:    "192.168.1.100",
+				MAC:        "AA:BB:CC:DD:EE:FF",
+				Model:      "SNSW-001P16EU",
+				Type:       "Plus1PM",
+				Generation: generation,
+			}},
+		},
+		DeviceStates: map[string]mock.DeviceState{"test-device": {}},
+	})
+	if err != nil {
+		t.Fatalf("failed to start demo: %v", err)
+	}
+	t.Cleanup(demo.Cleanup)
+
+	tf := factory.NewTestFactory(t)
+	demo.InjectIntoFactory(tf.Factory)
+	return tf
+}
+
+//nolint:paralleltest // Uses mock infrastructure with global state
+func TestRun_UploadsAndRestarts(t *testing.T) {
+	memFs := afero.NewMemMapFs()
+	config.SetFs(memFs)
+	defer config.SetFs(nil)
+	if err := afero.WriteFile(memFs, "/scripts/main.js", []byte(`print("hi");`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tf := startDemo(t, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err := run(ctx, &Options{
+		Factory:   tf.Factory,
+		Device:    "test-device",
+		ID:        1,
+		File:      "/scripts/main.js",
+		ChunkSize: 4,
+		NoLogs:    true,
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	out := tf.OutString() + tf.ErrString()
+	if !strings.Contains(out, "Uploaded 12 bytes in 3 chunk(s) and restarted script 1") {
+		t.Errorf("output = %q", out)
+	}
+}
+
+//nolint:paralleltest // Uses mock infrastructure with global state
+func TestRun_Gen1(t *testing.T) {
+	tf := startDemo(t, 1)
+
+	err := run(context.Background(), &Options{Factory: tf.Factory, Device: "test-device", ID: 1, File: "main.js", NoLogs: true})
+	if err == nil || !strings.Contains(err.Error(), "Gen2+") {
+		t.Errorf("run() error = %v, want Gen2+ error", err)
+	}
+}
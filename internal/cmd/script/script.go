@@ -4,8 +4,10 @@ package script
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/tj-smith47/shelly-cli/internal/cmd/script/bundle"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/script/create"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/script/deletecmd"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/script/dev"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/script/download"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/script/eval"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/script/get"
@@ -13,6 +15,7 @@ import (
 	"github.com/tj-smith47/shelly-cli/internal/cmd/script/start"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/script/stop"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/script/template"
+	"github.com/tj-smith47/shelly-cli/internal/cmd/script/test"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/script/update"
 	"github.com/tj-smith47/shelly-cli/internal/cmd/script/upload"
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
@@ -44,7 +47,16 @@ Note: Scripts are only available on Gen2+ devices.`,
   shelly script stop living-room 1
 
   # Evaluate code on a running script
-  shelly script eval living-room 1 "print('Hello!')"`,
+  shelly script eval living-room 1 "print('Hello!')"
+
+  # Live-reload a script from a local file and stream its output
+  shelly script dev living-room 1 main.js
+
+  # Bundle a script and its local modules, minified
+  shelly script bundle main.js --minify -o dist/main.js
+
+  # Run script tests offline against a simulated device
+  shelly script test tests/`,
 	}
 
 	cmd.AddCommand(list.NewCommand(f))
@@ -57,7 +69,10 @@ Note: Scripts are only available on Gen2+ devices.`,
 	cmd.AddCommand(eval.NewCommand(f))
 	cmd.AddCommand(upload.NewCommand(f))
 	cmd.AddCommand(download.NewCommand(f))
+	cmd.AddCommand(dev.NewCommand(f))
+	cmd.AddCommand(bundle.NewCommand(f))
 	cmd.AddCommand(template.NewCommand(f))
+	cmd.AddCommand(test.NewCommand(f))
 
 	return cmd
 }
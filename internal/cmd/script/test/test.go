@@ -0,0 +1,164 @@
+// Package test provides the script test subcommand.
+package test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptsim"
+)
+
+// Options holds the command options.
+type Options struct {
+	Factory    *cmdutil.Factory
+	Paths      []string
+	ShowOutput bool
+}
+
+// NewCommand creates the script test command.
+func NewCommand(f *cmdutil.Factory) *cobra.Command {
+	opts := &Options{Factory: f}
+
+	cmd := &cobra.Command{
+		Use:   "test <spec.test.yaml|dir>...",
+		Short: "Run script tests against a simulated device",
+		Long: `Run scripts offline against a simulated device and check what they do.
+
+Each test spec is a YAML file naming a script and a list of steps. The script
+runs in an embedded interpreter with stubs for Shelly.call, event and status
+handlers, Timer, MQTT, KVS (through KVS.* calls) and print. The device starts
+from the spec's state, optionally taken from a demo-mode fixtures file.
+
+Steps fire a component event ("input:0 single_push"), change a component's
+status ("switch:0" with a delta), deliver an MQTT message or wait for
+simulated time to pass. After a step, expect checks the RPC calls, prints,
+MQTT publishes and emitted events since the previous check, and the device
+state and KVS as they are now. Expected call params only need to match a
+subset of the actual ones.
+
+Directories are searched for *.test.yaml files. The command fails if any test
+fails, so it can run in CI without devices.
+
+  name: auto off
+  script: main.js
+  state:
+    "switch:0": {output: false}
+  steps:
+    - event: input:0 single_push
+      expect:
+        calls:
+          - method: Switch.Toggle
+            params: {id: 0}
+        state:
+          "switch:0": {output: true}
+    - wait: 60s
+      expect:
+        calls:
+          - method: Switch.Set
+            params: {id: 0, on: false}`,
+		Example: `  # Run one test
+  shelly script test tests/auto_off.test.yaml
+
+  # Run every test in a directory and show script output
+  shelly script test tests/ --show-output`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Paths = args
+			return run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.ShowOutput, "show-output", false, "Show what the scripts print")
+
+	return cmd
+}
+
+func run(_ context.Context, opts *Options) error {
+	ios := opts.Factory.IOStreams()
+
+	specs, err := expand(opts.Paths)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, path := range specs {
+		if !runOne(opts, path) {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d script test(s) failed", failed, len(specs))
+	}
+	ios.Success("%d script test(s) passed", len(specs))
+	return nil
+}
+
+// runOne runs one spec and reports whether it passed.
+func runOne(opts *Options, path string) bool {
+	ios := opts.Factory.IOStreams()
+
+	spec, err := scriptsim.LoadSpec(path)
+	if err != nil {
+		ios.Error("%s: %v", path, err)
+		return false
+	}
+	res, err := scriptsim.Run(spec)
+	if err != nil {
+		ios.Error("%s (%s): %v", spec.Name, path, err)
+		return false
+	}
+
+	if res.Passed() {
+		ios.Success("%s (%s)", res.Name, path)
+	} else {
+		ios.Error("%s (%s)", res.Name, path)
+		for _, f := range res.Failures {
+			ios.Errorf("    %s\n", f)
+		}
+	}
+	if opts.ShowOutput {
+		for _, line := range res.Output {
+			ios.Printf("    | %s\n", line)
+		}
+	}
+	return res.Passed()
+}
+
+// expand replaces directories with the test specs in them.
+func expand(paths []string) ([]string, error) {
+	fs := config.Fs()
+	var specs []string
+	for _, p := range paths {
+		info, err := fs.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		if !info.IsDir() {
+			specs = append(specs, p)
+			continue
+		}
+		var found []string
+		for _, pattern := range []string{"*.test.yaml", "*.test.yml"} {
+			matches, err := afero.Glob(fs, filepath.Join(p, pattern))
+			if err != nil {
+				return nil, err
+			}
+			found = append(found, matches...)
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("no *.test.yaml files in %s", p)
+		}
+		sort.Strings(found)
+		specs = append(specs, found...)
+	}
+	return specs, nil
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/factory"
+)
+
+func TestNewCommand(t *testing.T) {
+	t.Parallel()
+	cmd := NewCommand(cmdutil.NewFactory())
+
+	if cmd.Use != "test <spec.test.yaml|dir>..." {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+	if cmd.Short == "" || cmd.Long == "" || cmd.Example == "" {
+		t.Error("help text is incomplete")
+	}
+	if err := cmd.Args(cmd, []string{}); err == nil {
+		t.Error("expected error with no args")
+	}
+	flag := cmd.Flags().Lookup("show-output")
+	if flag == nil || flag.DefValue != "false" {
+		t.Errorf("--show-output flag = %+v", flag)
+	}
+}
+
+func setupFiles(t *testing.T) {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	config.SetFs(fs)
+	t.Cleanup(func() { config.SetFs(nil) })
+
+	files := map[string]string{
+		"/tests/main.js": "Shelly.addEventHandler(function (ev) {\n  print(\"pressed\", ev.component);\n  Shelly.call(\"Switch.Toggle\", {id: 0});\n});\n",
+		"/tests/toggle.test.yaml": `
+name: toggle
+script: main.js
+steps:
+  - event: input:0 single_push
+    expect:
+      calls:
+        - method: Switch.Toggle
+          params: {id: 0}
+      state:
+        "switch:0": {output: true}
+`,
+		"/tests/broken.yaml": `
+script: main.js
+steps:
+  - event: input:0 single_push
+    expect:
+      calls:
+        - method: Switch.Set
+`,
+	}
+	for name, content := range files {
+		if err := afero.WriteFile(fs, name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestRun_Directory(t *testing.T) {
+	setupFiles(t)
+	tf := factory.NewTestFactory(t)
+
+	err := run(context.Background(), &Options{Factory: tf.Factory, Paths: []string{"/tests"}, ShowOutput: true})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	out := tf.OutString()
+	for _, want := range []string{"toggle (/tests/toggle.test.yaml)", "| pressed input:0", "1 script test(s) passed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestRun_Failure(t *testing.T) {
+	setupFiles(t)
+	tf := factory.NewTestFactory(t)
+
+	err := run(context.Background(), &Options{Factory: tf.Factory, Paths: []string{"/tests/toggle.test.yaml", "/tests/broken.yaml"}})
+	if err == nil || err.Error() != "1 of 2 script test(s) failed" {
+		t.Fatalf("run() error = %v", err)
+	}
+	if errOut := tf.ErrString(); !strings.Contains(errOut, "broken (/tests/broken.yaml)") ||
+		!strings.Contains(errOut, "step 1: expected call Switch.Set") {
+		t.Errorf("unexpected stderr:\n%s", errOut)
+	}
+	if strings.Contains(tf.OutString(), "pressed") {
+		t.Error("script output shown without --show-output")
+	}
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestRun_NoSpecs(t *testing.T) {
+	setupFiles(t)
+	tf := factory.NewTestFactory(t)
+
+	if err := afero.WriteFile(config.Fs(), "/empty/readme.md", []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := run(context.Background(), &Options{Factory: tf.Factory, Paths: []string{"/empty"}})
+	if err == nil || !strings.Contains(err.Error(), "no *.test.yaml files") {
+		t.Errorf("run() error = %v", err)
+	}
+	err = run(context.Background(), &Options{Factory: tf.Factory, Paths: []string{"/missing.test.yaml"}})
+	if err == nil || !strings.Contains(err.Error(), "failed to read") {
+		t.Errorf("run() error = %v", err)
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
 	"github.com/tj-smith47/shelly-cli/internal/completion"
 	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptbundle"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptdev"
 )
 
 // Options holds the command options.
@@ -21,6 +23,13 @@ type Options struct {
 	ID      int
 	File    string
 	Append  bool
+	Bundle  bool
+	Minify  bool
+	MaxSize int
+	Allow   []string
+	// MaxSizeSet is true when --max-size was given. Otherwise the size
+	// limit of the device is used when it is known.
+	MaxSizeSet bool
 }
 
 // NewCommand creates the script upload command.
@@ -33,12 +42,24 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 		Short:   "Upload script from file",
 		Long: `Upload script code to a device from a file.
 
-By default, replaces the existing code. Use --append to add to existing code.`,
+By default, replaces the existing code. Use --append to add to existing code.
+
+Use --bundle to include the local modules the file imports, as done by
+"shelly script bundle", and --minify to make the upload smaller. Bundles
+larger than the script size limit of the device are not uploaded;
+--max-size sets the limit explicitly. Use --allow to skip a bundle check,
+as with "shelly script bundle".`,
 		Example: `  # Upload script from file
   shelly script upload living-room 1 script.js
 
   # Append code from file
-  shelly script upload living-room 1 additions.js --append`,
+  shelly script upload living-room 1 additions.js --append
+
+  # Bundle local modules and minify before uploading
+  shelly script upload living-room 1 main.js --bundle --minify
+
+  # Bundle a script that uses classes
+  shelly script upload living-room 1 main.js --bundle --allow class`,
 		Args:              cobra.ExactArgs(3),
 		ValidArgsFunction: completion.DeviceThenScriptID(),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -49,11 +70,16 @@ By default, replaces the existing code. Use --append to add to existing code.`,
 			opts.Device = args[0]
 			opts.ID = id
 			opts.File = args[2]
+			opts.MaxSizeSet = cmd.Flags().Changed("max-size")
 			return run(cmd.Context(), opts)
 		},
 	}
 
 	cmd.Flags().BoolVar(&opts.Append, "append", false, "Append to existing code")
+	cmd.Flags().BoolVar(&opts.Bundle, "bundle", false, "Bundle local modules the file imports")
+	cmd.Flags().BoolVar(&opts.Minify, "minify", false, "Minify the bundle (implies --bundle)")
+	cmd.Flags().IntVar(&opts.MaxSize, "max-size", scriptbundle.DefaultMaxSize, "Bundle size limit in bytes, instead of the device limit (0 for no limit)")
+	cmd.Flags().StringArrayVar(&opts.Allow, "allow", nil, "Skip a bundle check rule (class, async, promise, generator, module, destructuring, accessor)")
 
 	return cmd
 }
@@ -65,24 +91,57 @@ func run(ctx context.Context, opts *Options) error {
 	ios := opts.Factory.IOStreams()
 	svc := opts.Factory.AutomationService()
 
-	// Read file
-	data, err := afero.ReadFile(config.Fs(), opts.File)
+	if (opts.Bundle || opts.Minify) && !opts.MaxSizeSet {
+		limit, err := svc.ScriptSizeLimit(ctx, opts.Device)
+		if err != nil {
+			return fmt.Errorf("failed to read device script size limit: %w", err)
+		}
+		if limit > 0 {
+			opts.MaxSize = limit
+		}
+	}
+
+	code, err := readCode(opts)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return err
 	}
-	code := string(data)
 
 	err = cmdutil.RunWithSpinner(ctx, ios, "Uploading script...", func(ctx context.Context) error {
-		if uploadErr := svc.UpdateScriptCode(ctx, opts.Device, opts.ID, code, opts.Append); uploadErr != nil {
-			return fmt.Errorf("failed to upload script: %w", uploadErr)
-		}
-
 		if opts.Append {
+			if uploadErr := svc.UpdateScriptCode(ctx, opts.Device, opts.ID, code, true); uploadErr != nil {
+				return fmt.Errorf("failed to upload script: %w", uploadErr)
+			}
 			ios.Success("Appended %d bytes to script %d", len(code), opts.ID)
-		} else {
-			ios.Success("Uploaded %d bytes to script %d", len(code), opts.ID)
+			return nil
 		}
+
+		// Send the code in chunks so a large script does not overflow the
+		// device's RPC buffer.
+		if _, uploadErr := svc.UploadScriptCode(ctx, opts.Device, opts.ID, code, scriptdev.DefaultChunkSize); uploadErr != nil {
+			return fmt.Errorf("failed to upload script: %w", uploadErr)
+		}
+		ios.Success("Uploaded %d bytes to script %d", len(code), opts.ID)
 		return nil
 	})
 	return err
 }
+
+// readCode returns the code to upload: the file itself, or its bundle.
+func readCode(opts *Options) (string, error) {
+	if !opts.Bundle && !opts.Minify {
+		data, err := afero.ReadFile(config.Fs(), opts.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		return string(data), nil
+	}
+
+	res, err := scriptbundle.Bundle(opts.File, scriptbundle.Options{Minify: opts.Minify, Allow: opts.Allow})
+	if err != nil {
+		return "", err
+	}
+	if err := scriptbundle.CheckSize(res.Size, opts.MaxSize); err != nil {
+		return "", err
+	}
+	return res.Code, nil
+}
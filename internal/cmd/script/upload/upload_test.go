@@ -476,3 +476,123 @@ func TestNewCommand_ExecuteWithAppend(t *testing.T) {
 		t.Errorf("output should contain 'Appended', got: %q", output)
 	}
 }
+
+func TestNewCommand_BundleFlags(t *testing.T) {
+	t.Parallel()
+
+	cmd := NewCommand(cmdutil.NewFactory())
+	for name, want := range map[string]string{"bundle": "false", "minify": "false", "max-size": "16384", "allow": "[]"} {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			t.Errorf("--%s flag not found", name)
+			continue
+		}
+		if flag.DefValue != want {
+			t.Errorf("--%s default = %q, want %q", name, flag.DefValue, want)
+		}
+	}
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestReadCode_Bundle(t *testing.T) {
+	memFs := afero.NewMemMapFs()
+	config.SetFs(memFs)
+	defer config.SetFs(nil)
+
+	files := map[string]string{
+		"/scripts/main.js":  "import { greet } from \"./greet\";\ngreet(\"world\");\n",
+		"/scripts/greet.js": "export function greet(name) {\n  print(\"Hello \" + name);\n}\n",
+	}
+	for name, content := range files {
+		if err := afero.WriteFile(memFs, name, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	code, err := readCode(&Options{File: "/scripts/main.js"})
+	if err != nil || !strings.Contains(code, "import") {
+		t.Errorf("readCode() without --bundle = %q, %v; want file as is", code, err)
+	}
+
+	code, err = readCode(&Options{File: "/scripts/main.js", Bundle: true, MaxSize: 16384})
+	if err != nil {
+		t.Fatalf("readCode() error = %v", err)
+	}
+	if strings.Contains(code, "import") || !strings.Contains(code, "let greet = __greet.greet;") {
+		t.Errorf("readCode() did not bundle:\n%s", code)
+	}
+
+	if _, err := readCode(&Options{File: "/scripts/main.js", Minify: true, MaxSize: 20}); err == nil {
+		t.Error("expected error for bundle over the size limit")
+	}
+}
+
+//nolint:paralleltest // Uses mock infrastructure with global state
+func TestRun_BundleDeviceSizeLimit(t *testing.T) {
+	memFs := afero.NewMemMapFs()
+	config.SetFs(memFs)
+	defer config.SetFs(nil)
+
+	if err := afero.WriteFile(memFs, "/scripts/main.js", []byte("print(\"Hello World!\");\n"), 0o644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	fixtures := &mock.Fixtures{
+		Config: mock.ConfigFixture{
+			Devices: []mock.DeviceFixture{
+				{
+					Name:       "test-device",
+					Address:    "192.168.1.100",
+					MAC:        "AA:BB:CC:DD:EE:FF",
+					Model:      "SNSW-001P16EU",
+					Type:       "Plus1PM",
+					Generation: 2,
+				},
+			},
+		},
+		DeviceStates: map[string]mock.DeviceState{
+			"test-device": {},
+		},
+	}
+
+	demo, err := mock.StartWithFixtures(fixtures)
+	if err != nil {
+		t.Fatalf("failed to start demo: %v", err)
+	}
+	defer demo.Cleanup()
+
+	tf := factory.NewTestFactory(t)
+	demo.InjectIntoFactory(tf.Factory)
+
+	// Without --max-size, the device limit replaces the small default.
+	opts := &Options{Factory: tf.Factory, Device: "test-device", ID: 1, File: "/scripts/main.js", Bundle: true, MaxSize: 10}
+	if err := run(context.Background(), opts); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if opts.MaxSize != 16384 {
+		t.Errorf("MaxSize = %d, want device limit 16384", opts.MaxSize)
+	}
+
+	opts = &Options{Factory: tf.Factory, Device: "test-device", ID: 1, File: "/scripts/main.js", Bundle: true, MaxSize: 10, MaxSizeSet: true}
+	if err := run(context.Background(), opts); err == nil {
+		t.Error("expected error for bundle over an explicit --max-size")
+	}
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestReadCode_Allow(t *testing.T) {
+	memFs := afero.NewMemMapFs()
+	config.SetFs(memFs)
+	defer config.SetFs(nil)
+
+	if err := afero.WriteFile(memFs, "/scripts/main.js", []byte("class Lamp {}\n"), 0o644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	if _, err := readCode(&Options{File: "/scripts/main.js", Bundle: true}); err == nil {
+		t.Error("expected check error for class")
+	}
+	if _, err := readCode(&Options{File: "/scripts/main.js", Bundle: true, Allow: []string{"class"}}); err != nil {
+		t.Errorf("readCode() with --allow class error = %v", err)
+	}
+}
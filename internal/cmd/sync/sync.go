@@ -4,14 +4,17 @@ package sync
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/spf13/cobra"
 
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil/flags"
 	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
 	"github.com/tj-smith47/shelly-cli/internal/shelly"
 	"github.com/tj-smith47/shelly-cli/internal/term"
+	"github.com/tj-smith47/shelly-cli/internal/utils"
 )
 
 // commandUse is the command's Use string and primary name.
@@ -22,6 +25,8 @@ type Options struct {
 	Factory *cmdutil.Factory
 	Devices []string
 	DryRun  bool
+	Merge   bool
+	Prefer  string
 	Pull    bool
 	Push    bool
 }
@@ -37,10 +42,16 @@ func NewCommand(f *cmdutil.Factory) *cobra.Command {
 		Long: `Synchronize device configurations between local storage and devices.
 
 Operations:
-  --pull  Download device configs to local storage
-  --push  Upload local configs to devices
-
-Configurations are stored in the CLI config directory.`,
+  --pull   Download device configs to local storage
+  --push   Upload local configs to devices
+  --merge  Merge local edits and device changes in both directions
+
+Configurations are stored in the CLI config directory. Every sync records the
+config both sides agreed on as a base. --merge compares the base, the local
+file and the live device: keys changed on one side only are merged
+automatically, and keys changed differently on both sides are conflicts.
+Conflicts are resolved with --prefer device|local, prompted for on a terminal,
+or reported and left unsynced otherwise.`,
 		Example: `  # Pull all device configs to local storage
   shelly sync --pull
 
@@ -48,7 +59,13 @@ Configurations are stored in the CLI config directory.`,
   shelly sync --push --device kitchen-light
 
   # Preview sync without making changes
-  shelly sync --pull --dry-run`,
+  shelly sync --pull --dry-run
+
+  # Merge local edits with changes made in the device web UI
+  shelly sync --merge
+
+  # Merge, keeping local values where both sides changed
+  shelly sync --merge --prefer local`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return run(cmd.Context(), opts)
 		},
@@ -56,21 +73,39 @@ Configurations are stored in the CLI config directory.`,
 
 	cmd.Flags().BoolVar(&opts.Push, "push", false, "Push local configs to devices")
 	cmd.Flags().BoolVar(&opts.Pull, "pull", false, "Pull device configs to local storage")
+	cmd.Flags().BoolVar(&opts.Merge, "merge", false, "Three-way merge local configs and devices")
+	cmd.Flags().StringVar(&opts.Prefer, "prefer", "", "Resolve merge conflicts: device, local")
 	flags.AddDryRunFlag(cmd, &opts.DryRun)
 	cmd.Flags().StringSliceVar(&opts.Devices, "device", nil, "Specific devices to sync (default: all)")
 
+	utils.Must(cmd.RegisterFlagCompletionFunc("prefer", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return []string{string(shelly.SyncPreferDevice), string(shelly.SyncPreferLocal)}, cobra.ShellCompDirectiveNoFileComp
+	}))
+
 	return cmd
 }
 
 func run(ctx context.Context, opts *Options) error {
-	if !opts.Push && !opts.Pull {
-		return fmt.Errorf("specify --push or --pull")
+	if opts.Merge && (opts.Push || opts.Pull) {
+		return fmt.Errorf("cannot use --merge with --push or --pull")
+	}
+
+	if !opts.Push && !opts.Pull && !opts.Merge {
+		return fmt.Errorf("specify --push or --pull (or --merge)")
 	}
 
 	if opts.Push && opts.Pull {
 		return fmt.Errorf("cannot use --push and --pull together")
 	}
 
+	prefer, err := shelly.ParseSyncPrefer(opts.Prefer)
+	if err != nil {
+		return err
+	}
+	if prefer != shelly.SyncPreferNone && !opts.Merge {
+		return fmt.Errorf("--prefer requires --merge")
+	}
+
 	ios := opts.Factory.IOStreams()
 	svc := opts.Factory.ShellyService()
 
@@ -84,7 +119,7 @@ func run(ctx context.Context, opts *Options) error {
 		term.DisplaySyncProgress(ios, result.Device, result.Status)
 	}
 
-	if opts.Pull {
+	if opts.Pull || opts.Merge {
 		cfg, err := opts.Factory.Config()
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
@@ -95,6 +130,7 @@ func run(ctx context.Context, opts *Options) error {
 			for name := range cfg.Devices {
 				devices = append(devices, name)
 			}
+			slices.Sort(devices)
 		}
 
 		if len(devices) == 0 {
@@ -102,6 +138,10 @@ func run(ctx context.Context, opts *Options) error {
 			return nil
 		}
 
+		if opts.Merge {
+			return runMerge(ctx, opts, syncDir, devices, prefer)
+		}
+
 		term.DisplaySyncHeader(ios, "Pulling", len(devices), opts.DryRun)
 		success, failed := svc.PullDeviceConfigs(ctx, devices, syncDir, opts.DryRun, progress)
 		term.DisplaySyncSummary(ios, success, failed, opts.DryRun, syncDir)
@@ -117,3 +157,92 @@ func run(ctx context.Context, opts *Options) error {
 	term.DisplayPushSummary(ios, success, failed, skipped)
 	return nil
 }
+
+// runMerge three-way merges each device in turn so conflicts can be prompted
+// for one device at a time.
+func runMerge(ctx context.Context, opts *Options, syncDir string, devices []string, prefer shelly.SyncPrefer) error {
+	ios := opts.Factory.IOStreams()
+	svc := opts.Factory.ShellyService()
+
+	term.DisplaySyncHeader(ios, "Merging", len(devices), opts.DryRun)
+
+	var synced, unchanged, conflicted, failed int
+	for _, device := range devices {
+		devCtx, cancel := context.WithTimeout(ctx, shelly.DefaultTimeout)
+		m, err := svc.MergeDeviceConfig(devCtx, syncDir, device)
+		cancel()
+		if err != nil {
+			term.DisplaySyncProgress(ios, device, fmt.Sprintf("failed (%v)", err))
+			failed++
+			continue
+		}
+
+		if prefer != shelly.SyncPreferNone {
+			m.ResolveAll(prefer)
+		}
+		term.DisplaySyncMerge(ios, m)
+		if !m.Changed() && m.HasBase {
+			unchanged++
+			continue
+		}
+
+		if m.Unresolved() > 0 && !opts.DryRun {
+			if err := resolveConflicts(ios, m); err != nil {
+				return err
+			}
+		}
+		if m.Unresolved() > 0 {
+			conflicted++
+			continue
+		}
+		if opts.DryRun {
+			synced++
+			continue
+		}
+
+		devCtx, cancel = context.WithTimeout(ctx, shelly.DefaultTimeout)
+		err = svc.ApplySyncMerge(devCtx, syncDir, m)
+		cancel()
+		if err != nil {
+			term.DisplaySyncProgress(ios, device, fmt.Sprintf("failed (%v)", err))
+			failed++
+			continue
+		}
+		synced++
+	}
+
+	term.DisplayMergeSummary(ios, synced, unchanged, conflicted, failed, opts.DryRun)
+	if conflicted > 0 || failed > 0 {
+		return fmt.Errorf("%d device(s) not synced", conflicted+failed)
+	}
+	return nil
+}
+
+// resolveConflicts prompts for each unresolved conflict. Choosing to skip
+// leaves the remaining conflicts unresolved so the device is not synced.
+func resolveConflicts(ios *iostreams.IOStreams, m *shelly.SyncMerge) error {
+	if !ios.CanPrompt() {
+		return nil
+	}
+	for i := range m.Conflicts {
+		c := &m.Conflicts[i]
+		options := []string{
+			"local:  " + term.FormatSyncValue(c.Local),
+			"device: " + term.FormatSyncValue(c.Device),
+			"skip " + m.Device,
+		}
+		choice, err := ios.Select(fmt.Sprintf("%s %s changed on both sides", m.Device, c.Key()), options, 0)
+		if err != nil {
+			return err
+		}
+		switch slices.Index(options, choice) {
+		case 0:
+			c.Resolution = shelly.SyncPreferLocal
+		case 1:
+			c.Resolution = shelly.SyncPreferDevice
+		default:
+			return nil
+		}
+	}
+	return nil
+}
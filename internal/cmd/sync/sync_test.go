@@ -12,6 +12,7 @@ import (
 	"github.com/tj-smith47/shelly-cli/internal/cmdutil"
 	"github.com/tj-smith47/shelly-cli/internal/config"
 	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
 	"github.com/tj-smith47/shelly-cli/internal/term"
 	"github.com/tj-smith47/shelly-cli/internal/testutil"
 )
@@ -486,6 +487,8 @@ func TestNewCommand_AllFlagsExist(t *testing.T) {
 		"pull",
 		"dry-run",
 		"device",
+		"merge",
+		"prefer",
 	}
 
 	for _, flag := range requiredFlags {
@@ -506,3 +509,73 @@ func TestNewCommand_Args(t *testing.T) {
 		t.Errorf("Use = %q, want %q", cmd.Use, "sync")
 	}
 }
+
+// TestRun_MergeFlagErrors tests invalid --merge and --prefer combinations.
+func TestRun_MergeFlagErrors(t *testing.T) {
+	config.SetFs(afero.NewMemMapFs())
+	t.Cleanup(func() { config.SetFs(nil) })
+	t.Setenv("XDG_CONFIG_HOME", "/test/config")
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"merge with push", Options{Merge: true, Push: true}, "cannot use --merge"},
+		{"invalid prefer", Options{Merge: true, Prefer: "newest"}, "invalid preference"},
+		{"prefer without merge", Options{Pull: true, Prefer: "local"}, "--prefer requires --merge"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr testutil.SafeBuffer
+			opts := tt.opts
+			opts.Factory = cmdutil.NewWithIOStreams(iostreams.Test(nil, &stdout, &stderr))
+
+			err := run(context.Background(), &opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestRun_MergeNoDevices tests merge with no configured devices.
+func TestRun_MergeNoDevices(t *testing.T) {
+	config.SetFs(afero.NewMemMapFs())
+	t.Cleanup(func() { config.SetFs(nil) })
+	t.Setenv("XDG_CONFIG_HOME", "/test/config")
+
+	var stdout, stderr testutil.SafeBuffer
+	ios := iostreams.Test(nil, &stdout, &stderr)
+	tf := cmdutil.NewWithIOStreams(ios)
+	tf.SetConfigManager(config.NewTestManager(&config.Config{}))
+
+	if err := run(context.Background(), &Options{Factory: tf, Merge: true}); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+	if !strings.Contains(stderr.String()+stdout.String(), "No devices configured") {
+		t.Errorf("output = %q, want no devices warning", stderr.String())
+	}
+}
+
+// TestDisplaySyncMerge tests the merge change listing.
+func TestDisplaySyncMerge(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+	ios := iostreams.Test(nil, &stdout, &stderr)
+
+	m := shelly.MergeSyncConfigs("den",
+		map[string]any{"switch:0": map[string]any{"name": "Lamp", "auto_off": false}},
+		map[string]any{"switch:0": map[string]any{"name": "Desk", "auto_off": true}},
+		map[string]any{"switch:0": map[string]any{"name": "Reading", "auto_off": false}},
+	)
+	term.DisplaySyncMerge(ios, m)
+
+	output := stdout.String()
+	for _, want := range []string{"den", "switch:0.auto_off", "conflict", `"Reading"`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package cmdutil
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rollout"
+	"github.com/tj-smith47/shelly-cli/internal/term"
+)
+
+// RunRollout runs a firmware rollout, printing progress as devices are
+// updated and the rollout state when it finishes or halts. The rollout
+// start and resume commands share it. An interrupted rollout keeps its
+// state file so it can be resumed.
+func RunRollout(ctx context.Context, f *Factory, state *config.RolloutState) error {
+	ios := f.IOStreams()
+
+	err := f.ShellyService().RunRollout(ctx, state, func(e rollout.Event) {
+		term.DisplayRolloutEvent(ios, e)
+	})
+	if ctx.Err() != nil {
+		ios.Warning("Rollout interrupted. Run 'shelly firmware rollout resume' to continue.")
+		return ctx.Err()
+	}
+	if err != nil && !errors.Is(err, rollout.ErrHalted) {
+		return err
+	}
+
+	ios.Println("")
+	term.DisplayRolloutState(ios, state)
+	return err
+}
@@ -221,6 +221,21 @@ func SceneNames() func(*cobra.Command, []string, string) ([]string, cobra.ShellC
 	}
 }
 
+// RuleNames returns a completion function for rule names.
+func RuleNames() func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		rules := config.ListRules()
+
+		var completions []string
+		for name := range rules {
+			if strings.HasPrefix(name, toComplete) {
+				completions = append(completions, name)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
 // OutputFormats returns a completion function for output format options.
 func OutputFormats() func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
 	return func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
@@ -639,6 +654,17 @@ func NameThenDevice() func(*cobra.Command, []string, string) ([]string, cobra.Sh
 	}
 }
 
+// NameThenDevices returns a completion function that skips completion
+// for the first arg (user-provided name) and completes device names for the rest.
+func NameThenDevices() func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return deviceNamesFiltered(toComplete)
+	}
+}
+
 // SettingKeys returns a completion function for CLI setting keys.
 func SettingKeys() func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
 	return func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
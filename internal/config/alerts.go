@@ -7,14 +7,16 @@ import (
 
 // Alert represents a monitoring alert configuration.
 type Alert struct {
-	Name         string `mapstructure:"name" json:"name" yaml:"name"`
-	Description  string `mapstructure:"description,omitempty" json:"description,omitempty" yaml:"description,omitempty"`
-	Device       string `mapstructure:"device" json:"device" yaml:"device"`
-	Condition    string `mapstructure:"condition" json:"condition" yaml:"condition"` // e.g., "offline", "power>100", "temperature>30"
-	Action       string `mapstructure:"action" json:"action" yaml:"action"`          // e.g., "notify", "webhook:http://...", "command:..."
-	Enabled      bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
-	SnoozedUntil string `mapstructure:"snoozed_until,omitempty" json:"snoozed_until,omitempty" yaml:"snoozed_until,omitempty"`
-	CreatedAt    string `mapstructure:"created_at" json:"created_at" yaml:"created_at"`
+	Name           string `mapstructure:"name" json:"name" yaml:"name"`
+	Description    string `mapstructure:"description,omitempty" json:"description,omitempty" yaml:"description,omitempty"`
+	Device         string `mapstructure:"device" json:"device" yaml:"device"`
+	Condition      string `mapstructure:"condition" json:"condition" yaml:"condition"` // e.g., "offline", "power>100", "temperature>30"
+	Action         string `mapstructure:"action" json:"action" yaml:"action"`          // e.g., "notify", "webhook:http://...", "command:..."
+	Enabled        bool   `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	RepeatInterval string `mapstructure:"repeat_interval,omitempty" json:"repeat_interval,omitempty" yaml:"repeat_interval,omitempty"` // e.g., "1h"; re-notify while firing
+	NotifyResolved bool   `mapstructure:"notify_resolved,omitempty" json:"notify_resolved,omitempty" yaml:"notify_resolved,omitempty"`
+	SnoozedUntil   string `mapstructure:"snoozed_until,omitempty" json:"snoozed_until,omitempty" yaml:"snoozed_until,omitempty"`
+	CreatedAt      string `mapstructure:"created_at" json:"created_at" yaml:"created_at"`
 }
 
 // IsSnoozed returns true if the alert is currently snoozed.
@@ -31,6 +33,19 @@ func (a Alert) IsSnoozed() bool {
 	return time.Now().Before(snoozedUntil)
 }
 
+// RepeatDuration returns the parsed repeat interval, or 0 when reminders are
+// disabled or the interval is invalid.
+func (a Alert) RepeatDuration() time.Duration {
+	if a.RepeatInterval == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(a.RepeatInterval)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
 // Package-level functions delegate to the default manager.
 
 // CreateAlert creates a new alert.
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// AlertLifecycle is the lifecycle state of an alert.
+type AlertLifecycle string
+
+// Alert lifecycle states.
+const (
+	// AlertStateOK means the condition is not met.
+	AlertStateOK AlertLifecycle = "ok"
+	// AlertStatePending means the condition matched but a "for" window has not elapsed.
+	AlertStatePending AlertLifecycle = "pending"
+	// AlertStateFiring means the condition holds and the alert has notified.
+	AlertStateFiring AlertLifecycle = "firing"
+	// AlertStateResolved means a firing alert's condition has cleared.
+	AlertStateResolved AlertLifecycle = "resolved"
+)
+
+// maxAlertHistory caps the number of entries kept in the alert history file.
+const maxAlertHistory = 5000
+
+// AlertRecord is the persisted lifecycle state of a single alert.
+type AlertRecord struct {
+	State        AlertLifecycle `json:"state"`
+	Since        time.Time      `json:"since"`
+	LastNotified time.Time      `json:"last_notified,omitzero"`
+	LastValue    string         `json:"last_value,omitempty"`
+	Condition    string         `json:"condition,omitempty"`
+}
+
+// AlertHistoryEntry records one alert lifecycle transition.
+type AlertHistoryEntry struct {
+	Time       time.Time      `json:"time"`
+	Alert      string         `json:"alert"`
+	Device     string         `json:"device"`
+	Condition  string         `json:"condition"`
+	Transition string         `json:"transition"` // pending, firing, repeat, resolved
+	State      AlertLifecycle `json:"state"`
+	Value      string         `json:"value,omitempty"`
+}
+
+// LoadAlertStates returns the persisted alert lifecycle states.
+func LoadAlertStates() (map[string]AlertRecord, error) {
+	return getDefaultManager().LoadAlertStates()
+}
+
+// SaveAlertStates persists alert lifecycle states.
+func SaveAlertStates(states map[string]AlertRecord) error {
+	return getDefaultManager().SaveAlertStates(states)
+}
+
+// AppendAlertHistory appends entries to the alert history file.
+func AppendAlertHistory(entries ...AlertHistoryEntry) error {
+	return getDefaultManager().AppendAlertHistory(entries...)
+}
+
+// AlertHistory returns the most recent alert history entries, oldest first.
+func AlertHistory(limit int) ([]AlertHistoryEntry, error) {
+	return getDefaultManager().AlertHistory(limit)
+}
+
+// =============================================================================
+// Manager Alert State Methods
+// =============================================================================
+
+// alertsDir returns the directory holding alert state and history, next to
+// the config file. In-memory managers (no path) have no alert files.
+func (m *Manager) alertsDir() string {
+	if m.path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(m.path), "alerts")
+}
+
+// LoadAlertStates returns the persisted alert lifecycle states.
+func (m *Manager) LoadAlertStates() (map[string]AlertRecord, error) {
+	states := make(map[string]AlertRecord)
+	dir := m.alertsDir()
+	if dir == "" {
+		return states, nil
+	}
+
+	data, err := afero.ReadFile(m.Fs(), filepath.Join(dir, "state.json"))
+	if errors.Is(err, iofs.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read alert state: %w", err)
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("parse alert state: %w", err)
+	}
+	return states, nil
+}
+
+// SaveAlertStates persists alert lifecycle states.
+func (m *Manager) SaveAlertStates(states map[string]AlertRecord) error {
+	dir := m.alertsDir()
+	if dir == "" {
+		return nil
+	}
+	if err := m.Fs().MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create alerts directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal alert state: %w", err)
+	}
+	if err := afero.WriteFile(m.Fs(), filepath.Join(dir, "state.json"), data, 0o600); err != nil {
+		return fmt.Errorf("write alert state: %w", err)
+	}
+	return nil
+}
+
+// AppendAlertHistory appends entries to the alert history file, trimming the
+// oldest entries once the file exceeds its cap.
+func (m *Manager) AppendAlertHistory(entries ...AlertHistoryEntry) error {
+	dir := m.alertsDir()
+	if dir == "" || len(entries) == 0 {
+		return nil
+	}
+	if err := m.Fs().MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create alerts directory: %w", err)
+	}
+	if err := appendJSONLines(m.Fs(), filepath.Join(dir, "history.jsonl"), maxAlertHistory, entries); err != nil {
+		return fmt.Errorf("write alert history: %w", err)
+	}
+	return nil
+}
+
+// AlertHistory returns the most recent alert history entries, oldest first.
+// A limit of 0 returns all entries.
+func (m *Manager) AlertHistory(limit int) ([]AlertHistoryEntry, error) {
+	dir := m.alertsDir()
+	if dir == "" {
+		return nil, nil
+	}
+
+	entries, err := readJSONLines[AlertHistoryEntry](m.Fs(), filepath.Join(dir, "history.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("read alert history: %w", err)
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// appendJSONLines appends entries to a JSON-lines file, then rewrites it
+// keeping only the newest limit entries once it grows past that.
+func appendJSONLines[T any](fs afero.Fs, path string, limit int, entries []T) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+
+	f, err := fs.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, werr := f.Write(buf.Bytes())
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		return werr
+	}
+
+	all, err := readJSONLines[T](fs, path)
+	if err != nil || len(all) <= limit {
+		return err
+	}
+	buf.Reset()
+	for _, e := range all[len(all)-limit:] {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return afero.WriteFile(fs, path, buf.Bytes(), 0o600)
+}
+
+// readJSONLines reads a JSON-lines file, skipping malformed lines. A missing
+// file has no entries.
+func readJSONLines[T any](fs afero.Fs, path string) ([]T, error) {
+	data, err := afero.ReadFile(fs, path)
+	if errors.Is(err, iofs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []T
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var e T
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
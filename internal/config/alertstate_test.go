@@ -0,0 +1,121 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// setupAlertStateTest returns a Manager backed by an in-memory filesystem.
+func setupAlertStateTest(t *testing.T) *Manager {
+	t.Helper()
+	SetFs(afero.NewMemMapFs())
+	t.Cleanup(func() { SetFs(nil) })
+	m := NewManager("/test/config/config.yaml")
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	return m
+}
+
+//nolint:paralleltest // Tests modify global state
+func TestManager_AlertStates_RoundTrip(t *testing.T) {
+	m := setupAlertStateTest(t)
+
+	states, err := m.LoadAlertStates()
+	if err != nil {
+		t.Fatalf("LoadAlertStates() error = %v", err)
+	}
+	if len(states) != 0 {
+		t.Fatalf("expected no states initially, got %d", len(states))
+	}
+
+	since := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	states["high-power"] = AlertRecord{State: AlertStateFiring, Since: since, LastNotified: since, LastValue: "1620"}
+	if err := m.SaveAlertStates(states); err != nil {
+		t.Fatalf("SaveAlertStates() error = %v", err)
+	}
+
+	loaded, err := m.LoadAlertStates()
+	if err != nil {
+		t.Fatalf("LoadAlertStates() error = %v", err)
+	}
+	got := loaded["high-power"]
+	if got.State != AlertStateFiring || !got.Since.Equal(since) || got.LastValue != "1620" {
+		t.Errorf("loaded record = %+v", got)
+	}
+}
+
+//nolint:paralleltest // Tests modify global state
+func TestManager_AlertHistory(t *testing.T) {
+	m := setupAlertStateTest(t)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, tr := range []string{"pending", "firing", "resolved"} {
+		err := m.AppendAlertHistory(AlertHistoryEntry{
+			Time:       base.Add(time.Duration(i) * time.Minute),
+			Alert:      "high-power",
+			Transition: tr,
+		})
+		if err != nil {
+			t.Fatalf("AppendAlertHistory() error = %v", err)
+		}
+	}
+
+	all, err := m.AlertHistory(0)
+	if err != nil {
+		t.Fatalf("AlertHistory() error = %v", err)
+	}
+	if len(all) != 3 || all[0].Transition != "pending" || all[2].Transition != "resolved" {
+		t.Errorf("AlertHistory(0) = %+v", all)
+	}
+
+	last, err := m.AlertHistory(2)
+	if err != nil {
+		t.Fatalf("AlertHistory() error = %v", err)
+	}
+	if len(last) != 2 || last[0].Transition != "firing" {
+		t.Errorf("AlertHistory(2) = %+v", last)
+	}
+}
+
+func TestManager_AlertState_InMemory(t *testing.T) {
+	t.Parallel()
+
+	m := NewTestManager(&Config{})
+	if err := m.SaveAlertStates(map[string]AlertRecord{"a": {State: AlertStateFiring}}); err != nil {
+		t.Errorf("SaveAlertStates() error = %v", err)
+	}
+	if err := m.AppendAlertHistory(AlertHistoryEntry{Alert: "a"}); err != nil {
+		t.Errorf("AppendAlertHistory() error = %v", err)
+	}
+	states, err := m.LoadAlertStates()
+	if err != nil || len(states) != 0 {
+		t.Errorf("LoadAlertStates() = %v, %v; want empty", states, err)
+	}
+	history, err := m.AlertHistory(0)
+	if err != nil || len(history) != 0 {
+		t.Errorf("AlertHistory() = %v, %v; want empty", history, err)
+	}
+}
+
+func TestAlert_RepeatDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		interval string
+		want     time.Duration
+	}{
+		{"", 0},
+		{"1h", time.Hour},
+		{"30m", 30 * time.Minute},
+		{"bogus", 0},
+		{"-5m", 0},
+	}
+	for _, tt := range tests {
+		if got := (Alert{RepeatInterval: tt.interval}).RepeatDuration(); got != tt.want {
+			t.Errorf("RepeatDuration(%q) = %v, want %v", tt.interval, got, tt.want)
+		}
+	}
+}
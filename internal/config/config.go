@@ -80,6 +80,15 @@ type Config struct {
 	// Alerts
 	Alerts map[string]Alert `mapstructure:"alerts" yaml:"alerts,omitempty"`
 
+	// Notifiers (alert delivery targets referenced as "notifier:NAME")
+	Notifiers map[string]Notifier `mapstructure:"notifiers" yaml:"notifiers,omitempty"`
+
+	// Rules (local cross-device automations run by "shelly rules run")
+	Rules map[string]Rule `mapstructure:"rules" yaml:"rules,omitempty"`
+
+	// Drift baselines (per-device desired state watched by "shelly drift watch")
+	Drift map[string]DriftBaseline `mapstructure:"drift" yaml:"drift,omitempty"`
+
 	// Plugin settings
 	Plugins PluginsConfig `mapstructure:"plugins" yaml:"plugins,omitempty"`
 
@@ -139,6 +148,60 @@ func DefaultTUIEventsConfig() TUIEventsConfig {
 type EnergyConfig struct {
 	CostRate float64 `mapstructure:"cost_rate" yaml:"cost_rate,omitempty"` // Cost per kWh in local currency
 	Currency string  `mapstructure:"currency" yaml:"currency,omitempty"`   // Currency symbol (e.g., "$", "€", "£")
+
+	// Time-of-use/tiered tariff; cost_rate applies when no period matches
+	Tariff TariffConfig `mapstructure:"tariff" yaml:"tariff,omitempty"`
+
+	// Retention of the local energy store fed by "shelly energy collect"
+	Retention EnergyRetentionConfig `mapstructure:"retention" yaml:"retention,omitempty"`
+
+	// Default meters for "shelly energy solar" and the monitor solar overlay
+	Solar SolarConfig `mapstructure:"solar" yaml:"solar,omitempty"`
+}
+
+// SolarConfig names the meters used for self-consumption analysis.
+type SolarConfig struct {
+	Grid   string `mapstructure:"grid" yaml:"grid,omitempty"`       // Device metering the grid connection (EM or EM1)
+	GridID int    `mapstructure:"grid_id" yaml:"grid_id,omitempty"` // Component ID on the grid meter
+	PV     string `mapstructure:"pv" yaml:"pv,omitempty"`           // Device metering PV production (optional)
+	PVID   int    `mapstructure:"pv_id" yaml:"pv_id,omitempty"`     // Component ID on the PV meter
+}
+
+// TariffConfig describes an electricity tariff. Periods are matched in order
+// against the start of each metered interval; the first match sets the
+// import rate.
+type TariffConfig struct {
+	Timezone       string         `mapstructure:"timezone" yaml:"timezone,omitempty"`               // IANA zone for period windows (default: local)
+	StandingCharge float64        `mapstructure:"standing_charge" yaml:"standing_charge,omitempty"` // Fixed charge per day
+	FeedIn         float64        `mapstructure:"feed_in" yaml:"feed_in,omitempty"`                 // Credit per exported kWh
+	Periods        []TariffPeriod `mapstructure:"periods" yaml:"periods,omitempty"`
+}
+
+// TariffPeriod is a rate window. Empty Months, Days or From/To match any
+// month, day or time; a To before From wraps past midnight.
+type TariffPeriod struct {
+	Name   string       `mapstructure:"name" yaml:"name"`
+	Rate   float64      `mapstructure:"rate" yaml:"rate,omitempty"`     // Cost per kWh
+	Tiers  []TariffTier `mapstructure:"tiers" yaml:"tiers,omitempty"`   // Block rates by monthly import, instead of rate
+	Months []int        `mapstructure:"months" yaml:"months,omitempty"` // 1-12
+	Days   []string     `mapstructure:"days" yaml:"days,omitempty"`     // mon..sun, weekdays, weekends
+	From   string       `mapstructure:"from" yaml:"from,omitempty"`     // HH:MM
+	To     string       `mapstructure:"to" yaml:"to,omitempty"`         // HH:MM
+}
+
+// TariffTier is a consumption block: Rate applies until the month's import
+// reaches UpToKWh. Zero UpToKWh means unlimited (last tier).
+type TariffTier struct {
+	UpToKWh float64 `mapstructure:"up_to_kwh" yaml:"up_to_kwh,omitempty"`
+	Rate    float64 `mapstructure:"rate" yaml:"rate"`
+}
+
+// EnergyRetentionConfig holds how many days each tier of the local energy
+// store is kept. Zero uses the default; a negative value keeps data forever.
+type EnergyRetentionConfig struct {
+	RawDays    int `mapstructure:"raw_days" yaml:"raw_days,omitempty"`       // Individual samples
+	HourlyDays int `mapstructure:"hourly_days" yaml:"hourly_days,omitempty"` // Hourly aggregates
+	DailyDays  int `mapstructure:"daily_days" yaml:"daily_days,omitempty"`   // Daily aggregates
 }
 
 // DefaultEnergyConfig returns sensible defaults for energy configuration.
@@ -146,6 +209,11 @@ func DefaultEnergyConfig() EnergyConfig {
 	return EnergyConfig{
 		CostRate: 0.12, // Default $0.12/kWh (US average)
 		Currency: "$",
+		Retention: EnergyRetentionConfig{
+			RawDays:    30,
+			HourlyDays: 730,
+			DailyDays:  -1, // Keep daily totals forever
+		},
 	}
 }
 
@@ -261,10 +329,16 @@ type Scene struct {
 }
 
 // SceneAction represents a single action within a scene.
+// Actions sharing a Group run in parallel; groups run one after another in
+// ascending order, each starting once the previous group's delays and
+// transitions have elapsed.
 type SceneAction struct {
-	Device string         `mapstructure:"device" json:"device" yaml:"device"`
-	Method string         `mapstructure:"method" json:"method" yaml:"method"`
-	Params map[string]any `mapstructure:"params,omitempty" json:"params,omitempty" yaml:"params,omitempty"`
+	Device     string         `mapstructure:"device" json:"device" yaml:"device"`
+	Method     string         `mapstructure:"method" json:"method" yaml:"method"`
+	Params     map[string]any `mapstructure:"params,omitempty" json:"params,omitempty" yaml:"params,omitempty"`
+	Group      int            `mapstructure:"group,omitempty" json:"group,omitempty" yaml:"group,omitempty"`
+	Delay      string         `mapstructure:"delay,omitempty" json:"delay,omitempty" yaml:"delay,omitempty"`                // e.g., "500ms"; wait before the action within its group
+	Transition string         `mapstructure:"transition,omitempty" json:"transition,omitempty" yaml:"transition,omitempty"` // e.g., "3s"; fade duration for light Set methods
 }
 
 // TemplatesConfig holds all template types.
@@ -480,6 +554,15 @@ func (c *Config) GetEnergyConfig() EnergyConfig {
 	if cfg.Currency == "" {
 		cfg.Currency = defaults.Currency
 	}
+	if cfg.Retention.RawDays == 0 {
+		cfg.Retention.RawDays = defaults.Retention.RawDays
+	}
+	if cfg.Retention.HourlyDays == 0 {
+		cfg.Retention.HourlyDays = defaults.Retention.HourlyDays
+	}
+	if cfg.Retention.DailyDays == 0 {
+		cfg.Retention.DailyDays = defaults.Retention.DailyDays
+	}
 
 	return cfg
 }
@@ -746,6 +829,15 @@ func BackupsDir() (string, error) {
 	return filepath.Join(configDir, "backups"), nil
 }
 
+// FirmwareMirrorDir returns the default firmware mirror directory path.
+func FirmwareMirrorDir() (string, error) {
+	configDir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "firmware", "mirror"), nil
+}
+
 // GetAllDeviceCredentials returns credentials for all devices that have auth configured.
 func (c *Config) GetAllDeviceCredentials() map[string]struct{ Username, Password string } {
 	return getDefaultManager().GetAllDeviceCredentials()
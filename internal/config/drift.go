@@ -0,0 +1,261 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Drift baseline kinds.
+const (
+	DriftBaselineBackup   = "backup"
+	DriftBaselineTemplate = "template"
+	DriftBaselineManifest = "manifest"
+)
+
+// Drift event types, as recorded in the drift history.
+const (
+	DriftEventDetected = "detected" // device moved away from its baseline
+	DriftEventChanged  = "changed"  // drifted device drifted differently
+	DriftEventResolved = "resolved" // device matches its baseline again
+)
+
+// maxDriftHistory caps the number of entries kept in the drift history file.
+const maxDriftHistory = 5000
+
+// DriftBaseline is the desired state a device is compared against. Exactly
+// one of Backup, Template or Manifest is set.
+type DriftBaseline struct {
+	Backup     string `mapstructure:"backup,omitempty" json:"backup,omitempty" yaml:"backup,omitempty"`       // backup file path
+	Template   string `mapstructure:"template,omitempty" json:"template,omitempty" yaml:"template,omitempty"` // device template name
+	Manifest   string `mapstructure:"manifest,omitempty" json:"manifest,omitempty" yaml:"manifest,omitempty"` // manifest file or directory
+	AssignedAt string `mapstructure:"assigned_at,omitempty" json:"assigned_at,omitempty" yaml:"assigned_at,omitempty"`
+}
+
+// Kind returns the baseline kind, or "" when none or several sources are set.
+func (b DriftBaseline) Kind() string {
+	kind := ""
+	for k, v := range map[string]string{
+		DriftBaselineBackup:   b.Backup,
+		DriftBaselineTemplate: b.Template,
+		DriftBaselineManifest: b.Manifest,
+	} {
+		if v == "" {
+			continue
+		}
+		if kind != "" {
+			return ""
+		}
+		kind = k
+	}
+	return kind
+}
+
+// Source returns the backup path, template name or manifest path.
+func (b DriftBaseline) Source() string {
+	switch b.Kind() {
+	case DriftBaselineBackup:
+		return b.Backup
+	case DriftBaselineTemplate:
+		return b.Template
+	case DriftBaselineManifest:
+		return b.Manifest
+	default:
+		return ""
+	}
+}
+
+// String returns "kind:source", e.g. "template:office-plug".
+func (b DriftBaseline) String() string {
+	return b.Kind() + ":" + b.Source()
+}
+
+// DriftRecord is the persisted drift state of a single device.
+type DriftRecord struct {
+	Drifted   bool      `json:"drifted"`
+	Since     time.Time `json:"since,omitzero"` // when the device last started drifting or came back in sync
+	LastCheck time.Time `json:"last_check,omitzero"`
+	Baseline  string    `json:"baseline,omitempty"`
+	Changes   []string  `json:"changes,omitempty"`
+	Error     string    `json:"error,omitempty"` // last check failure; state is kept from the previous check
+}
+
+// DriftEvent records one drift transition of a device.
+type DriftEvent struct {
+	Time     time.Time `json:"time"`
+	Device   string    `json:"device"`
+	Baseline string    `json:"baseline"`
+	Event    string    `json:"event"` // detected, changed, resolved
+	Changes  []string  `json:"changes,omitempty"`
+}
+
+// Package-level functions delegate to the default manager.
+
+// SetDriftBaseline assigns the baseline a device is compared against.
+func SetDriftBaseline(device string, baseline DriftBaseline) error {
+	return getDefaultManager().SetDriftBaseline(device, baseline)
+}
+
+// DeleteDriftBaseline removes a device's baseline.
+func DeleteDriftBaseline(device string) error {
+	return getDefaultManager().DeleteDriftBaseline(device)
+}
+
+// GetDriftBaseline returns a device's baseline.
+func GetDriftBaseline(device string) (DriftBaseline, bool) {
+	return getDefaultManager().GetDriftBaseline(device)
+}
+
+// ListDriftBaselines returns all assigned baselines keyed by device.
+func ListDriftBaselines() map[string]DriftBaseline {
+	return getDefaultManager().ListDriftBaselines()
+}
+
+// =============================================================================
+// Manager Drift Methods
+// =============================================================================
+
+// SetDriftBaseline assigns the baseline a device is compared against,
+// replacing any earlier assignment.
+func (m *Manager) SetDriftBaseline(device string, baseline DriftBaseline) error {
+	if device == "" {
+		return fmt.Errorf("device is required")
+	}
+	if baseline.Kind() == "" {
+		return fmt.Errorf("baseline needs exactly one of backup, template or manifest")
+	}
+	if baseline.AssignedAt == "" {
+		baseline.AssignedAt = time.Now().Format(time.RFC3339)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.config.Drift == nil {
+		m.config.Drift = make(map[string]DriftBaseline)
+	}
+	m.config.Drift[device] = baseline
+	return m.saveWithoutLock()
+}
+
+// DeleteDriftBaseline removes a device's baseline.
+func (m *Manager) DeleteDriftBaseline(device string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.config.Drift[device]; !exists {
+		return fmt.Errorf("no drift baseline for device %q", device)
+	}
+
+	delete(m.config.Drift, device)
+	return m.saveWithoutLock()
+}
+
+// GetDriftBaseline returns a device's baseline.
+func (m *Manager) GetDriftBaseline(device string) (DriftBaseline, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	baseline, ok := m.config.Drift[device]
+	return baseline, ok
+}
+
+// ListDriftBaselines returns all assigned baselines keyed by device.
+func (m *Manager) ListDriftBaselines() map[string]DriftBaseline {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]DriftBaseline, len(m.config.Drift))
+	for k, v := range m.config.Drift {
+		result[k] = v
+	}
+	return result
+}
+
+// driftDir returns the directory holding drift state and history, next to
+// the config file. In-memory managers (no path) have no drift files.
+func (m *Manager) driftDir() string {
+	if m.path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(m.path), "drift")
+}
+
+// LoadDriftStates returns the persisted drift state of each device.
+func (m *Manager) LoadDriftStates() (map[string]DriftRecord, error) {
+	states := make(map[string]DriftRecord)
+	dir := m.driftDir()
+	if dir == "" {
+		return states, nil
+	}
+
+	data, err := afero.ReadFile(m.Fs(), filepath.Join(dir, "state.json"))
+	if errors.Is(err, iofs.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read drift state: %w", err)
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("parse drift state: %w", err)
+	}
+	return states, nil
+}
+
+// SaveDriftStates persists the drift state of each device.
+func (m *Manager) SaveDriftStates(states map[string]DriftRecord) error {
+	dir := m.driftDir()
+	if dir == "" {
+		return nil
+	}
+	if err := m.Fs().MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create drift directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal drift state: %w", err)
+	}
+	if err := afero.WriteFile(m.Fs(), filepath.Join(dir, "state.json"), data, 0o600); err != nil {
+		return fmt.Errorf("write drift state: %w", err)
+	}
+	return nil
+}
+
+// AppendDriftEvents appends events to the drift history file, trimming the
+// oldest entries once the file exceeds its cap.
+func (m *Manager) AppendDriftEvents(events ...DriftEvent) error {
+	dir := m.driftDir()
+	if dir == "" || len(events) == 0 {
+		return nil
+	}
+	if err := m.Fs().MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create drift directory: %w", err)
+	}
+	if err := appendJSONLines(m.Fs(), filepath.Join(dir, "history.jsonl"), maxDriftHistory, events); err != nil {
+		return fmt.Errorf("write drift history: %w", err)
+	}
+	return nil
+}
+
+// DriftHistory returns the most recent drift events, oldest first.
+// A limit of 0 returns all events.
+func (m *Manager) DriftHistory(limit int) ([]DriftEvent, error) {
+	dir := m.driftDir()
+	if dir == "" {
+		return nil, nil
+	}
+
+	events, err := readJSONLines[DriftEvent](m.Fs(), filepath.Join(dir, "history.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("read drift history: %w", err)
+	}
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events, nil
+}
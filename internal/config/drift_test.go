@@ -0,0 +1,85 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDriftBaseline_Kind(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		baseline DriftBaseline
+		want     string
+	}{
+		{DriftBaseline{Backup: "/b/kitchen.json"}, DriftBaselineBackup},
+		{DriftBaseline{Template: "plug"}, DriftBaselineTemplate},
+		{DriftBaseline{Manifest: "/repo"}, DriftBaselineManifest},
+		{DriftBaseline{}, ""},
+		{DriftBaseline{Backup: "/b/kitchen.json", Template: "plug"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.baseline.Kind(); got != tt.want {
+			t.Errorf("%+v.Kind() = %q, want %q", tt.baseline, got, tt.want)
+		}
+	}
+	if got := (DriftBaseline{Template: "plug"}).String(); got != "template:plug" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+//nolint:paralleltest // Tests modify global state
+func TestManager_DriftBaselines(t *testing.T) {
+	m := setupAlertStateTest(t)
+
+	if err := m.SetDriftBaseline("kitchen", DriftBaseline{}); err == nil {
+		t.Error("expected error for empty baseline")
+	}
+	if err := m.SetDriftBaseline("kitchen", DriftBaseline{Template: "plug"}); err != nil {
+		t.Fatalf("SetDriftBaseline() error = %v", err)
+	}
+	got, ok := m.GetDriftBaseline("kitchen")
+	if !ok || got.Template != "plug" || got.AssignedAt == "" {
+		t.Errorf("GetDriftBaseline() = %+v, %v", got, ok)
+	}
+	if err := m.DeleteDriftBaseline("kitchen"); err != nil {
+		t.Fatalf("DeleteDriftBaseline() error = %v", err)
+	}
+	if err := m.DeleteDriftBaseline("kitchen"); err == nil {
+		t.Error("expected error deleting a missing baseline")
+	}
+}
+
+//nolint:paralleltest // Tests modify global state
+func TestManager_DriftStateAndHistory(t *testing.T) {
+	m := setupAlertStateTest(t)
+
+	since := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	states := map[string]DriftRecord{
+		"kitchen": {Drifted: true, Since: since, Baseline: "template:plug", Changes: []string{"schedule missing"}},
+	}
+	if err := m.SaveDriftStates(states); err != nil {
+		t.Fatalf("SaveDriftStates() error = %v", err)
+	}
+	loaded, err := m.LoadDriftStates()
+	if err != nil {
+		t.Fatalf("LoadDriftStates() error = %v", err)
+	}
+	if rec := loaded["kitchen"]; !rec.Drifted || !rec.Since.Equal(since) || len(rec.Changes) != 1 {
+		t.Errorf("loaded record = %+v", rec)
+	}
+
+	for i, ev := range []string{DriftEventDetected, DriftEventResolved} {
+		err := m.AppendDriftEvents(DriftEvent{Time: since.Add(time.Duration(i) * time.Hour), Device: "kitchen", Event: ev})
+		if err != nil {
+			t.Fatalf("AppendDriftEvents() error = %v", err)
+		}
+	}
+	events, err := m.DriftHistory(1)
+	if err != nil {
+		t.Fatalf("DriftHistory() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Event != DriftEventResolved {
+		t.Errorf("DriftHistory(1) = %+v", events)
+	}
+}
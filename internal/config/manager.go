@@ -507,3 +507,12 @@ func (m *Manager) Reload() error {
 func (m *Manager) Path() string {
 	return m.path
 }
+
+// EnergyDir returns the directory of the local energy store, next to the
+// config file. In-memory managers (no path) return "".
+func (m *Manager) EnergyDir() string {
+	if m.path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(m.path), "energy")
+}
@@ -0,0 +1,47 @@
+package config
+
+// Notifier is a named alert delivery target, referenced from an alert's action
+// as "notifier:NAME".
+type Notifier struct {
+	Type     string        `mapstructure:"type" json:"type" yaml:"type"`                                  // smtp, ntfy, gotify, slack, discord, mattermost, mqtt
+	URL      string        `mapstructure:"url,omitempty" json:"url,omitempty" yaml:"url,omitempty"`       // ntfy topic URL, Gotify server URL or chat webhook URL
+	Token    string        `mapstructure:"token,omitempty" json:"token,omitempty" yaml:"token,omitempty"` // ntfy access token or Gotify application token
+	Priority int           `mapstructure:"priority,omitempty" json:"priority,omitempty" yaml:"priority,omitempty"`
+	Title    string        `mapstructure:"title,omitempty" json:"title,omitempty" yaml:"title,omitempty"`          // Go template for the title/subject
+	Template string        `mapstructure:"template,omitempty" json:"template,omitempty" yaml:"template,omitempty"` // Go template for the body
+	SMTP     *SMTPNotifier `mapstructure:"smtp,omitempty" json:"smtp,omitempty" yaml:"smtp,omitempty"`
+	MQTT     *MQTTNotifier `mapstructure:"mqtt,omitempty" json:"mqtt,omitempty" yaml:"mqtt,omitempty"`
+}
+
+// SMTPNotifier holds the settings for an email notifier.
+type SMTPNotifier struct {
+	Host     string   `mapstructure:"host" json:"host" yaml:"host"`
+	Port     int      `mapstructure:"port,omitempty" json:"port,omitempty" yaml:"port,omitempty"` // Default: 587
+	Username string   `mapstructure:"username,omitempty" json:"username,omitempty" yaml:"username,omitempty"`
+	Password string   `mapstructure:"password,omitempty" json:"password,omitempty" yaml:"password,omitempty"`
+	From     string   `mapstructure:"from" json:"from" yaml:"from"`
+	To       []string `mapstructure:"to" json:"to" yaml:"to"`
+}
+
+// MQTTNotifier holds the settings for an MQTT publish notifier.
+type MQTTNotifier struct {
+	Broker   string `mapstructure:"broker" json:"broker" yaml:"broker"` // e.g., tcp://localhost:1883
+	Topic    string `mapstructure:"topic" json:"topic" yaml:"topic"`    // Go template, e.g., shelly/alerts/{{.Alert}}
+	Username string `mapstructure:"username,omitempty" json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `mapstructure:"password,omitempty" json:"password,omitempty" yaml:"password,omitempty"`
+	QoS      byte   `mapstructure:"qos,omitempty" json:"qos,omitempty" yaml:"qos,omitempty"`
+	Retain   bool   `mapstructure:"retain,omitempty" json:"retain,omitempty" yaml:"retain,omitempty"`
+}
+
+// GetNotifier returns a notifier by name.
+func GetNotifier(name string) (Notifier, bool) {
+	return getDefaultManager().GetNotifier(name)
+}
+
+// GetNotifier returns a notifier by name.
+func (m *Manager) GetNotifier(name string) (Notifier, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n, ok := m.config.Notifiers[name]
+	return n, ok
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// ErrNoRollout is returned by LoadRolloutState when no rollout is recorded.
+var ErrNoRollout = errors.New("no firmware rollout in progress")
+
+// RolloutDeviceStatus is the progress of one device in a firmware rollout.
+type RolloutDeviceStatus string
+
+// Rollout device states.
+const (
+	// RolloutPending means the device has not been updated yet.
+	RolloutPending RolloutDeviceStatus = "pending"
+	// RolloutUpdating means the update was started but health is unconfirmed.
+	RolloutUpdating RolloutDeviceStatus = "updating"
+	// RolloutHealthy means the device passed its post-update health checks.
+	RolloutHealthy RolloutDeviceStatus = "healthy"
+	// RolloutFailed means the update or the health checks failed.
+	RolloutFailed RolloutDeviceStatus = "failed"
+	// RolloutRolledBack means a failed device was rolled back.
+	RolloutRolledBack RolloutDeviceStatus = "rolled_back"
+	// RolloutSkipped means the device was unreachable before its update.
+	RolloutSkipped RolloutDeviceStatus = "skipped"
+)
+
+// RolloutWave is a set of devices updated together.
+type RolloutWave struct {
+	Name    string   `json:"name"`
+	Devices []string `json:"devices"`
+}
+
+// RolloutDevice is the persisted progress of one device. Components and
+// Errors are the pre-update health baseline, kept so a resumed rollout can
+// verify a device whose update was already started.
+type RolloutDevice struct {
+	Wave        int                 `json:"wave"`
+	Status      RolloutDeviceStatus `json:"status"`
+	FromVersion string              `json:"from_version,omitempty"`
+	ToVersion   string              `json:"to_version,omitempty"`
+	Components  []string            `json:"components,omitempty"`
+	Errors      []string            `json:"errors,omitempty"`
+	Problem     string              `json:"problem,omitempty"`
+	UpdatedAt   time.Time           `json:"updated_at,omitzero"`
+}
+
+// RolloutState is a resumable firmware rollout.
+type RolloutState struct {
+	StartedAt       time.Time                `json:"started_at"`
+	UpdatedAt       time.Time                `json:"updated_at"`
+	Beta            bool                     `json:"beta,omitempty"`
+	URL             string                   `json:"url,omitempty"`
+	Parallelism     int                      `json:"parallelism"`
+	MaxFailureRatio float64                  `json:"max_failure_ratio"`
+	RollbackFailed  bool                     `json:"rollback_failed,omitempty"`
+	HealthTimeout   time.Duration            `json:"health_timeout"`
+	CheckRPCs       []string                 `json:"check_rpcs,omitempty"`
+	Waves           []RolloutWave            `json:"waves"`
+	Devices         map[string]RolloutDevice `json:"devices"`
+	Halted          bool                     `json:"halted,omitempty"`
+	HaltReason      string                   `json:"halt_reason,omitempty"`
+	Completed       bool                     `json:"completed,omitempty"`
+}
+
+// LoadRolloutState returns the recorded firmware rollout, or ErrNoRollout.
+func LoadRolloutState() (*RolloutState, error) {
+	return getDefaultManager().LoadRolloutState()
+}
+
+// SaveRolloutState records a firmware rollout.
+func SaveRolloutState(state *RolloutState) error {
+	return getDefaultManager().SaveRolloutState(state)
+}
+
+// ClearRolloutState removes the recorded firmware rollout.
+func ClearRolloutState() error {
+	return getDefaultManager().ClearRolloutState()
+}
+
+// =============================================================================
+// Manager Rollout State Methods
+// =============================================================================
+
+// rolloutPath returns the rollout state file, next to the config file.
+// In-memory managers (no path) have no rollout file.
+func (m *Manager) rolloutPath() string {
+	if m.path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(m.path), "firmware", "rollout.json")
+}
+
+// LoadRolloutState returns the recorded firmware rollout, or ErrNoRollout.
+func (m *Manager) LoadRolloutState() (*RolloutState, error) {
+	path := m.rolloutPath()
+	if path == "" {
+		return nil, ErrNoRollout
+	}
+
+	data, err := afero.ReadFile(m.Fs(), path)
+	if errors.Is(err, iofs.ErrNotExist) {
+		return nil, ErrNoRollout
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read rollout state: %w", err)
+	}
+	var state RolloutState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse rollout state: %w", err)
+	}
+	if state.Devices == nil {
+		state.Devices = make(map[string]RolloutDevice)
+	}
+	return &state, nil
+}
+
+// SaveRolloutState records a firmware rollout.
+func (m *Manager) SaveRolloutState(state *RolloutState) error {
+	path := m.rolloutPath()
+	if path == "" {
+		return nil
+	}
+	if err := m.Fs().MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create firmware directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal rollout state: %w", err)
+	}
+	if err := afero.WriteFile(m.Fs(), path, data, 0o600); err != nil {
+		return fmt.Errorf("write rollout state: %w", err)
+	}
+	return nil
+}
+
+// ClearRolloutState removes the recorded firmware rollout.
+func (m *Manager) ClearRolloutState() error {
+	path := m.rolloutPath()
+	if path == "" {
+		return nil
+	}
+	if err := m.Fs().Remove(path); err != nil && !errors.Is(err, iofs.ErrNotExist) {
+		return fmt.Errorf("remove rollout state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+//nolint:paralleltest // Tests modify global state
+func TestManager_RolloutState(t *testing.T) {
+	m := setupAlertStateTest(t)
+
+	if _, err := m.LoadRolloutState(); !errors.Is(err, ErrNoRollout) {
+		t.Fatalf("LoadRolloutState() error = %v, want ErrNoRollout", err)
+	}
+
+	started := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	state := &RolloutState{
+		StartedAt:       started,
+		MaxFailureRatio: 0.2,
+		HealthTimeout:   10 * time.Minute,
+		Waves:           []RolloutWave{{Name: "canary", Devices: []string{"kitchen"}}},
+		Devices: map[string]RolloutDevice{
+			"kitchen": {Wave: 0, Status: RolloutHealthy, FromVersion: "1.3.0", ToVersion: "1.4.0", Components: []string{"switch:0"}},
+		},
+	}
+	if err := m.SaveRolloutState(state); err != nil {
+		t.Fatalf("SaveRolloutState() error = %v", err)
+	}
+
+	loaded, err := m.LoadRolloutState()
+	if err != nil {
+		t.Fatalf("LoadRolloutState() error = %v", err)
+	}
+	if !loaded.StartedAt.Equal(started) || loaded.HealthTimeout != 10*time.Minute || len(loaded.Waves) != 1 {
+		t.Errorf("loaded state = %+v", loaded)
+	}
+	if dev := loaded.Devices["kitchen"]; dev.Status != RolloutHealthy || dev.ToVersion != "1.4.0" || len(dev.Components) != 1 {
+		t.Errorf("loaded device = %+v", dev)
+	}
+
+	if err := m.ClearRolloutState(); err != nil {
+		t.Fatalf("ClearRolloutState() error = %v", err)
+	}
+	if _, err := m.LoadRolloutState(); !errors.Is(err, ErrNoRollout) {
+		t.Errorf("after clear: error = %v, want ErrNoRollout", err)
+	}
+	if err := m.ClearRolloutState(); err != nil {
+		t.Errorf("ClearRolloutState() without state error = %v", err)
+	}
+}
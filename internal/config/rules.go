@@ -0,0 +1,197 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule is a local automation: when Trigger fires and all Conditions hold,
+// Actions run in order. Rules are evaluated by "shelly rules run" on the
+// workstation, so devices of any generation can drive each other.
+type Rule struct {
+	Name        string          `mapstructure:"name" json:"name" yaml:"name"`
+	Description string          `mapstructure:"description,omitempty" json:"description,omitempty" yaml:"description,omitempty"`
+	Disabled    bool            `mapstructure:"disabled,omitempty" json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Trigger     RuleTrigger     `mapstructure:"trigger" json:"trigger" yaml:"trigger"`
+	Conditions  []RuleCondition `mapstructure:"conditions,omitempty" json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	Actions     []RuleAction    `mapstructure:"actions" json:"actions" yaml:"actions"`
+	Cooldown    string          `mapstructure:"cooldown,omitempty" json:"cooldown,omitempty" yaml:"cooldown,omitempty"` // e.g., "2s"; minimum time between firings
+}
+
+// RuleTrigger selects what fires a rule. Exactly one of Event or Condition is set.
+type RuleTrigger struct {
+	Device    string `mapstructure:"device" json:"device" yaml:"device"`
+	Event     string `mapstructure:"event,omitempty" json:"event,omitempty" yaml:"event,omitempty"`             // e.g., "input:0.single_push" or "long_push"
+	Condition string `mapstructure:"condition,omitempty" json:"condition,omitempty" yaml:"condition,omitempty"` // alert condition; fires when it becomes true
+}
+
+// RuleCondition is a condition on a device's current state that must hold for
+// a rule to run.
+type RuleCondition struct {
+	Device    string `mapstructure:"device" json:"device" yaml:"device"`
+	Condition string `mapstructure:"condition" json:"condition" yaml:"condition"`
+}
+
+// RuleAction is one step of a rule. Exactly one of Action, Method or Scene is set.
+type RuleAction struct {
+	Device string         `mapstructure:"device,omitempty" json:"device,omitempty" yaml:"device,omitempty"`
+	Action string         `mapstructure:"action,omitempty" json:"action,omitempty" yaml:"action,omitempty"` // on, off or toggle
+	ID     *int           `mapstructure:"id,omitempty" json:"id,omitempty" yaml:"id,omitempty"`             // component ID for Action; all when unset
+	Method string         `mapstructure:"method,omitempty" json:"method,omitempty" yaml:"method,omitempty"` // RPC method, e.g., "Switch.Set"
+	Params map[string]any `mapstructure:"params,omitempty" json:"params,omitempty" yaml:"params,omitempty"`
+	Scene  string         `mapstructure:"scene,omitempty" json:"scene,omitempty" yaml:"scene,omitempty"`
+}
+
+// CooldownDuration returns the parsed cooldown, or 0 when unset or invalid.
+func (r Rule) CooldownDuration() time.Duration {
+	if r.Cooldown == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(r.Cooldown)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Devices returns the distinct devices a rule's trigger and conditions read.
+func (r Rule) Devices() []string {
+	devices := []string{r.Trigger.Device}
+	for _, c := range r.Conditions {
+		if !slices.Contains(devices, c.Device) {
+			devices = append(devices, c.Device)
+		}
+	}
+	return devices
+}
+
+// rulesFile is the on-disk rule format: a top-level "rules" list.
+type rulesFile struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// ParseRulesFile reads rules from a YAML (or JSON) file. The file holds either
+// a top-level "rules" list or a bare list of rules.
+func ParseRulesFile(file string) ([]Rule, error) {
+	data, err := afero.ReadFile(Fs(), file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return ParseRules(data, filepath.Base(file))
+}
+
+// ParseRules parses rule definitions; source is used in error messages.
+func ParseRules(data []byte, source string) ([]Rule, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+
+	var rules []Rule
+	if len(doc.Content) > 0 && doc.Content[0].Kind == yaml.SequenceNode {
+		if err := doc.Decode(&rules); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+		}
+	} else if len(doc.Content) > 0 {
+		var f rulesFile
+		if err := doc.Decode(&f); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+		}
+		rules = f.Rules
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%s contains no rules", source)
+	}
+	seen := make(map[string]bool, len(rules))
+	for i, r := range rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("%s: rule %d has no name", source, i+1)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("%s: duplicate rule %q", source, r.Name)
+		}
+		seen[r.Name] = true
+	}
+	return rules, nil
+}
+
+// Package-level functions delegate to the default manager.
+
+// SaveRule creates or replaces a rule.
+func SaveRule(rule Rule) error {
+	return getDefaultManager().SaveRule(rule)
+}
+
+// DeleteRule removes a rule.
+func DeleteRule(name string) error {
+	return getDefaultManager().DeleteRule(name)
+}
+
+// GetRule returns a rule by name.
+func GetRule(name string) (Rule, bool) {
+	return getDefaultManager().GetRule(name)
+}
+
+// ListRules returns all rules.
+func ListRules() map[string]Rule {
+	return getDefaultManager().ListRules()
+}
+
+// =============================================================================
+// Manager Rule Methods
+// =============================================================================
+
+// SaveRule creates or replaces a rule.
+func (m *Manager) SaveRule(rule Rule) error {
+	if err := ValidateName(rule.Name, "rule"); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.config.Rules == nil {
+		m.config.Rules = make(map[string]Rule)
+	}
+	m.config.Rules[rule.Name] = rule
+	return m.saveWithoutLock()
+}
+
+// DeleteRule removes a rule.
+func (m *Manager) DeleteRule(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.config.Rules[name]; !exists {
+		return fmt.Errorf("rule %q not found", name)
+	}
+
+	delete(m.config.Rules, name)
+	return m.saveWithoutLock()
+}
+
+// GetRule returns a rule by name.
+func (m *Manager) GetRule(name string) (Rule, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rule, ok := m.config.Rules[name]
+	return rule, ok
+}
+
+// ListRules returns all rules.
+func (m *Manager) ListRules() map[string]Rule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]Rule, len(m.config.Rules))
+	for k, v := range m.config.Rules {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const testRulesYAML = `rules:
+  - name: hallway-button
+    trigger:
+      device: hallway-i4
+      event: input:0.single_push
+    conditions:
+      - device: kitchen
+        condition: "switch:0.output == false"
+      - device: hallway-i4
+        condition: online
+    actions:
+      - device: kitchen
+        action: toggle
+        id: 0
+      - scene: evening
+    cooldown: 2s
+`
+
+func TestParseRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := ParseRules([]byte(testRulesYAML), "rules.yaml")
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	if len(rules) != 1 {
+		t.Fatalf("len(rules) = %d, want 1", len(rules))
+	}
+
+	r := rules[0]
+	if r.Trigger.Device != "hallway-i4" || r.Trigger.Event != "input:0.single_push" {
+		t.Errorf("Trigger = %+v", r.Trigger)
+	}
+	if len(r.Actions) != 2 || r.Actions[0].ID == nil || *r.Actions[0].ID != 0 || r.Actions[1].Scene != "evening" {
+		t.Errorf("Actions = %+v", r.Actions)
+	}
+	if r.CooldownDuration() != 2*time.Second {
+		t.Errorf("CooldownDuration() = %v, want 2s", r.CooldownDuration())
+	}
+	if devices := r.Devices(); len(devices) != 2 || devices[0] != "hallway-i4" || devices[1] != "kitchen" {
+		t.Errorf("Devices() = %v, want [hallway-i4 kitchen]", devices)
+	}
+}
+
+func TestParseRules_BareList(t *testing.T) {
+	t.Parallel()
+
+	data := `- name: a
+  trigger: {device: x, condition: offline}
+  actions: [{device: y, action: "on"}]
+- name: b
+  trigger: {device: x, event: long_push}
+  actions: [{scene: night}]
+`
+	rules, err := ParseRules([]byte(data), "list.yaml")
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	if len(rules) != 2 || rules[1].Name != "b" {
+		t.Errorf("rules = %+v", rules)
+	}
+}
+
+func TestParseRules_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"no rules", "rules: []"},
+		{"invalid yaml", "rules: [unclosed"},
+		{"missing name", "rules:\n  - trigger: {device: x}\n"},
+		{"duplicate", "rules:\n  - name: a\n  - name: a\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := ParseRules([]byte(tt.data), "rules.yaml"); err == nil {
+				t.Error("ParseRules() expected error")
+			}
+		})
+	}
+}
+
+func TestRule_CooldownDuration_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, c := range []string{"", "soon", "-1s"} {
+		if d := (Rule{Cooldown: c}).CooldownDuration(); d != 0 {
+			t.Errorf("CooldownDuration(%q) = %v, want 0", c, d)
+		}
+	}
+}
+
+//nolint:paralleltest // Test modifies global state via SetFs
+func TestParseRulesFile(t *testing.T) {
+	SetFs(afero.NewMemMapFs())
+	t.Cleanup(func() { SetFs(nil) })
+
+	if err := afero.WriteFile(Fs(), "/rules.yaml", []byte(testRulesYAML), 0o600); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	rules, err := ParseRulesFile("/rules.yaml")
+	if err != nil {
+		t.Fatalf("ParseRulesFile() error = %v", err)
+	}
+	if len(rules) != 1 || rules[0].Name != "hallway-button" {
+		t.Errorf("rules = %+v", rules)
+	}
+
+	if _, err := ParseRulesFile("/missing.yaml"); err == nil {
+		t.Error("ParseRulesFile() expected error for missing file")
+	}
+}
+
+//nolint:paralleltest // Tests modify global state
+func TestPackageLevel_Rules(t *testing.T) {
+	SetFs(afero.NewMemMapFs())
+	t.Cleanup(func() { SetFs(nil) })
+	ResetDefaultManagerForTesting()
+
+	rule := Rule{Name: "night", Trigger: RuleTrigger{Device: "hall", Condition: "offline"}}
+	if err := SaveRule(rule); err != nil {
+		t.Fatalf("SaveRule() error = %v", err)
+	}
+	if err := SaveRule(Rule{Name: "bad name"}); err == nil {
+		t.Error("SaveRule() expected error for invalid name")
+	}
+
+	got, ok := GetRule("night")
+	if !ok || got.Trigger.Device != "hall" {
+		t.Errorf("GetRule() = %+v, %v", got, ok)
+	}
+	if n := len(ListRules()); n != 1 {
+		t.Errorf("len(ListRules()) = %d, want 1", n)
+	}
+
+	if err := DeleteRule("night"); err != nil {
+		t.Fatalf("DeleteRule() error = %v", err)
+	}
+	if err := DeleteRule("night"); err == nil {
+		t.Error("DeleteRule() expected error for missing rule")
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/afero"
 	"gopkg.in/yaml.v3"
@@ -15,6 +17,50 @@ func ValidateSceneName(name string) error {
 	return ValidateName(name, "scene")
 }
 
+// SceneTransitionMethods lists the RPC methods that accept a transition.
+var SceneTransitionMethods = []string{"Light.Set", "RGB.Set", "RGBW.Set", "CCT.Set"}
+
+// DelayDuration returns the parsed delay, or 0 when unset or invalid.
+func (a SceneAction) DelayDuration() time.Duration {
+	return parseSceneDuration(a.Delay)
+}
+
+// TransitionDuration returns the parsed transition, or 0 when unset or invalid.
+func (a SceneAction) TransitionDuration() time.Duration {
+	return parseSceneDuration(a.Transition)
+}
+
+func parseSceneDuration(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// ValidateSceneAction checks that an action has a device and method and that
+// its delay and transition are valid durations.
+func ValidateSceneAction(a SceneAction) error {
+	if a.Device == "" || a.Method == "" {
+		return fmt.Errorf("action requires a device and method")
+	}
+	for field, value := range map[string]string{"delay": a.Delay, "transition": a.Transition} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("%s %s: invalid %s %q", a.Device, a.Method, field, value)
+		}
+	}
+	if a.Transition != "" && !slices.Contains(SceneTransitionMethods, a.Method) {
+		return fmt.Errorf("%s %s: transition requires one of %s", a.Device, a.Method, strings.Join(SceneTransitionMethods, ", "))
+	}
+	return nil
+}
+
 // Package-level functions delegate to the default manager.
 
 // CreateScene creates a new scene.
@@ -104,6 +150,11 @@ func ImportScene(scene *Scene, overwrite bool) error {
 	if scene.Name == "" {
 		return fmt.Errorf("scene name is required")
 	}
+	for i, action := range scene.Actions {
+		if err := ValidateSceneAction(action); err != nil {
+			return fmt.Errorf("action %d: %w", i+1, err)
+		}
+	}
 
 	// Check if scene exists
 	if _, exists := GetScene(scene.Name); exists {
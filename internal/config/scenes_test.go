@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 )
@@ -236,6 +237,64 @@ func TestImportScene_EmptyName(t *testing.T) {
 	}
 }
 
+func TestImportScene_InvalidAction(t *testing.T) {
+	t.Parallel()
+
+	scene := &Scene{
+		Name:    "bad-transition",
+		Actions: []SceneAction{{Device: "cover", Method: "Cover.Close", Transition: "3s"}},
+	}
+
+	// Validation fails before the default manager is touched.
+	if err := ImportScene(scene, false); err == nil {
+		t.Error("expected error importing scene with invalid action")
+	}
+}
+
+func TestValidateSceneAction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		action  SceneAction
+		wantErr bool
+	}{
+		{"plain", SceneAction{Device: "d", Method: "Switch.Set"}, false},
+		{"delay and transition", SceneAction{Device: "d", Method: "Light.Set", Delay: "500ms", Transition: "3s"}, false},
+		{"rgbw transition", SceneAction{Device: "d", Method: "RGBW.Set", Transition: "1.5s"}, false},
+		{"missing device", SceneAction{Method: "Switch.Set"}, true},
+		{"missing method", SceneAction{Device: "d"}, true},
+		{"invalid delay", SceneAction{Device: "d", Method: "Switch.Set", Delay: "soon"}, true},
+		{"negative delay", SceneAction{Device: "d", Method: "Switch.Set", Delay: "-1s"}, true},
+		{"invalid transition", SceneAction{Device: "d", Method: "Light.Set", Transition: "slow"}, true},
+		{"transition on switch", SceneAction{Device: "d", Method: "Switch.Set", Transition: "1s"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := ValidateSceneAction(tt.action); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSceneAction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSceneAction_Durations(t *testing.T) {
+	t.Parallel()
+
+	a := SceneAction{Delay: "250ms", Transition: "3s"}
+	if a.DelayDuration() != 250*time.Millisecond {
+		t.Errorf("DelayDuration() = %v, want 250ms", a.DelayDuration())
+	}
+	if a.TransitionDuration() != 3*time.Second {
+		t.Errorf("TransitionDuration() = %v, want 3s", a.TransitionDuration())
+	}
+	if d := (SceneAction{Delay: "bad"}).DelayDuration(); d != 0 {
+		t.Errorf("DelayDuration(bad) = %v, want 0", d)
+	}
+}
+
 // setupScenesTest sets up an isolated environment for scene package-level function tests.
 func setupScenesTest(t *testing.T) {
 	t.Helper()
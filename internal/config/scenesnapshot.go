@@ -0,0 +1,146 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// SceneSnapshot records device state captured before a scene was activated.
+// Actions restore that state when replayed.
+type SceneSnapshot struct {
+	Scene   string        `json:"scene"`
+	Time    time.Time     `json:"time"`
+	Actions []SceneAction `json:"actions"`
+}
+
+// SaveSceneSnapshot stores the pre-activation snapshot for a scene, replacing
+// any earlier snapshot of the same scene.
+func SaveSceneSnapshot(snap SceneSnapshot) error {
+	return getDefaultManager().SaveSceneSnapshot(snap)
+}
+
+// GetSceneSnapshot returns the snapshot for a scene, or the most recent
+// snapshot of any scene when name is empty.
+func GetSceneSnapshot(name string) (SceneSnapshot, bool, error) {
+	return getDefaultManager().GetSceneSnapshot(name)
+}
+
+// DeleteSceneSnapshot removes the snapshot for a scene.
+func DeleteSceneSnapshot(name string) error {
+	return getDefaultManager().DeleteSceneSnapshot(name)
+}
+
+// =============================================================================
+// Manager Scene Snapshot Methods
+// =============================================================================
+
+// sceneSnapshotPath returns the snapshot file next to the config file.
+// In-memory managers (no path) keep no snapshots.
+func (m *Manager) sceneSnapshotPath() string {
+	if m.path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(m.path), "scenes", "snapshots.json")
+}
+
+func (m *Manager) loadSceneSnapshots() (map[string]SceneSnapshot, error) {
+	snaps := make(map[string]SceneSnapshot)
+	path := m.sceneSnapshotPath()
+	if path == "" {
+		return snaps, nil
+	}
+
+	data, err := afero.ReadFile(m.Fs(), path)
+	if errors.Is(err, iofs.ErrNotExist) {
+		return snaps, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read scene snapshots: %w", err)
+	}
+	if err := json.Unmarshal(data, &snaps); err != nil {
+		return nil, fmt.Errorf("parse scene snapshots: %w", err)
+	}
+	return snaps, nil
+}
+
+func (m *Manager) saveSceneSnapshots(snaps map[string]SceneSnapshot) error {
+	path := m.sceneSnapshotPath()
+	if path == "" {
+		return nil
+	}
+	if err := m.Fs().MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create scenes directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(snaps, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal scene snapshots: %w", err)
+	}
+	if err := afero.WriteFile(m.Fs(), path, data, 0o600); err != nil {
+		return fmt.Errorf("write scene snapshots: %w", err)
+	}
+	return nil
+}
+
+// SaveSceneSnapshot stores the pre-activation snapshot for a scene.
+func (m *Manager) SaveSceneSnapshot(snap SceneSnapshot) error {
+	if snap.Scene == "" {
+		return fmt.Errorf("snapshot scene name is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snaps, err := m.loadSceneSnapshots()
+	if err != nil {
+		return err
+	}
+	snaps[snap.Scene] = snap
+	return m.saveSceneSnapshots(snaps)
+}
+
+// GetSceneSnapshot returns the snapshot for a scene, or the most recent
+// snapshot of any scene when name is empty.
+func (m *Manager) GetSceneSnapshot(name string) (SceneSnapshot, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snaps, err := m.loadSceneSnapshots()
+	if err != nil {
+		return SceneSnapshot{}, false, err
+	}
+	if name != "" {
+		snap, ok := snaps[name]
+		return snap, ok, nil
+	}
+
+	var latest SceneSnapshot
+	for _, snap := range snaps {
+		if snap.Time.After(latest.Time) {
+			latest = snap
+		}
+	}
+	return latest, latest.Scene != "", nil
+}
+
+// DeleteSceneSnapshot removes the snapshot for a scene.
+func (m *Manager) DeleteSceneSnapshot(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snaps, err := m.loadSceneSnapshots()
+	if err != nil {
+		return err
+	}
+	if _, ok := snaps[name]; !ok {
+		return fmt.Errorf("no snapshot for scene %q", name)
+	}
+	delete(snaps, name)
+	return m.saveSceneSnapshots(snaps)
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+//nolint:paralleltest // Tests modify global state
+func TestManager_SceneSnapshots(t *testing.T) {
+	m := setupAlertStateTest(t)
+
+	if _, ok, err := m.GetSceneSnapshot(""); err != nil || ok {
+		t.Fatalf("GetSceneSnapshot() = %v, %v; want none", ok, err)
+	}
+
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	present := SceneSnapshot{
+		Scene:   "presentation",
+		Time:    base,
+		Actions: []SceneAction{{Device: "room-a", Method: "Light.Set", Params: map[string]any{"id": 0.0, "on": true}}},
+	}
+	blackout := SceneSnapshot{Scene: "blackout", Time: base.Add(time.Hour)}
+	for _, snap := range []SceneSnapshot{present, blackout} {
+		if err := m.SaveSceneSnapshot(snap); err != nil {
+			t.Fatalf("SaveSceneSnapshot() error = %v", err)
+		}
+	}
+	if err := m.SaveSceneSnapshot(SceneSnapshot{}); err == nil {
+		t.Error("SaveSceneSnapshot() expected error without scene name")
+	}
+
+	got, ok, err := m.GetSceneSnapshot("presentation")
+	if err != nil || !ok || len(got.Actions) != 1 || got.Actions[0].Params["on"] != true {
+		t.Errorf("GetSceneSnapshot(presentation) = %+v, %v, %v", got, ok, err)
+	}
+	latest, ok, err := m.GetSceneSnapshot("")
+	if err != nil || !ok || latest.Scene != "blackout" {
+		t.Errorf("GetSceneSnapshot(\"\") = %+v, %v, %v; want blackout", latest, ok, err)
+	}
+
+	if err := m.DeleteSceneSnapshot("blackout"); err != nil {
+		t.Fatalf("DeleteSceneSnapshot() error = %v", err)
+	}
+	if err := m.DeleteSceneSnapshot("blackout"); err == nil {
+		t.Error("DeleteSceneSnapshot() expected error for missing snapshot")
+	}
+	if latest, _, _ = m.GetSceneSnapshot(""); latest.Scene != "presentation" {
+		t.Errorf("latest after delete = %q, want presentation", latest.Scene)
+	}
+}
+
+func TestManager_SceneSnapshots_InMemory(t *testing.T) {
+	t.Parallel()
+
+	m := NewTestManager(&Config{})
+	if err := m.SaveSceneSnapshot(SceneSnapshot{Scene: "a", Time: time.Now()}); err != nil {
+		t.Errorf("SaveSceneSnapshot() error = %v", err)
+	}
+	if _, ok, err := m.GetSceneSnapshot("a"); err != nil || ok {
+		t.Errorf("GetSceneSnapshot() = %v, %v; want none", ok, err)
+	}
+}
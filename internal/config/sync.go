@@ -38,6 +38,11 @@ func SaveSyncConfig(syncDir, device string, cfg map[string]any) error {
 	return nil
 }
 
+// SyncConfigExists reports whether a device has a config in the sync directory.
+func SyncConfigExists(syncDir, device string) (bool, error) {
+	return afero.Exists(Fs(), filepath.Join(syncDir, fmt.Sprintf("%s.json", device)))
+}
+
 // LoadSyncConfig loads a device config from the sync directory.
 func LoadSyncConfig(syncDir, filename string) (map[string]any, error) {
 	fullPath := filepath.Join(syncDir, filename)
@@ -51,3 +56,34 @@ func LoadSyncConfig(syncDir, filename string) (map[string]any, error) {
 	}
 	return cfg, nil
 }
+
+// syncBaseDir is the sync subdirectory holding the last-synced configs that
+// three-way merges are computed against.
+const syncBaseDir = ".base"
+
+// SaveSyncBase records cfg as the last config both the device and the local
+// sync file agreed on.
+func SaveSyncBase(syncDir, device string, cfg map[string]any) error {
+	baseDir := filepath.Join(syncDir, syncBaseDir)
+	if err := Fs().MkdirAll(baseDir, 0o700); err != nil {
+		return fmt.Errorf("create base directory: %w", err)
+	}
+	return SaveSyncConfig(baseDir, device, cfg)
+}
+
+// LoadSyncBase loads the last-synced config for a device. It reports false
+// when the device has never been synced.
+func LoadSyncBase(syncDir, device string) (map[string]any, bool, error) {
+	exists, err := SyncConfigExists(filepath.Join(syncDir, syncBaseDir), device)
+	if err != nil {
+		return nil, false, fmt.Errorf("stat base: %w", err)
+	}
+	if !exists {
+		return nil, false, nil
+	}
+	cfg, err := LoadSyncConfig(filepath.Join(syncDir, syncBaseDir), fmt.Sprintf("%s.json", device))
+	if err != nil {
+		return nil, false, fmt.Errorf("base: %w", err)
+	}
+	return cfg, true, nil
+}
@@ -153,3 +153,30 @@ func TestSaveSyncConfig_WriteError(t *testing.T) {
 		t.Error("expected error writing to read-only filesystem")
 	}
 }
+
+//nolint:paralleltest // Test modifies global state via SetFs
+func TestSyncBase(t *testing.T) {
+	SetFs(afero.NewMemMapFs())
+	t.Cleanup(func() { SetFs(nil) })
+
+	if _, ok, err := LoadSyncBase(testSyncDir, "device1"); ok || err != nil {
+		t.Fatalf("LoadSyncBase() before save = %v, %v; want false, nil", ok, err)
+	}
+
+	if err := SaveSyncBase(testSyncDir, "device1", map[string]any{"setting1": "value1"}); err != nil {
+		t.Fatalf("SaveSyncBase() error: %v", err)
+	}
+
+	base, ok, err := LoadSyncBase(testSyncDir, "device1")
+	if err != nil || !ok {
+		t.Fatalf("LoadSyncBase() = %v, %v", ok, err)
+	}
+	if base["setting1"] != "value1" {
+		t.Errorf("setting1 = %v, want %q", base["setting1"], "value1")
+	}
+
+	// The base must not show up as a device config in the sync directory.
+	if _, err := Fs().Stat(filepath.Join(testSyncDir, "device1.json")); err == nil {
+		t.Error("base was written over the sync config")
+	}
+}
@@ -5,12 +5,17 @@ import "time"
 
 // BenchmarkResult holds the results of a device performance benchmark.
 type BenchmarkResult struct {
-	Device      string       `json:"device"`
-	Iterations  int          `json:"iterations"`
-	PingLatency LatencyStats `json:"ping_latency"`
-	RPCLatency  LatencyStats `json:"rpc_latency"`
-	Summary     string       `json:"summary"`
-	Timestamp   time.Time    `json:"timestamp"`
+	Device      string                `json:"device"`
+	Model       string                `json:"model,omitempty"`
+	Firmware    string                `json:"firmware,omitempty"`
+	Method      string                `json:"method,omitempty"`
+	Iterations  int                   `json:"iterations"`
+	PingLatency LatencyStats          `json:"ping_latency"`
+	RPCLatency  LatencyStats          `json:"rpc_latency"`
+	Load        []LoadResult          `json:"load,omitempty"`
+	Regressions []BenchmarkRegression `json:"regressions,omitempty"`
+	Summary     string                `json:"summary"`
+	Timestamp   time.Time             `json:"timestamp"`
 }
 
 // LatencyStats holds latency statistics for benchmark measurements.
@@ -23,3 +28,25 @@ type LatencyStats struct {
 	P99    time.Duration `json:"p99"`
 	Errors int           `json:"errors"`
 }
+
+// LoadResult holds the results of one concurrent load profile.
+type LoadResult struct {
+	Transport   string        `json:"transport"`
+	Method      string        `json:"method"`
+	Concurrency int           `json:"concurrency"`
+	TargetRPS   float64       `json:"target_rps,omitempty"`
+	Requests    int           `json:"requests"`
+	Duration    time.Duration `json:"duration"`
+	Throughput  float64       `json:"throughput"` // successful requests per second
+	ErrorRate   float64       `json:"error_rate"` // 0-1
+	Latency     LatencyStats  `json:"latency"`
+}
+
+// BenchmarkRegression is a metric that got worse than a saved baseline.
+type BenchmarkRegression struct {
+	Profile  string  `json:"profile"`
+	Metric   string  `json:"metric"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+	Change   float64 `json:"change_pct"`
+}
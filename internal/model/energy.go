@@ -15,6 +15,8 @@ type DashboardData struct {
 	EstimatedCost *float64               `json:"estimated_cost,omitempty"`
 	CostCurrency  string                 `json:"cost_currency,omitempty"`
 	CostPerKwh    float64                `json:"cost_per_kwh,omitempty"`
+	CostToday     *EnergyCost            `json:"cost_today,omitempty"`
+	CurrentRate   *TariffRate            `json:"current_rate,omitempty"`
 }
 
 // DashboardDeviceEntry represents energy status for a single device in the dashboard.
@@ -25,6 +27,7 @@ type DashboardDeviceEntry struct {
 	TotalPower  float64          `json:"total_power_w"`
 	TotalEnergy float64          `json:"total_energy_wh,omitempty"`
 	Components  []ComponentPower `json:"components,omitempty"`
+	CostToday   *EnergyCost      `json:"cost_today,omitempty"`
 }
 
 // ComponentPower represents power data for a single component.
@@ -46,18 +49,81 @@ type ComparisonData struct {
 	TotalEnergy float64        `json:"total_energy_kwh"`
 	MaxEnergy   float64        `json:"max_energy_kwh"`
 	MinEnergy   float64        `json:"min_energy_kwh"`
+	Cost        *EnergyCost    `json:"cost,omitempty"`
 }
 
 // DeviceEnergy represents energy data for a single device.
 type DeviceEnergy struct {
-	Device     string  `json:"device"`
-	Energy     float64 `json:"energy_kwh"`
-	AvgPower   float64 `json:"avg_power_w"`
-	PeakPower  float64 `json:"peak_power_w"`
-	DataPoints int     `json:"data_points"`
-	Online     bool    `json:"online"`
-	Error      string  `json:"error,omitempty"`
-	Percentage float64 `json:"percentage,omitempty"`
+	Device     string      `json:"device"`
+	Energy     float64     `json:"energy_kwh"`
+	AvgPower   float64     `json:"avg_power_w"`
+	PeakPower  float64     `json:"peak_power_w"`
+	DataPoints int         `json:"data_points"`
+	Online     bool        `json:"online"`
+	Error      string      `json:"error,omitempty"`
+	Percentage float64     `json:"percentage,omitempty"`
+	Cost       *EnergyCost `json:"cost,omitempty"`
+}
+
+// EnergyCost is the cost of metered energy under a tariff. Export is
+// credited at the feed-in rate; Total is import cost plus standing charge
+// minus export credit.
+type EnergyCost struct {
+	Currency       string       `json:"currency"`
+	ImportKWh      float64      `json:"import_kwh"`
+	ExportKWh      float64      `json:"export_kwh,omitempty"`
+	ImportCost     float64      `json:"import_cost"`
+	ExportCredit   float64      `json:"export_credit,omitempty"`
+	StandingCharge float64      `json:"standing_charge,omitempty"`
+	Total          float64      `json:"total"`
+	Periods        []PeriodCost `json:"periods,omitempty"`
+}
+
+// PeriodCost is the imported energy and its cost within one tariff period.
+type PeriodCost struct {
+	Name string  `json:"name"`
+	KWh  float64 `json:"kwh"`
+	Cost float64 `json:"cost"`
+}
+
+// TariffRate is the import rate in effect at a point in time.
+type TariffRate struct {
+	Period   string  `json:"period"`
+	Rate     float64 `json:"rate"`
+	Currency string  `json:"currency"`
+}
+
+// SolarReport is the self-consumption and net-metering analysis of a grid
+// meter, optionally paired with a PV meter, broken down per day.
+type SolarReport struct {
+	Grid  string       `json:"grid"`
+	PV    string       `json:"pv,omitempty"`
+	From  time.Time    `json:"from"`
+	To    time.Time    `json:"to"`
+	Days  []SolarDay   `json:"days"`
+	Total SolarSummary `json:"total"`
+}
+
+// SolarDay is the analysis of one calendar day.
+type SolarDay struct {
+	Date string `json:"date"`
+	SolarSummary
+}
+
+// SolarSummary holds the energy balance and derived ratios over a span.
+// Production, self-consumption and autarky need a PV meter; without one they
+// are zero or nil. Phase imbalance needs a 3-phase grid meter.
+type SolarSummary struct {
+	ImportKWh       float64   `json:"import_kwh"`
+	ExportKWh       float64   `json:"export_kwh"`
+	ProductionKWh   float64   `json:"production_kwh,omitempty"`
+	SelfConsumedKWh float64   `json:"self_consumed_kwh,omitempty"`
+	ConsumptionKWh  float64   `json:"consumption_kwh,omitempty"`
+	SelfConsumption *float64  `json:"self_consumption_pct,omitempty"` // Share of production used on site
+	Autarky         *float64  `json:"autarky_pct,omitempty"`          // Share of consumption covered by PV
+	PeakDemand      float64   `json:"peak_demand_w"`                  // Highest 15-minute average import
+	PeakDemandAt    time.Time `json:"peak_demand_at,omitzero"`
+	PhaseImbalance  *float64  `json:"phase_imbalance_pct,omitempty"` // Mean deviation of the busiest phase from the average
 }
 
 // EMStatus represents the status of an Energy Monitor (EM) component (3-phase).
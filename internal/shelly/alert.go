@@ -6,47 +6,55 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
 	"os/exec"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/spf13/afero"
+
 	"github.com/tj-smith47/shelly-cli/internal/config"
 	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/alerting"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/notifier"
 )
 
 const statusUnreachable = "unreachable"
 
 // Action type constants.
 const (
-	ActionTypeNotify  = "notify"
-	ActionTypeWebhook = "webhook"
-	ActionTypeCommand = "command"
-	ActionTypeUnknown = "unknown"
+	ActionTypeNotify   = "notify"
+	ActionTypeWebhook  = "webhook"
+	ActionTypeCommand  = "command"
+	ActionTypeNotifier = "notifier"
+	ActionTypeUnknown  = "unknown"
 )
 
 // Alert evaluation literals.
 const (
-	alertValueInvalid     = "invalid"
-	componentPrefixSwitch = "switch:0"
-	componentPrefixEM     = "em:0"
-	componentPrefixTemp   = "temperature:0"
-	metricPower           = "power"
-	metricApower          = "apower"
-	metricTemperature     = "temperature"
+	alertValueInvalid = "invalid"
+	metricApower      = "apower"
 )
 
 // AlertConditionResult holds the result of evaluating an alert condition.
 type AlertConditionResult struct {
 	Triggered bool
+	Pending   bool // Matched, but a "for" window has not elapsed
+	Unknown   bool // Status values were missing, e.g. device unreachable
 	Value     string
 }
 
 // AlertState tracks the state of an alert for edge detection.
+// Record holds the persisted pending/firing/resolved lifecycle; the condition
+// evaluator keeps duration windows and hysteresis across checks and is rebuilt
+// when the condition changes.
 type AlertState struct {
 	LastTriggered time.Time
 	LastValue     string
 	Triggered     bool
+	Record        config.AlertRecord
+
+	evaluator *alerting.Evaluator
 }
 
 // AlertCheckResult represents what happened when checking an alert.
@@ -64,35 +72,126 @@ type AlertAction int
 const (
 	// AlertActionNone means no state change.
 	AlertActionNone AlertAction = iota
-	// AlertActionTriggered means the alert just triggered.
+	// AlertActionTriggered means the alert just started firing.
 	AlertActionTriggered
-	// AlertActionCleared means the alert condition cleared.
+	// AlertActionCleared means a firing alert resolved.
 	AlertActionCleared
+	// AlertActionPending means the condition matched but its "for" window is still running.
+	AlertActionPending
+	// AlertActionRepeat means a still-firing alert is due a reminder.
+	AlertActionRepeat
+)
+
+// Alert lifecycle transition names, as recorded in the alert history.
+const (
+	AlertTransitionPending  = "pending"
+	AlertTransitionFiring   = "firing"
+	AlertTransitionRepeat   = "repeat"
+	AlertTransitionResolved = "resolved"
 )
 
-// CheckAlert evaluates an alert and updates state, returning what action to take.
+// Transition returns the history name of the action, or "" for AlertActionNone.
+func (a AlertAction) Transition() string {
+	switch a {
+	case AlertActionTriggered:
+		return AlertTransitionFiring
+	case AlertActionCleared:
+		return AlertTransitionResolved
+	case AlertActionPending:
+		return AlertTransitionPending
+	case AlertActionRepeat:
+		return AlertTransitionRepeat
+	default:
+		return ""
+	}
+}
+
+// Notifies reports whether the action should run the alert's configured action.
+// Resolutions notify only when the alert opts in with notify_resolved.
+func (a AlertAction) Notifies(alert config.Alert) bool {
+	switch a {
+	case AlertActionTriggered, AlertActionRepeat:
+		return true
+	case AlertActionCleared:
+		return alert.NotifyResolved
+	default:
+		return false
+	}
+}
+
+// CheckAlert evaluates an alert and advances its lifecycle, returning the
+// transition that occurred. Each transition is reported once: a stuck
+// condition stays firing silently until the repeat interval elapses.
 func (s *Service) CheckAlert(ctx context.Context, alert config.Alert, state *AlertState) AlertCheckResult {
-	result := s.EvaluateAlertCondition(ctx, alert)
+	var snap alerting.Snapshot
+	if ev := state.evaluatorFor(alert); ev != nil {
+		snap = s.AlertSnapshot(ctx, alert.Device, ev.Condition().NeedsStatus())
+	}
+	return checkAlertSnapshot(alert, state, snap)
+}
 
-	checkResult := AlertCheckResult{
+// checkAlertSnapshot evaluates an alert against an already captured snapshot
+// and advances its lifecycle.
+func checkAlertSnapshot(alert config.Alert, state *AlertState, snap alerting.Snapshot) AlertCheckResult {
+	result := state.evaluate(alert, snap)
+
+	return AlertCheckResult{
 		Name:      alert.Name,
 		Condition: alert.Condition,
 		Device:    alert.Device,
 		Value:     result.Value,
+		Action:    state.advance(alert, result, time.Now()),
 	}
+}
 
-	// Edge detection: only trigger on state change
-	if result.Triggered && !state.Triggered {
-		state.Triggered = true
-		state.LastTriggered = time.Now()
-		state.LastValue = result.Value
-		checkResult.Action = AlertActionTriggered
-	} else if !result.Triggered && state.Triggered {
-		state.Triggered = false
-		checkResult.Action = AlertActionCleared
+// advance moves the alert through ok → pending → firing → resolved.
+func (st *AlertState) advance(alert config.Alert, result AlertConditionResult, now time.Time) AlertAction {
+	rec := &st.Record
+	rec.Condition = alert.Condition
+	rec.LastValue = result.Value
+	if rec.State == "" {
+		rec.State = config.AlertStateOK
 	}
 
-	return checkResult
+	switch {
+	case result.Triggered:
+		if rec.State != config.AlertStateFiring {
+			rec.State, rec.Since, rec.LastNotified = config.AlertStateFiring, now, now
+			st.Triggered, st.LastTriggered, st.LastValue = true, now, result.Value
+			return AlertActionTriggered
+		}
+		st.Triggered = true
+		if repeat := alert.RepeatDuration(); repeat > 0 && now.Sub(rec.LastNotified) >= repeat {
+			rec.LastNotified = now
+			return AlertActionRepeat
+		}
+		return AlertActionNone
+
+	case result.Pending:
+		// A firing alert whose window is re-arming (e.g. after a restart)
+		// stays firing rather than flapping back through pending.
+		if rec.State == config.AlertStateFiring || rec.State == config.AlertStatePending {
+			return AlertActionNone
+		}
+		rec.State, rec.Since = config.AlertStatePending, now
+		return AlertActionPending
+
+	case result.Unknown:
+		// Missing status neither fires nor resolves; keep the current state.
+		return AlertActionNone
+
+	default:
+		switch rec.State {
+		case config.AlertStateFiring:
+			rec.State, rec.Since = config.AlertStateResolved, now
+			st.Triggered = false
+			return AlertActionCleared
+		case config.AlertStatePending:
+			rec.State, rec.Since = config.AlertStateOK, now
+		case config.AlertStateOK, config.AlertStateResolved:
+		}
+		return AlertActionNone
+	}
 }
 
 // ActionResult holds the result of executing an alert action.
@@ -105,13 +204,20 @@ type ActionResult struct {
 
 // ExecuteAlertAction executes the action for a triggered alert.
 func ExecuteAlertAction(ctx context.Context, alert config.Alert, value string) ActionResult {
+	return ExecuteAlertTransition(ctx, alert, AlertTransitionFiring, value)
+}
+
+// ExecuteAlertTransition executes the action for an alert lifecycle transition.
+// Webhooks receive the transition in the payload's "state" field; commands see
+// it in SHELLY_ALERT_STATE alongside SHELLY_ALERT_NAME, _DEVICE and _VALUE.
+func ExecuteAlertTransition(ctx context.Context, alert config.Alert, transition, value string) ActionResult {
 	switch {
 	case alert.Action == ActionTypeNotify || alert.Action == "":
 		return ActionResult{Type: ActionTypeNotify}
 
 	case strings.HasPrefix(alert.Action, ActionTypeWebhook+":"):
 		url := strings.TrimPrefix(alert.Action, ActionTypeWebhook+":")
-		result := ExecuteWebhook(ctx, url, alert, value)
+		result := ExecuteWebhook(ctx, url, alert, transition, value)
 		return ActionResult{
 			Type:       ActionTypeWebhook,
 			StatusCode: result.StatusCode,
@@ -120,156 +226,131 @@ func ExecuteAlertAction(ctx context.Context, alert config.Alert, value string) A
 
 	case strings.HasPrefix(alert.Action, ActionTypeCommand+":"):
 		cmdStr := strings.TrimPrefix(alert.Action, ActionTypeCommand+":")
-		result := ExecuteCommand(ctx, cmdStr)
+		result := ExecuteCommand(ctx, cmdStr,
+			"SHELLY_ALERT_NAME="+alert.Name,
+			"SHELLY_ALERT_DEVICE="+alert.Device,
+			"SHELLY_ALERT_STATE="+transition,
+			"SHELLY_ALERT_VALUE="+value,
+		)
 		return ActionResult{
 			Type:   ActionTypeCommand,
 			Output: result.Output,
 			Error:  result.Error,
 		}
 
+	case strings.HasPrefix(alert.Action, ActionTypeNotifier+":"):
+		name, _ := AlertNotifierName(alert.Action)
+		n, ok := config.GetNotifier(name)
+		if !ok {
+			return ActionResult{Type: ActionTypeNotifier, Error: fmt.Errorf("notifier %q not configured", name)}
+		}
+		return ActionResult{Type: ActionTypeNotifier, Error: NotifyAlert(ctx, n, alert, transition, value)}
+
 	default:
 		return ActionResult{Type: ActionTypeUnknown, Error: fmt.Errorf("unknown action: %s", alert.Action)}
 	}
 }
 
-// EvaluateAlertCondition checks if an alert's condition is met.
-func (s *Service) EvaluateAlertCondition(ctx context.Context, alert config.Alert) AlertConditionResult {
-	condition := strings.ToLower(alert.Condition)
-
-	// Check offline/online conditions
-	if condition == "offline" || condition == "online" {
-		return s.evaluateConnectivity(ctx, alert.Device, condition)
-	}
-
-	// For threshold conditions, we need device status
-	return s.evaluateThreshold(ctx, alert.Device, condition)
+// AlertNotifierName returns the notifier referenced by a "notifier:NAME" action.
+func AlertNotifierName(action string) (string, bool) {
+	name, ok := strings.CutPrefix(action, ActionTypeNotifier+":")
+	return name, ok && name != ""
 }
 
-// evaluateConnectivity checks online/offline conditions.
-func (s *Service) evaluateConnectivity(ctx context.Context, device, condition string) AlertConditionResult {
-	conn, err := s.Connect(ctx, device)
-	if err != nil {
-		return AlertConditionResult{
-			Triggered: condition == "offline",
-			Value:     statusUnreachable,
-		}
-	}
-	defer iostreams.CloseWithDebug("closing alert connectivity check", conn)
-
-	// Device is reachable
-	return AlertConditionResult{
-		Triggered: condition == "online",
-		Value:     "reachable",
-	}
+// NotifyAlert delivers an alert transition through a configured notifier.
+func NotifyAlert(ctx context.Context, n config.Notifier, alert config.Alert, transition, value string) error {
+	return notifier.Send(ctx, n, notifier.Message{
+		Alert:     alert.Name,
+		Device:    alert.Device,
+		Condition: alert.Condition,
+		State:     transition,
+		Value:     value,
+		Time:      time.Now(),
+	})
 }
 
-// evaluateThreshold checks threshold-based conditions (power, temperature, etc.).
-func (s *Service) evaluateThreshold(ctx context.Context, device, condition string) AlertConditionResult {
-	conn, err := s.Connect(ctx, device)
-	if err != nil {
-		return AlertConditionResult{Triggered: false, Value: statusUnreachable}
-	}
-	defer iostreams.CloseWithDebug("closing alert threshold check", conn)
-
-	result, err := conn.Call(ctx, "Shelly.GetStatus", nil)
+// EvaluateAlertCondition checks if an alert's condition is met right now.
+// Sustained-duration windows are treated as satisfied and hysteresis is not
+// applied; use CheckAlert to evaluate across successive polls.
+func (s *Service) EvaluateAlertCondition(ctx context.Context, alert config.Alert) AlertConditionResult {
+	cond, err := alerting.Parse(alert.Condition)
 	if err != nil {
-		return AlertConditionResult{Triggered: false, Value: "error"}
-	}
-
-	status, ok := result.(map[string]any)
-	if !ok {
 		return AlertConditionResult{Triggered: false, Value: alertValueInvalid}
 	}
 
-	return parseThresholdCondition(status, condition)
+	snap := s.AlertSnapshot(ctx, alert.Device, cond.NeedsStatus())
+	return alertConditionResult(cond.EvaluateInstant(snap), snap)
 }
 
-// parseThresholdCondition parses and evaluates conditions like "power>100" or "temperature<30".
-func parseThresholdCondition(status map[string]any, condition string) AlertConditionResult {
-	if strings.Contains(condition, ">") {
-		return checkThreshold(status, condition, ">", func(current, threshold float64) bool {
-			return current > threshold
-		})
+// evaluatorFor returns the evaluator for the alert's condition, rebuilding it
+// when the condition has changed. It returns nil for an invalid condition.
+func (st *AlertState) evaluatorFor(alert config.Alert) *alerting.Evaluator {
+	if st.evaluator == nil || st.evaluator.Condition().Source() != alert.Condition {
+		cond, err := alerting.Parse(alert.Condition)
+		if err != nil {
+			return nil
+		}
+		st.evaluator = alerting.NewEvaluator(cond)
 	}
+	return st.evaluator
+}
 
-	if strings.Contains(condition, "<") {
-		return checkThreshold(status, condition, "<", func(current, threshold float64) bool {
-			return current < threshold
-		})
+// evaluate evaluates an alert against a snapshot using the evaluator held in state.
+func (st *AlertState) evaluate(alert config.Alert, snap alerting.Snapshot) AlertConditionResult {
+	ev := st.evaluatorFor(alert)
+	if ev == nil {
+		return AlertConditionResult{Triggered: false, Value: alertValueInvalid}
 	}
-
-	return AlertConditionResult{Triggered: false, Value: "unknown condition"}
+	return alertConditionResult(ev.Evaluate(snap), snap)
 }
 
-// checkThreshold evaluates a threshold condition.
-func checkThreshold(status map[string]any, condition, separator string, compare func(float64, float64) bool) AlertConditionResult {
-	parts := strings.SplitN(condition, separator, 2)
-	if len(parts) != 2 {
-		return AlertConditionResult{Triggered: false, Value: "invalid condition"}
-	}
+// AlertSnapshot captures the reachability and, when requested, the full status
+// of a device for condition evaluation.
+func (s *Service) AlertSnapshot(ctx context.Context, device string, withStatus bool) alerting.Snapshot {
+	snap := alerting.Snapshot{Time: time.Now()}
 
-	metric := parts[0]
-	threshold, err := strconv.ParseFloat(parts[1], 64)
+	conn, err := s.Connect(ctx, device)
 	if err != nil {
-		return AlertConditionResult{Triggered: false, Value: "invalid threshold"}
+		return snap
 	}
+	defer iostreams.CloseWithDebug("closing alert status check", conn)
+	snap.Online = true
 
-	currentValue := getMetricValue(status, metric)
-	return AlertConditionResult{
-		Triggered: compare(currentValue, threshold),
-		Value:     fmt.Sprintf("%.1f", currentValue),
+	if !withStatus {
+		return snap
 	}
-}
 
-// getMetricValue extracts a metric value from device status.
-func getMetricValue(status map[string]any, metric string) float64 {
-	metric = strings.ToLower(metric)
-	componentPrefixes := []string{componentPrefixSwitch, "pm1:0", componentPrefixEM, componentPrefixTemp, "cover:0"}
-
-	for _, prefix := range componentPrefixes {
-		comp, ok := status[prefix].(map[string]any)
-		if !ok {
-			continue
-		}
-		if v := extractMetric(comp, metric); v != 0 {
-			return v
-		}
+	result, err := conn.Call(ctx, "Shelly.GetStatus", nil)
+	if err != nil {
+		iostreams.DebugErr("alert status for "+device, err)
+		return snap
+	}
+	if status, ok := result.(map[string]any); ok {
+		snap.Status = status
 	}
-	return 0
+	return snap
 }
 
-// extractMetric extracts a specific metric from a component.
-func extractMetric(comp map[string]any, metric string) float64 {
-	switch metric {
-	case metricPower, metricApower:
-		if v, ok := comp[metricApower].(float64); ok {
-			return v
-		}
-	case metricTemperature, "temp":
-		return extractTemperature(comp)
-	case "voltage":
-		if v, ok := comp["voltage"].(float64); ok {
-			return v
-		}
-	case "current":
-		if v, ok := comp["current"].(float64); ok {
-			return v
-		}
+// LoadAlertSnapshot reads a recorded status snapshot for condition evaluation.
+func LoadAlertSnapshot(path string) (alerting.Snapshot, error) {
+	data, err := afero.ReadFile(config.Fs(), path)
+	if err != nil {
+		return alerting.Snapshot{}, fmt.Errorf("read snapshot: %w", err)
 	}
-	return 0
+	return alerting.ParseSnapshot(data)
 }
 
-// extractTemperature extracts temperature from a component.
-func extractTemperature(comp map[string]any) float64 {
-	if v, ok := comp[metricTemperature].(map[string]any); ok {
-		if tC, ok := v["tC"].(float64); ok {
-			return tC
+// alertConditionResult converts an evaluation result to the value reported
+// for an alert, falling back to reachability for online/offline conditions.
+func alertConditionResult(res alerting.Result, snap alerting.Snapshot) AlertConditionResult {
+	value := res.Value()
+	if value == "" {
+		value = "reachable"
+		if !snap.Online {
+			value = statusUnreachable
 		}
 	}
-	if v, ok := comp["tC"].(float64); ok {
-		return v
-	}
-	return 0
+	return AlertConditionResult{Triggered: res.Triggered, Pending: res.Pending, Unknown: res.Unknown, Value: value}
 }
 
 // WebhookResult holds the result of executing a webhook.
@@ -279,9 +360,10 @@ type WebhookResult struct {
 }
 
 // ExecuteWebhook sends an HTTP POST to the specified URL with alert data.
-func ExecuteWebhook(ctx context.Context, url string, alert config.Alert, value string) WebhookResult {
-	payload := fmt.Sprintf(`{"alert":%q,"device":%q,"condition":%q,"value":%q,"timestamp":%q}`,
-		alert.Name, alert.Device, alert.Condition, value, time.Now().Format(time.RFC3339))
+// state is the lifecycle transition being reported (firing, repeat, resolved).
+func ExecuteWebhook(ctx context.Context, url string, alert config.Alert, state, value string) WebhookResult {
+	payload := fmt.Sprintf(`{"alert":%q,"device":%q,"condition":%q,"state":%q,"value":%q,"timestamp":%q}`,
+		alert.Name, alert.Device, alert.Condition, state, value, time.Now().Format(time.RFC3339))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(payload))
 	if err != nil {
@@ -334,10 +416,73 @@ func (s *Service) CheckAlerts(ctx context.Context, alerts map[string]config.Aler
 	return results
 }
 
-// ExecuteCommand runs a shell command.
-func ExecuteCommand(ctx context.Context, command string) CommandResult {
+// LoadAlertStates restores per-alert lifecycle state from the persisted
+// records. Records for alerts whose condition has since changed are dropped so
+// a redefined alert starts from ok.
+func LoadAlertStates(mgr *config.Manager, alerts map[string]config.Alert) (map[string]*AlertState, error) {
+	states := make(map[string]*AlertState, len(alerts))
+	for name := range alerts {
+		states[name] = &AlertState{}
+	}
+
+	records, err := mgr.LoadAlertStates()
+	if err != nil {
+		return states, err
+	}
+	for name, rec := range records {
+		alert, ok := alerts[name]
+		if !ok || rec.Condition != alert.Condition {
+			continue
+		}
+		states[name].Record = rec
+		states[name].Triggered = rec.State == config.AlertStateFiring
+		states[name].LastValue = rec.LastValue
+	}
+	return states, nil
+}
+
+// RecordAlertResults persists the lifecycle state of every alert and appends a
+// history entry for each transition in results.
+func RecordAlertResults(mgr *config.Manager, results []AlertCheckResult, states map[string]*AlertState) error {
+	records := make(map[string]config.AlertRecord, len(states))
+	for name, st := range states {
+		if st.Record.State != "" {
+			records[name] = st.Record
+		}
+	}
+	if err := mgr.SaveAlertStates(records); err != nil {
+		return err
+	}
+
+	entries := make([]config.AlertHistoryEntry, 0, len(results))
+	for _, r := range results {
+		transition := r.Action.Transition()
+		if transition == "" {
+			continue
+		}
+		entry := config.AlertHistoryEntry{
+			Time:       time.Now(),
+			Alert:      r.Name,
+			Device:     r.Device,
+			Condition:  r.Condition,
+			Transition: transition,
+			Value:      r.Value,
+		}
+		if st, ok := states[r.Name]; ok {
+			entry.State = st.Record.State
+		}
+		entries = append(entries, entry)
+	}
+	return mgr.AppendAlertHistory(entries...)
+}
+
+// ExecuteCommand runs a shell command with optional extra environment variables.
+func ExecuteCommand(ctx context.Context, command string, env ...string) CommandResult {
 	//nolint:gosec // G204: alert commands are user-configured shell actions executed by design.
 	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -123,302 +123,6 @@ func TestActionTypeConstants(t *testing.T) {
 	}
 }
 
-func TestParseThresholdCondition(t *testing.T) {
-	t.Parallel()
-
-	t.Run("power greater than", func(t *testing.T) {
-		t.Parallel()
-
-		status := map[string]any{
-			"switch:0": map[string]any{
-				"apower": 150.0,
-			},
-		}
-
-		result := parseThresholdCondition(status, "power>100")
-
-		if !result.Triggered {
-			t.Error("expected condition to be triggered")
-		}
-		if result.Value != "150.0" {
-			t.Errorf("expected Value '150.0', got %q", result.Value)
-		}
-	})
-
-	t.Run("power less than", func(t *testing.T) {
-		t.Parallel()
-
-		status := map[string]any{
-			"switch:0": map[string]any{
-				"apower": 50.0,
-			},
-		}
-
-		result := parseThresholdCondition(status, "power<100")
-
-		if !result.Triggered {
-			t.Error("expected condition to be triggered")
-		}
-	})
-
-	t.Run("power not triggered", func(t *testing.T) {
-		t.Parallel()
-
-		status := map[string]any{
-			"switch:0": map[string]any{
-				"apower": 50.0,
-			},
-		}
-
-		result := parseThresholdCondition(status, "power>100")
-
-		if result.Triggered {
-			t.Error("expected condition NOT to be triggered")
-		}
-	})
-
-	t.Run("unknown condition", func(t *testing.T) {
-		t.Parallel()
-
-		status := map[string]any{}
-
-		result := parseThresholdCondition(status, "power=100")
-
-		if result.Triggered {
-			t.Error("expected unknown condition to not trigger")
-		}
-		if result.Value != "unknown condition" {
-			t.Errorf("expected Value 'unknown condition', got %q", result.Value)
-		}
-	})
-}
-
-func TestCheckThreshold(t *testing.T) {
-	t.Parallel()
-
-	t.Run("valid threshold comparison", func(t *testing.T) {
-		t.Parallel()
-
-		status := map[string]any{
-			"switch:0": map[string]any{
-				"apower": 200.0,
-			},
-		}
-
-		result := checkThreshold(status, "power>100", ">", func(current, threshold float64) bool {
-			return current > threshold
-		})
-
-		if !result.Triggered {
-			t.Error("expected condition to be triggered")
-		}
-	})
-
-	t.Run("invalid condition format", func(t *testing.T) {
-		t.Parallel()
-
-		status := map[string]any{}
-
-		result := checkThreshold(status, "power", ">", func(current, threshold float64) bool {
-			return current > threshold
-		})
-
-		if result.Triggered {
-			t.Error("expected invalid condition to not trigger")
-		}
-		if result.Value != "invalid condition" {
-			t.Errorf("expected Value 'invalid condition', got %q", result.Value)
-		}
-	})
-
-	t.Run("invalid threshold value", func(t *testing.T) {
-		t.Parallel()
-
-		status := map[string]any{}
-
-		result := checkThreshold(status, "power>abc", ">", func(current, threshold float64) bool {
-			return current > threshold
-		})
-
-		if result.Triggered {
-			t.Error("expected invalid threshold to not trigger")
-		}
-		if result.Value != "invalid threshold" {
-			t.Errorf("expected Value 'invalid threshold', got %q", result.Value)
-		}
-	})
-}
-
-func TestGetMetricValue(t *testing.T) {
-	t.Parallel()
-
-	t.Run("extracts power from switch:0", func(t *testing.T) {
-		t.Parallel()
-
-		status := map[string]any{
-			"switch:0": map[string]any{
-				"apower": 100.5,
-			},
-		}
-
-		value := getMetricValue(status, "power")
-
-		if value != 100.5 {
-			t.Errorf("expected 100.5, got %f", value)
-		}
-	})
-
-	t.Run("extracts temperature from temperature:0", func(t *testing.T) {
-		t.Parallel()
-
-		status := map[string]any{
-			"temperature:0": map[string]any{
-				"tC": 25.5,
-			},
-		}
-
-		value := getMetricValue(status, "temperature")
-
-		if value != 25.5 {
-			t.Errorf("expected 25.5, got %f", value)
-		}
-	})
-
-	t.Run("extracts voltage", func(t *testing.T) {
-		t.Parallel()
-
-		status := map[string]any{
-			"pm1:0": map[string]any{
-				"voltage": 230.5,
-			},
-		}
-
-		value := getMetricValue(status, "voltage")
-
-		if value != 230.5 {
-			t.Errorf("expected 230.5, got %f", value)
-		}
-	})
-
-	t.Run("extracts current", func(t *testing.T) {
-		t.Parallel()
-
-		status := map[string]any{
-			"em:0": map[string]any{
-				"current": 5.2,
-			},
-		}
-
-		value := getMetricValue(status, "current")
-
-		if value != 5.2 {
-			t.Errorf("expected 5.2, got %f", value)
-		}
-	})
-
-	t.Run("returns 0 for missing metric", func(t *testing.T) {
-		t.Parallel()
-
-		status := map[string]any{}
-
-		value := getMetricValue(status, "power")
-
-		if value != 0 {
-			t.Errorf("expected 0, got %f", value)
-		}
-	})
-}
-
-func TestExtractMetric(t *testing.T) {
-	t.Parallel()
-
-	t.Run("extracts apower", func(t *testing.T) {
-		t.Parallel()
-
-		comp := map[string]any{
-			"apower": 150.0,
-		}
-
-		value := extractMetric(comp, "power")
-		if value != 150.0 {
-			t.Errorf("expected 150.0, got %f", value)
-		}
-	})
-
-	t.Run("extracts temperature with nested structure", func(t *testing.T) {
-		t.Parallel()
-
-		comp := map[string]any{
-			"temperature": map[string]any{
-				"tC": 22.5,
-			},
-		}
-
-		value := extractMetric(comp, "temperature")
-		if value != 22.5 {
-			t.Errorf("expected 22.5, got %f", value)
-		}
-	})
-
-	t.Run("returns 0 for unknown metric", func(t *testing.T) {
-		t.Parallel()
-
-		comp := map[string]any{
-			"apower": 100.0,
-		}
-
-		value := extractMetric(comp, "unknown")
-		if value != 0 {
-			t.Errorf("expected 0, got %f", value)
-		}
-	})
-}
-
-func TestExtractTemperature(t *testing.T) {
-	t.Parallel()
-
-	t.Run("from nested temperature map", func(t *testing.T) {
-		t.Parallel()
-
-		comp := map[string]any{
-			"temperature": map[string]any{
-				"tC": 30.5,
-			},
-		}
-
-		value := extractTemperature(comp)
-		if value != 30.5 {
-			t.Errorf("expected 30.5, got %f", value)
-		}
-	})
-
-	t.Run("from direct tC field", func(t *testing.T) {
-		t.Parallel()
-
-		comp := map[string]any{
-			"tC": 28.0,
-		}
-
-		value := extractTemperature(comp)
-		if value != 28.0 {
-			t.Errorf("expected 28.0, got %f", value)
-		}
-	})
-
-	t.Run("returns 0 when no temperature", func(t *testing.T) {
-		t.Parallel()
-
-		comp := map[string]any{
-			"power": 100.0,
-		}
-
-		value := extractTemperature(comp)
-		if value != 0 {
-			t.Errorf("expected 0, got %f", value)
-		}
-	})
-}
-
 func TestWebhookResult_Fields(t *testing.T) {
 	t.Parallel()
 
@@ -549,3 +253,115 @@ func TestExecuteAlertAction(t *testing.T) {
 		}
 	})
 }
+
+func TestAlertState_Advance(t *testing.T) {
+	t.Parallel()
+
+	alert := config.Alert{Name: "high-power", Condition: "power>100 for 1m", RepeatInterval: "10m"}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	state := &AlertState{}
+
+	steps := []struct {
+		offset time.Duration
+		result AlertConditionResult
+		want   AlertAction
+		state  config.AlertLifecycle
+	}{
+		{0, AlertConditionResult{Pending: true}, AlertActionPending, config.AlertStatePending},
+		{30 * time.Second, AlertConditionResult{Pending: true}, AlertActionNone, config.AlertStatePending},
+		{time.Minute, AlertConditionResult{Triggered: true}, AlertActionTriggered, config.AlertStateFiring},
+		{2 * time.Minute, AlertConditionResult{Triggered: true}, AlertActionNone, config.AlertStateFiring},
+		{3 * time.Minute, AlertConditionResult{Pending: true}, AlertActionNone, config.AlertStateFiring},
+		{4 * time.Minute, AlertConditionResult{Unknown: true}, AlertActionNone, config.AlertStateFiring},
+		{11 * time.Minute, AlertConditionResult{Triggered: true}, AlertActionRepeat, config.AlertStateFiring},
+		{12 * time.Minute, AlertConditionResult{Triggered: true}, AlertActionNone, config.AlertStateFiring},
+		{13 * time.Minute, AlertConditionResult{}, AlertActionCleared, config.AlertStateResolved},
+		{14 * time.Minute, AlertConditionResult{}, AlertActionNone, config.AlertStateResolved},
+	}
+
+	for i, step := range steps {
+		got := state.advance(alert, step.result, start.Add(step.offset))
+		if got != step.want || state.Record.State != step.state {
+			t.Errorf("step %d: action=%v state=%q, want %v/%q", i, got, state.Record.State, step.want, step.state)
+		}
+	}
+}
+
+func TestAlertState_AdvancePendingAborted(t *testing.T) {
+	t.Parallel()
+
+	alert := config.Alert{Name: "a", Condition: "offline for 5m"}
+	now := time.Now()
+	state := &AlertState{}
+
+	if got := state.advance(alert, AlertConditionResult{Pending: true}, now); got != AlertActionPending {
+		t.Fatalf("advance() = %v, want pending", got)
+	}
+	if got := state.advance(alert, AlertConditionResult{}, now.Add(time.Minute)); got != AlertActionNone {
+		t.Errorf("advance() = %v, want none", got)
+	}
+	if state.Record.State != config.AlertStateOK {
+		t.Errorf("state = %q, want ok", state.Record.State)
+	}
+}
+
+func TestAlertAction_TransitionAndNotifies(t *testing.T) {
+	t.Parallel()
+
+	quiet := config.Alert{}
+	loud := config.Alert{NotifyResolved: true}
+
+	tests := []struct {
+		action     AlertAction
+		transition string
+		notifies   bool
+		resolved   bool
+	}{
+		{AlertActionNone, "", false, false},
+		{AlertActionPending, AlertTransitionPending, false, false},
+		{AlertActionTriggered, AlertTransitionFiring, true, true},
+		{AlertActionRepeat, AlertTransitionRepeat, true, true},
+		{AlertActionCleared, AlertTransitionResolved, false, true},
+	}
+	for _, tt := range tests {
+		if got := tt.action.Transition(); got != tt.transition {
+			t.Errorf("Transition(%v) = %q, want %q", tt.action, got, tt.transition)
+		}
+		if got := tt.action.Notifies(quiet); got != tt.notifies {
+			t.Errorf("Notifies(%v) = %v, want %v", tt.action, got, tt.notifies)
+		}
+		if got := tt.action.Notifies(loud); got != tt.resolved {
+			t.Errorf("Notifies(%v, notify_resolved) = %v, want %v", tt.action, got, tt.resolved)
+		}
+	}
+}
+
+func TestLoadAlertStates_InMemory(t *testing.T) {
+	t.Parallel()
+
+	mgr := config.NewTestManager(&config.Config{})
+	alerts := map[string]config.Alert{"a": {Name: "a", Condition: "offline"}}
+
+	states, err := LoadAlertStates(mgr, alerts)
+	if err != nil {
+		t.Fatalf("LoadAlertStates() error = %v", err)
+	}
+	if st, ok := states["a"]; !ok || st.Record.State != "" {
+		t.Errorf("expected fresh state for alert, got %+v", st)
+	}
+	if err := RecordAlertResults(mgr, nil, states); err != nil {
+		t.Errorf("RecordAlertResults() error = %v", err)
+	}
+}
+
+func TestExecuteCommand_Env(t *testing.T) {
+	t.Parallel()
+
+	result := ExecuteCommand(context.Background(), `printf %s "$SHELLY_ALERT_STATE"`, "SHELLY_ALERT_STATE=firing")
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+	if string(result.Output) != "firing" {
+		t.Errorf("Output = %q, want %q", result.Output, "firing")
+	}
+}
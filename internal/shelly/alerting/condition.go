@@ -0,0 +1,615 @@
+// Package alerting provides the alert condition language and its evaluator.
+//
+// Conditions reference device status by component-qualified paths and can be
+// combined with boolean operators, sustained-duration windows and hysteresis:
+//
+//	switch:0.apower > 1500 clear < 1200 for 5m and sys.ram_free < 20000
+//	offline for 2m
+//	not switch:0.output or cover:0.state == "stopped"
+//	event input:0.long_push or switch:0.errors == overpower
+//
+// The legacy shorthands accepted by earlier releases (offline, online,
+// power>N, temperature<N, voltage>N, current>N) remain valid conditions.
+package alerting
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Condition is a parsed alert condition.
+type Condition struct {
+	source string
+	root   node
+}
+
+// Parse parses an alert condition expression.
+func Parse(source string) (*Condition, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("empty condition")
+	}
+
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+
+	return &Condition{source: source, root: root}, nil
+}
+
+// Validate reports whether source is a valid condition expression.
+func Validate(source string) error {
+	_, err := Parse(source)
+	return err
+}
+
+// Source returns the original condition text.
+func (c *Condition) Source() string {
+	return c.source
+}
+
+// String returns the normalized form of the condition.
+func (c *Condition) String() string {
+	return c.root.String()
+}
+
+// NeedsStatus reports whether evaluating the condition requires device status,
+// as opposed to reachability alone.
+func (c *Condition) NeedsStatus() bool {
+	return len(c.Paths()) > 0
+}
+
+// UsesEvents reports whether the condition tests device events, which are
+// only seen on streamed devices.
+func (c *Condition) UsesEvents() bool {
+	found := false
+	walk(c.root, func(n node) {
+		if _, ok := n.(*eventNode); ok {
+			found = true
+		}
+	})
+	return found
+}
+
+// Paths returns the status paths referenced by the condition, in order of appearance.
+func (c *Condition) Paths() []string {
+	var paths []string
+	seen := make(map[string]bool)
+	walk(c.root, func(n node) {
+		if cmp, ok := n.(*comparison); ok && !seen[cmp.path] {
+			seen[cmp.path] = true
+			paths = append(paths, cmp.path)
+		}
+	})
+	return paths
+}
+
+// =============================================================================
+// AST
+// =============================================================================
+
+// node is an element of a parsed condition.
+type node interface {
+	eval(ec *evalContext) truth
+	children() []node
+	String() string
+}
+
+type orNode struct{ terms []node }
+
+type andNode struct{ terms []node }
+
+type notNode struct{ inner node }
+
+type sustainedNode struct {
+	inner    node
+	duration time.Duration
+}
+
+// reachabilityNode matches the online/offline keywords.
+type reachabilityNode struct{ online bool }
+
+// eventNode matches a device event (NotifyEvent) received since the previous
+// snapshot. An empty component matches the event from any component.
+type eventNode struct {
+	component string
+	event     string
+}
+
+// comparison compares a status path against a literal value, optionally with
+// a separate clear predicate that must hold before a latched match releases.
+type comparison struct {
+	path  string
+	op    string
+	value literal
+	clear *predicate
+}
+
+// predicate is an operator/literal pair.
+type predicate struct {
+	op    string
+	value literal
+}
+
+// literal is a typed constant in a condition.
+type literal struct {
+	kind literalKind
+	num  float64
+	str  string
+	b    bool
+}
+
+type literalKind int
+
+const (
+	literalNumber literalKind = iota
+	literalString
+	literalBool
+)
+
+func (n *orNode) children() []node           { return n.terms }
+func (n *andNode) children() []node          { return n.terms }
+func (n *notNode) children() []node          { return []node{n.inner} }
+func (n *sustainedNode) children() []node    { return []node{n.inner} }
+func (n *reachabilityNode) children() []node { return nil }
+func (n *eventNode) children() []node        { return nil }
+func (n *comparison) children() []node       { return nil }
+
+func (n *orNode) String() string  { return joinNodes(n.terms, " or ") }
+func (n *andNode) String() string { return joinNodes(n.terms, " and ") }
+func (n *notNode) String() string { return "not " + wrap(n.inner) }
+
+func (n *sustainedNode) String() string {
+	return wrap(n.inner) + " for " + n.duration.String()
+}
+
+func (n *reachabilityNode) String() string {
+	if n.online {
+		return kwOnline
+	}
+	return kwOffline
+}
+
+func (n *eventNode) String() string {
+	if n.component == "" {
+		return kwEvent + " " + n.event
+	}
+	return kwEvent + " " + n.component + "." + n.event
+}
+
+func (n *comparison) String() string {
+	if n.op == "" {
+		return n.path
+	}
+	s := n.path + " " + n.op + " " + n.value.String()
+	if n.clear != nil {
+		s += " clear " + n.clear.op + " " + n.clear.value.String()
+	}
+	return s
+}
+
+func (l literal) String() string {
+	switch l.kind {
+	case literalString:
+		return strconv.Quote(l.str)
+	case literalBool:
+		return strconv.FormatBool(l.b)
+	default:
+		return strconv.FormatFloat(l.num, 'f', -1, 64)
+	}
+}
+
+func joinNodes(nodes []node, sep string) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		parts[i] = wrap(n)
+	}
+	return strings.Join(parts, sep)
+}
+
+// wrap parenthesizes compound nodes so String output re-parses identically.
+func wrap(n node) string {
+	switch n.(type) {
+	case *orNode, *andNode, *sustainedNode:
+		return "(" + n.String() + ")"
+	default:
+		return n.String()
+	}
+}
+
+func walk(n node, fn func(node)) {
+	fn(n)
+	for _, child := range n.children() {
+		walk(child, fn)
+	}
+}
+
+// =============================================================================
+// Lexer
+// =============================================================================
+
+// Keywords recognized by the parser (case-insensitive).
+const (
+	kwAnd     = "and"
+	kwOr      = "or"
+	kwNot     = "not"
+	kwFor     = "for"
+	kwClear   = "clear"
+	kwOnline  = "online"
+	kwOffline = "offline"
+	kwEvent   = "event"
+	kwTrue    = "true"
+	kwFalse   = "false"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokNumber
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokString, text: string(runes[i+1 : end]), pos: i})
+			i = end + 1
+		case strings.ContainsRune("<>=!&|", r):
+			op, n := lexOperator(runes[i:])
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at position %d", string(r), i)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += n
+		case isNumberStart(runes, i):
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.' || unicode.IsLetter(runes[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[i:end]), pos: i})
+			i = end
+		case isWordRune(r):
+			end := i + 1
+			for end < len(runes) && isWordRune(runes[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokWord, text: string(runes[i:end]), pos: i})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", string(r), i)
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(runes)}), nil
+}
+
+// lexOperator returns the operator at the start of runes and its length.
+func lexOperator(runes []rune) (op string, n int) {
+	if len(runes) >= 2 {
+		switch two := string(runes[:2]); two {
+		case ">=", "<=", "==", "!=", "&&", "||":
+			return two, 2
+		}
+	}
+	switch runes[0] {
+	case '>', '<', '!':
+		return string(runes[0]), 1
+	case '=':
+		return "==", 1
+	}
+	return "", 0
+}
+
+func isNumberStart(runes []rune, i int) bool {
+	r := runes[i]
+	if unicode.IsDigit(r) {
+		return true
+	}
+	return (r == '-' || r == '.') && i+1 < len(runes) && unicode.IsDigit(runes[i+1])
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == ':' || r == '.' || r == '-'
+}
+
+// =============================================================================
+// Parser
+// =============================================================================
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// acceptKeyword consumes the next token if it is one of the given keywords or operators.
+func (p *parser) acceptKeyword(words ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokWord && tok.kind != tokOp {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(tok.text, w) {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	terms := []node{first}
+	for p.acceptKeyword(kwOr, "||") {
+		term, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return &orNode{terms: terms}, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	terms := []node{first}
+	for p.acceptKeyword(kwAnd, "&&") {
+		term, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return &andNode{terms: terms}, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.acceptKeyword(kwNot, "!") {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{inner: inner}, nil
+	}
+
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.acceptKeyword(kwFor) {
+		tok := p.next()
+		if tok.kind != tokNumber {
+			return nil, fmt.Errorf("expected duration after %q at position %d", kwFor, tok.pos)
+		}
+		d, err := time.ParseDuration(tok.text)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid duration %q at position %d (use e.g. 30s, 5m, 1h)", tok.text, tok.pos)
+		}
+		n = &sustainedNode{inner: n, duration: d}
+	}
+
+	return n, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("expected ')' at position %d", closing.pos)
+		}
+		return inner, nil
+
+	case tokWord:
+		switch strings.ToLower(tok.text) {
+		case kwOnline:
+			return &reachabilityNode{online: true}, nil
+		case kwOffline:
+			return &reachabilityNode{online: false}, nil
+		case kwEvent:
+			return p.parseEvent(tok)
+		case kwAnd, kwOr, kwNot, kwFor, kwClear:
+			return nil, fmt.Errorf("unexpected keyword %q at position %d", tok.text, tok.pos)
+		}
+		return p.parseComparison(tok)
+
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of condition")
+
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+}
+
+// parseEvent parses "event NAME" or "event COMPONENT.NAME",
+// e.g. "event long_push" or "event input:0.single_push".
+func (p *parser) parseEvent(kwTok token) (node, error) {
+	tok := p.next()
+	if tok.kind != tokWord {
+		return nil, fmt.Errorf("expected event name after %q at position %d", kwEvent, kwTok.pos)
+	}
+	name := tok.text
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+		return nil, fmt.Errorf("invalid event %q at position %d", name, tok.pos)
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return &eventNode{component: name[:i], event: name[i+1:]}, nil
+	}
+	return &eventNode{event: name}, nil
+}
+
+func (p *parser) parseComparison(pathTok token) (node, error) {
+	path, err := normalizePath(pathTok.text)
+	if err != nil {
+		return nil, fmt.Errorf("%w at position %d", err, pathTok.pos)
+	}
+
+	cmp := &comparison{path: path}
+
+	// A bare path is a truthiness test, e.g. "switch:0.output".
+	if !isComparisonOp(p.peek()) {
+		return cmp, nil
+	}
+
+	pred, err := p.parsePredicate()
+	if err != nil {
+		return nil, err
+	}
+	cmp.op, cmp.value = pred.op, pred.value
+
+	if p.acceptKeyword(kwClear) {
+		clearPred, err := p.parsePredicate()
+		if err != nil {
+			return nil, err
+		}
+		if !isOrdered(cmp.op) || !isOrdered(clearPred.op) || clearPred.value.kind != literalNumber {
+			return nil, fmt.Errorf("%q requires numeric thresholds with <, <=, > or >=", kwClear)
+		}
+		cmp.clear = &clearPred
+	}
+
+	return cmp, nil
+}
+
+func (p *parser) parsePredicate() (predicate, error) {
+	opTok := p.next()
+	if opTok.kind != tokOp || !isComparisonOp(opTok) {
+		return predicate{}, fmt.Errorf("expected comparison operator at position %d", opTok.pos)
+	}
+
+	valTok := p.next()
+	var val literal
+	switch valTok.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(valTok.text, 64)
+		if err != nil {
+			return predicate{}, fmt.Errorf("invalid number %q at position %d", valTok.text, valTok.pos)
+		}
+		val = literal{kind: literalNumber, num: f}
+	case tokString:
+		val = literal{kind: literalString, str: valTok.text}
+	case tokWord:
+		switch strings.ToLower(valTok.text) {
+		case kwTrue:
+			val = literal{kind: literalBool, b: true}
+		case kwFalse:
+			val = literal{kind: literalBool, b: false}
+		default:
+			val = literal{kind: literalString, str: valTok.text}
+		}
+	default:
+		return predicate{}, fmt.Errorf("expected value at position %d", valTok.pos)
+	}
+
+	if isOrdered(opTok.text) && val.kind != literalNumber {
+		return predicate{}, fmt.Errorf("operator %q requires a numeric value at position %d", opTok.text, valTok.pos)
+	}
+
+	return predicate{op: opTok.text, value: val}, nil
+}
+
+func isComparisonOp(tok token) bool {
+	if tok.kind != tokOp {
+		return false
+	}
+	switch tok.text {
+	case ">", ">=", "<", "<=", "==", "!=":
+		return true
+	}
+	return false
+}
+
+func isOrdered(op string) bool {
+	switch op {
+	case ">", ">=", "<", "<=":
+		return true
+	}
+	return false
+}
+
+// normalizePath validates a status path and lowercases legacy metric names.
+func normalizePath(path string) (string, error) {
+	if strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") || strings.Contains(path, "..") {
+		return "", fmt.Errorf("invalid path %q", path)
+	}
+	if !strings.Contains(path, ".") {
+		lower := strings.ToLower(path)
+		if _, ok := legacyMetrics[lower]; ok {
+			return lower, nil
+		}
+		return "", fmt.Errorf("unknown metric %q (use a component path such as switch:0.apower)", path)
+	}
+	return path, nil
+}
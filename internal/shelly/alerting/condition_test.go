@@ -0,0 +1,164 @@
+package alerting
+
+import (
+	"testing"
+)
+
+func TestParse_Valid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"offline", "offline", "offline"},
+		{"online uppercase", "ONLINE", "online"},
+		{"legacy power", "power>100", "power > 100"},
+		{"legacy temperature", "Temperature<-5", "temperature < -5"},
+		{"component path", "switch:0.apower > 1500", "switch:0.apower > 1500"},
+		{"nested path", "switch:0.temperature.tC >= 70", "switch:0.temperature.tC >= 70"},
+		{"single equals", "cover:0.state = open", `cover:0.state == "open"`},
+		{"quoted string", `cover:0.state != "stopped"`, `cover:0.state != "stopped"`},
+		{"bool", "switch:0.output == true", "switch:0.output == true"},
+		{"bare path", "switch:0.output", "switch:0.output"},
+		{"duration", "switch:0.apower > 1500 for 5m", "switch:0.apower > 1500 for 5m0s"},
+		{"hysteresis", "switch:0.apower > 1500 clear < 1200", "switch:0.apower > 1500 clear < 1200"},
+		{
+			"and with duration",
+			"switch:0.apower > 1500 for 5m and sys.ram_free < 20000",
+			"(switch:0.apower > 1500 for 5m0s) and sys.ram_free < 20000",
+		},
+		{"or and precedence", "offline or power>1 and voltage<200", "offline or (power > 1 and voltage < 200)"},
+		{"symbolic operators", "!(offline || online) && power>1", "not (offline or online) and power > 1"},
+		{"grouped duration", "(offline or power<1) for 30s", "(offline or power < 1) for 30s"},
+		{"event any component", "EVENT long_push", "event long_push"},
+		{"event component", "event input:0.single_push or offline", "event input:0.single_push or offline"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cond, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if got := cond.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if cond.Source() != tt.input {
+				t.Errorf("Source() = %q, want %q", cond.Source(), tt.input)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", "   "},
+		{"unknown bare metric", "watts>100"},
+		{"missing value", "power>"},
+		{"missing operator value", "switch:0.apower >"},
+		{"ordered string", `cover:0.state > "open"`},
+		{"bad duration", "offline for 5"},
+		{"missing duration", "offline for"},
+		{"unbalanced paren", "(offline or online"},
+		{"trailing token", "offline online"},
+		{"dangling and", "offline and"},
+		{"unterminated string", `cover:0.state == "open`},
+		{"clear on equality", "switch:0.apower == 1 clear < 1"},
+		{"clear with bool", "switch:0.apower > 1 clear == true"},
+		{"bad path", "switch:0..apower > 1"},
+		{"bad character", "power > 1 ; rm"},
+		{"event without name", "event"},
+		{"event with operator", "event > 1"},
+		{"event trailing dot", "event input:0."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := Parse(tt.input); err == nil {
+				t.Errorf("Parse(%q) expected error", tt.input)
+			}
+		})
+	}
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		"switch:0.apower > 1500 clear < 1200 for 5m and sys.ram_free < 20000",
+		"not (offline or power<1) for 1m",
+		`cover:0.state == "open" or switch:0.output`,
+	}
+
+	for _, input := range inputs {
+		cond, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", input, err)
+		}
+		again, err := Parse(cond.String())
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", cond.String(), err)
+		}
+		if again.String() != cond.String() {
+			t.Errorf("round trip = %q, want %q", again.String(), cond.String())
+		}
+	}
+}
+
+func TestCondition_Paths(t *testing.T) {
+	t.Parallel()
+
+	cond, err := Parse("switch:0.apower > 1 and (sys.ram_free < 2 or switch:0.apower > 3) or offline")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	paths := cond.Paths()
+	if len(paths) != 2 || paths[0] != "switch:0.apower" || paths[1] != "sys.ram_free" {
+		t.Errorf("Paths() = %v, want [switch:0.apower sys.ram_free]", paths)
+	}
+	if !cond.NeedsStatus() {
+		t.Error("NeedsStatus() = false, want true")
+	}
+
+	offline, err := Parse("offline for 2m")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if offline.NeedsStatus() {
+		t.Error("NeedsStatus() = true for offline condition, want false")
+	}
+	if cond.UsesEvents() || offline.UsesEvents() {
+		t.Error("UsesEvents() = true for conditions without events")
+	}
+
+	evt, err := Parse("switch:0.output and not event input:0.long_push")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !evt.UsesEvents() {
+		t.Error("UsesEvents() = false, want true")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	if err := Validate("power>100"); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := Validate("power=>100"); err == nil {
+		t.Error("Validate() expected error")
+	}
+}
@@ -0,0 +1,503 @@
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Snapshot is the device state a condition is evaluated against.
+type Snapshot struct {
+	// Online reports whether the device was reachable.
+	Online bool
+	// Status is the Shelly.GetStatus result, keyed by component (e.g. "switch:0").
+	Status map[string]any
+	// Events lists device events received since the previous snapshot, as
+	// "component.event" (e.g. "input:0.single_push"). Polled snapshots have none.
+	Events []string
+	// Time is when the snapshot was taken. Zero means time.Now().
+	Time time.Time
+}
+
+// ParseSnapshot decodes a recorded Shelly.GetStatus response into an online
+// snapshot. The {"Info": ..., "Status": {...}} document written by
+// "shelly device status -o json" is also accepted.
+func ParseSnapshot(data []byte) (Snapshot, error) {
+	var status map[string]any
+	if err := json.Unmarshal(data, &status); err != nil {
+		return Snapshot{}, fmt.Errorf("parse status snapshot: %w", err)
+	}
+	for _, key := range []string{"Status", "status"} {
+		if inner, ok := status[key].(map[string]any); ok {
+			status = inner
+			break
+		}
+	}
+	return Snapshot{Online: true, Status: status}, nil
+}
+
+// Observation records the value seen for one comparison during evaluation.
+type Observation struct {
+	Path    string
+	Value   string
+	Matched bool
+}
+
+// Result is the outcome of evaluating a condition.
+type Result struct {
+	// Triggered reports whether the condition holds, including any sustained
+	// duration windows and hysteresis.
+	Triggered bool
+	// Pending reports that a clause matched but its "for" window has not elapsed.
+	Pending bool
+	// Unknown reports that the condition could not be decided because a status
+	// value it depends on was missing, typically because the device was
+	// unreachable. Triggered and Pending are false, and clause state is kept.
+	Unknown bool
+	// Observations lists the values seen for each comparison.
+	Observations []Observation
+}
+
+// Value summarizes the observed values for display and notifications.
+// A single comparison yields its bare value; several yield "path=value" pairs.
+func (r Result) Value() string {
+	switch len(r.Observations) {
+	case 0:
+		return ""
+	case 1:
+		return r.Observations[0].Value
+	}
+	parts := make([]string, len(r.Observations))
+	for i, o := range r.Observations {
+		parts[i] = o.Path + "=" + o.Value
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Evaluator evaluates a condition across successive snapshots, keeping the
+// per-clause state needed for "for" windows and "clear" hysteresis.
+type Evaluator struct {
+	cond  *Condition
+	state map[node]*clauseState
+}
+
+// clauseState is the memory kept for a stateful clause between evaluations.
+type clauseState struct {
+	since   time.Time // When a sustained clause first matched
+	latched bool      // Whether a hysteresis comparison is active
+}
+
+// NewEvaluator returns a stateful evaluator for the condition.
+func NewEvaluator(cond *Condition) *Evaluator {
+	return &Evaluator{cond: cond, state: make(map[node]*clauseState)}
+}
+
+// Condition returns the condition being evaluated.
+func (e *Evaluator) Condition() *Condition {
+	return e.cond
+}
+
+// Evaluate evaluates the condition against a snapshot, advancing clause state.
+func (e *Evaluator) Evaluate(snap Snapshot) Result {
+	return evaluate(e.cond, snap, e.state)
+}
+
+// Reset clears all duration and hysteresis state.
+func (e *Evaluator) Reset() {
+	e.state = make(map[node]*clauseState)
+}
+
+// EvaluateInstant evaluates the condition against a single snapshot without
+// history: "for" windows are treated as already satisfied and "clear"
+// thresholds are ignored.
+func (c *Condition) EvaluateInstant(snap Snapshot) Result {
+	return evaluate(c, snap, nil)
+}
+
+type evalContext struct {
+	snap    Snapshot
+	now     time.Time
+	state   map[node]*clauseState
+	pending bool
+	obs     []Observation
+}
+
+// truth is the three-valued outcome of evaluating a node. A comparison whose
+// status value is missing is unknown rather than false, so that "not" and
+// "!=" do not turn true when a device goes offline; only the online/offline
+// keywords match reachability.
+type truth uint8
+
+const (
+	truthFalse truth = iota
+	truthTrue
+	truthUnknown
+)
+
+func truthOf(b bool) truth {
+	if b {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+func evaluate(c *Condition, snap Snapshot, state map[node]*clauseState) Result {
+	now := snap.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	ec := &evalContext{snap: snap, now: now, state: state}
+	outcome := c.root.eval(ec)
+	return Result{
+		Triggered:    outcome == truthTrue,
+		Pending:      outcome == truthFalse && ec.pending,
+		Unknown:      outcome == truthUnknown,
+		Observations: ec.obs,
+	}
+}
+
+// stateFor returns the clause state for n, or nil when evaluating instantly.
+func (ec *evalContext) stateFor(n node) *clauseState {
+	if ec.state == nil {
+		return nil
+	}
+	st, ok := ec.state[n]
+	if !ok {
+		st = &clauseState{}
+		ec.state[n] = st
+	}
+	return st
+}
+
+// Every term is evaluated (no short-circuit) so that duration windows and
+// hysteresis in later terms keep tracking the device independently.
+// Unknown terms follow Kleene logic: a true term decides "or" and a false
+// term decides "and"; otherwise any unknown term makes the result unknown.
+
+func (n *orNode) eval(ec *evalContext) truth {
+	result := truthFalse
+	for _, t := range n.terms {
+		switch t.eval(ec) {
+		case truthTrue:
+			result = truthTrue
+		case truthUnknown:
+			if result == truthFalse {
+				result = truthUnknown
+			}
+		case truthFalse:
+		}
+	}
+	return result
+}
+
+func (n *andNode) eval(ec *evalContext) truth {
+	result := truthTrue
+	for _, t := range n.terms {
+		switch t.eval(ec) {
+		case truthFalse:
+			result = truthFalse
+		case truthUnknown:
+			if result == truthTrue {
+				result = truthUnknown
+			}
+		case truthTrue:
+		}
+	}
+	return result
+}
+
+func (n *notNode) eval(ec *evalContext) truth {
+	switch n.inner.eval(ec) {
+	case truthTrue:
+		return truthFalse
+	case truthFalse:
+		return truthTrue
+	default:
+		return truthUnknown
+	}
+}
+
+func (n *sustainedNode) eval(ec *evalContext) truth {
+	matched := n.inner.eval(ec)
+	st := ec.stateFor(n)
+	if st == nil || matched == truthUnknown {
+		// An unknown poll neither starts nor breaks the window.
+		return matched
+	}
+	if matched == truthFalse {
+		st.since = time.Time{}
+		return truthFalse
+	}
+	if st.since.IsZero() {
+		st.since = ec.now
+	}
+	if ec.now.Sub(st.since) >= n.duration {
+		return truthTrue
+	}
+	ec.pending = true
+	return truthFalse
+}
+
+func (n *reachabilityNode) eval(ec *evalContext) truth {
+	return truthOf(ec.snap.Online == n.online)
+}
+
+func (n *eventNode) eval(ec *evalContext) truth {
+	for _, e := range ec.snap.Events {
+		component, event, _ := strings.Cut(e, ".")
+		if strings.EqualFold(event, n.event) && (n.component == "" || strings.EqualFold(component, n.component)) {
+			ec.obs = append(ec.obs, Observation{Path: kwEvent, Value: e, Matched: true})
+			return truthTrue
+		}
+	}
+	return truthFalse
+}
+
+func (n *comparison) eval(ec *evalContext) truth {
+	raw, found := lookup(ec.snap.Status, n.path)
+	if !found {
+		// Hysteresis is kept as is until the value is seen again.
+		ec.obs = append(ec.obs, Observation{Path: n.path, Value: "n/a"})
+		return truthUnknown
+	}
+
+	matched := compare(raw, n.op, n.value)
+	if n.clear != nil {
+		if st := ec.stateFor(n); st != nil {
+			if st.latched {
+				matched = !compare(raw, n.clear.op, n.clear.value)
+			}
+			st.latched = matched
+		}
+	}
+
+	ec.obs = append(ec.obs, Observation{Path: n.path, Value: FormatValue(raw), Matched: matched})
+	return truthOf(matched)
+}
+
+// compare applies op to an observed status value and a literal.
+// An empty op tests truthiness.
+func compare(observed any, op string, lit literal) bool {
+	if op == "" {
+		return truthy(observed)
+	}
+
+	switch lit.kind {
+	case literalNumber:
+		v, ok := toFloat(observed)
+		if !ok {
+			return false
+		}
+		return compareFloat(v, op, lit.num)
+	case literalBool:
+		b, ok := observed.(bool)
+		if !ok {
+			return false
+		}
+		return equalityResult(b == lit.b, op)
+	default:
+		// Lists such as switch:0.errors compare by membership.
+		if list, ok := observed.([]any); ok {
+			found := false
+			for _, item := range list {
+				if strings.EqualFold(fmt.Sprint(item), lit.str) {
+					found = true
+				}
+			}
+			return equalityResult(found, op)
+		}
+		return equalityResult(strings.EqualFold(fmt.Sprint(observed), lit.str), op)
+	}
+}
+
+func compareFloat(v float64, op string, threshold float64) bool {
+	switch op {
+	case ">":
+		return v > threshold
+	case ">=":
+		return v >= threshold
+	case "<":
+		return v < threshold
+	case "<=":
+		return v <= threshold
+	default:
+		return equalityResult(v == threshold, op)
+	}
+}
+
+func equalityResult(equal bool, op string) bool {
+	switch op {
+	case "==":
+		return equal
+	case "!=":
+		return !equal
+	default:
+		return false
+	}
+}
+
+func truthy(v any) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case string:
+		return t != ""
+	default:
+		return v != nil
+	}
+}
+
+func toFloat(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case bool:
+		if t {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(t, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// FormatValue renders a status value compactly for display.
+func FormatValue(v any) string {
+	switch t := v.(type) {
+	case float64:
+		return strconv.FormatFloat(math.Round(t*100)/100, 'f', -1, 64)
+	case string:
+		return t
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// =============================================================================
+// Status lookup
+// =============================================================================
+
+// legacyMetrics maps the bare metric names of the original condition syntax to
+// the status field they were read from.
+var legacyMetrics = map[string]string{
+	"power":       "apower",
+	"apower":      "apower",
+	"temperature": "temperature",
+	"temp":        "temperature",
+	"voltage":     "voltage",
+	"current":     "current",
+}
+
+// legacyComponents are searched, in order, for bare metric names.
+var legacyComponents = []string{"switch:0", "pm1:0", "em:0", "temperature:0", "cover:0"}
+
+// lookup resolves a path such as "switch:0.apower" or "sys.ram_free" in a
+// status map. Bare legacy metric names search the common components.
+func lookup(status map[string]any, path string) (any, bool) {
+	if status == nil {
+		return nil, false
+	}
+	if _, ok := legacyMetrics[path]; ok {
+		return legacyMetric(status, path)
+	}
+
+	var cur any = status
+	for _, seg := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[seg]; !ok {
+			return nil, false
+		}
+	}
+
+	// "temperature:0.temperature" style paths often land on {tC, tF} objects.
+	if m, ok := cur.(map[string]any); ok {
+		if tC, ok := m["tC"]; ok {
+			return tC, true
+		}
+		return nil, false
+	}
+	return cur, cur != nil
+}
+
+// legacyMetric finds the first non-zero value of a bare metric name, falling
+// back to zero when a component reports the metric but all readings are zero.
+func legacyMetric(status map[string]any, metric string) (any, bool) {
+	found := false
+	for _, prefix := range legacyComponents {
+		comp, ok := status[prefix].(map[string]any)
+		if !ok {
+			continue
+		}
+		v, ok := extractMetric(comp, metric)
+		if !ok {
+			continue
+		}
+		if v != 0 {
+			return v, true
+		}
+		found = true
+	}
+	if found {
+		return 0.0, true
+	}
+	return nil, false
+}
+
+// extractMetric extracts a specific metric from a component.
+func extractMetric(comp map[string]any, metric string) (float64, bool) {
+	switch field := legacyMetrics[metric]; field {
+	case "temperature":
+		return extractTemperature(comp)
+	default:
+		v, ok := comp[field].(float64)
+		return v, ok
+	}
+}
+
+// extractTemperature extracts temperature from a component.
+func extractTemperature(comp map[string]any) (float64, bool) {
+	if v, ok := comp["temperature"].(map[string]any); ok {
+		if tC, ok := v["tC"].(float64); ok {
+			return tC, true
+		}
+	}
+	if v, ok := comp["tC"].(float64); ok {
+		return v, true
+	}
+	return 0, false
+}
+
+// MergeStatus returns base with delta deep-merged into it, without modifying
+// either map. It applies partial status notifications to a full status.
+func MergeStatus(base, delta map[string]any) map[string]any {
+	out := maps.Clone(base)
+	if out == nil {
+		out = make(map[string]any, len(delta))
+	}
+	for k, v := range delta {
+		if dv, ok := v.(map[string]any); ok {
+			if bv, ok := out[k].(map[string]any); ok {
+				out[k] = MergeStatus(bv, dv)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,315 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, src string) *Condition {
+	t.Helper()
+	cond, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", src, err)
+	}
+	return cond
+}
+
+func switchStatus(apower float64) map[string]any {
+	return map[string]any{
+		"switch:0": map[string]any{"apower": apower, "output": true},
+		"sys":      map[string]any{"ram_free": 18000.0},
+		"cover:0":  map[string]any{"state": "open"},
+	}
+}
+
+func TestEvaluateInstant(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		condition string
+		snap      Snapshot
+		want      bool
+		value     string
+	}{
+		{"legacy power above", "power>100", Snapshot{Online: true, Status: switchStatus(150)}, true, "150"},
+		{"legacy power below", "power<100", Snapshot{Online: true, Status: switchStatus(50)}, true, "50"},
+		{"legacy power not triggered", "power>100", Snapshot{Online: true, Status: switchStatus(50)}, false, "50"},
+		{"legacy power zero", "power<10", Snapshot{Online: true, Status: switchStatus(0)}, true, "0"},
+		{"offline", "offline", Snapshot{Online: false}, true, ""},
+		{"online", "online", Snapshot{Online: true}, true, ""},
+		{"path", "switch:0.apower > 1500", Snapshot{Online: true, Status: switchStatus(1620.456)}, true, "1620.46"},
+		{"bool", "switch:0.output == true", Snapshot{Online: true, Status: switchStatus(0)}, true, "true"},
+		{"bare path", "not switch:0.output", Snapshot{Online: true, Status: switchStatus(0)}, false, "true"},
+		{"string", "cover:0.state == OPEN", Snapshot{Online: true, Status: switchStatus(0)}, true, "open"},
+		{
+			"and multiple values",
+			"switch:0.apower > 1500 and sys.ram_free < 20000",
+			Snapshot{Online: true, Status: switchStatus(1600)},
+			true,
+			"switch:0.apower=1600, sys.ram_free=18000",
+		},
+		{"missing path", "em:0.act_power > 1", Snapshot{Online: true, Status: switchStatus(0)}, false, "n/a"},
+		{"no status", "switch:0.apower > 1", Snapshot{Online: false}, false, "n/a"},
+		{"duration ignored", "switch:0.apower > 1 for 1h", Snapshot{Online: true, Status: switchStatus(2)}, true, "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			res := mustParse(t, tt.condition).EvaluateInstant(tt.snap)
+			if res.Triggered != tt.want {
+				t.Errorf("Triggered = %v, want %v", res.Triggered, tt.want)
+			}
+			if res.Value() != tt.value {
+				t.Errorf("Value() = %q, want %q", res.Value(), tt.value)
+			}
+		})
+	}
+}
+
+func TestEvaluateInstant_Events(t *testing.T) {
+	t.Parallel()
+
+	snap := Snapshot{Online: true, Status: switchStatus(0), Events: []string{"input:0.single_push", "input:1.long_push"}}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"event single_push", true},
+		{"event input:1.long_push", true},
+		{"event input:0.long_push", false},
+		{"event double_push", false},
+		{"not event double_push", true},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.condition).EvaluateInstant(snap).Triggered; got != tt.want {
+			t.Errorf("%q: Triggered = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+
+	res := mustParse(t, "event long_push").EvaluateInstant(snap)
+	if res.Value() != "input:1.long_push" {
+		t.Errorf("Value() = %q, want matched event", res.Value())
+	}
+	if mustParse(t, "event single_push").EvaluateInstant(Snapshot{Online: true}).Triggered {
+		t.Error("expected no match without events")
+	}
+}
+
+func TestEvaluateInstant_ListMembership(t *testing.T) {
+	t.Parallel()
+
+	snap := Snapshot{Online: true, Status: map[string]any{
+		"switch:0": map[string]any{"errors": []any{"overpower", "overtemp"}},
+	}}
+
+	if !mustParse(t, "switch:0.errors == overpower").EvaluateInstant(snap).Triggered {
+		t.Error("expected overpower to be found in errors")
+	}
+	if mustParse(t, "switch:0.errors == overvoltage").EvaluateInstant(snap).Triggered {
+		t.Error("expected overvoltage not to be found in errors")
+	}
+	if !mustParse(t, "switch:0.errors != overvoltage").EvaluateInstant(snap).Triggered {
+		t.Error("expected != to hold for a missing entry")
+	}
+}
+
+func TestEvaluateInstant_Unknown(t *testing.T) {
+	t.Parallel()
+
+	offline := Snapshot{Online: false}
+	online := Snapshot{Online: true, Status: switchStatus(0)}
+
+	tests := []struct {
+		condition string
+		snap      Snapshot
+		triggered bool
+		unknown   bool
+	}{
+		{"not switch:0.output", offline, false, true},
+		{"switch:0.apower != 5", offline, false, true},
+		{"not em:0.act_power > 1", online, false, true},
+		{"offline or switch:0.apower > 1", offline, true, false},
+		{"online and switch:0.apower > 1", offline, false, false},
+		{"switch:0.apower > 1 or not switch:0.output", offline, false, true},
+		{"offline", offline, true, false},
+	}
+	for _, tt := range tests {
+		res := mustParse(t, tt.condition).EvaluateInstant(tt.snap)
+		if res.Triggered != tt.triggered || res.Unknown != tt.unknown || res.Pending {
+			t.Errorf("%q: Triggered=%v Unknown=%v Pending=%v, want %v/%v/false",
+				tt.condition, res.Triggered, res.Unknown, res.Pending, tt.triggered, tt.unknown)
+		}
+	}
+}
+
+func TestEvaluator_Duration(t *testing.T) {
+	t.Parallel()
+
+	ev := NewEvaluator(mustParse(t, "switch:0.apower > 1500 for 5m"))
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		offset  time.Duration
+		power   float64
+		want    bool
+		pending bool
+	}{
+		{0, 1600, false, true},
+		{3 * time.Minute, 1600, false, true},
+		{4 * time.Minute, 1000, false, false}, // window resets
+		{5 * time.Minute, 1600, false, true},
+		{9 * time.Minute, 1600, false, true},
+		{10 * time.Minute, 1600, true, false},
+		{11 * time.Minute, 1600, true, false},
+	}
+
+	for i, step := range steps {
+		res := ev.Evaluate(Snapshot{Online: true, Status: switchStatus(step.power), Time: start.Add(step.offset)})
+		if res.Triggered != step.want || res.Pending != step.pending {
+			t.Errorf("step %d: Triggered=%v Pending=%v, want %v/%v", i, res.Triggered, res.Pending, step.want, step.pending)
+		}
+	}
+}
+
+func TestEvaluator_Hysteresis(t *testing.T) {
+	t.Parallel()
+
+	ev := NewEvaluator(mustParse(t, "switch:0.apower > 1500 clear < 1200"))
+
+	steps := []struct {
+		power float64
+		want  bool
+	}{
+		{1400, false},
+		{1600, true},
+		{1400, true}, // still above clear threshold
+		{1250, true},
+		{1100, false}, // released
+		{1400, false}, // must exceed trigger again
+		{1501, true},
+	}
+
+	for i, step := range steps {
+		res := ev.Evaluate(Snapshot{Online: true, Status: switchStatus(step.power)})
+		if res.Triggered != step.want {
+			t.Errorf("step %d (power %.0f): Triggered=%v, want %v", i, step.power, res.Triggered, step.want)
+		}
+	}
+
+	ev.Reset()
+	if res := ev.Evaluate(Snapshot{Online: true, Status: switchStatus(1400)}); res.Triggered {
+		t.Error("expected Reset to release hysteresis latch")
+	}
+}
+
+func TestEvaluator_UnknownKeepsState(t *testing.T) {
+	t.Parallel()
+
+	ev := NewEvaluator(mustParse(t, "switch:0.apower > 1500 clear < 1200 for 5m"))
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	offline := Snapshot{Online: false, Time: start.Add(3 * time.Minute)}
+
+	ev.Evaluate(Snapshot{Online: true, Status: switchStatus(1600), Time: start})
+	if res := ev.Evaluate(offline); !res.Unknown || res.Triggered || res.Pending {
+		t.Errorf("offline poll: %+v, want unknown", res)
+	}
+	// The window started before the unknown poll still counts.
+	if res := ev.Evaluate(Snapshot{Online: true, Status: switchStatus(1600), Time: start.Add(5 * time.Minute)}); !res.Triggered {
+		t.Error("expected trigger 5m after the window opened")
+	}
+	offline.Time = start.Add(6 * time.Minute)
+	ev.Evaluate(offline)
+	// The hysteresis latch survives the unknown poll.
+	if res := ev.Evaluate(Snapshot{Online: true, Status: switchStatus(1300), Time: start.Add(7 * time.Minute)}); !res.Triggered {
+		t.Error("expected latch to hold above the clear threshold")
+	}
+}
+
+func TestEvaluator_OfflineDuration(t *testing.T) {
+	t.Parallel()
+
+	ev := NewEvaluator(mustParse(t, "offline for 2m"))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if res := ev.Evaluate(Snapshot{Online: false, Time: start}); res.Triggered {
+		t.Error("expected pending on first offline poll")
+	}
+	if res := ev.Evaluate(Snapshot{Online: false, Time: start.Add(2 * time.Minute)}); !res.Triggered {
+		t.Error("expected trigger after 2m offline")
+	}
+	if res := ev.Evaluate(Snapshot{Online: true, Time: start.Add(3 * time.Minute)}); res.Triggered {
+		t.Error("expected clear once online")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	status := map[string]any{
+		"switch:0":      map[string]any{"apower": 100.5, "temperature": map[string]any{"tC": 41.2, "tF": 106.2}},
+		"temperature:0": map[string]any{"tC": 25.5},
+		"pm1:0":         map[string]any{"voltage": 230.5},
+		"em:0":          map[string]any{"current": 5.2},
+	}
+
+	tests := []struct {
+		path  string
+		want  any
+		found bool
+	}{
+		{"power", 100.5, true},
+		{"temperature", 41.2, true},
+		{"voltage", 230.5, true},
+		{"current", 5.2, true},
+		{"switch:0.apower", 100.5, true},
+		{"switch:0.temperature", 41.2, true},
+		{"switch:0.temperature.tF", 106.2, true},
+		{"temperature:0.tC", 25.5, true},
+		{"switch:1.apower", nil, false},
+		{"switch:0.apower.x", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, found := lookup(status, tt.path)
+		if found != tt.found || got != tt.want {
+			t.Errorf("lookup(%q) = %v, %v; want %v, %v", tt.path, got, found, tt.want, tt.found)
+		}
+	}
+
+	if _, found := lookup(map[string]any{}, "power"); found {
+		t.Error("expected missing legacy metric to be not found")
+	}
+}
+
+func TestExtractTemperature(t *testing.T) {
+	t.Parallel()
+
+	if v, ok := extractTemperature(map[string]any{"temperature": map[string]any{"tC": 30.5}}); !ok || v != 30.5 {
+		t.Errorf("nested: got %v, %v", v, ok)
+	}
+	if v, ok := extractTemperature(map[string]any{"tC": 28.0}); !ok || v != 28.0 {
+		t.Errorf("direct: got %v, %v", v, ok)
+	}
+	if _, ok := extractTemperature(map[string]any{"power": 100.0}); ok {
+		t.Error("expected no temperature")
+	}
+}
+
+func TestMergeStatus(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]any{"a": map[string]any{"x": 1.0, "y": 2.0}, "b": 1.0}
+	got := MergeStatus(base, map[string]any{"a": map[string]any{"y": 3.0}, "ts": 5.0})
+
+	a, ok := got["a"].(map[string]any)
+	if !ok || a["x"] != 1.0 || a["y"] != 3.0 || got["b"] != 1.0 || got["ts"] != 5.0 {
+		t.Errorf("MergeStatus() = %v", got)
+	}
+	if orig, ok := base["a"].(map[string]any); !ok || orig["y"] != 2.0 {
+		t.Errorf("base mutated: %v", base)
+	}
+}
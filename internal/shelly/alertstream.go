@@ -0,0 +1,247 @@
+package shelly
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/tj-smith47/shelly-go/events"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/alerting"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/automation"
+)
+
+// alertStreamBuffer is how many device updates may queue before further
+// updates are coalesced into the next polling tick.
+const alertStreamBuffer = 64
+
+// AlertStream keeps a live view of device status built from EventStream
+// events, so alert conditions can be evaluated as soon as a device reports a
+// change instead of on the next poll.
+//
+// Only devices that have delivered a full status over WebSocket are streamed;
+// everything else (Gen1, unreachable at start-up) keeps being polled.
+type AlertStream struct {
+	mu      sync.Mutex
+	devices map[string]*streamedDevice
+	updates chan string
+	watched map[string]bool
+	// connect starts streaming a device; nil when events are not watched.
+	connect func(name, address string)
+}
+
+type streamedDevice struct {
+	online bool
+	status map[string]any // Copy-on-write: nested maps are never mutated in place
+	events []string       // "component.event" since the last snapshot
+}
+
+// NewAlertStream creates an empty alert stream.
+func NewAlertStream() *AlertStream {
+	return &AlertStream{
+		devices: make(map[string]*streamedDevice),
+		updates: make(chan string, alertStreamBuffer),
+		watched: make(map[string]bool),
+	}
+}
+
+// Filter returns an event filter for the watched devices' WebSocket updates
+// and online/offline transitions. Devices watched later are included.
+func (s *AlertStream) Filter() events.Filter {
+	return events.And(
+		func(evt events.Event) bool {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return s.watched[evt.DeviceID()]
+		},
+		events.Or(events.WithSource(events.EventSourceWebSocket), events.DeviceOnline(), events.DeviceOffline()),
+	)
+}
+
+// Updates delivers the name of each device whose streamed state changed.
+func (s *AlertStream) Updates() <-chan string {
+	return s.updates
+}
+
+// Handle applies an event to the streamed device state. It is safe to use as
+// an EventStream subscriber.
+func (s *AlertStream) Handle(evt events.Event) {
+	name := evt.DeviceID()
+
+	s.mu.Lock()
+	dev := s.devices[name]
+	changed := false
+
+	switch e := evt.(type) {
+	case *events.FullStatusEvent:
+		if e.Source() != events.EventSourceWebSocket {
+			break
+		}
+		var status map[string]any
+		if err := json.Unmarshal(e.Status, &status); err != nil {
+			iostreams.DebugErr("alert stream: parse full status for "+name, err)
+			break
+		}
+		if dev == nil {
+			dev = &streamedDevice{}
+			s.devices[name] = dev
+		}
+		dev.online, dev.status, changed = true, status, true
+
+	case *events.StatusChangeEvent:
+		if dev == nil {
+			break
+		}
+		var delta any
+		if err := json.Unmarshal(e.Status, &delta); err != nil {
+			iostreams.DebugErr("alert stream: parse status change for "+name, err)
+			break
+		}
+		dev.status = alerting.MergeStatus(dev.status, map[string]any{e.Component: delta})
+		dev.online, changed = true, true
+
+	case *events.NotifyEvent:
+		if dev == nil {
+			break
+		}
+		dev.events = append(dev.events, e.Component+"."+e.Event)
+		changed = true
+
+	case *events.DeviceOnlineEvent:
+		if dev != nil && !dev.online {
+			dev.online, changed = true, true
+		}
+
+	case *events.DeviceOfflineEvent:
+		if dev != nil && dev.online {
+			dev.online, changed = false, true
+		}
+	}
+	s.mu.Unlock()
+
+	if changed {
+		select {
+		case s.updates <- name:
+		default:
+			// Queue full; the next polling tick re-evaluates every device.
+		}
+	}
+}
+
+// Streaming reports whether a device's state is being kept from events.
+func (s *AlertStream) Streaming(device string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.devices[device]
+	return ok
+}
+
+// Snapshot returns the streamed state of a device and consumes its pending
+// events. ok is false when the device is not streamed.
+func (s *AlertStream) Snapshot(device string) (snap alerting.Snapshot, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dev, ok := s.devices[device]
+	if !ok {
+		return alerting.Snapshot{}, false
+	}
+	snap = alerting.Snapshot{
+		Online: dev.online,
+		Events: dev.events,
+		Time:   time.Now(),
+	}
+	if dev.online {
+		// Like a failed poll, an offline device reports no status.
+		snap.Status = maps.Clone(dev.status)
+	}
+	dev.events = nil
+	return snap, true
+}
+
+// WatchAlertEvents streams events from the registered devices referenced by
+// enabled alerts into a new AlertStream. Devices that cannot stream are left
+// to polling. Call stop to disconnect.
+func (s *Service) WatchAlertEvents(alerts map[string]config.Alert) (stream *AlertStream, stop func()) {
+	stream = NewAlertStream()
+	es := automation.NewEventStream(s)
+	stream.connect = es.AddDevice
+	es.SubscribeFiltered(stream.Filter(), stream.Handle)
+	stream.WatchAlerts(alerts)
+	return stream, es.Stop
+}
+
+// WatchAlerts starts streaming the registered devices referenced by enabled
+// alerts that are not watched yet, such as devices of alerts added after a
+// config reload. It does nothing for a stream that does not watch events.
+func (s *AlertStream) WatchAlerts(alerts map[string]config.Alert) {
+	if s.connect == nil {
+		return
+	}
+	for _, alert := range alerts {
+		if !alert.Enabled {
+			continue
+		}
+		dev, ok := config.GetDevice(alert.Device)
+		if !ok {
+			continue
+		}
+		s.mu.Lock()
+		watched := s.watched[alert.Device]
+		s.watched[alert.Device] = true
+		s.mu.Unlock()
+		if !watched {
+			s.connect(alert.Device, dev.Address)
+		}
+	}
+}
+
+// CheckStreamedAlerts evaluates enabled alerts using streamed device state
+// where available, polling devices that are not streamed. When device is set,
+// only that device's alerts are checked and it is never polled.
+func (s *Service) CheckStreamedAlerts(ctx context.Context, alerts map[string]config.Alert, states map[string]*AlertState, stream *AlertStream, device string) []AlertCheckResult {
+	snaps := make(map[string]alerting.Snapshot)
+	var results []AlertCheckResult
+
+	for name, alert := range alerts {
+		if !alert.Enabled || alert.IsSnoozed() {
+			continue
+		}
+		if device != "" && alert.Device != device {
+			continue
+		}
+
+		state, ok := states[name]
+		if !ok {
+			state = &AlertState{}
+			states[name] = state
+		}
+
+		snap, streamed := snaps[alert.Device]
+		if !streamed {
+			if snap, streamed = stream.Snapshot(alert.Device); streamed {
+				snaps[alert.Device] = snap
+			}
+		}
+
+		var result AlertCheckResult
+		switch {
+		case streamed:
+			result = checkAlertSnapshot(alert, state, snap)
+		case device != "":
+			continue
+		default:
+			result = s.CheckAlert(ctx, alert, state)
+		}
+
+		if result.Action != AlertActionNone {
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
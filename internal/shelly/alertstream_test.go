@@ -0,0 +1,181 @@
+package shelly
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/tj-smith47/shelly-go/events"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+func streamedKitchen(t *testing.T) *AlertStream {
+	t.Helper()
+	stream := NewAlertStream()
+	status := json.RawMessage(`{"switch:0":{"output":false,"apower":0,"temperature":{"tC":40}}}`)
+	stream.Handle(events.NewFullStatusEvent("kitchen", status).WithSource(events.EventSourceWebSocket))
+	<-stream.Updates()
+	return stream
+}
+
+func TestAlertStream_StatusChange(t *testing.T) {
+	t.Parallel()
+	stream := streamedKitchen(t)
+
+	before, ok := stream.Snapshot("kitchen")
+	if !ok {
+		t.Fatal("expected kitchen to be streamed")
+	}
+
+	delta := json.RawMessage(`{"output":true,"apower":1800,"temperature":{"tC":55}}`)
+	stream.Handle(events.NewStatusChangeEvent("kitchen", "switch:0", delta).WithSource(events.EventSourceWebSocket))
+	if got := <-stream.Updates(); got != "kitchen" {
+		t.Errorf("update for %q, want kitchen", got)
+	}
+
+	snap, _ := stream.Snapshot("kitchen")
+	sw, ok := snap.Status["switch:0"].(map[string]any)
+	if !ok {
+		t.Fatalf("switch:0 missing from %v", snap.Status)
+	}
+	temp, ok := sw["temperature"].(map[string]any)
+	if !ok || sw["output"] != true || sw["apower"] != 1800.0 || temp["tC"] != 55.0 {
+		t.Errorf("merged status = %v", snap.Status)
+	}
+
+	// Earlier snapshots are not affected by later updates.
+	old, ok := before.Status["switch:0"].(map[string]any)
+	if !ok || old["output"] != false {
+		t.Errorf("earlier snapshot mutated: %v", before.Status)
+	}
+}
+
+func TestAlertStream_EventsConsumed(t *testing.T) {
+	t.Parallel()
+	stream := streamedKitchen(t)
+
+	stream.Handle(events.NewNotifyEvent("kitchen", "input:0", "single_push").WithSource(events.EventSourceWebSocket))
+
+	snap, _ := stream.Snapshot("kitchen")
+	if len(snap.Events) != 1 || snap.Events[0] != "input:0.single_push" {
+		t.Errorf("Events = %v", snap.Events)
+	}
+	if snap, _ = stream.Snapshot("kitchen"); len(snap.Events) != 0 {
+		t.Errorf("events not consumed: %v", snap.Events)
+	}
+}
+
+func TestAlertStream_Offline(t *testing.T) {
+	t.Parallel()
+	stream := streamedKitchen(t)
+
+	stream.Handle(events.NewDeviceOfflineEvent("kitchen"))
+	snap, ok := stream.Snapshot("kitchen")
+	if !ok || snap.Online || snap.Status != nil {
+		t.Errorf("offline snapshot = %+v, %v", snap, ok)
+	}
+
+	stream.Handle(events.NewDeviceOnlineEvent("kitchen"))
+	if snap, _ = stream.Snapshot("kitchen"); !snap.Online || snap.Status == nil {
+		t.Errorf("online snapshot = %+v", snap)
+	}
+}
+
+func TestAlertStream_IgnoresUnstreamed(t *testing.T) {
+	t.Parallel()
+	stream := NewAlertStream()
+
+	// Gen1 polling publishes local full status; it must not start streaming.
+	stream.Handle(events.NewFullStatusEvent("gen1", json.RawMessage(`{"relays":[]}`)))
+	stream.Handle(events.NewStatusChangeEvent("gen1", "switch:0", json.RawMessage(`{"output":true}`)))
+	stream.Handle(events.NewDeviceOfflineEvent("gen1"))
+
+	if stream.Streaming("gen1") {
+		t.Error("gen1 should not be streamed")
+	}
+	select {
+	case name := <-stream.Updates():
+		t.Errorf("unexpected update for %q", name)
+	default:
+	}
+}
+
+func TestService_CheckStreamedAlerts(t *testing.T) {
+	t.Parallel()
+	stream := streamedKitchen(t)
+	svc := NewService()
+
+	alerts := map[string]config.Alert{
+		"button":      {Name: "button", Device: "kitchen", Condition: "event long_push", Enabled: true},
+		"hot":         {Name: "hot", Device: "kitchen", Condition: "switch:0.temperature > 50", Enabled: true},
+		"garage-down": {Name: "garage-down", Device: "garage", Condition: "offline", Enabled: true},
+	}
+	states := make(map[string]*AlertState)
+
+	stream.Handle(events.NewNotifyEvent("kitchen", "input:0", "long_push").WithSource(events.EventSourceWebSocket))
+	stream.Handle(events.NewStatusChangeEvent("kitchen", "switch:0", json.RawMessage(`{"temperature":{"tC":60}}`)).
+		WithSource(events.EventSourceWebSocket))
+
+	results := svc.CheckStreamedAlerts(context.Background(), alerts, states, stream, "kitchen")
+	fired := make(map[string]AlertAction)
+	for _, r := range results {
+		fired[r.Name] = r.Action
+	}
+	if fired["button"] != AlertActionTriggered || fired["hot"] != AlertActionTriggered {
+		t.Errorf("results = %+v", results)
+	}
+	if _, ok := fired["garage-down"]; ok {
+		t.Error("alerts for other devices must not be checked for a device update")
+	}
+
+	// The button event was consumed, so the next check resolves it.
+	results = svc.CheckStreamedAlerts(context.Background(), alerts, states, stream, "kitchen")
+	if len(results) != 1 || results[0].Name != "button" || results[0].Action != AlertActionCleared {
+		t.Errorf("second check results = %+v", results)
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem and manager
+func TestAlertStream_WatchAlerts(t *testing.T) {
+	config.SetFs(afero.NewMemMapFs())
+	t.Cleanup(func() { config.SetFs(nil) })
+	mgr := config.NewManager("/test/config/config.yaml")
+	if err := mgr.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for name, address := range map[string]string{"kitchen": "10.0.0.1", "porch": "10.0.0.2"} {
+		if err := mgr.RegisterDevice(name, address, 2, "", "", nil); err != nil {
+			t.Fatalf("RegisterDevice() error = %v", err)
+		}
+	}
+	config.SetDefaultManager(mgr)
+	t.Cleanup(config.ResetDefaultManagerForTesting)
+
+	var connected []string
+	stream := NewAlertStream()
+	stream.connect = func(name, address string) { connected = append(connected, name+"@"+address) }
+
+	stream.WatchAlerts(map[string]config.Alert{"hot": {Device: "kitchen", Enabled: true}})
+	// A reload adds porch; kitchen is already watched and unknown devices
+	// are left to polling.
+	stream.WatchAlerts(map[string]config.Alert{
+		"hot":     {Device: "kitchen", Enabled: true},
+		"door":    {Device: "porch", Enabled: true},
+		"garage":  {Device: "garage", Enabled: true},
+		"stopped": {Device: "cellar", Enabled: false},
+	})
+	if want := []string{"kitchen@10.0.0.1", "porch@10.0.0.2"}; !slices.Equal(connected, want) {
+		t.Errorf("connected = %v, want %v", connected, want)
+	}
+
+	filter := stream.Filter()
+	if !filter(events.NewDeviceOnlineEvent("porch")) {
+		t.Error("filter should pass events of a device watched after a reload")
+	}
+	if filter(events.NewDeviceOnlineEvent("garage")) {
+		t.Error("filter should drop events of unwatched devices")
+	}
+}
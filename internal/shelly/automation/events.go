@@ -96,6 +96,13 @@ func (es *EventStream) Start() error {
 	return nil
 }
 
+// ListenCoIoT starts the CoIoT listener without connecting any devices, so
+// Gen1 devices added with AddDevice get pushed updates instead of only
+// being polled.
+func (es *EventStream) ListenCoIoT() {
+	es.startCoIoTListener()
+}
+
 // connectDevice establishes a WebSocket connection to a device.
 func (es *EventStream) connectDevice(name, address string) {
 	ctx, cancel := context.WithCancel(es.ctx)
@@ -3,11 +3,14 @@ package automation
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/tj-smith47/shelly-go/gen2/components"
+	"github.com/tj-smith47/shelly-go/profiles"
 
 	"github.com/tj-smith47/shelly-cli/internal/cache"
 	"github.com/tj-smith47/shelly-cli/internal/client"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptdev"
 )
 
 // ScriptInfo contains information about a script.
@@ -116,6 +119,23 @@ func (s *Service) GetScriptConfig(ctx context.Context, identifier string, id int
 	return result, err
 }
 
+// ScriptSizeLimit returns the script size limit of a device in bytes, from
+// the profile of its model, or the Gen2+ default when no profile is
+// registered for it. It returns 0 when the device has no known limit.
+func (s *Service) ScriptSizeLimit(ctx context.Context, identifier string) (int, error) {
+	var limit int
+	err := s.parent.WithConnection(ctx, identifier, func(conn *client.Client) error {
+		info := conn.Info()
+		if p, ok := profiles.Get(info.Model); ok {
+			limit = p.Limits.MaxScriptSize
+		} else if info.Generation >= 2 {
+			limit = profiles.DefaultGen2Limits().MaxScriptSize
+		}
+		return nil
+	})
+	return limit, err
+}
+
 // GetScriptCode retrieves the source code of a script.
 func (s *Service) GetScriptCode(ctx context.Context, identifier string, id int) (string, error) {
 	var result string
@@ -165,6 +185,26 @@ func (s *Service) UpdateScriptCode(ctx context.Context, identifier string, id in
 	return err
 }
 
+// UploadScriptCode replaces the code of a script, sending it in chunks of
+// at most chunkSize bytes over one connection. It returns the number of
+// chunks sent.
+func (s *Service) UploadScriptCode(ctx context.Context, identifier string, id int, code string, chunkSize int) (int, error) {
+	chunks := scriptdev.Chunks(code, chunkSize)
+	err := s.parent.WithConnection(ctx, identifier, func(conn *client.Client) error {
+		script := components.NewScript(conn.RPCClient())
+		for i, chunk := range chunks {
+			if err := script.PutCode(ctx, id, chunk, i > 0); err != nil {
+				return fmt.Errorf("chunk %d/%d: %w", i+1, len(chunks), err)
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		s.invalidateCache(identifier, cache.TypeScripts)
+	}
+	return len(chunks), err
+}
+
 // UpdateScriptConfig updates the configuration of a script.
 func (s *Service) UpdateScriptConfig(ctx context.Context, identifier string, id int, name *string, enable *bool) error {
 	err := s.parent.WithConnection(ctx, identifier, func(conn *client.Client) error {
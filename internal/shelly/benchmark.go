@@ -0,0 +1,56 @@
+package shelly
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tj-smith47/shelly-go/rpc"
+	"github.com/tj-smith47/shelly-go/transport"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/benchmark"
+)
+
+// BenchmarkDialer returns a dialer that opens dedicated connections to a
+// Gen2+ device over the given transport (benchmark.TransportHTTP or
+// benchmark.TransportWS). The connections bypass the rate limiter so load
+// profiles measure the device rather than the CLI's own throttling.
+func (s *Service) BenchmarkDialer(ctx context.Context, device, transportName string) (benchmark.Dialer, error) {
+	dev, err := s.ResolveWithGeneration(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	if dev.Generation == 1 {
+		return nil, fmt.Errorf("benchmark is only supported on Gen2+ devices")
+	}
+
+	var opts []transport.Option
+	if dev.HasAuth() {
+		opts = append(opts, transport.WithAuth(dev.Auth.Username, dev.Auth.Password))
+	}
+	baseURL := dev.Address
+	if !strings.HasPrefix(baseURL, "http") {
+		baseURL = "http://" + baseURL
+	}
+	if strings.HasPrefix(baseURL, "https") {
+		opts = append(opts, transport.WithInsecureSkipVerify())
+	}
+
+	switch transportName {
+	case benchmark.TransportHTTP:
+		return func(context.Context) (benchmark.Client, error) {
+			return rpc.NewClient(transport.NewHTTP(baseURL, opts...)), nil
+		}, nil
+	case benchmark.TransportWS:
+		wsURL := "ws" + strings.TrimPrefix(baseURL, "http") + "/rpc"
+		return func(ctx context.Context) (benchmark.Client, error) {
+			ws := transport.NewWebSocket(wsURL, opts...)
+			if err := ws.Connect(ctx); err != nil {
+				return nil, fmt.Errorf("websocket connect: %w", err)
+			}
+			return rpc.NewClient(ws), nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown transport %q", transportName)
+	}
+}
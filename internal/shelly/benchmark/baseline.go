@@ -0,0 +1,137 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+)
+
+// DefaultThreshold is the percentage a metric may worsen before Compare
+// reports it.
+const DefaultThreshold = 20.0
+
+// errorRateTolerance is the absolute error-rate increase (0-1) tolerated
+// before Compare reports it; error rates are too small for a relative
+// threshold to be useful.
+const errorRateTolerance = 0.01
+
+// Regression metrics.
+const (
+	MetricP50        = "p50"
+	MetricP95        = "p95"
+	MetricThroughput = "throughput"
+	MetricErrorRate  = "error_rate"
+)
+
+// SaveBaseline writes a result for later use with LoadBaseline.
+func SaveBaseline(path string, result model.BenchmarkResult) error {
+	result.Regressions = nil
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode baseline: %w", err)
+	}
+	if err := afero.WriteFile(config.Fs(), path, data, 0o600); err != nil {
+		return fmt.Errorf("write baseline: %w", err)
+	}
+	return nil
+}
+
+// LoadBaseline reads a result written by SaveBaseline or `benchmark --json`.
+func LoadBaseline(path string) (model.BenchmarkResult, error) {
+	var result model.BenchmarkResult
+	data, err := afero.ReadFile(config.Fs(), path)
+	if err != nil {
+		return result, fmt.Errorf("read baseline: %w", err)
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, fmt.Errorf("parse baseline %s: %w", path, err)
+	}
+	return result, nil
+}
+
+// Compare reports metrics in current that are worse than baseline by more
+// than threshold percent. Latency is compared for the sequential RPC run
+// (when both used the same method) and for every load profile present in
+// both results; profiles only in one of them are skipped.
+func Compare(baseline, current model.BenchmarkResult, threshold float64) []model.BenchmarkRegression {
+	var out []model.BenchmarkRegression
+
+	if methodOrDefault(baseline.Method) == methodOrDefault(current.Method) {
+		profile := "sequential " + methodOrDefault(current.Method)
+		out = appendLatency(out, profile, baseline.RPCLatency, current.RPCLatency, threshold)
+	}
+
+	base := make(map[string]model.LoadResult, len(baseline.Load))
+	for _, l := range baseline.Load {
+		base[Key(l.Transport, l.Method, l.Concurrency)] = l
+	}
+	for _, cur := range current.Load {
+		key := Key(cur.Transport, cur.Method, cur.Concurrency)
+		prev, ok := base[key]
+		if !ok {
+			continue
+		}
+		out = appendLatency(out, key, prev.Latency, cur.Latency, threshold)
+		if prev.Throughput > 0 {
+			change := pctChange(prev.Throughput, cur.Throughput)
+			if -change > threshold {
+				out = append(out, model.BenchmarkRegression{
+					Profile: key, Metric: MetricThroughput,
+					Baseline: prev.Throughput, Current: cur.Throughput, Change: change,
+				})
+			}
+		}
+		if cur.ErrorRate-prev.ErrorRate > errorRateTolerance {
+			out = append(out, model.BenchmarkRegression{
+				Profile: key, Metric: MetricErrorRate,
+				Baseline: prev.ErrorRate, Current: cur.ErrorRate,
+				Change: (cur.ErrorRate - prev.ErrorRate) * 100,
+			})
+		}
+	}
+	return out
+}
+
+func appendLatency(out []model.BenchmarkRegression, profile string, prev, cur model.LatencyStats, threshold float64) []model.BenchmarkRegression {
+	for _, m := range []struct {
+		name      string
+		prev, cur time.Duration
+	}{
+		{MetricP50, prev.P50, cur.P50},
+		{MetricP95, prev.P95, cur.P95},
+	} {
+		if m.prev <= 0 || m.cur <= 0 {
+			continue
+		}
+		prevMs, curMs := durationMs(m.prev), durationMs(m.cur)
+		if change := pctChange(prevMs, curMs); change > threshold {
+			out = append(out, model.BenchmarkRegression{
+				Profile: profile, Metric: m.name,
+				Baseline: prevMs, Current: curMs, Change: change,
+			})
+		}
+	}
+	return out
+}
+
+func pctChange(prev, cur float64) float64 {
+	return (cur - prev) / prev * 100
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// methodOrDefault names the method of results saved without one, such as
+// older `benchmark --json` output.
+func methodOrDefault(method string) string {
+	if method == "" {
+		return DefaultMethod
+	}
+	return method
+}
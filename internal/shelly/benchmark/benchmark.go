@@ -0,0 +1,187 @@
+// Package benchmark runs concurrent RPC load profiles against a device and
+// compares results with saved baselines.
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/utils"
+)
+
+// DefaultMethod is the RPC method benchmarked when none is given.
+const DefaultMethod = "Shelly.GetDeviceInfo"
+
+// Transports.
+const (
+	TransportHTTP = "http"
+	TransportWS   = "ws"
+)
+
+// Client performs RPC calls over a single connection.
+type Client interface {
+	Call(ctx context.Context, method string, params any) (json.RawMessage, error)
+	Close() error
+}
+
+// Dialer opens a new connection to the device under test.
+type Dialer func(ctx context.Context) (Client, error)
+
+// Profile describes a load run.
+type Profile struct {
+	Transport   string
+	Method      string
+	Params      any
+	Concurrency int     // parallel clients, each with its own connection
+	Requests    int     // total requests across all clients
+	RPS         float64 // target request rate; 0 sends as fast as clients allow
+	Warmup      int     // unmeasured requests per client
+}
+
+// Key identifies a profile when matching results against a baseline.
+func Key(transport, method string, concurrency int) string {
+	return fmt.Sprintf("%s %s x%d", transport, method, concurrency)
+}
+
+// Run opens p.Concurrency connections with dial and spreads p.Requests
+// calls across them. Failed calls count towards the error rate; only a
+// failure to connect aborts the run.
+func Run(ctx context.Context, dial Dialer, p Profile) (model.LoadResult, error) {
+	if p.Concurrency < 1 {
+		p.Concurrency = 1
+	}
+	result := model.LoadResult{
+		Transport:   p.Transport,
+		Method:      p.Method,
+		Concurrency: p.Concurrency,
+		TargetRPS:   p.RPS,
+		Requests:    p.Requests,
+	}
+
+	clients := make([]Client, 0, p.Concurrency)
+	defer func() {
+		for _, c := range clients {
+			iostreams.CloseWithDebug("closing benchmark connection", c)
+		}
+	}()
+	for i := range p.Concurrency {
+		c, err := dial(ctx)
+		if err != nil {
+			return result, fmt.Errorf("connection %d: %w", i+1, err)
+		}
+		clients = append(clients, c)
+	}
+
+	for _, c := range clients {
+		for range p.Warmup {
+			if _, err := c.Call(ctx, p.Method, p.Params); err != nil {
+				iostreams.DebugErr("benchmark warmup call", err)
+			}
+		}
+	}
+
+	jobs := schedule(ctx, p.Requests, p.RPS)
+
+	var (
+		mu        sync.Mutex
+		latencies = make([]time.Duration, 0, p.Requests)
+		errs      int
+		wg        sync.WaitGroup
+	)
+	start := time.Now()
+	for _, c := range clients {
+		wg.Go(func() {
+			for range jobs {
+				callStart := time.Now()
+				_, err := c.Call(ctx, p.Method, p.Params)
+				elapsed := time.Since(callStart)
+
+				mu.Lock()
+				if err != nil {
+					errs++
+					iostreams.DebugErr("benchmark call", err)
+				} else {
+					latencies = append(latencies, elapsed)
+				}
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	result.Duration = time.Since(start)
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	result.Latency = utils.CalculateLatencyStats(latencies, errs)
+	if secs := result.Duration.Seconds(); secs > 0 {
+		result.Throughput = float64(len(latencies)) / secs
+	}
+	if p.Requests > 0 {
+		result.ErrorRate = float64(errs) / float64(p.Requests)
+	}
+	return result, nil
+}
+
+// schedule returns a channel yielding n request slots, paced at rps when
+// positive. The channel is closed after the last slot or when ctx ends.
+func schedule(ctx context.Context, n int, rps float64) <-chan struct{} {
+	if rps <= 0 {
+		jobs := make(chan struct{}, n)
+		for range n {
+			jobs <- struct{}{}
+		}
+		close(jobs)
+		return jobs
+	}
+
+	jobs := make(chan struct{})
+	go func() {
+		defer close(jobs)
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rps))
+		defer ticker.Stop()
+		for i := range n {
+			if i > 0 {
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case jobs <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return jobs
+}
+
+// SuggestMaxConcurrent picks a per-device concurrency limit from a sweep
+// over one transport: the highest level, up to limit, that ran without
+// errors and still raised throughput by at least 10% over the level
+// below it. It returns 0 when no level ran cleanly.
+func SuggestMaxConcurrent(results []model.LoadResult, limit int) int {
+	sorted := slices.Clone(results)
+	slices.SortFunc(sorted, func(a, b model.LoadResult) int { return a.Concurrency - b.Concurrency })
+
+	best, prevThroughput := 0, 0.0
+	for _, r := range sorted {
+		if r.Concurrency > limit || r.ErrorRate > 0 {
+			break
+		}
+		if best > 0 && r.Throughput < prevThroughput*1.1 {
+			break
+		}
+		best, prevThroughput = r.Concurrency, r.Throughput
+	}
+	return best
+}
@@ -0,0 +1,248 @@
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/model"
+)
+
+type fakeClient struct {
+	calls    *atomic.Int64
+	inFlight *atomic.Int64
+	peak     *atomic.Int64
+	failEach int64 // fail every n-th call when > 0
+	delay    time.Duration
+	closed   *atomic.Int64
+}
+
+func (c *fakeClient) Call(context.Context, string, any) (json.RawMessage, error) {
+	n := c.calls.Add(1)
+	cur := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if cur <= peak || c.peak.CompareAndSwap(peak, cur) {
+			break
+		}
+	}
+	time.Sleep(c.delay)
+	if c.failEach > 0 && n%c.failEach == 0 {
+		return nil, errors.New("boom")
+	}
+	return json.RawMessage(`{}`), nil
+}
+
+func (c *fakeClient) Close() error {
+	c.closed.Add(1)
+	return nil
+}
+
+type fakeDevice struct {
+	calls, inFlight, peak, closed, dials atomic.Int64
+	failEach                             int64
+	delay                                time.Duration
+}
+
+func (d *fakeDevice) dial(context.Context) (Client, error) {
+	d.dials.Add(1)
+	return &fakeClient{
+		calls: &d.calls, inFlight: &d.inFlight, peak: &d.peak, closed: &d.closed,
+		failEach: d.failEach, delay: d.delay,
+	}, nil
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	dev := &fakeDevice{delay: 2 * time.Millisecond}
+
+	res, err := Run(context.Background(), dev.dial, Profile{
+		Transport: TransportHTTP, Method: "Shelly.GetStatus", Concurrency: 4, Requests: 40, Warmup: 1,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if dev.dials.Load() != 4 || dev.closed.Load() != 4 {
+		t.Errorf("dials = %d, closed = %d, want 4 each", dev.dials.Load(), dev.closed.Load())
+	}
+	if dev.calls.Load() != 44 {
+		t.Errorf("calls = %d, want 40 + 4 warmup", dev.calls.Load())
+	}
+	if dev.peak.Load() < 2 {
+		t.Errorf("peak in-flight = %d, want parallel calls", dev.peak.Load())
+	}
+	if res.Concurrency != 4 || res.Requests != 40 || res.Method != "Shelly.GetStatus" || res.Transport != TransportHTTP {
+		t.Errorf("result = %+v", res)
+	}
+	if res.ErrorRate != 0 || res.Throughput <= 0 || res.Latency.P50 < 2*time.Millisecond {
+		t.Errorf("stats = %+v", res)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	t.Parallel()
+	dev := &fakeDevice{failEach: 4}
+
+	res, err := Run(context.Background(), dev.dial, Profile{Concurrency: 1, Requests: 20})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if res.Latency.Errors != 5 || res.ErrorRate != 0.25 {
+		t.Errorf("errors = %d, rate = %v, want 5 and 0.25", res.Latency.Errors, res.ErrorRate)
+	}
+}
+
+func TestRun_RPS(t *testing.T) {
+	t.Parallel()
+	dev := &fakeDevice{}
+
+	res, err := Run(context.Background(), dev.dial, Profile{Concurrency: 2, Requests: 6, RPS: 50})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// 6 requests at 50/s are spaced 20ms apart: at least 100ms in total.
+	if res.Duration < 100*time.Millisecond {
+		t.Errorf("duration = %v, want paced run of at least 100ms", res.Duration)
+	}
+	if res.TargetRPS != 50 {
+		t.Errorf("TargetRPS = %v", res.TargetRPS)
+	}
+}
+
+func TestRun_DialError(t *testing.T) {
+	t.Parallel()
+	dev := &fakeDevice{}
+	var mu sync.Mutex
+	n := 0
+	dial := func(ctx context.Context) (Client, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if n++; n == 3 {
+			return nil, errors.New("refused")
+		}
+		return dev.dial(ctx)
+	}
+
+	if _, err := Run(context.Background(), dial, Profile{Concurrency: 3, Requests: 3}); err == nil {
+		t.Fatal("expected dial error")
+	}
+	if dev.closed.Load() != 2 {
+		t.Errorf("closed = %d, want the 2 opened connections closed", dev.closed.Load())
+	}
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+	ms := time.Millisecond
+	baseline := model.BenchmarkResult{
+		Method:     "Shelly.GetStatus",
+		RPCLatency: model.LatencyStats{P50: 20 * ms, P95: 40 * ms},
+		Load: []model.LoadResult{
+			{Transport: TransportHTTP, Method: "Shelly.GetStatus", Concurrency: 2, Throughput: 100,
+				Latency: model.LatencyStats{P50: 20 * ms, P95: 40 * ms}},
+			{Transport: TransportWS, Method: "Shelly.GetStatus", Concurrency: 2, Throughput: 100},
+		},
+	}
+	current := model.BenchmarkResult{
+		Method:     "Shelly.GetStatus",
+		RPCLatency: model.LatencyStats{P50: 22 * ms, P95: 60 * ms}, // p95 +50%
+		Load: []model.LoadResult{
+			{Transport: TransportHTTP, Method: "Shelly.GetStatus", Concurrency: 2, Throughput: 70, ErrorRate: 0.05,
+				Latency: model.LatencyStats{P50: 30 * ms, P95: 44 * ms}},
+			{Transport: TransportHTTP, Method: "Shelly.GetStatus", Concurrency: 4, Throughput: 1}, // not in baseline
+		},
+	}
+
+	got := Compare(baseline, current, 20)
+	want := map[string]bool{
+		"sequential Shelly.GetStatus/p95":     true,
+		"http Shelly.GetStatus x2/p50":        true,
+		"http Shelly.GetStatus x2/throughput": true,
+		"http Shelly.GetStatus x2/error_rate": true,
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d regressions, want %d: %+v", len(got), len(want), got)
+	}
+	for _, r := range got {
+		if !want[r.Profile+"/"+r.Metric] {
+			t.Errorf("unexpected regression %+v", r)
+		}
+	}
+
+	if got := Compare(baseline, current, 100); len(got) != 1 || got[0].Metric != MetricErrorRate {
+		t.Errorf("Compare(threshold 100) = %+v, want only the error rate", got)
+	}
+
+	other := current
+	other.Method = "Switch.GetStatus"
+	for _, r := range Compare(baseline, other, 20) {
+		if r.Profile == "sequential Switch.GetStatus" {
+			t.Errorf("compared sequential runs of different methods: %+v", r)
+		}
+	}
+}
+
+func TestBaselineRoundTrip(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	result := model.BenchmarkResult{
+		Device:      "kitchen",
+		Firmware:    "1.4.4",
+		RPCLatency:  model.LatencyStats{P50: 15 * time.Millisecond},
+		Load:        []model.LoadResult{{Transport: TransportWS, Concurrency: 3, Throughput: 42}},
+		Regressions: []model.BenchmarkRegression{{Metric: MetricP50}},
+	}
+
+	if err := SaveBaseline(path, result); err != nil {
+		t.Fatalf("SaveBaseline() error = %v", err)
+	}
+	got, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline() error = %v", err)
+	}
+	if got.Firmware != "1.4.4" || got.RPCLatency.P50 != 15*time.Millisecond || len(got.Load) != 1 || got.Load[0].Throughput != 42 {
+		t.Errorf("round trip = %+v", got)
+	}
+	if len(got.Regressions) != 0 {
+		t.Error("regressions saved into baseline")
+	}
+
+	if _, err := LoadBaseline(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing baseline")
+	}
+}
+
+func TestSuggestMaxConcurrent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		results []model.LoadResult
+		want    int
+	}{
+		{"scales to limit", []model.LoadResult{
+			{Concurrency: 1, Throughput: 10}, {Concurrency: 3, Throughput: 25}, {Concurrency: 5, Throughput: 40}, {Concurrency: 8, Throughput: 60},
+		}, 5},
+		{"stops at errors", []model.LoadResult{
+			{Concurrency: 4, Throughput: 30, ErrorRate: 0.1}, {Concurrency: 1, Throughput: 10}, {Concurrency: 2, Throughput: 19},
+		}, 2},
+		{"stops when flat", []model.LoadResult{
+			{Concurrency: 1, Throughput: 10}, {Concurrency: 2, Throughput: 18}, {Concurrency: 3, Throughput: 18.5},
+		}, 2},
+		{"nothing clean", []model.LoadResult{{Concurrency: 1, ErrorRate: 0.5}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := SuggestMaxConcurrent(tt.results, 5); got != tt.want {
+				t.Errorf("SuggestMaxConcurrent() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
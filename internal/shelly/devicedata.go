@@ -2,13 +2,19 @@ package shelly
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/tj-smith47/shelly-go/types"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/tj-smith47/shelly-cli/internal/client"
 	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
 	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/backup"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/export"
 )
 
 // CollectDeviceData gathers device information for the given device names.
@@ -53,6 +59,104 @@ func (s *Service) CollectDeviceData(ctx context.Context, deviceNames []string) [
 	return result
 }
 
+// CollectDeviceBackups reads the configuration of online Gen2+ devices
+// concurrently, as WiFi-less backups keyed by device name. Offline and Gen1
+// devices, and devices whose backup fails, are left out and their reasons
+// returned by name.
+func (s *Service) CollectDeviceBackups(ctx context.Context, devices []model.DeviceData) (map[string]*backup.DeviceBackup, map[string]error) {
+	backups := make(map[string]*backup.DeviceBackup, len(devices))
+	failed := make(map[string]error)
+	var mu sync.Mutex
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(config.GetGlobalMaxConcurrent())
+
+	for _, d := range devices {
+		switch {
+		case !d.Online:
+			failed[d.Name] = fmt.Errorf("device is offline")
+			continue
+		case d.Generation < 2:
+			failed[d.Name] = fmt.Errorf("only Gen2+ devices have exportable config (this is Gen%d)", d.Generation)
+			continue
+		}
+		g.Go(func() error {
+			bkp, err := s.CreateBackup(ctx, d.Name, backup.Options{SkipWiFi: true})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[d.Name] = err
+				return nil
+			}
+			backups[d.Name] = bkp
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		iostreams.DebugErr("collect device backups", err)
+	}
+	return backups, failed
+}
+
+// CollectAnsibleHostStates reads device info and full status for dynamic
+// inventory host vars, concurrently, in the order of deviceNames. Devices
+// that can't be reached keep their config data with Online=false;
+// unreachable devices that aren't registered are left out.
+func (s *Service) CollectAnsibleHostStates(ctx context.Context, deviceNames []string) []export.AnsibleHostState {
+	states := make([]*export.AnsibleHostState, len(deviceNames))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(config.GetGlobalMaxConcurrent())
+
+	for i, device := range deviceNames {
+		g.Go(func() error {
+			state := export.AnsibleHostState{DeviceData: model.DeviceData{Name: device}}
+			deviceCfg, exists := config.GetDevice(device)
+			if exists {
+				state.Address = deviceCfg.Address
+				state.Model = deviceCfg.Model
+				state.Generation = deviceCfg.Generation
+			}
+
+			st, err := s.DeviceStatusAuto(ctx, device)
+			if err != nil || st.Info == nil {
+				iostreams.DebugErr("status "+device, err)
+				if exists {
+					states[i] = &state
+				}
+				return nil
+			}
+			info := st.Info
+			state.Address = info.Address
+			state.Model = info.Model
+			state.Generation = info.Generation
+			state.App = info.App
+			state.Online = true
+			state.ID = info.ID
+			state.MAC = info.MAC
+			state.Type = info.Type
+			state.Firmware = info.Firmware
+			state.AuthEn = info.AuthEn
+			state.Status = st.Status
+			states[i] = &state
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		iostreams.DebugErr("collect ansible hosts", err)
+	}
+
+	result := make([]export.AnsibleHostState, 0, len(states))
+	for _, st := range states {
+		if st != nil {
+			result = append(result, *st)
+		}
+	}
+	return result
+}
+
 // SplitDevicesAndFile splits command args into device names and an optional file path.
 // If the last argument ends with one of the valid extensions, it's treated as the file path.
 func SplitDevicesAndFile(args, validExtensions []string) (deviceNames []string, filePath string) {
@@ -1,8 +1,13 @@
 package shelly
 
 import (
+	"context"
+	"strings"
 	"testing"
 
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
 	"github.com/tj-smith47/shelly-cli/internal/model"
 )
 
@@ -160,3 +165,23 @@ func TestSplitDevicesAndFile(t *testing.T) {
 		}
 	})
 }
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestCollectDeviceBackups_Skipped(t *testing.T) {
+	config.SetFs(afero.NewMemMapFs())
+	t.Cleanup(func() { config.SetFs(nil) })
+
+	svc := &Service{}
+	backups, failed := svc.CollectDeviceBackups(context.Background(), []model.DeviceData{
+		{Name: "porch", Generation: 2},
+		{Name: "garage", Generation: 1, Online: true},
+	})
+	if len(backups) != 0 {
+		t.Errorf("backups = %v, want none", backups)
+	}
+	for name, want := range map[string]string{"porch": "offline", "garage": "Gen1"} {
+		if err := failed[name]; err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("failed[%s] = %v, want %q", name, err, want)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package shelly
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/automation"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/backup"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/export"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/gitops"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/kvs"
+)
+
+// Drift change states, as they appear in drift change descriptions.
+const (
+	driftMissing    = "missing"    // in the baseline, not on the device
+	driftUnexpected = "unexpected" // on the device, not in the baseline
+	driftChanged    = "changed"
+)
+
+// DriftResult is the outcome of comparing a device against its baseline.
+type DriftResult struct {
+	Device   string
+	Baseline config.DriftBaseline
+	Changes  []string // sorted, e.g. "schedule 0 0 22 * * * missing"
+	Warnings []string
+	Err      error
+}
+
+// Drifted reports whether the device no longer matches its baseline.
+func (r DriftResult) Drifted() bool {
+	return r.Err == nil && len(r.Changes) > 0
+}
+
+// CheckDrift compares a device's live state against its baseline. Backups
+// are compared in full; templates only for the components they define, since
+// templates are partial by design; manifests for the sections they manage,
+// with unlisted items in a managed section reported as unexpected. The
+// client reads manifest-managed state and may be nil for other baselines.
+func (s *Service) CheckDrift(ctx context.Context, client gitops.Client, device string, baseline config.DriftBaseline) DriftResult {
+	result := DriftResult{Device: device, Baseline: baseline}
+
+	switch baseline.Kind() {
+	case config.DriftBaselineBackup:
+		bkp, err := backup.LoadAndValidate(baseline.Backup)
+		if err != nil {
+			result.Err = err
+			break
+		}
+		diff, err := s.CompareBackup(ctx, device, bkp)
+		if err != nil {
+			result.Err = err
+			break
+		}
+		result.Changes = backupDriftChanges(diff)
+		result.Warnings = diff.Warnings
+
+	case config.DriftBaselineTemplate:
+		tpl, ok := config.GetDeviceTemplate(baseline.Template)
+		if !ok {
+			result.Err = fmt.Errorf("template %q not found", baseline.Template)
+			break
+		}
+		diffs, err := s.CompareTemplate(ctx, device, tpl.Config)
+		if err != nil {
+			result.Err = err
+			break
+		}
+		result.Changes = configDriftChanges(diffs, false)
+
+	case config.DriftBaselineManifest:
+		desired, err := ResolveManifest(baseline.Manifest, []string{device})
+		if err != nil {
+			result.Err = err
+			break
+		}
+		dp := gitops.PlanDevice(ctx, client, device, desired[device], true)
+		if dp.Err != nil {
+			result.Err = dp.Err
+			break
+		}
+		result.Changes = manifestDriftChanges(dp.Changes)
+
+	default:
+		result.Err = fmt.Errorf("invalid drift baseline for %s", device)
+	}
+
+	sort.Strings(result.Changes)
+	return result
+}
+
+// backupDriftChanges describes a backup comparison as drift changes.
+// Backup diffs are phrased as a restore would apply them: "added" items
+// exist only in the backup.
+func backupDriftChanges(diff *model.BackupDiff) []string {
+	changes := configDriftChanges(diff.ConfigDiffs, true)
+	for _, d := range diff.ScriptDiffs {
+		changes = append(changes, fmt.Sprintf("script %s %s", d.Name, driftState(d.DiffType)))
+	}
+	for _, d := range diff.ScheduleDiffs {
+		changes = append(changes, fmt.Sprintf("schedule %s %s", d.Timespec, driftState(d.DiffType)))
+	}
+	for _, d := range diff.WebhookDiffs {
+		name := d.Event
+		if d.Name != "" {
+			name += " (" + d.Name + ")"
+		}
+		changes = append(changes, fmt.Sprintf("webhook %s %s", name, driftState(d.DiffType)))
+	}
+	return changes
+}
+
+// configDriftChanges describes config diffs as drift changes. Components the
+// baseline does not define are only reported when unexpected is set.
+func configDriftChanges(diffs []model.ConfigDiff, unexpected bool) []string {
+	var changes []string
+	for _, d := range diffs {
+		if d.DiffType == model.DiffRemoved && !unexpected {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("config %s %s", d.Path, driftState(d.DiffType)))
+	}
+	return changes
+}
+
+// manifestDriftChanges describes the changes a manifest apply would make as
+// drift changes.
+func manifestDriftChanges(planned []gitops.Change) []string {
+	changes := make([]string, 0, len(planned))
+	for _, c := range planned {
+		state := driftChanged
+		switch c.Action {
+		case gitops.ActionCreate:
+			state = driftMissing
+		case gitops.ActionDelete:
+			state = driftUnexpected
+		}
+		line := fmt.Sprintf("%s %s %s", c.Kind, c.Name, state)
+		if len(c.Details) > 0 {
+			line += " (" + strings.Join(c.Details, ", ") + ")"
+		}
+		changes = append(changes, line)
+	}
+	return changes
+}
+
+func driftState(diffType string) string {
+	switch diffType {
+	case model.DiffAdded:
+		return driftMissing
+	case model.DiffRemoved:
+		return driftUnexpected
+	default:
+		return driftChanged
+	}
+}
+
+// AdvanceDrift applies a check result to a device's drift record and returns
+// the event to record, if any. Failed checks keep the previous state and only
+// note the error, so an unreachable device neither drifts nor resolves.
+func AdvanceDrift(rec config.DriftRecord, res DriftResult, now time.Time) (config.DriftRecord, *config.DriftEvent) {
+	rec.LastCheck = now
+	rec.Baseline = res.Baseline.String()
+	if res.Err != nil {
+		rec.Error = res.Err.Error()
+		return rec, nil
+	}
+	rec.Error = ""
+
+	event := ""
+	drifted := len(res.Changes) > 0
+	switch {
+	case drifted && !rec.Drifted:
+		event = config.DriftEventDetected
+		rec.Since = now
+	case drifted && !slices.Equal(rec.Changes, res.Changes):
+		event = config.DriftEventChanged
+	case !drifted && rec.Drifted:
+		event = config.DriftEventResolved
+		rec.Since = now
+	case rec.Since.IsZero():
+		rec.Since = now
+	}
+	rec.Drifted = drifted
+	rec.Changes = res.Changes
+
+	if event == "" {
+		return rec, nil
+	}
+	return rec, &config.DriftEvent{
+		Time:     now,
+		Device:   res.Device,
+		Baseline: rec.Baseline,
+		Event:    event,
+		Changes:  res.Changes,
+	}
+}
+
+// NotifyDrift runs an alert action ("notify", "webhook:URL", "command:CMD" or
+// "notifier:NAME") for a drift event. Detected and changed drift fire the
+// action; resolution sends a resolved transition.
+func NotifyDrift(ctx context.Context, action string, ev config.DriftEvent) ActionResult {
+	alert := config.Alert{
+		Name:      "drift:" + ev.Device,
+		Device:    ev.Device,
+		Condition: "drift from " + ev.Baseline,
+		Action:    action,
+	}
+	transition := AlertTransitionFiring
+	value := strings.Join(ev.Changes, "; ")
+	if ev.Event == config.DriftEventResolved {
+		transition = AlertTransitionResolved
+		value = "in sync"
+	}
+	return ExecuteAlertTransition(ctx, alert, transition, value)
+}
+
+// DriftStatus is a device's assigned baseline with its last known drift state.
+type DriftStatus struct {
+	Device   string               `json:"device"`
+	Baseline config.DriftBaseline `json:"baseline"`
+	State    *config.DriftRecord  `json:"state,omitempty"` // nil until the device has been checked
+}
+
+// DriftStatuses pairs each assigned baseline with its recorded state, sorted
+// by device.
+func DriftStatuses(baselines map[string]config.DriftBaseline, states map[string]config.DriftRecord) []DriftStatus {
+	result := make([]DriftStatus, 0, len(baselines))
+	for device, baseline := range baselines {
+		st := DriftStatus{Device: device, Baseline: baseline}
+		if rec, ok := states[device]; ok {
+			st.State = &rec
+		}
+		result = append(result, st)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Device < result[j].Device })
+	return result
+}
+
+// DriftMonitor periodically checks devices against their baselines, keeping
+// their drift state and exposing it as Prometheus metrics.
+type DriftMonitor struct {
+	svc    *Service
+	client gitops.Client
+	mgr    *config.Manager
+
+	mu     sync.RWMutex
+	states map[string]config.DriftRecord
+	events map[string]int // events recorded per device since start
+}
+
+// NewDriftMonitor creates a drift monitor, restoring the persisted drift
+// state so drift found before a restart is not reported again. When the state
+// cannot be read the monitor starts empty and the error is returned with it.
+func (s *Service) NewDriftMonitor(mgr *config.Manager, auto *automation.Service, kv *kvs.Service) (*DriftMonitor, error) {
+	states, err := mgr.LoadDriftStates()
+	if err != nil {
+		states = make(map[string]config.DriftRecord)
+	}
+	return &DriftMonitor{
+		svc:    s,
+		client: s.GitOpsClient(auto, kv),
+		mgr:    mgr,
+		states: states,
+		events: make(map[string]int),
+	}, err
+}
+
+// Check compares every device against its baseline concurrently, advances
+// and persists drift state, and returns the results sorted by device along
+// with the drift events that occurred. The state of devices not checked is
+// kept while they still have a baseline in the config, so a check limited to
+// some devices does not reset the others.
+func (m *DriftMonitor) Check(ctx context.Context, baselines map[string]config.DriftBaseline) ([]DriftResult, []config.DriftEvent, error) {
+	results := make([]DriftResult, 0, len(baselines))
+	var mu sync.Mutex
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(config.GetGlobalMaxConcurrent())
+	for device, baseline := range baselines {
+		g.Go(func() error {
+			res := m.svc.CheckDrift(gctx, m.client, device, baseline)
+			mu.Lock()
+			results = append(results, res)
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Device < results[j].Device })
+
+	now := time.Now()
+	var events []config.DriftEvent
+	m.mu.Lock()
+	for _, res := range results {
+		rec, ev := AdvanceDrift(m.states[res.Device], res, now)
+		m.states[res.Device] = rec
+		if ev != nil {
+			events = append(events, *ev)
+			m.events[res.Device]++
+		}
+	}
+	assigned := m.mgr.ListDriftBaselines()
+	for device := range m.states {
+		if _, ok := assigned[device]; !ok {
+			delete(m.states, device)
+		}
+	}
+	states := make(map[string]config.DriftRecord, len(m.states))
+	for k, v := range m.states {
+		states[k] = v
+	}
+	m.mu.Unlock()
+
+	if err := m.mgr.SaveDriftStates(states); err != nil {
+		return results, events, err
+	}
+	return results, events, m.mgr.AppendDriftEvents(events...)
+}
+
+// FormatMetrics returns the drift state in Prometheus exposition format.
+func (m *DriftMonitor) FormatMetrics() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	devices := make([]string, 0, len(m.states))
+	for device := range m.states {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+
+	metric := func(name, help, typ string, value func(string, config.DriftRecord) float64) []export.PrometheusMetric {
+		out := make([]export.PrometheusMetric, 0, len(devices))
+		for _, device := range devices {
+			rec := m.states[device]
+			out = append(out, export.PrometheusMetric{
+				Name:   name,
+				Help:   help,
+				Type:   typ,
+				Labels: map[string]string{"device": device, "baseline": rec.Baseline},
+				Value:  value(device, rec),
+			})
+		}
+		return out
+	}
+
+	var metrics []export.PrometheusMetric
+	metrics = append(metrics, metric("shelly_drift_detected", "Whether the device differs from its baseline (1 = drifted)", "gauge",
+		func(_ string, rec config.DriftRecord) float64 { return boolMetric(rec.Drifted) })...)
+	metrics = append(metrics, metric("shelly_drift_changes", "Number of differences from the baseline", "gauge",
+		func(_ string, rec config.DriftRecord) float64 { return float64(len(rec.Changes)) })...)
+	metrics = append(metrics, metric("shelly_drift_check_failed", "Whether the last drift check failed (1 = failed)", "gauge",
+		func(_ string, rec config.DriftRecord) float64 { return boolMetric(rec.Error != "") })...)
+	metrics = append(metrics, metric("shelly_drift_last_check_timestamp_seconds", "Unix time of the last drift check", "gauge",
+		func(_ string, rec config.DriftRecord) float64 { return float64(rec.LastCheck.Unix()) })...)
+	metrics = append(metrics, metric("shelly_drift_events_total", "Drift events recorded since the monitor started", "counter",
+		func(device string, _ config.DriftRecord) float64 { return float64(m.events[device]) })...)
+
+	return export.FormatPrometheusMetrics(&export.PrometheusMetrics{Metrics: metrics})
+}
+
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,174 @@
+package shelly
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/gitops"
+)
+
+func TestAdvanceDrift(t *testing.T) {
+	t.Parallel()
+
+	baseline := config.DriftBaseline{Template: "plug"}
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	check := func(rec config.DriftRecord, changes []string, err error, at time.Duration) (config.DriftRecord, string) {
+		res := DriftResult{Device: "kitchen", Baseline: baseline, Changes: changes, Err: err}
+		rec, ev := AdvanceDrift(rec, res, t0.Add(at))
+		if ev == nil {
+			return rec, ""
+		}
+		return rec, ev.Event
+	}
+
+	rec, ev := check(config.DriftRecord{}, nil, nil, 0)
+	if ev != "" || rec.Drifted || !rec.Since.Equal(t0) {
+		t.Fatalf("first in-sync check: rec = %+v, event %q", rec, ev)
+	}
+
+	rec, ev = check(rec, []string{"schedule @sunset missing"}, nil, time.Hour)
+	if ev != config.DriftEventDetected || !rec.Drifted || !rec.Since.Equal(t0.Add(time.Hour)) {
+		t.Fatalf("drift: rec = %+v, event %q", rec, ev)
+	}
+
+	if _, ev = check(rec, []string{"schedule @sunset missing"}, nil, 2*time.Hour); ev != "" {
+		t.Errorf("unchanged drift reported again: %q", ev)
+	}
+
+	rec, ev = check(rec, nil, errors.New("offline"), 3*time.Hour)
+	if ev != "" || !rec.Drifted || rec.Error != "offline" {
+		t.Errorf("failed check: rec = %+v, event %q", rec, ev)
+	}
+
+	rec, ev = check(rec, []string{"schedule @sunset missing", "script boot missing"}, nil, 4*time.Hour)
+	if ev != config.DriftEventChanged || rec.Error != "" || !rec.Since.Equal(t0.Add(time.Hour)) {
+		t.Errorf("changed drift: rec = %+v, event %q", rec, ev)
+	}
+
+	rec, ev = check(rec, nil, nil, 5*time.Hour)
+	if ev != config.DriftEventResolved || rec.Drifted || len(rec.Changes) != 0 {
+		t.Errorf("resolved: rec = %+v, event %q", rec, ev)
+	}
+}
+
+func TestBackupDriftChanges(t *testing.T) {
+	t.Parallel()
+
+	diff := &model.BackupDiff{
+		ConfigDiffs:   []model.ConfigDiff{{Path: "switch:0", DiffType: model.DiffChanged}},
+		ScheduleDiffs: []model.ScheduleDiff{{Timespec: "0 0 22 * * *", DiffType: model.DiffAdded}},
+		ScriptDiffs:   []model.ScriptDiff{{Name: "debug", DiffType: model.DiffRemoved}},
+		WebhookDiffs:  []model.WebhookDiff{{Event: "input.toggle_on", Name: "door", DiffType: model.DiffAdded}},
+	}
+	got := strings.Join(backupDriftChanges(diff), "\n")
+	want := strings.Join([]string{
+		"config switch:0 changed",
+		"script debug unexpected",
+		"schedule 0 0 22 * * * missing",
+		"webhook input.toggle_on (door) missing",
+	}, "\n")
+	if got != want {
+		t.Errorf("backupDriftChanges() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Templates define only some components; others on the device are not drift.
+	cfg := configDriftChanges([]model.ConfigDiff{{Path: "wifi", DiffType: model.DiffRemoved}}, false)
+	if len(cfg) != 0 {
+		t.Errorf("configDriftChanges() = %v, want none", cfg)
+	}
+}
+
+func TestManifestDriftChanges(t *testing.T) {
+	t.Parallel()
+
+	got := manifestDriftChanges([]gitops.Change{
+		{Kind: gitops.KindConfig, Action: gitops.ActionUpdate, Name: "switch:0", Details: []string{`name: "Old" -> "Kitchen"`}},
+		{Kind: gitops.KindKVS, Action: gitops.ActionDelete, Name: "stale"},
+	})
+	want := []string{`config switch:0 changed (name: "Old" -> "Kitchen")`, "kvs stale unexpected"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("manifestDriftChanges() = %q, want %q", got, want)
+	}
+}
+
+func TestDriftMonitor_FormatMetrics(t *testing.T) {
+	t.Parallel()
+
+	m := &DriftMonitor{
+		states: map[string]config.DriftRecord{
+			"kitchen": {Drifted: true, Baseline: "template:plug", Changes: []string{"a", "b"}},
+			"porch":   {Baseline: "backup:/b/porch.json", Error: "offline"},
+		},
+		events: map[string]int{"kitchen": 1},
+	}
+	out := m.FormatMetrics()
+	for _, want := range []string{
+		"# TYPE shelly_drift_detected gauge",
+		"shelly_drift_changes{",
+		"# TYPE shelly_drift_events_total counter",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "# HELP shelly_drift_detected") != 1 {
+		t.Errorf("metric family split:\n%s", out)
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestDriftMonitor_CheckKeepsUnfilteredState(t *testing.T) {
+	config.SetFs(afero.NewMemMapFs())
+	t.Cleanup(func() { config.SetFs(nil) })
+
+	mgr := config.NewManager("/test/config/config.yaml")
+	if err := mgr.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// Missing templates make each check fail fast without a device.
+	baselines := map[string]config.DriftBaseline{
+		"kitchen": {Template: "no-such-template-a"},
+		"porch":   {Template: "no-such-template-b"},
+	}
+	for device, baseline := range baselines {
+		if err := mgr.SetDriftBaseline(device, baseline); err != nil {
+			t.Fatalf("SetDriftBaseline() error = %v", err)
+		}
+	}
+	m := &DriftMonitor{svc: &Service{}, mgr: mgr, states: map[string]config.DriftRecord{}, events: map[string]int{}}
+	ctx := context.Background()
+
+	if _, _, err := m.Check(ctx, baselines); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if _, _, err := m.Check(ctx, map[string]config.DriftBaseline{"kitchen": baselines["kitchen"]}); err != nil {
+		t.Fatalf("filtered Check() error = %v", err)
+	}
+	states, err := mgr.LoadDriftStates()
+	if err != nil {
+		t.Fatalf("LoadDriftStates() error = %v", err)
+	}
+	if _, ok := states["porch"]; !ok {
+		t.Errorf("filtered check dropped porch state: %v", states)
+	}
+
+	if err := mgr.DeleteDriftBaseline("porch"); err != nil {
+		t.Fatalf("DeleteDriftBaseline() error = %v", err)
+	}
+	if _, _, err := m.Check(ctx, map[string]config.DriftBaseline{"kitchen": baselines["kitchen"]}); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if states, err = mgr.LoadDriftStates(); err != nil {
+		t.Fatalf("LoadDriftStates() error = %v", err)
+	}
+	if len(states) != 1 {
+		t.Errorf("state after baseline removal = %v, want kitchen only", states)
+	}
+}
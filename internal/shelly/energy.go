@@ -22,6 +22,7 @@ const (
 	periodDay   = "day"
 	periodWeek  = "week"
 	periodMonth = "month"
+	periodYear  = "year"
 )
 
 // DetectEnergyComponentByID auto-detects the energy component type by checking
@@ -89,7 +90,7 @@ func (s *Service) DetectEnergyComponentType(ctx context.Context, ios *iostreams.
 }
 
 // CalculateTimeRange converts period/from/to flags to Unix timestamps.
-// It supports predefined periods (hour, day, week, month) or explicit from/to times.
+// It supports predefined periods (hour, day, week, month, year) or explicit from/to times.
 // Returns nil pointers if no time range is specified (empty period and no from/to).
 func CalculateTimeRange(period, from, to string) (startTS, endTS *int64, err error) {
 	// If explicit from/to provided, use those
@@ -110,8 +111,10 @@ func CalculateTimeRange(period, from, to string) (startTS, endTS *int64, err err
 		start = now.Add(-7 * 24 * time.Hour)
 	case periodMonth:
 		start = now.Add(-30 * 24 * time.Hour)
+	case periodYear:
+		start = now.AddDate(-1, 0, 0)
 	default:
-		return nil, nil, fmt.Errorf("invalid period: %s (use: hour, day, week, month)", period)
+		return nil, nil, fmt.Errorf("invalid period: %s (use: hour, day, week, month, year)", period)
 	}
 
 	startUnix := start.Unix()
@@ -0,0 +1,191 @@
+package shelly
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/energystore"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/monitoring"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/tariff"
+)
+
+// EnergyCollectResult is the outcome of collecting one device's readings.
+type EnergyCollectResult struct {
+	Device  string `json:"device"`
+	Samples int    `json:"samples"`
+	Error   string `json:"error,omitempty"`
+}
+
+// OpenEnergyStore opens the local energy store next to the config file.
+// In-memory managers (tests) get an empty in-memory store.
+func OpenEnergyStore(mgr *config.Manager) *energystore.Store {
+	if dir := mgr.EnergyDir(); dir != "" {
+		return energystore.New(mgr.Fs(), dir)
+	}
+	return energystore.New(afero.NewMemMapFs(), "energy")
+}
+
+// EnergyStoreRetention converts the configured retention days into a store
+// retention policy.
+func EnergyStoreRetention(cfg config.EnergyConfig) energystore.Retention {
+	days := func(n int) time.Duration {
+		if n <= 0 {
+			return 0
+		}
+		return time.Duration(n) * 24 * time.Hour
+	}
+	r := cfg.Retention
+	return energystore.Retention{Raw: days(r.RawDays), Hourly: days(r.HourlyDays), Daily: days(r.DailyDays)}
+}
+
+// CollectEnergySamples reads the metering components of a device from its
+// full status.
+func (s *Service) CollectEnergySamples(ctx context.Context, device string) ([]energystore.Sample, error) {
+	status, err := s.GetFullStatusAuto(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	return energystore.SamplesFromStatus(status), nil
+}
+
+// CollectEnergy reads every device concurrently and appends their samples to
+// the store, all stamped with now. Results are in device order; a device
+// that cannot be read only fails its own result.
+func (s *Service) CollectEnergy(ctx context.Context, store *energystore.Store, devices []string, now time.Time) ([]EnergyCollectResult, error) {
+	results := make([]EnergyCollectResult, len(devices))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(config.GetGlobalMaxConcurrent())
+	for i, device := range devices {
+		g.Go(func() error {
+			res := EnergyCollectResult{Device: device}
+			samples, err := s.CollectEnergySamples(ctx, device)
+			if err == nil {
+				err = store.Append(device, now, samples)
+			}
+			if err != nil {
+				res.Error = err.Error()
+			} else {
+				res.Samples = len(samples)
+			}
+			results[i] = res
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// LocalEnergyHistory returns the stored series of a device whose component
+// is accepted by match (nil accepts all).
+func LocalEnergyHistory(store *energystore.Store, device string, q energystore.Query, match func(component string) bool) ([]energystore.SeriesData, error) {
+	series, err := store.Series(device)
+	if err != nil {
+		return nil, err
+	}
+	var out []energystore.SeriesData
+	for _, sr := range series {
+		if match != nil && !match(sr.Component) {
+			continue
+		}
+		points, err := store.Query(sr.Device, sr.Component, q)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, energystore.SeriesData{Series: sr, Totals: energystore.Summarize(points), Points: points})
+	}
+	return out, nil
+}
+
+// EnergyComponentMatcher returns a component filter for LocalEnergyHistory:
+// components of componentType (any with ComponentTypeAuto) and, when hasID is
+// set, with the given ID.
+func EnergyComponentMatcher(componentType string, id int, hasID bool) func(component string) bool {
+	return func(component string) bool {
+		typ, cid, _ := strings.Cut(component, ":")
+		if componentType != ComponentTypeAuto && typ != componentType {
+			return false
+		}
+		return !hasID || cid == strconv.Itoa(id)
+	}
+}
+
+// LocalComparisonData builds an energy comparison from the local store,
+// summing the components of each device, priced when a tariff is given.
+func LocalComparisonData(store *energystore.Store, devices []string, period string, startTS, endTS *int64, t *tariff.Tariff) model.ComparisonData {
+	comparison := model.ComparisonData{
+		Period:  period,
+		Devices: make([]model.DeviceEnergy, len(devices)),
+	}
+	q := energystore.Query{}
+	if startTS != nil {
+		comparison.From = time.Unix(*startTS, 0)
+		q.From = comparison.From
+	}
+	if endTS != nil {
+		comparison.To = time.Unix(*endTS, 0)
+		q.To = comparison.To
+	}
+
+	var wg sync.WaitGroup
+	for i, device := range devices {
+		wg.Go(func() {
+			result := model.DeviceEnergy{Device: device}
+			series, err := LocalEnergyHistory(store, device, q, nil)
+			switch {
+			case err != nil:
+				result.Error = err.Error()
+			case len(series) == 0:
+				result.Error = "no local data"
+			default:
+				var intervals []tariff.Interval
+				for _, sr := range series {
+					result.Energy += sr.Totals.Energy / 1000 // Wh to kWh
+					result.AvgPower += sr.Totals.AvgPower
+					result.PeakPower += sr.Totals.PeakPower
+					result.DataPoints += sr.Totals.Samples
+					for _, p := range sr.Points {
+						intervals = append(intervals, tariff.Interval{Start: p.Time(), Import: p.Energy})
+					}
+				}
+				if t != nil {
+					cost := t.Cost(intervals)
+					result.Cost = &cost
+				}
+				result.Online = result.DataPoints > 0
+				if !result.Online {
+					result.Error = "no data in range"
+				}
+			}
+			comparison.Devices[i] = result
+		})
+	}
+	wg.Wait()
+
+	comparison.MinEnergy = -1
+	for _, dev := range comparison.Devices {
+		if !dev.Online {
+			continue
+		}
+		comparison.TotalEnergy += dev.Energy
+		comparison.MaxEnergy = max(comparison.MaxEnergy, dev.Energy)
+		if comparison.MinEnergy < 0 || dev.Energy < comparison.MinEnergy {
+			comparison.MinEnergy = dev.Energy
+		}
+	}
+	comparison.MinEnergy = max(comparison.MinEnergy, 0)
+	if t != nil {
+		comparison.Cost = monitoring.ComparisonCost(comparison, t)
+	}
+	return comparison
+}
@@ -0,0 +1,209 @@
+// Package energystore provides an embedded time-series store for energy
+// readings collected from Shelly devices.
+//
+// Readings are kept per series (a device's metering component) in three
+// tiers: raw samples, hourly and daily aggregates. Each tier is a set of
+// append-only JSON-lines segments, one file per day, month and year
+// respectively, so compaction only ever appends and retention drops whole
+// files. Aggregate buckets are aligned to UTC.
+package energystore
+
+import (
+	"time"
+)
+
+// Tier names, finest first.
+const (
+	TierRaw    = "raw"
+	TierHourly = "1h"
+	TierDaily  = "1d"
+)
+
+// Automatic query resolution thresholds: spans up to rawSpan read raw
+// samples, up to hourlySpan hourly aggregates, anything longer daily ones.
+const (
+	rawSpan    = 2 * 24 * time.Hour
+	hourlySpan = 92 * 24 * time.Hour
+)
+
+// maxIntegrationGap is the longest gap between two samples over which power
+// is integrated into energy when a component reports no energy counter.
+const maxIntegrationGap = 15 * 60
+
+// tier describes one resolution of the store and how it is segmented.
+type tier struct {
+	name   string
+	step   int64                       // bucket width in seconds; 0 for raw samples
+	layout string                      // segment file name layout
+	next   func(t time.Time) time.Time // start of the following segment
+}
+
+var tiers = []tier{
+	{name: TierRaw, layout: "2006-01-02", next: func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+	{name: TierHourly, step: 3600, layout: "2006-01", next: func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+	{name: TierDaily, step: 86400, layout: "2006", next: func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+}
+
+// tierIndex returns the index of the named tier, or -1.
+func tierIndex(name string) int {
+	for i, t := range tiers {
+		if t.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Tiers returns the tier names, finest first.
+func Tiers() []string {
+	names := make([]string, len(tiers))
+	for i, t := range tiers {
+		names[i] = t.name
+	}
+	return names
+}
+
+// Sample is a single reading of a metering component.
+type Sample struct {
+	Component string   // component key, e.g. "switch:0", "em1:1", "meter:0"
+	Power     float64  // active power (W)
+	Voltage   float64  // voltage (V), 0 when not reported
+	Current   float64  // current (A), 0 when not reported
+	Total     *float64 // lifetime energy counter (Wh), nil when not reported
+}
+
+// Point is a stored reading: a raw sample or an aggregate over a bucket.
+type Point struct {
+	TS       int64   `json:"ts"` // Unix seconds; bucket start for aggregates
+	Power    float64 `json:"power_w"`
+	PowerMin float64 `json:"power_min_w"`
+	PowerMax float64 `json:"power_max_w"`
+	Voltage  float64 `json:"voltage_v,omitempty"`
+	Current  float64 `json:"current_a,omitempty"`
+	Energy   float64 `json:"energy_wh"` // energy consumed during the interval
+	Count    int     `json:"samples"`   // raw samples covered
+}
+
+// Time returns the point's timestamp.
+func (p Point) Time() time.Time {
+	return time.Unix(p.TS, 0)
+}
+
+// merge folds p into the aggregate a, weighting averages by sample count.
+func (a *Point) merge(p Point) {
+	if a.Count == 0 {
+		ts := a.TS
+		*a = p
+		a.TS = ts
+		return
+	}
+	total := float64(a.Count + p.Count)
+	weight := func(x, y float64) float64 {
+		return (x*float64(a.Count) + y*float64(p.Count)) / total
+	}
+	a.Power = weight(a.Power, p.Power)
+	a.Voltage = weight(a.Voltage, p.Voltage)
+	a.Current = weight(a.Current, p.Current)
+	a.PowerMin = min(a.PowerMin, p.PowerMin)
+	a.PowerMax = max(a.PowerMax, p.PowerMax)
+	a.Energy += p.Energy
+	a.Count += p.Count
+}
+
+// Downsample aggregates points into buckets of step seconds. Points must be
+// sorted by time.
+func Downsample(points []Point, step int64) []Point {
+	if step <= 0 {
+		return points
+	}
+	var out []Point
+	for _, p := range points {
+		bucket := floorTS(p.TS, step)
+		if n := len(out); n > 0 && out[n-1].TS == bucket {
+			out[n-1].merge(p)
+			continue
+		}
+		agg := Point{TS: bucket}
+		agg.merge(p)
+		out = append(out, agg)
+	}
+	return out
+}
+
+// Totals summarizes a range of points.
+type Totals struct {
+	Energy    float64 `json:"energy_wh"`
+	AvgPower  float64 `json:"avg_power_w"`
+	PeakPower float64 `json:"peak_power_w"`
+	Samples   int     `json:"samples"`
+}
+
+// Summarize returns the energy, average and peak power over points.
+func Summarize(points []Point) Totals {
+	var t Totals
+	var agg Point
+	for _, p := range points {
+		agg.merge(p)
+	}
+	if agg.Count > 0 {
+		t = Totals{Energy: agg.Energy, AvgPower: agg.Power, PeakPower: agg.PowerMax, Samples: agg.Count}
+	}
+	return t
+}
+
+// Retention holds how long each tier is kept. Zero keeps a tier forever.
+type Retention struct {
+	Raw    time.Duration
+	Hourly time.Duration
+	Daily  time.Duration
+}
+
+// forTier returns the retention of the tier at index i.
+func (r Retention) forTier(i int) time.Duration {
+	return [...]time.Duration{r.Raw, r.Hourly, r.Daily}[i]
+}
+
+// ResolutionFor returns the tier an automatic query over [from, to) reads.
+func ResolutionFor(from, to time.Time) string {
+	span := to.Sub(from)
+	switch {
+	case from.IsZero():
+		return TierDaily
+	case span <= rawSpan:
+		return TierRaw
+	case span <= hourlySpan:
+		return TierHourly
+	default:
+		return TierDaily
+	}
+}
+
+// energyDelta returns the energy consumed between the previous sample of a
+// series and s. Counters are preferred; a counter that went backwards was
+// reset (reboot or "shelly energy reset"), so everything since counts.
+// Without a counter, power is integrated over short gaps.
+func energyDelta(st seriesState, ts int64, s Sample) float64 {
+	if st.LastTS == 0 {
+		return 0
+	}
+	if s.Total != nil && st.LastTotal != nil {
+		if *s.Total >= *st.LastTotal {
+			return *s.Total - *st.LastTotal
+		}
+		return *s.Total
+	}
+	dt := ts - st.LastTS
+	if dt <= 0 || dt > maxIntegrationGap {
+		return 0
+	}
+	return (st.LastPower + s.Power) / 2 * float64(dt) / 3600
+}
+
+// floorTS rounds ts down to a multiple of step.
+func floorTS(ts, step int64) int64 {
+	r := ts % step
+	if r < 0 {
+		r += step
+	}
+	return ts - r
+}
@@ -0,0 +1,215 @@
+package energystore
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestStore_AppendEnergyDeltas(t *testing.T) {
+	t.Parallel()
+
+	st := New(afero.NewMemMapFs(), "/energy")
+	t0 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	appendAt := func(at time.Duration, s Sample) {
+		t.Helper()
+		if err := st.Append("kitchen", t0.Add(at), []Sample{s}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	// Counter: deltas, then a reset back to zero.
+	appendAt(0, Sample{Component: "switch:0", Power: 100, Total: ptr(1000)})
+	appendAt(time.Minute, Sample{Component: "switch:0", Power: 100, Total: ptr(1002)})
+	appendAt(2*time.Minute, Sample{Component: "switch:0", Power: 100, Total: ptr(1)})
+	// Duplicate timestamps are ignored.
+	appendAt(2*time.Minute, Sample{Component: "switch:0", Power: 100, Total: ptr(5)})
+
+	// No counter: trapezoidal integration, none across long gaps.
+	appendAt(0, Sample{Component: "pm1:0", Power: 100})
+	appendAt(time.Hour/6, Sample{Component: "pm1:0", Power: 200})
+	appendAt(2*time.Hour, Sample{Component: "pm1:0", Power: 200})
+
+	pts, err := st.Query("kitchen", "switch:0", Query{From: t0, To: t0.Add(time.Hour), Resolution: TierRaw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{0, 2, 1}
+	if len(pts) != len(want) {
+		t.Fatalf("got %d points, want %d", len(pts), len(want))
+	}
+	for i, w := range want {
+		if !approx(pts[i].Energy, w) {
+			t.Errorf("point %d energy = %v, want %v", i, pts[i].Energy, w)
+		}
+	}
+
+	pts, err = st.Query("kitchen", "pm1:0", Query{From: t0, To: t0.Add(3 * time.Hour), Resolution: TierRaw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pts) != 3 || !approx(pts[1].Energy, 25) || pts[2].Energy != 0 {
+		t.Errorf("integrated points = %+v", pts)
+	}
+
+	series, err := st.Series("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 2 || series[0] != (Series{Device: "kitchen", Component: "pm1:0"}) {
+		t.Errorf("Series() = %+v", series)
+	}
+}
+
+func TestStore_CompactAndRetention(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	st := New(fs, "/energy")
+	t0 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// Three days of 15-minute samples at 400W with a counter: 100Wh each.
+	total := 0.0
+	for at := time.Duration(0); at < 72*time.Hour; at += 15 * time.Minute {
+		total += 100
+		if err := st.Append("porch", t0.Add(at), []Sample{{Component: "switch:0", Power: 400, Total: ptr(total)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := t0.Add(72 * time.Hour)
+	retention := Retention{Raw: 24 * time.Hour}
+	if err := st.Compact(now, retention); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	// Compacting again must not duplicate aggregates.
+	if err := st.Compact(now, retention); err != nil {
+		t.Fatal(err)
+	}
+
+	hourly, err := st.Query("porch", "switch:0", Query{From: t0, To: now, Resolution: TierHourly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hourly) != 72 {
+		t.Fatalf("got %d hourly points, want 72", len(hourly))
+	}
+	if hourly[1].Count != 4 || !approx(hourly[1].Energy, 400) || !approx(hourly[1].Power, 400) {
+		t.Errorf("hourly point = %+v", hourly[1])
+	}
+
+	daily, err := st.Query("porch", "switch:0", Query{From: t0, To: now, Resolution: TierDaily})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(daily) != 3 {
+		t.Fatalf("got %d daily points, want 3", len(daily))
+	}
+	// The first sample has no previous counter reading.
+	if !approx(daily[0].Energy, 9500) || !approx(daily[2].Energy, 9600) {
+		t.Errorf("daily energy = %v, %v", daily[0].Energy, daily[2].Energy)
+	}
+
+	// Raw days older than the retention are gone; raw queries fall back to
+	// the hourly tier for them.
+	if ok, _ := afero.Exists(fs, "/energy/porch/switch%3A0/raw/2026-03-01.jsonl"); ok {
+		t.Error("expired raw segment not pruned")
+	}
+	raw, err := st.Query("porch", "switch:0", Query{From: t0, To: now, Resolution: TierRaw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != 48+96 || raw[0].Count != 4 || raw[len(raw)-1].Count != 1 {
+		t.Errorf("raw query: %d points, first %+v", len(raw), raw[0])
+	}
+	if got := Summarize(raw).Energy; !approx(got, total-100) {
+		t.Errorf("summarized energy = %v, want %v", got, total-100)
+	}
+}
+
+func TestStore_QueryUncompactedTail(t *testing.T) {
+	t.Parallel()
+
+	st := New(afero.NewMemMapFs(), "/energy")
+	t0 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 6 {
+		if err := st.Append("desk", t0.Add(time.Duration(i)*30*time.Minute), []Sample{{Component: "switch:0", Power: float64(i)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pts, err := st.Query("desk", "switch:0", Query{From: t0, To: t0.Add(3 * time.Hour), Resolution: TierHourly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pts) != 3 || pts[2].PowerMin != 4 || pts[2].PowerMax != 5 || !approx(pts[2].Power, 4.5) {
+		t.Errorf("hourly tail = %+v", pts)
+	}
+
+	if _, err := st.Query("desk", "switch:0", Query{Resolution: "5m"}); err == nil {
+		t.Error("expected error for invalid resolution")
+	}
+}
+
+func TestResolutionFor(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		from time.Time
+		want string
+	}{
+		{now.Add(-24 * time.Hour), TierRaw},
+		{now.AddDate(0, 0, -30), TierHourly},
+		{now.AddDate(-1, 0, 0), TierDaily},
+		{time.Time{}, TierDaily},
+	}
+	for _, tt := range tests {
+		if got := ResolutionFor(tt.from, now); got != tt.want {
+			t.Errorf("ResolutionFor(%v) = %q, want %q", tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestSamplesFromStatus(t *testing.T) {
+	t.Parallel()
+
+	status := map[string]json.RawMessage{
+		"switch:0":  json.RawMessage(`{"output":true,"apower":52.5,"voltage":230.1,"current":0.25,"aenergy":{"total":1234.5}}`),
+		"switch:1":  json.RawMessage(`{"output":false}`),
+		"em1:0":     json.RawMessage(`{"act_power":-300,"voltage":231,"current":1.4}`),
+		"em1data:0": json.RawMessage(`{"total_act_energy":5000}`),
+		"em:0":      json.RawMessage(`{"total_act_power":900,"total_current":4,"a_voltage":230,"b_voltage":232,"c_voltage":0}`),
+		"emdata:0":  json.RawMessage(`{"total_act":88000}`),
+		"wifi":      json.RawMessage(`{"rssi":-60}`),
+		"meters":    json.RawMessage(`[{"power":12,"total":600,"is_valid":true},{"power":0,"is_valid":false}]`),
+	}
+
+	got := SamplesFromStatus(status)
+	if len(got) != 4 {
+		t.Fatalf("got %d samples: %+v", len(got), got)
+	}
+	byComp := make(map[string]Sample)
+	for _, s := range got {
+		byComp[s.Component] = s
+	}
+	if s := byComp["switch:0"]; s.Power != 52.5 || s.Total == nil || *s.Total != 1234.5 {
+		t.Errorf("switch sample = %+v", s)
+	}
+	if s := byComp["em1:0"]; s.Power != -300 || s.Total == nil || *s.Total != 5000 {
+		t.Errorf("em1 sample = %+v", s)
+	}
+	if s := byComp["em:0"]; s.Power != 900 || s.Voltage != 231 || *s.Total != 88000 {
+		t.Errorf("em sample = %+v", s)
+	}
+	if s := byComp["meter:0"]; s.Power != 12 || *s.Total != 10 {
+		t.Errorf("gen1 meter sample = %+v", s)
+	}
+}
@@ -0,0 +1,168 @@
+package energystore
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// meteredComponents are the Gen2+ component types that may report active
+// power (apower) and an aenergy counter.
+var meteredComponents = map[string]bool{
+	"switch": true,
+	"cover":  true,
+	"light":  true,
+	"rgb":    true,
+	"rgbw":   true,
+	"cct":    true,
+	"pm1":    true,
+}
+
+// gen2Meter is the metering subset of a Gen2+ component status.
+type gen2Meter struct {
+	APower   *float64 `json:"apower"`
+	ActPower *float64 `json:"act_power"`
+	Voltage  float64  `json:"voltage"`
+	Current  float64  `json:"current"`
+	AEnergy  *struct {
+		Total float64 `json:"total"`
+	} `json:"aenergy"`
+}
+
+// gen2EM is the metering subset of a 3-phase EM status.
+type gen2EM struct {
+	TotalActPower *float64 `json:"total_act_power"`
+	TotalCurrent  float64  `json:"total_current"`
+	AVoltage      float64  `json:"a_voltage"`
+	BVoltage      float64  `json:"b_voltage"`
+	CVoltage      float64  `json:"c_voltage"`
+}
+
+// gen1Meter covers entries of the Gen1 "meters" and "emeters" arrays.
+type gen1Meter struct {
+	Power   float64  `json:"power"`
+	Voltage float64  `json:"voltage"`
+	Current float64  `json:"current"`
+	Total   *float64 `json:"total"`
+	IsValid *bool    `json:"is_valid"`
+}
+
+// SamplesFromStatus extracts metering samples from a full device status:
+// Shelly.GetStatus for Gen2+ devices or /status for Gen1. Components that do
+// not report power are skipped. Samples are sorted by component.
+func SamplesFromStatus(status map[string]json.RawMessage) []Sample {
+	var samples []Sample
+	for key, raw := range status {
+		typ, id, ok := strings.Cut(key, ":")
+		if !ok {
+			continue
+		}
+		switch {
+		case typ == "em":
+			if s, ok := emSample(key, raw, status["emdata:"+id]); ok {
+				samples = append(samples, s)
+			}
+		case typ == "em1":
+			if s, ok := meterSample(key, raw, status["em1data:"+id]); ok {
+				samples = append(samples, s)
+			}
+		case meteredComponents[typ]:
+			if s, ok := meterSample(key, raw, nil); ok {
+				samples = append(samples, s)
+			}
+		}
+	}
+
+	// Gen1: meters report totals in watt-minutes, emeters in watt-hours.
+	samples = append(samples, gen1Samples("meter", status["meters"], 1.0/60)...)
+	samples = append(samples, gen1Samples("emeter", status["emeters"], 1)...)
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Component < samples[j].Component })
+	return samples
+}
+
+func meterSample(key string, raw, em1data json.RawMessage) (Sample, bool) {
+	var m gen2Meter
+	if json.Unmarshal(raw, &m) != nil {
+		return Sample{}, false
+	}
+	power := m.APower
+	if power == nil {
+		power = m.ActPower
+	}
+	if power == nil {
+		return Sample{}, false
+	}
+
+	s := Sample{Component: key, Power: *power, Voltage: m.Voltage, Current: m.Current}
+	if m.AEnergy != nil {
+		s.Total = &m.AEnergy.Total
+	}
+	if em1data != nil {
+		var d struct {
+			Total *float64 `json:"total_act_energy"`
+		}
+		if json.Unmarshal(em1data, &d) == nil && d.Total != nil {
+			s.Total = d.Total
+		}
+	}
+	return s, true
+}
+
+func emSample(key string, raw, emdata json.RawMessage) (Sample, bool) {
+	var m gen2EM
+	if json.Unmarshal(raw, &m) != nil || m.TotalActPower == nil {
+		return Sample{}, false
+	}
+
+	s := Sample{Component: key, Power: *m.TotalActPower, Current: m.TotalCurrent}
+	var sum float64
+	var n int
+	for _, v := range []float64{m.AVoltage, m.BVoltage, m.CVoltage} {
+		if v > 0 {
+			sum += v
+			n++
+		}
+	}
+	if n > 0 {
+		s.Voltage = sum / float64(n)
+	}
+	if emdata != nil {
+		var d struct {
+			Total *float64 `json:"total_act"`
+		}
+		if json.Unmarshal(emdata, &d) == nil && d.Total != nil {
+			s.Total = d.Total
+		}
+	}
+	return s, true
+}
+
+func gen1Samples(prefix string, raw json.RawMessage, toWh float64) []Sample {
+	if raw == nil {
+		return nil
+	}
+	var meters []gen1Meter
+	if json.Unmarshal(raw, &meters) != nil {
+		return nil
+	}
+	var samples []Sample
+	for i, m := range meters {
+		if m.IsValid != nil && !*m.IsValid {
+			continue
+		}
+		s := Sample{
+			Component: prefix + ":" + strconv.Itoa(i),
+			Power:     m.Power,
+			Voltage:   m.Voltage,
+			Current:   m.Current,
+		}
+		if m.Total != nil {
+			total := *m.Total * toWh
+			s.Total = &total
+		}
+		samples = append(samples, s)
+	}
+	return samples
+}
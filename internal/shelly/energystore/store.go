@@ -0,0 +1,450 @@
+package energystore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	iofs "io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Store is an energy time-series store rooted at a directory:
+//
+//	<dir>/<device>/<component>/state.json
+//	<dir>/<device>/<component>/raw/2026-03-14.jsonl
+//	<dir>/<device>/<component>/1h/2026-03.jsonl
+//	<dir>/<device>/<component>/1d/2026.jsonl
+//
+// A Store is safe for concurrent use within one process.
+type Store struct {
+	fs  afero.Fs
+	dir string
+	mu  sync.Mutex
+}
+
+// Series identifies a stored series.
+type Series struct {
+	Device    string `json:"device"`
+	Component string `json:"component"`
+}
+
+// SeriesData is a series with the points of a query and their totals.
+type SeriesData struct {
+	Series
+	Totals Totals  `json:"totals"`
+	Points []Point `json:"points"`
+}
+
+// seriesState is the per-series bookkeeping persisted next to its segments.
+type seriesState struct {
+	LastTS    int64            `json:"last_ts,omitempty"`
+	LastPower float64          `json:"last_power,omitempty"`
+	LastTotal *float64         `json:"last_total,omitempty"`
+	Compacted map[string]int64 `json:"compacted,omitempty"` // tier → end of the last aggregated bucket
+}
+
+// Query selects stored points.
+type Query struct {
+	From       time.Time // zero reads from the beginning
+	To         time.Time // zero reads up to now
+	Resolution string    // tier name, or "" to pick one from the span
+}
+
+// New returns a store rooted at dir on fs.
+func New(fs afero.Fs, dir string) *Store {
+	return &Store{fs: fs, dir: dir}
+}
+
+// Dir returns the store's root directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Append records samples taken from device at the given time.
+func (s *Store) Append(device string, at time.Time, samples []Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts := at.Unix()
+	for _, sample := range samples {
+		dir := s.seriesDir(device, sample.Component)
+		st, err := s.loadState(dir)
+		if err != nil {
+			return err
+		}
+		if ts <= st.LastTS {
+			continue // duplicate or out-of-order sample
+		}
+		p := Point{
+			TS:       ts,
+			Power:    sample.Power,
+			PowerMin: sample.Power,
+			PowerMax: sample.Power,
+			Voltage:  sample.Voltage,
+			Current:  sample.Current,
+			Energy:   energyDelta(st, ts, sample),
+			Count:    1,
+		}
+		if err := s.appendPoints(dir, tiers[0], []Point{p}); err != nil {
+			return err
+		}
+		st.LastTS, st.LastPower, st.LastTotal = ts, sample.Power, sample.Total
+		if err := s.saveState(dir, st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Series returns the stored series, sorted, limited to device unless it is "".
+func (s *Store) Series(device string) ([]Series, error) {
+	var devices []string
+	if device != "" {
+		devices = []string{escapeName(device)}
+	} else {
+		names, err := s.readDirNames(s.dir, true)
+		if err != nil {
+			return nil, err
+		}
+		devices = names
+	}
+
+	var out []Series
+	for _, d := range devices {
+		dev, err := url.QueryUnescape(d)
+		if err != nil {
+			continue
+		}
+		comps, err := s.readDirNames(filepath.Join(s.dir, d), true)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range comps {
+			if comp, err := url.QueryUnescape(c); err == nil {
+				out = append(out, Series{Device: dev, Component: comp})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Device != out[j].Device {
+			return out[i].Device < out[j].Device
+		}
+		return out[i].Component < out[j].Component
+	})
+	return out, nil
+}
+
+// Query returns the points of a series within the query range, sorted by
+// time. Data not yet aggregated into the chosen tier is downsampled from finer
+// tiers on the fly, and ranges already dropped from it by retention are filled
+// from coarser tiers.
+func (s *Store) Query(device, component string, q Query) ([]Point, error) {
+	to := q.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	resolution := q.Resolution
+	if resolution == "" {
+		resolution = ResolutionFor(q.From, to)
+	}
+	ti := tierIndex(resolution)
+	if ti < 0 {
+		return nil, fmt.Errorf("invalid resolution %q (use: %s)", resolution, strings.Join(Tiers(), ", "))
+	}
+
+	var from int64
+	if !q.From.IsZero() {
+		from = q.From.Unix()
+	}
+	end := to.Unix()
+
+	dir := s.seriesDir(device, component)
+	points, err := s.points(dir, ti, from, end)
+	if err != nil {
+		return nil, err
+	}
+
+	for c := ti + 1; c < len(tiers); c++ {
+		cutoff := end
+		if len(points) > 0 {
+			cutoff = points[0].TS
+		}
+		if cutoff <= from {
+			break
+		}
+		older, err := s.readTier(dir, tiers[c], from, cutoff)
+		if err != nil {
+			return nil, err
+		}
+		older = slices.DeleteFunc(older, func(p Point) bool { return p.TS+tiers[c].step > cutoff })
+		points = append(older, points...)
+	}
+	return points, nil
+}
+
+// points reads tier ti of a series and appends the not yet aggregated tail,
+// downsampled from finer tiers.
+func (s *Store) points(dir string, ti int, from, to int64) ([]Point, error) {
+	points, err := s.readTier(dir, tiers[ti], from, to)
+	if err != nil || ti == 0 {
+		return points, err
+	}
+	after := from
+	if n := len(points); n > 0 {
+		after = max(after, points[n-1].TS+tiers[ti].step)
+	}
+	if after >= to {
+		return points, nil
+	}
+	finer, err := s.points(dir, ti-1, after, to)
+	if err != nil {
+		return nil, err
+	}
+	return append(points, Downsample(finer, tiers[ti].step)...), nil
+}
+
+// Compact aggregates completed buckets into the hourly and daily tiers and
+// drops segments older than their tier's retention. Raw and hourly segments
+// are only dropped once they have been aggregated into the next tier.
+func (s *Store) Compact(now time.Time, retention Retention) error {
+	series, err := s.Series("")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for _, sr := range series {
+		if err := s.compactSeries(s.seriesDir(sr.Device, sr.Component), now, retention); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", sr.Device, sr.Component, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) compactSeries(dir string, now time.Time, retention Retention) error {
+	st, err := s.loadState(dir)
+	if err != nil {
+		return err
+	}
+	if st.Compacted == nil {
+		st.Compacted = make(map[string]int64)
+	}
+
+	for ti := 1; ti < len(tiers); ti++ {
+		t := tiers[ti]
+		start := st.Compacted[t.name]
+		cutoff := floorTS(now.Unix(), t.step)
+		if cutoff <= start {
+			continue
+		}
+		finer, err := s.readTier(dir, tiers[ti-1], start, cutoff)
+		if err != nil {
+			return err
+		}
+		if err := s.appendPoints(dir, t, Downsample(finer, t.step)); err != nil {
+			return err
+		}
+		st.Compacted[t.name] = cutoff
+	}
+
+	for ti, t := range tiers {
+		keep := retention.forTier(ti)
+		if keep <= 0 {
+			continue
+		}
+		limit := now.Add(-keep).Unix()
+		if ti+1 < len(tiers) {
+			limit = min(limit, st.Compacted[tiers[ti+1].name])
+		}
+		if err := s.prune(dir, t, limit); err != nil {
+			return err
+		}
+	}
+
+	return s.saveState(dir, st)
+}
+
+// prune removes segments of a tier that end at or before limit.
+func (s *Store) prune(dir string, t tier, limit int64) error {
+	tierDir := filepath.Join(dir, t.name)
+	names, err := s.readDirNames(tierDir, false)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		_, end, ok := segmentRange(t, name)
+		if !ok || end.Unix() > limit {
+			continue
+		}
+		if err := s.fs.Remove(filepath.Join(tierDir, name)); err != nil {
+			return fmt.Errorf("remove segment: %w", err)
+		}
+	}
+	return nil
+}
+
+// readTier returns the points of a tier with from <= TS < to.
+func (s *Store) readTier(dir string, t tier, from, to int64) ([]Point, error) {
+	tierDir := filepath.Join(dir, t.name)
+	names, err := s.readDirNames(tierDir, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []Point
+	for _, name := range names {
+		start, end, ok := segmentRange(t, name)
+		if !ok || end.Unix() <= from || start.Unix() >= to {
+			continue
+		}
+		data, err := afero.ReadFile(s.fs, filepath.Join(tierDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("read segment: %w", err)
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			var p Point
+			if json.Unmarshal(scanner.Bytes(), &p) != nil {
+				continue // skip a line torn by an interrupted write
+			}
+			if p.TS >= from && p.TS < to {
+				points = append(points, p)
+			}
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].TS < points[j].TS })
+	return points, nil
+}
+
+// appendPoints appends points to the segments of a tier.
+func (s *Store) appendPoints(dir string, t tier, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+	tierDir := filepath.Join(dir, t.name)
+	if err := s.fs.MkdirAll(tierDir, 0o700); err != nil {
+		return fmt.Errorf("create energy store directory: %w", err)
+	}
+
+	lines := make(map[string]*bytes.Buffer)
+	var order []string
+	for _, p := range points {
+		name := time.Unix(p.TS, 0).UTC().Format(t.layout) + ".jsonl"
+		buf, ok := lines[name]
+		if !ok {
+			buf = &bytes.Buffer{}
+			lines[name] = buf
+			order = append(order, name)
+		}
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	for _, name := range order {
+		f, err := s.fs.OpenFile(filepath.Join(tierDir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return fmt.Errorf("open segment: %w", err)
+		}
+		_, werr := f.Write(lines[name].Bytes())
+		if cerr := f.Close(); werr == nil {
+			werr = cerr
+		}
+		if werr != nil {
+			return fmt.Errorf("write segment: %w", werr)
+		}
+	}
+	return nil
+}
+
+func (s *Store) loadState(dir string) (seriesState, error) {
+	var st seriesState
+	data, err := afero.ReadFile(s.fs, filepath.Join(dir, "state.json"))
+	if errors.Is(err, iofs.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("read series state: %w", err)
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("parse series state: %w", err)
+	}
+	return st, nil
+}
+
+func (s *Store) saveState(dir string, st seriesState) error {
+	if err := s.fs.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create energy store directory: %w", err)
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := afero.WriteFile(s.fs, filepath.Join(dir, "state.json"), data, 0o600); err != nil {
+		return fmt.Errorf("write series state: %w", err)
+	}
+	return nil
+}
+
+// readDirNames returns the sorted names of the subdirectories (dirs) or files
+// of dir, or none if it is missing.
+func (s *Store) readDirNames(dir string, dirs bool) ([]string, error) {
+	entries, err := afero.ReadDir(s.fs, dir)
+	if errors.Is(err, iofs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read energy store: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() == dirs {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *Store) seriesDir(device, component string) string {
+	return filepath.Join(s.dir, escapeName(device), escapeName(component))
+}
+
+// segmentRange returns the time range covered by a segment file.
+func segmentRange(t tier, name string) (start, end time.Time, ok bool) {
+	base, found := strings.CutSuffix(name, ".jsonl")
+	if !found {
+		return start, end, false
+	}
+	start, err := time.ParseInLocation(t.layout, base, time.UTC)
+	if err != nil {
+		return start, end, false
+	}
+	return start, t.next(start), true
+}
+
+// escapeName makes a device or component name safe as a single path element.
+func escapeName(name string) string {
+	escaped := url.QueryEscape(name)
+	if strings.HasPrefix(escaped, ".") {
+		escaped = "%2E" + escaped[1:]
+	}
+	return escaped
+}
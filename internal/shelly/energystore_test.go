@@ -0,0 +1,80 @@
+package shelly
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/energystore"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/tariff"
+)
+
+func TestLocalComparisonData(t *testing.T) {
+	t.Parallel()
+
+	store := energystore.New(afero.NewMemMapFs(), "/energy")
+	t0 := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	total := func(v float64) *float64 { return &v }
+	for i, wh := range []float64{0, 500, 1500} {
+		at := t0.Add(time.Duration(i) * time.Hour)
+		if err := store.Append("kitchen", at, []energystore.Sample{{Component: "switch:0", Power: 500, Total: total(wh)}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Append("porch", at, []energystore.Sample{{Component: "pm1:0", Power: 100, Total: total(wh / 5)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start, end := t0.Unix(), t0.Add(24*time.Hour).Unix()
+	cmp := LocalComparisonData(store, []string{"kitchen", "porch", "garage"}, "day", &start, &end, tariff.Flat(0.2, "$"))
+	if len(cmp.Devices) != 3 {
+		t.Fatalf("got %d devices", len(cmp.Devices))
+	}
+	if d := cmp.Devices[0]; !d.Online || math.Abs(d.Energy-1.5) > 1e-9 || d.DataPoints != 3 {
+		t.Errorf("kitchen = %+v", d)
+	}
+	if d := cmp.Devices[2]; d.Online || d.Error == "" {
+		t.Errorf("garage = %+v", d)
+	}
+	if math.Abs(cmp.TotalEnergy-1.8) > 1e-9 || math.Abs(cmp.MinEnergy-0.3) > 1e-9 || math.Abs(cmp.MaxEnergy-1.5) > 1e-9 {
+		t.Errorf("totals = %v, min %v, max %v", cmp.TotalEnergy, cmp.MinEnergy, cmp.MaxEnergy)
+	}
+	if cmp.Cost == nil || math.Abs(cmp.Cost.Total-0.36) > 1e-9 || cmp.Devices[2].Cost != nil {
+		t.Errorf("cost = %+v, garage cost = %+v", cmp.Cost, cmp.Devices[2].Cost)
+	}
+}
+
+func TestEnergyComponentMatcher(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		typ       string
+		id        int
+		hasID     bool
+		component string
+		want      bool
+	}{
+		{ComponentTypeAuto, 0, false, "switch:1", true},
+		{ComponentTypeAuto, 1, true, "switch:1", true},
+		{ComponentTypeAuto, 0, true, "switch:1", false},
+		{ComponentTypeEM1, 0, false, "em1:0", true},
+		{ComponentTypeEM1, 0, false, "em:0", false},
+	}
+	for _, tt := range tests {
+		if got := EnergyComponentMatcher(tt.typ, tt.id, tt.hasID)(tt.component); got != tt.want {
+			t.Errorf("EnergyComponentMatcher(%q, %d, %v)(%q) = %v, want %v", tt.typ, tt.id, tt.hasID, tt.component, got, tt.want)
+		}
+	}
+}
+
+func TestEnergyStoreRetention(t *testing.T) {
+	t.Parallel()
+
+	r := EnergyStoreRetention((&config.Config{}).GetEnergyConfig())
+	if r.Raw != 30*24*time.Hour || r.Hourly != 730*24*time.Hour || r.Daily != 0 {
+		t.Errorf("EnergyStoreRetention(defaults) = %+v", r)
+	}
+}
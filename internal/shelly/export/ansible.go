@@ -2,10 +2,15 @@
 package export
 
 import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/tj-smith47/shelly-cli/internal/config"
 	"github.com/tj-smith47/shelly-cli/internal/model"
 )
 
@@ -93,3 +98,179 @@ func NormalizeGroupName(modelStr string) string {
 	name = strings.ReplaceAll(name, ".", "_")
 	return name
 }
+
+// AnsibleHostState is the live state of one host in a dynamic inventory.
+type AnsibleHostState struct {
+	model.DeviceData
+	ID       string
+	MAC      string
+	Type     string // model code, e.g. SNPL-00112EU
+	Firmware string // firmware ID as reported by the device
+	AuthEn   bool
+	Status   map[string]any // full device status; nil when offline
+}
+
+// AnsibleDynamicGroup is a group in dynamic inventory --list output.
+type AnsibleDynamicGroup struct {
+	Hosts    []string `json:"hosts,omitempty"`
+	Children []string `json:"children,omitempty"`
+}
+
+// firmwareVersionRe matches the semantic version inside a firmware ID,
+// e.g. 1.4.2 in "20240625-122245/1.4.2-gc2639da" or "v1.14.0-gcb84623".
+var firmwareVersionRe = regexp.MustCompile(`(\d+\.\d+\.\d+)`)
+
+// FirmwareVersion extracts the x.y.z version from a firmware ID, or returns
+// the ID unchanged when it contains none.
+func FirmwareVersion(fw string) string {
+	if m := firmwareVersionRe.FindString(fw); m != "" {
+		return m
+	}
+	return fw
+}
+
+// BuildAnsibleDynamicInventory builds dynamic inventory --list output: the
+// top-level group with every host, groups per config group (group_<name>),
+// model (model_<model>), generation (gen<N>) and firmware version
+// (fw_<x_y_z>), an offline group, and host vars for every host under
+// _meta.hostvars.
+func BuildAnsibleDynamicInventory(hosts []AnsibleHostState, groups map[string]config.Group, groupName string) map[string]any {
+	members := make(map[string][]string)
+	add := func(group, host string) {
+		members[group] = append(members[group], host)
+	}
+
+	hostVars := make(map[string]any, len(hosts))
+	var all []string
+	for _, h := range hosts {
+		all = append(all, h.Name)
+		hostVars[h.Name] = AnsibleHostVars(h, groups)
+
+		for _, g := range deviceGroups(h.Name, groups) {
+			add("group_"+NormalizeGroupName(g), h.Name)
+		}
+		if h.Model != "" {
+			add("model_"+NormalizeGroupName(h.Model), h.Name)
+		}
+		if h.Generation > 0 {
+			add(fmt.Sprintf("gen%d", h.Generation), h.Name)
+		}
+		if h.Firmware != "" {
+			add("fw_"+NormalizeGroupName(FirmwareVersion(h.Firmware)), h.Name)
+		}
+		if !h.Online {
+			add("offline", h.Name)
+		}
+	}
+	sort.Strings(all)
+
+	children := make([]string, 0, len(members))
+	for name := range members {
+		children = append(children, name)
+	}
+	sort.Strings(children)
+
+	inventory := map[string]any{
+		"_meta":   map[string]any{"hostvars": hostVars},
+		groupName: AnsibleDynamicGroup{Hosts: all, Children: children},
+	}
+	for name, hostNames := range members {
+		sort.Strings(hostNames)
+		inventory[name] = AnsibleDynamicGroup{Hosts: hostNames}
+	}
+	return inventory
+}
+
+// AnsibleHostVars returns the host vars of one host: connection address,
+// device identity and firmware, config groups and, for online hosts, live
+// status (uptime, WiFi, available updates and the full status).
+func AnsibleHostVars(h AnsibleHostState, groups map[string]config.Group) map[string]any {
+	vars := map[string]any{
+		"ansible_host":      h.Address,
+		"shelly_name":       h.Name,
+		"shelly_model":      h.Model,
+		"shelly_generation": h.Generation,
+		"shelly_online":     h.Online,
+		"shelly_groups":     deviceGroups(h.Name, groups),
+	}
+	setString := func(key, value string) {
+		if value != "" {
+			vars[key] = value
+		}
+	}
+	setString("shelly_app", h.App)
+	setString("shelly_id", h.ID)
+	setString("shelly_mac", h.MAC)
+	setString("shelly_type", h.Type)
+	setString("shelly_fw_id", h.Firmware)
+	if h.Firmware != "" {
+		vars["shelly_fw_version"] = FirmwareVersion(h.Firmware)
+	}
+	if !h.Online {
+		return vars
+	}
+	vars["shelly_auth_enabled"] = h.AuthEn
+
+	if h.Status == nil {
+		return vars
+	}
+	vars["shelly_status"] = h.Status
+	if h.Generation == 1 {
+		addGen1StatusVars(vars, h.Status)
+		return vars
+	}
+	if sys, ok := h.Status["sys"].(map[string]any); ok {
+		if uptime, ok := sys["uptime"].(float64); ok {
+			vars["shelly_uptime"] = uptime
+		}
+		updates, _ := sys["available_updates"].(map[string]any)
+		for _, channel := range []string{"stable", "beta"} {
+			if u, ok := updates[channel].(map[string]any); ok {
+				vars["shelly_update_"+channel] = fmt.Sprint(u["version"])
+			}
+		}
+	}
+	addWifiVars(vars, h.Status["wifi"], "sta_ip")
+	return vars
+}
+
+// addGen1StatusVars adds uptime, WiFi and update vars from a Gen1 status.
+func addGen1StatusVars(vars, status map[string]any) {
+	if uptime, ok := status["uptime"].(float64); ok {
+		vars["shelly_uptime"] = uptime
+	}
+	if update, ok := status["update"].(map[string]any); ok && update["has_update"] == true {
+		vars["shelly_update_stable"] = fmt.Sprint(update["new_version"])
+	}
+	addWifiVars(vars, status["wifi_sta"], "ip")
+}
+
+// addWifiVars adds RSSI, SSID and IP vars from a WiFi status object.
+func addWifiVars(vars map[string]any, wifi any, ipKey string) {
+	w, ok := wifi.(map[string]any)
+	if !ok {
+		return
+	}
+	if rssi, ok := w["rssi"].(float64); ok {
+		vars["shelly_wifi_rssi"] = rssi
+	}
+	if ssid, ok := w["ssid"].(string); ok && ssid != "" {
+		vars["shelly_wifi_ssid"] = ssid
+	}
+	if ip, ok := w[ipKey].(string); ok && ip != "" {
+		vars["shelly_ip"] = ip
+	}
+}
+
+// deviceGroups returns the sorted names of the config groups containing
+// the device.
+func deviceGroups(device string, groups map[string]config.Group) []string {
+	names := []string{}
+	for name, g := range groups {
+		if slices.Contains(g.Devices, device) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -6,12 +6,14 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/tj-smith47/shelly-go/gen2/components"
 
 	"github.com/tj-smith47/shelly-cli/internal/iostreams"
 	"github.com/tj-smith47/shelly-cli/internal/output"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/energystore"
 )
 
 // EMDataCSVHeaders defines the CSV header row for 3-phase energy meter data.
@@ -32,6 +34,12 @@ var EM1DataCSVHeaders = []string{
 	"act_energy", "act_ret_energy",
 }
 
+// LocalEnergyCSVHeaders defines the CSV header row for local energy store data.
+var LocalEnergyCSVHeaders = []string{
+	"timestamp", "device", "component",
+	"power_w", "power_min_w", "power_max_w", "voltage_v", "current_a", "energy_wh", "samples",
+}
+
 // dataBlock represents a generic data block with timestamp and period.
 type dataBlock[V any] struct {
 	TS     int64
@@ -161,6 +169,61 @@ func formatEM1DataRow(timestamp string, v components.EM1DataValues) []string {
 	}
 }
 
+// FormatLocalEnergyCSV converts series from the local energy store to CSV,
+// one row per point.
+func FormatLocalEnergyCSV(series []energystore.SeriesData) ([]byte, error) {
+	var buf bytes.Buffer
+	csvWriter := csv.NewWriter(&buf)
+	if err := csvWriter.Write(LocalEnergyCSVHeaders); err != nil {
+		return nil, fmt.Errorf("failed to write CSV headers: %w", err)
+	}
+
+	rows := 0
+	for _, sr := range series {
+		for _, p := range sr.Points {
+			row := []string{
+				time.Unix(p.TS, 0).UTC().Format(time.RFC3339), sr.Device, sr.Component,
+				output.FormatFloat(p.Power),
+				output.FormatFloat(p.PowerMin),
+				output.FormatFloat(p.PowerMax),
+				output.FormatFloat(p.Voltage),
+				output.FormatFloat(p.Current),
+				output.FormatFloat(p.Energy),
+				strconv.Itoa(p.Count),
+			}
+			if err := csvWriter.Write(row); err != nil {
+				return nil, fmt.Errorf("failed to write CSV row: %w", err)
+			}
+			rows++
+		}
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("no data to export")
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return nil, fmt.Errorf("CSV write error: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// LocalEnergy exports series from the local energy store in the specified format.
+func LocalEnergy(ios *iostreams.IOStreams, series []energystore.SeriesData, format, outputFile string) error {
+	switch format {
+	case FormatCSV:
+		return output.ExportCSV(ios, outputFile, func() ([]byte, error) {
+			return FormatLocalEnergyCSV(series)
+		})
+	case FormatJSON:
+		return output.ExportToFile(ios, series, outputFile, output.FormatJSON, "JSON")
+	case FormatYAML:
+		return output.ExportToFile(ios, series, outputFile, output.FormatYAML, "YAML")
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
 // EnergyExportFormat constants for output format types.
 const (
 	FormatCSV  = "csv"
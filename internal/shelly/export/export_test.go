@@ -2,9 +2,17 @@
 package export
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	shellybackup "github.com/tj-smith47/shelly-go/backup"
@@ -12,7 +20,9 @@ import (
 
 	"github.com/tj-smith47/shelly-cli/internal/config"
 	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/ratelimit"
 	"github.com/tj-smith47/shelly-cli/internal/shelly/backup"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/energystore"
 )
 
 const (
@@ -301,6 +311,26 @@ func TestFormatEM1DataCSV(t *testing.T) {
 	}
 }
 
+func TestFormatLocalEnergyCSV(t *testing.T) {
+	t.Parallel()
+
+	if _, err := FormatLocalEnergyCSV(nil); err == nil {
+		t.Error("expected error for no data")
+	}
+
+	data, err := FormatLocalEnergyCSV([]energystore.SeriesData{{
+		Series: energystore.Series{Device: "kitchen", Component: "switch:0"},
+		Points: []energystore.Point{{TS: 1700000000, Power: 50, PowerMin: 40, PowerMax: 60, Energy: 50, Count: 60}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "2023-11-14T22:13:20Z,kitchen,switch:0,") || !strings.HasSuffix(lines[1], ",60") {
+		t.Errorf("CSV = %q", data)
+	}
+}
+
 func TestSanitizeFilename(t *testing.T) {
 	t.Parallel()
 
@@ -491,3 +521,521 @@ func TestFormatConstants(t *testing.T) {
 		t.Errorf("got FormatYAML=%q, want %q", FormatYAML, "yaml")
 	}
 }
+
+func TestFormatPrometheusMetrics_GroupsByName(t *testing.T) {
+	t.Parallel()
+
+	got := FormatPrometheusMetrics(&PrometheusMetrics{Metrics: []PrometheusMetric{
+		{Name: "a", Help: "A", Type: promTypeGauge, Labels: map[string]string{"device": "x", "component": "c"}, Value: 1},
+		{Name: "b", Help: "B", Type: promTypeGauge, Value: 2},
+		{Name: "a", Help: "A", Type: promTypeGauge, Labels: map[string]string{"device": "y"}, Value: 3},
+	}})
+
+	want := `# HELP a A
+# TYPE a gauge
+a{component="c",device="x"} 1
+a{device="y"} 3
+# HELP b B
+# TYPE b gauge
+b 2
+`
+	if got != want {
+		t.Errorf("FormatPrometheusMetrics() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExtractComponentPrometheusMetrics(t *testing.T) {
+	t.Parallel()
+
+	status := map[string]any{
+		"cover:0":          map[string]any{"id": 0.0, "state": "opening", "current_pos": 40.0},
+		"rgbw:0":           map[string]any{"output": true, "brightness": 75.0},
+		"thermostat:0":     map[string]any{"enable": true, "target_C": 21.5, "current_C": 19.0, "output": false},
+		"humidity:0":       map[string]any{"rh": 55.0},
+		"flood:0":          map[string]any{"alarm": true},
+		"devicepower:0":    map[string]any{"battery": map[string]any{"percent": 80.0}},
+		"input:1":          map[string]any{"percent": 12.0, "state": nil},
+		"bthomesensor:200": map[string]any{"value": 22.4},
+		"boolean:200":      map[string]any{"value": true},
+		"sys":              map[string]any{"uptime": 10.0},
+	}
+
+	metrics := ExtractComponentPrometheusMetrics("dev", status)
+	values := make(map[string]float64)
+	for _, m := range metrics {
+		key := m.Name + "/" + m.Labels[tagComponent] + ":" + m.Labels[tagComponentID] + "/" + m.Labels["state"]
+		values[key] = m.Value
+	}
+
+	tests := map[string]float64{
+		"shelly_cover_position_percent/cover:0/":         40,
+		"shelly_cover_state/cover:0/opening":             1,
+		"shelly_cover_state/cover:0/closed":              0,
+		"shelly_light_on/rgbw:0/":                        1,
+		"shelly_light_brightness_percent/rgbw:0/":        75,
+		"shelly_thermostat_target_celsius/thermostat:0/": 21.5,
+		"shelly_thermostat_output/thermostat:0/":         0,
+		"shelly_sensor_humidity_percent/humidity:0/":     55,
+		"shelly_flood_alarm/flood:0/":                    1,
+		"shelly_battery_percent/devicepower:0/":          80,
+		"shelly_input_percent/input:1/":                  12,
+		"shelly_bthome_sensor_value/bthomesensor:200/":   22.4,
+		"shelly_virtual_value/boolean:200/":              1,
+	}
+	for key, want := range tests {
+		got, ok := values[key]
+		if !ok {
+			t.Errorf("missing metric %s", key)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+	if _, ok := values["shelly_input_state/input:1/"]; ok {
+		t.Error("analog input should not report a null state")
+	}
+}
+
+func TestExtractFirmwarePrometheusMetrics(t *testing.T) {
+	t.Parallel()
+
+	labels := map[string]string{tagDevice: "dev"}
+	gen2 := ExtractFirmwarePrometheusMetrics(labels, map[string]any{
+		"sys": map[string]any{"available_updates": map[string]any{"beta": map[string]any{"version": "1.5.0-beta1"}}},
+	})
+	if len(gen2) != 2 || gen2[0].Value != 0 || gen2[1].Labels["channel"] != "beta" || gen2[1].Value != 1 {
+		t.Errorf("Gen2 firmware metrics = %+v, want stable=0 beta=1", gen2)
+	}
+
+	gen1 := ExtractFirmwarePrometheusMetrics(labels, map[string]any{"update": map[string]any{"has_update": true}})
+	if len(gen1) != 1 || gen1[0].Value != 1 {
+		t.Errorf("Gen1 firmware metrics = %+v, want stable=1", gen1)
+	}
+}
+
+func TestCircuitPrometheusMetrics(t *testing.T) {
+	t.Parallel()
+
+	metrics := CircuitPrometheusMetrics([]ratelimit.DeviceStats{{
+		Address:  testAddress,
+		InFlight: 1,
+		Circuit:  ratelimit.CircuitStats{State: ratelimit.StateOpen, FailCount: 3},
+	}})
+
+	var open, failures float64
+	for _, m := range metrics {
+		switch {
+		case m.Name == "shelly_exporter_circuit_state" && m.Labels["state"] == ratelimit.StateOpen.String():
+			open = m.Value
+		case m.Name == "shelly_exporter_circuit_failures":
+			failures = m.Value
+		}
+	}
+	if open != 1 || failures != 3 {
+		t.Errorf("open = %v, failures = %v, want 1 and 3", open, failures)
+	}
+}
+
+func testSample() DeviceSample {
+	energy := 1200.0
+	return DeviceSample{
+		Device: "plug",
+		Time:   time.Unix(1700000000, 0),
+		Online: true,
+		Status: map[string]any{
+			"wifi": map[string]any{"rssi": -60.0},
+			"sys":  map[string]any{"uptime": 3600.0},
+		},
+		Readings: []model.ComponentReading{{Device: "plug", Type: "pm1", ID: 0, Power: 42.5, Voltage: 230, Energy: &energy}},
+	}
+}
+
+func TestSampleInfluxDBPoints(t *testing.T) {
+	t.Parallel()
+
+	points := SampleInfluxDBPoints(testSample(), "home", map[string]string{"site": "a"})
+	if len(points) != 2 {
+		t.Fatalf("got %d points, want 2", len(points))
+	}
+	if points[0].Measurement != "home" || points[0].Fields["power"] != 42.5 || points[0].Tags["site"] != "a" {
+		t.Errorf("reading point = %+v", points[0])
+	}
+	dev := points[1]
+	if dev.Measurement != "home_device" || dev.Tags["site"] != "a" {
+		t.Errorf("device point = %+v", dev)
+	}
+	if dev.Fields["online"] != 1 || dev.Fields["wifi_rssi"] != -60 || dev.Fields["uptime_seconds"] != 3600 {
+		t.Errorf("device fields = %v", dev.Fields)
+	}
+}
+
+func TestSamplesInfluxDBReadingPoints(t *testing.T) {
+	t.Parallel()
+
+	points := SamplesInfluxDBReadingPoints([]DeviceSample{testSample()}, "home", map[string]string{"site": "a"})
+	if len(points) != 1 {
+		t.Fatalf("got %d points, want only the reading", len(points))
+	}
+	if points[0].Measurement != "home" || points[0].Fields["power"] != 42.5 || points[0].Tags["site"] != "a" {
+		t.Errorf("reading point = %+v", points[0])
+	}
+}
+
+func TestSamplesToOTLP(t *testing.T) {
+	t.Parallel()
+
+	req := SamplesToOTLP([]DeviceSample{testSample()}, map[string]string{"host.name": "pi"})
+	if len(req.ResourceMetrics) != 1 || len(req.ResourceMetrics[0].ScopeMetrics) != 1 {
+		t.Fatalf("unexpected request shape: %+v", req)
+	}
+	attrs := req.ResourceMetrics[0].Resource.Attributes
+	if len(attrs) != 2 || attrs[0].Key != "host.name" || attrs[1].Value.StringValue != "shelly-cli" {
+		t.Errorf("resource attributes = %+v", attrs)
+	}
+
+	byName := make(map[string]OTLPMetric)
+	for _, m := range req.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		byName[m.Name] = m
+	}
+	power := byName["shelly_power_watts"]
+	if power.Gauge == nil || len(power.Gauge.DataPoints) != 1 || power.Gauge.DataPoints[0].AsDouble != 42.5 {
+		t.Errorf("power metric = %+v", power)
+	}
+	if ts := power.Gauge.DataPoints[0].TimeUnixNano; ts != "1700000000000000000" {
+		t.Errorf("timeUnixNano = %q", ts)
+	}
+	energy := byName["shelly_energy_wh_total"]
+	if energy.Sum == nil || !energy.Sum.IsMonotonic || energy.Sum.AggregationTemporality != otlpTemporalityCumulative {
+		t.Errorf("energy metric = %+v", energy)
+	}
+}
+
+var testRetry = RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+func TestInfluxDBWriter_BatchesAndAuth(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/write" || r.URL.Query().Get("bucket") != "b" || r.URL.Query().Get("org") != "o" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if got := r.Header.Get("Authorization"); got != "Token secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		body, _ := io.ReadAll(r.Body) //nolint:errcheck // test server
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	w, err := NewInfluxDBWriter(InfluxDBWriterConfig{URL: srv.URL, Org: "o", Bucket: "b", Token: "secret", BatchSize: 2, Retry: testRetry})
+	if err != nil {
+		t.Fatalf("NewInfluxDBWriter: %v", err)
+	}
+	points := ReadingsToInfluxDBPoints([]model.ComponentReading{{Device: "a"}, {Device: "b"}, {Device: "c"}}, time.Unix(0, 0))
+	if err := w.WritePoints(context.Background(), points); err != nil {
+		t.Fatalf("WritePoints: %v", err)
+	}
+	if len(bodies) != 2 || strings.Count(bodies[0], "\n") != 2 || strings.Count(bodies[1], "\n") != 1 {
+		t.Errorf("batches = %q", bodies)
+	}
+}
+
+func TestInfluxDBWriter_RetriesServerErrors(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	w, err := NewInfluxDBWriter(InfluxDBWriterConfig{URL: srv.URL, Bucket: "b", Retry: testRetry})
+	if err != nil {
+		t.Fatalf("NewInfluxDBWriter: %v", err)
+	}
+	if err := w.WriteSamples(context.Background(), []DeviceSample{testSample()}); err != nil {
+		t.Fatalf("WriteSamples: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3", calls.Load())
+	}
+}
+
+func TestInfluxDBWriter_NoRetryOnClientError(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	w, err := NewInfluxDBWriter(InfluxDBWriterConfig{URL: srv.URL, Bucket: "b", Retry: testRetry})
+	if err != nil {
+		t.Fatalf("NewInfluxDBWriter: %v", err)
+	}
+	err = w.WriteSamples(context.Background(), []DeviceSample{testSample()})
+	var pushErr *PushError
+	if !errors.As(err, &pushErr) || pushErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want 401 PushError", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestNewInfluxDBWriter_Validation(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewInfluxDBWriter(InfluxDBWriterConfig{Bucket: "b"}); err == nil {
+		t.Error("expected error without url")
+	}
+	if _, err := NewInfluxDBWriter(InfluxDBWriterConfig{URL: "http://x"}); err == nil {
+		t.Error("expected error without bucket")
+	}
+}
+
+func TestOTLPWriter_WriteSamples(t *testing.T) {
+	t.Parallel()
+
+	var got OTLPMetricsRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Key") != "k" {
+			t.Errorf("unexpected request %s %v", r.URL, r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	w, err := NewOTLPWriter(OTLPWriterConfig{Endpoint: srv.URL + "/", Headers: map[string]string{"X-Key": "k"}, Retry: testRetry})
+	if err != nil {
+		t.Fatalf("NewOTLPWriter: %v", err)
+	}
+	if err := w.WriteSamples(context.Background(), []DeviceSample{testSample()}); err != nil {
+		t.Fatalf("WriteSamples: %v", err)
+	}
+	if len(got.ResourceMetrics) != 1 || len(got.ResourceMetrics[0].ScopeMetrics[0].Metrics) == 0 {
+		t.Errorf("received %+v", got)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	t.Parallel()
+
+	p := RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	tests := []struct {
+		attempt    int
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{1, 0, time.Second},
+		{2, 0, 2 * time.Second},
+		{4, 0, 5 * time.Second},
+		{1, 3 * time.Second, 3 * time.Second},
+		{1, time.Minute, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.delay(tt.attempt, tt.retryAfter); got != tt.want {
+			t.Errorf("delay(%d, %s) = %s, want %s", tt.attempt, tt.retryAfter, got, tt.want)
+		}
+	}
+}
+
+func TestBuildTerraformResources(t *testing.T) {
+	t.Parallel()
+
+	bkp := &backup.DeviceBackup{Backup: &shellybackup.Backup{
+		Config: json.RawMessage(`{
+			"switch:0": {"id": 0, "name": "Lamp", "auto_off": true, "auto_off_delay": 60.5, "in_mode": "follow"},
+			"sys": {"device": {"name": "kitchen"}}
+		}`),
+		Schedules: json.RawMessage(`{"jobs": [{"id": 3, "enable": true, "timespec": "0 0 7 * * MON-FRI",
+			"calls": [{"method": "Switch.Set", "params": {"id": 0, "on": true}}]}]}`),
+		Webhooks: json.RawMessage(`{"hooks": [{"id": 1, "cid": 0, "enable": true, "event": "switch.on", "name": "notify",
+			"urls": ["http://example.com/?v=${status}"]}]}`),
+		Scripts: []*shellybackup.Script{{ID: 1, Name: "auto", Enable: true, Code: "let x = 1;\nprint(`${x}`);"}},
+		KVS:     map[string]json.RawMessage{"my key": json.RawMessage(`{"etag": "x", "value": 42}`)},
+	}}
+	devices := []model.DeviceData{{Name: "Kitchen", Address: testAddress, Generation: 2, Online: true}}
+
+	resources := BuildTerraformResources(devices, map[string]*backup.DeviceBackup{"Kitchen": bkp}, "shelly_devices")
+
+	wantAddrs := []string{
+		"shelly_switch.kitchen_switch_0",
+		"shelly_schedule.kitchen_schedule_3",
+		"shelly_webhook.kitchen_webhook_1",
+		"shelly_script.kitchen_script_1",
+		"shelly_kvs.kitchen_kvs_my_key",
+	}
+	if len(resources) != len(wantAddrs) {
+		t.Fatalf("got %d resources, want %d", len(resources), len(wantAddrs))
+	}
+	for i, want := range wantAddrs {
+		if got := resources[i].Address(); got != want {
+			t.Errorf("resource[%d] = %q, want %q", i, got, want)
+		}
+	}
+	if resources[1].ImportID != testAddress+"/schedule:3" {
+		t.Errorf("schedule import ID = %q", resources[1].ImportID)
+	}
+
+	if _, err := BuildTerraformResourceConfig(devices, resources, TerraformResourceOptions{ResourceName: "shelly_devices"}); err == nil {
+		t.Error("BuildTerraformResourceConfig without a provider source should fail")
+	}
+	cfg, err := BuildTerraformResourceConfig(devices, resources, TerraformResourceOptions{
+		ResourceName: "shelly_devices", ProviderSource: "example/shelly",
+	})
+	if err != nil {
+		t.Fatalf("BuildTerraformResourceConfig: %v", err)
+	}
+	for _, want := range []string{
+		`source = "example/shelly"`,
+		`resource "shelly_switch" "kitchen_switch_0" {`,
+		`device         = local.shelly_devices["kitchen"].address`,
+		`auto_off_delay = 60.5`,
+		`timespec = "0 0 7 * * MON-FRI"`,
+		`method = "Switch.Set"`,
+		`"http://example.com/?v=$${status}",`,
+		"code   = <<EOT\nlet x = 1;\nprint(`$${x}`);\nEOT\n",
+		`value  = 42`,
+	} {
+		if !strings.Contains(cfg, want) {
+			t.Errorf("config missing %q:\n%s", want, cfg)
+		}
+	}
+	if strings.Contains(cfg, "null_resource") {
+		t.Error("resource config should not include the locals usage example")
+	}
+
+	imports := FormatTerraformImports(resources)
+	if !strings.Contains(imports, "terraform import shelly_kvs.kitchen_kvs_my_key '"+testAddress+"/kvs:my key'") {
+		t.Errorf("imports = %s", imports)
+	}
+}
+
+func TestBuildTerraformResources_KVSLabelsUnique(t *testing.T) {
+	t.Parallel()
+
+	bkp := &backup.DeviceBackup{Backup: &shellybackup.Backup{KVS: map[string]json.RawMessage{
+		"a-b":   json.RawMessage(`{"value": 1}`),
+		"a_b":   json.RawMessage(`{"value": 2}`),
+		"a_b_2": json.RawMessage(`{"value": 3}`),
+	}}}
+	devices := []model.DeviceData{{Name: "k", Address: testAddress, Generation: 2}}
+
+	resources := BuildTerraformResources(devices, map[string]*backup.DeviceBackup{"k": bkp}, "shelly_devices")
+	seen := make(map[string]bool)
+	for _, r := range resources {
+		if seen[r.Address()] {
+			t.Errorf("duplicate resource address %s", r.Address())
+		}
+		seen[r.Address()] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("got %d unique resources, want 3", len(seen))
+	}
+}
+
+func TestBuildTerraformResources_SkipsDevicesWithoutBackup(t *testing.T) {
+	t.Parallel()
+
+	devices := []model.DeviceData{{Name: "gen1", Address: testAddress, Generation: 1}}
+	if got := BuildTerraformResources(devices, nil, "shelly_devices"); len(got) != 0 {
+		t.Errorf("got %d resources, want 0", len(got))
+	}
+}
+
+func TestHCLString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", `"plain"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"a\nb", `"a\nb"`},
+		{"${var} %{if}", `"$${var} %%{if}"`},
+		{`back\slash`, `"back\\slash"`},
+	}
+	for _, tt := range tests {
+		if got := hclString(tt.in); got != tt.want {
+			t.Errorf("hclString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFirmwareVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"20240625-122245/1.4.2-gc2639da":   "1.4.2",
+		"20230913-112003/v1.14.0-gcb84623": "1.14.0",
+		"1.2.3-beta":                       "1.2.3",
+		"custom":                           "custom",
+	}
+	for in, want := range tests {
+		if got := FirmwareVersion(in); got != want {
+			t.Errorf("FirmwareVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBuildAnsibleDynamicInventory(t *testing.T) {
+	t.Parallel()
+
+	hosts := []AnsibleHostState{
+		{
+			DeviceData: model.DeviceData{Name: "plug", Address: testAddress, Model: "Shelly Plus Plug S", Generation: 2, Online: true},
+			Firmware:   "20240625-122245/1.3.3-gc2639da",
+			Status: map[string]any{
+				"sys":  map[string]any{"uptime": 100.0, "available_updates": map[string]any{"stable": map[string]any{"version": "1.4.2"}}},
+				"wifi": map[string]any{"rssi": -55.0, "sta_ip": testAddress, "ssid": "home"},
+			},
+		},
+		{DeviceData: model.DeviceData{Name: "old", Address: "192.168.1.102", Model: "Shelly 1", Generation: 1}},
+	}
+	groups := map[string]config.Group{"Living Room": {Devices: []string{"plug"}}}
+
+	inv := BuildAnsibleDynamicInventory(hosts, groups, "shelly")
+
+	wantGroups := map[string][]string{
+		"group_living_room":        {"plug"},
+		"model_shelly_plus_plug_s": {"plug"},
+		"gen2":                     {"plug"},
+		"gen1":                     {"old"},
+		"fw_1_3_3":                 {"plug"},
+		"offline":                  {"old"},
+	}
+	for name, want := range wantGroups {
+		g, ok := inv[name].(AnsibleDynamicGroup)
+		if !ok || strings.Join(g.Hosts, ",") != strings.Join(want, ",") {
+			t.Errorf("group %s = %+v, want hosts %v", name, inv[name], want)
+		}
+	}
+	top, ok := inv["shelly"].(AnsibleDynamicGroup)
+	if !ok || len(top.Hosts) != 2 || len(top.Children) != len(wantGroups)+1 { // + model_shelly_1
+		t.Errorf("top group = %+v", inv["shelly"])
+	}
+
+	vars := inv["_meta"].(map[string]any)["hostvars"].(map[string]any)
+	plug := vars["plug"].(map[string]any)
+	if plug["shelly_fw_version"] != "1.3.3" || plug["shelly_update_stable"] != "1.4.2" || plug["shelly_wifi_rssi"] != -55.0 {
+		t.Errorf("plug vars = %v", plug)
+	}
+	if _, ok := vars["old"].(map[string]any)["shelly_status"]; ok {
+		t.Error("offline host should have no status vars")
+	}
+}
@@ -2,7 +2,10 @@
 package export
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 	"time"
@@ -188,3 +191,84 @@ func CollectEMReadings(device string, emStatuses []*model.EMStatus) []model.Comp
 	}
 	return readings
 }
+
+// InfluxDBWriterConfig configures an InfluxDBWriter.
+type InfluxDBWriterConfig struct {
+	URL         string // server base URL, e.g. http://localhost:8086
+	Org         string
+	Bucket      string // database name on InfluxDB 3
+	Token       string
+	Measurement string            // default "shelly"
+	Tags        map[string]string // added to every point
+	BatchSize   int               // points per request; default DefaultPushBatchSize
+	Retry       RetryPolicy       // default DefaultRetryPolicy
+	Client      *http.Client
+}
+
+// InfluxDBWriter writes samples to the InfluxDB v2 write API
+// (/api/v2/write), which InfluxDB 3 also serves.
+type InfluxDBWriter struct {
+	cfg    InfluxDBWriterConfig
+	client *http.Client
+}
+
+// NewInfluxDBWriter creates an InfluxDB writer. URL and bucket are required.
+func NewInfluxDBWriter(cfg InfluxDBWriterConfig) (*InfluxDBWriter, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("influxdb url is required")
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("influxdb bucket is required")
+	}
+	if cfg.Measurement == "" {
+		cfg.Measurement = "shelly"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultPushBatchSize
+	}
+	if cfg.Retry == (RetryPolicy{}) {
+		cfg.Retry = DefaultRetryPolicy
+	}
+	return &InfluxDBWriter{cfg: cfg, client: pushClient(cfg.Client)}, nil
+}
+
+// Name returns "influxdb".
+func (w *InfluxDBWriter) Name() string {
+	return "influxdb"
+}
+
+// WriteSamples converts the samples to points and writes them.
+func (w *InfluxDBWriter) WriteSamples(ctx context.Context, samples []DeviceSample) error {
+	return w.WritePoints(ctx, SamplesInfluxDBPoints(samples, w.cfg.Measurement, w.cfg.Tags))
+}
+
+// WritePoints writes points in batches of at most BatchSize, stopping at
+// the first batch that still fails after retries.
+func (w *InfluxDBWriter) WritePoints(ctx context.Context, points []InfluxDBPoint) error {
+	query := url.Values{}
+	query.Set("bucket", w.cfg.Bucket)
+	if w.cfg.Org != "" {
+		query.Set("org", w.cfg.Org)
+	}
+	query.Set("precision", "ns")
+	endpoint := strings.TrimRight(w.cfg.URL, "/") + "/api/v2/write?" + query.Encode()
+
+	headers := map[string]string{}
+	if w.cfg.Token != "" {
+		headers["Authorization"] = "Token " + w.cfg.Token
+	}
+
+	for start := 0; start < len(points); start += w.cfg.BatchSize {
+		end := min(start+w.cfg.BatchSize, len(points))
+		req := pushRequest{
+			url:         endpoint,
+			contentType: "text/plain; charset=utf-8",
+			headers:     headers,
+			body:        []byte(FormatInfluxDBLineProtocol(points[start:end])),
+		}
+		if err := postWithRetry(ctx, w.client, w.cfg.Retry, req); err != nil {
+			return fmt.Errorf("write points %d-%d of %d: %w", start+1, end, len(points), err)
+		}
+	}
+	return nil
+}
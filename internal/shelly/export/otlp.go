@@ -0,0 +1,198 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tj-smith47/shelly-cli/internal/version"
+)
+
+// OTLP aggregation temporality for cumulative sums.
+const otlpTemporalityCumulative = 2
+
+// OTLPMetricsRequest is an OTLP ExportMetricsServiceRequest in the
+// OTLP/HTTP JSON encoding.
+type OTLPMetricsRequest struct {
+	ResourceMetrics []OTLPResourceMetrics `json:"resourceMetrics"`
+}
+
+// OTLPResourceMetrics groups the metrics of one resource.
+type OTLPResourceMetrics struct {
+	Resource     OTLPResource       `json:"resource"`
+	ScopeMetrics []OTLPScopeMetrics `json:"scopeMetrics"`
+}
+
+// OTLPResource describes the entity producing the metrics.
+type OTLPResource struct {
+	Attributes []OTLPAttribute `json:"attributes"`
+}
+
+// OTLPScopeMetrics groups the metrics of one instrumentation scope.
+type OTLPScopeMetrics struct {
+	Scope   OTLPScope    `json:"scope"`
+	Metrics []OTLPMetric `json:"metrics"`
+}
+
+// OTLPScope is an instrumentation scope.
+type OTLPScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// OTLPMetric is a gauge or a cumulative monotonic sum.
+type OTLPMetric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Gauge       *OTLPGauge `json:"gauge,omitempty"`
+	Sum         *OTLPSum   `json:"sum,omitempty"`
+}
+
+// OTLPGauge holds gauge data points.
+type OTLPGauge struct {
+	DataPoints []OTLPDataPoint `json:"dataPoints"`
+}
+
+// OTLPSum holds sum data points.
+type OTLPSum struct {
+	DataPoints             []OTLPDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+// OTLPDataPoint is one numeric sample. Timestamps are nanoseconds since the
+// epoch, encoded as strings as the JSON mapping requires for 64-bit values.
+type OTLPDataPoint struct {
+	Attributes   []OTLPAttribute `json:"attributes,omitempty"`
+	TimeUnixNano string          `json:"timeUnixNano"`
+	AsDouble     float64         `json:"asDouble"`
+}
+
+// OTLPAttribute is a string key/value attribute.
+type OTLPAttribute struct {
+	Key   string             `json:"key"`
+	Value OTLPAttributeValue `json:"value"`
+}
+
+// OTLPAttributeValue holds a string attribute value.
+type OTLPAttributeValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// SamplesToOTLP converts device samples into an OTLP metrics request. The
+// metrics are those of the Prometheus exporter; labels become data point
+// attributes and counters become cumulative monotonic sums.
+func SamplesToOTLP(samples []DeviceSample, resource map[string]string) OTLPMetricsRequest {
+	var names []string
+	metrics := make(map[string]*OTLPMetric)
+	for _, s := range samples {
+		ts := strconv.FormatInt(s.Time.UnixNano(), 10)
+		for _, m := range SamplePrometheusMetrics(s) {
+			om, ok := metrics[m.Name]
+			if !ok {
+				om = &OTLPMetric{Name: m.Name, Description: m.Help}
+				if m.Type == promTypeCounter {
+					om.Sum = &OTLPSum{AggregationTemporality: otlpTemporalityCumulative, IsMonotonic: true}
+				} else {
+					om.Gauge = &OTLPGauge{}
+				}
+				metrics[m.Name] = om
+				names = append(names, m.Name)
+			}
+			dp := OTLPDataPoint{Attributes: otlpAttributes(m.Labels), TimeUnixNano: ts, AsDouble: m.Value}
+			if om.Sum != nil {
+				om.Sum.DataPoints = append(om.Sum.DataPoints, dp)
+			} else {
+				om.Gauge.DataPoints = append(om.Gauge.DataPoints, dp)
+			}
+		}
+	}
+
+	scope := OTLPScopeMetrics{Scope: OTLPScope{Name: "shelly-cli", Version: version.Version}}
+	for _, name := range names {
+		scope.Metrics = append(scope.Metrics, *metrics[name])
+	}
+
+	attrs := map[string]string{"service.name": "shelly-cli"}
+	for k, v := range resource {
+		attrs[k] = v
+	}
+	return OTLPMetricsRequest{ResourceMetrics: []OTLPResourceMetrics{{
+		Resource:     OTLPResource{Attributes: otlpAttributes(attrs)},
+		ScopeMetrics: []OTLPScopeMetrics{scope},
+	}}}
+}
+
+// otlpAttributes converts a label set to attributes sorted by key.
+func otlpAttributes(labels map[string]string) []OTLPAttribute {
+	if len(labels) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]OTLPAttribute, len(keys))
+	for i, k := range keys {
+		attrs[i] = OTLPAttribute{Key: k, Value: OTLPAttributeValue{StringValue: labels[k]}}
+	}
+	return attrs
+}
+
+// OTLPWriterConfig configures an OTLPWriter.
+type OTLPWriterConfig struct {
+	Endpoint string            // collector base URL, e.g. http://localhost:4318
+	Headers  map[string]string // sent with every request, e.g. authorization
+	Resource map[string]string // resource attributes besides service.name
+	Retry    RetryPolicy       // default DefaultRetryPolicy
+	Client   *http.Client
+}
+
+// OTLPWriter exports samples to an OTLP/HTTP metrics endpoint using the
+// JSON encoding.
+type OTLPWriter struct {
+	cfg    OTLPWriterConfig
+	url    string
+	client *http.Client
+}
+
+// NewOTLPWriter creates an OTLP writer. The endpoint is the collector base
+// URL; /v1/metrics is appended unless the endpoint already ends with it.
+func NewOTLPWriter(cfg OTLPWriterConfig) (*OTLPWriter, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("otlp endpoint is required")
+	}
+	if cfg.Retry == (RetryPolicy{}) {
+		cfg.Retry = DefaultRetryPolicy
+	}
+	url := strings.TrimRight(cfg.Endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/metrics") {
+		url += "/v1/metrics"
+	}
+	return &OTLPWriter{cfg: cfg, url: url, client: pushClient(cfg.Client)}, nil
+}
+
+// Name returns "otlp".
+func (w *OTLPWriter) Name() string {
+	return "otlp"
+}
+
+// WriteSamples exports the samples in one request.
+func (w *OTLPWriter) WriteSamples(ctx context.Context, samples []DeviceSample) error {
+	body, err := json.Marshal(SamplesToOTLP(samples, w.cfg.Resource))
+	if err != nil {
+		return fmt.Errorf("marshal otlp metrics: %w", err)
+	}
+	return postWithRetry(ctx, w.client, w.cfg.Retry, pushRequest{
+		url:         w.url,
+		contentType: "application/json",
+		headers:     w.cfg.Headers,
+		body:        body,
+	})
+}
@@ -3,10 +3,13 @@ package export
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/ratelimit"
 )
 
 // PrometheusMetrics represents metrics in Prometheus exposition format.
@@ -71,37 +74,46 @@ func ReadingsToPrometheusMetrics(readings []model.ComponentReading) []Prometheus
 }
 
 // FormatPrometheusMetrics formats metrics as Prometheus exposition format.
+// Samples are grouped by metric name in order of first appearance, as the
+// format requires when several devices report the same metric.
 func FormatPrometheusMetrics(metrics *PrometheusMetrics) string {
-	var result string
-	seen := make(map[string]bool)
-
+	var names []string
+	groups := make(map[string][]PrometheusMetric)
 	for _, m := range metrics.Metrics {
-		// Print HELP and TYPE only once per metric name
-		if !seen[m.Name] {
-			result += fmt.Sprintf("# HELP %s %s\n", m.Name, m.Help)
-			result += fmt.Sprintf("# TYPE %s %s\n", m.Name, m.Type)
-			seen[m.Name] = true
-		}
-
-		// Format labels
-		labels := ""
-		if len(m.Labels) > 0 {
-			first := true
-			labels = "{"
-			for k, v := range m.Labels {
-				if !first {
-					labels += ","
-				}
-				labels += fmt.Sprintf("%s=%q", k, v)
-				first = false
-			}
-			labels += "}"
+		if _, ok := groups[m.Name]; !ok {
+			names = append(names, m.Name)
 		}
+		groups[m.Name] = append(groups[m.Name], m)
+	}
 
-		result += fmt.Sprintf("%s%s %g\n", m.Name, labels, m.Value)
+	var b strings.Builder
+	for _, name := range names {
+		group := groups[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n", name, group[0].Help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, group[0].Type)
+		for _, m := range group {
+			fmt.Fprintf(&b, "%s%s %g\n", m.Name, formatPrometheusLabels(m.Labels), m.Value)
+		}
+	}
+	return b.String()
+}
+
+// formatPrometheusLabels formats a label set with keys in sorted order.
+func formatPrometheusLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 
-	return result
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%q", k, labels[k])
+	}
+	return "{" + strings.Join(parts, ",") + "}"
 }
 
 // ExtractWifiMetrics extracts WiFi RSSI metrics from device status.
@@ -211,9 +223,15 @@ func CollectSystemPrometheusMetrics(device string, status map[string]any) []Prom
 	// System metrics (uptime, ram, temp)
 	metrics = append(metrics, ExtractSysPrometheusMetrics(deviceLabels, status)...)
 
+	// Firmware update availability
+	metrics = append(metrics, ExtractFirmwarePrometheusMetrics(deviceLabels, status)...)
+
 	// Switch states
 	metrics = append(metrics, ExtractSwitchPrometheusMetrics(device, status)...)
 
+	// Covers, lights, thermostats, sensors, inputs, BTHome and virtual components
+	metrics = append(metrics, ExtractComponentPrometheusMetrics(device, status)...)
+
 	return metrics
 }
 
@@ -246,3 +264,219 @@ func CollectMeterPrometheusMetrics[T model.MeterReading](
 	}
 	return metrics
 }
+
+// ExtractFirmwarePrometheusMetrics reports whether a firmware update is
+// available, per channel: sys.available_updates on Gen2+, update.has_update
+// (stable only) on Gen1.
+func ExtractFirmwarePrometheusMetrics(labels map[string]string, status map[string]any) []PrometheusMetric {
+	available := func(channel string, value bool) PrometheusMetric {
+		return PrometheusMetric{
+			Name: "shelly_firmware_update_available", Help: "Firmware update available (1=yes, 0=no)",
+			Type: promTypeGauge, Labels: withLabel(labels, "channel", channel), Value: boolValue(value),
+		}
+	}
+
+	if sys, ok := status["sys"].(map[string]any); ok {
+		updates, _ := sys["available_updates"].(map[string]any)
+		_, stable := updates["stable"]
+		_, beta := updates["beta"]
+		return []PrometheusMetric{available("stable", stable), available("beta", beta)}
+	}
+	if update, ok := status["update"].(map[string]any); ok {
+		if hasUpdate, ok := update["has_update"].(bool); ok {
+			return []PrometheusMetric{available("stable", hasUpdate)}
+		}
+	}
+	return nil
+}
+
+// componentMetric maps one numeric or boolean field of a component status
+// to a metric.
+type componentMetric struct {
+	path string // dotted path into the component status
+	name string
+	help string
+	typ  string
+}
+
+// lightMetrics apply to every dimmable light component type.
+var lightMetrics = []componentMetric{
+	{"output", "shelly_light_on", "Light state (1=on, 0=off)", promTypeGauge},
+	{"brightness", "shelly_light_brightness_percent", "Light brightness in percent", promTypeGauge},
+	{"white", "shelly_light_white", "White channel level (0-255)", promTypeGauge},
+	{"ct", "shelly_light_color_temperature_kelvin", "Light color temperature in kelvin", promTypeGauge},
+}
+
+// componentPrometheusMetrics lists the exported fields per component type.
+var componentPrometheusMetrics = map[string][]componentMetric{
+	"cover": {
+		{"current_pos", "shelly_cover_position_percent", "Cover position in percent (100=open)", promTypeGauge},
+		{"apower", "shelly_cover_power_watts", "Cover motor power in watts", promTypeGauge},
+	},
+	"light": lightMetrics,
+	"rgb":   lightMetrics,
+	"rgbw":  lightMetrics,
+	"cct":   lightMetrics,
+	"thermostat": {
+		{"enable", "shelly_thermostat_enabled", "Thermostat enabled (1=yes, 0=no)", promTypeGauge},
+		{"target_C", "shelly_thermostat_target_celsius", "Thermostat target temperature in Celsius", promTypeGauge},
+		{"current_C", "shelly_thermostat_current_celsius", "Thermostat measured temperature in Celsius", promTypeGauge},
+		{"output", "shelly_thermostat_output", "Thermostat actuator active (1=heating/cooling, 0=idle)", promTypeGauge},
+	},
+	"temperature": {
+		{"tC", "shelly_sensor_temperature_celsius", "Temperature sensor reading in Celsius", promTypeGauge},
+	},
+	"humidity": {
+		{"rh", "shelly_sensor_humidity_percent", "Relative humidity in percent", promTypeGauge},
+	},
+	"illuminance": {
+		{"lux", "shelly_sensor_illuminance_lux", "Illuminance in lux", promTypeGauge},
+	},
+	"flood": {
+		{"alarm", "shelly_flood_alarm", "Flood detected (1=alarm, 0=dry)", promTypeGauge},
+	},
+	"smoke": {
+		{"alarm", "shelly_smoke_alarm", "Smoke detected (1=alarm, 0=clear)", promTypeGauge},
+	},
+	"devicepower": {
+		{"battery.percent", "shelly_battery_percent", "Battery charge in percent", promTypeGauge},
+		{"battery.V", "shelly_battery_volts", "Battery voltage in volts", promTypeGauge},
+		{"external.present", "shelly_external_power", "External power connected (1=yes, 0=no)", promTypeGauge},
+	},
+	"input": {
+		{"state", "shelly_input_state", "Digital input state (1=on, 0=off)", promTypeGauge},
+		{"percent", "shelly_input_percent", "Analog input level in percent", promTypeGauge},
+		{"counts.total", "shelly_input_count_total", "Pulses counted by the input", promTypeCounter},
+	},
+	"bthomedevice": {
+		{"rssi", "shelly_bthome_rssi", "BTHome device signal strength in dBm", promTypeGauge},
+		{"battery", "shelly_bthome_battery_percent", "BTHome device battery in percent", promTypeGauge},
+		{"last_updated_ts", "shelly_bthome_last_updated_timestamp_seconds", "Unix time of the last BTHome packet", promTypeGauge},
+	},
+	"bthomesensor": {
+		{"value", "shelly_bthome_sensor_value", "BTHome sensor reading", promTypeGauge},
+	},
+	"boolean": {
+		{"value", "shelly_virtual_value", "Virtual component value (booleans as 1/0)", promTypeGauge},
+	},
+	"number": {
+		{"value", "shelly_virtual_value", "Virtual component value (booleans as 1/0)", promTypeGauge},
+	},
+}
+
+// coverStates are the cover states reported as a state set.
+var coverStates = []string{"open", "closed", "opening", "closing", "stopped", "calibrating"}
+
+// ExtractComponentPrometheusMetrics extracts state metrics for covers,
+// lights, thermostats, environmental sensors, inputs, BTHome devices and
+// virtual components from a Gen2+ device status. Fields a component does
+// not report (e.g. state on an analog input) are skipped.
+func ExtractComponentPrometheusMetrics(device string, status map[string]any) []PrometheusMetric {
+	keys := make([]string, 0, len(status))
+	for key := range status {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var metrics []PrometheusMetric
+	for _, key := range keys {
+		compType, id, ok := strings.Cut(key, ":")
+		if !ok {
+			continue
+		}
+		comp, ok := status[key].(map[string]any)
+		if !ok {
+			continue
+		}
+		labels := map[string]string{tagDevice: device, tagComponent: compType, tagComponentID: id}
+
+		for _, cm := range componentPrometheusMetrics[compType] {
+			value, ok := numericField(comp, cm.path)
+			if !ok {
+				continue
+			}
+			metrics = append(metrics, PrometheusMetric{Name: cm.name, Help: cm.help, Type: cm.typ, Labels: labels, Value: value})
+		}
+
+		if compType == "cover" {
+			if state, ok := comp["state"].(string); ok {
+				for _, s := range coverStates {
+					metrics = append(metrics, PrometheusMetric{
+						Name: "shelly_cover_state", Help: "Cover state (1 for the current state)",
+						Type: promTypeGauge, Labels: withLabel(labels, "state", s), Value: boolValue(state == s),
+					})
+				}
+			}
+		}
+	}
+	return metrics
+}
+
+// CircuitPrometheusMetrics converts rate limiter statistics into circuit
+// breaker metrics, one state set and failure count per device address.
+func CircuitPrometheusMetrics(stats []ratelimit.DeviceStats) []PrometheusMetric {
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Address < stats[j].Address })
+
+	states := []ratelimit.State{ratelimit.StateClosed, ratelimit.StateOpen, ratelimit.StateHalfOpen}
+	metrics := make([]PrometheusMetric, 0, len(stats)*(len(states)+2))
+	for _, st := range stats {
+		labels := map[string]string{"address": st.Address}
+		for _, state := range states {
+			metrics = append(metrics, PrometheusMetric{
+				Name: "shelly_exporter_circuit_state", Help: "Circuit breaker state per device (1 for the current state)",
+				Type: promTypeGauge, Labels: withLabel(labels, "state", state.String()), Value: boolValue(st.Circuit.State == state),
+			})
+		}
+		metrics = append(metrics,
+			PrometheusMetric{
+				Name: "shelly_exporter_circuit_failures", Help: "Consecutive failures counted by the circuit breaker",
+				Type: promTypeGauge, Labels: labels, Value: float64(st.Circuit.FailCount),
+			},
+			PrometheusMetric{
+				Name: "shelly_exporter_requests_in_flight", Help: "Requests currently in flight per device",
+				Type: promTypeGauge, Labels: labels, Value: float64(st.InFlight),
+			},
+		)
+	}
+	return metrics
+}
+
+// numericField reads a number or boolean at a dotted path in a status map.
+func numericField(comp map[string]any, path string) (float64, bool) {
+	var value any = comp
+	for part := range strings.SplitSeq(path, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return 0, false
+		}
+		value = m[part]
+	}
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case bool:
+		return boolValue(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// withLabel returns a copy of labels with one more label set.
+func withLabel(labels map[string]string, key, value string) map[string]string {
+	out := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
+
+// boolValue converts a boolean to a 1/0 metric value.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
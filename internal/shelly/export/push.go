@@ -0,0 +1,166 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+)
+
+// Default push settings.
+const (
+	DefaultPushBatchSize  = 5000
+	DefaultPushMaxRetries = 3
+	defaultPushBackoff    = time.Second
+	defaultPushMaxBackoff = 30 * time.Second
+	defaultPushTimeout    = 30 * time.Second
+)
+
+// SampleWriter pushes collected device samples to a remote metrics backend.
+type SampleWriter interface {
+	// Name identifies the backend in log messages.
+	Name() string
+	// WriteSamples sends the samples, retrying transient failures.
+	WriteSamples(ctx context.Context, samples []DeviceSample) error
+}
+
+// RetryPolicy controls how push writers retry failed requests. Network
+// errors, 429 and 5xx responses are retried; other responses fail at once.
+type RetryPolicy struct {
+	MaxRetries int
+	Backoff    time.Duration // delay before the first retry, doubled on each one
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used by writers configured without a policy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: DefaultPushMaxRetries,
+	Backoff:    defaultPushBackoff,
+	MaxBackoff: defaultPushMaxBackoff,
+}
+
+// delay returns the wait before retry number attempt (starting at 1),
+// preferring the server's Retry-After when it sent one.
+func (p RetryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	d := p.Backoff << (attempt - 1)
+	if retryAfter > 0 {
+		d = retryAfter
+	}
+	if p.MaxBackoff > 0 && (d > p.MaxBackoff || d <= 0) {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// PushError is a non-2xx response from a push endpoint.
+type PushError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	Detail     string
+	retryAfter time.Duration
+}
+
+func (e *PushError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("%s returned %s", e.URL, e.Status)
+	}
+	return fmt.Sprintf("%s returned %s: %s", e.URL, e.Status, e.Detail)
+}
+
+// Temporary reports whether the request may succeed when retried.
+func (e *PushError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// pushRequest is one HTTP POST made by a push writer.
+type pushRequest struct {
+	url         string
+	contentType string
+	headers     map[string]string
+	body        []byte
+}
+
+// postWithRetry sends a push request, retrying transient failures with
+// exponential backoff until the policy is exhausted or ctx is done.
+func postWithRetry(ctx context.Context, client *http.Client, policy RetryPolicy, req pushRequest) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			var retryAfter time.Duration
+			var pushErr *PushError
+			if errors.As(err, &pushErr) {
+				retryAfter = pushErr.retryAfter
+			}
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(policy.delay(attempt, retryAfter)):
+			}
+		}
+
+		err = post(ctx, client, req)
+		if err == nil || attempt >= policy.MaxRetries || !retryable(err) {
+			return err
+		}
+		iostreams.DebugErr(fmt.Sprintf("push to %s (attempt %d)", req.url, attempt+1), err)
+	}
+}
+
+// retryable reports whether a failed push should be retried.
+func retryable(err error) bool {
+	var pushErr *PushError
+	if errors.As(err, &pushErr) {
+		return pushErr.Temporary()
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// post sends one push request and treats any non-2xx response as a PushError.
+func post(ctx context.Context, client *http.Client, req pushRequest) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.url, bytes.NewReader(req.body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", req.contentType)
+	for k, v := range req.headers {
+		if v != "" {
+			httpReq.Header.Set(k, v)
+		}
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			iostreams.DebugErr("closing push response body", cerr)
+		}
+	}()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512)) //nolint:errcheck // best-effort error detail
+	pushErr := &PushError{URL: req.url, StatusCode: resp.StatusCode, Status: resp.Status, Detail: strings.TrimSpace(string(detail))}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		pushErr.retryAfter = time.Duration(seconds) * time.Second
+	}
+	return pushErr
+}
+
+// pushClient returns client, or a client with the default push timeout.
+func pushClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return &http.Client{Timeout: defaultPushTimeout}
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/model"
+)
+
+// DeviceSample is one device's raw metrics from a single collection pass.
+// It is the common input of the Prometheus, JSON, InfluxDB and OTLP outputs.
+type DeviceSample struct {
+	Device   string
+	Time     time.Time
+	Online   bool
+	Status   map[string]any // full device status; nil when unreachable
+	Readings []model.ComponentReading
+}
+
+// SamplePrometheusMetrics converts a device sample into Prometheus metrics:
+// system and component state from the status, power metering from the
+// readings, and shelly_device_online.
+func SamplePrometheusMetrics(sample DeviceSample) []PrometheusMetric {
+	var metrics []PrometheusMetric
+	if sample.Status != nil {
+		metrics = append(metrics, CollectSystemPrometheusMetrics(sample.Device, sample.Status)...)
+	}
+	metrics = append(metrics, ReadingsToPrometheusMetrics(sample.Readings)...)
+	metrics = append(metrics, PrometheusMetric{
+		Name: "shelly_device_online", Help: "Device online status (1=online, 0=offline)",
+		Type: promTypeGauge, Labels: map[string]string{tagDevice: sample.Device}, Value: boolValue(sample.Online),
+	})
+	return metrics
+}
+
+// SamplesPrometheusMetrics converts several device samples into one
+// metric list.
+func SamplesPrometheusMetrics(samples []DeviceSample) []PrometheusMetric {
+	var metrics []PrometheusMetric
+	for _, s := range samples {
+		metrics = append(metrics, SamplePrometheusMetrics(s)...)
+	}
+	return metrics
+}
+
+// SamplesJSONMetrics converts device samples into the JSON metrics output.
+func SamplesJSONMetrics(samples []DeviceSample, timestamp time.Time) JSONMetricsOutput {
+	output := JSONMetricsOutput{Timestamp: timestamp, Devices: make([]JSONMetricsDevice, len(samples))}
+	for i, s := range samples {
+		output.Devices[i] = JSONMetricsDevice{Device: s.Device, Online: s.Online, Components: s.Readings}
+	}
+	return output
+}
+
+// SampleInfluxDBPoints converts a device sample into InfluxDB points: one
+// point per meter reading in the given measurement, and one
+// <measurement>_device point with online state and system metrics (wifi_rssi,
+// uptime_seconds, ram and temperature). Extra tags are added to every point.
+func SampleInfluxDBPoints(sample DeviceSample, measurement string, tags map[string]string) []InfluxDBPoint {
+	points := ReadingsToInfluxDBPoints(sample.Readings, sample.Time)
+	for i := range points {
+		points[i].Measurement = measurement
+	}
+
+	fields := map[string]float64{"online": boolValue(sample.Online)}
+	if sample.Status != nil {
+		labels := map[string]string{tagDevice: sample.Device}
+		system := append(ExtractWifiMetrics(labels, sample.Status), ExtractSysPrometheusMetrics(labels, sample.Status)...)
+		for _, m := range system {
+			fields[strings.TrimPrefix(m.Name, "shelly_")] = m.Value
+		}
+	}
+	points = append(points, InfluxDBPoint{
+		Measurement: measurement + "_device",
+		Tags:        map[string]string{tagDevice: sample.Device},
+		Fields:      fields,
+		Timestamp:   sample.Time,
+	})
+
+	addInfluxDBTags(points, tags)
+	return points
+}
+
+// SamplesInfluxDBPoints converts several device samples into InfluxDB points.
+func SamplesInfluxDBPoints(samples []DeviceSample, measurement string, tags map[string]string) []InfluxDBPoint {
+	var points []InfluxDBPoint
+	for _, s := range samples {
+		points = append(points, SampleInfluxDBPoints(s, measurement, tags)...)
+	}
+	return points
+}
+
+// SamplesInfluxDBReadingPoints converts device samples into one InfluxDB
+// point per meter reading in the given measurement, without the
+// <measurement>_device points.
+func SamplesInfluxDBReadingPoints(samples []DeviceSample, measurement string, tags map[string]string) []InfluxDBPoint {
+	var points []InfluxDBPoint
+	for _, s := range samples {
+		points = append(points, ReadingsToInfluxDBPoints(s.Readings, s.Time)...)
+	}
+	for i := range points {
+		points[i].Measurement = measurement
+	}
+	addInfluxDBTags(points, tags)
+	return points
+}
+
+func addInfluxDBTags(points []InfluxDBPoint, tags map[string]string) {
+	for i := range points {
+		for k, v := range tags {
+			points[i].Tags[k] = v
+		}
+	}
+}
@@ -2,10 +2,16 @@ package export
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
 	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/backup"
 )
 
 // TerraformDevice represents a device in Terraform format.
@@ -93,3 +99,386 @@ func NormalizeResourceName(name string) string {
 	result = strings.ReplaceAll(result, ".", "_")
 	return result
 }
+
+// terraformComponentTypes are the component types exported as resources,
+// mapped to their resource type.
+var terraformComponentTypes = map[string]string{
+	"switch": "shelly_switch",
+	"cover":  "shelly_cover",
+	"light":  "shelly_light",
+	"rgb":    "shelly_rgb",
+	"rgbw":   "shelly_rgbw",
+	"cct":    "shelly_cct",
+}
+
+// TerraformResource is one provider resource generated from a device's
+// live configuration.
+type TerraformResource struct {
+	Type     string // e.g. shelly_switch
+	Name     string // resource label
+	ImportID string // <address>/<object>, the ID accepted by terraform import
+	Attrs    []TerraformAttr
+}
+
+// Address returns the resource address, e.g. shelly_switch.kitchen_switch_0.
+func (r TerraformResource) Address() string {
+	return r.Type + "." + r.Name
+}
+
+// TerraformAttr is a resource attribute. Values are rendered as HCL:
+// strings, numbers, bools, lists, maps and TerraformExpr.
+type TerraformAttr struct {
+	Key   string
+	Value any
+}
+
+// TerraformExpr is an HCL expression rendered verbatim, such as a reference
+// to a local value.
+type TerraformExpr string
+
+// TerraformHeredoc is a multi-line string rendered as a heredoc.
+type TerraformHeredoc string
+
+// TerraformResourceOptions configures BuildTerraformResourceConfig.
+type TerraformResourceOptions struct {
+	ResourceName   string // locals map name, referenced by each resource's device
+	ProviderSource string // required; the provider must implement the shelly_* resources
+}
+
+// BuildTerraformResources converts device backups into provider resources:
+// switch, cover and light component configs, schedules, webhooks, scripts
+// and KVS entries. Backups are keyed by device name; devices without one
+// (offline or Gen1) are left to the locals block.
+func BuildTerraformResources(devices []model.DeviceData, backups map[string]*backup.DeviceBackup, resourceName string) []TerraformResource {
+	var resources []TerraformResource
+	for _, d := range devices {
+		bkp, ok := backups[d.Name]
+		if !ok || bkp == nil || bkp.Backup == nil {
+			continue
+		}
+		label := NormalizeResourceName(d.Name)
+		device := TerraformAttr{Key: "device", Value: TerraformExpr(fmt.Sprintf("local.%s[%q].address", resourceName, label))}
+		res := terraformDeviceResources{label: label, address: d.Address, device: device}
+
+		resources = append(resources, res.components(bkp.Config)...)
+		resources = append(resources, res.schedules(bkp.Schedules)...)
+		resources = append(resources, res.webhooks(bkp.Webhooks)...)
+		resources = append(resources, res.scripts(bkp)...)
+		resources = append(resources, res.kvs(bkp.KVS)...)
+	}
+	return resources
+}
+
+// terraformDeviceResources builds the resources of one device.
+type terraformDeviceResources struct {
+	label   string
+	address string
+	device  TerraformAttr
+}
+
+func (t terraformDeviceResources) resource(typ, suffix, object string, attrs []TerraformAttr) TerraformResource {
+	return TerraformResource{
+		Type:     typ,
+		Name:     NormalizeResourceName(t.label + "_" + suffix),
+		ImportID: t.address + "/" + object,
+		Attrs:    append([]TerraformAttr{t.device}, attrs...),
+	}
+}
+
+// components exports component configs from Shelly.GetConfig, every field
+// except the ID, which is part of the import ID.
+func (t terraformDeviceResources) components(raw json.RawMessage) []TerraformResource {
+	var cfg map[string]map[string]any
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil
+	}
+	keys := make([]string, 0, len(cfg))
+	for key := range cfg {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var resources []TerraformResource
+	for _, key := range keys {
+		compType, id, ok := strings.Cut(key, ":")
+		typ, exported := terraformComponentTypes[compType]
+		if !ok || !exported {
+			continue
+		}
+		attrs := []TerraformAttr{{Key: "component_id", Value: json.Number(id)}}
+		attrs = append(attrs, mapAttrs(cfg[key], "id")...)
+		resources = append(resources, t.resource(typ, compType+"_"+id, key, attrs))
+	}
+	return resources
+}
+
+func (t terraformDeviceResources) schedules(raw json.RawMessage) []TerraformResource {
+	var data struct {
+		Jobs []map[string]any `json:"jobs"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &data) != nil {
+		return nil
+	}
+	resources := make([]TerraformResource, 0, len(data.Jobs))
+	for _, job := range data.Jobs {
+		id := fmt.Sprint(job["id"])
+		resources = append(resources, t.resource("shelly_schedule", "schedule_"+id, "schedule:"+id, mapAttrs(job, "id")))
+	}
+	return resources
+}
+
+func (t terraformDeviceResources) webhooks(raw json.RawMessage) []TerraformResource {
+	var data struct {
+		Hooks []map[string]any `json:"hooks"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &data) != nil {
+		return nil
+	}
+	resources := make([]TerraformResource, 0, len(data.Hooks))
+	for _, hook := range data.Hooks {
+		id := fmt.Sprint(hook["id"])
+		resources = append(resources, t.resource("shelly_webhook", "webhook_"+id, "webhook:"+id, mapAttrs(hook, "id")))
+	}
+	return resources
+}
+
+func (t terraformDeviceResources) scripts(bkp *backup.DeviceBackup) []TerraformResource {
+	resources := make([]TerraformResource, 0, len(bkp.Scripts))
+	for _, s := range bkp.Scripts {
+		id := strconv.Itoa(s.ID)
+		resources = append(resources, t.resource("shelly_script", "script_"+id, "script:"+id, []TerraformAttr{
+			{Key: "name", Value: s.Name},
+			{Key: "enable", Value: s.Enable},
+			{Key: "code", Value: TerraformHeredoc(s.Code)},
+		}))
+	}
+	return resources
+}
+
+func (t terraformDeviceResources) kvs(entries map[string]json.RawMessage) []TerraformResource {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	resources := make([]TerraformResource, 0, len(keys))
+	labels := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		var entry struct {
+			Value any `json:"value"`
+		}
+		if err := json.Unmarshal(entries[key], &entry); err != nil {
+			continue
+		}
+		// Keys such as "a-b" and "a_b" sanitize alike; number the repeats.
+		label := "kvs_" + sanitizeLabel(key)
+		for n := 2; labels[label]; n++ {
+			label = "kvs_" + sanitizeLabel(key) + "_" + strconv.Itoa(n)
+		}
+		labels[label] = true
+		resources = append(resources, t.resource("shelly_kvs", label, "kvs:"+key, []TerraformAttr{
+			{Key: "key", Value: key},
+			{Key: "value", Value: entry.Value},
+		}))
+	}
+	return resources
+}
+
+// mapAttrs converts a JSON object to attributes in key order, skipping
+// nulls and the given keys.
+func mapAttrs(m map[string]any, skip ...string) []TerraformAttr {
+	keys := make([]string, 0, len(m))
+	for k, v := range m {
+		if v != nil && !slices.Contains(skip, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	attrs := make([]TerraformAttr, len(keys))
+	for i, k := range keys {
+		attrs[i] = TerraformAttr{Key: k, Value: m[k]}
+	}
+	return attrs
+}
+
+// BuildTerraformResourceConfig renders a complete configuration: the
+// required provider, the device locals block and one resource per object.
+func BuildTerraformResourceConfig(devices []model.DeviceData, resources []TerraformResource, opts TerraformResourceOptions) (string, error) {
+	source := opts.ProviderSource
+	if source == "" {
+		return "", fmt.Errorf("a provider source is required")
+	}
+
+	locals, err := BuildTerraformConfig(devices, opts.ResourceName)
+	if err != nil {
+		return "", err
+	}
+	// Keep the generated header, drop the null_resource usage example.
+	if i := strings.Index(locals, "\n# Example usage:"); i >= 0 {
+		locals = locals[:i+1]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "terraform {\n  required_providers {\n    shelly = {\n      source = %s\n    }\n  }\n}\n\n", hclString(source))
+	b.WriteString("provider \"shelly\" {}\n\n")
+	b.WriteString(locals)
+	for _, r := range resources {
+		b.WriteString("\n")
+		b.WriteString(FormatTerraformResource(r))
+	}
+	return b.String(), nil
+}
+
+// FormatTerraformResource renders a resource block.
+func FormatTerraformResource(r TerraformResource) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "resource %q %q {\n", r.Type, r.Name)
+	writeHCLAttrs(&b, r.Attrs, "  ")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// FormatTerraformImports renders terraform import commands, one per
+// resource, as a shell script.
+func FormatTerraformImports(resources []TerraformResource) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n# Import existing Shelly objects into Terraform state\n# Generated by shelly-cli\nset -e\n\n")
+	for _, r := range resources {
+		fmt.Fprintf(&b, "terraform import %s %s\n", r.Address(), shellQuote(r.ImportID))
+	}
+	return b.String()
+}
+
+// writeHCLAttrs writes attributes with their equals signs aligned.
+func writeHCLAttrs(b *strings.Builder, attrs []TerraformAttr, indent string) {
+	width := 0
+	for _, a := range attrs {
+		width = max(width, len(hclKey(a.Key)))
+	}
+	for _, a := range attrs {
+		fmt.Fprintf(b, "%s%-*s = %s\n", indent, width, hclKey(a.Key), hclValue(a.Value, indent))
+	}
+}
+
+// hclValue renders a value as an HCL expression.
+func hclValue(v any, indent string) string {
+	switch val := v.(type) {
+	case TerraformExpr:
+		return string(val)
+	case TerraformHeredoc:
+		return hclHeredoc(string(val))
+	case string:
+		return hclString(val)
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(val)
+	case json.Number:
+		return val.String()
+	case []any:
+		if len(val) == 0 {
+			return "[]"
+		}
+		var b strings.Builder
+		b.WriteString("[\n")
+		for _, item := range val {
+			fmt.Fprintf(&b, "%s  %s,\n", indent, hclValue(item, indent+"  "))
+		}
+		b.WriteString(indent + "]")
+		return b.String()
+	case map[string]any:
+		if len(val) == 0 {
+			return "{}"
+		}
+		var b strings.Builder
+		b.WriteString("{\n")
+		writeHCLAttrs(&b, mapAttrs(val), indent+"  ")
+		b.WriteString(indent + "}")
+		return b.String()
+	case nil:
+		return "null"
+	}
+	return hclString(fmt.Sprint(v))
+}
+
+// hclKey returns an attribute or object key, quoted unless it is a valid
+// identifier.
+func hclKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for i, r := range key {
+		letter := r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+		if !letter && (i == 0 || r != '-' && (r < '0' || r > '9')) {
+			return hclString(key)
+		}
+	}
+	return key
+}
+
+// hclString quotes a string literal, escaping template sequences so the
+// value is taken literally.
+func hclString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return escapeHCLTemplate(b.String())
+}
+
+// hclHeredoc renders multi-line text as a heredoc with a delimiter that
+// does not occur in the text.
+func hclHeredoc(s string) string {
+	delim := "EOT"
+	for strings.Contains("\n"+s+"\n", "\n"+delim+"\n") {
+		delim += "_"
+	}
+	if !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	return "<<" + delim + "\n" + escapeHCLTemplate(s) + delim
+}
+
+// escapeHCLTemplate escapes ${ and %{ so they are not interpolated.
+func escapeHCLTemplate(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	return strings.ReplaceAll(s, "%{", "%%{")
+}
+
+// sanitizeLabel turns an arbitrary string into a resource label fragment.
+func sanitizeLabel(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// shellQuote single-quotes a shell argument.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
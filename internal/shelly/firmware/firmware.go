@@ -38,6 +38,12 @@ type Info struct {
 	Platform    string // "shelly", "tasmota", etc.
 }
 
+// HasBetaUpdate reports whether a beta image other than the running
+// firmware is available.
+func (i *Info) HasBetaUpdate() bool {
+	return i.Beta != "" && i.Beta != i.Current
+}
+
 // Status contains the current firmware status.
 type Status struct {
 	Status      string
@@ -521,6 +527,12 @@ func (s *Service) UpdateDevice(ctx context.Context, device model.Device, useBeta
 // CheckDevicesForUpdates checks multiple devices for firmware updates and returns those needing updates.
 // The staged parameter controls what percentage of devices with updates to return (for staged rollouts).
 func (s *Service) CheckDevicesForUpdates(ctx context.Context, ios *iostreams.IOStreams, devices []string, staged int) []DeviceUpdateStatus {
+	return s.CheckDevicesForStage(ctx, ios, devices, staged, false)
+}
+
+// CheckDevicesForStage is CheckDevicesForUpdates for a release stage: with
+// beta, devices are selected by beta availability instead of a stable update.
+func (s *Service) CheckDevicesForStage(ctx context.Context, ios *iostreams.IOStreams, devices []string, staged int, beta bool) []DeviceUpdateStatus {
 	ios.StartProgress("Checking devices for updates...")
 
 	var (
@@ -537,6 +549,9 @@ func (s *Service) CheckDevicesForUpdates(ctx context.Context, ios *iostreams.IOS
 		g.Go(func() error {
 			info, checkErr := s.Check(gctx, deviceName)
 			hasUpdate := checkErr == nil && info != nil && info.HasUpdate
+			if beta {
+				hasUpdate = checkErr == nil && info != nil && info.HasBetaUpdate()
+			}
 			mu.Lock()
 			statuses = append(statuses, DeviceUpdateStatus{
 				Name:      deviceName,
@@ -640,7 +655,7 @@ func BuildUpdateList(results []CheckResult, devices map[string]model.Device) []U
 			entry.Error = r.Err
 		} else if r.Info != nil {
 			entry.HasUpdate = r.Info.HasUpdate
-			entry.HasBeta = r.Info.Beta != "" && r.Info.Beta != r.Info.Current
+			entry.HasBeta = r.Info.HasBetaUpdate()
 		}
 		if entry.HasUpdate || entry.HasBeta {
 			entries = append(entries, entry)
@@ -723,6 +723,24 @@ func TestFilterDevicesByNameAndPlatform(t *testing.T) {
 	}
 }
 
+func TestInfo_HasBetaUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		info Info
+		want bool
+	}{
+		{Info{Current: "1.0.0", Beta: "1.1.0-beta1"}, true},
+		{Info{Current: "1.1.0-beta1", Beta: "1.1.0-beta1"}, false},
+		{Info{Current: "1.0.0", Available: "1.1.0", HasUpdate: true}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.info.HasBetaUpdate(); got != tt.want {
+			t.Errorf("HasBetaUpdate(%+v) = %v, want %v", tt.info, got, tt.want)
+		}
+	}
+}
+
 func TestFilterEntriesByStage(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,73 @@
+package shelly
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/tj-smith47/shelly-go/firmware"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/fwmirror"
+)
+
+// FirmwareMirrorSources asks each device which firmware image it would
+// update to at stage (firmware.StageStable or firmware.StageBeta). Devices
+// with nothing to update to are left out; devices that could not be
+// checked are returned in failed with their error.
+func (s *Service) FirmwareMirrorSources(ctx context.Context, devices []string, stage string) (sources []fwmirror.Source, failed map[string]error) {
+	var mu sync.Mutex
+	failed = make(map[string]error)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(config.GetGlobalMaxConcurrent())
+	for _, name := range devices {
+		g.Go(func() error {
+			src, ok, err := s.firmwareMirrorSource(gctx, name, stage)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				failed[name] = err
+			case ok:
+				sources = append(sources, src)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		iostreams.DebugErr("checking firmware mirror sources", err)
+	}
+	return sources, failed
+}
+
+func (s *Service) firmwareMirrorSource(ctx context.Context, name, stage string) (fwmirror.Source, bool, error) {
+	info, err := s.CheckFirmware(ctx, name)
+	if err != nil {
+		return fwmirror.Source{}, false, err
+	}
+	version := info.Available
+	if stage == firmware.StageBeta && info.Beta != "" {
+		version = info.Beta
+	}
+	if version == "" {
+		return fwmirror.Source{}, false, nil
+	}
+	if info.Generation == 1 {
+		return fwmirror.Source{}, false, fmt.Errorf("mirroring is only supported on Gen2+ devices")
+	}
+
+	url, err := s.GetFirmwareURL(ctx, name, stage)
+	if err != nil {
+		return fwmirror.Source{}, false, err
+	}
+	return fwmirror.Source{
+		Model:   info.DeviceModel,
+		Version: version,
+		Stage:   stage,
+		URL:     url,
+		Devices: []string{name},
+	}, true, nil
+}
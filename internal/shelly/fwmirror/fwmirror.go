@@ -0,0 +1,344 @@
+// Package fwmirror keeps a local mirror of firmware images for a device
+// fleet and serves it over HTTP, so devices on a LAN update from the mirror
+// instead of each downloading the same image from the internet.
+package fwmirror
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// IndexFile is the mirror index, at the root of the mirror directory.
+const IndexFile = "index.json"
+
+// Sync actions.
+const (
+	ActionDownloaded = "downloaded"
+	ActionVerified   = "verified"
+	ActionRepaired   = "repaired"
+)
+
+// Source is a firmware image needed by one or more devices.
+type Source struct {
+	Model   string
+	Version string
+	Stage   string
+	URL     string
+	Devices []string
+}
+
+// Image is a mirrored firmware image. File is relative to the mirror
+// directory, with forward slashes.
+type Image struct {
+	Model      string    `json:"model"`
+	Version    string    `json:"version"`
+	Stage      string    `json:"stage"`
+	URL        string    `json:"url"`
+	File       string    `json:"file"`
+	Size       int64     `json:"size"`
+	SHA256     string    `json:"sha256"`
+	Devices    []string  `json:"devices,omitempty"`
+	MirroredAt time.Time `json:"mirrored_at"`
+}
+
+// Index lists the images of a mirror.
+type Index struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Images    []Image   `json:"images"`
+}
+
+// Find returns the image for a model and version, or nil.
+func (idx *Index) Find(model, version string) *Image {
+	for i := range idx.Images {
+		if idx.Images[i].Model == model && idx.Images[i].Version == version {
+			return &idx.Images[i]
+		}
+	}
+	return nil
+}
+
+// Latest returns the most recently mirrored image of a stage for a model,
+// or nil.
+func (idx *Index) Latest(model, stage string) *Image {
+	var best *Image
+	for i := range idx.Images {
+		img := &idx.Images[i]
+		if img.Model != model || img.Stage != stage {
+			continue
+		}
+		if best == nil || img.MirroredAt.After(best.MirroredAt) {
+			best = img
+		}
+	}
+	return best
+}
+
+// Fetcher downloads url into w and returns the number of bytes written.
+type Fetcher func(ctx context.Context, url string, w io.Writer) (int64, error)
+
+// Result is the outcome of syncing one source.
+type Result struct {
+	Image  Image
+	Action string
+	Err    error
+}
+
+// LoadIndex reads the index of the mirror in dir. A directory without an
+// index is an empty mirror.
+func LoadIndex(dir string) (*Index, error) {
+	data, err := afero.ReadFile(config.Fs(), filepath.Join(dir, IndexFile))
+	if errors.Is(err, iofs.ErrNotExist) {
+		return &Index{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read mirror index: %w", err)
+	}
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("parse mirror index: %w", err)
+	}
+	return &idx, nil
+}
+
+// SaveIndex writes the index of the mirror in dir.
+func SaveIndex(dir string, idx *Index) error {
+	sort.Slice(idx.Images, func(i, j int) bool {
+		if idx.Images[i].Model != idx.Images[j].Model {
+			return idx.Images[i].Model < idx.Images[j].Model
+		}
+		return idx.Images[i].Version < idx.Images[j].Version
+	})
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode mirror index: %w", err)
+	}
+	if err := afero.WriteFile(config.Fs(), filepath.Join(dir, IndexFile), data, 0o644); err != nil {
+		return fmt.Errorf("write mirror index: %w", err)
+	}
+	return nil
+}
+
+// Merge combines sources for the same model and version, collecting their
+// devices, so each image is downloaded once.
+func Merge(sources []Source) []Source {
+	byKey := make(map[string]*Source)
+	var order []string
+	for _, s := range sources {
+		key := s.Model + "@" + s.Version
+		if existing, ok := byKey[key]; ok {
+			existing.Devices = append(existing.Devices, s.Devices...)
+			continue
+		}
+		c := s
+		c.Devices = slices.Clone(s.Devices)
+		byKey[key] = &c
+		order = append(order, key)
+	}
+	sort.Strings(order)
+	out := make([]Source, 0, len(order))
+	for _, key := range order {
+		s := byKey[key]
+		slices.Sort(s.Devices)
+		s.Devices = slices.Compact(s.Devices)
+		out = append(out, *s)
+	}
+	return out
+}
+
+// Sync makes sure every source is mirrored in dir and updates the index.
+// Images already in the index are kept when their checksum still matches
+// and downloaded again otherwise. A failed source is reported in its
+// Result and does not stop the others.
+func Sync(ctx context.Context, dir string, sources []Source, fetch Fetcher) ([]Result, error) {
+	fs := config.Fs()
+	if err := fs.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create mirror directory: %w", err)
+	}
+	idx, err := LoadIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(sources))
+	for _, src := range Merge(sources) {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		res := syncOne(ctx, dir, idx, src, fetch)
+		results = append(results, res)
+		if res.Err != nil {
+			continue
+		}
+		if existing := idx.Find(src.Model, src.Version); existing != nil {
+			*existing = res.Image
+		} else {
+			idx.Images = append(idx.Images, res.Image)
+		}
+		// Save after each image so an interrupted sync keeps its progress.
+		idx.UpdatedAt = time.Now()
+		if err := SaveIndex(dir, idx); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+func syncOne(ctx context.Context, dir string, idx *Index, src Source, fetch Fetcher) Result {
+	action := ActionDownloaded
+	if existing := idx.Find(src.Model, src.Version); existing != nil {
+		if Verify(dir, *existing) == nil {
+			img := *existing
+			img.Devices = src.Devices
+			return Result{Image: img, Action: ActionVerified}
+		}
+		action = ActionRepaired
+	}
+
+	img := Image{
+		Model:   src.Model,
+		Version: src.Version,
+		Stage:   src.Stage,
+		URL:     src.URL,
+		File:    imagePath(src),
+		Devices: src.Devices,
+	}
+	size, sum, err := download(ctx, filepath.Join(dir, filepath.FromSlash(img.File)), src.URL, fetch)
+	if err != nil {
+		return Result{Image: img, Err: err}
+	}
+	img.Size, img.SHA256, img.MirroredAt = size, sum, time.Now()
+	return Result{Image: img, Action: action}
+}
+
+// imagePath returns where a source is stored in the mirror:
+// <model>/<version>/<file name from the URL>.
+func imagePath(src Source) string {
+	name := "firmware.zip"
+	if u, err := url.Parse(src.URL); err == nil {
+		if base := path.Base(u.Path); base != "." && base != "/" {
+			name = base
+		}
+	}
+	return path.Join(safeSegment(src.Model), safeSegment(src.Version), safeSegment(name))
+}
+
+// safeSegment keeps a path segment inside its directory.
+func safeSegment(s string) string {
+	s = strings.NewReplacer("/", "_", "\\", "_").Replace(s)
+	if s == "" || s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
+
+// download fetches url into dest through a temporary file, so a failed
+// download never replaces a good image, and returns its size and SHA-256.
+func download(ctx context.Context, dest, url string, fetch Fetcher) (size int64, sum string, err error) {
+	fs := config.Fs()
+	if err := fs.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return 0, "", fmt.Errorf("create image directory: %w", err)
+	}
+	tmp := dest + ".part"
+	f, err := fs.Create(tmp)
+	if err != nil {
+		return 0, "", fmt.Errorf("create image: %w", err)
+	}
+
+	h := sha256.New()
+	size, err = fetch(ctx, url, io.MultiWriter(f, h))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = checkArchive(tmp)
+	}
+	if err != nil {
+		if rmErr := fs.Remove(tmp); rmErr != nil && !errors.Is(rmErr, iofs.ErrNotExist) {
+			err = errors.Join(err, rmErr)
+		}
+		return 0, "", fmt.Errorf("download %s: %w", url, err)
+	}
+	if err := fs.Rename(tmp, dest); err != nil {
+		return 0, "", fmt.Errorf("store image: %w", err)
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify checks that an image is present with its recorded size and
+// SHA-256, and that a .zip image is a readable archive.
+func Verify(dir string, img Image) error {
+	file := filepath.Join(dir, filepath.FromSlash(img.File))
+	data, err := afero.ReadFile(config.Fs(), file)
+	if err != nil {
+		return fmt.Errorf("read image: %w", err)
+	}
+	if int64(len(data)) != img.Size {
+		return fmt.Errorf("size %d, want %d", len(data), img.Size)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != img.SHA256 {
+		return fmt.Errorf("checksum mismatch: sha256 %s, want %s", got, img.SHA256)
+	}
+	return checkArchive(file)
+}
+
+// checkArchive rejects truncated or corrupt .zip images.
+func checkArchive(file string) error {
+	if !strings.EqualFold(filepath.Ext(strings.TrimSuffix(file, ".part")), ".zip") {
+		return nil
+	}
+	data, err := afero.ReadFile(config.Fs(), file)
+	if err != nil {
+		return err
+	}
+	if _, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err != nil {
+		return fmt.Errorf("invalid firmware archive: %w", err)
+	}
+	return nil
+}
+
+// Prune removes images whose model and version are not in keep, and
+// returns them. When a removal fails, the index keeps that image and every
+// image not yet visited.
+func Prune(dir string, idx *Index, keep []Source) ([]Image, error) {
+	wanted := make(map[string]bool, len(keep))
+	for _, s := range keep {
+		wanted[s.Model+"@"+s.Version] = true
+	}
+
+	var removed []Image
+	kept := make([]Image, 0, len(idx.Images))
+	for i, img := range idx.Images {
+		if wanted[img.Model+"@"+img.Version] {
+			kept = append(kept, img)
+			continue
+		}
+		err := config.Fs().Remove(filepath.Join(dir, filepath.FromSlash(img.File)))
+		if err != nil && !errors.Is(err, iofs.ErrNotExist) {
+			idx.Images = append(kept, idx.Images[i:]...)
+			return removed, fmt.Errorf("remove %s: %w", img.File, err)
+		}
+		removed = append(removed, img)
+	}
+	idx.Images = kept
+	return removed, nil
+}
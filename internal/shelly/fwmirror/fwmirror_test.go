@@ -0,0 +1,299 @@
+package fwmirror
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+const mirrorDir = "/mirror"
+
+func setupFs(t *testing.T) afero.Fs {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	config.SetFs(fs)
+	t.Cleanup(func() { config.SetFs(nil) })
+	return fs
+}
+
+func zipImage(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, content); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// fakeUpstream serves images by URL and counts downloads.
+type fakeUpstream struct {
+	images    map[string][]byte
+	downloads map[string]int
+}
+
+func (u *fakeUpstream) fetch(_ context.Context, url string, w io.Writer) (int64, error) {
+	data, ok := u.images[url]
+	if !ok {
+		return 0, errors.New("HTTP status 404")
+	}
+	u.downloads[url]++
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	got := Merge([]Source{
+		{Model: "SNSW-001P16EU", Version: "1.4.4", Devices: []string{"kitchen"}},
+		{Model: "SHSW-1", Version: "1.14.0", Devices: []string{"garage"}},
+		{Model: "SNSW-001P16EU", Version: "1.4.4", Devices: []string{"office", "kitchen"}},
+	})
+	want := []Source{
+		{Model: "SHSW-1", Version: "1.14.0", Devices: []string{"garage"}},
+		{Model: "SNSW-001P16EU", Version: "1.4.4", Devices: []string{"kitchen", "office"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %+v, want %+v", got, want)
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestSync(t *testing.T) {
+	fs := setupFs(t)
+	up := &fakeUpstream{
+		images: map[string][]byte{
+			"http://fw.example/plus1pm/1.4.4.zip": zipImage(t, "plus1pm"),
+			"http://fw.example/shsw1/1.14.0.zip":  zipImage(t, "shsw1"),
+		},
+		downloads: map[string]int{},
+	}
+	sources := []Source{
+		{Model: "SNSW-001P16EU", Version: "1.4.4", Stage: "stable", URL: "http://fw.example/plus1pm/1.4.4.zip", Devices: []string{"kitchen"}},
+		{Model: "SNSW-001P16EU", Version: "1.4.4", Stage: "stable", URL: "http://fw.example/plus1pm/1.4.4.zip", Devices: []string{"office"}},
+		{Model: "SHSW-1", Version: "1.14.0", Stage: "stable", URL: "http://fw.example/shsw1/1.14.0.zip", Devices: []string{"garage"}},
+		{Model: "S3SW-001X8EU", Version: "1.5.0", Stage: "stable", URL: "http://fw.example/missing.zip", Devices: []string{"hall"}},
+	}
+
+	results, err := Sync(context.Background(), mirrorDir, sources, up.fetch)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3 merged sources", len(results))
+	}
+	failed := 0
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+		case r.Action != ActionDownloaded:
+			t.Errorf("%s action = %q, want downloaded", r.Image.Model, r.Action)
+		}
+	}
+	if failed != 1 {
+		t.Errorf("failed = %d, want the missing image only", failed)
+	}
+	if up.downloads["http://fw.example/plus1pm/1.4.4.zip"] != 1 {
+		t.Error("shared image was not downloaded exactly once")
+	}
+
+	idx, err := LoadIndex(mirrorDir)
+	if err != nil {
+		t.Fatalf("LoadIndex() error = %v", err)
+	}
+	img := idx.Find("SNSW-001P16EU", "1.4.4")
+	if img == nil {
+		t.Fatal("image missing from index")
+	}
+	if img.File != "SNSW-001P16EU/1.4.4/1.4.4.zip" || len(img.SHA256) != 64 || !reflect.DeepEqual(img.Devices, []string{"kitchen", "office"}) {
+		t.Errorf("image = %+v", img)
+	}
+	if err := Verify(mirrorDir, *img); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	for _, f := range []string{"missing.zip", "missing.zip.part"} {
+		if ok, _ := afero.Exists(fs, filepath.Join(mirrorDir, "S3SW-001X8EU/1.5.0", f)); ok {
+			t.Errorf("failed download left %s behind", f)
+		}
+	}
+
+	// A second sync verifies instead of downloading; a corrupted image is repaired.
+	if err := afero.WriteFile(fs, filepath.Join(mirrorDir, "SHSW-1/1.14.0/1.14.0.zip"), []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	results, err = Sync(context.Background(), mirrorDir, sources[:3], up.fetch)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	actions := map[string]string{}
+	for _, r := range results {
+		actions[r.Image.Model] = r.Action
+	}
+	if actions["SNSW-001P16EU"] != ActionVerified || actions["SHSW-1"] != ActionRepaired {
+		t.Errorf("actions = %v", actions)
+	}
+	if up.downloads["http://fw.example/plus1pm/1.4.4.zip"] != 1 {
+		t.Error("verified image was downloaded again")
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestSync_RejectsCorruptArchive(t *testing.T) {
+	setupFs(t)
+	up := &fakeUpstream{
+		images:    map[string][]byte{"http://fw.example/bad.zip": []byte("not a zip")},
+		downloads: map[string]int{},
+	}
+
+	results, err := Sync(context.Background(), mirrorDir, []Source{{Model: "X", Version: "1", URL: "http://fw.example/bad.zip"}}, up.fetch)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "invalid firmware archive") {
+		t.Errorf("results = %+v, want archive error", results)
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestPrune(t *testing.T) {
+	fs := setupFs(t)
+	idx := &Index{Images: []Image{
+		{Model: "A", Version: "1", File: "A/1/a.zip"},
+		{Model: "A", Version: "2", File: "A/2/a.zip"},
+	}}
+	for _, img := range idx.Images {
+		if err := afero.WriteFile(fs, filepath.Join(mirrorDir, img.File), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := Prune(mirrorDir, idx, []Source{{Model: "A", Version: "2"}})
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(removed) != 1 || removed[0].Version != "1" || len(idx.Images) != 1 || idx.Images[0].Version != "2" {
+		t.Errorf("removed = %+v, kept = %+v", removed, idx.Images)
+	}
+	if ok, _ := afero.Exists(fs, filepath.Join(mirrorDir, "A/1/a.zip")); ok {
+		t.Error("pruned image still on disk")
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestPrune_RemoveError(t *testing.T) {
+	fs := setupFs(t)
+	idx := &Index{Images: []Image{
+		{Model: "A", Version: "1", File: "A/1/a.zip"},
+		{Model: "A", Version: "2", File: "A/2/a.zip"},
+		{Model: "A", Version: "3", File: "A/3/a.zip"},
+	}}
+	for _, img := range idx.Images {
+		if err := afero.WriteFile(fs, filepath.Join(mirrorDir, img.File), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config.SetFs(afero.NewReadOnlyFs(fs))
+
+	if _, err := Prune(mirrorDir, idx, []Source{{Model: "A", Version: "2"}}); err == nil {
+		t.Fatal("Prune() expected error on read-only mirror")
+	}
+	if len(idx.Images) != 3 {
+		t.Errorf("index after failed prune = %+v, want all 3 images", idx.Images)
+	}
+}
+
+func TestIndex_Latest(t *testing.T) {
+	t.Parallel()
+	idx := &Index{Images: []Image{
+		{Model: "A", Version: "1", Stage: "stable"},
+		{Model: "A", Version: "2", Stage: "stable"},
+		{Model: "A", Version: "3-beta", Stage: "beta"},
+	}}
+	idx.Images[1].MirroredAt = idx.Images[0].MirroredAt.Add(1)
+
+	if got := idx.Latest("A", "stable"); got == nil || got.Version != "2" {
+		t.Errorf("Latest(stable) = %+v, want version 2", got)
+	}
+	if got := idx.Latest("B", "stable"); got != nil {
+		t.Errorf("Latest(unknown model) = %+v, want nil", got)
+	}
+}
+
+func TestImagePath(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		src  Source
+		want string
+	}{
+		{Source{Model: "SHSW-1", Version: "1.14.0", URL: "http://x/y/SHSW-1.zip?token=1"}, "SHSW-1/1.14.0/SHSW-1.zip"},
+		{Source{Model: "../etc", Version: "..", URL: "http://x/"}, ".._etc/_/firmware.zip"},
+	}
+	for _, tt := range tests {
+		if got := imagePath(tt.src); got != tt.want {
+			t.Errorf("imagePath(%+v) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestHandler(t *testing.T) {
+	fs := setupFs(t)
+	if err := afero.WriteFile(fs, filepath.Join(mirrorDir, "A/1/a.zip"), []byte("image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(fs, filepath.Join(mirrorDir, "A/2/a.zip.part"), []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(Handler(mirrorDir))
+	defer srv.Close()
+
+	get := func(method, p string) (int, string) {
+		req, err := http.NewRequestWithContext(context.Background(), method, srv.URL+p, http.NoBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	if code, body := get(http.MethodGet, "/A/1/a.zip"); code != http.StatusOK || body != "image" {
+		t.Errorf("GET image = %d %q", code, body)
+	}
+	if code, _ := get(http.MethodGet, "/A/2/a.zip.part"); code != http.StatusNotFound {
+		t.Errorf("GET partial = %d, want 404", code)
+	}
+	if code, _ := get(http.MethodPost, "/A/1/a.zip"); code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want 405", code)
+	}
+	if got := ImageURL("http://10.0.0.2:8080/", Image{File: "A/1/a.zip"}); got != "http://10.0.0.2:8080/A/1/a.zip" {
+		t.Errorf("ImageURL() = %q", got)
+	}
+}
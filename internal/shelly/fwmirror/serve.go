@@ -0,0 +1,35 @@
+package fwmirror
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// Handler serves the mirror in dir read-only: the images under their index
+// paths and the index itself at /index.json. Partial downloads are hidden.
+func Handler(dir string) http.Handler {
+	files := http.FileServer(afero.NewHttpFs(config.Fs()).Dir(dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, ".part") {
+			http.NotFound(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// ImageURL returns the URL of an image on a mirror served at base, such as
+// "http://192.168.1.10:8080".
+func ImageURL(base string, img Image) string {
+	return strings.TrimSuffix(base, "/") + "/" + path.Clean(img.File)
+}
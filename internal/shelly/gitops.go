@@ -0,0 +1,298 @@
+package shelly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tj-smith47/shelly-cli/internal/client"
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/automation"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/gitops"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/kvs"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptdev"
+)
+
+// ResolveManifest loads a declarative manifest and returns the desired state
+// of each device, with groups expanded from the CLI config. When only is
+// set, devices not listed are left out.
+func ResolveManifest(path string, only []string) (map[string]gitops.Spec, error) {
+	m, err := gitops.LoadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := m.Resolve(func(name string) ([]string, error) {
+		group, ok := config.GetGroup(name)
+		if !ok {
+			return nil, fmt.Errorf("group not found")
+		}
+		return group.Devices, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(only) > 0 {
+		for device := range desired {
+			if !slices.Contains(only, device) {
+				delete(desired, device)
+			}
+		}
+		for _, device := range only {
+			if _, ok := desired[device]; !ok {
+				return nil, fmt.Errorf("device %q is not in the manifest", device)
+			}
+		}
+	}
+	return desired, nil
+}
+
+// GitOpsClient returns a client that reads and converges device state for
+// declarative manifests. Scripts, schedules and KVS go through the given
+// services so their caches are invalidated on change.
+func (s *Service) GitOpsClient(auto *automation.Service, kv *kvs.Service) gitops.Client {
+	return gitopsClient{s: s, auto: auto, kvs: kv}
+}
+
+type gitopsClient struct {
+	s    *Service
+	auto *automation.Service
+	kvs  *kvs.Service
+}
+
+func (g gitopsClient) GetConfig(ctx context.Context, device string) (map[string]any, error) {
+	if err := g.requireGen2(ctx, device); err != nil {
+		return nil, err
+	}
+	result := g.s.FetchDeviceConfig(ctx, device)
+	return result.Config, result.Err
+}
+
+func (g gitopsClient) SetConfig(ctx context.Context, device string, cfg map[string]any) error {
+	return g.s.PushDeviceConfig(ctx, device, cfg)
+}
+
+func (g gitopsClient) ListScripts(ctx context.Context, device string) ([]gitops.DeviceScript, error) {
+	if err := g.requireGen2(ctx, device); err != nil {
+		return nil, err
+	}
+	scripts, err := g.auto.ListScripts(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]gitops.DeviceScript, 0, len(scripts))
+	for _, sc := range scripts {
+		code, err := g.auto.GetScriptCode(ctx, device, sc.ID)
+		if err != nil {
+			return nil, fmt.Errorf("script %d: %w", sc.ID, err)
+		}
+		enable := sc.Enable
+		result = append(result, gitops.DeviceScript{
+			ID:     sc.ID,
+			Script: gitops.Script{Name: sc.Name, Code: code, Enable: &enable},
+		})
+	}
+	return result, nil
+}
+
+func (g gitopsClient) CreateScript(ctx context.Context, device string, sc gitops.Script) error {
+	id, err := g.auto.CreateScript(ctx, device, sc.Name)
+	if err != nil {
+		return err
+	}
+	return g.UpdateScript(ctx, device, id, sc)
+}
+
+func (g gitopsClient) UpdateScript(ctx context.Context, device string, id int, sc gitops.Script) error {
+	if _, err := g.auto.UploadScriptCode(ctx, device, id, sc.Code, scriptdev.DefaultChunkSize); err != nil {
+		return err
+	}
+	enable := sc.Enabled()
+	return g.auto.UpdateScriptConfig(ctx, device, id, nil, &enable)
+}
+
+func (g gitopsClient) DeleteScript(ctx context.Context, device string, id int) error {
+	return g.auto.DeleteScript(ctx, device, id)
+}
+
+func (g gitopsClient) ListSchedules(ctx context.Context, device string) ([]gitops.DeviceSchedule, error) {
+	if err := g.requireGen2(ctx, device); err != nil {
+		return nil, err
+	}
+	jobs, err := g.auto.ListSchedules(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]gitops.DeviceSchedule, 0, len(jobs))
+	for _, job := range jobs {
+		calls := make([]gitops.ScheduleCall, len(job.Calls))
+		for i, c := range job.Calls {
+			calls[i] = gitops.ScheduleCall{Method: c.Method, Params: c.Params}
+			if len(c.Params) == 0 {
+				calls[i].Params = nil
+			}
+		}
+		enable := job.Enable
+		result = append(result, gitops.DeviceSchedule{
+			ID:       job.ID,
+			Schedule: gitops.Schedule{Timespec: job.Timespec, Enable: &enable, Calls: calls},
+		})
+	}
+	return result, nil
+}
+
+func (g gitopsClient) CreateSchedule(ctx context.Context, device string, sch gitops.Schedule) error {
+	_, err := g.auto.CreateSchedule(ctx, device, sch.Enabled(), sch.Timespec, scheduleCalls(sch))
+	return err
+}
+
+func (g gitopsClient) UpdateSchedule(ctx context.Context, device string, id int, sch gitops.Schedule) error {
+	enable := sch.Enabled()
+	return g.auto.UpdateSchedule(ctx, device, id, &enable, &sch.Timespec, scheduleCalls(sch))
+}
+
+func (g gitopsClient) DeleteSchedule(ctx context.Context, device string, id int) error {
+	return g.auto.DeleteSchedule(ctx, device, id)
+}
+
+func scheduleCalls(sch gitops.Schedule) []automation.ScheduleCall {
+	calls := make([]automation.ScheduleCall, len(sch.Calls))
+	for i, c := range sch.Calls {
+		calls[i] = automation.ScheduleCall{Method: c.Method, Params: c.Params}
+	}
+	return calls
+}
+
+func (g gitopsClient) ListWebhooks(ctx context.Context, device string) ([]gitops.DeviceWebhook, error) {
+	if err := g.requireGen2(ctx, device); err != nil {
+		return nil, err
+	}
+	hooks, err := g.s.ListWebhooks(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]gitops.DeviceWebhook, 0, len(hooks))
+	for _, h := range hooks {
+		enable := h.Enable
+		result = append(result, gitops.DeviceWebhook{
+			ID:      h.ID,
+			Webhook: gitops.Webhook{Name: h.Name, Event: h.Event, URLs: h.URLs, Cid: h.Cid, Enable: &enable},
+		})
+	}
+	return result, nil
+}
+
+func (g gitopsClient) CreateWebhook(ctx context.Context, device string, w gitops.Webhook) error {
+	_, err := g.s.CreateWebhook(ctx, device, CreateWebhookParams{
+		Event: w.Event, URLs: w.URLs, Name: w.Name, Enable: w.Enabled(), Cid: w.Cid,
+	})
+	return err
+}
+
+// UpdateWebhook updates a webhook in place. Webhook.Update cannot move a
+// hook to another component, so a changed cid recreates it.
+func (g gitopsClient) UpdateWebhook(ctx context.Context, device string, id int, w gitops.Webhook) error {
+	hooks, err := g.s.ListWebhooks(ctx, device)
+	if err != nil {
+		return err
+	}
+	for _, h := range hooks {
+		if h.ID == id && h.Cid != w.Cid {
+			if err := g.s.DeleteWebhook(ctx, device, id); err != nil {
+				return err
+			}
+			return g.CreateWebhook(ctx, device, w)
+		}
+	}
+	enable := w.Enabled()
+	return g.s.UpdateWebhook(ctx, device, id, UpdateWebhookParams{
+		Event: w.Event, URLs: w.URLs, Name: w.Name, Enable: &enable,
+	})
+}
+
+func (g gitopsClient) DeleteWebhook(ctx context.Context, device string, id int) error {
+	return g.s.DeleteWebhook(ctx, device, id)
+}
+
+func (g gitopsClient) ListKVS(ctx context.Context, device string) (map[string]any, error) {
+	if err := g.requireGen2(ctx, device); err != nil {
+		return nil, err
+	}
+	items, err := g.kvs.GetAll(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]any, len(items))
+	for _, item := range items {
+		result[item.Key] = item.Value
+	}
+	return result, nil
+}
+
+func (g gitopsClient) SetKVS(ctx context.Context, device, key string, value any) error {
+	return g.kvs.Set(ctx, device, key, value)
+}
+
+func (g gitopsClient) DeleteKVS(ctx context.Context, device, key string) error {
+	return g.kvs.Delete(ctx, device, key)
+}
+
+func (g gitopsClient) ListVirtual(ctx context.Context, device string) ([]gitops.Virtual, error) {
+	if err := g.requireGen2(ctx, device); err != nil {
+		return nil, err
+	}
+	var result []gitops.Virtual
+	err := g.s.WithConnection(ctx, device, func(conn *client.Client) error {
+		comps, err := conn.GetComponentsAll(ctx, map[string]any{"dynamic_only": true, "include_config": true})
+		if err != nil {
+			return err
+		}
+		for _, comp := range comps {
+			if _, ok := parseVirtualComponent(comp); !ok {
+				continue
+			}
+			v := gitops.Virtual{Key: comp.Key}
+			if len(comp.Config) > 0 {
+				if err := json.Unmarshal(comp.Config, &v.Config); err != nil {
+					return fmt.Errorf("%s: %w", comp.Key, err)
+				}
+			}
+			result = append(result, v)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (g gitopsClient) AddVirtual(ctx context.Context, device string, v gitops.Virtual) error {
+	_, err := g.s.AddVirtualComponent(ctx, device, AddVirtualComponentParams{
+		Type:   VirtualComponentType(v.Type()),
+		ID:     v.ID(),
+		Config: v.Settings(),
+	})
+	return err
+}
+
+func (g gitopsClient) UpdateVirtual(ctx context.Context, device string, v gitops.Virtual) error {
+	method := strings.ToUpper(v.Type()[:1]) + v.Type()[1:] + ".SetConfig"
+	_, err := g.s.RawRPC(ctx, device, method, map[string]any{"id": v.ID(), "config": v.Settings()})
+	return err
+}
+
+func (g gitopsClient) DeleteVirtual(ctx context.Context, device, key string) error {
+	return g.s.DeleteVirtualComponent(ctx, device, key)
+}
+
+// requireGen2 rejects Gen1 devices, which have none of the managed RPC
+// components.
+func (g gitopsClient) requireGen2(ctx context.Context, device string) error {
+	isGen1, _, err := g.s.IsGen1Device(ctx, device)
+	if err != nil {
+		return err
+	}
+	if isGen1 {
+		return fmt.Errorf("declarative state requires a Gen2+ device")
+	}
+	return nil
+}
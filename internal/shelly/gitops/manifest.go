@@ -0,0 +1,390 @@
+// Package gitops converges devices to a declarative desired state.
+//
+// A manifest describes, per device or per group, the configuration, scripts,
+// schedules, webhooks, KVS entries and virtual components a device should
+// have:
+//
+//	devices:
+//	  kitchen:
+//	    config:
+//	      switch:0: {name: Kitchen, auto_off: true, auto_off_delay: 600}
+//	    scripts:
+//	      - name: night-light
+//	        file: scripts/night-light.js
+//	    schedules:
+//	      - timespec: "0 0 22 * * *"
+//	        calls:
+//	          - method: Switch.Set
+//	            params: {id: 0, on: false}
+//	    webhooks:
+//	      - name: door-open
+//	        event: input.toggle_on
+//	        urls: ["http://hub.local/door"]
+//	    kvs:
+//	      mode: eco
+//	    virtual:
+//	      - key: boolean:200
+//	        name: Away
+//	groups:
+//	  office:
+//	    kvs: {site: office}
+//
+// Config is partial: only the keys listed are managed. For the other
+// sections, only sections present in the manifest are managed, and items on
+// the device that the manifest does not list are deleted when pruning. An
+// empty list (scripts: []) manages a section with no items.
+//
+// Group specs apply to every group member; a device's own spec is layered on
+// top, overriding config keys and items with the same identity.
+package gitops
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// Manifest is a desired-state file or directory of files.
+type Manifest struct {
+	Devices map[string]Spec `yaml:"devices,omitempty" json:"devices,omitempty"`
+	Groups  map[string]Spec `yaml:"groups,omitempty" json:"groups,omitempty"`
+}
+
+// Spec is the desired state of a device. Nil sections are not managed.
+type Spec struct {
+	Config    map[string]any `yaml:"config,omitempty" json:"config,omitempty"`
+	Scripts   []Script       `yaml:"scripts,omitempty" json:"scripts,omitempty"`
+	Schedules []Schedule     `yaml:"schedules,omitempty" json:"schedules,omitempty"`
+	Webhooks  []Webhook      `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+	KVS       map[string]any `yaml:"kvs,omitempty" json:"kvs,omitempty"`
+	Virtual   []Virtual      `yaml:"virtual,omitempty" json:"virtual,omitempty"`
+}
+
+// Script is a device script, identified by name.
+type Script struct {
+	Name string `yaml:"name" json:"name"`
+	// File is read relative to the manifest file when the manifest loads.
+	File   string `yaml:"file,omitempty" json:"file,omitempty"`
+	Code   string `yaml:"code,omitempty" json:"code,omitempty"`
+	Enable *bool  `yaml:"enable,omitempty" json:"enable,omitempty"` // default true
+}
+
+// Schedule is a device schedule, identified by ID when set and otherwise by
+// its timespec and calls.
+type Schedule struct {
+	// ID is the schedule's job ID on the device. With it, a changed timespec
+	// or call updates the schedule instead of creating another one.
+	ID       int            `yaml:"id,omitempty" json:"id,omitempty"`
+	Timespec string         `yaml:"timespec" json:"timespec"`
+	Enable   *bool          `yaml:"enable,omitempty" json:"enable,omitempty"` // default true
+	Calls    []ScheduleCall `yaml:"calls" json:"calls"`
+}
+
+// ScheduleCall is an RPC call made by a schedule.
+type ScheduleCall struct {
+	Method string         `yaml:"method" json:"method"`
+	Params map[string]any `yaml:"params,omitempty" json:"params,omitempty"`
+}
+
+// Webhook is a device webhook, identified by name.
+type Webhook struct {
+	Name   string   `yaml:"name" json:"name"`
+	Event  string   `yaml:"event" json:"event"`
+	URLs   []string `yaml:"urls" json:"urls"`
+	Cid    int      `yaml:"cid,omitempty" json:"cid,omitempty"`
+	Enable *bool    `yaml:"enable,omitempty" json:"enable,omitempty"` // default true
+}
+
+// Virtual is a virtual component, identified by key (e.g. "boolean:200").
+type Virtual struct {
+	Key    string         `yaml:"key" json:"key"`
+	Name   string         `yaml:"name,omitempty" json:"name,omitempty"`
+	Config map[string]any `yaml:"config,omitempty" json:"config,omitempty"`
+}
+
+// Enabled reports whether the script should be enabled.
+func (s Script) Enabled() bool { return s.Enable == nil || *s.Enable }
+
+// Enabled reports whether the schedule should be enabled.
+func (s Schedule) Enabled() bool { return s.Enable == nil || *s.Enable }
+
+// Enabled reports whether the webhook should be enabled.
+func (w Webhook) Enabled() bool { return w.Enable == nil || *w.Enable }
+
+// Identity returns the key schedules are matched by.
+func (s Schedule) Identity() string {
+	if s.ID > 0 {
+		return fmt.Sprintf("id:%d", s.ID)
+	}
+	calls, err := json.Marshal(s.Calls)
+	if err != nil {
+		return s.Timespec
+	}
+	return s.Timespec + " " + string(calls)
+}
+
+// Type returns the component type of the key, e.g. "boolean".
+func (v Virtual) Type() string {
+	t, _, _ := strings.Cut(v.Key, ":")
+	return t
+}
+
+// ID returns the component id of the key, e.g. 200.
+func (v Virtual) ID() int {
+	_, id, _ := strings.Cut(v.Key, ":")
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// VirtualTypes are the virtual component types a manifest may declare.
+var VirtualTypes = []string{"boolean", "number", "text", "enum", "button", "group"}
+
+// LoadManifest reads a manifest file, or every .yaml/.yml file in a
+// directory. Script files are read relative to the file that names them.
+func LoadManifest(path string) (*Manifest, error) {
+	fs := config.Fs()
+	info, err := fs.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := afero.ReadDir(fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("read manifest directory: %w", err)
+		}
+		files = files[:0]
+		for _, e := range entries {
+			ext := filepath.Ext(e.Name())
+			if !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, filepath.Join(path, e.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no manifest files in %s", path)
+		}
+	}
+
+	m := &Manifest{Devices: map[string]Spec{}, Groups: map[string]Spec{}}
+	for _, file := range files {
+		part, err := loadManifestFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := mergeInto(m.Devices, part.Devices, "device", file); err != nil {
+			return nil, err
+		}
+		if err := mergeInto(m.Groups, part.Groups, "group", file); err != nil {
+			return nil, err
+		}
+	}
+	return m, m.Validate()
+}
+
+func loadManifestFile(file string) (*Manifest, error) {
+	data, err := afero.ReadFile(config.Fs(), file)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+
+	dir := filepath.Dir(file)
+	for _, specs := range []map[string]Spec{m.Devices, m.Groups} {
+		for name, spec := range specs {
+			for i, s := range spec.Scripts {
+				if s.File == "" {
+					continue
+				}
+				scriptPath := s.File
+				if !filepath.IsAbs(scriptPath) {
+					scriptPath = filepath.Join(dir, scriptPath)
+				}
+				code, err := afero.ReadFile(config.Fs(), scriptPath)
+				if err != nil {
+					return nil, fmt.Errorf("%s: script %q: %w", name, s.Name, err)
+				}
+				spec.Scripts[i].Code = string(code)
+			}
+		}
+	}
+	return &m, nil
+}
+
+func mergeInto(dst, src map[string]Spec, kind, file string) error {
+	for name, spec := range src {
+		if _, ok := dst[name]; ok {
+			return fmt.Errorf("%s: %s %q is defined in more than one manifest file", file, kind, name)
+		}
+		dst[name] = spec
+	}
+	return nil
+}
+
+// Validate checks every spec in the manifest.
+func (m *Manifest) Validate() error {
+	for _, name := range slices.Sorted(maps.Keys(m.Devices)) {
+		if err := m.Devices[name].Validate(); err != nil {
+			return fmt.Errorf("device %q: %w", name, err)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.Groups)) {
+		if err := m.Groups[name].Validate(); err != nil {
+			return fmt.Errorf("group %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that every item is complete and identities are unique.
+func (s Spec) Validate() error {
+	seen := map[string]bool{}
+	for _, sc := range s.Scripts {
+		switch {
+		case sc.Name == "":
+			return fmt.Errorf("script name is required")
+		case sc.File == "" && sc.Code == "":
+			return fmt.Errorf("script %q: file or code is required", sc.Name)
+		case seen["script "+sc.Name]:
+			return fmt.Errorf("script %q is listed twice", sc.Name)
+		}
+		seen["script "+sc.Name] = true
+	}
+	for _, sch := range s.Schedules {
+		switch {
+		case sch.Timespec == "" || len(sch.Calls) == 0:
+			return fmt.Errorf("schedule: timespec and at least one call are required")
+		case sch.ID < 0:
+			return fmt.Errorf("schedule %q: id must be positive", sch.Timespec)
+		case sch.ID > 0 && seen[sch.Identity()]:
+			return fmt.Errorf("schedule id %d is listed twice", sch.ID)
+		}
+		if sch.ID > 0 {
+			seen[sch.Identity()] = true
+		}
+		for _, c := range sch.Calls {
+			if c.Method == "" {
+				return fmt.Errorf("schedule %q: call method is required", sch.Timespec)
+			}
+		}
+	}
+	for _, w := range s.Webhooks {
+		switch {
+		case w.Name == "":
+			return fmt.Errorf("webhook name is required")
+		case w.Event == "" || len(w.URLs) == 0:
+			return fmt.Errorf("webhook %q: event and urls are required", w.Name)
+		case seen["webhook "+w.Name]:
+			return fmt.Errorf("webhook %q is listed twice", w.Name)
+		}
+		seen["webhook "+w.Name] = true
+	}
+	for key := range s.KVS {
+		if key == "" {
+			return fmt.Errorf("kvs key is required")
+		}
+	}
+	for _, v := range s.Virtual {
+		if !slices.Contains(VirtualTypes, v.Type()) || v.ID() < 200 || v.ID() > 299 {
+			return fmt.Errorf("virtual %q: key must be <type>:<200-299> with type one of %s", v.Key, strings.Join(VirtualTypes, ", "))
+		}
+		if seen["virtual "+v.Key] {
+			return fmt.Errorf("virtual %q is listed twice", v.Key)
+		}
+		seen["virtual "+v.Key] = true
+	}
+	return nil
+}
+
+// Resolve expands groups into the desired spec of every device. members
+// returns the devices in a group. Groups are applied in name order, then the
+// device's own spec.
+func (m *Manifest) Resolve(members func(group string) ([]string, error)) (map[string]Spec, error) {
+	desired := make(map[string]Spec)
+	for _, group := range slices.Sorted(maps.Keys(m.Groups)) {
+		devices, err := members(group)
+		if err != nil {
+			return nil, fmt.Errorf("group %q: %w", group, err)
+		}
+		for _, device := range devices {
+			desired[device] = Overlay(desired[device], m.Groups[group])
+		}
+	}
+	for device, spec := range m.Devices {
+		desired[device] = Overlay(desired[device], spec)
+	}
+	return desired, nil
+}
+
+// Overlay returns base with top layered over it. Config and KVS merge key by
+// key; list items replace items with the same identity.
+func Overlay(base, top Spec) Spec {
+	return Spec{
+		Config:    overlayConfig(base.Config, top.Config),
+		Scripts:   overlayList(base.Scripts, top.Scripts, func(s Script) string { return s.Name }),
+		Schedules: overlayList(base.Schedules, top.Schedules, Schedule.Identity),
+		Webhooks:  overlayList(base.Webhooks, top.Webhooks, func(w Webhook) string { return w.Name }),
+		KVS:       overlayMap(base.KVS, top.KVS),
+		Virtual:   overlayList(base.Virtual, top.Virtual, func(v Virtual) string { return v.Key }),
+	}
+}
+
+func overlayConfig(base, top map[string]any) map[string]any {
+	if base == nil || top == nil {
+		return overlayMap(base, top)
+	}
+	out := maps.Clone(base)
+	for key, v := range top {
+		b, bok := out[key].(map[string]any)
+		t, tok := v.(map[string]any)
+		if bok && tok {
+			out[key] = overlayConfig(b, t)
+			continue
+		}
+		out[key] = v
+	}
+	return out
+}
+
+func overlayMap(base, top map[string]any) map[string]any {
+	if base == nil {
+		return top
+	}
+	out := maps.Clone(base)
+	maps.Copy(out, top)
+	return out
+}
+
+func overlayList[T any](base, top []T, id func(T) string) []T {
+	if base == nil {
+		return top
+	}
+	if top == nil {
+		return base
+	}
+	out := slices.Clone(base)
+	for _, item := range top {
+		i := slices.IndexFunc(out, func(b T) bool { return id(b) == id(item) })
+		if i >= 0 {
+			out[i] = item
+		} else {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package gitops
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+const testManifest = `devices:
+  kitchen:
+    config:
+      switch:0: {name: Kitchen, auto_off: true}
+    scripts:
+      - name: night-light
+        file: scripts/night.js
+    kvs:
+      mode: eco
+groups:
+  office:
+    scripts: []
+    kvs:
+      site: office
+      mode: normal
+`
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestLoadManifest(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	config.SetFs(fs)
+	t.Cleanup(func() { config.SetFs(nil) })
+
+	if err := afero.WriteFile(fs, "/repo/shelly.yaml", []byte(testManifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(fs, "/repo/scripts/night.js", []byte("print(1);"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/repo/shelly.yaml", "/repo"} {
+		m, err := LoadManifest(path)
+		if err != nil {
+			t.Fatalf("LoadManifest(%s) error: %v", path, err)
+		}
+		kitchen := m.Devices["kitchen"]
+		if len(kitchen.Scripts) != 1 || kitchen.Scripts[0].Code != "print(1);" || !kitchen.Scripts[0].Enabled() {
+			t.Errorf("kitchen scripts = %+v", kitchen.Scripts)
+		}
+		if office := m.Groups["office"]; office.Scripts == nil || len(office.Scripts) != 0 {
+			t.Errorf("office scripts = %#v, want managed empty list", office.Scripts)
+		}
+	}
+}
+
+//nolint:paralleltest // Uses global config.SetFs which cannot be parallelized
+func TestLoadManifest_Duplicate(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	config.SetFs(fs)
+	t.Cleanup(func() { config.SetFs(nil) })
+
+	spec := []byte("devices:\n  kitchen:\n    kvs: {a: 1}\n")
+	for _, name := range []string{"/repo/a.yaml", "/repo/b.yml"} {
+		if err := afero.WriteFile(fs, name, spec, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := LoadManifest("/repo"); err == nil || !strings.Contains(err.Error(), "more than one") {
+		t.Errorf("err = %v, want duplicate device error", err)
+	}
+}
+
+func TestSpec_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		spec Spec
+		want string
+	}{
+		{"script without code", Spec{Scripts: []Script{{Name: "a"}}}, "file or code"},
+		{"duplicate script", Spec{Scripts: []Script{{Name: "a", Code: "x"}, {Name: "a", Code: "y"}}}, "twice"},
+		{"schedule without calls", Spec{Schedules: []Schedule{{Timespec: "@sunrise"}}}, "at least one call"},
+		{"duplicate schedule id", Spec{Schedules: []Schedule{
+			{ID: 1, Timespec: "@sunrise", Calls: []ScheduleCall{{Method: "Switch.On"}}},
+			{ID: 1, Timespec: "@sunset", Calls: []ScheduleCall{{Method: "Switch.Off"}}},
+		}}, "listed twice"},
+		{"webhook without urls", Spec{Webhooks: []Webhook{{Name: "w", Event: "input.toggle_on"}}}, "urls"},
+		{"virtual bad key", Spec{Virtual: []Virtual{{Key: "boolean:5"}}}, "200-299"},
+		{"virtual bad type", Spec{Virtual: []Virtual{{Key: "light:200"}}}, "type one of"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tt.spec.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	valid := Spec{
+		Scripts:   []Script{{Name: "a", Code: "x"}},
+		Schedules: []Schedule{{Timespec: "@sunset", Calls: []ScheduleCall{{Method: "Switch.Set"}}}},
+		Webhooks:  []Webhook{{Name: "w", Event: "input.toggle_on", URLs: []string{"http://x"}}},
+		Virtual:   []Virtual{{Key: "number:201"}},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
+
+func TestManifest_Resolve(t *testing.T) {
+	t.Parallel()
+
+	m := &Manifest{
+		Groups: map[string]Spec{
+			"office": {
+				Config: map[string]any{"sys": map[string]any{"location": map[string]any{"tz": "UTC"}, "debug": false}},
+				KVS:    map[string]any{"site": "office", "mode": "normal"},
+			},
+		},
+		Devices: map[string]Spec{
+			"desk": {
+				Config: map[string]any{"sys": map[string]any{"debug": true}},
+				KVS:    map[string]any{"mode": "eco"},
+			},
+		},
+	}
+	desired, err := m.Resolve(func(string) ([]string, error) { return []string{"desk", "printer"}, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desk := desired["desk"]
+	if desk.KVS["site"] != "office" || desk.KVS["mode"] != "eco" {
+		t.Errorf("desk kvs = %v", desk.KVS)
+	}
+	sys, _ := desk.Config["sys"].(map[string]any)
+	if sys["debug"] != true || sys["location"] == nil {
+		t.Errorf("desk sys config = %v", sys)
+	}
+	if printer := desired["printer"]; printer.KVS["mode"] != "normal" {
+		t.Errorf("printer kvs = %v", printer.KVS)
+	}
+	// Overlaying must not modify the group spec shared by other members.
+	if m.Groups["office"].KVS["mode"] != "normal" {
+		t.Error("group spec was modified")
+	}
+}
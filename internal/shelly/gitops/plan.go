@@ -0,0 +1,647 @@
+package gitops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/tj-smith47/shelly-cli/internal/utils"
+)
+
+// Client reads and changes device state. It is implemented by the shelly
+// service.
+type Client interface {
+	GetConfig(ctx context.Context, device string) (map[string]any, error)
+	SetConfig(ctx context.Context, device string, cfg map[string]any) error
+
+	ListScripts(ctx context.Context, device string) ([]DeviceScript, error)
+	CreateScript(ctx context.Context, device string, s Script) error
+	UpdateScript(ctx context.Context, device string, id int, s Script) error
+	DeleteScript(ctx context.Context, device string, id int) error
+
+	ListSchedules(ctx context.Context, device string) ([]DeviceSchedule, error)
+	CreateSchedule(ctx context.Context, device string, s Schedule) error
+	UpdateSchedule(ctx context.Context, device string, id int, s Schedule) error
+	DeleteSchedule(ctx context.Context, device string, id int) error
+
+	ListWebhooks(ctx context.Context, device string) ([]DeviceWebhook, error)
+	CreateWebhook(ctx context.Context, device string, w Webhook) error
+	UpdateWebhook(ctx context.Context, device string, id int, w Webhook) error
+	DeleteWebhook(ctx context.Context, device string, id int) error
+
+	ListKVS(ctx context.Context, device string) (map[string]any, error)
+	SetKVS(ctx context.Context, device, key string, value any) error
+	DeleteKVS(ctx context.Context, device, key string) error
+
+	ListVirtual(ctx context.Context, device string) ([]Virtual, error)
+	AddVirtual(ctx context.Context, device string, v Virtual) error
+	UpdateVirtual(ctx context.Context, device string, v Virtual) error
+	DeleteVirtual(ctx context.Context, device, key string) error
+}
+
+// DeviceScript is a script on a device.
+type DeviceScript struct {
+	ID int
+	Script
+}
+
+// DeviceSchedule is a schedule on a device.
+type DeviceSchedule struct {
+	ID int
+	Schedule
+}
+
+// DeviceWebhook is a webhook on a device.
+type DeviceWebhook struct {
+	ID int
+	Webhook
+}
+
+// Change actions.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Change kinds, in the order changes are applied.
+const (
+	KindConfig   = "config"
+	KindVirtual  = "virtual"
+	KindKVS      = "kvs"
+	KindScript   = "script"
+	KindSchedule = "schedule"
+	KindWebhook  = "webhook"
+)
+
+// Change is a single planned change to a device.
+type Change struct {
+	Device  string   `json:"device"`
+	Kind    string   `json:"kind"`
+	Action  string   `json:"action"`
+	Name    string   `json:"name"`
+	Details []string `json:"details,omitempty"`
+
+	apply func(ctx context.Context, c Client) error
+}
+
+// DevicePlan holds the changes planned for one device. Err is set when the
+// device's state could not be read. Warnings note device state the plan
+// leaves in place that the manifest may have meant to replace.
+type DevicePlan struct {
+	Device   string   `json:"device"`
+	Changes  []Change `json:"changes"`
+	Warnings []string `json:"warnings,omitempty"`
+	Err      error    `json:"-"`
+}
+
+// Plan is the set of changes that converges devices to their desired state.
+type Plan struct {
+	Devices []DevicePlan `json:"devices"`
+}
+
+// Counts returns the number of planned creates, updates and deletes.
+func (p *Plan) Counts() (create, update, del int) {
+	for _, d := range p.Devices {
+		for _, c := range d.Changes {
+			switch c.Action {
+			case ActionCreate:
+				create++
+			case ActionUpdate:
+				update++
+			case ActionDelete:
+				del++
+			}
+		}
+	}
+	return create, update, del
+}
+
+// HasChanges reports whether any device has pending changes.
+func (p *Plan) HasChanges() bool {
+	c, u, d := p.Counts()
+	return c+u+d > 0
+}
+
+// Failed returns the devices whose state could not be read.
+func (p *Plan) Failed() []DevicePlan {
+	var failed []DevicePlan
+	for _, d := range p.Devices {
+		if d.Err != nil {
+			failed = append(failed, d)
+		}
+	}
+	return failed
+}
+
+// Err reports the devices that could not be read, so that a partial plan or
+// apply fails.
+func (p *Plan) Err() error {
+	if failed := p.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%d device(s) could not be read", len(failed))
+	}
+	return nil
+}
+
+// BuildPlan reads each device's state and plans the changes that converge it
+// to the desired spec, reading up to concurrent devices at once. With prune,
+// items in managed sections that the spec does not list are deleted.
+func BuildPlan(ctx context.Context, c Client, desired map[string]Spec, prune bool, concurrent int) *Plan {
+	devices := slices.Sorted(maps.Keys(desired))
+	plan := &Plan{Devices: make([]DevicePlan, len(devices))}
+
+	sem := make(chan struct{}, max(concurrent, 1))
+	var wg sync.WaitGroup
+	for i, device := range devices {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			plan.Devices[i] = PlanDevice(ctx, c, device, desired[device], prune)
+		})
+	}
+	wg.Wait()
+	return plan
+}
+
+// PlanDevice plans the changes for a single device.
+func PlanDevice(ctx context.Context, c Client, device string, spec Spec, prune bool) DevicePlan {
+	dp := DevicePlan{Device: device}
+	planners := []func() ([]Change, error){
+		func() ([]Change, error) { return planConfig(ctx, c, device, spec.Config) },
+		func() ([]Change, error) { return planVirtual(ctx, c, device, spec.Virtual, prune) },
+		func() ([]Change, error) { return planKVS(ctx, c, device, spec.KVS, prune) },
+		func() ([]Change, error) { return planScripts(ctx, c, device, spec.Scripts, prune) },
+		func() ([]Change, error) {
+			changes, unmatched, err := planSchedules(ctx, c, device, spec.Schedules, prune)
+			if len(unmatched) > 0 && !prune {
+				dp.Warnings = append(dp.Warnings, fmt.Sprintf(
+					"%d schedule(s) on the device are not in the manifest and keep running (use --prune to delete them): %s",
+					len(unmatched), scheduleNames(unmatched)))
+			}
+			return changes, err
+		},
+		func() ([]Change, error) { return planWebhooks(ctx, c, device, spec.Webhooks, prune) },
+	}
+	for _, plan := range planners {
+		changes, err := plan()
+		if err != nil {
+			dp.Err = err
+			dp.Changes = nil
+			dp.Warnings = nil
+			return dp
+		}
+		dp.Changes = append(dp.Changes, changes...)
+	}
+	return dp
+}
+
+// ApplyResult is the outcome of applying one change.
+type ApplyResult struct {
+	Change Change
+	Err    error
+}
+
+// Apply makes the planned changes, device by device in plan order, calling
+// progress after each one. Devices with read errors are skipped. It returns
+// the number of changes applied and failed.
+func Apply(ctx context.Context, c Client, plan *Plan, progress func(ApplyResult)) (applied, failed int) {
+	for _, dp := range plan.Devices {
+		if dp.Err != nil {
+			continue
+		}
+		for _, change := range dp.Changes {
+			err := change.apply(ctx, c)
+			if err != nil {
+				failed++
+			} else {
+				applied++
+			}
+			if progress != nil {
+				progress(ApplyResult{Change: change, Err: err})
+			}
+		}
+	}
+	return applied, failed
+}
+
+func planConfig(ctx context.Context, c Client, device string, desired map[string]any) ([]Change, error) {
+	if len(desired) == 0 {
+		return nil, nil
+	}
+	current, err := c.GetConfig(ctx, device)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	var changes []Change
+	for _, comp := range slices.Sorted(maps.Keys(desired)) {
+		details := diffConfig("", desired[comp], current[comp])
+		if len(details) == 0 {
+			continue
+		}
+		update := map[string]any{comp: desired[comp]}
+		changes = append(changes, Change{
+			Device: device, Kind: KindConfig, Action: ActionUpdate, Name: comp, Details: details,
+			apply: func(ctx context.Context, c Client) error { return c.SetConfig(ctx, device, update) },
+		})
+	}
+	return changes, nil
+}
+
+// diffConfig lists the desired leaves that differ from the current config.
+func diffConfig(path string, desired, current any) []string {
+	if d, ok := desired.(map[string]any); ok && len(d) > 0 {
+		cur, _ := current.(map[string]any)
+		var details []string
+		for _, key := range slices.Sorted(maps.Keys(d)) {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			details = append(details, diffConfig(child, d[key], cur[key])...)
+		}
+		return details
+	}
+	if utils.DeepEqualJSON(desired, current) {
+		return nil
+	}
+	if path == "" {
+		path = "(value)"
+	}
+	return []string{fmt.Sprintf("%s: %s -> %s", path, FormatValue(current), FormatValue(desired))}
+}
+
+func planScripts(ctx context.Context, c Client, device string, desired []Script, prune bool) ([]Change, error) {
+	if desired == nil {
+		return nil, nil
+	}
+	current, err := c.ListScripts(ctx, device)
+	if err != nil {
+		return nil, fmt.Errorf("read scripts: %w", err)
+	}
+
+	var changes []Change
+	matched := make(map[int]bool)
+	for _, want := range desired {
+		i := slices.IndexFunc(current, func(s DeviceScript) bool { return s.Name == want.Name && !matched[s.ID] })
+		if i < 0 {
+			changes = append(changes, Change{
+				Device: device, Kind: KindScript, Action: ActionCreate, Name: want.Name,
+				Details: []string{fmt.Sprintf("%d bytes, enable: %t", len(want.Code), want.Enabled())},
+				apply:   func(ctx context.Context, c Client) error { return c.CreateScript(ctx, device, want) },
+			})
+			continue
+		}
+		have := current[i]
+		matched[have.ID] = true
+
+		var details []string
+		if have.Code != want.Code {
+			details = append(details, fmt.Sprintf("code: %d -> %d bytes", len(have.Code), len(want.Code)))
+		}
+		if have.Enabled() != want.Enabled() {
+			details = append(details, fmt.Sprintf("enable: %t -> %t", have.Enabled(), want.Enabled()))
+		}
+		if len(details) > 0 {
+			changes = append(changes, Change{
+				Device: device, Kind: KindScript, Action: ActionUpdate, Name: want.Name, Details: details,
+				apply: func(ctx context.Context, c Client) error { return c.UpdateScript(ctx, device, have.ID, want) },
+			})
+		}
+	}
+
+	if prune {
+		for _, have := range current {
+			if !matched[have.ID] {
+				changes = append(changes, Change{
+					Device: device, Kind: KindScript, Action: ActionDelete, Name: scriptName(have),
+					apply: func(ctx context.Context, c Client) error { return c.DeleteScript(ctx, device, have.ID) },
+				})
+			}
+		}
+	}
+	return changes, nil
+}
+
+func scriptName(s DeviceScript) string {
+	if s.Name == "" {
+		return fmt.Sprintf("script:%d", s.ID)
+	}
+	return s.Name
+}
+
+// planSchedules matches desired schedules to device schedules by ID, then by
+// timespec and calls, then by calls alone in order, so that a changed
+// timespec updates a schedule instead of adding another one. It also returns
+// the device schedules left unmatched.
+func planSchedules(ctx context.Context, c Client, device string, desired []Schedule, prune bool) ([]Change, []DeviceSchedule, error) {
+	if desired == nil {
+		return nil, nil, nil
+	}
+	current, err := c.ListSchedules(ctx, device)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read schedules: %w", err)
+	}
+
+	matched := make(map[int]bool)
+	match := make([]int, len(desired))
+	for j := range match {
+		match[j] = -1
+	}
+	pass := func(same func(have DeviceSchedule, want Schedule) bool) {
+		for j, want := range desired {
+			if match[j] >= 0 {
+				continue
+			}
+			i := slices.IndexFunc(current, func(s DeviceSchedule) bool { return !matched[s.ID] && same(s, want) })
+			if i >= 0 {
+				match[j] = i
+				matched[current[i].ID] = true
+			}
+		}
+	}
+	pass(func(have DeviceSchedule, want Schedule) bool { return want.ID > 0 && have.ID == want.ID })
+	pass(func(have DeviceSchedule, want Schedule) bool {
+		return have.Timespec == want.Timespec && utils.DeepEqualJSON(have.Calls, want.Calls)
+	})
+	pass(func(have DeviceSchedule, want Schedule) bool { return utils.DeepEqualJSON(have.Calls, want.Calls) })
+
+	var changes []Change
+	for j, want := range desired {
+		name := scheduleName(want)
+		if match[j] < 0 {
+			changes = append(changes, Change{
+				Device: device, Kind: KindSchedule, Action: ActionCreate, Name: name,
+				Details: []string{fmt.Sprintf("timespec: %s, enable: %t", want.Timespec, want.Enabled())},
+				apply:   func(ctx context.Context, c Client) error { return c.CreateSchedule(ctx, device, want) },
+			})
+			continue
+		}
+		have := current[match[j]]
+		if details := scheduleDetails(have.Schedule, want); len(details) > 0 {
+			changes = append(changes, Change{
+				Device: device, Kind: KindSchedule, Action: ActionUpdate, Name: name, Details: details,
+				apply: func(ctx context.Context, c Client) error { return c.UpdateSchedule(ctx, device, have.ID, want) },
+			})
+		}
+	}
+
+	var unmatched []DeviceSchedule
+	for _, have := range current {
+		if matched[have.ID] {
+			continue
+		}
+		unmatched = append(unmatched, have)
+		if prune {
+			changes = append(changes, Change{
+				Device: device, Kind: KindSchedule, Action: ActionDelete, Name: scheduleName(have.Schedule),
+				apply: func(ctx context.Context, c Client) error { return c.DeleteSchedule(ctx, device, have.ID) },
+			})
+		}
+	}
+	return changes, unmatched, nil
+}
+
+// scheduleDetails describes how a device schedule differs from the desired
+// one.
+func scheduleDetails(have, want Schedule) []string {
+	var details []string
+	if have.Timespec != want.Timespec {
+		details = append(details, fmt.Sprintf("timespec: %s -> %s", have.Timespec, want.Timespec))
+	}
+	for i := range max(len(have.Calls), len(want.Calls)) {
+		var from, to any
+		if i < len(have.Calls) {
+			from = callValue(have.Calls[i])
+		}
+		if i < len(want.Calls) {
+			to = callValue(want.Calls[i])
+		}
+		if utils.DeepEqualJSON(from, to) {
+			continue
+		}
+		path := fmt.Sprintf("call %d", i+1)
+		if diff := diffConfig(path, to, from); len(diff) > 0 {
+			details = append(details, diff...)
+		} else {
+			// Only keys were removed, which diffConfig does not list.
+			details = append(details, fmt.Sprintf("%s: %s -> %s", path, FormatValue(from), FormatValue(to)))
+		}
+	}
+	if have.Enabled() != want.Enabled() {
+		details = append(details, fmt.Sprintf("enable: %t -> %t", have.Enabled(), want.Enabled()))
+	}
+	return details
+}
+
+// callValue returns a schedule call as a generic value for comparison and
+// display.
+func callValue(c ScheduleCall) any {
+	v := map[string]any{"method": c.Method}
+	if len(c.Params) > 0 {
+		v["params"] = c.Params
+	}
+	return v
+}
+
+// scheduleName describes a schedule by its timespec and called methods.
+func scheduleName(s Schedule) string {
+	methods := make([]string, 0, len(s.Calls))
+	for _, c := range s.Calls {
+		methods = append(methods, c.Method)
+	}
+	return fmt.Sprintf("%s (%s)", s.Timespec, strings.Join(methods, ", "))
+}
+
+// scheduleNames lists device schedules with their IDs, which the manifest
+// can use to match them.
+func scheduleNames(schedules []DeviceSchedule) string {
+	names := make([]string, len(schedules))
+	for i, s := range schedules {
+		names[i] = fmt.Sprintf("id %d: %s", s.ID, scheduleName(s.Schedule))
+	}
+	return strings.Join(names, "; ")
+}
+
+func planWebhooks(ctx context.Context, c Client, device string, desired []Webhook, prune bool) ([]Change, error) {
+	if desired == nil {
+		return nil, nil
+	}
+	current, err := c.ListWebhooks(ctx, device)
+	if err != nil {
+		return nil, fmt.Errorf("read webhooks: %w", err)
+	}
+
+	var changes []Change
+	matched := make(map[int]bool)
+	for _, want := range desired {
+		i := slices.IndexFunc(current, func(w DeviceWebhook) bool { return w.Name == want.Name && !matched[w.ID] })
+		if i < 0 {
+			changes = append(changes, Change{
+				Device: device, Kind: KindWebhook, Action: ActionCreate, Name: want.Name,
+				Details: []string{fmt.Sprintf("event: %s, urls: %s", want.Event, strings.Join(want.URLs, ", "))},
+				apply:   func(ctx context.Context, c Client) error { return c.CreateWebhook(ctx, device, want) },
+			})
+			continue
+		}
+		have := current[i]
+		matched[have.ID] = true
+
+		var details []string
+		if have.Event != want.Event {
+			details = append(details, fmt.Sprintf("event: %s -> %s", have.Event, want.Event))
+		}
+		if !slices.Equal(have.URLs, want.URLs) {
+			details = append(details, fmt.Sprintf("urls: %s -> %s", strings.Join(have.URLs, ", "), strings.Join(want.URLs, ", ")))
+		}
+		if have.Cid != want.Cid {
+			details = append(details, fmt.Sprintf("cid: %d -> %d", have.Cid, want.Cid))
+		}
+		if have.Enabled() != want.Enabled() {
+			details = append(details, fmt.Sprintf("enable: %t -> %t", have.Enabled(), want.Enabled()))
+		}
+		if len(details) > 0 {
+			changes = append(changes, Change{
+				Device: device, Kind: KindWebhook, Action: ActionUpdate, Name: want.Name, Details: details,
+				apply: func(ctx context.Context, c Client) error { return c.UpdateWebhook(ctx, device, have.ID, want) },
+			})
+		}
+	}
+
+	if prune {
+		for _, have := range current {
+			if !matched[have.ID] {
+				name := have.Name
+				if name == "" {
+					name = fmt.Sprintf("%s (id %d)", have.Event, have.ID)
+				}
+				changes = append(changes, Change{
+					Device: device, Kind: KindWebhook, Action: ActionDelete, Name: name,
+					apply: func(ctx context.Context, c Client) error { return c.DeleteWebhook(ctx, device, have.ID) },
+				})
+			}
+		}
+	}
+	return changes, nil
+}
+
+func planKVS(ctx context.Context, c Client, device string, desired map[string]any, prune bool) ([]Change, error) {
+	if desired == nil {
+		return nil, nil
+	}
+	current, err := c.ListKVS(ctx, device)
+	if err != nil {
+		return nil, fmt.Errorf("read kvs: %w", err)
+	}
+
+	var changes []Change
+	for _, key := range slices.Sorted(maps.Keys(desired)) {
+		want := desired[key]
+		have, exists := current[key]
+		action := ActionCreate
+		details := []string{FormatValue(want)}
+		if exists {
+			if utils.DeepEqualJSON(have, want) {
+				continue
+			}
+			action = ActionUpdate
+			details = []string{fmt.Sprintf("%s -> %s", FormatValue(have), FormatValue(want))}
+		}
+		changes = append(changes, Change{
+			Device: device, Kind: KindKVS, Action: action, Name: key, Details: details,
+			apply: func(ctx context.Context, c Client) error { return c.SetKVS(ctx, device, key, want) },
+		})
+	}
+
+	if prune {
+		for _, key := range slices.Sorted(maps.Keys(current)) {
+			if _, ok := desired[key]; !ok {
+				changes = append(changes, Change{
+					Device: device, Kind: KindKVS, Action: ActionDelete, Name: key,
+					apply: func(ctx context.Context, c Client) error { return c.DeleteKVS(ctx, device, key) },
+				})
+			}
+		}
+	}
+	return changes, nil
+}
+
+func planVirtual(ctx context.Context, c Client, device string, desired []Virtual, prune bool) ([]Change, error) {
+	if desired == nil {
+		return nil, nil
+	}
+	current, err := c.ListVirtual(ctx, device)
+	if err != nil {
+		return nil, fmt.Errorf("read virtual components: %w", err)
+	}
+
+	var changes []Change
+	matched := make(map[string]bool)
+	for _, want := range desired {
+		i := slices.IndexFunc(current, func(v Virtual) bool { return v.Key == want.Key })
+		if i < 0 {
+			changes = append(changes, Change{
+				Device: device, Kind: KindVirtual, Action: ActionCreate, Name: want.Key,
+				Details: diffConfig("", want.Settings(), nil),
+				apply:   func(ctx context.Context, c Client) error { return c.AddVirtual(ctx, device, want) },
+			})
+			continue
+		}
+		matched[want.Key] = true
+		if details := diffConfig("", want.Settings(), current[i].Settings()); len(details) > 0 {
+			changes = append(changes, Change{
+				Device: device, Kind: KindVirtual, Action: ActionUpdate, Name: want.Key, Details: details,
+				apply: func(ctx context.Context, c Client) error { return c.UpdateVirtual(ctx, device, want) },
+			})
+		}
+	}
+
+	if prune {
+		for _, have := range current {
+			if !matched[have.Key] {
+				changes = append(changes, Change{
+					Device: device, Kind: KindVirtual, Action: ActionDelete, Name: have.Key,
+					apply: func(ctx context.Context, c Client) error { return c.DeleteVirtual(ctx, device, have.Key) },
+				})
+			}
+		}
+	}
+	return changes, nil
+}
+
+// Settings returns the component config with the name folded in.
+func (v Virtual) Settings() map[string]any {
+	cfg := maps.Clone(v.Config)
+	if cfg == nil {
+		cfg = make(map[string]any)
+	}
+	if v.Name != "" {
+		cfg["name"] = v.Name
+	}
+	return cfg
+}
+
+// FormatValue formats a value compactly for plan output.
+func FormatValue(v any) string {
+	if v == nil {
+		return "(unset)"
+	}
+	s := fmt.Sprintf("%v", v)
+	switch v.(type) {
+	case string:
+		s = fmt.Sprintf("%q", v)
+	case map[string]any, []any:
+		if data, err := json.Marshal(v); err == nil {
+			s = string(data)
+		}
+	}
+	const maxLen = 40
+	if len(s) > maxLen {
+		return s[:maxLen-3] + "..."
+	}
+	return s
+}
@@ -0,0 +1,342 @@
+package gitops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"testing"
+)
+
+// fakeClient is an in-memory device used to exercise planning and apply.
+type fakeClient struct {
+	config    map[string]any
+	scripts   []DeviceScript
+	schedules []DeviceSchedule
+	webhooks  []DeviceWebhook
+	kvs       map[string]any
+	virtual   []Virtual
+	nextID    int
+	calls     []string
+}
+
+func (f *fakeClient) id() int {
+	f.nextID++
+	return f.nextID
+}
+
+func (f *fakeClient) record(format string, args ...any) {
+	f.calls = append(f.calls, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeClient) GetConfig(context.Context, string) (map[string]any, error) {
+	return f.config, nil
+}
+
+func (f *fakeClient) SetConfig(_ context.Context, _ string, cfg map[string]any) error {
+	for comp, v := range cfg {
+		cur, _ := f.config[comp].(map[string]any)
+		cur = maps.Clone(cur)
+		if cur == nil {
+			cur = map[string]any{}
+		}
+		maps.Copy(cur, v.(map[string]any))
+		f.config[comp] = cur
+		f.record("config %s", comp)
+	}
+	return nil
+}
+
+func (f *fakeClient) ListScripts(context.Context, string) ([]DeviceScript, error) {
+	return f.scripts, nil
+}
+
+func (f *fakeClient) CreateScript(_ context.Context, _ string, s Script) error {
+	f.scripts = append(f.scripts, DeviceScript{ID: f.id(), Script: s})
+	f.record("create script %s", s.Name)
+	return nil
+}
+
+func (f *fakeClient) UpdateScript(_ context.Context, _ string, id int, s Script) error {
+	for i := range f.scripts {
+		if f.scripts[i].ID == id {
+			f.scripts[i].Script = s
+		}
+	}
+	f.record("update script %s", s.Name)
+	return nil
+}
+
+func (f *fakeClient) DeleteScript(_ context.Context, _ string, id int) error {
+	for i, s := range f.scripts {
+		if s.ID == id {
+			f.scripts = append(f.scripts[:i], f.scripts[i+1:]...)
+			f.record("delete script %s", s.Name)
+			return nil
+		}
+	}
+	return errors.New("no such script")
+}
+
+func (f *fakeClient) ListSchedules(context.Context, string) ([]DeviceSchedule, error) {
+	return f.schedules, nil
+}
+
+func (f *fakeClient) CreateSchedule(_ context.Context, _ string, s Schedule) error {
+	f.schedules = append(f.schedules, DeviceSchedule{ID: f.id(), Schedule: s})
+	f.record("create schedule %s", s.Timespec)
+	return nil
+}
+
+func (f *fakeClient) UpdateSchedule(_ context.Context, _ string, id int, s Schedule) error {
+	for i := range f.schedules {
+		if f.schedules[i].ID == id {
+			f.schedules[i].Schedule = s
+		}
+	}
+	f.record("update schedule %s", s.Timespec)
+	return nil
+}
+
+func (f *fakeClient) DeleteSchedule(_ context.Context, _ string, id int) error {
+	f.record("delete schedule %d", id)
+	return nil
+}
+
+func (f *fakeClient) ListWebhooks(context.Context, string) ([]DeviceWebhook, error) {
+	return f.webhooks, nil
+}
+
+func (f *fakeClient) CreateWebhook(_ context.Context, _ string, w Webhook) error {
+	f.webhooks = append(f.webhooks, DeviceWebhook{ID: f.id(), Webhook: w})
+	f.record("create webhook %s", w.Name)
+	return nil
+}
+
+func (f *fakeClient) UpdateWebhook(_ context.Context, _ string, _ int, w Webhook) error {
+	f.record("update webhook %s", w.Name)
+	return nil
+}
+
+func (f *fakeClient) DeleteWebhook(_ context.Context, _ string, id int) error {
+	f.record("delete webhook %d", id)
+	return nil
+}
+
+func (f *fakeClient) ListKVS(context.Context, string) (map[string]any, error) {
+	return f.kvs, nil
+}
+
+func (f *fakeClient) SetKVS(_ context.Context, _, key string, value any) error {
+	f.kvs[key] = value
+	f.record("set kvs %s", key)
+	return nil
+}
+
+func (f *fakeClient) DeleteKVS(_ context.Context, _, key string) error {
+	delete(f.kvs, key)
+	f.record("delete kvs %s", key)
+	return nil
+}
+
+func (f *fakeClient) ListVirtual(context.Context, string) ([]Virtual, error) {
+	return f.virtual, nil
+}
+
+func (f *fakeClient) AddVirtual(_ context.Context, _ string, v Virtual) error {
+	f.virtual = append(f.virtual, Virtual{Key: v.Key, Config: v.Settings()})
+	f.record("add virtual %s", v.Key)
+	return nil
+}
+
+func (f *fakeClient) UpdateVirtual(_ context.Context, _ string, v Virtual) error {
+	f.record("update virtual %s", v.Key)
+	return nil
+}
+
+func (f *fakeClient) DeleteVirtual(_ context.Context, _, key string) error {
+	f.record("delete virtual %s", key)
+	return nil
+}
+
+func newFakeClient() *fakeClient {
+	off := false
+	return &fakeClient{
+		nextID: 10,
+		config: map[string]any{
+			"switch:0": map[string]any{"id": 0.0, "name": "Old", "auto_off": false},
+			"sys":      map[string]any{"device": map[string]any{"name": "kitchen"}},
+		},
+		scripts: []DeviceScript{
+			{ID: 1, Script: Script{Name: "night-light", Code: "old();", Enable: &off}},
+			{ID: 2, Script: Script{Name: "stray", Code: "x();"}},
+		},
+		schedules: []DeviceSchedule{
+			{ID: 1, Schedule: Schedule{Timespec: "0 0 22 * * *", Calls: []ScheduleCall{{Method: "Switch.Set", Params: map[string]any{"id": 0.0, "on": false}}}}},
+		},
+		kvs:     map[string]any{"mode": "eco", "stale": 1.0},
+		virtual: []Virtual{{Key: "boolean:200", Config: map[string]any{"id": 200.0, "name": "Away"}}},
+	}
+}
+
+func testSpec() Spec {
+	return Spec{
+		Config: map[string]any{"switch:0": map[string]any{"name": "Kitchen", "auto_off": false}},
+		Scripts: []Script{
+			{Name: "night-light", Code: "new();"},
+			{Name: "motion", Code: "m();"},
+		},
+		Schedules: []Schedule{
+			{Timespec: "0 0 22 * * *", Calls: []ScheduleCall{{Method: "Switch.Set", Params: map[string]any{"id": 0, "on": false}}}},
+		},
+		Webhooks: []Webhook{{Name: "door", Event: "input.toggle_on", URLs: []string{"http://hub/door"}}},
+		KVS:      map[string]any{"mode": "eco", "site": "home"},
+		Virtual:  []Virtual{{Key: "boolean:200", Name: "Away"}},
+	}
+}
+
+func TestPlanDevice(t *testing.T) {
+	t.Parallel()
+
+	dp := PlanDevice(context.Background(), newFakeClient(), "kitchen", testSpec(), false)
+	if dp.Err != nil {
+		t.Fatal(dp.Err)
+	}
+
+	want := []string{
+		"config update switch:0",
+		"kvs create site",
+		"script update night-light",
+		"script create motion",
+		"webhook create door",
+	}
+	got := changeList(dp.Changes)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("changes = %v\nwant      %v", got, want)
+	}
+	if d := dp.Changes[0].Details; len(d) != 1 || d[0] != `name: "Old" -> "Kitchen"` {
+		t.Errorf("config details = %v", d)
+	}
+	if d := dp.Changes[2].Details; len(d) != 2 {
+		t.Errorf("script details = %v, want code and enable", d)
+	}
+}
+
+func TestPlanDevice_Prune(t *testing.T) {
+	t.Parallel()
+
+	spec := testSpec()
+	spec.Schedules = []Schedule{}
+	spec.Virtual = nil // unmanaged: never pruned
+
+	dp := PlanDevice(context.Background(), newFakeClient(), "kitchen", spec, true)
+	var deletes []string
+	for _, c := range dp.Changes {
+		if c.Action == ActionDelete {
+			deletes = append(deletes, c.Kind+" "+c.Name)
+		}
+	}
+	want := []string{"kvs stale", "script stray", "schedule 0 0 22 * * * (Switch.Set)"}
+	if fmt.Sprint(deletes) != fmt.Sprint(want) {
+		t.Errorf("deletes = %v, want %v", deletes, want)
+	}
+}
+
+func TestPlanDevice_Schedules(t *testing.T) {
+	t.Parallel()
+
+	off := func(id int, timespec string) Schedule {
+		return Schedule{ID: id, Timespec: timespec, Calls: []ScheduleCall{{Method: "Switch.Set", Params: map[string]any{"id": 0, "on": false}}}}
+	}
+	on := func(id int, timespec string) Schedule {
+		return Schedule{ID: id, Timespec: timespec, Calls: []ScheduleCall{{Method: "Switch.Set", Params: map[string]any{"id": 0, "on": true}}}}
+	}
+
+	tests := []struct {
+		name     string
+		want     Schedule
+		prune    bool
+		changes  []string
+		details  []string
+		warnings int
+	}{
+		{"unchanged", off(0, "0 0 22 * * *"), false, nil, nil, 0},
+		{"timespec changed", off(0, "0 30 22 * * *"), false,
+			[]string{"schedule update 0 30 22 * * * (Switch.Set)"}, []string{"timespec: 0 0 22 * * * -> 0 30 22 * * *"}, 0},
+		{"call changed without id", on(0, "0 0 22 * * *"), false,
+			[]string{"schedule create 0 0 22 * * * (Switch.Set)"}, nil, 1},
+		{"call changed without id, pruned", on(0, "0 0 22 * * *"), true,
+			[]string{"schedule create 0 0 22 * * * (Switch.Set)", "schedule delete 0 0 22 * * * (Switch.Set)"}, nil, 0},
+		{"call changed with id", on(1, "0 0 22 * * *"), false,
+			[]string{"schedule update 0 0 22 * * * (Switch.Set)"}, []string{"call 1.params.on: false -> true"}, 0},
+		{"unknown id matches by content", off(99, "0 0 22 * * *"), false, nil, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dp := PlanDevice(context.Background(), newFakeClient(), "kitchen", Spec{Schedules: []Schedule{tt.want}}, tt.prune)
+			if dp.Err != nil {
+				t.Fatal(dp.Err)
+			}
+			if got := changeList(dp.Changes); fmt.Sprint(got) != fmt.Sprint(tt.changes) {
+				t.Errorf("changes = %v, want %v", got, tt.changes)
+			}
+			if tt.details != nil && fmt.Sprint(dp.Changes[0].Details) != fmt.Sprint(tt.details) {
+				t.Errorf("details = %q, want %q", dp.Changes[0].Details, tt.details)
+			}
+			if len(dp.Warnings) != tt.warnings {
+				t.Errorf("warnings = %v, want %d", dp.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestApply_Converges(t *testing.T) {
+	t.Parallel()
+
+	c := newFakeClient()
+	desired := map[string]Spec{"kitchen": testSpec()}
+
+	plan := BuildPlan(context.Background(), c, desired, true, 2)
+	if !plan.HasChanges() {
+		t.Fatal("expected changes")
+	}
+	applied, failed := Apply(context.Background(), c, plan, nil)
+	if failed != 0 || applied == 0 {
+		t.Fatalf("Apply() = %d applied, %d failed", applied, failed)
+	}
+
+	again := BuildPlan(context.Background(), c, desired, true, 2)
+	if again.HasChanges() {
+		t.Errorf("second plan not empty: %v", changeList(again.Devices[0].Changes))
+	}
+}
+
+type failingClient struct{ *fakeClient }
+
+func (failingClient) ListKVS(context.Context, string) (map[string]any, error) {
+	return nil, errors.New("offline")
+}
+
+func TestBuildPlan_ReadError(t *testing.T) {
+	t.Parallel()
+
+	plan := BuildPlan(context.Background(), failingClient{newFakeClient()}, map[string]Spec{"kitchen": testSpec()}, false, 1)
+	failed := plan.Failed()
+	if len(failed) != 1 || len(failed[0].Changes) != 0 {
+		t.Fatalf("Failed() = %+v", failed)
+	}
+	if applied, _ := Apply(context.Background(), failingClient{newFakeClient()}, plan, nil); applied != 0 {
+		t.Errorf("applied %d changes to an unreadable device", applied)
+	}
+}
+
+func changeList(changes []Change) []string {
+	out := make([]string, 0, len(changes))
+	for _, c := range changes {
+		out = append(out, c.Kind+" "+c.Action+" "+c.Name)
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package shelly
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/hadiscovery"
+)
+
+// HADiscoveryDevice reads the info, full status and config Home Assistant
+// discovery is built from.
+func (s *Service) HADiscoveryDevice(ctx context.Context, device string) (*hadiscovery.Device, error) {
+	st, err := s.DeviceStatusAuto(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	if st.Info == nil {
+		return nil, fmt.Errorf("no device info for %s", device)
+	}
+	cfg, err := s.GetConfig(ctx, device)
+	if err != nil {
+		return nil, fmt.Errorf("get config: %w", err)
+	}
+
+	info := st.Info
+	return &hadiscovery.Device{
+		Name:       device,
+		ID:         info.ID,
+		MAC:        info.MAC,
+		Model:      info.Type,
+		ModelName:  info.Model,
+		Generation: info.Generation,
+		Firmware:   info.Firmware,
+		Address:    info.Address,
+		Status:     st.Status,
+		Config:     cfg,
+	}, nil
+}
+
+// CollectHADiscoveryDevices reads discovery data from each device
+// concurrently, in the order of deviceNames. Devices that fail are left
+// out and their errors returned by name.
+func (s *Service) CollectHADiscoveryDevices(ctx context.Context, deviceNames []string) ([]hadiscovery.Device, map[string]error) {
+	devices := make([]*hadiscovery.Device, len(deviceNames))
+	errs := make(map[string]error)
+	var mu sync.Mutex
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(config.GetGlobalMaxConcurrent())
+
+	for i, name := range deviceNames {
+		g.Go(func() error {
+			dev, err := s.HADiscoveryDevice(ctx, name)
+			if err != nil {
+				mu.Lock()
+				errs[name] = err
+				mu.Unlock()
+				return nil
+			}
+			devices[i] = dev
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		iostreams.DebugErr("collect ha discovery devices", err)
+	}
+
+	result := make([]hadiscovery.Device, 0, len(devices))
+	for _, dev := range devices {
+		if dev != nil {
+			result = append(result, *dev)
+		}
+	}
+	return result, errs
+}
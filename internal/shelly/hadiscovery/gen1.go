@@ -0,0 +1,179 @@
+package hadiscovery
+
+import (
+	"fmt"
+)
+
+// energyFromWattMinutes converts Gen1 energy counters (watt-minutes) to Wh.
+const energyFromWattMinutes = "{{ (value | float / 60) | round(2) }}"
+
+// gen1 adds entities for a Gen1 device from its /status and /settings,
+// using the shellies/<id>/... topics the device publishes.
+func (b *builder) gen1() {
+	st := b.dev.Status
+	cfg := b.dev.Config
+
+	// Battery devices sleep between updates, so "online" would mark them
+	// unavailable most of the time.
+	if _, ok := st["bat"]; !ok {
+		b.availability = b.prefix + "/online"
+	}
+
+	if stringValue(cfg, "mode") == "roller" {
+		for i := range sliceValue(st, "rollers") {
+			b.gen1Roller(i, gen1Name(cfg, "rollers", "Roller", i))
+		}
+	} else {
+		meters := sliceValue(st, "meters")
+		for i := range sliceValue(st, "relays") {
+			name := gen1Name(cfg, "relays", "Relay", i)
+			base := fmt.Sprintf("%s/relay/%d", b.prefix, i)
+			b.add("switch", fmt.Sprintf("relay_%d", i), map[string]any{
+				"name":          name,
+				"state_topic":   base,
+				"state_on":      "on",
+				"state_off":     "off",
+				"command_topic": base + "/command",
+				"payload_on":    "on",
+				"payload_off":   "off",
+			})
+			if i < len(meters) {
+				b.gen1Meter(fmt.Sprintf("relay_%d", i), name, base)
+			}
+		}
+	}
+
+	kind := "light"
+	switch stringValue(cfg, "mode") {
+	case "color":
+		kind = "color"
+	case "white":
+		kind = "white"
+	}
+	for i := range sliceValue(st, "lights") {
+		name := gen1Name(cfg, "lights", "Light", i)
+		b.gen1Light(kind, i, name)
+		if i < len(sliceValue(st, "meters")) && len(sliceValue(st, "relays")) == 0 {
+			b.gen1Meter(fmt.Sprintf("%s_%d", kind, i), name, fmt.Sprintf("%s/%s/%d", b.prefix, kind, i))
+		}
+	}
+
+	for i := range sliceValue(st, "inputs") {
+		b.add("binary_sensor", fmt.Sprintf("input_%d", i), map[string]any{
+			"name":        fmt.Sprintf("Input %d", i),
+			"state_topic": fmt.Sprintf("%s/input/%d", b.prefix, i),
+			"payload_on":  "1",
+			"payload_off": "0",
+		})
+	}
+
+	for i := range sliceValue(st, "emeters") {
+		key := fmt.Sprintf("emeter_%d", i)
+		name := fmt.Sprintf("EMeter %d", i)
+		base := fmt.Sprintf("%s/emeter/%d", b.prefix, i)
+		b.rawSensor(key, name, base+"/power", sensorPower, "")
+		b.rawSensor(key, name, base+"/voltage", sensorVoltage, "")
+		b.rawSensor(key, name, base+"/current", sensorCurrent, "")
+		b.rawSensor(key, name, base+"/total", sensorEnergy, "")
+	}
+
+	// Relays such as the 1PM also report "tmp", but publish their
+	// temperature as <prefix>/temperature. Only sensor devices (those with
+	// humidity, light or a battery, or without a device temperature) use
+	// the sensor/* topics.
+	_, hasDeviceTemp := st["temperature"]
+	sensorDevice := !hasDeviceTemp
+	for _, key := range []string{"hum", "lux", "bat"} {
+		if _, ok := st[key]; ok {
+			sensorDevice = true
+		}
+	}
+	for _, s := range []struct {
+		status string
+		topic  string
+		spec   sensorSpec
+	}{
+		{"tmp", "temperature", sensorTemperature},
+		{"hum", "humidity", sensorHumidity},
+		{"lux", "lux", sensorIlluminance},
+		{"bat", "battery", sensorBattery},
+	} {
+		if _, ok := st[s.status]; ok && sensorDevice {
+			b.rawSensor("sensor", "", b.prefix+"/sensor/"+s.topic, s.spec, "")
+		}
+	}
+
+	if t, ok := numberValue(st, "temperature"); ok && t != 0 {
+		b.rawSensor("device", "Device", b.prefix+"/temperature", sensorTemperature, "")
+	}
+}
+
+func (b *builder) gen1Meter(key, name, base string) {
+	b.rawSensor(key, name, base+"/power", sensorPower, "")
+	b.rawSensor(key, name, base+"/energy", sensorEnergy, energyFromWattMinutes)
+}
+
+func (b *builder) gen1Roller(i int, name string) {
+	base := fmt.Sprintf("%s/roller/%d", b.prefix, i)
+	key := fmt.Sprintf("roller_%d", i)
+	b.add("cover", key, map[string]any{
+		"name":               name,
+		"device_class":       "shutter",
+		"state_topic":        base,
+		"state_open":         "open",
+		"state_closed":       "close",
+		"state_stopped":      "stop",
+		"command_topic":      base + "/command",
+		"payload_open":       "open",
+		"payload_close":      "close",
+		"payload_stop":       "stop",
+		"position_topic":     base + "/pos",
+		"set_position_topic": base + "/command/pos",
+	})
+	b.gen1Meter(key, name, base)
+}
+
+// gen1Light adds a template-schema light driven by JSON on the channel's
+// /set topic. Colour channels carry brightness as "gain".
+func (b *builder) gen1Light(kind string, i int, name string) {
+	base := fmt.Sprintf("%s/%s/%d", b.prefix, kind, i)
+	level := "brightness"
+	if kind == "color" {
+		level = "gain"
+	}
+
+	on := `{"turn":"on"{% if brightness is defined %},"` + level + `":{{ (brightness / 2.55) | round(0) | int }}{% endif %}`
+	if kind == "color" {
+		on += `{% if red is defined %},"red":{{ red }},"green":{{ green }},"blue":{{ blue }}{% endif %}`
+	}
+	on += "}"
+
+	cfg := map[string]any{
+		"name":                 name,
+		"schema":               "template",
+		"command_topic":        base + "/set",
+		"command_on_template":  on,
+		"command_off_template": `{"turn":"off"}`,
+		"state_topic":          base + "/status",
+		"state_template":       "{{ 'on' if value_json.ison else 'off' }}",
+		"brightness_template":  "{{ (value_json." + level + " * 2.55) | round(0) | int }}",
+	}
+	if kind == "color" {
+		cfg["red_template"] = "{{ value_json.red }}"
+		cfg["green_template"] = "{{ value_json.green }}"
+		cfg["blue_template"] = "{{ value_json.blue }}"
+	}
+	b.add("light", fmt.Sprintf("%s_%d", kind, i), cfg)
+}
+
+// gen1Name returns the channel name from /settings, or "<fallback> <i>".
+func gen1Name(cfg map[string]any, list, fallback string, i int) string {
+	if items := sliceValue(cfg, list); i < len(items) {
+		if item, ok := items[i].(map[string]any); ok {
+			if name := stringValue(item, "name"); name != "" {
+				return name
+			}
+		}
+	}
+	return fmt.Sprintf("%s %d", fallback, i)
+}
@@ -0,0 +1,282 @@
+package hadiscovery
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	stateTemplateOutput = "{{ 'ON' if value_json.output else 'OFF' }}"
+	stateTemplateValue  = "{{ 'ON' if value_json.value else 'OFF' }}"
+	valueTemplateValue  = "{{ value_json.value }}"
+)
+
+// gen2 adds entities for every Gen2+ component in Shelly.GetStatus. State
+// is read from <prefix>/status/<key>, which the device publishes when
+// status notifications are on; commands go to <prefix>/command/<key> where
+// the device accepts them and to <prefix>/rpc otherwise.
+func (b *builder) gen2() {
+	b.availability = b.prefix + "/online"
+
+	keys := make([]string, 0, len(b.dev.Status))
+	for key := range b.dev.Status {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		typ, id, ok := componentKey(key)
+		if !ok {
+			continue
+		}
+		st := mapValue(b.dev.Status, key)
+		if st == nil {
+			st = map[string]any{}
+		}
+		name := stringValue(mapValue(b.dev.Config, key), "name")
+		if name == "" {
+			name = fmt.Sprintf("%s %d", titleCase(typ), id)
+		}
+		topic := b.prefix + "/status/" + key
+
+		switch typ {
+		case "switch":
+			b.add("switch", key, map[string]any{
+				"name":           name,
+				"state_topic":    topic,
+				"value_template": stateTemplateOutput,
+				"state_on":       "ON",
+				"state_off":      "OFF",
+				"command_topic":  b.prefix + "/command/" + key,
+				"payload_on":     "on",
+				"payload_off":    "off",
+			})
+			b.gen2Meter(key, name, topic, st)
+		case "cover":
+			b.gen2Cover(key, name, topic, st)
+			b.gen2Meter(key, name, topic, st)
+		case "light", "rgb", "rgbw":
+			b.gen2Light(key, typ, id, name, topic)
+			b.gen2Meter(key, name, topic, st)
+		case "pm1":
+			b.gen2Meter(key, name, topic, st)
+		case "input":
+			b.gen2Input(key, name, topic, st)
+		case "temperature":
+			b.jsonSensor(key, name, topic, sensorTemperature.at("tC"))
+		case "humidity":
+			b.jsonSensor(key, name, topic, sensorHumidity.at("rh"))
+		case "illuminance":
+			b.jsonSensor(key, name, topic, sensorIlluminance.at("lux"))
+		case "voltmeter":
+			b.jsonSensor(key, name, topic, sensorVoltage.at("voltage"))
+		case "devicepower":
+			if has(st, "battery.percent") {
+				b.jsonSensor(key, name, topic, sensorBattery.at("battery.percent"))
+			}
+		case "em":
+			b.gen2EM(key, name, topic, st)
+		case "em1":
+			b.jsonSensor(key, name, topic, sensorPower.at("act_power"))
+			b.jsonSensor(key, name, topic, sensorVoltage.at("voltage"))
+			b.jsonSensor(key, name, topic, sensorCurrent.at("current"))
+			if has(st, "freq") {
+				b.jsonSensor(key, name, topic, sensorFrequency.at("freq"))
+			}
+		case "emdata":
+			b.jsonSensor(key, name, topic, sensorEnergy.at("total_act"))
+			b.jsonSensor(key, name, topic, sensorEnergy.at("total_act_ret").named("returned_energy", "Returned Energy"))
+		case "em1data":
+			b.jsonSensor(key, name, topic, sensorEnergy.at("total_act_energy"))
+			b.jsonSensor(key, name, topic, sensorEnergy.at("total_act_ret_energy").named("returned_energy", "Returned Energy"))
+		case "boolean", "number", "text", "enum", "button":
+			b.gen2Virtual(key, typ, id, name, topic)
+		}
+	}
+}
+
+// gen2Meter adds the metering sensors a switch, cover, light or PM
+// reports in its own status.
+func (b *builder) gen2Meter(key, name, topic string, st map[string]any) {
+	for _, s := range []sensorSpec{
+		sensorPower.at("apower"),
+		sensorVoltage.at("voltage"),
+		sensorCurrent.at("current"),
+		sensorFrequency.at("freq"),
+		sensorEnergy.at("aenergy.total"),
+		sensorTemperature.at("temperature.tC"),
+	} {
+		if has(st, s.path) {
+			b.jsonSensor(key, name, topic, s)
+		}
+	}
+}
+
+func (b *builder) gen2Cover(key, name, topic string, st map[string]any) {
+	cmd := b.prefix + "/command/" + key
+	cfg := map[string]any{
+		"name":           name,
+		"device_class":   "shutter",
+		"state_topic":    topic,
+		"value_template": "{{ value_json.state }}",
+		"state_open":     "open",
+		"state_closed":   "closed",
+		"state_opening":  "opening",
+		"state_closing":  "closing",
+		"state_stopped":  "stopped",
+		"command_topic":  cmd,
+		"payload_open":   "open",
+		"payload_close":  "close",
+		"payload_stop":   "stop",
+	}
+	if has(st, "current_pos") || st["pos_control"] == true {
+		cfg["position_topic"] = topic
+		cfg["position_template"] = "{{ value_json.current_pos }}"
+		cfg["set_position_topic"] = cmd
+		cfg["set_position_template"] = "pos,{{ position }}"
+	}
+	b.add("cover", key, cfg)
+}
+
+// gen2Light adds a template-schema light driven by <Type>.Set RPC frames.
+// Home Assistant brightness (0-255) is scaled to the device's percent.
+func (b *builder) gen2Light(key, typ string, id int, name, topic string) {
+	method := map[string]string{"light": "Light.Set", "rgb": "RGB.Set", "rgbw": "RGBW.Set"}[typ]
+
+	onParams := fmt.Sprintf(`"id":%d,"on":true`+
+		`{%% if brightness is defined %%},"brightness":{{ (brightness / 2.55) | round(0) | int }}{%% endif %%}`, id)
+	if typ != "light" {
+		onParams += `{% if red is defined %},"rgb":[{{ red }},{{ green }},{{ blue }}]{% endif %}`
+	}
+
+	cfg := map[string]any{
+		"name":                 name,
+		"schema":               "template",
+		"command_topic":        b.prefix + "/rpc",
+		"command_on_template":  rpcTemplate(method, onParams),
+		"command_off_template": rpcTemplate(method, fmt.Sprintf(`"id":%d,"on":false`, id)),
+		"state_topic":          topic,
+		"state_template":       "{{ 'on' if value_json.output else 'off' }}",
+		"brightness_template":  "{{ (value_json.brightness * 2.55) | round(0) | int }}",
+	}
+	if typ != "light" {
+		cfg["red_template"] = "{{ value_json.rgb[0] }}"
+		cfg["green_template"] = "{{ value_json.rgb[1] }}"
+		cfg["blue_template"] = "{{ value_json.rgb[2] }}"
+	}
+	b.add("light", key, cfg)
+}
+
+// gen2Input adds a binary sensor for switch-type inputs and a percent
+// sensor for analog ones. Button-type inputs have no state to track.
+func (b *builder) gen2Input(key, name, topic string, st map[string]any) {
+	if _, ok := st["state"].(bool); ok {
+		b.add("binary_sensor", key, map[string]any{
+			"name":           name,
+			"state_topic":    topic,
+			"value_template": "{{ 'ON' if value_json.state else 'OFF' }}",
+			"payload_on":     "ON",
+			"payload_off":    "OFF",
+		})
+		return
+	}
+	if has(st, "percent") {
+		b.rawSensor(key, name, topic, sensorSpec{suffix: "percent", unit: "%", stateClass: "measurement"},
+			"{{ value_json.percent }}")
+	}
+}
+
+func (b *builder) gen2EM(key, name, topic string, st map[string]any) {
+	b.jsonSensor(key, name, topic, sensorPower.at("total_act_power").named("power", "Total Power"))
+	b.jsonSensor(key, name, topic, sensorCurrent.at("total_current").named("current", "Total Current"))
+	for _, phase := range []string{"a", "b", "c"} {
+		label := "Phase " + titleCase(phase)
+		for _, s := range []sensorSpec{sensorPower.at(phase + "_act_power"), sensorVoltage.at(phase + "_voltage"), sensorCurrent.at(phase + "_current")} {
+			if has(st, s.path) {
+				b.jsonSensor(key, name, topic, s.named("phase_"+phase+"_"+s.suffix, label+" "+s.name))
+			}
+		}
+	}
+}
+
+// gen2Virtual adds entities for virtual components, set through their
+// <Type>.Set (or Button.Trigger) RPC.
+func (b *builder) gen2Virtual(key, typ string, id int, name, topic string) {
+	rpcTopic := b.prefix + "/rpc"
+	cfg := mapValue(b.dev.Config, key)
+
+	switch typ {
+	case "boolean":
+		b.add("switch", key, map[string]any{
+			"name":           name,
+			"state_topic":    topic,
+			"value_template": stateTemplateValue,
+			"state_on":       "ON",
+			"state_off":      "OFF",
+			"command_topic":  rpcTopic,
+			"payload_on":     rpcTemplate("Boolean.Set", fmt.Sprintf(`"id":%d,"value":true`, id)),
+			"payload_off":    rpcTemplate("Boolean.Set", fmt.Sprintf(`"id":%d,"value":false`, id)),
+		})
+	case "number":
+		entity := map[string]any{
+			"name":             name,
+			"state_topic":      topic,
+			"value_template":   valueTemplateValue,
+			"command_topic":    rpcTopic,
+			"command_template": rpcTemplate("Number.Set", fmt.Sprintf(`"id":%d,"value":{{ value }}`, id)),
+		}
+		if v, ok := numberValue(cfg, "min"); ok {
+			entity["min"] = v
+		}
+		if v, ok := numberValue(cfg, "max"); ok {
+			entity["max"] = v
+		}
+		ui := mapValue(mapValue(cfg, "meta"), "ui")
+		if v, ok := numberValue(ui, "step"); ok {
+			entity["step"] = v
+		}
+		if unit := stringValue(ui, "unit"); unit != "" {
+			entity["unit_of_measurement"] = unit
+		}
+		if stringValue(ui, "view") == "slider" {
+			entity["mode"] = "slider"
+		}
+		b.add("number", key, entity)
+	case "text":
+		entity := map[string]any{
+			"name":             name,
+			"state_topic":      topic,
+			"value_template":   valueTemplateValue,
+			"command_topic":    rpcTopic,
+			"command_template": rpcTemplate("Text.Set", fmt.Sprintf(`"id":%d,"value":{{ value | tojson }}`, id)),
+		}
+		if v, ok := numberValue(cfg, "max_len"); ok {
+			entity["max"] = v
+		}
+		b.add("text", key, entity)
+	case "enum":
+		options := make([]string, 0)
+		for _, o := range sliceValue(cfg, "options") {
+			if s, ok := o.(string); ok {
+				options = append(options, s)
+			}
+		}
+		if len(options) == 0 {
+			return // select entities need their options
+		}
+		b.add("select", key, map[string]any{
+			"name":             name,
+			"options":          options,
+			"state_topic":      topic,
+			"value_template":   valueTemplateValue,
+			"command_topic":    rpcTopic,
+			"command_template": rpcTemplate("Enum.Set", fmt.Sprintf(`"id":%d,"value":{{ value | tojson }}`, id)),
+		})
+	case "button":
+		b.add("button", key, map[string]any{
+			"name":          name,
+			"command_topic": rpcTopic,
+			"payload_press": rpcTemplate("Button.Trigger", fmt.Sprintf(`"id":%d,"event":"single_push"`, id)),
+		})
+	}
+}
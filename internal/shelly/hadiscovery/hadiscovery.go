@@ -0,0 +1,342 @@
+// Package hadiscovery builds Home Assistant MQTT discovery payloads for
+// Shelly devices, pointing each entity at the device's native MQTT topics.
+package hadiscovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultPrefix is Home Assistant's default discovery topic prefix.
+const DefaultPrefix = "homeassistant"
+
+// rpcSource is the "src" of RPC frames sent to Gen2+ devices over MQTT;
+// the device publishes replies to <src>/rpc.
+const rpcSource = "shelly-cli"
+
+// Device is what discovery is built from: identity, full status and
+// config as reported by the device.
+type Device struct {
+	Name       string // registered name, used as the Home Assistant device name
+	ID         string // device ID, e.g. shellyplus1pm-a8032ab12345
+	MAC        string
+	Model      string // model code, e.g. SNSW-001P16EU
+	ModelName  string // display name
+	Generation int
+	Firmware   string
+	Address    string
+	Status     map[string]any
+	Config     map[string]any // Gen2+: Shelly.GetConfig; Gen1: /settings
+}
+
+// Entity is one Home Assistant entity found on a device.
+type Entity struct {
+	Component string // Home Assistant platform: switch, cover, light, sensor, ...
+	ObjectID  string // also the unique_id
+	Config    map[string]any
+}
+
+// Message is a retained discovery config message. An empty payload
+// removes the entity from Home Assistant.
+type Message struct {
+	Topic   string
+	Payload []byte
+}
+
+// Topic returns the entity's discovery config topic.
+func (e Entity) Topic(prefix string) string {
+	return fmt.Sprintf("%s/%s/%s/config", prefix, e.Component, e.ObjectID)
+}
+
+// TopicPrefix returns the device's native MQTT topic prefix: the
+// configured topic_prefix (or device ID) on Gen2+, shellies/<id> on Gen1.
+func (d Device) TopicPrefix() string {
+	mqtt := mapValue(d.Config, "mqtt")
+	if d.Generation == 1 {
+		id := stringValue(mqtt, "id")
+		if id == "" {
+			id = stringValue(mapValue(d.Config, "device"), "hostname")
+		}
+		if id == "" {
+			id = d.ID
+		}
+		return "shellies/" + id
+	}
+	if prefix := stringValue(mqtt, "topic_prefix"); prefix != "" {
+		return prefix
+	}
+	return d.ID
+}
+
+// Warnings reports MQTT settings that keep entities from receiving state.
+// Nothing is reported when the config doesn't include MQTT settings.
+func (d Device) Warnings() []string {
+	mqtt := mapValue(d.Config, "mqtt")
+	if mqtt == nil {
+		return nil
+	}
+	var warnings []string
+	if enabled, ok := mqtt["enable"].(bool); ok && !enabled {
+		warnings = append(warnings, "MQTT is disabled on the device (enable it with 'shelly mqtt set')")
+	}
+	if d.Generation >= 2 {
+		if ntf, ok := mqtt["status_ntf"].(bool); ok && !ntf {
+			warnings = append(warnings, "status notifications (status_ntf) are off, so entities won't receive state")
+		}
+	}
+	return warnings
+}
+
+// Entities returns the device's entities with device, availability and
+// origin blocks filled in, sorted by component then object ID.
+func Entities(d Device) []Entity {
+	b := &builder{dev: d, prefix: d.TopicPrefix(), nodeID: sanitizeID(d.ID)}
+	if b.nodeID == "" {
+		b.nodeID = sanitizeID(d.Name)
+	}
+	if d.Generation == 1 {
+		b.gen1()
+	} else {
+		b.gen2()
+	}
+
+	deviceBlock := b.deviceBlock()
+	for _, e := range b.entities {
+		e.Config["unique_id"] = e.ObjectID
+		e.Config["device"] = deviceBlock
+		e.Config["origin"] = map[string]any{"name": "shelly-cli", "support_url": "https://github.com/tj-smith47/shelly-cli"}
+		if b.availability != "" {
+			e.Config["availability_topic"] = b.availability
+			e.Config["payload_available"] = "true"
+			e.Config["payload_not_available"] = "false"
+		}
+	}
+
+	sort.Slice(b.entities, func(i, j int) bool {
+		if b.entities[i].Component != b.entities[j].Component {
+			return b.entities[i].Component < b.entities[j].Component
+		}
+		return b.entities[i].ObjectID < b.entities[j].ObjectID
+	})
+	return b.entities
+}
+
+// Messages returns the retained config messages for a device's entities,
+// or the empty payloads that remove them when remove is set.
+func Messages(d Device, prefix string, remove bool) ([]Message, error) {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	entities := Entities(d)
+	msgs := make([]Message, 0, len(entities))
+	for _, e := range entities {
+		msg := Message{Topic: e.Topic(prefix)}
+		if !remove {
+			payload, err := json.Marshal(e.Config)
+			if err != nil {
+				return nil, fmt.Errorf("marshal %s: %w", e.ObjectID, err)
+			}
+			msg.Payload = payload
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+type builder struct {
+	dev          Device
+	prefix       string
+	nodeID       string
+	availability string
+	entities     []Entity
+}
+
+func (b *builder) add(component, suffix string, cfg map[string]any) {
+	b.entities = append(b.entities, Entity{
+		Component: component,
+		ObjectID:  b.nodeID + "_" + sanitizeID(suffix),
+		Config:    cfg,
+	})
+}
+
+func (b *builder) deviceBlock() map[string]any {
+	dev := map[string]any{
+		"identifiers":  []string{b.nodeID},
+		"name":         b.dev.Name,
+		"manufacturer": "Shelly",
+	}
+	if b.dev.MAC != "" {
+		dev["connections"] = [][]string{{"mac", strings.ToLower(formatMAC(b.dev.MAC))}}
+	}
+	if b.dev.ModelName != "" {
+		dev["model"] = b.dev.ModelName
+	}
+	if b.dev.Model != "" {
+		dev["model_id"] = b.dev.Model
+	}
+	if b.dev.Firmware != "" {
+		dev["sw_version"] = b.dev.Firmware
+	}
+	if b.dev.Address != "" {
+		url := b.dev.Address
+		if !strings.Contains(url, "://") {
+			url = "http://" + url
+		}
+		dev["configuration_url"] = url
+	}
+	return dev
+}
+
+// sensorSpec describes a numeric sensor read from a JSON status payload.
+type sensorSpec struct {
+	suffix      string
+	name        string
+	path        string // dotted path into the status payload
+	unit        string
+	deviceClass string
+	stateClass  string
+}
+
+var (
+	sensorPower       = sensorSpec{"power", "Power", "", "W", "power", "measurement"}
+	sensorVoltage     = sensorSpec{"voltage", "Voltage", "", "V", "voltage", "measurement"}
+	sensorCurrent     = sensorSpec{"current", "Current", "", "A", "current", "measurement"}
+	sensorFrequency   = sensorSpec{"frequency", "Frequency", "", "Hz", "frequency", "measurement"}
+	sensorEnergy      = sensorSpec{"energy", "Energy", "", "Wh", "energy", "total_increasing"}
+	sensorTemperature = sensorSpec{"temperature", "Temperature", "", "°C", "temperature", "measurement"}
+	sensorHumidity    = sensorSpec{"humidity", "Humidity", "", "%", "humidity", "measurement"}
+	sensorIlluminance = sensorSpec{"illuminance", "Illuminance", "", "lx", "illuminance", "measurement"}
+	sensorBattery     = sensorSpec{"battery", "Battery", "", "%", "battery", "measurement"}
+)
+
+func (s sensorSpec) at(path string) sensorSpec {
+	s.path = path
+	return s
+}
+
+func (s sensorSpec) named(suffix, name string) sensorSpec {
+	s.suffix = suffix
+	s.name = name
+	return s
+}
+
+// jsonSensor adds a sensor reading path from the JSON payload on topic.
+func (b *builder) jsonSensor(key, baseName, topic string, s sensorSpec) {
+	cfg := sensorConfig(baseName, s)
+	cfg["state_topic"] = topic
+	cfg["value_template"] = "{{ value_json." + s.path + " }}"
+	b.add("sensor", key+"_"+s.suffix, cfg)
+}
+
+// rawSensor adds a sensor whose topic carries the bare value.
+func (b *builder) rawSensor(key, baseName, topic string, s sensorSpec, template string) {
+	cfg := sensorConfig(baseName, s)
+	cfg["state_topic"] = topic
+	if template != "" {
+		cfg["value_template"] = template
+	}
+	b.add("sensor", key+"_"+s.suffix, cfg)
+}
+
+func sensorConfig(baseName string, s sensorSpec) map[string]any {
+	cfg := map[string]any{
+		"name":                strings.TrimSpace(baseName + " " + s.name),
+		"unit_of_measurement": s.unit,
+	}
+	if s.deviceClass != "" {
+		cfg["device_class"] = s.deviceClass
+	}
+	if s.stateClass != "" {
+		cfg["state_class"] = s.stateClass
+	}
+	return cfg
+}
+
+// rpcTemplate renders an RPC frame for the device's <prefix>/rpc topic.
+// params is spliced in verbatim so it can carry Jinja expressions.
+func rpcTemplate(method, params string) string {
+	return fmt.Sprintf(`{"id":1,"src":%q,"method":%q,"params":{%s}}`, rpcSource, method, params)
+}
+
+// componentKey splits "switch:0" into its type and ID.
+func componentKey(key string) (string, int, bool) {
+	typ, idStr, ok := strings.Cut(key, ":")
+	if !ok {
+		return "", 0, false
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return "", 0, false
+	}
+	return typ, id, true
+}
+
+func mapValue(m map[string]any, key string) map[string]any {
+	v, ok := m[key].(map[string]any)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func sliceValue(m map[string]any, key string) []any {
+	v, ok := m[key].([]any)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func stringValue(m map[string]any, key string) string {
+	v, ok := m[key].(string)
+	if !ok {
+		return ""
+	}
+	return v
+}
+
+func numberValue(m map[string]any, key string) (float64, bool) {
+	v, ok := m[key].(float64)
+	return v, ok
+}
+
+// has reports whether a dotted path exists in m.
+func has(m map[string]any, path string) bool {
+	parts := strings.Split(path, ".")
+	for _, p := range parts[:len(parts)-1] {
+		if m = mapValue(m, p); m == nil {
+			return false
+		}
+	}
+	v, ok := m[parts[len(parts)-1]]
+	return ok && v != nil
+}
+
+var invalidIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+func sanitizeID(s string) string {
+	return strings.Trim(invalidIDChars.ReplaceAllString(s, "_"), "_")
+}
+
+// formatMAC normalizes AABBCCDDEEFF to AA:BB:CC:DD:EE:FF.
+func formatMAC(mac string) string {
+	if strings.Contains(mac, ":") || len(mac) != 12 {
+		return mac
+	}
+	parts := make([]string, 0, 6)
+	for i := 0; i < 12; i += 2 {
+		parts = append(parts, mac[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
+
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,312 @@
+package hadiscovery
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/mqttclient"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/mqtttest"
+)
+
+func gen2Device() Device {
+	return Device{
+		Name:       "kitchen",
+		ID:         "shellyplus1pm-a8032ab12345",
+		MAC:        "A8032AB12345",
+		Model:      "SNSW-001P16EU",
+		ModelName:  "Shelly Plus 1PM",
+		Generation: 2,
+		Firmware:   "1.4.4",
+		Address:    "192.168.1.10",
+		Status: map[string]any{
+			"sys": map[string]any{"uptime": 10.0},
+			"switch:0": map[string]any{
+				"output": true, "apower": 12.5, "voltage": 230.0, "current": 0.05,
+				"aenergy":     map[string]any{"total": 1000.0},
+				"temperature": map[string]any{"tC": 40.0},
+			},
+			"input:0":     map[string]any{"state": false},
+			"input:1":     map[string]any{"state": nil},
+			"cover:0":     map[string]any{"state": "stopped", "current_pos": 50.0},
+			"light:0":     map[string]any{"output": true, "brightness": 40.0},
+			"rgbw:0":      map[string]any{"output": false, "rgb": []any{255.0, 0.0, 0.0}},
+			"boolean:200": map[string]any{"value": true},
+			"number:200":  map[string]any{"value": 21.5},
+			"enum:200":    map[string]any{"value": "eco"},
+			"enum:201":    map[string]any{"value": nil},
+		},
+		Config: map[string]any{
+			"mqtt":       map[string]any{"enable": true, "topic_prefix": "home/kitchen", "status_ntf": false},
+			"switch:0":   map[string]any{"name": "Kettle"},
+			"number:200": map[string]any{"min": 5.0, "max": 30.0, "meta": map[string]any{"ui": map[string]any{"step": 0.5, "unit": "°C"}}},
+			"enum:200":   map[string]any{"options": []any{"eco", "comfort"}},
+		},
+	}
+}
+
+func findEntity(t *testing.T, entities []Entity, component, objectID string) Entity {
+	t.Helper()
+	for _, e := range entities {
+		if e.Component == component && e.ObjectID == objectID {
+			return e
+		}
+	}
+	t.Fatalf("entity %s/%s not found", component, objectID)
+	return Entity{}
+}
+
+func TestTopicPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		dev  Device
+		want string
+	}{
+		{"gen2 configured", Device{Generation: 2, ID: "dev", Config: map[string]any{"mqtt": map[string]any{"topic_prefix": "home/x"}}}, "home/x"},
+		{"gen2 default", Device{Generation: 3, ID: "shelly1g3-abc"}, "shelly1g3-abc"},
+		{"gen1 mqtt id", Device{Generation: 1, ID: "x", Config: map[string]any{"mqtt": map[string]any{"id": "shelly1-B929CC"}}}, "shellies/shelly1-B929CC"},
+		{"gen1 hostname", Device{Generation: 1, ID: "x", Config: map[string]any{"device": map[string]any{"hostname": "shelly1-AABBCC"}}}, "shellies/shelly1-AABBCC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.dev.TopicPrefix(); got != tt.want {
+				t.Errorf("TopicPrefix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntities_Gen2(t *testing.T) {
+	t.Parallel()
+	entities := Entities(gen2Device())
+	node := "shellyplus1pm-a8032ab12345"
+
+	sw := findEntity(t, entities, "switch", node+"_switch_0")
+	if sw.Config["name"] != "Kettle" ||
+		sw.Config["state_topic"] != "home/kitchen/status/switch:0" ||
+		sw.Config["command_topic"] != "home/kitchen/command/switch:0" ||
+		sw.Config["availability_topic"] != "home/kitchen/online" ||
+		sw.Config["unique_id"] != node+"_switch_0" {
+		t.Errorf("switch config = %v", sw.Config)
+	}
+	dev, ok := sw.Config["device"].(map[string]any)
+	if !ok || dev["name"] != "kitchen" || dev["model_id"] != "SNSW-001P16EU" || dev["configuration_url"] != "http://192.168.1.10" {
+		t.Errorf("device block = %v", sw.Config["device"])
+	}
+	if conns, ok := dev["connections"].([][]string); !ok || conns[0][1] != "a8:03:2a:b1:23:45" {
+		t.Errorf("connections = %v", dev["connections"])
+	}
+
+	energy := findEntity(t, entities, "sensor", node+"_switch_0_energy")
+	if energy.Config["value_template"] != "{{ value_json.aenergy.total }}" || energy.Config["state_class"] != "total_increasing" {
+		t.Errorf("energy config = %v", energy.Config)
+	}
+	findEntity(t, entities, "sensor", node+"_switch_0_power")
+	findEntity(t, entities, "sensor", node+"_switch_0_temperature")
+
+	cover := findEntity(t, entities, "cover", node+"_cover_0")
+	if cover.Config["set_position_template"] != "pos,{{ position }}" {
+		t.Errorf("cover config = %v", cover.Config)
+	}
+
+	light := findEntity(t, entities, "light", node+"_light_0")
+	on, _ := light.Config["command_on_template"].(string) //nolint:errcheck // checked below
+	if light.Config["command_topic"] != "home/kitchen/rpc" || !strings.Contains(on, `"method":"Light.Set"`) || strings.Contains(on, "rgb") {
+		t.Errorf("light config = %v", light.Config)
+	}
+	rgbw := findEntity(t, entities, "light", node+"_rgbw_0")
+	if on, _ := rgbw.Config["command_on_template"].(string); !strings.Contains(on, `"rgb":[{{ red }}`) { //nolint:errcheck // checked here
+		t.Errorf("rgbw on template = %q", on)
+	}
+
+	findEntity(t, entities, "binary_sensor", node+"_input_0")
+	findEntity(t, entities, "switch", node+"_boolean_200")
+
+	num := findEntity(t, entities, "number", node+"_number_200")
+	if num.Config["min"] != 5.0 || num.Config["max"] != 30.0 || num.Config["step"] != 0.5 || num.Config["unit_of_measurement"] != "°C" {
+		t.Errorf("number config = %v", num.Config)
+	}
+	sel := findEntity(t, entities, "select", node+"_enum_200")
+	if opts, ok := sel.Config["options"].([]string); !ok || len(opts) != 2 {
+		t.Errorf("select options = %v", sel.Config["options"])
+	}
+
+	for _, e := range entities {
+		if e.ObjectID == node+"_input_1" || e.ObjectID == node+"_enum_201" || strings.Contains(e.ObjectID, "sys") {
+			t.Errorf("unexpected entity %s/%s", e.Component, e.ObjectID)
+		}
+	}
+}
+
+func TestEntities_Gen2EM(t *testing.T) {
+	t.Parallel()
+	dev := Device{
+		Name: "grid", ID: "shellypro3em-aabbcc", Generation: 2,
+		Status: map[string]any{
+			"em:0":     map[string]any{"total_act_power": 100.0, "total_current": 1.0, "a_act_power": 50.0, "a_voltage": 230.0, "a_current": 0.5},
+			"emdata:0": map[string]any{"total_act": 5000.0, "total_act_ret": 10.0},
+		},
+	}
+	entities := Entities(dev)
+	node := "shellypro3em-aabbcc"
+	findEntity(t, entities, "sensor", node+"_em_0_power")
+	findEntity(t, entities, "sensor", node+"_em_0_phase_a_voltage")
+	findEntity(t, entities, "sensor", node+"_emdata_0_returned_energy")
+	for _, e := range entities {
+		if strings.Contains(e.ObjectID, "phase_b") {
+			t.Errorf("unexpected entity %s", e.ObjectID)
+		}
+	}
+}
+
+func TestEntities_Gen1(t *testing.T) {
+	t.Parallel()
+
+	relay := Device{
+		Name: "porch", ID: "shelly1pm-B929CC", Generation: 1,
+		Status: map[string]any{
+			"relays":      []any{map[string]any{"ison": true}},
+			"meters":      []any{map[string]any{"power": 10.0}},
+			"inputs":      []any{map[string]any{"input": 0.0}},
+			"temperature": 45.0,
+			"tmp":         map[string]any{"tC": 45.0, "is_valid": true},
+		},
+		Config: map[string]any{"mqtt": map[string]any{"id": "shelly1pm-B929CC"}, "relays": []any{map[string]any{"name": "Porch Light"}}},
+	}
+	entities := Entities(relay)
+	node := "shelly1pm-B929CC"
+	sw := findEntity(t, entities, "switch", node+"_relay_0")
+	if sw.Config["name"] != "Porch Light" || sw.Config["command_topic"] != "shellies/shelly1pm-B929CC/relay/0/command" ||
+		sw.Config["availability_topic"] != "shellies/shelly1pm-B929CC/online" {
+		t.Errorf("relay config = %v", sw.Config)
+	}
+	energy := findEntity(t, entities, "sensor", node+"_relay_0_energy")
+	if energy.Config["value_template"] != energyFromWattMinutes {
+		t.Errorf("energy config = %v", energy.Config)
+	}
+	findEntity(t, entities, "binary_sensor", node+"_input_0")
+	findEntity(t, entities, "sensor", node+"_device_temperature")
+	for _, e := range entities {
+		if strings.Contains(e.ObjectID, "_sensor_") {
+			t.Errorf("relay should not have sensor/* entities: %s", e.ObjectID)
+		}
+	}
+
+	roller := Device{
+		Name: "blinds", ID: "shellyswitch25-AABBCC", Generation: 1,
+		Status: map[string]any{"rollers": []any{map[string]any{"state": "stop"}}, "relays": []any{map[string]any{}, map[string]any{}}},
+		Config: map[string]any{"mode": "roller"},
+	}
+	entities = Entities(roller)
+	cover := findEntity(t, entities, "cover", "shellyswitch25-AABBCC_roller_0")
+	if cover.Config["position_topic"] != "shellies/shellyswitch25-AABBCC/roller/0/pos" {
+		t.Errorf("roller config = %v", cover.Config)
+	}
+	for _, e := range entities {
+		if e.Component == "switch" {
+			t.Errorf("relays in roller mode should not be switches: %s", e.ObjectID)
+		}
+	}
+
+	ht := Device{
+		Name: "bedroom", ID: "shellyht-AABBCC", Generation: 1,
+		Status: map[string]any{"tmp": map[string]any{"tC": 21.0}, "hum": map[string]any{"value": 40.0}, "bat": map[string]any{"value": 90.0}},
+	}
+	entities = Entities(ht)
+	temp := findEntity(t, entities, "sensor", "shellyht-AABBCC_sensor_temperature")
+	if temp.Config["state_topic"] != "shellies/shellyht-AABBCC/sensor/temperature" {
+		t.Errorf("temperature config = %v", temp.Config)
+	}
+	if _, ok := temp.Config["availability_topic"]; ok {
+		t.Error("battery device should not have an availability topic")
+	}
+	findEntity(t, entities, "sensor", "shellyht-AABBCC_sensor_battery")
+}
+
+func TestWarnings(t *testing.T) {
+	t.Parallel()
+
+	if w := gen2Device().Warnings(); len(w) != 1 || !strings.Contains(w[0], "status_ntf") {
+		t.Errorf("Warnings() = %v", w)
+	}
+	if w := (Device{Generation: 2}).Warnings(); len(w) != 0 {
+		t.Errorf("Warnings() without mqtt config = %v", w)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	t.Parallel()
+
+	msgs, err := Messages(gen2Device(), "", false)
+	if err != nil {
+		t.Fatalf("Messages() error = %v", err)
+	}
+	if len(msgs) == 0 {
+		t.Fatal("no messages")
+	}
+	var found bool
+	for _, m := range msgs {
+		if m.Topic == "homeassistant/switch/shellyplus1pm-a8032ab12345_switch_0/config" {
+			found = true
+			var cfg map[string]any
+			if err := json.Unmarshal(m.Payload, &cfg); err != nil {
+				t.Fatalf("payload is not JSON: %v", err)
+			}
+			if cfg["unique_id"] != "shellyplus1pm-a8032ab12345_switch_0" {
+				t.Errorf("payload = %v", cfg)
+			}
+		}
+	}
+	if !found {
+		t.Error("switch config message not found")
+	}
+
+	removals, err := Messages(gen2Device(), "ha", true)
+	if err != nil {
+		t.Fatalf("Messages(remove) error = %v", err)
+	}
+	if len(removals) != len(msgs) {
+		t.Errorf("removals = %d, want %d", len(removals), len(msgs))
+	}
+	for _, m := range removals {
+		if !strings.HasPrefix(m.Topic, "ha/") || len(m.Payload) != 0 {
+			t.Errorf("removal = %s %q", m.Topic, m.Payload)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	t.Parallel()
+	broker := mqtttest.NewBroker(t)
+
+	msgs := []Message{
+		{Topic: "homeassistant/switch/a/config", Payload: []byte(`{"name":"a"}`)},
+		{Topic: "homeassistant/sensor/b/config"},
+	}
+	if err := Publish(context.Background(), mqttclient.BrokerConfig{Broker: broker.URL, QoS: 1}, msgs); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	for _, want := range msgs {
+		select {
+		case p := <-broker.Published:
+			if p.TopicName != want.Topic || !p.Retain || string(p.Payload) != string(want.Payload) {
+				t.Errorf("publish = %s retain=%v %q, want %s %q", p.TopicName, p.Retain, p.Payload, want.Topic, want.Payload)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no PUBLISH received for %s", want.Topic)
+		}
+	}
+}
+
+func TestPublish_NoBroker(t *testing.T) {
+	t.Parallel()
+	if err := Publish(context.Background(), mqttclient.BrokerConfig{}, nil); err == nil {
+		t.Error("Publish() expected error without broker")
+	}
+}
@@ -0,0 +1,35 @@
+package hadiscovery
+
+import (
+	"context"
+	"fmt"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/mqttclient"
+)
+
+// Publish sends msgs as retained messages over a single broker connection,
+// stopping at the first publish that fails.
+func Publish(ctx context.Context, cfg mqttclient.BrokerConfig, msgs []Message) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	opts := cfg.ClientOptions("shelly-cli-ha").
+		SetAutoReconnect(false).
+		SetConnectRetry(false)
+
+	client := mqtt.NewClient(opts)
+	if err := mqttclient.WaitToken(ctx, client.Connect()); err != nil {
+		return fmt.Errorf("connect to %s: %w", cfg.Broker, err)
+	}
+	defer client.Disconnect(250)
+
+	for _, msg := range msgs {
+		if err := mqttclient.WaitToken(ctx, client.Publish(msg.Topic, cfg.QoS, true, msg.Payload)); err != nil {
+			return fmt.Errorf("publish to %s: %w", msg.Topic, err)
+		}
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/tj-smith47/shelly-cli/internal/shelly/automation"
 	"github.com/tj-smith47/shelly-cli/internal/shelly/export"
 	"github.com/tj-smith47/shelly-cli/internal/shelly/monitoring"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/tariff"
 )
 
 // MonitoringOptions is an alias for monitoring.Options.
@@ -37,6 +38,9 @@ type MonitoringResolvedDevice = monitoring.ResolvedDevice
 // MonitoringDeviceStatus is an alias for monitoring.DeviceStatusResult.
 type MonitoringDeviceStatus = monitoring.DeviceStatusResult
 
+// PrometheusCollector is an alias for monitoring.PrometheusCollector.
+type PrometheusCollector = monitoring.PrometheusCollector
+
 // Delegation methods - these delegate to the monitoring subpackage.
 
 // GetEMStatus returns the status of an Energy Monitor (EM) component.
@@ -144,6 +148,11 @@ func (s *Service) CollectPrometheusMetrics(ctx context.Context, device string) (
 	return s.Monitoring().CollectPrometheusMetrics(ctx, device)
 }
 
+// CollectSamples collects a metrics sample from each device concurrently.
+func (s *Service) CollectSamples(ctx context.Context, devices []string) []export.DeviceSample {
+	return s.Monitoring().CollectSamples(ctx, devices)
+}
+
 // CollectComponentReadings collects all meter readings from a device.
 func (s *Service) CollectComponentReadings(ctx context.Context, device string) []model.ComponentReading {
 	return s.Monitoring().CollectComponentReadings(ctx, device)
@@ -209,17 +218,20 @@ func (s *Service) CollectInfluxDBPointsMulti(ctx context.Context, devices []stri
 	return s.Monitoring().CollectInfluxDBPointsMulti(ctx, devices, measurement, tags)
 }
 
-// CollectDashboardData collects energy data from multiple devices concurrently.
-func (s *Service) CollectDashboardData(ctx context.Context, ios *iostreams.IOStreams, devices []string) model.DashboardData {
-	return s.Monitoring().CollectDashboardData(ctx, ios, devices)
+// CollectDashboardData collects energy data from multiple devices concurrently,
+// with today's cost when a tariff is given.
+func (s *Service) CollectDashboardData(ctx context.Context, ios *iostreams.IOStreams, devices []string, t *tariff.Tariff) model.DashboardData {
+	return s.Monitoring().CollectDashboardData(ctx, ios, devices, t)
 }
 
-// CollectComparisonData collects energy comparison data from multiple devices.
-func (s *Service) CollectComparisonData(ctx context.Context, ios *iostreams.IOStreams, devices []string, period string, startTS, endTS *int64) model.ComparisonData {
-	return s.Monitoring().CollectComparisonData(ctx, ios, devices, period, startTS, endTS)
+// CollectComparisonData collects energy comparison data from multiple devices,
+// priced when a tariff is given.
+func (s *Service) CollectComparisonData(ctx context.Context, ios *iostreams.IOStreams, devices []string, period string, startTS, endTS *int64, t *tariff.Tariff) model.ComparisonData {
+	return s.Monitoring().CollectComparisonData(ctx, ios, devices, period, startTS, endTS, t)
 }
 
-// NewPrometheusCollector creates a new Prometheus metrics collector.
+// NewPrometheusCollector creates a new Prometheus metrics collector that
+// also reports the service's circuit breaker state.
 func NewPrometheusCollector(svc *Service, devices []string) *monitoring.PrometheusCollector {
-	return monitoring.NewPrometheusCollector(svc.Monitoring(), devices)
+	return monitoring.NewPrometheusCollector(svc.Monitoring(), devices, svc.RateLimiter())
 }
@@ -1,7 +1,11 @@
 package monitoring
 
 import (
+	"time"
+
 	"github.com/tj-smith47/shelly-go/gen2/components"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/tariff"
 )
 
 // energyDataBlock represents a block of energy data with period and power values.
@@ -69,3 +73,54 @@ func CalculateEM1Metrics(data *components.EM1DataGetDataResult) (energy, avgPowe
 	}
 	return calculateMetrics(blocks)
 }
+
+// intervalEnergy returns the import and export energy (Wh) of one value,
+// from its energy counters or, when absent, its signed power over the period.
+func intervalEnergy(power float64, imported, exported *float64, period int) (imp, exp float64) {
+	hours := float64(period) / 3600.0
+	if imported != nil {
+		imp = *imported
+	} else if power > 0 {
+		imp = power * hours
+	}
+	if exported != nil {
+		exp = *exported
+	} else if power < 0 {
+		exp = -power * hours
+	}
+	return imp, exp
+}
+
+// EMDataIntervals converts EM data history into timestamped intervals with
+// separate import and export energy for tariff pricing.
+func EMDataIntervals(data *components.EMDataGetDataResult) []tariff.Interval {
+	var intervals []tariff.Interval
+	for _, b := range data.Data {
+		for i, v := range b.Values {
+			imp, exp := intervalEnergy(v.TotalActivePower, v.TotalActEnergy, v.TotalActRetEnergy, b.Period)
+			intervals = append(intervals, tariff.Interval{
+				Start:  time.Unix(b.TS+int64(i*b.Period), 0),
+				Import: imp,
+				Export: exp,
+			})
+		}
+	}
+	return intervals
+}
+
+// EM1DataIntervals converts EM1 data history into timestamped intervals with
+// separate import and export energy for tariff pricing.
+func EM1DataIntervals(data *components.EM1DataGetDataResult) []tariff.Interval {
+	var intervals []tariff.Interval
+	for _, b := range data.Data {
+		for i, v := range b.Values {
+			imp, exp := intervalEnergy(v.ActivePower, v.ActEnergy, v.ActRetEnergy, b.Period)
+			intervals = append(intervals, tariff.Interval{
+				Start:  time.Unix(b.TS+int64(i*b.Period), 0),
+				Import: imp,
+				Export: exp,
+			})
+		}
+	}
+	return intervals
+}
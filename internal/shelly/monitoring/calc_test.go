@@ -262,3 +262,48 @@ func TestCalculateEM1Metrics(t *testing.T) {
 		}
 	})
 }
+
+func TestEMDataIntervals(t *testing.T) {
+	t.Parallel()
+
+	ret := 12.5
+	data := &components.EMDataGetDataResult{
+		Data: []components.EMDataBlock{{
+			TS:     1700000000,
+			Period: 60,
+			Values: []components.EMDataValues{
+				{TotalActivePower: 600},                           // import from power: 10 Wh
+				{TotalActivePower: -300, TotalActRetEnergy: &ret}, // export from counter
+			},
+		}},
+	}
+
+	intervals := EMDataIntervals(data)
+	if len(intervals) != 2 {
+		t.Fatalf("EMDataIntervals() len = %d, want 2", len(intervals))
+	}
+	if intervals[0].Import != 10 || intervals[0].Export != 0 {
+		t.Errorf("intervals[0] = %+v, want 10 Wh import", intervals[0])
+	}
+	if intervals[1].Start.Unix() != 1700000060 || intervals[1].Export != 12.5 || intervals[1].Import != 0 {
+		t.Errorf("intervals[1] = %+v, want 12.5 Wh export at +60s", intervals[1])
+	}
+}
+
+func TestEM1DataIntervals(t *testing.T) {
+	t.Parallel()
+
+	act := 7.0
+	data := &components.EM1DataGetDataResult{
+		Data: []components.EM1DataBlock{{
+			TS:     1700000000,
+			Period: 60,
+			Values: []components.EM1DataValues{{ActivePower: 420, ActEnergy: &act}},
+		}},
+	}
+
+	intervals := EM1DataIntervals(data)
+	if len(intervals) != 1 || intervals[0].Import != 7 {
+		t.Errorf("EM1DataIntervals() = %+v, want 7 Wh import", intervals)
+	}
+}
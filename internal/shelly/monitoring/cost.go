@@ -0,0 +1,64 @@
+package monitoring
+
+import (
+	"context"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/tariff"
+)
+
+// EMEnergyCost prices an EM component's EMData history over [from, to).
+// The standing charge is not included.
+func (s *Service) EMEnergyCost(ctx context.Context, device string, id int, t *tariff.Tariff, from, to time.Time) (model.EnergyCost, error) {
+	startTS, endTS := from.Unix(), to.Unix()
+	data, err := s.GetEMDataHistory(ctx, device, id, &startTS, &endTS)
+	if err != nil {
+		return model.EnergyCost{}, err
+	}
+	return t.Cost(EMDataIntervals(data)), nil
+}
+
+// EM1EnergyCost prices an EM1 component's EM1Data history over [from, to).
+// The standing charge is not included.
+func (s *Service) EM1EnergyCost(ctx context.Context, device string, id int, t *tariff.Tariff, from, to time.Time) (model.EnergyCost, error) {
+	startTS, endTS := from.Unix(), to.Unix()
+	data, err := s.GetEM1DataHistory(ctx, device, id, &startTS, &endTS)
+	if err != nil {
+		return model.EnergyCost{}, err
+	}
+	return t.Cost(EM1DataIntervals(data)), nil
+}
+
+// DeviceEnergyCost prices the history of every EM and EM1 component of a
+// device over [from, to); metered is false when the device has none. The
+// standing charge is not included.
+func (s *Service) DeviceEnergyCost(ctx context.Context, device string, t *tariff.Tariff, from, to time.Time) (cost model.EnergyCost, metered bool, err error) {
+	var costs []model.EnergyCost
+
+	emIDs, err := s.ListEMComponents(ctx, device)
+	if err != nil {
+		return cost, false, err
+	}
+	for _, id := range emIDs {
+		c, err := s.EMEnergyCost(ctx, device, id, t, from, to)
+		if err != nil {
+			return cost, false, err
+		}
+		costs = append(costs, c)
+	}
+
+	em1IDs, err := s.ListEM1Components(ctx, device)
+	if err != nil {
+		return cost, false, err
+	}
+	for _, id := range em1IDs {
+		c, err := s.EM1EnergyCost(ctx, device, id, t, from, to)
+		if err != nil {
+			return cost, false, err
+		}
+		costs = append(costs, c)
+	}
+
+	return tariff.Sum(t.Currency(), costs...), len(costs) > 0, nil
+}
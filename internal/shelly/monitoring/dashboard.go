@@ -2,6 +2,7 @@ package monitoring
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/tj-smith47/shelly-cli/internal/config"
 	"github.com/tj-smith47/shelly-cli/internal/iostreams"
 	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/tariff"
 )
 
 // componentCollector defines how to collect power data from a component type.
@@ -41,9 +43,12 @@ func collectComponents[T any](ctx context.Context, device string, c componentCol
 }
 
 // CollectDashboardData collects energy data from multiple devices concurrently.
-func (s *Service) CollectDashboardData(ctx context.Context, ios *iostreams.IOStreams, devices []string) model.DashboardData {
+// With a tariff, today's cost of each device's EM/EM1 history is included,
+// plus one day's standing charge in the total.
+func (s *Service) CollectDashboardData(ctx context.Context, ios *iostreams.IOStreams, devices []string, t *tariff.Tariff) model.DashboardData {
+	now := time.Now()
 	dashboard := model.DashboardData{
-		Timestamp:   time.Now(),
+		Timestamp:   now,
 		DeviceCount: len(devices),
 		Devices:     make([]model.DashboardDeviceEntry, len(devices)),
 	}
@@ -56,6 +61,9 @@ func (s *Service) CollectDashboardData(ctx context.Context, ios *iostreams.IOStr
 		idx, dev := i, device
 		g.Go(func() error {
 			dashboard.Devices[idx] = s.collectDashboardDeviceStatus(ctx, dev)
+			if t != nil && dashboard.Devices[idx].Online {
+				s.collectDashboardDeviceCost(ctx, ios, &dashboard.Devices[idx], t, now)
+			}
 			return nil
 		})
 	}
@@ -75,9 +83,49 @@ func (s *Service) CollectDashboardData(ctx context.Context, ios *iostreams.IOStr
 		}
 	}
 
+	if t != nil {
+		dashboard.CostToday = dashboardCostToday(dashboard.Devices, t, now)
+		rate := t.RateAt(now, 0)
+		dashboard.CurrentRate = &rate
+	}
+
 	return dashboard
 }
 
+// collectDashboardDeviceCost prices today's history of a device with energy
+// meters.
+func (s *Service) collectDashboardDeviceCost(ctx context.Context, ios *iostreams.IOStreams, status *model.DashboardDeviceEntry, t *tariff.Tariff, now time.Time) {
+	if !slices.ContainsFunc(status.Components, func(c model.ComponentPower) bool { return c.Type == "EM" || c.Type == "EM1" }) {
+		return
+	}
+	cost, metered, err := s.DeviceEnergyCost(ctx, status.Device, t, t.StartOfDay(now), now)
+	if err != nil {
+		ios.DebugErr("energy cost "+status.Device, err)
+		return
+	}
+	if metered {
+		status.CostToday = &cost
+	}
+}
+
+// dashboardCostToday sums the devices' costs today and adds one day's
+// standing charge. Returns nil when no device was priced.
+func dashboardCostToday(devices []model.DashboardDeviceEntry, t *tariff.Tariff, now time.Time) *model.EnergyCost {
+	var costs []model.EnergyCost
+	for _, dev := range devices {
+		if dev.CostToday != nil {
+			costs = append(costs, *dev.CostToday)
+		}
+	}
+	if len(costs) == 0 {
+		return nil
+	}
+	total := tariff.Sum(t.Currency(), costs...)
+	day := t.StartOfDay(now)
+	tariff.AddStandingCharge(&total, t.StandingCharge(day, day.AddDate(0, 0, 1)))
+	return &total
+}
+
 func (s *Service) collectDashboardDeviceStatus(ctx context.Context, device string) model.DashboardDeviceEntry {
 	status := model.DashboardDeviceEntry{Device: device, Online: true}
 
@@ -130,7 +178,9 @@ func (s *Service) collectDashboardDeviceStatus(ctx context.Context, device strin
 }
 
 // CollectComparisonData collects energy comparison data from multiple devices.
-func (s *Service) CollectComparisonData(ctx context.Context, ios *iostreams.IOStreams, devices []string, period string, startTS, endTS *int64) model.ComparisonData {
+// With a tariff, each device's history is priced and the total includes the
+// standing charge over the range.
+func (s *Service) CollectComparisonData(ctx context.Context, ios *iostreams.IOStreams, devices []string, period string, startTS, endTS *int64, t *tariff.Tariff) model.ComparisonData {
 	comparison := model.ComparisonData{
 		Period:  period,
 		Devices: make([]model.DeviceEnergy, len(devices)),
@@ -152,7 +202,7 @@ func (s *Service) CollectComparisonData(ctx context.Context, ios *iostreams.IOSt
 	for i, device := range devices {
 		idx, dev := i, device
 		g.Go(func() error {
-			result := s.collectDeviceEnergy(ctx, dev, startTS, endTS)
+			result := s.collectDeviceEnergy(ctx, dev, startTS, endTS, t)
 			mu.Lock()
 			comparison.Devices[idx] = result
 			mu.Unlock()
@@ -181,21 +231,50 @@ func (s *Service) CollectComparisonData(ctx context.Context, ios *iostreams.IOSt
 		comparison.MinEnergy = 0
 	}
 
+	if t != nil {
+		comparison.Cost = ComparisonCost(comparison, t)
+	}
+
 	return comparison
 }
 
-func (s *Service) collectDeviceEnergy(ctx context.Context, device string, startTS, endTS *int64) model.DeviceEnergy {
+// ComparisonCost sums the priced devices of a comparison and adds the
+// standing charge over its range. Returns nil when no device was priced.
+func ComparisonCost(comparison model.ComparisonData, t *tariff.Tariff) *model.EnergyCost {
+	var costs []model.EnergyCost
+	for _, dev := range comparison.Devices {
+		if dev.Cost != nil {
+			costs = append(costs, *dev.Cost)
+		}
+	}
+	if len(costs) == 0 {
+		return nil
+	}
+	total := tariff.Sum(t.Currency(), costs...)
+	tariff.AddStandingCharge(&total, t.StandingCharge(comparison.From, comparison.To))
+	return &total
+}
+
+func (s *Service) collectDeviceEnergy(ctx context.Context, device string, startTS, endTS *int64, t *tariff.Tariff) model.DeviceEnergy {
 	result := model.DeviceEnergy{Device: device, Online: true}
 
 	// Try EM data first
 	if emData, err := s.GetEMDataHistory(ctx, device, 0, startTS, endTS); err == nil && emData != nil && len(emData.Data) > 0 {
 		result.Energy, result.AvgPower, result.PeakPower, result.DataPoints = CalculateEMMetrics(emData)
+		if t != nil {
+			cost := t.Cost(EMDataIntervals(emData))
+			result.Cost = &cost
+		}
 		return result
 	}
 
 	// Try EM1 data
 	if em1Data, err := s.GetEM1DataHistory(ctx, device, 0, startTS, endTS); err == nil && em1Data != nil && len(em1Data.Data) > 0 {
 		result.Energy, result.AvgPower, result.PeakPower, result.DataPoints = CalculateEM1Metrics(em1Data)
+		if t != nil {
+			cost := t.Cost(EM1DataIntervals(em1Data))
+			result.Cost = &cost
+		}
 		return result
 	}
 
@@ -3,69 +3,65 @@ package monitoring
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
 	"golang.org/x/sync/errgroup"
 
 	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
 	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/ratelimit"
 	"github.com/tj-smith47/shelly-cli/internal/shelly/export"
 )
 
-// CollectPrometheusMetrics collects metrics from a device in Prometheus format.
-func (s *Service) CollectPrometheusMetrics(ctx context.Context, device string) (*export.PrometheusMetrics, error) {
-	metrics := &export.PrometheusMetrics{}
-	online := true
+// CollectDeviceSample reads a device's status and meter readings once.
+// It is the single collection step shared by every metrics output. An
+// unreachable device yields an offline sample alongside the error.
+func (s *Service) CollectDeviceSample(ctx context.Context, device string) (export.DeviceSample, error) {
+	sample := export.DeviceSample{Device: device, Time: time.Now()}
 
+	// Meter readings are collected on their own, so they are still reported
+	// when the status read fails.
 	deviceStatus, err := s.connector.DeviceStatus(ctx, device)
+	sample.Readings = s.CollectComponentReadings(ctx, device)
 	if err != nil {
-		online = false
-	} else if deviceStatus.Status != nil {
-		metrics.Metrics = append(metrics.Metrics, export.CollectSystemPrometheusMetrics(device, deviceStatus.Status)...)
+		return sample, fmt.Errorf("failed to get device status: %w", err)
 	}
+	sample.Online = true
+	sample.Status = deviceStatus.Status
+	return sample, nil
+}
 
-	pmIDs, pmErr := s.ListPMComponents(ctx, device)
-	if pmErr != nil {
-		online = false
-	} else {
-		pmMetrics := export.CollectMeterPrometheusMetrics(device, "pm", pmIDs, func(id int) (*model.PMStatus, error) {
-			return s.GetPMStatus(ctx, device, id)
-		})
-		metrics.Metrics = append(metrics.Metrics, pmMetrics...)
-	}
+// CollectSamples collects samples from multiple devices concurrently,
+// returned in the order of devices. Unreachable devices are reported offline.
+func (s *Service) CollectSamples(ctx context.Context, devices []string) []export.DeviceSample {
+	samples := make([]export.DeviceSample, len(devices))
 
-	if pm1IDs, err := s.ListPM1Components(ctx, device); err == nil {
-		pm1Metrics := export.CollectMeterPrometheusMetrics(device, "pm1", pm1IDs, func(id int) (*model.PMStatus, error) {
-			return s.GetPM1Status(ctx, device, id)
-		})
-		metrics.Metrics = append(metrics.Metrics, pm1Metrics...)
-	}
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(config.GetGlobalMaxConcurrent())
 
-	if em1IDs, err := s.ListEM1Components(ctx, device); err == nil {
-		em1Metrics := export.CollectMeterPrometheusMetrics(device, "em1", em1IDs, func(id int) (*model.EM1Status, error) {
-			return s.GetEM1Status(ctx, device, id)
+	for i, device := range devices {
+		g.Go(func() error {
+			samples[i], _ = s.CollectDeviceSample(ctx, device) //nolint:errcheck // offline samples carry the failure
+			return nil
 		})
-		metrics.Metrics = append(metrics.Metrics, em1Metrics...)
 	}
 
-	onlineVal := 0.0
-	if online {
-		onlineVal = 1.0
+	if err := g.Wait(); err != nil {
+		iostreams.DebugErr("collecting metrics samples", err)
 	}
-	metrics.Metrics = append(metrics.Metrics, export.PrometheusMetric{
-		Name: "shelly_device_online", Help: "Device online status (1=online, 0=offline)",
-		Type: "gauge", Labels: map[string]string{"device": device}, Value: onlineVal,
-	})
-
-	metrics.Metrics = append(metrics.Metrics, s.collectEMPrometheusMetrics(ctx, device)...)
-
-	return metrics, nil
+	return samples
 }
 
-func (s *Service) collectEMPrometheusMetrics(ctx context.Context, device string) []export.PrometheusMetric {
-	readings := s.collectEMReadings(ctx, device)
-	return export.ReadingsToPrometheusMetrics(readings)
+// CollectPrometheusMetrics collects metrics from a device in Prometheus format.
+// When the device status cannot be read the returned metrics still report
+// the device offline, alongside the error.
+func (s *Service) CollectPrometheusMetrics(ctx context.Context, device string) (*export.PrometheusMetrics, error) {
+	sample, err := s.CollectDeviceSample(ctx, device)
+	return &export.PrometheusMetrics{Metrics: export.SamplePrometheusMetrics(sample)}, err
 }
 
 // CollectComponentReadings collects all meter readings from a device.
@@ -165,60 +161,49 @@ func (s *Service) CollectInfluxDBPoints(ctx context.Context, device string) ([]e
 
 // CollectJSONMetrics collects metrics from multiple devices for JSON output.
 func (s *Service) CollectJSONMetrics(ctx context.Context, devices []string) export.JSONMetricsOutput {
-	output := export.JSONMetricsOutput{
-		Timestamp: time.Now(),
-		Devices:   make([]export.JSONMetricsDevice, len(devices)),
-	}
-
-	g, ctx := errgroup.WithContext(ctx)
-	g.SetLimit(config.GetGlobalMaxConcurrent())
-
-	var mu sync.Mutex
-
-	for i, device := range devices {
-		idx := i
-		dev := device
-		g.Go(func() error {
-			readings := s.CollectComponentReadings(ctx, dev)
-			mu.Lock()
-			output.Devices[idx] = export.JSONMetricsDevice{
-				Device:     dev,
-				Online:     len(readings) > 0,
-				Components: readings,
-			}
-			mu.Unlock()
-			return nil
-		})
-	}
-
-	if err := g.Wait(); err != nil {
-		return output
-	}
-
-	return output
+	return export.SamplesJSONMetrics(s.CollectSamples(ctx, devices), time.Now())
 }
 
 // PrometheusCollector collects and caches Prometheus metrics from multiple devices.
+// It also keeps per-device scrape statistics, reported as exporter self-metrics
+// together with circuit breaker state from the rate limiter.
 type PrometheusCollector struct {
 	svc     *Service
 	devices []string
+	limiter *ratelimit.DeviceRateLimiter
 
 	mu      sync.RWMutex
 	metrics map[string]*export.PrometheusMetrics
 	errors  map[string]error
+
+	statsMu sync.Mutex
+	scrapes map[string]*scrapeStats
+}
+
+// scrapeStats tracks the scrapes of one device.
+type scrapeStats struct {
+	duration time.Duration // of the last scrape
+	total    int
+	failures int
 }
 
 // NewPrometheusCollector creates a new Prometheus metrics collector.
-func NewPrometheusCollector(svc *Service, devices []string) *PrometheusCollector {
+// The rate limiter may be nil, in which case no circuit breaker metrics are
+// exported.
+func NewPrometheusCollector(svc *Service, devices []string, limiter *ratelimit.DeviceRateLimiter) *PrometheusCollector {
 	return &PrometheusCollector{
 		svc:     svc,
 		devices: devices,
+		limiter: limiter,
 		metrics: make(map[string]*export.PrometheusMetrics),
 		errors:  make(map[string]error),
+		scrapes: make(map[string]*scrapeStats),
 	}
 }
 
 // Collect fetches metrics from all configured devices concurrently.
+// Devices that fail keep reporting whatever could be read, including
+// shelly_device_online.
 func (c *PrometheusCollector) Collect(ctx context.Context) {
 	newMetrics := make(map[string]*export.PrometheusMetrics)
 	newErrors := make(map[string]error)
@@ -231,13 +216,12 @@ func (c *PrometheusCollector) Collect(ctx context.Context) {
 	for _, device := range c.devices {
 		dev := device
 		g.Go(func() error {
-			m, err := c.svc.CollectPrometheusMetrics(ctx, dev)
+			m, err := c.scrape(ctx, dev)
 			mu.Lock()
 			if err != nil {
 				newErrors[dev] = err
-			} else {
-				newMetrics[dev] = m
 			}
+			newMetrics[dev] = m
 			mu.Unlock()
 			return nil
 		})
@@ -253,6 +237,81 @@ func (c *PrometheusCollector) Collect(ctx context.Context) {
 	c.mu.Unlock()
 }
 
+// Probe scrapes a single device on demand, in the style of the blackbox
+// exporter: the result carries the device metrics plus probe_success and
+// probe_duration_seconds. The device need not be one of the collector's
+// devices, but only collector and registered devices are counted in the
+// self-metrics, so arbitrary targets cannot grow them without bound.
+func (c *PrometheusCollector) Probe(ctx context.Context, target string) string {
+	start := time.Now()
+	var (
+		m   *export.PrometheusMetrics
+		err error
+	)
+	if c.tracked(target) {
+		m, err = c.scrape(ctx, target)
+	} else {
+		m, err = c.collect(ctx, target)
+	}
+	duration := time.Since(start)
+
+	success := 1.0
+	if err != nil {
+		success = 0
+	}
+	m.Metrics = append(m.Metrics,
+		export.PrometheusMetric{
+			Name: "probe_success", Help: "Whether the device status could be read",
+			Type: "gauge", Value: success,
+		},
+		export.PrometheusMetric{
+			Name: "probe_duration_seconds", Help: "Time taken to scrape the device",
+			Type: "gauge", Value: duration.Seconds(),
+		},
+	)
+	return export.FormatPrometheusMetrics(m)
+}
+
+// tracked reports whether scrapes of device are kept in the self-metrics.
+func (c *PrometheusCollector) tracked(device string) bool {
+	if slices.Contains(c.devices, device) {
+		return true
+	}
+	_, ok := config.GetDevice(device)
+	return ok
+}
+
+// collect fetches one device's metrics without recording statistics.
+func (c *PrometheusCollector) collect(ctx context.Context, device string) (*export.PrometheusMetrics, error) {
+	m, err := c.svc.CollectPrometheusMetrics(ctx, device)
+	if m == nil {
+		m = &export.PrometheusMetrics{}
+	}
+	return m, err
+}
+
+// scrape collects one device and records its scrape statistics.
+func (c *PrometheusCollector) scrape(ctx context.Context, device string) (*export.PrometheusMetrics, error) {
+	start := time.Now()
+	m, err := c.collect(ctx, device)
+	duration := time.Since(start)
+
+	c.statsMu.Lock()
+	st, ok := c.scrapes[device]
+	if !ok {
+		st = &scrapeStats{}
+		c.scrapes[device] = st
+	}
+	st.duration = duration
+	st.total++
+	if err != nil {
+		st.failures++
+	}
+	c.statsMu.Unlock()
+
+	return m, err
+}
+
 // Errors returns any collection errors from the last Collect call.
 func (c *PrometheusCollector) Errors() map[string]error {
 	c.mu.RLock()
@@ -264,18 +323,68 @@ func (c *PrometheusCollector) Errors() map[string]error {
 	return result
 }
 
-// FormatMetrics returns the collected metrics in Prometheus exposition format.
+// SelfMetrics returns the exporter's own metrics: scrape duration, scrape
+// and error counts per device, and circuit breaker state per address.
+func (c *PrometheusCollector) SelfMetrics() []export.PrometheusMetric {
+	c.statsMu.Lock()
+	devices := make([]string, 0, len(c.scrapes))
+	for device := range c.scrapes {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+
+	metrics := make([]export.PrometheusMetric, 0, len(devices)*3)
+	for _, device := range devices {
+		st := c.scrapes[device]
+		labels := map[string]string{"device": device}
+		metrics = append(metrics,
+			export.PrometheusMetric{
+				Name: "shelly_exporter_scrape_duration_seconds", Help: "Duration of the last scrape of the device",
+				Type: "gauge", Labels: labels, Value: st.duration.Seconds(),
+			},
+			export.PrometheusMetric{
+				Name: "shelly_exporter_scrapes_total", Help: "Scrapes of the device since the exporter started",
+				Type: "counter", Labels: labels, Value: float64(st.total),
+			},
+			export.PrometheusMetric{
+				Name: "shelly_exporter_scrape_errors_total", Help: "Failed scrapes of the device since the exporter started",
+				Type: "counter", Labels: labels, Value: float64(st.failures),
+			},
+		)
+	}
+	c.statsMu.Unlock()
+
+	if c.limiter != nil {
+		metrics = append(metrics, export.CircuitPrometheusMetrics(c.limiter.AllStats())...)
+	}
+	return metrics
+}
+
+// FormatSelfMetrics returns only the exporter self-metrics in Prometheus
+// exposition format.
+func (c *PrometheusCollector) FormatSelfMetrics() string {
+	return export.FormatPrometheusMetrics(&export.PrometheusMetrics{Metrics: c.SelfMetrics()})
+}
+
+// FormatMetrics returns the collected metrics, followed by the exporter
+// self-metrics, in Prometheus exposition format.
 func (c *PrometheusCollector) FormatMetrics() string {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
+	devices := make([]string, 0, len(c.metrics))
+	for device := range c.metrics {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
 
 	combined := &export.PrometheusMetrics{}
-	for _, m := range c.metrics {
-		if m != nil {
+	for _, device := range devices {
+		if m := c.metrics[device]; m != nil {
 			combined.Metrics = append(combined.Metrics, m.Metrics...)
 		}
 	}
+	c.mu.RUnlock()
 
+	combined.Metrics = append(combined.Metrics, c.SelfMetrics()...)
 	return export.FormatPrometheusMetrics(combined)
 }
 
@@ -299,35 +408,5 @@ func (s *Service) StreamInfluxDBPoints(ctx context.Context, devices []string, me
 
 // CollectInfluxDBPointsMulti collects InfluxDB points from multiple devices concurrently.
 func (s *Service) CollectInfluxDBPointsMulti(ctx context.Context, devices []string, measurement string, tags map[string]string) []export.InfluxDBPoint {
-	now := time.Now()
-
-	g, ctx := errgroup.WithContext(ctx)
-	g.SetLimit(config.GetGlobalMaxConcurrent())
-
-	var mu sync.Mutex
-	var allPoints []export.InfluxDBPoint
-
-	for _, device := range devices {
-		dev := device
-		g.Go(func() error {
-			readings := s.CollectComponentReadings(ctx, dev)
-			points := export.ReadingsToInfluxDBPoints(readings, now)
-			for i := range points {
-				points[i].Measurement = measurement
-				for k, v := range tags {
-					points[i].Tags[k] = v
-				}
-			}
-			mu.Lock()
-			allPoints = append(allPoints, points...)
-			mu.Unlock()
-			return nil
-		})
-	}
-
-	if err := g.Wait(); err != nil {
-		return allPoints
-	}
-
-	return allPoints
+	return export.SamplesInfluxDBReadingPoints(s.CollectSamples(ctx, devices), measurement, tags)
 }
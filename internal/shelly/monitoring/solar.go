@@ -0,0 +1,103 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/tj-smith47/shelly-go/gen2/components"
+
+	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/solar"
+)
+
+// EMDataSolarIntervals converts EM data history into solar analysis
+// intervals, with the per-phase active power of each interval.
+func EMDataSolarIntervals(data *components.EMDataGetDataResult) []solar.Interval {
+	var intervals []solar.Interval
+	for _, b := range data.Data {
+		for i, v := range b.Values {
+			imp, exp := intervalEnergy(v.TotalActivePower, v.TotalActEnergy, v.TotalActRetEnergy, b.Period)
+			intervals = append(intervals, solar.Interval{
+				Start:  time.Unix(b.TS+int64(i*b.Period), 0),
+				Period: time.Duration(b.Period) * time.Second,
+				Import: imp,
+				Export: exp,
+				Phases: []float64{v.AActivePower, v.BActivePower, v.CActivePower},
+			})
+		}
+	}
+	return intervals
+}
+
+// EM1DataSolarIntervals converts EM1 data history into solar analysis
+// intervals.
+func EM1DataSolarIntervals(data *components.EM1DataGetDataResult) []solar.Interval {
+	var intervals []solar.Interval
+	for _, b := range data.Data {
+		for i, v := range b.Values {
+			imp, exp := intervalEnergy(v.ActivePower, v.ActEnergy, v.ActRetEnergy, b.Period)
+			intervals = append(intervals, solar.Interval{
+				Start:  time.Unix(b.TS+int64(i*b.Period), 0),
+				Period: time.Duration(b.Period) * time.Second,
+				Import: imp,
+				Export: exp,
+			})
+		}
+	}
+	return intervals
+}
+
+// MeterIntervals fetches the history of the EM or EM1 component with the
+// given ID over [from, to) as solar analysis intervals.
+func (s *Service) MeterIntervals(ctx context.Context, device string, id int, from, to time.Time) ([]solar.Interval, error) {
+	startTS, endTS := from.Unix(), to.Unix()
+
+	emIDs, err := s.ListEMComponents(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(emIDs, id) {
+		data, err := s.GetEMDataHistory(ctx, device, id, &startTS, &endTS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get EMData history: %w", err)
+		}
+		return EMDataSolarIntervals(data), nil
+	}
+
+	em1IDs, err := s.ListEM1Components(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(em1IDs, id) {
+		data, err := s.GetEM1DataHistory(ctx, device, id, &startTS, &endTS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get EM1Data history: %w", err)
+		}
+		return EM1DataSolarIntervals(data), nil
+	}
+
+	return nil, fmt.Errorf("%s has no energy meter with ID %d (EM or EM1 required)", device, id)
+}
+
+// SolarAnalysis analyzes the grid meter history over [from, to), paired with
+// the PV meter history when pv is set. Days are split in local time.
+func (s *Service) SolarAnalysis(ctx context.Context, grid string, gridID int, pv string, pvID int, from, to time.Time) (model.SolarReport, error) {
+	report := model.SolarReport{Grid: grid, PV: pv, From: from, To: to}
+
+	gridIntervals, err := s.MeterIntervals(ctx, grid, gridID, from, to)
+	if err != nil {
+		return report, fmt.Errorf("grid meter: %w", err)
+	}
+
+	var pvIntervals []solar.Interval
+	if pv != "" {
+		if pvIntervals, err = s.MeterIntervals(ctx, pv, pvID, from, to); err != nil {
+			return report, fmt.Errorf("PV meter: %w", err)
+		}
+	}
+
+	report.Days, report.Total = solar.Analyze(gridIntervals, pvIntervals, time.Local)
+	return report, nil
+}
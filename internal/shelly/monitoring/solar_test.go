@@ -0,0 +1,51 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-go/gen2/components"
+)
+
+func TestEMDataSolarIntervals(t *testing.T) {
+	t.Parallel()
+
+	data := &components.EMDataGetDataResult{
+		Data: []components.EMDataBlock{{
+			TS:     1700000000,
+			Period: 60,
+			Values: []components.EMDataValues{
+				{TotalActivePower: 1200, AActivePower: 600, BActivePower: 400, CActivePower: 200},
+			},
+		}},
+	}
+
+	intervals := EMDataSolarIntervals(data)
+	if len(intervals) != 1 {
+		t.Fatalf("EMDataSolarIntervals() len = %d, want 1", len(intervals))
+	}
+	iv := intervals[0]
+	if iv.Period != time.Minute || iv.Import != 20 {
+		t.Errorf("interval = %+v, want 20 Wh over 1m", iv)
+	}
+	if len(iv.Phases) != 3 || iv.Phases[0] != 600 || iv.Phases[2] != 200 {
+		t.Errorf("Phases = %v, want [600 400 200]", iv.Phases)
+	}
+}
+
+func TestEM1DataSolarIntervals(t *testing.T) {
+	t.Parallel()
+
+	data := &components.EM1DataGetDataResult{
+		Data: []components.EM1DataBlock{{
+			TS:     1700000000,
+			Period: 60,
+			Values: []components.EM1DataValues{{ActivePower: -600}},
+		}},
+	}
+
+	intervals := EM1DataSolarIntervals(data)
+	if len(intervals) != 1 || intervals[0].Export != 10 || intervals[0].Phases != nil {
+		t.Errorf("EM1DataSolarIntervals() = %+v, want 10 Wh export without phases", intervals)
+	}
+}
@@ -0,0 +1,108 @@
+package shelly
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tj-smith47/shelly-go/events"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/automation"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/mqttbridge"
+)
+
+// bridgeCommandTimeout bounds each command received over MQTT.
+const bridgeCommandTimeout = 10 * time.Second
+
+// BridgeExecutor returns an executor that performs MQTT bridge commands
+// through the service. Commands work on Gen1 and Gen2+ devices alike.
+func (s *Service) BridgeExecutor() mqttbridge.Executor {
+	return bridgeExecutor{s}
+}
+
+type bridgeExecutor struct{ s *Service }
+
+func (b bridgeExecutor) Execute(ctx context.Context, cmd mqttbridge.Command) error {
+	ctx, cancel := context.WithTimeout(ctx, bridgeCommandTimeout)
+	defer cancel()
+
+	s, dev, id := b.s, cmd.Device, cmd.ID
+	var err error
+	switch cmd.Component + "." + cmd.Action {
+	case "switch.on":
+		err = s.SwitchOn(ctx, dev, id)
+	case "switch.off":
+		err = s.SwitchOff(ctx, dev, id)
+	case "switch.toggle":
+		_, err = s.SwitchToggle(ctx, dev, id)
+	case "cover.open":
+		err = s.CoverOpen(ctx, dev, id, nil)
+	case "cover.close":
+		err = s.CoverClose(ctx, dev, id, nil)
+	case "cover.stop":
+		err = s.CoverStop(ctx, dev, id)
+	case "cover.position":
+		err = s.CoverPosition(ctx, dev, id, cmd.Position)
+	case "light.on":
+		err = s.LightOn(ctx, dev, id)
+	case "light.off":
+		err = s.LightOff(ctx, dev, id)
+	case "light.toggle":
+		_, err = s.LightToggle(ctx, dev, id)
+	case "light.set":
+		err = s.LightSet(ctx, dev, id, cmd.Set.Brightness, nil, cmd.Set.On)
+	case "rgb.on":
+		err = s.RGBOn(ctx, dev, id)
+	case "rgb.off":
+		err = s.RGBOff(ctx, dev, id)
+	case "rgb.toggle":
+		_, err = s.RGBToggle(ctx, dev, id)
+	case "rgb.set":
+		params := RGBSetParams{Brightness: cmd.Set.Brightness, On: cmd.Set.On}
+		if len(cmd.Set.RGB) == 3 {
+			params.Red, params.Green, params.Blue = &cmd.Set.RGB[0], &cmd.Set.RGB[1], &cmd.Set.RGB[2]
+		}
+		err = s.RGBSet(ctx, dev, id, params)
+	case "rgbw.on":
+		err = s.RGBWOn(ctx, dev, id)
+	case "rgbw.off":
+		err = s.RGBWOff(ctx, dev, id)
+	case "rgbw.toggle":
+		_, err = s.RGBWToggle(ctx, dev, id)
+	case "rgbw.set":
+		params := RGBWSetParams{Brightness: cmd.Set.Brightness, White: cmd.Set.White, On: cmd.Set.On}
+		if len(cmd.Set.RGB) == 3 {
+			params.Red, params.Green, params.Blue = &cmd.Set.RGB[0], &cmd.Set.RGB[1], &cmd.Set.RGB[2]
+		}
+		err = s.RGBWSet(ctx, dev, id, params)
+	default:
+		err = fmt.Errorf("unsupported command %s %s", cmd.Component, cmd.Action)
+	}
+	return err
+}
+
+// RunMQTTBridge streams events from the given registered devices into
+// bridge until ctx is cancelled. Gen2+ devices are streamed over
+// WebSocket; Gen1 devices report over CoIoT, with HTTP polling as the
+// fallback.
+func (s *Service) RunMQTTBridge(ctx context.Context, devices []string, bridge *mqttbridge.Bridge) {
+	es := automation.NewEventStream(s)
+	es.SubscribeFiltered(events.WithDeviceIDs(devices...), bridge.Handle)
+
+	coiot := false
+	for _, name := range devices {
+		address := name
+		if dev, ok := config.GetDevice(name); ok {
+			address = dev.Address
+			coiot = coiot || dev.Generation == 1
+		}
+		es.AddDevice(name, address)
+	}
+	if coiot {
+		es.ListenCoIoT()
+	}
+
+	<-ctx.Done()
+	es.Stop()
+}
@@ -0,0 +1,307 @@
+// Package mqttbridge mirrors device state from an EventStream onto MQTT
+// topics and turns command messages into device calls, so devices that
+// have MQTT disabled (or speak CoIoT) appear on a broker under one scheme:
+//
+//	<base>/bridge/state                      online | offline (retained)
+//	<base>/<device>/online                   true | false (retained)
+//	<base>/<device>/<type>/<id>              component status JSON (retained)
+//	<base>/<device>/<type>/<id>/event        input events, e.g. single_push
+//	<base>/<device>/<type>/<id>/command  ←   on | off | toggle | open | close | stop
+//	<base>/<device>/<type>/<id>/set      ←   {"on":true,"brightness":50,"rgb":[255,0,0],"white":0}
+//	<base>/<device>/cover/<id>/position  ←   0-100
+//
+// Gen1 relays, rollers, lights and inputs appear as switch, cover, light
+// and input components, like their Gen2 counterparts.
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tj-smith47/shelly-go/events"
+
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/alerting"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rules"
+)
+
+// DefaultBaseTopic is the default topic prefix.
+const DefaultBaseTopic = "shelly"
+
+// Command actions.
+const (
+	ActionOn       = "on"
+	ActionOff      = "off"
+	ActionToggle   = "toggle"
+	ActionOpen     = "open"
+	ActionClose    = "close"
+	ActionStop     = "stop"
+	ActionPosition = "position"
+	ActionSet      = "set"
+)
+
+// Command is a device call decoded from a command topic.
+type Command struct {
+	Device    string
+	Component string // switch, cover, light, rgb or rgbw
+	ID        int
+	Action    string
+	Position  int       // ActionPosition
+	Set       SetParams // ActionSet
+}
+
+// SetParams are the fields of a /set payload; unset fields are left alone.
+type SetParams struct {
+	On         *bool `json:"on,omitempty"`
+	Brightness *int  `json:"brightness,omitempty"`
+	RGB        []int `json:"rgb,omitempty"`
+	White      *int  `json:"white,omitempty"`
+}
+
+// Executor performs commands on devices.
+type Executor interface {
+	Execute(ctx context.Context, cmd Command) error
+}
+
+// Publisher publishes MQTT messages.
+type Publisher interface {
+	Publish(topic string, payload []byte, retain bool) error
+}
+
+// componentActions lists the /command payloads each component accepts.
+var componentActions = map[string][]string{
+	"switch": {ActionOn, ActionOff, ActionToggle},
+	"light":  {ActionOn, ActionOff, ActionToggle},
+	"rgb":    {ActionOn, ActionOff, ActionToggle},
+	"rgbw":   {ActionOn, ActionOff, ActionToggle},
+	"cover":  {ActionOpen, ActionClose, ActionStop},
+}
+
+// Bridge tracks device state and publishes changes. It is safe for
+// concurrent use.
+type Bridge struct {
+	base    string
+	pub     Publisher
+	devices map[string]bool
+
+	mu     sync.Mutex
+	online map[string]bool
+	state  map[string]map[string]any // device -> component key -> published status
+}
+
+// New creates a bridge for the given devices. Commands for other devices
+// are rejected.
+func New(base string, devices []string, pub Publisher) *Bridge {
+	b := &Bridge{
+		base:    baseTopic(base),
+		pub:     pub,
+		devices: make(map[string]bool, len(devices)),
+		online:  make(map[string]bool),
+		state:   make(map[string]map[string]any),
+	}
+	for _, d := range devices {
+		b.devices[d] = true
+	}
+	return b
+}
+
+// StateTopic returns the bridge's own availability topic under base.
+func StateTopic(base string) string {
+	return baseTopic(base) + "/bridge/state"
+}
+
+func baseTopic(base string) string {
+	if base = strings.TrimRight(base, "/"); base == "" {
+		return DefaultBaseTopic
+	}
+	return base
+}
+
+// CommandFilters returns the subscriptions that receive commands.
+func (b *Bridge) CommandFilters() []string {
+	return []string{
+		b.base + "/+/+/+/command",
+		b.base + "/+/+/+/set",
+		b.base + "/+/+/+/position",
+	}
+}
+
+// Handle applies an EventStream event and publishes what changed. It is
+// safe to use as an EventStream subscriber.
+func (b *Bridge) Handle(evt events.Event) {
+	name := evt.DeviceID()
+	if !b.devices[name] {
+		return
+	}
+
+	switch e := evt.(type) {
+	case *events.FullStatusEvent:
+		var status map[string]any
+		if err := json.Unmarshal(e.Status, &status); err != nil {
+			iostreams.DebugErr("mqtt bridge: parse full status for "+name, err)
+			return
+		}
+		if _, ok := status["sensors"]; ok {
+			status = NormalizeCoIoT(status)
+		} else {
+			status = rules.NormalizeStatus(status)
+		}
+		b.setOnline(name, true)
+		for key, v := range status {
+			if strings.Contains(key, ":") {
+				b.publishComponent(name, key, v, false)
+			}
+		}
+
+	case *events.StatusChangeEvent:
+		var delta any
+		if err := json.Unmarshal(e.Status, &delta); err != nil {
+			iostreams.DebugErr("mqtt bridge: parse status change for "+name, err)
+			return
+		}
+		b.setOnline(name, true)
+		b.publishComponent(name, e.Component, delta, true)
+
+	case *events.NotifyEvent:
+		if topic, ok := b.componentTopic(name, e.Component); ok && e.Event != "" {
+			b.publish(topic+"/event", []byte(e.Event), false)
+		}
+
+	case *events.DeviceOnlineEvent:
+		b.setOnline(name, true)
+
+	case *events.DeviceOfflineEvent:
+		b.setOnline(name, false)
+	}
+}
+
+func (b *Bridge) setOnline(device string, online bool) {
+	b.mu.Lock()
+	prev, known := b.online[device]
+	b.online[device] = online
+	b.mu.Unlock()
+
+	if !known || prev != online {
+		b.publish(b.base+"/"+device+"/online", []byte(strconv.FormatBool(online)), true)
+	}
+}
+
+// publishComponent publishes a component's status when it changed. With
+// merge set, v is a delta applied to the last known status.
+func (b *Bridge) publishComponent(device, key string, v any, merge bool) {
+	topic, ok := b.componentTopic(device, key)
+	if !ok {
+		return
+	}
+
+	b.mu.Lock()
+	components := b.state[device]
+	if components == nil {
+		components = make(map[string]any)
+		b.state[device] = components
+	}
+	prev := components[key]
+	next := v
+	if merge {
+		prevMap, _ := prev.(map[string]any) //nolint:errcheck // nil when not a map
+		deltaMap, isMap := v.(map[string]any)
+		if isMap {
+			next = alerting.MergeStatus(prevMap, deltaMap)
+		}
+	}
+	if reflect.DeepEqual(prev, next) {
+		b.mu.Unlock()
+		return
+	}
+	components[key] = next
+	b.mu.Unlock()
+
+	payload, err := json.Marshal(next)
+	if err != nil {
+		iostreams.DebugErr("mqtt bridge: marshal "+device+" "+key, err)
+		return
+	}
+	b.publish(topic, payload, true)
+}
+
+// componentTopic maps "switch:0" to <base>/<device>/switch/0.
+func (b *Bridge) componentTopic(device, key string) (string, bool) {
+	typ, id, ok := strings.Cut(key, ":")
+	if !ok || typ == "" || id == "" {
+		return "", false
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", b.base, device, typ, id), true
+}
+
+func (b *Bridge) publish(topic string, payload []byte, retain bool) {
+	if err := b.pub.Publish(topic, payload, retain); err != nil {
+		iostreams.DebugErr("mqtt bridge: publish "+topic, err)
+	}
+}
+
+// ParseCommand decodes a message received on one of CommandFilters.
+func (b *Bridge) ParseCommand(topic string, payload []byte) (Command, error) {
+	rest, ok := strings.CutPrefix(topic, b.base+"/")
+	parts := strings.Split(rest, "/")
+	if !ok || len(parts) != 4 {
+		return Command{}, fmt.Errorf("unexpected topic %q", topic)
+	}
+	device, typ, idStr, verb := parts[0], parts[1], parts[2], parts[3]
+	if !b.devices[device] {
+		return Command{}, fmt.Errorf("device %q is not bridged", device)
+	}
+	actions, ok := componentActions[typ]
+	if !ok {
+		return Command{}, fmt.Errorf("%s components cannot be controlled", typ)
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id < 0 {
+		return Command{}, fmt.Errorf("invalid component id %q", idStr)
+	}
+
+	cmd := Command{Device: device, Component: typ, ID: id}
+	value := strings.ToLower(strings.TrimSpace(string(payload)))
+
+	switch verb {
+	case "command":
+		if !slices.Contains(actions, value) {
+			return Command{}, fmt.Errorf("%s does not accept %q (want one of %s)", typ, value, strings.Join(actions, ", "))
+		}
+		cmd.Action = value
+	case "position":
+		if typ != "cover" {
+			return Command{}, fmt.Errorf("position only applies to covers")
+		}
+		pos, err := strconv.Atoi(value)
+		if err != nil || pos < 0 || pos > 100 {
+			return Command{}, fmt.Errorf("position must be 0-100, got %q", value)
+		}
+		cmd.Action, cmd.Position = ActionPosition, pos
+	case "set":
+		if typ == "switch" || typ == "cover" {
+			return Command{}, fmt.Errorf("set does not apply to %s; use command", typ)
+		}
+		if err := json.Unmarshal(payload, &cmd.Set); err != nil {
+			return Command{}, fmt.Errorf("invalid set payload: %w", err)
+		}
+		if cmd.Set.RGB != nil && len(cmd.Set.RGB) != 3 {
+			return Command{}, fmt.Errorf("rgb must have 3 values")
+		}
+		if cmd.Set.RGB != nil && typ == "light" {
+			return Command{}, fmt.Errorf("light components have no color")
+		}
+		if cmd.Set.White != nil && typ != "rgbw" {
+			return Command{}, fmt.Errorf("white only applies to rgbw")
+		}
+		cmd.Action = ActionSet
+	default:
+		return Command{}, fmt.Errorf("unknown command verb %q", verb)
+	}
+	return cmd, nil
+}
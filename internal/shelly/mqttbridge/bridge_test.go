@@ -0,0 +1,279 @@
+package mqttbridge
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tj-smith47/shelly-go/events"
+)
+
+type message struct {
+	topic   string
+	payload string
+	retain  bool
+}
+
+type fakePublisher struct {
+	mu   sync.Mutex
+	msgs []message
+}
+
+func (p *fakePublisher) Publish(topic string, payload []byte, retain bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.msgs = append(p.msgs, message{topic, string(payload), retain})
+	return nil
+}
+
+func (p *fakePublisher) take() map[string]message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make(map[string]message, len(p.msgs))
+	for _, m := range p.msgs {
+		out[m.topic] = m
+	}
+	p.msgs = nil
+	return out
+}
+
+func TestHandle_FullStatusGen2(t *testing.T) {
+	t.Parallel()
+	pub := &fakePublisher{}
+	b := New("home/", []string{"kitchen"}, pub)
+
+	b.Handle(events.NewFullStatusEvent("kitchen", json.RawMessage(
+		`{"switch:0":{"id":0,"output":true,"apower":12.5},"sys":{"uptime":10}}`)))
+
+	got := pub.take()
+	if m := got["home/kitchen/online"]; m.payload != "true" || !m.retain {
+		t.Errorf("online = %+v", m)
+	}
+	m, ok := got["home/kitchen/switch/0"]
+	if !ok || !m.retain {
+		t.Fatalf("switch:0 not published retained: %v", got)
+	}
+	if !strings.Contains(m.payload, `"output":true`) {
+		t.Errorf("switch:0 payload = %s", m.payload)
+	}
+	if len(got) != 2 {
+		t.Errorf("published %d topics, want 2 (sys has no id): %v", len(got), got)
+	}
+
+	// Same status again publishes nothing.
+	b.Handle(events.NewFullStatusEvent("kitchen", json.RawMessage(
+		`{"switch:0":{"id":0,"output":true,"apower":12.5},"sys":{"uptime":11}}`)))
+	if got := pub.take(); len(got) != 0 {
+		t.Errorf("unchanged status republished: %v", got)
+	}
+}
+
+func TestHandle_FullStatusGen1(t *testing.T) {
+	t.Parallel()
+	pub := &fakePublisher{}
+	b := New("", []string{"porch"}, pub)
+
+	b.Handle(events.NewFullStatusEvent("porch", json.RawMessage(
+		`{"relays":[{"ison":true}],"inputs":[{"input":0}],"rollers":[{"state":"stop","current_pos":40}]}`)))
+
+	got := pub.take()
+	for _, topic := range []string{"shelly/porch/switch/0", "shelly/porch/input/0", "shelly/porch/cover/0"} {
+		if _, ok := got[topic]; !ok {
+			t.Errorf("%s not published: %v", topic, got)
+		}
+	}
+	if _, ok := got["shelly/porch/relays"]; ok {
+		t.Error("Gen1 field published as a component")
+	}
+}
+
+func TestHandle_CoIoT(t *testing.T) {
+	t.Parallel()
+	pub := &fakePublisher{}
+	b := New("", []string{"porch"}, pub)
+
+	b.Handle(events.NewFullStatusEvent("porch", json.RawMessage(
+		`{"sensors":{"1101":1,"4101":35.5,"2101":0}}`)))
+
+	got := pub.take()
+	m, ok := got["shelly/porch/switch/0"]
+	if !ok || !strings.Contains(m.payload, `"output":true`) || !strings.Contains(m.payload, `"apower":35.5`) {
+		t.Errorf("switch:0 = %+v", m)
+	}
+	if m := got["shelly/porch/input/0"]; m.payload != `{"state":false}` {
+		t.Errorf("input:0 = %+v", m)
+	}
+}
+
+func TestHandle_StatusChangeMerges(t *testing.T) {
+	t.Parallel()
+	pub := &fakePublisher{}
+	b := New("", []string{"kitchen"}, pub)
+
+	b.Handle(events.NewFullStatusEvent("kitchen", json.RawMessage(`{"switch:0":{"id":0,"output":false,"apower":0}}`)))
+	pub.take()
+
+	b.Handle(events.NewStatusChangeEvent("kitchen", "switch:0", json.RawMessage(`{"output":true}`)))
+	got := pub.take()
+	m, ok := got["shelly/kitchen/switch/0"]
+	if !ok {
+		t.Fatalf("change not published: %v", got)
+	}
+	var status map[string]any
+	if err := json.Unmarshal([]byte(m.payload), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status["output"] != true || status["apower"] != 0.0 || status["id"] != 0.0 {
+		t.Errorf("merged status = %v", status)
+	}
+
+	b.Handle(events.NewStatusChangeEvent("kitchen", "switch:0", json.RawMessage(`{"output":true}`)))
+	if got := pub.take(); len(got) != 0 {
+		t.Errorf("no-op change republished: %v", got)
+	}
+}
+
+func TestHandle_NotifyAndAvailability(t *testing.T) {
+	t.Parallel()
+	pub := &fakePublisher{}
+	b := New("", []string{"kitchen"}, pub)
+
+	b.Handle(events.NewNotifyEvent("kitchen", "input:0", "single_push"))
+	got := pub.take()
+	if m := got["shelly/kitchen/input/0/event"]; m.payload != "single_push" || m.retain {
+		t.Errorf("event = %+v", m)
+	}
+
+	b.Handle(events.NewDeviceOfflineEvent("kitchen"))
+	b.Handle(events.NewDeviceOfflineEvent("kitchen"))
+	b.Handle(events.NewDeviceOnlineEvent("kitchen"))
+	pub.mu.Lock()
+	msgs := pub.msgs
+	pub.mu.Unlock()
+	if len(msgs) != 2 || msgs[0].payload != "false" || msgs[1].payload != "true" {
+		t.Errorf("availability messages = %+v", msgs)
+	}
+
+	pub.take()
+	b.Handle(events.NewDeviceOnlineEvent("garage"))
+	if got := pub.take(); len(got) != 0 {
+		t.Errorf("unbridged device published: %v", got)
+	}
+}
+
+func TestStateTopic(t *testing.T) {
+	t.Parallel()
+	if got := StateTopic(""); got != "shelly/bridge/state" {
+		t.Errorf("StateTopic(\"\") = %q", got)
+	}
+	if got := StateTopic("home/shelly/"); got != "home/shelly/bridge/state" {
+		t.Errorf("StateTopic(home/shelly/) = %q", got)
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	t.Parallel()
+	b := New("home", []string{"kitchen"}, nil)
+
+	tests := []struct {
+		name    string
+		topic   string
+		payload string
+		want    Command
+		wantErr string
+	}{
+		{"switch on", "home/kitchen/switch/0/command", "ON", Command{Device: "kitchen", Component: "switch", ID: 0, Action: ActionOn}, ""},
+		{"cover stop", "home/kitchen/cover/1/command", "stop", Command{Device: "kitchen", Component: "cover", ID: 1, Action: ActionStop}, ""},
+		{"cover position", "home/kitchen/cover/0/position", "45", Command{Device: "kitchen", Component: "cover", Action: ActionPosition, Position: 45}, ""},
+		{"wrong base", "other/kitchen/switch/0/command", "on", Command{}, "unexpected topic"},
+		{"unknown device", "home/garage/switch/0/command", "on", Command{}, "not bridged"},
+		{"read-only component", "home/kitchen/input/0/command", "on", Command{}, "cannot be controlled"},
+		{"bad id", "home/kitchen/switch/x/command", "on", Command{}, "invalid component id"},
+		{"switch open", "home/kitchen/switch/0/command", "open", Command{}, "does not accept"},
+		{"position range", "home/kitchen/cover/0/position", "120", Command{}, "0-100"},
+		{"switch position", "home/kitchen/switch/0/position", "10", Command{}, "only applies to covers"},
+		{"switch set", "home/kitchen/switch/0/set", `{"on":true}`, Command{}, "use command"},
+		{"light rgb", "home/kitchen/light/0/set", `{"rgb":[1,2,3]}`, Command{}, "no color"},
+		{"rgb white", "home/kitchen/rgb/0/set", `{"white":10}`, Command{}, "only applies to rgbw"},
+		{"rgb length", "home/kitchen/rgbw/0/set", `{"rgb":[1,2]}`, Command{}, "3 values"},
+		{"unknown verb", "home/kitchen/switch/0/toggle", "", Command{}, "unknown command verb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := b.ParseCommand(tt.topic, []byte(tt.payload))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCommand() error = %v", err)
+			}
+			if got.Device != tt.want.Device || got.Component != tt.want.Component || got.ID != tt.want.ID ||
+				got.Action != tt.want.Action || got.Position != tt.want.Position {
+				t.Errorf("ParseCommand() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommand_Set(t *testing.T) {
+	t.Parallel()
+	b := New("", []string{"strip"}, nil)
+
+	got, err := b.ParseCommand("shelly/strip/rgbw/0/set", []byte(`{"on":true,"brightness":50,"rgb":[255,0,0],"white":20}`))
+	if err != nil {
+		t.Fatalf("ParseCommand() error = %v", err)
+	}
+	if got.Action != ActionSet || got.Set.On == nil || !*got.Set.On || got.Set.Brightness == nil || *got.Set.Brightness != 50 {
+		t.Errorf("Set = %+v", got.Set)
+	}
+	if len(got.Set.RGB) != 3 || got.Set.RGB[0] != 255 || got.Set.White == nil || *got.Set.White != 20 {
+		t.Errorf("Set = %+v", got.Set)
+	}
+}
+
+func TestNormalizeCoIoT(t *testing.T) {
+	t.Parallel()
+
+	status := map[string]any{"sensors": map[string]any{
+		"1101": 1.0, "4101": 20.0, "4103": 120.0, // relay 0 with meter
+		"1201": 0.0,                  // relay 1
+		"1102": "stop", "1103": 55.0, // roller state on channel 0 takes precedence
+		"2101": 1.0,
+		"3101": 21.5, "3103": 40.0, "3106": 300.0, "3111": 88.0,
+		"9999": 1.0, "junk": 1.0,
+	}}
+	got := NormalizeCoIoT(status)
+
+	cover, _ := got["cover:0"].(map[string]any)
+	if cover["state"] != "stopped" || cover["current_pos"] != 55.0 || cover["apower"] != 20.0 {
+		t.Errorf("cover:0 = %v", cover)
+	}
+	if energy, _ := cover["aenergy"].(map[string]any); energy["total"] != 2.0 {
+		t.Errorf("cover:0.aenergy = %v", cover["aenergy"])
+	}
+	if sw1, _ := got["switch:1"].(map[string]any); sw1["output"] != false {
+		t.Errorf("switch:1 = %v", sw1)
+	}
+	if in, _ := got["input:0"].(map[string]any); in["state"] != true {
+		t.Errorf("input:0 = %v", in)
+	}
+	if temp, _ := got["temperature:0"].(map[string]any); temp["tC"] != 21.5 {
+		t.Errorf("temperature:0 = %v", temp)
+	}
+	if dp, _ := got["devicepower:0"].(map[string]any); dp["battery"] == nil {
+		t.Errorf("devicepower:0 = %v", dp)
+	}
+	if len(got) != 7 {
+		t.Errorf("got %d components, want 7: %v", len(got), got)
+	}
+
+	light := NormalizeCoIoT(map[string]any{"sensors": map[string]any{"1101": 1.0, "5101": 70.0}})
+	if l, _ := light["light:0"].(map[string]any); l["output"] != true || l["brightness"] != 70.0 {
+		t.Errorf("light:0 = %v", light)
+	}
+}
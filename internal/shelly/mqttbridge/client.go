@@ -0,0 +1,119 @@
+package mqttbridge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/mqttclient"
+)
+
+// publishTimeout bounds each publish so a stalled broker can't block the
+// event stream.
+const publishTimeout = 10 * time.Second
+
+// Client is a reconnecting broker connection that publishes the bridge's
+// state topic as a retained online/offline last will. Subscriptions are
+// renewed on every reconnect, since the session is clean.
+type Client struct {
+	client     mqtt.Client
+	qos        byte
+	stateTopic string
+
+	mu   sync.Mutex
+	subs []subscription
+}
+
+type subscription struct {
+	filters map[string]byte
+	handler mqtt.MessageHandler
+}
+
+// Connect connects to the broker. stateTopic is set to "offline" by the
+// broker when the connection drops, and to "online" on every (re)connect.
+func Connect(ctx context.Context, cfg mqttclient.BrokerConfig, stateTopic string) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := &Client{qos: cfg.QoS, stateTopic: stateTopic}
+	opts := cfg.ClientOptions("shelly-cli-bridge").
+		SetAutoReconnect(true).
+		SetConnectRetry(false).
+		SetWill(stateTopic, "offline", cfg.QoS, true).
+		SetOnConnectHandler(c.onConnect)
+
+	c.client = mqtt.NewClient(opts)
+	if err := mqttclient.WaitToken(ctx, c.client.Connect()); err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", cfg.Broker, err)
+	}
+	return c, nil
+}
+
+// onConnect marks the bridge online and restores subscriptions, which a
+// clean session loses on reconnect.
+func (c *Client) onConnect(client mqtt.Client) {
+	client.Publish(c.stateTopic, c.qos, true, "online")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, sub := range c.subs {
+		client.SubscribeMultiple(sub.filters, sub.handler)
+	}
+}
+
+// Publish implements Publisher.
+func (c *Client) Publish(topic string, payload []byte, retain bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	return mqttclient.WaitToken(ctx, c.client.Publish(topic, c.qos, retain, payload))
+}
+
+// Subscribe delivers messages matching filters to handler, including after
+// reconnects. Handlers run on the client's delivery goroutine and should
+// not block.
+func (c *Client) Subscribe(ctx context.Context, filters []string, handler func(topic string, payload []byte)) error {
+	sub := subscription{
+		filters: make(map[string]byte, len(filters)),
+		handler: func(_ mqtt.Client, msg mqtt.Message) {
+			handler(msg.Topic(), msg.Payload())
+		},
+	}
+	for _, f := range filters {
+		sub.filters[f] = c.qos
+	}
+
+	c.mu.Lock()
+	c.subs = append(c.subs, sub)
+	c.mu.Unlock()
+	return mqttclient.WaitToken(ctx, c.client.SubscribeMultiple(sub.filters, sub.handler))
+}
+
+// Close marks the bridge offline and disconnects.
+func (c *Client) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = mqttclient.WaitToken(ctx, c.client.Publish(c.stateTopic, c.qos, true, "offline")) //nolint:errcheck // best effort on shutdown
+	c.client.Disconnect(250)
+}
+
+// ServeCommands subscribes to the bridge's command topics on client and
+// runs each decoded command with exec in its own goroutine. report is
+// called, possibly concurrently, with every command and its outcome;
+// messages that fail to decode are reported as a zero Command and the
+// decode error.
+func (b *Bridge) ServeCommands(ctx context.Context, client *Client, exec Executor, report func(Command, error)) error {
+	return client.Subscribe(ctx, b.CommandFilters(), func(topic string, payload []byte) {
+		cmd, err := b.ParseCommand(topic, payload)
+		if err != nil {
+			report(cmd, fmt.Errorf("%s: %w", topic, err))
+			return
+		}
+		go func() {
+			report(cmd, exec.Execute(ctx, cmd))
+		}()
+	})
+}
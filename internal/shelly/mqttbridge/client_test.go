@@ -0,0 +1,58 @@
+package mqttbridge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/mqttclient"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/mqtttest"
+)
+
+func TestClient_ResubscribesOnReconnect(t *testing.T) {
+	t.Parallel()
+	broker := mqtttest.NewBroker(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := Connect(ctx, mqttclient.BrokerConfig{Broker: broker.URL, QoS: 1}, "shelly/bridge/state")
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer c.Close()
+
+	<-broker.Connected
+	if err := c.Subscribe(ctx, []string{"shelly/+/set"}, func(string, []byte) {}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	waitSubscribe(t, broker, "shelly/+/set")
+
+	broker.DropConnections()
+	select {
+	case <-broker.Connected:
+	case <-ctx.Done():
+		t.Fatal("client did not reconnect")
+	}
+	// Drop subscriptions from the first connection still in the channel.
+	for len(broker.Subscribed) > 0 {
+		<-broker.Subscribed
+	}
+	waitSubscribe(t, broker, "shelly/+/set")
+}
+
+func waitSubscribe(t *testing.T, broker *mqtttest.Broker, topic string) {
+	t.Helper()
+	for {
+		select {
+		case sub := <-broker.Subscribed:
+			for _, got := range sub.Topics {
+				if got == topic {
+					return
+				}
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("no SUBSCRIBE to %s", topic)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package mqttbridge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NormalizeCoIoT maps the sensor values of a CoIoT status (as published by
+// EventStream for Gen1 devices) to Gen2-style components. CoIoT v2 sensor
+// IDs encode the value type, channel and property as TCPP:
+//
+//	1C01 output        → switch:C-1 output (light:C-1 when it has brightness)
+//	1C02 / 1C03        → cover:C-1 state / current_pos
+//	2C01 input         → input:C-1 state
+//	4C01 / 4C03        → apower / aenergy.total (Wh) of the channel's output
+//	5C01 brightness    → light:C-1 brightness
+//	3101, 3103, 3106, 3111 → temperature, humidity, illuminance, devicepower
+//
+// Unknown IDs are ignored.
+func NormalizeCoIoT(status map[string]any) map[string]any {
+	sensors, _ := status["sensors"].(map[string]any) //nolint:errcheck // nil when absent
+	type channel struct {
+		output, brightness, power, energy any
+		rollerState, rollerPos            any
+		hasOutput, hasLight, hasRoller    bool
+		hasPower, hasEnergy               bool
+	}
+	channels := make(map[int]*channel)
+	get := func(ch int) *channel {
+		c, ok := channels[ch]
+		if !ok {
+			c = &channel{}
+			channels[ch] = c
+		}
+		return c
+	}
+
+	out := make(map[string]any)
+	for key, v := range sensors {
+		id := key
+		if _, after, ok := strings.Cut(key, "_"); ok {
+			id = after
+		}
+		n, err := strconv.Atoi(id)
+		if err != nil || n < 1000 || n > 9999 {
+			continue
+		}
+		typ, ch, prop := n/1000, (n/100)%10-1, n%100
+		if ch < 0 {
+			continue
+		}
+
+		switch {
+		case typ == 1 && prop == 1:
+			c := get(ch)
+			c.output, c.hasOutput = v, true
+		case typ == 1 && prop == 2:
+			c := get(ch)
+			c.rollerState, c.hasRoller = coiotRollerState(v), true
+		case typ == 1 && prop == 3:
+			c := get(ch)
+			c.rollerPos, c.hasRoller = v, true
+		case typ == 2 && prop == 1:
+			out[fmt.Sprintf("input:%d", ch)] = map[string]any{"state": truthy(v)}
+		case typ == 4 && prop == 1:
+			c := get(ch)
+			c.power, c.hasPower = v, true
+		case typ == 4 && prop == 3:
+			c := get(ch)
+			if f, ok := v.(float64); ok {
+				c.energy, c.hasEnergy = f/60, true // watt-minutes
+			}
+		case typ == 5 && prop == 1:
+			c := get(ch)
+			c.brightness, c.hasLight = v, true
+		case n == 3101:
+			out["temperature:0"] = map[string]any{"tC": v}
+		case n == 3103:
+			out["humidity:0"] = map[string]any{"rh": v}
+		case n == 3106:
+			out["illuminance:0"] = map[string]any{"lux": v}
+		case n == 3111:
+			out["devicepower:0"] = map[string]any{"battery": map[string]any{"percent": v}}
+		}
+	}
+
+	for ch, c := range channels {
+		comp := make(map[string]any)
+		if c.hasPower {
+			comp["apower"] = c.power
+		}
+		if c.hasEnergy {
+			comp["aenergy"] = map[string]any{"total": c.energy}
+		}
+		switch {
+		case c.hasRoller:
+			if c.rollerState != nil {
+				comp["state"] = c.rollerState
+			}
+			if c.rollerPos != nil {
+				comp["current_pos"] = c.rollerPos
+			}
+			out[fmt.Sprintf("cover:%d", ch)] = comp
+		case c.hasLight:
+			comp["brightness"] = c.brightness
+			if c.hasOutput {
+				comp["output"] = truthy(c.output)
+			}
+			out[fmt.Sprintf("light:%d", ch)] = comp
+		case c.hasOutput:
+			comp["output"] = truthy(c.output)
+			out[fmt.Sprintf("switch:%d", ch)] = comp
+		}
+	}
+	return out
+}
+
+// coiotRollerState maps Gen1 roller states to Gen2 cover states.
+func coiotRollerState(v any) any {
+	switch v {
+	case "open":
+		return "opening"
+	case "close":
+		return "closing"
+	case "stop":
+		return "stopped"
+	}
+	return v
+}
+
+func truthy(v any) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case string:
+		return t == "1" || t == "on" || t == "true"
+	}
+	return false
+}
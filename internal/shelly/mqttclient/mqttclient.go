@@ -0,0 +1,59 @@
+// Package mqttclient holds the MQTT broker connection settings and helpers
+// shared by the commands that talk to a broker.
+package mqttclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// BrokerConfig holds an MQTT broker connection.
+type BrokerConfig struct {
+	Broker   string // e.g. tcp://localhost:1883
+	Username string
+	Password string
+	ClientID string // default <prefix>-<unix nanos>, see ClientOptions
+	QoS      byte
+}
+
+// Validate checks that a broker is set and the QoS is valid.
+func (c BrokerConfig) Validate() error {
+	if c.Broker == "" {
+		return fmt.Errorf("broker is required")
+	}
+	if c.QoS > 2 {
+		return fmt.Errorf("invalid qos %d", c.QoS)
+	}
+	return nil
+}
+
+// ClientOptions returns client options for the broker with credentials set.
+// Without a configured client ID, one is derived from idPrefix. Reconnect
+// behavior is left to the caller.
+func (c BrokerConfig) ClientOptions(idPrefix string) *mqtt.ClientOptions {
+	clientID := c.ClientID
+	if clientID == "" {
+		clientID = fmt.Sprintf("%s-%d", idPrefix, time.Now().UnixNano())
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker(c.Broker).
+		SetClientID(clientID)
+	if c.Username != "" {
+		opts.SetUsername(c.Username)
+		opts.SetPassword(c.Password)
+	}
+	return opts
+}
+
+// WaitToken waits for tok to complete or ctx to end.
+func WaitToken(ctx context.Context, tok mqtt.Token) error {
+	select {
+	case <-tok.Done():
+		return tok.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
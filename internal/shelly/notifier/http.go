@@ -0,0 +1,106 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+)
+
+// sendNtfy publishes to an ntfy topic URL (e.g. https://ntfy.sh/my-topic).
+func sendNtfy(ctx context.Context, n config.Notifier, msg Message) error {
+	title, body, err := Render(n, msg)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{"Title": title}
+	if n.Priority > 0 {
+		headers["Priority"] = strconv.Itoa(n.Priority)
+	}
+	if n.Token != "" {
+		headers["Authorization"] = "Bearer " + n.Token
+	}
+	return post(ctx, n.URL, "text/plain; charset=utf-8", []byte(body), headers)
+}
+
+// sendGotify posts to a Gotify server's /message endpoint.
+func sendGotify(ctx context.Context, n config.Notifier, msg Message) error {
+	title, body, err := Render(n, msg)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"title":    title,
+		"message":  body,
+		"priority": n.Priority,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal gotify message: %w", err)
+	}
+
+	url := strings.TrimRight(n.URL, "/") + "/message"
+	return post(ctx, url, "application/json", payload, map[string]string{"X-Gotify-Key": n.Token})
+}
+
+// sendChat posts to a Slack, Mattermost or Discord incoming webhook.
+func sendChat(ctx context.Context, n config.Notifier, msg Message) error {
+	title, body, err := Render(n, msg)
+	if err != nil {
+		return err
+	}
+
+	var payload map[string]string
+	switch n.Type {
+	case TypeDiscord:
+		payload = map[string]string{"content": fmt.Sprintf("**%s**\n%s", title, body)}
+	case TypeMattermost:
+		payload = map[string]string{"text": fmt.Sprintf("**%s**\n%s", title, body)}
+	default:
+		payload = map[string]string{"text": fmt.Sprintf("*%s*\n%s", title, body)}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal %s message: %w", n.Type, err)
+	}
+	return post(ctx, n.URL, "application/json", data, nil)
+}
+
+// post sends an HTTP POST and treats any non-2xx response as an error.
+func post(ctx context.Context, url, contentType string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		if v != "" {
+			req.Header.Set(k, v)
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			iostreams.DebugErr("closing notifier response body", cerr)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512)) //nolint:errcheck // best-effort error detail
+		return fmt.Errorf("%s returned %s: %s", url, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/mqttclient"
+)
+
+// sendMQTT publishes msg to the notifier's topic. The topic is a template; the
+// payload is the rendered body when a template is set, otherwise the message
+// as JSON.
+func sendMQTT(ctx context.Context, n config.Notifier, msg Message) error {
+	cfg := n.MQTT
+
+	topic, err := render("topic", cfg.Topic, "", msg)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	if n.Template != "" {
+		_, body, err := Render(n, msg)
+		if err != nil {
+			return err
+		}
+		payload = []byte(body)
+	} else if payload, err = json.Marshal(msg); err != nil {
+		return fmt.Errorf("marshal mqtt message: %w", err)
+	}
+
+	broker := mqttclient.BrokerConfig{Broker: cfg.Broker, Username: cfg.Username, Password: cfg.Password, QoS: cfg.QoS}
+	opts := broker.ClientOptions("shelly-cli-notify").
+		SetAutoReconnect(false).
+		SetConnectRetry(false)
+
+	client := mqtt.NewClient(opts)
+	if err := mqttclient.WaitToken(ctx, client.Connect()); err != nil {
+		return fmt.Errorf("connect to %s: %w", cfg.Broker, err)
+	}
+	defer client.Disconnect(250)
+
+	if err := mqttclient.WaitToken(ctx, client.Publish(topic, cfg.QoS, cfg.Retain, payload)); err != nil {
+		return fmt.Errorf("publish to %s: %w", topic, err)
+	}
+	return nil
+}
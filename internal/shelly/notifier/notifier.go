@@ -0,0 +1,151 @@
+// Package notifier delivers alert notifications to external services.
+//
+// Notifiers are configured once under the "notifiers:" config section and
+// referenced from alerts with the "notifier:NAME" action. Each notifier has a
+// type that selects its transport:
+//
+//	smtp        email via an SMTP relay
+//	ntfy        push via an ntfy topic URL
+//	gotify      push via a Gotify server
+//	slack       Slack incoming webhook
+//	discord     Discord webhook
+//	mattermost  Mattermost incoming webhook
+//	mqtt        publish to an MQTT topic
+//
+// Titles and bodies are Go templates rendered against a Message.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// Notifier types.
+const (
+	TypeSMTP       = "smtp"
+	TypeNtfy       = "ntfy"
+	TypeGotify     = "gotify"
+	TypeSlack      = "slack"
+	TypeDiscord    = "discord"
+	TypeMattermost = "mattermost"
+	TypeMQTT       = "mqtt"
+)
+
+// Default templates used when a notifier does not set its own.
+const (
+	DefaultTitle    = "{{.Alert}} {{.State}} on {{.Device}}"
+	DefaultTemplate = "{{.Condition}} (value: {{.Value}})"
+)
+
+// sendTimeout bounds a single delivery attempt.
+const sendTimeout = 30 * time.Second
+
+// Types returns the supported notifier types.
+func Types() []string {
+	return []string{TypeSMTP, TypeNtfy, TypeGotify, TypeSlack, TypeDiscord, TypeMattermost, TypeMQTT}
+}
+
+// Message is the data available to notifier templates.
+type Message struct {
+	Alert     string    `json:"alert"`
+	Device    string    `json:"device"`
+	Condition string    `json:"condition"`
+	State     string    `json:"state"` // firing, repeat, resolved or test
+	Value     string    `json:"value"`
+	Time      time.Time `json:"timestamp"`
+}
+
+// Validate checks that a notifier has the settings its type requires and that
+// its templates parse.
+func Validate(n config.Notifier) error {
+	if !slices.Contains(Types(), n.Type) {
+		return fmt.Errorf("unknown notifier type %q (want one of %s)", n.Type, strings.Join(Types(), ", "))
+	}
+
+	switch n.Type {
+	case TypeSMTP:
+		if n.SMTP == nil || n.SMTP.Host == "" || n.SMTP.From == "" || len(n.SMTP.To) == 0 {
+			return errors.New("smtp notifier requires smtp.host, smtp.from and smtp.to")
+		}
+	case TypeMQTT:
+		if n.MQTT == nil || n.MQTT.Broker == "" || n.MQTT.Topic == "" {
+			return errors.New("mqtt notifier requires mqtt.broker and mqtt.topic")
+		}
+		if n.MQTT.QoS > 2 {
+			return fmt.Errorf("mqtt qos must be 0, 1 or 2, got %d", n.MQTT.QoS)
+		}
+	default:
+		if n.URL == "" {
+			return fmt.Errorf("%s notifier requires url", n.Type)
+		}
+	}
+
+	if _, _, err := Render(n, Message{}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Render renders the notifier's title and body templates for msg.
+func Render(n config.Notifier, msg Message) (title, body string, err error) {
+	title, err = render("title", n.Title, DefaultTitle, msg)
+	if err != nil {
+		return "", "", err
+	}
+	body, err = render("template", n.Template, DefaultTemplate, msg)
+	if err != nil {
+		return "", "", err
+	}
+	return title, body, nil
+}
+
+func render(name, text, fallback string, msg Message) (string, error) {
+	if text == "" {
+		text = fallback
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse %s template: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, msg); err != nil {
+		return "", fmt.Errorf("render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// Send delivers msg through the notifier.
+func Send(ctx context.Context, n config.Notifier, msg Message) error {
+	if err := Validate(n); err != nil {
+		return err
+	}
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	switch n.Type {
+	case TypeSMTP:
+		return sendSMTP(ctx, n, msg)
+	case TypeNtfy:
+		return sendNtfy(ctx, n, msg)
+	case TypeGotify:
+		return sendGotify(ctx, n, msg)
+	case TypeSlack, TypeMattermost, TypeDiscord:
+		return sendChat(ctx, n, msg)
+	case TypeMQTT:
+		return sendMQTT(ctx, n, msg)
+	default:
+		return fmt.Errorf("unknown notifier type %q", n.Type)
+	}
+}
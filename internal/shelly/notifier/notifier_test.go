@@ -0,0 +1,336 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/testutil/mqtttest"
+)
+
+var testMessage = Message{
+	Alert:     "high-power",
+	Device:    "heater",
+	Condition: "power>2000",
+	State:     "firing",
+	Value:     "2150",
+	Time:      time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+}
+
+type capturedRequest struct {
+	path    string
+	headers http.Header
+	body    string
+}
+
+// captureServer starts an HTTP stand-in that records the first request.
+func captureServer(t *testing.T) (*httptest.Server, <-chan capturedRequest) {
+	t.Helper()
+	ch := make(chan capturedRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read body: %v", err)
+		}
+		ch <- capturedRequest{path: r.URL.Path, headers: r.Header, body: string(body)}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, ch
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		n       config.Notifier
+		wantErr bool
+	}{
+		{"ntfy", config.Notifier{Type: TypeNtfy, URL: "https://ntfy.sh/x"}, false},
+		{"slack", config.Notifier{Type: TypeSlack, URL: "https://hooks.slack.com/x"}, false},
+		{"smtp", config.Notifier{Type: TypeSMTP, SMTP: &config.SMTPNotifier{Host: "mail", From: "a@b", To: []string{"c@d"}}}, false},
+		{"mqtt", config.Notifier{Type: TypeMQTT, MQTT: &config.MQTTNotifier{Broker: "tcp://b:1883", Topic: "t"}}, false},
+		{"unknown type", config.Notifier{Type: "pager", URL: "x"}, true},
+		{"missing url", config.Notifier{Type: TypeGotify}, true},
+		{"missing smtp", config.Notifier{Type: TypeSMTP}, true},
+		{"missing mqtt topic", config.Notifier{Type: TypeMQTT, MQTT: &config.MQTTNotifier{Broker: "tcp://b:1883"}}, true},
+		{"bad qos", config.Notifier{Type: TypeMQTT, MQTT: &config.MQTTNotifier{Broker: "tcp://b", Topic: "t", QoS: 3}}, true},
+		{"bad template", config.Notifier{Type: TypeNtfy, URL: "x", Template: "{{.Nope"}, true},
+		{"unknown field", config.Notifier{Type: TypeNtfy, URL: "x", Title: "{{.Nope}}"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := Validate(tt.n); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	title, body, err := Render(config.Notifier{}, testMessage)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if title != "high-power firing on heater" {
+		t.Errorf("title = %q", title)
+	}
+	if body != "power>2000 (value: 2150)" {
+		t.Errorf("body = %q", body)
+	}
+
+	_, body, err = Render(config.Notifier{Template: "{{.Device}}={{.Value}} at {{.Time.Format \"15:04\"}}"}, testMessage)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if body != "heater=2150 at 12:00" {
+		t.Errorf("custom body = %q", body)
+	}
+}
+
+func TestSend_Ntfy(t *testing.T) {
+	t.Parallel()
+	srv, ch := captureServer(t)
+
+	n := config.Notifier{Type: TypeNtfy, URL: srv.URL + "/alerts", Token: "tk", Priority: 4}
+	if err := Send(context.Background(), n, testMessage); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	req := <-ch
+	if req.path != "/alerts" || req.body != "power>2000 (value: 2150)" {
+		t.Errorf("request = %+v", req)
+	}
+	if req.headers.Get("Title") != "high-power firing on heater" || req.headers.Get("Priority") != "4" {
+		t.Errorf("headers = %v", req.headers)
+	}
+	if req.headers.Get("Authorization") != "Bearer tk" {
+		t.Errorf("Authorization = %q", req.headers.Get("Authorization"))
+	}
+}
+
+func TestSend_Gotify(t *testing.T) {
+	t.Parallel()
+	srv, ch := captureServer(t)
+
+	n := config.Notifier{Type: TypeGotify, URL: srv.URL + "/", Token: "app-token", Priority: 8}
+	if err := Send(context.Background(), n, testMessage); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	req := <-ch
+	if req.path != "/message" || req.headers.Get("X-Gotify-Key") != "app-token" {
+		t.Errorf("request = %+v", req)
+	}
+	var payload struct {
+		Title    string `json:"title"`
+		Message  string `json:"message"`
+		Priority int    `json:"priority"`
+	}
+	if err := json.Unmarshal([]byte(req.body), &payload); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if payload.Title != "high-power firing on heater" || payload.Priority != 8 {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestSend_Chat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		typ   string
+		field string
+		want  string
+	}{
+		{TypeSlack, "text", "*high-power firing on heater*\npower>2000 (value: 2150)"},
+		{TypeMattermost, "text", "**high-power firing on heater**\npower>2000 (value: 2150)"},
+		{TypeDiscord, "content", "**high-power firing on heater**\npower>2000 (value: 2150)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			t.Parallel()
+			srv, ch := captureServer(t)
+
+			if err := Send(context.Background(), config.Notifier{Type: tt.typ, URL: srv.URL}, testMessage); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+
+			var payload map[string]string
+			if err := json.Unmarshal([]byte((<-ch).body), &payload); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if payload[tt.field] != tt.want {
+				t.Errorf("%s = %q, want %q", tt.field, payload[tt.field], tt.want)
+			}
+		})
+	}
+}
+
+func TestSend_HTTPError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+	}))
+	t.Cleanup(srv.Close)
+
+	err := Send(context.Background(), config.Notifier{Type: TypeSlack, URL: srv.URL}, testMessage)
+	if err == nil || !strings.Contains(err.Error(), "invalid token") {
+		t.Errorf("Send() error = %v, want 401 detail", err)
+	}
+}
+
+// smtpServer starts a minimal SMTP stand-in that records the DATA section.
+func smtpServer(t *testing.T) (host string, port int, data <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() }) //nolint:errcheck // test cleanup
+
+	ch := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }() //nolint:errcheck // test cleanup
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) } //nolint:errcheck // test server
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				reply("250 OK")
+			case "DATA":
+				reply("354 go ahead")
+				var b strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					b.WriteString(l)
+				}
+				ch <- b.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, ch
+}
+
+func TestSend_SMTP(t *testing.T) {
+	t.Parallel()
+	host, port, data := smtpServer(t)
+
+	n := config.Notifier{
+		Type:  TypeSMTP,
+		Title: "[shelly] {{.Alert}}",
+		SMTP:  &config.SMTPNotifier{Host: host, Port: port, From: "shelly@home", To: []string{"ops@home"}},
+	}
+	if err := Send(context.Background(), n, testMessage); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	mail := <-data
+	for _, want := range []string{"From: shelly@home", "To: ops@home", "Subject: [shelly] high-power", "power>2000 (value: 2150)"} {
+		if !strings.Contains(mail, want) {
+			t.Errorf("mail missing %q:\n%s", want, mail)
+		}
+	}
+}
+
+func TestBuildMail(t *testing.T) {
+	t.Parallel()
+
+	mail := string(buildMail("a@home", []string{"b@home"}, "Alarm\r\nBcc: x@evil", "one\r\ntwo\nthree", testMessage.Time))
+	if !strings.Contains(mail, "Subject: Alarm Bcc: x@evil\r\n") {
+		t.Errorf("subject not folded:\n%q", mail)
+	}
+	if !strings.HasSuffix(mail, "\r\n\r\none\r\ntwo\r\nthree\r\n") {
+		t.Errorf("body line endings not normalized:\n%q", mail)
+	}
+
+	mail = string(buildMail("a@home", []string{"b@home"}, "Küche offline", "", testMessage.Time))
+	if !strings.Contains(mail, "Subject: =?utf-8?q?K=C3=BCche_offline?=\r\n") {
+		t.Errorf("subject not RFC 2047 encoded:\n%q", mail)
+	}
+}
+
+func TestSend_MQTT(t *testing.T) {
+	t.Parallel()
+	broker := mqtttest.NewBroker(t)
+
+	n := config.Notifier{
+		Type: TypeMQTT,
+		MQTT: &config.MQTTNotifier{Broker: broker.URL, Topic: "shelly/alerts/{{.Alert}}", Retain: true},
+	}
+	if err := Send(context.Background(), n, testMessage); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	select {
+	case p := <-broker.Published:
+		if p.TopicName != "shelly/alerts/high-power" || !p.Retain {
+			t.Errorf("publish topic=%q retain=%v", p.TopicName, p.Retain)
+		}
+		var got Message
+		if err := json.Unmarshal(p.Payload, &got); err != nil {
+			t.Fatalf("unmarshal payload: %v", err)
+		}
+		if got.Alert != "high-power" || got.State != "firing" || got.Value != "2150" {
+			t.Errorf("payload = %+v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no PUBLISH received")
+	}
+}
+
+func TestSend_MQTTUnreachable(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close() //nolint:errcheck // freeing the port on purpose
+
+	n := config.Notifier{
+		Type: TypeMQTT,
+		MQTT: &config.MQTTNotifier{Broker: "tcp://127.0.0.1:" + strconv.Itoa(port), Topic: "t"},
+	}
+	if err := Send(context.Background(), n, testMessage); err == nil {
+		t.Error("Send() expected error for unreachable broker")
+	}
+}
@@ -3,9 +3,11 @@ package shelly
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/tj-smith47/shelly-cli/internal/iostreams"
 	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/tariff"
 )
 
 // deviceInfoResult holds parsed device info from API.
@@ -131,13 +133,22 @@ func (s *Service) GenerateDevicesReport(ctx context.Context, devices map[string]
 	return report
 }
 
-// GenerateEnergyReport generates an energy consumption report.
-func (s *Service) GenerateEnergyReport(ctx context.Context, devices map[string]model.Device) model.DeviceReport {
+// GenerateEnergyReport generates an energy consumption report. With a tariff,
+// today's cost of the devices' energy meters is summarised.
+func (s *Service) GenerateEnergyReport(ctx context.Context, devices map[string]model.Device, t *tariff.Tariff) model.DeviceReport {
 	report := model.NewDeviceReport("energy")
 	var totalPower float64
 	var devicesWithEnergy int
+	var costs []model.EnergyCost
+	now := time.Now()
 
 	for name := range devices {
+		if t != nil {
+			if cost, metered, err := s.DeviceEnergyCost(ctx, name, t, t.StartOfDay(now), now); err == nil && metered {
+				costs = append(costs, cost)
+			}
+		}
+
 		conn, connErr := s.Connect(ctx, name)
 		if connErr != nil {
 			continue
@@ -159,6 +170,24 @@ func (s *Service) GenerateEnergyReport(ctx context.Context, devices map[string]m
 	report.Summary["total_power_w"] = totalPower
 	report.Summary["devices_reporting"] = devicesWithEnergy
 
+	if t != nil {
+		rate := t.RateAt(now, 0)
+		report.Summary["currency"] = t.Currency()
+		report.Summary["rate_period"] = rate.Period
+		report.Summary["rate_per_kwh"] = rate.Rate
+		if len(costs) > 0 {
+			cost := tariff.Sum(t.Currency(), costs...)
+			day := t.StartOfDay(now)
+			tariff.AddStandingCharge(&cost, t.StandingCharge(day, day.AddDate(0, 0, 1)))
+			report.Summary["import_kwh_today"] = cost.ImportKWh
+			report.Summary["export_kwh_today"] = cost.ExportKWh
+			report.Summary["import_cost_today"] = cost.ImportCost
+			report.Summary["export_credit_today"] = cost.ExportCredit
+			report.Summary["standing_charge_today"] = cost.StandingCharge
+			report.Summary["cost_today"] = cost.Total
+		}
+	}
+
 	return report
 }
 
@@ -0,0 +1,70 @@
+package shelly
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rollout"
+)
+
+// rolloutCallTimeout bounds each device call of a rollout so a device that
+// is rebooting into new firmware does not stall the health polls.
+const rolloutCallTimeout = 15 * time.Second
+
+// RunRollout runs a firmware rollout, saving progress to the rollout state
+// file after every device so it can be resumed.
+func (s *Service) RunRollout(ctx context.Context, state *config.RolloutState, notify func(rollout.Event)) error {
+	r := &rollout.Runner{
+		Device: rolloutDevice{svc: s},
+		Notify: notify,
+		Save:   config.SaveRolloutState,
+	}
+	return r.Run(ctx, state)
+}
+
+// rolloutDevice implements rollout.Device with the service.
+type rolloutDevice struct {
+	svc *Service
+}
+
+func (d rolloutDevice) Snapshot(ctx context.Context, name string) (rollout.Snapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, rolloutCallTimeout)
+	defer cancel()
+	status, err := d.svc.DeviceStatusAuto(ctx, name)
+	if err != nil {
+		return rollout.Snapshot{}, err
+	}
+	return rollout.SnapshotFromStatus(status.Info.Firmware, status.Status), nil
+}
+
+func (d rolloutDevice) Update(ctx context.Context, name string, beta bool, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, rolloutCallTimeout)
+	defer cancel()
+	switch {
+	case url != "":
+		return d.svc.UpdateFirmwareFromURL(ctx, name, url)
+	case beta:
+		return d.svc.UpdateFirmwareBeta(ctx, name)
+	default:
+		return d.svc.UpdateFirmwareStable(ctx, name)
+	}
+}
+
+func (d rolloutDevice) Rollback(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, rolloutCallTimeout)
+	defer cancel()
+	return d.svc.RollbackFirmware(ctx, name)
+}
+
+func (d rolloutDevice) Probe(ctx context.Context, name, method string) error {
+	ctx, cancel := context.WithTimeout(ctx, rolloutCallTimeout)
+	defer cancel()
+	if strings.HasPrefix(method, "/") {
+		_, err := d.svc.RawGen1Call(ctx, name, method)
+		return err
+	}
+	_, err := d.svc.RawRPC(ctx, name, method, nil)
+	return err
+}
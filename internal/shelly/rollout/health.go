@@ -0,0 +1,63 @@
+package rollout
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rules"
+)
+
+// Snapshot is the part of a device's state compared before and after an
+// update.
+type Snapshot struct {
+	Firmware   string
+	Components []string // component keys such as "switch:0", sorted
+	Errors     []string // "component: error" entries, sorted
+}
+
+// SnapshotFromStatus builds a snapshot from a device status. Gen1 status is
+// normalized first so relays, inputs, rollers and lights count as
+// components on both generations.
+func SnapshotFromStatus(firmware string, status map[string]any) Snapshot {
+	snap := Snapshot{Firmware: firmware}
+	for key, value := range rules.NormalizeStatus(status) {
+		if strings.Contains(key, ":") {
+			snap.Components = append(snap.Components, key)
+		}
+		comp, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		errs, _ := comp["errors"].([]any)
+		for _, e := range errs {
+			snap.Errors = append(snap.Errors, fmt.Sprintf("%s: %v", key, e))
+		}
+	}
+	sort.Strings(snap.Components)
+	sort.Strings(snap.Errors)
+	return snap
+}
+
+// CheckHealth compares a post-update snapshot with the pre-update one and
+// describes every problem: components that disappeared and errors that
+// were not reported before. It returns nil for a healthy device.
+func CheckHealth(before, after Snapshot) []string {
+	var problems []string
+	var missing []string
+	for _, c := range before.Components {
+		if !slices.Contains(after.Components, c) {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) > 0 {
+		problems = append(problems, "missing components: "+strings.Join(missing, ", "))
+	}
+	for _, e := range after.Errors {
+		if !slices.Contains(before.Errors, e) {
+			problems = append(problems, "new error "+e)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,149 @@
+// Package rollout plans and runs health-gated firmware rollouts: devices are
+// updated in waves, each device is verified after its update, and the
+// rollout halts when too many devices in a wave fail.
+package rollout
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// Wave grouping modes.
+const (
+	WaveByNone  = "none"
+	WaveByModel = "model"
+	WaveByGroup = "group"
+)
+
+// CanaryWave names the first wave of a plan with a canary.
+const CanaryWave = "canary"
+
+// ungrouped names the wave of devices in no group when waving by group.
+const ungrouped = "ungrouped"
+
+// Target is a device to update.
+type Target struct {
+	Name   string
+	Model  string
+	Groups []string
+}
+
+// PlanOpts controls how targets are split into waves.
+type PlanOpts struct {
+	CanaryGroup string // devices in this group form the canary wave
+	CanaryCount int    // otherwise, the first N devices by name do
+	WaveBy      string // WaveByNone, WaveByModel or WaveByGroup
+	WaveSize    int    // max devices per wave; 0 = no limit
+}
+
+// BuildPlan splits targets into waves: an optional canary wave, then the
+// remaining devices grouped by model or device group, each group split
+// into waves of at most WaveSize devices.
+func BuildPlan(targets []Target, opts PlanOpts) ([]config.RolloutWave, error) {
+	sorted := slices.Clone(targets)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var waves []config.RolloutWave
+	rest := sorted
+
+	switch {
+	case opts.CanaryGroup != "":
+		var canary []string
+		rest = nil
+		for _, t := range sorted {
+			if slices.Contains(t.Groups, opts.CanaryGroup) {
+				canary = append(canary, t.Name)
+			} else {
+				rest = append(rest, t)
+			}
+		}
+		if len(canary) == 0 {
+			return nil, fmt.Errorf("canary group %q has no devices to update", opts.CanaryGroup)
+		}
+		waves = append(waves, config.RolloutWave{Name: CanaryWave, Devices: canary})
+	case opts.CanaryCount > 0:
+		n := min(opts.CanaryCount, len(sorted))
+		canary := make([]string, 0, n)
+		for _, t := range sorted[:n] {
+			canary = append(canary, t.Name)
+		}
+		waves = append(waves, config.RolloutWave{Name: CanaryWave, Devices: canary})
+		rest = sorted[n:]
+	}
+
+	buckets, order, err := bucket(rest, opts.WaveBy)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range order {
+		names := buckets[key]
+		chunks := chunk(names, opts.WaveSize)
+		for i, c := range chunks {
+			name := key
+			if len(chunks) > 1 {
+				name = fmt.Sprintf("%s (%d/%d)", key, i+1, len(chunks))
+			}
+			waves = append(waves, config.RolloutWave{Name: name, Devices: c})
+		}
+	}
+	return waves, nil
+}
+
+// bucket groups targets by waveBy and returns the bucket names in order.
+// A device in several groups goes to the first group by name.
+func bucket(targets []Target, waveBy string) (buckets map[string][]string, order []string, err error) {
+	buckets = make(map[string][]string)
+	for _, t := range targets {
+		var key string
+		switch waveBy {
+		case "", WaveByNone:
+			key = "all"
+		case WaveByModel:
+			key = "model " + t.Model
+			if t.Model == "" {
+				key = "model unknown"
+			}
+		case WaveByGroup:
+			key = ungrouped
+			if len(t.Groups) > 0 {
+				key = "group " + slices.Min(t.Groups)
+			}
+		default:
+			return nil, nil, fmt.Errorf("invalid wave grouping %q (want %s, %s or %s)", waveBy, WaveByNone, WaveByModel, WaveByGroup)
+		}
+		if _, ok := buckets[key]; !ok {
+			order = append(order, key)
+		}
+		buckets[key] = append(buckets[key], t.Name)
+	}
+	sort.Strings(order)
+	return buckets, order, nil
+}
+
+func chunk(names []string, size int) [][]string {
+	if size <= 0 || len(names) <= size {
+		return [][]string{names}
+	}
+	var out [][]string
+	for start := 0; start < len(names); start += size {
+		out = append(out, names[start:min(start+size, len(names))])
+	}
+	return out
+}
+
+// NewState creates the persisted state for a planned rollout.
+func NewState(waves []config.RolloutWave) *config.RolloutState {
+	state := &config.RolloutState{
+		Waves:   waves,
+		Devices: make(map[string]config.RolloutDevice),
+	}
+	for i, w := range waves {
+		for _, name := range w.Devices {
+			state.Devices[name] = config.RolloutDevice{Wave: i, Status: config.RolloutPending}
+		}
+	}
+	return state
+}
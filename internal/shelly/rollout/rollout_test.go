@@ -0,0 +1,354 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+func TestBuildPlan(t *testing.T) {
+	t.Parallel()
+	targets := []Target{
+		{Name: "e", Model: "SNSW-001X16EU", Groups: []string{"office"}},
+		{Name: "a", Model: "SHSW-1", Groups: []string{"test", "office"}},
+		{Name: "d", Model: "SNSW-001X16EU"},
+		{Name: "b", Model: "SHSW-1"},
+		{Name: "c", Model: "SNSW-001X16EU", Groups: []string{"office"}},
+	}
+
+	tests := []struct {
+		name string
+		opts PlanOpts
+		want []config.RolloutWave
+	}{
+		{"single wave", PlanOpts{}, []config.RolloutWave{
+			{Name: "all", Devices: []string{"a", "b", "c", "d", "e"}},
+		}},
+		{"canary count and size", PlanOpts{CanaryCount: 1, WaveSize: 3}, []config.RolloutWave{
+			{Name: CanaryWave, Devices: []string{"a"}},
+			{Name: "all (1/2)", Devices: []string{"b", "c", "d"}},
+			{Name: "all (2/2)", Devices: []string{"e"}},
+		}},
+		{"canary group by model", PlanOpts{CanaryGroup: "test", WaveBy: WaveByModel}, []config.RolloutWave{
+			{Name: CanaryWave, Devices: []string{"a"}},
+			{Name: "model SHSW-1", Devices: []string{"b"}},
+			{Name: "model SNSW-001X16EU", Devices: []string{"c", "d", "e"}},
+		}},
+		{"by group", PlanOpts{WaveBy: WaveByGroup}, []config.RolloutWave{
+			{Name: "group office", Devices: []string{"a", "c", "e"}},
+			{Name: ungrouped, Devices: []string{"b", "d"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := BuildPlan(targets, tt.opts)
+			if err != nil {
+				t.Fatalf("BuildPlan() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildPlan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := BuildPlan(targets, PlanOpts{CanaryGroup: "missing"}); err == nil {
+		t.Error("expected error for empty canary group")
+	}
+	if _, err := BuildPlan(targets, PlanOpts{WaveBy: "room"}); err == nil {
+		t.Error("expected error for invalid wave grouping")
+	}
+}
+
+func TestSnapshotFromStatus(t *testing.T) {
+	t.Parallel()
+
+	gen2 := SnapshotFromStatus("1.4.4", map[string]any{
+		"sys":      map[string]any{"uptime": 10.0},
+		"switch:1": map[string]any{"output": true, "errors": []any{"overtemp"}},
+		"switch:0": map[string]any{"output": false},
+	})
+	if !reflect.DeepEqual(gen2.Components, []string{"switch:0", "switch:1"}) {
+		t.Errorf("Gen2 components = %v", gen2.Components)
+	}
+	if !reflect.DeepEqual(gen2.Errors, []string{"switch:1: overtemp"}) {
+		t.Errorf("Gen2 errors = %v", gen2.Errors)
+	}
+
+	gen1 := SnapshotFromStatus("20230913-114008", map[string]any{
+		"relays": []any{map[string]any{"ison": true}},
+		"inputs": []any{map[string]any{"input": 0.0}},
+	})
+	if !reflect.DeepEqual(gen1.Components, []string{"input:0", "switch:0"}) {
+		t.Errorf("Gen1 components = %v", gen1.Components)
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	t.Parallel()
+	before := Snapshot{Components: []string{"input:0", "switch:0"}, Errors: []string{"switch:0: overtemp"}}
+
+	if got := CheckHealth(before, before); got != nil {
+		t.Errorf("CheckHealth(same) = %v, want nil", got)
+	}
+	got := CheckHealth(before, Snapshot{
+		Components: []string{"switch:0"},
+		Errors:     []string{"switch:0: overtemp", "switch:0: overpower"},
+	})
+	want := []string{"missing components: input:0", "new error switch:0: overpower"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckHealth() = %v, want %v", got, want)
+	}
+}
+
+// fakeDevice moves from firmware "1.0" to "2.0" on update; broken devices
+// come back without their switch.
+type fakeDevice struct {
+	mu          sync.Mutex
+	firmware    map[string]string
+	broken      map[string]bool
+	offline     map[string]bool
+	updates     map[string]int
+	rollbacks   []string
+	failProbe   string
+	failUpdates bool
+}
+
+func newFakeDevice(names ...string) *fakeDevice {
+	d := &fakeDevice{
+		firmware: map[string]string{},
+		broken:   map[string]bool{},
+		offline:  map[string]bool{},
+		updates:  map[string]int{},
+	}
+	for _, n := range names {
+		d.firmware[n] = "1.0"
+	}
+	return d
+}
+
+func (d *fakeDevice) Snapshot(_ context.Context, name string) (Snapshot, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.offline[name] {
+		return Snapshot{}, errors.New("timeout")
+	}
+	snap := Snapshot{Firmware: d.firmware[name], Components: []string{"switch:0"}}
+	if d.broken[name] && d.firmware[name] != "1.0" {
+		snap.Components = nil
+	}
+	return snap, nil
+}
+
+func (d *fakeDevice) Update(_ context.Context, name string, _ bool, _ string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.failUpdates {
+		return errors.New("no update available")
+	}
+	d.updates[name]++
+	d.firmware[name] = "2.0"
+	return nil
+}
+
+func (d *fakeDevice) Rollback(_ context.Context, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rollbacks = append(d.rollbacks, name)
+	d.firmware[name] = "1.0"
+	return nil
+}
+
+func (d *fakeDevice) Probe(_ context.Context, _, method string) error {
+	if method == d.failProbe {
+		return errors.New("method not found")
+	}
+	return nil
+}
+
+func newTestState(waves []config.RolloutWave) *config.RolloutState {
+	state := NewState(waves)
+	state.Parallelism = 2
+	state.MaxFailureRatio = 0.5
+	state.HealthTimeout = 20 * time.Millisecond
+	state.CheckRPCs = []string{"Switch.GetStatus"}
+	return state
+}
+
+func TestRunner_Run(t *testing.T) {
+	t.Parallel()
+	dev := newFakeDevice("a", "b", "c", "d")
+	dev.offline["d"] = true
+	state := newTestState([]config.RolloutWave{
+		{Name: CanaryWave, Devices: []string{"a"}},
+		{Name: "rest", Devices: []string{"b", "c", "d"}},
+	})
+	saves := 0
+	var events []Event
+	r := &Runner{
+		Device:       dev,
+		PollInterval: time.Millisecond,
+		Notify:       func(e Event) { events = append(events, e) },
+		Save:         func(*config.RolloutState) error { saves++; return nil },
+	}
+
+	if err := r.Run(context.Background(), state); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !state.Completed || state.Halted {
+		t.Errorf("state completed = %v, halted = %v", state.Completed, state.Halted)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		d := state.Devices[name]
+		if d.Status != config.RolloutHealthy || d.FromVersion != "1.0" || d.ToVersion != "2.0" {
+			t.Errorf("device %s = %+v", name, d)
+		}
+	}
+	if d := state.Devices["d"]; d.Status != config.RolloutSkipped || !strings.Contains(d.Problem, "unreachable") {
+		t.Errorf("offline device = %+v, want skipped", d)
+	}
+	if saves == 0 || len(events) == 0 {
+		t.Errorf("saves = %d, events = %d", saves, len(events))
+	}
+}
+
+func TestRunner_CanaryHalts(t *testing.T) {
+	t.Parallel()
+	dev := newFakeDevice("a", "b")
+	dev.broken["a"] = true
+	state := newTestState([]config.RolloutWave{
+		{Name: CanaryWave, Devices: []string{"a"}},
+		{Name: "rest", Devices: []string{"b"}},
+	})
+	state.RollbackFailed = true
+	r := &Runner{Device: dev, PollInterval: time.Millisecond}
+
+	err := r.Run(context.Background(), state)
+	if !errors.Is(err, ErrHalted) {
+		t.Fatalf("Run() error = %v, want ErrHalted", err)
+	}
+	a := state.Devices["a"]
+	if a.Status != config.RolloutRolledBack || !strings.Contains(a.Problem, "missing components: switch:0") {
+		t.Errorf("canary = %+v, want rolled back with missing component", a)
+	}
+	if !reflect.DeepEqual(dev.rollbacks, []string{"a"}) {
+		t.Errorf("rollbacks = %v", dev.rollbacks)
+	}
+	if state.Devices["b"].Status != config.RolloutPending || dev.updates["b"] != 0 {
+		t.Error("wave after halted canary was updated")
+	}
+	if !state.Halted || state.Completed {
+		t.Errorf("state halted = %v, completed = %v", state.Halted, state.Completed)
+	}
+
+	// A halted rollout stays halted until cleared.
+	if err := r.Run(context.Background(), state); !errors.Is(err, ErrHalted) {
+		t.Errorf("Run(halted) error = %v, want ErrHalted", err)
+	}
+}
+
+func TestRunner_FailureRatio(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		ratio  float64
+		halted bool
+	}{
+		{"under threshold", 0.5, false},
+		{"over threshold", 0.2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dev := newFakeDevice("a", "b", "c")
+			state := newTestState([]config.RolloutWave{{Name: "all", Devices: []string{"a", "b"}}, {Name: "next", Devices: []string{"c"}}})
+			state.MaxFailureRatio = tt.ratio
+			dev.broken["b"] = true
+			r := &Runner{Device: dev, PollInterval: time.Millisecond}
+
+			err := r.Run(context.Background(), state)
+			if halted := errors.Is(err, ErrHalted); halted != tt.halted {
+				t.Errorf("halted = %v (err %v), want %v", halted, err, tt.halted)
+			}
+			if state.Devices["b"].Status != config.RolloutFailed {
+				t.Errorf("broken device = %+v, want failed", state.Devices["b"])
+			}
+		})
+	}
+}
+
+func TestRunner_ProbeAndUpdateFailures(t *testing.T) {
+	t.Parallel()
+	dev := newFakeDevice("a")
+	dev.failProbe = "Switch.GetStatus"
+	state := newTestState([]config.RolloutWave{{Name: "all", Devices: []string{"a"}}})
+	state.MaxFailureRatio = 1
+	r := &Runner{Device: dev, PollInterval: time.Millisecond}
+
+	if err := r.Run(context.Background(), state); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if a := state.Devices["a"]; a.Status != config.RolloutFailed || !strings.Contains(a.Problem, "Switch.GetStatus failed") {
+		t.Errorf("device = %+v, want failed probe", a)
+	}
+
+	dev = newFakeDevice("a")
+	dev.failUpdates = true
+	state = newTestState([]config.RolloutWave{{Name: "all", Devices: []string{"a"}}})
+	state.MaxFailureRatio = 1
+	r.Device = dev
+	if err := r.Run(context.Background(), state); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if a := state.Devices["a"]; a.Status != config.RolloutFailed || !strings.HasPrefix(a.Problem, "update:") {
+		t.Errorf("device = %+v, want failed update", a)
+	}
+}
+
+func TestRunner_Resume(t *testing.T) {
+	t.Parallel()
+	dev := newFakeDevice("a", "b")
+	dev.firmware["a"] = "2.0" // updated before the previous run was interrupted
+	state := newTestState([]config.RolloutWave{{Name: "all", Devices: []string{"a", "b"}}})
+	for _, name := range []string{"a", "b"} {
+		state.Devices[name] = config.RolloutDevice{
+			Status: config.RolloutUpdating, FromVersion: "1.0", Components: []string{"switch:0"},
+		}
+	}
+	r := &Runner{Device: dev, PollInterval: time.Millisecond}
+
+	if err := r.Run(context.Background(), state); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if dev.updates["a"] != 0 {
+		t.Error("re-issued update for a device already on new firmware")
+	}
+	if dev.updates["b"] != 1 {
+		t.Errorf("updates[b] = %d, want update re-issued once", dev.updates["b"])
+	}
+	for _, name := range []string{"a", "b"} {
+		if state.Devices[name].Status != config.RolloutHealthy {
+			t.Errorf("device %s = %+v, want healthy", name, state.Devices[name])
+		}
+	}
+}
+
+func TestRunner_SaveError(t *testing.T) {
+	t.Parallel()
+	state := newTestState([]config.RolloutWave{{Name: "all", Devices: []string{"a"}}})
+	r := &Runner{
+		Device:       newFakeDevice("a"),
+		PollInterval: time.Millisecond,
+		Save:         func(*config.RolloutState) error { return errors.New("disk full") },
+	}
+	if err := r.Run(context.Background(), state); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Run() error = %v, want save error", err)
+	}
+}
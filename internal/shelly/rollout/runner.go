@@ -0,0 +1,274 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// ErrHalted is returned by Runner.Run when a wave failed too often.
+var ErrHalted = errors.New("rollout halted")
+
+// DefaultPollInterval is how often an updated device is polled while
+// waiting for it to come back healthy.
+const DefaultPollInterval = 5 * time.Second
+
+// Device performs the device operations of a rollout.
+type Device interface {
+	Snapshot(ctx context.Context, name string) (Snapshot, error)
+	Update(ctx context.Context, name string, beta bool, url string) error
+	Rollback(ctx context.Context, name string) error
+	// Probe calls method and reports whether it answered; Gen1 methods are
+	// HTTP paths such as "/status".
+	Probe(ctx context.Context, name, method string) error
+}
+
+// Event reports rollout progress. Device is empty for the start of a wave.
+type Event struct {
+	Wave     int
+	WaveName string
+	Device   string
+	Status   config.RolloutDeviceStatus
+	Problem  string
+}
+
+// Runner executes a rollout state wave by wave.
+type Runner struct {
+	Device       Device
+	PollInterval time.Duration
+	Notify       func(Event)
+	Save         func(*config.RolloutState) error
+
+	mu sync.Mutex
+}
+
+// Run updates every pending device of state, saving state after each
+// change so an interrupted or halted rollout can be resumed by calling Run
+// again. Devices left "updating" by an interrupted run are verified
+// without re-issuing the update when their firmware already changed.
+//
+// A wave halts the rollout when any canary device fails, or when the ratio
+// of failed to attempted devices exceeds state.MaxFailureRatio. Run then
+// returns an error wrapping ErrHalted.
+func (r *Runner) Run(ctx context.Context, state *config.RolloutState) error {
+	if state.Halted {
+		return fmt.Errorf("%w: %s", ErrHalted, state.HaltReason)
+	}
+	for i, wave := range state.Waves {
+		if !hasWork(state, wave) {
+			continue
+		}
+		r.notify(Event{Wave: i, WaveName: wave.Name})
+		if err := r.runWave(ctx, state, wave); err != nil {
+			return err
+		}
+		if reason := haltReason(state, i); reason != "" {
+			state.Halted, state.HaltReason = true, reason
+			if err := r.save(state); err != nil {
+				return err
+			}
+			return fmt.Errorf("%w: %s", ErrHalted, reason)
+		}
+	}
+	state.Completed = true
+	return r.save(state)
+}
+
+func hasWork(state *config.RolloutState, wave config.RolloutWave) bool {
+	for _, name := range wave.Devices {
+		if s := state.Devices[name].Status; s == config.RolloutPending || s == config.RolloutUpdating {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Runner) runWave(ctx context.Context, state *config.RolloutState, wave config.RolloutWave) error {
+	var todo []string
+	for _, name := range wave.Devices {
+		if s := state.Devices[name].Status; s == config.RolloutPending || s == config.RolloutUpdating {
+			todo = append(todo, name)
+		}
+	}
+
+	var g errgroup.Group
+	g.SetLimit(max(state.Parallelism, 1))
+	for _, name := range todo {
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return r.runDevice(ctx, state, name)
+		})
+	}
+	err := g.Wait()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// runDevice moves one device from pending or updating to a final status.
+// Only failures to save state are returned; device problems are recorded.
+func (r *Runner) runDevice(ctx context.Context, state *config.RolloutState, name string) error {
+	r.mu.Lock()
+	dev := state.Devices[name]
+	r.mu.Unlock()
+
+	current, snapErr := r.Device.Snapshot(ctx, name)
+	switch {
+	case dev.Status == config.RolloutPending && snapErr != nil:
+		dev.Status, dev.Problem = config.RolloutSkipped, "unreachable: "+snapErr.Error()
+		return r.record(state, name, dev)
+	case dev.Status == config.RolloutPending:
+		dev.Status = config.RolloutUpdating
+		dev.FromVersion = current.Firmware
+		dev.Components, dev.Errors = current.Components, current.Errors
+		if err := r.record(state, name, dev); err != nil {
+			return err
+		}
+		if err := r.Device.Update(ctx, name, state.Beta, state.URL); err != nil {
+			return r.fail(ctx, state, name, dev, "update: "+err.Error())
+		}
+	case snapErr == nil && current.Firmware == dev.FromVersion:
+		// Resumed before the device started updating: issue the update again.
+		if err := r.Device.Update(ctx, name, state.Beta, state.URL); err != nil {
+			return r.fail(ctx, state, name, dev, "update: "+err.Error())
+		}
+	}
+
+	version, problem := r.waitHealthy(ctx, state, name, dev)
+	if ctx.Err() != nil {
+		return nil // leave the device updating so a resume verifies it
+	}
+	if problem != "" {
+		return r.fail(ctx, state, name, dev, problem)
+	}
+	dev.Status, dev.ToVersion, dev.Problem = config.RolloutHealthy, version, ""
+	return r.record(state, name, dev)
+}
+
+// waitHealthy polls the device until it runs new firmware and passes its
+// health checks, and returns the new version. On timeout it returns the
+// last problem seen.
+func (r *Runner) waitHealthy(ctx context.Context, state *config.RolloutState, name string, dev config.RolloutDevice) (version, problem string) {
+	before := Snapshot{Firmware: dev.FromVersion, Components: dev.Components, Errors: dev.Errors}
+	interval := r.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	deadline := time.Now().Add(state.HealthTimeout)
+	problem = fmt.Sprintf("still on firmware %s after %s", dev.FromVersion, state.HealthTimeout)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err().Error()
+		case <-time.After(interval):
+		}
+
+		after, err := r.Device.Snapshot(ctx, name)
+		switch {
+		case err != nil:
+			problem = "not back online: " + err.Error()
+		case after.Firmware == dev.FromVersion:
+			// Still downloading or rebooting.
+		default:
+			problems := CheckHealth(before, after)
+			for _, method := range state.CheckRPCs {
+				if err := r.Device.Probe(ctx, name, method); err != nil {
+					problems = append(problems, fmt.Sprintf("%s failed: %v", method, err))
+				}
+			}
+			if len(problems) == 0 {
+				return after.Firmware, ""
+			}
+			problem = strings.Join(problems, "; ")
+		}
+
+		if !time.Now().Before(deadline) {
+			return "", problem
+		}
+	}
+}
+
+// fail records a failed device, rolling it back when the rollout asks to.
+func (r *Runner) fail(ctx context.Context, state *config.RolloutState, name string, dev config.RolloutDevice, problem string) error {
+	dev.Status, dev.Problem = config.RolloutFailed, problem
+	if state.RollbackFailed {
+		if err := r.Device.Rollback(ctx, name); err != nil {
+			dev.Problem += "; rollback failed: " + err.Error()
+		} else {
+			dev.Status = config.RolloutRolledBack
+		}
+	}
+	return r.record(state, name, dev)
+}
+
+func (r *Runner) record(state *config.RolloutState, name string, dev config.RolloutDevice) error {
+	dev.UpdatedAt = time.Now()
+	r.mu.Lock()
+	state.Devices[name] = dev
+	defer r.mu.Unlock()
+	r.notify(Event{Wave: dev.Wave, WaveName: state.Waves[dev.Wave].Name, Device: name, Status: dev.Status, Problem: dev.Problem})
+	return r.save(state)
+}
+
+func (r *Runner) save(state *config.RolloutState) error {
+	state.UpdatedAt = time.Now()
+	if r.Save == nil {
+		return nil
+	}
+	if err := r.Save(state); err != nil {
+		return fmt.Errorf("save rollout state: %w", err)
+	}
+	return nil
+}
+
+func (r *Runner) notify(e Event) {
+	if r.Notify != nil {
+		r.Notify(e)
+	}
+}
+
+// haltReason reports why wave i should halt the rollout, or "".
+func haltReason(state *config.RolloutState, i int) string {
+	wave := state.Waves[i]
+	attempted, failed := 0, 0
+	for _, name := range wave.Devices {
+		switch state.Devices[name].Status {
+		case config.RolloutHealthy:
+			attempted++
+		case config.RolloutFailed, config.RolloutRolledBack:
+			attempted++
+			failed++
+		}
+	}
+	if failed == 0 {
+		return ""
+	}
+	if wave.Name == CanaryWave {
+		return fmt.Sprintf("%d of %d canary devices failed", failed, attempted)
+	}
+	if ratio := float64(failed) / float64(attempted); ratio > state.MaxFailureRatio {
+		return fmt.Sprintf("wave %q: %d of %d devices failed (%.0f%% > %.0f%%)",
+			wave.Name, failed, attempted, ratio*100, state.MaxFailureRatio*100)
+	}
+	return ""
+}
+
+// Counts tallies devices by status.
+func Counts(state *config.RolloutState) map[config.RolloutDeviceStatus]int {
+	out := make(map[config.RolloutDeviceStatus]int)
+	for _, d := range state.Devices {
+		out[d.Status]++
+	}
+	return out
+}
@@ -0,0 +1,150 @@
+package shelly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tj-smith47/shelly-go/events"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/automation"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rules"
+)
+
+// ruleActionTimeout bounds each rule action and status read.
+const ruleActionTimeout = 10 * time.Second
+
+// RuleExecutor returns an executor that performs rule actions through the
+// service. Control actions work on Gen1 and Gen2+ devices alike.
+func (s *Service) RuleExecutor() rules.Executor {
+	return ruleExecutor{s}
+}
+
+type ruleExecutor struct{ s *Service }
+
+func (r ruleExecutor) Control(ctx context.Context, device, action string, id *int) error {
+	ctx, cancel := context.WithTimeout(ctx, ruleActionTimeout)
+	defer cancel()
+
+	var err error
+	switch action {
+	case rules.ActionOn:
+		_, err = r.s.QuickOn(ctx, device, id)
+	case rules.ActionOff:
+		_, err = r.s.QuickOff(ctx, device, id)
+	case rules.ActionToggle:
+		_, err = r.s.QuickToggle(ctx, device, id)
+	default:
+		err = fmt.Errorf("unknown action %q", action)
+	}
+	return err
+}
+
+func (r ruleExecutor) Call(ctx context.Context, device, method string, params map[string]any) error {
+	ctx, cancel := context.WithTimeout(ctx, ruleActionTimeout)
+	defer cancel()
+
+	_, err := r.s.RawRPC(ctx, device, method, params)
+	return err
+}
+
+func (r ruleExecutor) ActivateScene(ctx context.Context, name string) error {
+	return r.s.ActivateScene(ctx, name)
+}
+
+func (r ruleExecutor) Status(ctx context.Context, device string) (map[string]any, error) {
+	ctx, cancel := context.WithTimeout(ctx, ruleActionTimeout)
+	defer cancel()
+
+	var status map[string]any
+	err := r.s.WithDevice(ctx, device, func(dev *DeviceClient) error {
+		if dev.IsGen1() {
+			data, err := dev.Gen1().Call(ctx, "/status")
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(data, &status)
+		}
+		result, err := dev.Gen2().Call(ctx, "Shelly.GetStatus", nil)
+		if err != nil {
+			return err
+		}
+		status, _ = result.(map[string]any)
+		return nil
+	})
+	return status, err
+}
+
+// RunRules runs the engine until ctx is cancelled. Gen2+ trigger devices are
+// streamed over WebSocket; Gen1 devices, and any device that has not delivered
+// a streamed status, are polled every interval. Each fired rule runs in its
+// own goroutine and its outcome is passed to report, one call at a time.
+func (s *Service) RunRules(ctx context.Context, engine *rules.Engine, exec rules.Executor, interval time.Duration, report func(rules.Outcome)) {
+	var (
+		wg       sync.WaitGroup
+		reportMu sync.Mutex
+	)
+	dispatch := func(matches []rules.Match) {
+		for _, m := range matches {
+			wg.Go(func() {
+				out := engine.Run(ctx, exec, m)
+				reportMu.Lock()
+				defer reportMu.Unlock()
+				report(out)
+			})
+		}
+	}
+
+	devices := engine.Devices()
+	es := automation.NewEventStream(s)
+	es.SubscribeFiltered(events.WithDeviceIDs(devices...), func(evt events.Event) {
+		dispatch(engine.Handle(evt))
+	})
+	for _, name := range devices {
+		address := name
+		if dev, ok := config.GetDevice(name); ok {
+			if dev.Generation == 1 {
+				continue // Gen1 has no event stream; polled below
+			}
+			address = dev.Address
+		}
+		es.AddDevice(name, address)
+	}
+
+	poll := func() {
+		var pollWg sync.WaitGroup
+		for _, name := range engine.PollDevices() {
+			pollWg.Go(func() {
+				status, err := exec.Status(ctx, name)
+				if err != nil {
+					iostreams.DebugErr("rules: poll "+name, err)
+					status = nil
+				}
+				if ctx.Err() == nil {
+					dispatch(engine.Observe(name, status))
+				}
+			})
+		}
+		pollWg.Wait()
+		dispatch(engine.Tick())
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	poll()
+	for {
+		select {
+		case <-ctx.Done():
+			es.Stop()
+			wg.Wait()
+			return
+		case <-ticker.C:
+			poll()
+		}
+	}
+}
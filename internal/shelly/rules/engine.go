@@ -0,0 +1,371 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tj-smith47/shelly-go/events"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/alerting"
+)
+
+// Match is a rule whose trigger fired.
+type Match struct {
+	Rule config.Rule
+	// Cause describes what fired the rule, e.g. "input:0.single_push".
+	Cause string
+	Time  time.Time
+
+	conditions []*alerting.Condition
+}
+
+// ActionResult is the outcome of one rule action.
+type ActionResult struct {
+	Action string
+	Err    error
+}
+
+// Outcome is the result of running a matched rule.
+type Outcome struct {
+	Match
+	// Blocked is the condition that did not hold; no actions ran.
+	Blocked string
+	Actions []ActionResult
+}
+
+// Failed reports whether any action failed.
+func (o Outcome) Failed() bool {
+	for _, a := range o.Actions {
+		if a.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+type compiledRule struct {
+	rule       config.Rule
+	component  string // Event trigger component; empty matches any
+	event      string
+	trigger    *alerting.Evaluator // Condition trigger
+	primed     bool                // Trigger condition has a baseline
+	active     bool                // Trigger condition held at the last evaluation
+	conditions []*alerting.Condition
+	cooldown   time.Duration
+	lastFired  time.Time
+}
+
+type deviceState struct {
+	online   bool
+	status   map[string]any
+	streamed bool            // Status is kept from WebSocket events
+	counters map[int]float64 // Gen1 input event counters
+}
+
+// Engine matches device events against rules and runs them.
+type Engine struct {
+	mu      sync.Mutex
+	rules   []*compiledRule
+	devices map[string]*deviceState
+	now     func() time.Time
+}
+
+// New validates and compiles rules. Disabled rules are skipped.
+func New(rules []config.Rule) (*Engine, error) {
+	e := &Engine{
+		devices: make(map[string]*deviceState),
+		now:     time.Now,
+	}
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		if err := Validate(rule); err != nil {
+			return nil, err
+		}
+		cr := &compiledRule{rule: rule, cooldown: rule.CooldownDuration()}
+		if rule.Trigger.Event != "" {
+			cr.component, cr.event, _ = parseEvent(rule.Trigger.Event) // Validated above
+		} else {
+			cond, _ := alerting.Parse(rule.Trigger.Condition) // Validated above
+			cr.trigger = alerting.NewEvaluator(cond)
+		}
+		for _, c := range rule.Conditions {
+			cond, _ := alerting.Parse(c.Condition) // Validated above
+			cr.conditions = append(cr.conditions, cond)
+		}
+		e.rules = append(e.rules, cr)
+	}
+	return e, nil
+}
+
+// Rules returns the number of enabled rules.
+func (e *Engine) Rules() int {
+	return len(e.rules)
+}
+
+// Devices returns the trigger devices of all enabled rules.
+func (e *Engine) Devices() []string {
+	var devices []string
+	for _, cr := range e.rules {
+		if !slices.Contains(devices, cr.rule.Trigger.Device) {
+			devices = append(devices, cr.rule.Trigger.Device)
+		}
+	}
+	slices.Sort(devices)
+	return devices
+}
+
+// PollDevices returns the trigger devices whose state is not streamed.
+func (e *Engine) PollDevices() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var devices []string
+	for _, name := range e.Devices() {
+		if st := e.devices[name]; st == nil || !st.streamed {
+			devices = append(devices, name)
+		}
+	}
+	return devices
+}
+
+// Handle applies an EventStream event and returns the rules it fires.
+// Full status is only taken from WebSocket events; other devices are fed
+// through Observe.
+func (e *Engine) Handle(evt events.Event) []Match {
+	name := evt.DeviceID()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := e.state(name)
+	var fired []string
+
+	switch ev := evt.(type) {
+	case *events.FullStatusEvent:
+		if ev.Source() != events.EventSourceWebSocket {
+			return nil
+		}
+		var status map[string]any
+		if err := json.Unmarshal(ev.Status, &status); err != nil {
+			iostreams.DebugErr("rules: parse full status for "+name, err)
+			return nil
+		}
+		st.online, st.status, st.streamed = true, status, true
+
+	case *events.StatusChangeEvent:
+		if !st.streamed {
+			return nil
+		}
+		var delta any
+		if err := json.Unmarshal(ev.Status, &delta); err != nil {
+			iostreams.DebugErr("rules: parse status change for "+name, err)
+			return nil
+		}
+		st.online = true
+		st.status = alerting.MergeStatus(st.status, map[string]any{ev.Component: delta})
+
+	case *events.NotifyEvent:
+		fired = append(fired, strings.ToLower(ev.Component+"."+ev.Event))
+
+	case *events.DeviceOnlineEvent:
+		st.online = true
+
+	case *events.DeviceOfflineEvent:
+		st.online = false
+
+	default:
+		return nil
+	}
+
+	return e.match(name, st, fired)
+}
+
+// Observe applies a polled status (nil when the device was unreachable) and
+// returns the rules it fires. Gen1 input events are derived from changes in
+// their event counters.
+func (e *Engine) Observe(device string, status map[string]any) []Match {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := e.state(device)
+	if st.streamed {
+		return nil
+	}
+
+	var fired []string
+	if status == nil {
+		st.online = false
+	} else {
+		if IsGen1Status(status) {
+			fired, st.counters = gen1InputEvents(status, st.counters)
+			status = NormalizeStatus(status)
+		}
+		st.online, st.status = true, status
+	}
+	return e.match(device, st, fired)
+}
+
+// Tick re-evaluates condition triggers on streamed devices, so "for" windows
+// complete even when a device reports no further changes.
+func (e *Engine) Tick() []Match {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var matches []Match
+	for name, st := range e.devices {
+		if st.streamed {
+			matches = append(matches, e.match(name, st, nil)...)
+		}
+	}
+	return matches
+}
+
+func (e *Engine) state(device string) *deviceState {
+	st, ok := e.devices[device]
+	if !ok {
+		st = &deviceState{}
+		e.devices[device] = st
+	}
+	return st
+}
+
+// match evaluates the triggers of rules on device. Callers hold e.mu.
+func (e *Engine) match(device string, st *deviceState, fired []string) []Match {
+	now := e.now()
+	snap := st.snapshot(now)
+	snap.Events = fired
+
+	var matches []Match
+	for _, cr := range e.rules {
+		if cr.rule.Trigger.Device != device {
+			continue
+		}
+
+		var cause string
+		if cr.trigger != nil {
+			cause = cr.evaluateTrigger(st, snap)
+		} else {
+			cause = cr.matchEvent(fired)
+		}
+		if cause == "" {
+			continue
+		}
+		if cr.cooldown > 0 && now.Sub(cr.lastFired) < cr.cooldown {
+			continue
+		}
+		cr.lastFired = now
+		matches = append(matches, Match{Rule: cr.rule, Cause: cause, Time: now, conditions: cr.conditions})
+	}
+	return matches
+}
+
+// matchEvent returns the first fired event that matches the rule's trigger.
+func (cr *compiledRule) matchEvent(fired []string) string {
+	for _, evt := range fired {
+		i := strings.LastIndex(evt, ".")
+		if i < 0 || evt[i+1:] != cr.event {
+			continue
+		}
+		if cr.component == "" || evt[:i] == cr.component {
+			return evt
+		}
+	}
+	return ""
+}
+
+// evaluateTrigger fires a condition trigger when it becomes true. The first
+// evaluation only records a baseline, so a condition that already holds when
+// the daemon starts does not fire.
+func (cr *compiledRule) evaluateTrigger(st *deviceState, snap alerting.Snapshot) string {
+	if st.online && st.status == nil && cr.trigger.Condition().NeedsStatus() {
+		return "" // Online, but no status yet
+	}
+	res := cr.trigger.Evaluate(snap)
+	if res.Unknown {
+		return "" // Keep the previous state until the values are seen again
+	}
+	wasActive := cr.active
+	cr.active = res.Triggered
+	if !cr.primed {
+		cr.primed = true
+		return ""
+	}
+	if !res.Triggered || wasActive {
+		return ""
+	}
+	if v := res.Value(); v != "" {
+		return cr.rule.Trigger.Condition + " (" + v + ")"
+	}
+	return cr.rule.Trigger.Condition
+}
+
+func (st *deviceState) snapshot(now time.Time) alerting.Snapshot {
+	snap := alerting.Snapshot{Online: st.online, Time: now}
+	if st.online {
+		snap.Status = maps.Clone(st.status)
+	}
+	return snap
+}
+
+// Run checks a matched rule's conditions and, if they hold, runs its actions
+// in order. Conditions use streamed state where available and otherwise read
+// the device's current status.
+func (e *Engine) Run(ctx context.Context, exec Executor, m Match) Outcome {
+	out := Outcome{Match: m}
+
+	for i, cond := range m.conditions {
+		c := m.Rule.Conditions[i]
+		snap := e.conditionSnapshot(ctx, exec, c.Device)
+		if !cond.EvaluateInstant(snap).Triggered {
+			out.Blocked = c.Device + " " + c.Condition
+			return out
+		}
+	}
+
+	for _, a := range m.Rule.Actions {
+		out.Actions = append(out.Actions, ActionResult{
+			Action: DescribeAction(a),
+			Err:    runAction(ctx, exec, a),
+		})
+	}
+	return out
+}
+
+func (e *Engine) conditionSnapshot(ctx context.Context, exec Executor, device string) alerting.Snapshot {
+	e.mu.Lock()
+	if st := e.devices[device]; st != nil && st.streamed {
+		snap := st.snapshot(e.now())
+		e.mu.Unlock()
+		return snap
+	}
+	e.mu.Unlock()
+
+	snap := alerting.Snapshot{Time: e.now()}
+	status, err := exec.Status(ctx, device)
+	if err != nil {
+		iostreams.DebugErr("rules: status for "+device, err)
+		return snap
+	}
+	snap.Online, snap.Status = true, NormalizeStatus(status)
+	return snap
+}
+
+func runAction(ctx context.Context, exec Executor, a config.RuleAction) error {
+	switch {
+	case a.Scene != "":
+		return exec.ActivateScene(ctx, a.Scene)
+	case a.Method != "":
+		return exec.Call(ctx, a.Device, a.Method, a.Params)
+	default:
+		return exec.Control(ctx, a.Device, a.Action, a.ID)
+	}
+}
@@ -0,0 +1,295 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-go/events"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+type fakeExecutor struct {
+	mu     sync.Mutex
+	calls  []string
+	status map[string]map[string]any
+	fail   map[string]error
+}
+
+func (f *fakeExecutor) record(call string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	return f.fail[call]
+}
+
+func (f *fakeExecutor) Control(_ context.Context, device, action string, _ *int) error {
+	return f.record(action + " " + device)
+}
+
+func (f *fakeExecutor) Call(_ context.Context, device, method string, _ map[string]any) error {
+	return f.record(device + " " + method)
+}
+
+func (f *fakeExecutor) ActivateScene(_ context.Context, name string) error {
+	return f.record("scene " + name)
+}
+
+func (f *fakeExecutor) Status(_ context.Context, device string) (map[string]any, error) {
+	if s, ok := f.status[device]; ok {
+		return s, nil
+	}
+	return nil, errors.New("unreachable")
+}
+
+func newEngine(t *testing.T, rules ...config.Rule) *Engine {
+	t.Helper()
+	e, err := New(rules)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return e
+}
+
+func wsStatus(device, status string) events.Event {
+	return events.NewFullStatusEvent(device, json.RawMessage(status)).WithSource(events.EventSourceWebSocket)
+}
+
+func TestEngine_EventTrigger(t *testing.T) {
+	t.Parallel()
+
+	e := newEngine(t,
+		config.Rule{
+			Name:    "exact",
+			Trigger: config.RuleTrigger{Device: "hall", Event: "input:0.single_push"},
+			Actions: []config.RuleAction{{Device: "kitchen", Action: ActionToggle}},
+		},
+		config.Rule{
+			Name:    "any-long",
+			Trigger: config.RuleTrigger{Device: "hall", Event: "long_push"},
+			Actions: []config.RuleAction{{Scene: "night"}},
+		},
+		config.Rule{
+			Name:     "disabled",
+			Disabled: true,
+			Trigger:  config.RuleTrigger{Device: "hall", Event: "single_push"},
+			Actions:  []config.RuleAction{{Scene: "never"}},
+		},
+	)
+
+	if e.Rules() != 2 {
+		t.Errorf("Rules() = %d, want 2", e.Rules())
+	}
+
+	if m := e.Handle(events.NewNotifyEvent("hall", "input:1", "single_push")); len(m) != 0 {
+		t.Errorf("input:1 matched %+v", m)
+	}
+	m := e.Handle(events.NewNotifyEvent("hall", "input:0", "single_push"))
+	if len(m) != 1 || m[0].Rule.Name != "exact" || m[0].Cause != "input:0.single_push" {
+		t.Errorf("single_push matches = %+v", m)
+	}
+	m = e.Handle(events.NewNotifyEvent("hall", "input:3", "long_push"))
+	if len(m) != 1 || m[0].Rule.Name != "any-long" {
+		t.Errorf("long_push matches = %+v", m)
+	}
+	if m = e.Handle(events.NewNotifyEvent("other", "input:0", "single_push")); len(m) != 0 {
+		t.Errorf("other device matched %+v", m)
+	}
+}
+
+func TestEngine_ConditionTriggerEdge(t *testing.T) {
+	t.Parallel()
+
+	e := newEngine(t, config.Rule{
+		Name:    "heavy-load",
+		Trigger: config.RuleTrigger{Device: "plug", Condition: "switch:0.apower > 1000"},
+		Actions: []config.RuleAction{{Device: "fan", Action: ActionOn}},
+	})
+
+	// Already true at start-up: baseline only.
+	if m := e.Handle(wsStatus("plug", `{"switch:0":{"apower":1500}}`)); len(m) != 0 {
+		t.Errorf("baseline fired %+v", m)
+	}
+
+	change := func(power string) []Match {
+		return e.Handle(events.NewStatusChangeEvent("plug", "switch:0", json.RawMessage(`{"apower":`+power+`}`)).
+			WithSource(events.EventSourceWebSocket))
+	}
+	if m := change("1600"); len(m) != 0 {
+		t.Errorf("still true fired %+v", m)
+	}
+	if m := change("10"); len(m) != 0 {
+		t.Errorf("false fired %+v", m)
+	}
+	m := change("1200")
+	if len(m) != 1 || m[0].Cause != "switch:0.apower > 1000 (1200)" {
+		t.Errorf("rising edge = %+v", m)
+	}
+	if len(e.PollDevices()) != 0 {
+		t.Errorf("PollDevices() = %v, want none for a streamed device", e.PollDevices())
+	}
+}
+
+func TestEngine_OfflineTrigger(t *testing.T) {
+	t.Parallel()
+
+	e := newEngine(t, config.Rule{
+		Name:    "router-down",
+		Trigger: config.RuleTrigger{Device: "router-plug", Condition: "offline"},
+		Actions: []config.RuleAction{{Scene: "alarm"}},
+	})
+
+	if m := e.Handle(events.NewDeviceOnlineEvent("router-plug")); len(m) != 0 {
+		t.Errorf("online fired %+v", m)
+	}
+	if m := e.Handle(events.NewDeviceOfflineEvent("router-plug")); len(m) != 1 {
+		t.Errorf("offline matches = %+v", m)
+	}
+}
+
+func TestEngine_Cooldown(t *testing.T) {
+	t.Parallel()
+
+	e := newEngine(t, config.Rule{
+		Name:     "debounced",
+		Trigger:  config.RuleTrigger{Device: "hall", Event: "single_push"},
+		Actions:  []config.RuleAction{{Scene: "evening"}},
+		Cooldown: "5s",
+	})
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+
+	push := func() int { return len(e.Handle(events.NewNotifyEvent("hall", "input:0", "single_push"))) }
+	if push() != 1 {
+		t.Fatal("first push did not fire")
+	}
+	now = now.Add(2 * time.Second)
+	if push() != 0 {
+		t.Error("push within cooldown fired")
+	}
+	now = now.Add(4 * time.Second)
+	if push() != 1 {
+		t.Error("push after cooldown did not fire")
+	}
+}
+
+func TestEngine_ObserveGen1(t *testing.T) {
+	t.Parallel()
+
+	e := newEngine(t,
+		config.Rule{
+			Name:    "gen1-button",
+			Trigger: config.RuleTrigger{Device: "i3", Event: "input:1.long_push"},
+			Actions: []config.RuleAction{{Device: "gen2-light", Action: ActionToggle}},
+		},
+		config.Rule{
+			Name:    "gen1-relay",
+			Trigger: config.RuleTrigger{Device: "shelly1", Condition: "switch:0.output"},
+			Actions: []config.RuleAction{{Device: "gen2-light", Action: ActionOn}},
+		},
+	)
+
+	if got := e.PollDevices(); len(got) != 2 {
+		t.Errorf("PollDevices() = %v", got)
+	}
+
+	inputs := func(cnt int, evt string) map[string]any {
+		return map[string]any{"inputs": []any{
+			map[string]any{"input": 0.0, "event": "", "event_cnt": 0.0},
+			map[string]any{"input": 0.0, "event": evt, "event_cnt": float64(cnt)},
+		}}
+	}
+	if m := e.Observe("i3", inputs(4, "L")); len(m) != 0 {
+		t.Errorf("first poll fired %+v", m)
+	}
+	if m := e.Observe("i3", inputs(4, "L")); len(m) != 0 {
+		t.Errorf("unchanged counter fired %+v", m)
+	}
+	m := e.Observe("i3", inputs(5, "L"))
+	if len(m) != 1 || m[0].Cause != "input:1.long_push" {
+		t.Errorf("long push = %+v", m)
+	}
+
+	relay := func(on bool) map[string]any {
+		return map[string]any{"relays": []any{map[string]any{"ison": on}}}
+	}
+	e.Observe("shelly1", relay(false))
+	if m := e.Observe("shelly1", relay(true)); len(m) != 1 || m[0].Rule.Name != "gen1-relay" {
+		t.Errorf("relay on = %+v", m)
+	}
+}
+
+func TestEngine_Run(t *testing.T) {
+	t.Parallel()
+
+	rule := config.Rule{
+		Name:    "evening",
+		Trigger: config.RuleTrigger{Device: "hall", Event: "single_push"},
+		Conditions: []config.RuleCondition{
+			{Device: "hall", Condition: "switch:0.output == false"},
+			{Device: "garden", Condition: "light:0.output"},
+		},
+		Actions: []config.RuleAction{
+			{Device: "kitchen", Action: ActionToggle},
+			{Device: "kitchen", Method: "Switch.Set"},
+			{Scene: "evening"},
+		},
+	}
+	e := newEngine(t, rule)
+	e.Handle(wsStatus("hall", `{"switch:0":{"output":false}}`))
+	m := e.Handle(events.NewNotifyEvent("hall", "input:0", "single_push"))
+	if len(m) != 1 {
+		t.Fatalf("matches = %+v", m)
+	}
+
+	// garden is not streamed, so its (Gen1) status is read from the executor.
+	exec := &fakeExecutor{
+		status: map[string]map[string]any{"garden": {"lights": []any{map[string]any{"ison": true}}}},
+		fail:   map[string]error{"kitchen Switch.Set": errors.New("boom")},
+	}
+	out := e.Run(context.Background(), exec, m[0])
+	if out.Blocked != "" {
+		t.Fatalf("Blocked = %q", out.Blocked)
+	}
+	want := []string{"toggle kitchen", "kitchen Switch.Set", "scene evening"}
+	if len(exec.calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", exec.calls, want)
+	}
+	for i := range want {
+		if exec.calls[i] != want[i] {
+			t.Errorf("calls[%d] = %q, want %q", i, exec.calls[i], want[i])
+		}
+	}
+	if !out.Failed() || out.Actions[1].Err == nil || out.Actions[0].Err != nil {
+		t.Errorf("Actions = %+v", out.Actions)
+	}
+
+	// An unreachable condition device blocks the rule.
+	exec = &fakeExecutor{}
+	out = e.Run(context.Background(), exec, m[0])
+	if out.Blocked != "garden light:0.output" || len(exec.calls) != 0 {
+		t.Errorf("Blocked = %q, calls = %v", out.Blocked, exec.calls)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	inner := &fakeExecutor{status: map[string]map[string]any{"a": {"sys": map[string]any{}}}}
+	exec := DryRun(inner)
+	ctx := context.Background()
+	if exec.Control(ctx, "a", ActionOn, nil) != nil || exec.Call(ctx, "a", "Switch.Set", nil) != nil ||
+		exec.ActivateScene(ctx, "x") != nil {
+		t.Error("dry run action returned an error")
+	}
+	if len(inner.calls) != 0 {
+		t.Errorf("dry run performed %v", inner.calls)
+	}
+	if _, err := exec.Status(ctx, "a"); err != nil {
+		t.Errorf("Status() error = %v", err)
+	}
+}
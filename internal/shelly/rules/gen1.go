@@ -0,0 +1,140 @@
+package rules
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// gen1Events maps Gen1 input event codes to Gen2 event names.
+var gen1Events = map[string]string{
+	"S":   "single_push",
+	"SS":  "double_push",
+	"SSS": "triple_push",
+	"L":   "long_push",
+	"SL":  "short_long_push",
+	"LS":  "long_short_push",
+}
+
+// gen1Cover maps Gen1 roller states to Gen2 cover states.
+var gen1Cover = map[string]string{
+	"open":  "opening",
+	"close": "closing",
+	"stop":  "stopped",
+}
+
+// IsGen1Status reports whether status is a Gen1 /status response rather than
+// a Gen2 Shelly.GetStatus result.
+func IsGen1Status(status map[string]any) bool {
+	if _, ok := status["sys"]; ok {
+		return false
+	}
+	for k := range status {
+		if strings.Contains(k, ":") {
+			return false
+		}
+	}
+	for _, k := range []string{"relays", "inputs", "rollers", "lights", "meters"} {
+		if _, ok := status[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeStatus adds Gen2 component names to a Gen1 status so conditions
+// such as "switch:0.output" work on both generations:
+//
+//	relays[i]  → switch:i  {output, apower, temperature}
+//	inputs[i]  → input:i   {state}
+//	rollers[i] → cover:i   {state, current_pos, apower}
+//	lights[i]  → light:i   {output, brightness}
+//
+// The original Gen1 fields are kept. Gen2 status is returned unchanged.
+func NormalizeStatus(status map[string]any) map[string]any {
+	if !IsGen1Status(status) {
+		return status
+	}
+	out := maps.Clone(status)
+
+	meters, _ := status["meters"].([]any)
+	meterPower := func(i int) (any, bool) {
+		if i >= len(meters) {
+			return nil, false
+		}
+		m, ok := meters[i].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		p, ok := m["power"]
+		return p, ok
+	}
+	temperature, hasTemp := status["tmp"].(map[string]any)
+
+	for i, r := range gen1List(status, "relays") {
+		sw := map[string]any{"output": r["ison"]}
+		if p, ok := meterPower(i); ok {
+			sw["apower"] = p
+		}
+		if hasTemp {
+			sw["temperature"] = map[string]any{"tC": temperature["tC"], "tF": temperature["tF"]}
+		}
+		out[fmt.Sprintf("switch:%d", i)] = sw
+	}
+
+	for i, in := range gen1List(status, "inputs") {
+		state, _ := in["input"].(float64)
+		out[fmt.Sprintf("input:%d", i)] = map[string]any{"state": state != 0}
+	}
+
+	for i, r := range gen1List(status, "rollers") {
+		cover := map[string]any{"current_pos": r["current_pos"], "apower": r["power"]}
+		if st, ok := r["state"].(string); ok {
+			cover["state"] = gen1Cover[st]
+		}
+		out[fmt.Sprintf("cover:%d", i)] = cover
+	}
+
+	for i, l := range gen1List(status, "lights") {
+		out[fmt.Sprintf("light:%d", i)] = map[string]any{"output": l["ison"], "brightness": l["brightness"]}
+	}
+
+	return out
+}
+
+// gen1InputEvents returns the input events reported since prev, and the new
+// per-input event counters. Gen1 devices only expose the last event and a
+// counter, so events are detected from counter changes.
+func gen1InputEvents(status map[string]any, prev map[int]float64) (evts []string, counters map[int]float64) {
+	inputs := gen1List(status, "inputs")
+	if len(inputs) == 0 {
+		return nil, prev
+	}
+	counters = make(map[int]float64, len(inputs))
+	for i, in := range inputs {
+		cnt, ok := in["event_cnt"].(float64)
+		if !ok {
+			continue
+		}
+		counters[i] = cnt
+		last, seen := prev[i]
+		if !seen || cnt == last {
+			continue
+		}
+		code, _ := in["event"].(string)
+		if name, ok := gen1Events[strings.ToUpper(code)]; ok {
+			evts = append(evts, fmt.Sprintf("input:%d.%s", i, name))
+		}
+	}
+	return evts, counters
+}
+
+func gen1List(status map[string]any, key string) []map[string]any {
+	raw, _ := status[key].([]any)
+	list := make([]map[string]any, 0, len(raw))
+	for _, v := range raw {
+		m, _ := v.(map[string]any)
+		list = append(list, m)
+	}
+	return list
+}
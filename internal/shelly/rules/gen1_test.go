@@ -0,0 +1,96 @@
+package rules
+
+import (
+	"testing"
+)
+
+func TestIsGen1Status(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		status map[string]any
+		want   bool
+	}{
+		{"gen1 relays", map[string]any{"relays": []any{}, "wifi_sta": map[string]any{}}, true},
+		{"gen1 inputs", map[string]any{"inputs": []any{}}, true},
+		{"gen2", map[string]any{"sys": map[string]any{}, "switch:0": map[string]any{}}, false},
+		{"gen2 without sys", map[string]any{"switch:0": map[string]any{}}, false},
+		{"empty", map[string]any{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := IsGen1Status(tt.status); got != tt.want {
+				t.Errorf("IsGen1Status() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeStatus(t *testing.T) {
+	t.Parallel()
+
+	status := map[string]any{
+		"relays":  []any{map[string]any{"ison": true}, map[string]any{"ison": false}},
+		"meters":  []any{map[string]any{"power": 42.5}},
+		"inputs":  []any{map[string]any{"input": 1.0}},
+		"rollers": []any{map[string]any{"state": "stop", "current_pos": 30.0, "power": 0.0}},
+		"lights":  []any{map[string]any{"ison": true, "brightness": 60.0}},
+		"tmp":     map[string]any{"tC": 41.2, "tF": 106.2},
+	}
+	got := NormalizeStatus(status)
+
+	sw0, _ := got["switch:0"].(map[string]any)
+	if sw0["output"] != true || sw0["apower"] != 42.5 {
+		t.Errorf("switch:0 = %v", sw0)
+	}
+	if temp, _ := sw0["temperature"].(map[string]any); temp["tC"] != 41.2 {
+		t.Errorf("switch:0.temperature = %v", sw0["temperature"])
+	}
+	if sw1, _ := got["switch:1"].(map[string]any); sw1["output"] != false || sw1["apower"] != nil {
+		t.Errorf("switch:1 = %v", sw1)
+	}
+	if in, _ := got["input:0"].(map[string]any); in["state"] != true {
+		t.Errorf("input:0 = %v", in)
+	}
+	if cover, _ := got["cover:0"].(map[string]any); cover["state"] != "stopped" || cover["current_pos"] != 30.0 {
+		t.Errorf("cover:0 = %v", cover)
+	}
+	if light, _ := got["light:0"].(map[string]any); light["output"] != true || light["brightness"] != 60.0 {
+		t.Errorf("light:0 = %v", light)
+	}
+	if _, ok := got["relays"]; !ok {
+		t.Error("original Gen1 fields dropped")
+	}
+	if _, ok := status["switch:0"]; ok {
+		t.Error("input status mutated")
+	}
+
+	gen2 := map[string]any{"switch:0": map[string]any{"output": true}}
+	if out := NormalizeStatus(gen2); len(out) != 1 {
+		t.Errorf("Gen2 status changed: %v", out)
+	}
+}
+
+func TestGen1InputEvents(t *testing.T) {
+	t.Parallel()
+
+	status := func(evt string, cnt float64) map[string]any {
+		return map[string]any{"inputs": []any{map[string]any{"input": 0.0, "event": evt, "event_cnt": cnt}}}
+	}
+
+	evts, counters := gen1InputEvents(status("S", 1), nil)
+	if len(evts) != 0 || counters[0] != 1 {
+		t.Errorf("first poll = %v, %v", evts, counters)
+	}
+	for code, want := range gen1Events {
+		evts, counters = gen1InputEvents(status(code, counters[0]+1), counters)
+		if len(evts) != 1 || evts[0] != "input:0."+want {
+			t.Errorf("event %q = %v, want input:0.%s", code, evts, want)
+		}
+	}
+	if evts, _ = gen1InputEvents(status("?", counters[0]+1), counters); len(evts) != 0 {
+		t.Errorf("unknown event code = %v", evts)
+	}
+}
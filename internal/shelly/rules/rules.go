@@ -0,0 +1,253 @@
+// Package rules evaluates local cross-device automation rules.
+//
+// A rule fires when its trigger device reports an event (such as
+// input:0.single_push) or when a trigger condition becomes true. Optional
+// conditions on other devices' current state are then checked and the rule's
+// actions run in order:
+//
+//	rules:
+//	  - name: hallway-button
+//	    trigger:
+//	      device: hallway-i4
+//	      event: input:0.single_push
+//	    conditions:
+//	      - device: kitchen
+//	        condition: "switch:0.output == false"
+//	    actions:
+//	      - device: kitchen
+//	        action: toggle
+//	      - scene: evening
+//
+// Conditions use the alert condition language (see the alerting package).
+// Gen1 status is normalized to Gen2 component names, so rules read the same
+// regardless of device generation.
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/alerting"
+)
+
+// Control actions.
+const (
+	ActionOn     = "on"
+	ActionOff    = "off"
+	ActionToggle = "toggle"
+)
+
+// Executor performs rule actions and reads device status.
+type Executor interface {
+	// Control turns a device's components on, off or toggles them. A nil id
+	// affects all controllable components.
+	Control(ctx context.Context, device, action string, id *int) error
+	// Call invokes an RPC method on a device.
+	Call(ctx context.Context, device, method string, params map[string]any) error
+	// ActivateScene runs a configured scene.
+	ActivateScene(ctx context.Context, name string) error
+	// Status returns a device's current status. Gen1 devices may return their
+	// raw /status response; it is normalized by the engine.
+	Status(ctx context.Context, device string) (map[string]any, error)
+}
+
+// Validate checks a rule's trigger, conditions and actions.
+func Validate(rule config.Rule) error {
+	if err := config.ValidateName(rule.Name, "rule"); err != nil {
+		return err
+	}
+	if err := validateTrigger(rule.Trigger); err != nil {
+		return fmt.Errorf("rule %q trigger: %w", rule.Name, err)
+	}
+	for i, c := range rule.Conditions {
+		if err := validateCondition(c); err != nil {
+			return fmt.Errorf("rule %q condition %d: %w", rule.Name, i+1, err)
+		}
+	}
+	if len(rule.Actions) == 0 {
+		return fmt.Errorf("rule %q has no actions", rule.Name)
+	}
+	for i, a := range rule.Actions {
+		if err := validateAction(a); err != nil {
+			return fmt.Errorf("rule %q action %d: %w", rule.Name, i+1, err)
+		}
+	}
+	if rule.Cooldown != "" {
+		if d, err := time.ParseDuration(rule.Cooldown); err != nil || d < 0 {
+			return fmt.Errorf("rule %q: invalid cooldown %q", rule.Name, rule.Cooldown)
+		}
+	}
+	return nil
+}
+
+func validateTrigger(t config.RuleTrigger) error {
+	if t.Device == "" {
+		return errors.New("device is required")
+	}
+	switch {
+	case t.Event != "" && t.Condition != "":
+		return errors.New("set either event or condition, not both")
+	case t.Event != "":
+		if _, _, err := parseEvent(t.Event); err != nil {
+			return err
+		}
+	case t.Condition != "":
+		if err := alerting.Validate(t.Condition); err != nil {
+			return err
+		}
+	default:
+		return errors.New("event or condition is required")
+	}
+	return nil
+}
+
+func validateCondition(c config.RuleCondition) error {
+	if c.Device == "" {
+		return errors.New("device is required")
+	}
+	cond, err := alerting.Parse(c.Condition)
+	if err != nil {
+		return err
+	}
+	if cond.UsesEvents() {
+		return errors.New("events can only be used in the trigger")
+	}
+	return nil
+}
+
+func validateAction(a config.RuleAction) error {
+	set := 0
+	for _, v := range []string{a.Action, a.Method, a.Scene} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("set exactly one of action, method or scene")
+	}
+
+	switch {
+	case a.Scene != "":
+		if a.Device != "" {
+			return errors.New("scene actions do not take a device")
+		}
+	case a.Device == "":
+		return errors.New("device is required")
+	case a.Action != "":
+		switch a.Action {
+		case ActionOn, ActionOff, ActionToggle:
+		default:
+			return fmt.Errorf("unknown action %q (use on, off or toggle)", a.Action)
+		}
+	}
+	return nil
+}
+
+// parseEvent splits "input:0.single_push" into component and event. A bare
+// event name matches any component.
+func parseEvent(s string) (component, event string, err error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.LastIndex(s, "."); i >= 0 {
+		component, event = s[:i], s[i+1:]
+		if component == "" {
+			return "", "", fmt.Errorf("invalid event %q", s)
+		}
+	} else {
+		event = s
+	}
+	if event == "" || strings.ContainsAny(event, " \t") {
+		return "", "", fmt.Errorf("invalid event %q", s)
+	}
+	return component, event, nil
+}
+
+// DescribeTrigger returns a short description of a rule's trigger.
+func DescribeTrigger(t config.RuleTrigger) string {
+	if t.Event != "" {
+		return t.Device + " " + t.Event
+	}
+	return t.Device + " when " + t.Condition
+}
+
+// DescribeAction returns a short description of a rule action.
+func DescribeAction(a config.RuleAction) string {
+	switch {
+	case a.Scene != "":
+		return "scene " + a.Scene
+	case a.Method != "":
+		return a.Device + " " + a.Method
+	case a.ID != nil:
+		return a.Action + " " + a.Device + ":" + strconv.Itoa(*a.ID)
+	default:
+		return a.Action + " " + a.Device
+	}
+}
+
+// DryRun wraps an executor so actions are only reported, never performed.
+// Status reads still reach the devices.
+func DryRun(exec Executor) Executor {
+	return dryRun{exec}
+}
+
+type dryRun struct{ Executor }
+
+func (dryRun) Control(context.Context, string, string, *int) error        { return nil }
+func (dryRun) Call(context.Context, string, string, map[string]any) error { return nil }
+func (dryRun) ActivateScene(context.Context, string) error                { return nil }
+
+// ImportFile validates the rules in file and saves them to the config. All
+// rules are checked before any is saved; existing rules are only replaced
+// when overwrite is set.
+func ImportFile(file string, overwrite bool) (string, error) {
+	list, err := config.ParseRulesFile(file)
+	if err != nil {
+		return "", err
+	}
+	for _, rule := range list {
+		if err := Validate(rule); err != nil {
+			return "", err
+		}
+		if _, exists := config.GetRule(rule.Name); exists && !overwrite {
+			return "", fmt.Errorf("rule %q already exists (use --overwrite to replace)", rule.Name)
+		}
+	}
+	for _, rule := range list {
+		if err := config.SaveRule(rule); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("Imported %d rule(s)", len(list)), nil
+}
+
+// Warnings returns problems that do not stop a rule from running, such as
+// devices that are not registered (and will be used as addresses) or scenes
+// that do not exist.
+func Warnings(rule config.Rule) []string {
+	var warnings []string
+	unknown := make(map[string]bool)
+	checkDevice := func(device string) {
+		if _, ok := config.GetDevice(device); !ok && !unknown[device] {
+			unknown[device] = true
+			warnings = append(warnings, fmt.Sprintf("rule %q: device %q is not registered", rule.Name, device))
+		}
+	}
+
+	for _, device := range rule.Devices() {
+		checkDevice(device)
+	}
+	for _, a := range rule.Actions {
+		if a.Scene != "" {
+			if _, ok := config.GetScene(a.Scene); !ok {
+				warnings = append(warnings, fmt.Sprintf("rule %q: scene %q not found", rule.Name, a.Scene))
+			}
+			continue
+		}
+		checkDevice(a.Device)
+	}
+	return warnings
+}
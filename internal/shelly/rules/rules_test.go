@@ -0,0 +1,103 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+func validRule() config.Rule {
+	return config.Rule{
+		Name:    "hallway-button",
+		Trigger: config.RuleTrigger{Device: "hallway", Event: "input:0.single_push"},
+		Actions: []config.RuleAction{{Device: "kitchen", Action: ActionToggle}},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	id := 1
+	tests := []struct {
+		name    string
+		modify  func(r *config.Rule)
+		wantErr bool
+	}{
+		{"valid event trigger", func(*config.Rule) {}, false},
+		{"bare event", func(r *config.Rule) { r.Trigger.Event = "long_push" }, false},
+		{"condition trigger", func(r *config.Rule) {
+			r.Trigger = config.RuleTrigger{Device: "hallway", Condition: "switch:0.apower > 100 for 1m"}
+		}, false},
+		{"all action kinds", func(r *config.Rule) {
+			r.Actions = []config.RuleAction{
+				{Device: "kitchen", Action: ActionOn, ID: &id},
+				{Device: "kitchen", Method: "Switch.Set", Params: map[string]any{"id": 0, "on": true}},
+				{Scene: "evening"},
+			}
+		}, false},
+		{"condition", func(r *config.Rule) {
+			r.Conditions = []config.RuleCondition{{Device: "kitchen", Condition: "switch:0.output == false"}}
+		}, false},
+		{"invalid name", func(r *config.Rule) { r.Name = "bad name" }, true},
+		{"no trigger device", func(r *config.Rule) { r.Trigger.Device = "" }, true},
+		{"no trigger", func(r *config.Rule) { r.Trigger.Event = "" }, true},
+		{"event and condition", func(r *config.Rule) { r.Trigger.Condition = "offline" }, true},
+		{"invalid event", func(r *config.Rule) { r.Trigger.Event = ".single_push" }, true},
+		{"invalid trigger condition", func(r *config.Rule) {
+			r.Trigger = config.RuleTrigger{Device: "hallway", Condition: "power >"}
+		}, true},
+		{"condition without device", func(r *config.Rule) {
+			r.Conditions = []config.RuleCondition{{Condition: "online"}}
+		}, true},
+		{"event in condition", func(r *config.Rule) {
+			r.Conditions = []config.RuleCondition{{Device: "kitchen", Condition: "event long_push"}}
+		}, true},
+		{"no actions", func(r *config.Rule) { r.Actions = nil }, true},
+		{"unknown action", func(r *config.Rule) { r.Actions[0].Action = "blink" }, true},
+		{"action without device", func(r *config.Rule) { r.Actions[0].Device = "" }, true},
+		{"two action kinds", func(r *config.Rule) { r.Actions[0].Scene = "evening" }, true},
+		{"scene with device", func(r *config.Rule) {
+			r.Actions = []config.RuleAction{{Device: "kitchen", Scene: "evening"}}
+		}, true},
+		{"invalid cooldown", func(r *config.Rule) { r.Cooldown = "soon" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rule := validRule()
+			tt.modify(&rule)
+			err := Validate(rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	t.Parallel()
+
+	id := 0
+	tests := []struct {
+		action config.RuleAction
+		want   string
+	}{
+		{config.RuleAction{Device: "kitchen", Action: ActionToggle}, "toggle kitchen"},
+		{config.RuleAction{Device: "kitchen", Action: ActionOn, ID: &id}, "on kitchen:0"},
+		{config.RuleAction{Device: "kitchen", Method: "Switch.Set"}, "kitchen Switch.Set"},
+		{config.RuleAction{Scene: "evening"}, "scene evening"},
+	}
+	for _, tt := range tests {
+		if got := DescribeAction(tt.action); got != tt.want {
+			t.Errorf("DescribeAction() = %q, want %q", got, tt.want)
+		}
+	}
+
+	if got := DescribeTrigger(config.RuleTrigger{Device: "hall", Event: "long_push"}); got != "hall long_push" {
+		t.Errorf("DescribeTrigger() = %q", got)
+	}
+	if got := DescribeTrigger(config.RuleTrigger{Device: "hall", Condition: "offline"}); got != "hall when offline" {
+		t.Errorf("DescribeTrigger() = %q", got)
+	}
+}
@@ -0,0 +1,399 @@
+package shelly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// SceneStep is a scene action together with its position in the scene.
+type SceneStep struct {
+	Index  int
+	Action config.SceneAction
+}
+
+// SceneStage is a group of scene actions that run in parallel.
+type SceneStage struct {
+	Group int
+	Steps []SceneStep
+}
+
+// Duration returns the longest delay plus transition in the stage, i.e. the
+// minimum time before the next stage may start.
+func (st SceneStage) Duration() time.Duration {
+	var longest time.Duration
+	for _, step := range st.Steps {
+		longest = max(longest, step.Action.DelayDuration()+step.Action.TransitionDuration())
+	}
+	return longest
+}
+
+// PlanScene splits scene actions into stages by group, in ascending group
+// order. Actions keep their scene order within a stage.
+func PlanScene(actions []config.SceneAction) []SceneStage {
+	byGroup := make(map[int]*SceneStage)
+	for i, action := range actions {
+		stage, ok := byGroup[action.Group]
+		if !ok {
+			stage = &SceneStage{Group: action.Group}
+			byGroup[action.Group] = stage
+		}
+		stage.Steps = append(stage.Steps, SceneStep{Index: i, Action: action})
+	}
+
+	groups := slices.Sorted(maps.Keys(byGroup))
+	stages := make([]SceneStage, 0, len(groups))
+	for _, g := range groups {
+		stages = append(stages, *byGroup[g])
+	}
+	return stages
+}
+
+// SceneActionParams returns the RPC params for an action, adding the
+// device-side transition_duration (in seconds) when a transition is set.
+func SceneActionParams(a config.SceneAction) map[string]any {
+	d := a.TransitionDuration()
+	if d == 0 || !slices.Contains(config.SceneTransitionMethods, a.Method) {
+		return a.Params
+	}
+	params := maps.Clone(a.Params)
+	if params == nil {
+		params = make(map[string]any)
+	}
+	if _, ok := params["transition_duration"]; !ok {
+		params["transition_duration"] = d.Seconds()
+	}
+	return params
+}
+
+// SceneRunOptions controls scene execution.
+type SceneRunOptions struct {
+	// Concurrent limits in-flight RPC calls; 0 means unlimited.
+	Concurrent int
+	// Timeout bounds each RPC call; 0 means no per-call timeout.
+	Timeout time.Duration
+	// OnStart, when set, is called as a step's RPC call begins.
+	OnStart func(SceneStep)
+	// OnDone, when set, is called with each step's result.
+	OnDone func(SceneStep, error)
+}
+
+// SceneCallFunc performs a single scene action with the given params.
+type SceneCallFunc func(ctx context.Context, device, method string, params map[string]any) error
+
+// RunSceneActions runs scene actions stage by stage using call. Within a
+// stage each action waits for its delay, runs, then holds the stage open for
+// its transition, so the next stage starts after lights have finished fading.
+// A failed action does not stop the scene; all failures are returned joined.
+func RunSceneActions(ctx context.Context, actions []config.SceneAction, call SceneCallFunc, opts SceneRunOptions) error {
+	var sem chan struct{}
+	if opts.Concurrent > 0 {
+		sem = make(chan struct{}, opts.Concurrent)
+	}
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	done := func(step SceneStep, err error) {
+		if err != nil {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("%s %s: %w", step.Action.Device, step.Action.Method, err))
+			mu.Unlock()
+		}
+		if opts.OnDone != nil {
+			opts.OnDone(step, err)
+		}
+	}
+
+	for _, stage := range PlanScene(actions) {
+		var wg sync.WaitGroup
+		for _, step := range stage.Steps {
+			wg.Go(func() {
+				if err := sleepCtx(ctx, step.Action.DelayDuration()); err != nil {
+					done(step, err)
+					return
+				}
+				if opts.OnStart != nil {
+					opts.OnStart(step)
+				}
+				err := runSceneStep(ctx, step.Action, call, sem, opts.Timeout)
+				if err == nil {
+					err = sleepCtx(ctx, step.Action.TransitionDuration())
+				}
+				done(step, err)
+			})
+		}
+		wg.Wait()
+	}
+
+	return errors.Join(errs...)
+}
+
+func runSceneStep(ctx context.Context, a config.SceneAction, call SceneCallFunc, sem chan struct{}, timeout time.Duration) error {
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return call(ctx, a.Device, a.Method, SceneActionParams(a))
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunScene runs a scene's actions over RPC. See RunSceneActions.
+func (s *Service) RunScene(ctx context.Context, scene config.Scene, opts SceneRunOptions) error {
+	return RunSceneActions(ctx, scene.Actions, func(ctx context.Context, device, method string, params map[string]any) error {
+		_, err := s.RawRPC(ctx, device, method, params)
+		return err
+	}, opts)
+}
+
+// ActivateScene runs a configured scene and returns the combined errors of
+// any failed actions.
+func (s *Service) ActivateScene(ctx context.Context, name string) error {
+	scene, ok := config.GetScene(name)
+	if !ok {
+		return fmt.Errorf("scene %q not found", name)
+	}
+	return s.RunScene(ctx, scene, SceneRunOptions{Timeout: ruleActionTimeout})
+}
+
+// CaptureScene reads the current state of Gen2+ devices and returns scene
+// actions that restore it.
+func (s *Service) CaptureScene(ctx context.Context, devices []string) ([]config.SceneAction, error) {
+	var actions []config.SceneAction
+	for _, device := range devices {
+		isGen1, _, err := s.IsGen1Device(ctx, device)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", device, err)
+		}
+		if isGen1 {
+			return nil, fmt.Errorf("%s: scene capture requires a Gen2+ device", device)
+		}
+
+		raw, err := s.GetFullStatus(ctx, device)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", device, err)
+		}
+		status := make(map[string]any, len(raw))
+		for key, value := range raw {
+			var v any
+			if json.Unmarshal(value, &v) == nil {
+				status[key] = v
+			}
+		}
+
+		captured := SceneActionsFromStatus(device, status)
+		if len(captured) == 0 {
+			return nil, fmt.Errorf("%s: no controllable components", device)
+		}
+		actions = append(actions, captured...)
+	}
+	return actions, nil
+}
+
+// SnapshotScene captures the current state of every component the scene's
+// actions touch, so that replaying the snapshot's actions undoes the scene.
+// A pending snapshot of the same scene, saved by an earlier activation that
+// was not reverted, is kept as is: only components it does not cover are
+// captured, so activating a scene twice still reverts to the state before
+// the first activation. Devices that cannot be read are left out and
+// returned with their error.
+func (s *Service) SnapshotScene(ctx context.Context, scene config.Scene, pending config.SceneSnapshot) (config.SceneSnapshot, map[string]error) {
+	snap := config.SceneSnapshot{Scene: scene.Name, Time: time.Now()}
+	if pending.Scene == scene.Name && len(pending.Actions) > 0 {
+		snap = pending
+	}
+
+	devices, touched := snapshotTargets(scene, snap)
+	skipped := make(map[string]error)
+	for _, device := range devices {
+		captured, err := s.CaptureScene(ctx, []string{device})
+		if err != nil {
+			skipped[device] = err
+			continue
+		}
+		for _, action := range captured {
+			if comp, _ := SceneActionComponent(action); touched[device][comp] {
+				snap.Actions = append(snap.Actions, action)
+			}
+		}
+	}
+	return snap, skipped
+}
+
+// snapshotTargets returns the devices whose state a snapshot of scene still
+// needs, in action order, and the components to capture on each. Components
+// already restored by snap are left out.
+func snapshotTargets(scene config.Scene, snap config.SceneSnapshot) ([]string, map[string]map[string]bool) {
+	covered := make(map[string]bool)
+	for _, action := range snap.Actions {
+		if comp, ok := SceneActionComponent(action); ok {
+			covered[action.Device+"/"+comp] = true
+		}
+	}
+
+	touched := make(map[string]map[string]bool)
+	var devices []string
+	for _, action := range scene.Actions {
+		comp, ok := SceneActionComponent(action)
+		if !ok || covered[action.Device+"/"+comp] {
+			continue
+		}
+		if touched[action.Device] == nil {
+			touched[action.Device] = make(map[string]bool)
+			devices = append(devices, action.Device)
+		}
+		touched[action.Device][comp] = true
+	}
+	return devices, touched
+}
+
+// RevertScene replays a snapshot's actions and, when all of them succeed,
+// deletes the stored snapshot so it is not applied twice.
+func (s *Service) RevertScene(ctx context.Context, snap config.SceneSnapshot, opts SceneRunOptions) error {
+	if err := s.RunScene(ctx, config.Scene{Name: snap.Scene, Actions: snap.Actions}, opts); err != nil {
+		return err
+	}
+	return config.DeleteSceneSnapshot(snap.Scene)
+}
+
+// SceneActionComponent returns the component key (e.g. "light:0") an action
+// controls, derived from its method namespace and "id" param. Only
+// components that SceneActionsFromStatus can restore are reported.
+func SceneActionComponent(a config.SceneAction) (string, bool) {
+	ns, _, ok := strings.Cut(a.Method, ".")
+	if !ok {
+		return "", false
+	}
+	kind := strings.ToLower(ns)
+	switch kind {
+	case "switch", "light", "rgb", "rgbw", "cct", "cover":
+	default:
+		return "", false
+	}
+
+	id := 0
+	switch v := a.Params["id"].(type) {
+	case int:
+		id = v
+	case float64:
+		id = int(v)
+	case uint64:
+		id = int(v)
+	}
+	return fmt.Sprintf("%s:%d", kind, id), true
+}
+
+// SceneActionsFromStatus builds actions that restore the switch, light,
+// RGB(W), CCT and cover components in a Gen2+ Shelly.GetStatus result.
+func SceneActionsFromStatus(device string, status map[string]any) []config.SceneAction {
+	keys := make([]string, 0, len(status))
+	for key := range status {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var actions []config.SceneAction
+	for _, key := range keys {
+		kind, idStr, ok := strings.Cut(key, ":")
+		if !ok {
+			continue
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			continue
+		}
+		comp, ok := status[key].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		params := map[string]any{"id": id}
+		method := ""
+		switch kind {
+		case "switch":
+			method = "Switch.Set"
+			copyStatusFields(params, comp, "output:on")
+		case "light":
+			method = "Light.Set"
+			copyStatusFields(params, comp, "output:on", "brightness")
+		case "rgb":
+			method = "RGB.Set"
+			copyStatusFields(params, comp, "output:on", "brightness", "rgb")
+		case "rgbw":
+			method = "RGBW.Set"
+			copyStatusFields(params, comp, "output:on", "brightness", "rgb", "white")
+		case "cct":
+			method = "CCT.Set"
+			copyStatusFields(params, comp, "output:on", "brightness", "ct")
+		case "cover":
+			method, params = coverRestoreAction(id, comp)
+		}
+		if method == "" {
+			continue
+		}
+		actions = append(actions, config.SceneAction{Device: device, Method: method, Params: params})
+	}
+	return actions
+}
+
+// copyStatusFields copies status fields into params. A field written as
+// "status:param" is renamed.
+func copyStatusFields(params, comp map[string]any, fields ...string) {
+	for _, field := range fields {
+		from, to, ok := strings.Cut(field, ":")
+		if !ok {
+			to = from
+		}
+		if v, ok := comp[from]; ok {
+			params[to] = v
+		}
+	}
+}
+
+// coverRestoreAction returns a GoToPosition action for calibrated covers and
+// Open/Close for uncalibrated ones at an end stop.
+func coverRestoreAction(id int, comp map[string]any) (string, map[string]any) {
+	if pos, ok := comp["current_pos"].(float64); ok {
+		return "Cover.GoToPosition", map[string]any{"id": id, "pos": int(pos)}
+	}
+	switch comp["state"] {
+	case "open":
+		return "Cover.Open", map[string]any{"id": id}
+	case "closed":
+		return "Cover.Close", map[string]any{"id": id}
+	}
+	return "", nil
+}
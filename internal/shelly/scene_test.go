@@ -0,0 +1,230 @@
+package shelly
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+func TestPlanScene(t *testing.T) {
+	t.Parallel()
+
+	actions := []config.SceneAction{
+		{Device: "cover", Method: "Cover.Close", Group: 2},
+		{Device: "lamp", Method: "Light.Set", Transition: "3s"},
+		{Device: "strip", Method: "RGB.Set", Delay: "1s", Transition: "1s"},
+		{Device: "tv", Method: "Switch.Set", Group: 1},
+	}
+
+	stages := PlanScene(actions)
+	if len(stages) != 3 {
+		t.Fatalf("len(stages) = %d, want 3", len(stages))
+	}
+	if stages[0].Group != 0 || len(stages[0].Steps) != 2 || stages[0].Steps[0].Index != 1 {
+		t.Errorf("stages[0] = %+v", stages[0])
+	}
+	if stages[1].Group != 1 || stages[2].Group != 2 || stages[2].Steps[0].Index != 0 {
+		t.Errorf("stage order = %d, %d", stages[1].Group, stages[2].Group)
+	}
+	if d := stages[0].Duration(); d != 3*time.Second {
+		t.Errorf("stages[0].Duration() = %v, want 3s", d)
+	}
+}
+
+func TestSceneActionParams(t *testing.T) {
+	t.Parallel()
+
+	a := config.SceneAction{Device: "lamp", Method: "Light.Set", Params: map[string]any{"id": 0, "brightness": 10}, Transition: "2500ms"}
+	params := SceneActionParams(a)
+	if params["transition_duration"] != 2.5 {
+		t.Errorf("transition_duration = %v, want 2.5", params["transition_duration"])
+	}
+	if _, ok := a.Params["transition_duration"]; ok {
+		t.Error("action params mutated")
+	}
+
+	a.Params["transition_duration"] = 1.0
+	if got := SceneActionParams(a)["transition_duration"]; got != 1.0 {
+		t.Errorf("explicit transition_duration overridden: %v", got)
+	}
+
+	plain := config.SceneAction{Device: "tv", Method: "Switch.Set"}
+	if params := SceneActionParams(plain); params != nil {
+		t.Errorf("params = %v, want nil", params)
+	}
+}
+
+func TestRunSceneActions_Ordering(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		calls []string
+		start = time.Now()
+		at    = map[string]time.Duration{}
+	)
+	call := func(_ context.Context, device, method string, params map[string]any) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, device)
+		at[device] = time.Since(start)
+		if device == "broken" {
+			return errors.New("boom")
+		}
+		if device == "lamp" && params["transition_duration"] == nil {
+			t.Errorf("lamp params = %v, want transition_duration", params)
+		}
+		return nil
+	}
+
+	actions := []config.SceneAction{
+		{Device: "cover", Method: "Cover.Close", Group: 1},
+		{Device: "lamp", Method: "Light.Set", Transition: "60ms"},
+		{Device: "broken", Method: "Switch.Set", Delay: "20ms"},
+	}
+	var done []int
+	err := RunSceneActions(context.Background(), actions, call, SceneRunOptions{
+		Concurrent: 1,
+		OnDone: func(step SceneStep, _ error) {
+			mu.Lock()
+			done = append(done, step.Index)
+			mu.Unlock()
+		},
+	})
+	if err == nil {
+		t.Error("expected error from failed action")
+	}
+	if len(calls) != 3 || calls[2] != "cover" {
+		t.Fatalf("calls = %v, want cover last", calls)
+	}
+	if at["broken"] < 20*time.Millisecond {
+		t.Errorf("delayed action ran after %v, want >= 20ms", at["broken"])
+	}
+	if at["cover"] < 60*time.Millisecond {
+		t.Errorf("group 1 started after %v, want after the 60ms transition", at["cover"])
+	}
+	if len(done) != 3 {
+		t.Errorf("OnDone called %d times, want 3", len(done))
+	}
+}
+
+func TestRunSceneActions_Cancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err := RunSceneActions(ctx, []config.SceneAction{{Device: "a", Method: "Switch.Set", Delay: "1h"}},
+		func(context.Context, string, string, map[string]any) error {
+			called = true
+			return nil
+		}, SceneRunOptions{})
+	if !errors.Is(err, context.Canceled) || called {
+		t.Errorf("err = %v, called = %v", err, called)
+	}
+}
+
+func TestSceneActionsFromStatus(t *testing.T) {
+	t.Parallel()
+
+	status := map[string]any{
+		"sys":      map[string]any{"uptime": 10.0},
+		"switch:0": map[string]any{"output": true, "apower": 12.0},
+		"light:1":  map[string]any{"output": false, "brightness": 40.0},
+		"rgbw:0":   map[string]any{"output": true, "brightness": 80.0, "rgb": []any{255.0, 0.0, 0.0}, "white": 10.0},
+		"cover:0":  map[string]any{"state": "stopped", "current_pos": 35.0},
+		"cover:1":  map[string]any{"state": "closed"},
+		"cover:2":  map[string]any{"state": "stopped"},
+		"input:0":  map[string]any{"state": false},
+	}
+
+	actions := SceneActionsFromStatus("den", status)
+	want := map[string]string{
+		"Switch.Set":         "on",
+		"Light.Set":          "brightness",
+		"RGBW.Set":           "white",
+		"Cover.GoToPosition": "pos",
+		"Cover.Close":        "id",
+	}
+	if len(actions) != len(want) {
+		t.Fatalf("actions = %+v, want %d", actions, len(want))
+	}
+	for _, a := range actions {
+		field, ok := want[a.Method]
+		if !ok {
+			t.Errorf("unexpected action %s", a.Method)
+			continue
+		}
+		if a.Device != "den" {
+			t.Errorf("%s device = %q", a.Method, a.Device)
+		}
+		if _, ok := a.Params[field]; !ok {
+			t.Errorf("%s params = %v, missing %s", a.Method, a.Params, field)
+		}
+	}
+	if actions[0].Method != "Cover.GoToPosition" || actions[0].Params["pos"] != 35 {
+		t.Errorf("actions[0] = %+v", actions[0])
+	}
+}
+
+func TestSceneActionComponent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		action config.SceneAction
+		want   string
+		ok     bool
+	}{
+		{config.SceneAction{Method: "Light.Set", Params: map[string]any{"id": 1}}, "light:1", true},
+		{config.SceneAction{Method: "Switch.Toggle"}, "switch:0", true},
+		{config.SceneAction{Method: "Cover.GoToPosition", Params: map[string]any{"id": 2.0}}, "cover:2", true},
+		{config.SceneAction{Method: "RGBW.Set", Params: map[string]any{"id": 0}}, "rgbw:0", true},
+		{config.SceneAction{Method: "Script.Start", Params: map[string]any{"id": 1}}, "", false},
+		{config.SceneAction{Method: "Reboot"}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := SceneActionComponent(tt.action)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("SceneActionComponent(%s) = %q, %v; want %q, %v", tt.action.Method, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// Captured restore actions map back to the component they were read from.
+	for _, a := range SceneActionsFromStatus("d", map[string]any{"light:3": map[string]any{"output": true}}) {
+		if comp, _ := SceneActionComponent(a); comp != "light:3" {
+			t.Errorf("restore action component = %q, want light:3", comp)
+		}
+	}
+}
+
+func TestSnapshotTargets(t *testing.T) {
+	t.Parallel()
+
+	scene := config.Scene{Name: "movie", Actions: []config.SceneAction{
+		{Device: "lamp", Method: "Light.Set", Params: map[string]any{"id": 0}},
+		{Device: "lamp", Method: "Light.Set", Params: map[string]any{"id": 1}},
+		{Device: "blinds", Method: "Cover.Close"},
+		{Device: "tv", Method: "Script.Start", Params: map[string]any{"id": 1}},
+	}}
+
+	devices, touched := snapshotTargets(scene, config.SceneSnapshot{})
+	if strings.Join(devices, ",") != "lamp,blinds" || !touched["lamp"]["light:1"] || !touched["blinds"]["cover:0"] {
+		t.Errorf("empty snapshot: devices = %v, touched = %v", devices, touched)
+	}
+
+	// A pending snapshot keeps its components; only the rest are captured.
+	pending := config.SceneSnapshot{Scene: "movie", Actions: []config.SceneAction{
+		{Device: "lamp", Method: "Light.Set", Params: map[string]any{"id": 0, "on": false}},
+		{Device: "blinds", Method: "Cover.GoToPosition", Params: map[string]any{"id": 0, "pos": 100}},
+	}}
+	devices, touched = snapshotTargets(scene, pending)
+	if strings.Join(devices, ",") != "lamp" || touched["lamp"]["light:0"] || !touched["lamp"]["light:1"] {
+		t.Errorf("pending snapshot: devices = %v, touched = %v", devices, touched)
+	}
+}
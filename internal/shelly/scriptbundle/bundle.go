@@ -0,0 +1,389 @@
+// Package scriptbundle bundles a device script and the local helper
+// modules it imports into a single file for upload. Comments are stripped,
+// names can be shortened, and constructs the device script engine does
+// not support are rejected before anything reaches a device.
+package scriptbundle
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptjs"
+)
+
+// DefaultMaxSize is the default size limit of a bundled script, in bytes.
+const DefaultMaxSize = 16 * 1024
+
+// Options control bundling.
+type Options struct {
+	// Minify removes all unneeded whitespace and shortens declared names.
+	Minify bool
+	// Allow lists check rules to skip.
+	Allow []string
+}
+
+// Module is a file included in a bundle.
+type Module struct {
+	File string `json:"file"`
+	Size int    `json:"size"`
+}
+
+// Result is a bundled script.
+type Result struct {
+	Code    string   `json:"-"`
+	Size    int      `json:"size"`
+	Modules []Module `json:"modules"`
+}
+
+// Bundle reads the script entry and every local module it imports or
+// requires, and returns them as one script. Modules are included once,
+// dependencies first, each wrapped in its own function scope. A *CheckError
+// is returned when unsupported constructs are found.
+func Bundle(entry string, opts Options) (*Result, error) {
+	b := &bundler{
+		allow:   make(map[string]bool),
+		modules: make(map[string]*module),
+		vars:    make(map[string]bool),
+	}
+	for _, rule := range opts.Allow {
+		if _, ok := Rules[rule]; !ok {
+			return nil, fmt.Errorf("unknown check rule %q", rule)
+		}
+		b.allow[rule] = true
+	}
+
+	entry = filepath.Clean(entry)
+	if err := b.load(entry, nil); err != nil {
+		return nil, err
+	}
+	if len(b.problems) > 0 {
+		return nil, &CheckError{Problems: b.problems}
+	}
+
+	var out strings.Builder
+	for _, m := range b.order {
+		code, err := b.transform(m, m.file == entry)
+		if err != nil {
+			return nil, err
+		}
+		if m.file == entry {
+			out.WriteString(code)
+			continue
+		}
+		fmt.Fprintf(&out, "let %s = (function () {\nlet module = { exports: {} };\nlet exports = module.exports;\n%s\nreturn module.exports;\n})();\n", m.name, code)
+	}
+
+	toks, err := scriptjs.Lex(out.String())
+	if err != nil {
+		return nil, fmt.Errorf("bundle: %w", err)
+	}
+	if opts.Minify {
+		toks = renameLocals(toks)
+	}
+	code := render(toks, opts.Minify)
+
+	res := &Result{Code: code, Size: len(code)}
+	for _, m := range b.order {
+		res.Modules = append(res.Modules, Module{File: m.file, Size: m.size})
+	}
+	return res, nil
+}
+
+type module struct {
+	file       string
+	name       string
+	size       int
+	toks       []scriptjs.Token
+	deps       map[string]string // import specifier to file
+	hasDefault bool
+}
+
+type bundler struct {
+	allow    map[string]bool
+	modules  map[string]*module
+	order    []*module
+	vars     map[string]bool
+	problems []Problem
+}
+
+func (b *bundler) load(file string, stack []string) error {
+	if i := slices.Index(stack, file); i >= 0 {
+		return fmt.Errorf("circular import: %s", strings.Join(append(stack[i:], file), " -> "))
+	}
+	if _, ok := b.modules[file]; ok {
+		return nil
+	}
+
+	data, err := afero.ReadFile(config.Fs(), file)
+	if err != nil {
+		return fmt.Errorf("read module: %w", err)
+	}
+	toks, err := scriptjs.Lex(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	b.problems = append(b.problems, check(file, toks, b.allow)...)
+
+	m := &module{file: file, name: b.varName(file), size: len(data), toks: stripComments(toks), deps: make(map[string]string)}
+	for i, t := range m.toks {
+		spec, ok := importSpec(m.toks, i)
+		if !ok {
+			if t.Is(scriptjs.Ident, "export") && i+1 < len(m.toks) && m.toks[i+1].Is(scriptjs.Ident, "default") {
+				m.hasDefault = true
+			}
+			continue
+		}
+		dep, err := resolve(file, spec)
+		if err != nil {
+			return fmt.Errorf("%s:%d:%d: %w", file, t.Line, t.Col, err)
+		}
+		m.deps[spec] = dep
+		if err := b.load(dep, append(stack, file)); err != nil {
+			return err
+		}
+	}
+
+	b.modules[file] = m
+	b.order = append(b.order, m)
+	return nil
+}
+
+// importSpec returns the module specifier when toks[i] starts an import
+// statement or a require("...") call.
+func importSpec(toks []scriptjs.Token, i int) (string, bool) {
+	t := toks[i]
+	if t.Kind != scriptjs.Ident || (i > 0 && toks[i-1].Is(scriptjs.Punct, ".")) {
+		return "", false
+	}
+	switch t.Text {
+	case "require":
+		if i+3 < len(toks) && toks[i+1].Is(scriptjs.Punct, "(") && toks[i+2].Kind == scriptjs.String && toks[i+3].Is(scriptjs.Punct, ")") {
+			return unquote(toks[i+2].Text), true
+		}
+	case "import":
+		for j := i + 1; j < len(toks); j++ {
+			if toks[j].Kind == scriptjs.String {
+				return unquote(toks[j].Text), true
+			}
+			if toks[j].Is(scriptjs.Punct, ";") || toks[j].Is(scriptjs.Punct, "(") {
+				break
+			}
+		}
+	}
+	return "", false
+}
+
+// resolve finds the file of a relative module specifier: the path itself,
+// with ".js" added, or its index.js.
+func resolve(from, spec string) (string, error) {
+	if !strings.HasPrefix(spec, "./") && !strings.HasPrefix(spec, "../") {
+		return "", fmt.Errorf("cannot bundle %q: only local modules (./ or ../) can be imported", spec)
+	}
+	base := filepath.Join(filepath.Dir(from), filepath.FromSlash(spec))
+	for _, candidate := range []string{base, base + ".js", filepath.Join(base, "index.js")} {
+		if info, err := config.Fs().Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find module %q", spec)
+}
+
+// varName returns a unique variable name for the exports of file.
+func (b *bundler) varName(file string) string {
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	base = strings.Map(func(r rune) rune {
+		if scriptjs.IsIdentPart(r) && r < unicode.MaxASCII {
+			return r
+		}
+		return '_'
+	}, base)
+	name := "__" + base
+	for n := 2; b.vars[name]; n++ {
+		name = fmt.Sprintf("__%s%d", base, n)
+	}
+	b.vars[name] = true
+	return name
+}
+
+// transform returns the code of a module with its imports bound to the
+// exports of its dependencies and its exports assigned to module.exports.
+// The entry script has nothing to export to, so its exports are dropped.
+func (b *bundler) transform(m *module, entry bool) (string, error) {
+	var out strings.Builder
+	var exports [][2]string // local name, exported name
+	toks := slices.Clone(m.toks)
+	errorf := func(t scriptjs.Token, format string, args ...any) error {
+		return fmt.Errorf("%s:%d:%d: %s", m.file, t.Line, t.Col, fmt.Sprintf(format, args...))
+	}
+
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		spec, isImport := importSpec(toks, i)
+		switch {
+		case isImport && t.Text == "require":
+			out.WriteString(t.Space + b.modules[m.deps[spec]].name)
+			i += 3
+		case isImport:
+			end, decl, err := b.importDecl(m, toks, i, spec)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(t.Space + decl)
+			i = end
+		case t.Is(scriptjs.Ident, "export") && (i == 0 || !toks[i-1].Is(scriptjs.Punct, ".")):
+			end, names, err := exportDecl(toks, i)
+			if err != nil {
+				return "", errorf(t, "%v", err)
+			}
+			exports = append(exports, names...)
+			if end > i {
+				i = end
+				continue
+			}
+			if toks[i+1].Is(scriptjs.Ident, "default") {
+				i++
+				if !entry {
+					out.WriteString(t.Space + "module.exports.default =")
+					continue
+				}
+			}
+			// The keywords are dropped; what follows takes their place.
+			out.WriteString(t.Space)
+			if i+1 < len(toks) {
+				toks[i+1].Space = ""
+			}
+		default:
+			out.WriteString(t.Space + t.Text)
+		}
+	}
+	if entry {
+		return out.String(), nil
+	}
+	for _, e := range exports {
+		fmt.Fprintf(&out, "\nmodule.exports.%s = %s;", e[1], e[0])
+	}
+	return out.String(), nil
+}
+
+// importDecl turns the import statement at toks[i] into variable
+// declarations and returns the index of its last token.
+func (b *bundler) importDecl(m *module, toks []scriptjs.Token, i int, spec string) (int, string, error) {
+	dep := b.modules[m.deps[spec]]
+	var bindings []string
+	bind := func(local, expr string) { bindings = append(bindings, local+" = "+expr) }
+
+	j := i + 1
+	if toks[j].Kind == scriptjs.Ident && toks[j].Text != "from" {
+		if dep.hasDefault {
+			bind(toks[j].Text, dep.name+".default")
+		} else {
+			bind(toks[j].Text, dep.name)
+		}
+		j++
+		if toks[j].Is(scriptjs.Punct, ",") {
+			j++
+		}
+	}
+	switch {
+	case toks[j].Is(scriptjs.Punct, "*"):
+		if j+2 >= len(toks) || !toks[j+1].Is(scriptjs.Ident, "as") || toks[j+2].Kind != scriptjs.Ident {
+			return 0, "", fmt.Errorf("%s:%d:%d: expected \"* as name\"", m.file, toks[j].Line, toks[j].Col)
+		}
+		bind(toks[j+2].Text, dep.name)
+		j += 3
+	case toks[j].Is(scriptjs.Punct, "{"):
+		for j++; j < len(toks) && !toks[j].Is(scriptjs.Punct, "}"); j++ {
+			if toks[j].Is(scriptjs.Punct, ",") {
+				continue
+			}
+			name, local := toks[j].Text, toks[j].Text
+			if j+2 < len(toks) && toks[j+1].Is(scriptjs.Ident, "as") {
+				local = toks[j+2].Text
+				j += 2
+			}
+			bind(local, dep.name+"."+name)
+		}
+		j++
+	}
+	for j < len(toks) && toks[j].Kind != scriptjs.String {
+		j++
+	}
+	if j+1 < len(toks) && toks[j+1].Is(scriptjs.Punct, ";") {
+		j++
+	}
+	if len(bindings) == 0 {
+		return j, "", nil
+	}
+	return j, "let " + strings.Join(bindings, ", ") + ";", nil
+}
+
+// exportDecl handles the export statement at toks[i]. It returns the
+// exported names and, when the whole statement is consumed (export
+// lists), the index of its last token. For declarations only the export
+// keyword is consumed and the declaration is kept.
+func exportDecl(toks []scriptjs.Token, i int) (int, [][2]string, error) {
+	if i+1 >= len(toks) {
+		return 0, nil, fmt.Errorf("unexpected end of module after export")
+	}
+	next := toks[i+1]
+	switch {
+	case next.Is(scriptjs.Ident, "default"):
+		return i, nil, nil
+	case next.Is(scriptjs.Ident, "function"):
+		j := i + 2
+		if j < len(toks) && toks[j].Is(scriptjs.Punct, "*") {
+			j++
+		}
+		if j < len(toks) && toks[j].Kind == scriptjs.Ident {
+			return i, [][2]string{{toks[j].Text, toks[j].Text}}, nil
+		}
+	case next.Is(scriptjs.Ident, "let"), next.Is(scriptjs.Ident, "const"), next.Is(scriptjs.Ident, "var"):
+		if i+2 < len(toks) && toks[i+2].Kind == scriptjs.Ident {
+			return i, [][2]string{{toks[i+2].Text, toks[i+2].Text}}, nil
+		}
+	case next.Is(scriptjs.Punct, "{"):
+		var names [][2]string
+		j := i + 2
+		for ; j < len(toks) && !toks[j].Is(scriptjs.Punct, "}"); j++ {
+			if toks[j].Is(scriptjs.Punct, ",") {
+				continue
+			}
+			local, name := toks[j].Text, toks[j].Text
+			if j+2 < len(toks) && toks[j+1].Is(scriptjs.Ident, "as") {
+				name = toks[j+2].Text
+				j += 2
+			}
+			names = append(names, [2]string{local, name})
+		}
+		if j+1 < len(toks) && toks[j+1].Is(scriptjs.Ident, "from") {
+			return 0, nil, fmt.Errorf("re-exports are not supported; import the names first")
+		}
+		if j+1 < len(toks) && toks[j+1].Is(scriptjs.Punct, ";") {
+			j++
+		}
+		return j, names, nil
+	}
+	return 0, nil, fmt.Errorf("unsupported export form %q", next.Text)
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// CheckSize returns an error when a bundle of size bytes is over limit.
+// A limit of zero or less disables the check.
+func CheckSize(size, limit int) error {
+	if limit > 0 && size > limit {
+		return fmt.Errorf("bundle is %d bytes, %d over the %d byte limit", size, size-limit, limit)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package scriptbundle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptjs"
+)
+
+// Rules for constructs the device script engine does not support.
+const (
+	RuleClass     = "class"
+	RuleAsync     = "async"
+	RulePromise   = "promise"
+	RuleGenerator = "generator"
+	RuleModule    = "module"
+	// RuleDestructuring rejects destructuring declarations and parameters.
+	RuleDestructuring = "destructuring"
+	// RuleAccessor rejects getters and setters in object literals.
+	RuleAccessor = "accessor"
+)
+
+// Rules lists every check rule with what it rejects.
+var Rules = map[string]string{
+	RuleClass:     "class declarations",
+	RuleAsync:     "async functions and await",
+	RulePromise:   "Promise",
+	RuleGenerator: "generator functions and yield",
+	RuleModule:    "dynamic import()",
+
+	RuleDestructuring: "destructuring declarations and parameters",
+	RuleAccessor:      "getters and setters",
+}
+
+// Problem is an unsupported construct found in a script.
+type Problem struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Col     int    `json:"col"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", p.File, p.Line, p.Col, p.Message, p.Rule)
+}
+
+// CheckError reports the unsupported constructs that stopped a bundle.
+type CheckError struct {
+	Problems []Problem
+}
+
+func (e *CheckError) Error() string {
+	if len(e.Problems) == 1 {
+		return "unsupported construct: " + e.Problems[0].String()
+	}
+	lines := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		lines = append(lines, p.String())
+	}
+	return fmt.Sprintf("%d unsupported constructs:\n  %s", len(e.Problems), strings.Join(lines, "\n  "))
+}
+
+// check finds constructs the engine does not support, skipping allowed
+// rules.
+func check(file string, toks []scriptjs.Token, allow map[string]bool) []Problem {
+	var problems []Problem
+	report := func(t scriptjs.Token, rule, msg string) {
+		if !allow[rule] {
+			problems = append(problems, Problem{File: file, Line: t.Line, Col: t.Col, Rule: rule, Message: msg})
+		}
+	}
+
+	code := stripComments(toks)
+	for i, t := range code {
+		if t.Kind != scriptjs.Ident || (i > 0 && (code[i-1].Is(scriptjs.Punct, ".") || code[i-1].Is(scriptjs.Punct, "?."))) {
+			continue
+		}
+		prev, next, after := tokenAt(code, i-1), tokenAt(code, i+1), tokenAt(code, i+2)
+		switch t.Text {
+		case "class":
+			report(t, RuleClass, "classes are not supported")
+		case "async":
+			if next.Is(scriptjs.Ident, "function") || next.Is(scriptjs.Punct, "(") || next.Kind == scriptjs.Ident {
+				report(t, RuleAsync, "async functions are not supported")
+			}
+		case "await":
+			report(t, RuleAsync, "await is not supported")
+		case "Promise":
+			report(t, RulePromise, "Promise is not supported")
+		case "yield":
+			report(t, RuleGenerator, "generators are not supported")
+		case "function":
+			if next.Is(scriptjs.Punct, "*") {
+				report(t, RuleGenerator, "generators are not supported")
+			}
+			if p, ok := destructuredParam(code, i+1); ok {
+				report(p, RuleDestructuring, "destructuring is not supported")
+			}
+		case "let", "const", "var":
+			if next.Is(scriptjs.Punct, "{") || next.Is(scriptjs.Punct, "[") {
+				report(next, RuleDestructuring, "destructuring is not supported")
+			}
+		case "get", "set":
+			if (prev.Is(scriptjs.Punct, "{") || prev.Is(scriptjs.Punct, ",")) &&
+				next.Kind == scriptjs.Ident && after.Is(scriptjs.Punct, "(") {
+				report(t, RuleAccessor, "getters and setters are not supported")
+			}
+		case "import":
+			if next.Is(scriptjs.Punct, "(") {
+				report(t, RuleModule, "dynamic import() is not supported")
+			}
+		}
+	}
+	return problems
+}
+
+// tokenAt returns the token at i, or the zero token when i is out of range.
+func tokenAt(toks []scriptjs.Token, i int) scriptjs.Token {
+	if i < 0 || i >= len(toks) {
+		return scriptjs.Token{}
+	}
+	return toks[i]
+}
+
+// destructuredParam finds a destructuring pattern in the parameter list of
+// the function whose name or "(" is at i, and returns its first token.
+func destructuredParam(toks []scriptjs.Token, i int) (scriptjs.Token, bool) {
+	for i < len(toks) && !toks[i].Is(scriptjs.Punct, "(") {
+		if toks[i].Is(scriptjs.Punct, "{") {
+			return scriptjs.Token{}, false
+		}
+		i++
+	}
+	depth := 0
+	for ; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case t.Is(scriptjs.Punct, "(") || t.Is(scriptjs.Punct, "[") || t.Is(scriptjs.Punct, "{"):
+			if depth == 1 && !t.Is(scriptjs.Punct, "(") {
+				if prev := toks[i-1]; prev.Is(scriptjs.Punct, "(") || prev.Is(scriptjs.Punct, ",") {
+					return t, true
+				}
+			}
+			depth++
+		case t.Is(scriptjs.Punct, ")") || t.Is(scriptjs.Punct, "]") || t.Is(scriptjs.Punct, "}"):
+			depth--
+			if depth == 0 {
+				return scriptjs.Token{}, false
+			}
+		}
+	}
+	return scriptjs.Token{}, false
+}
@@ -0,0 +1,359 @@
+package scriptbundle
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptjs"
+)
+
+// reserved words and literals are never used as names.
+var reserved = setOf(
+	"break", "case", "catch", "class", "const", "continue", "debugger", "default", "delete", "do",
+	"else", "export", "extends", "false", "finally", "for", "function", "if", "import", "in",
+	"instanceof", "let", "new", "null", "return", "super", "switch", "this", "throw", "true",
+	"try", "typeof", "var", "void", "while", "with", "yield", "await", "async", "of", "static",
+	"enum", "implements", "interface", "package", "private", "protected", "public",
+	"arguments", "undefined", "NaN", "Infinity", "eval",
+)
+
+// engineGlobals are provided by the device script engine. They are never
+// renamed, even where a script declares a local of the same name.
+var engineGlobals = setOf(
+	"Shelly", "Timer", "MQTT", "BLE", "HTTPServer", "Virtual", "Script", "KVS", "Cloud",
+	"print", "console", "die", "atob", "btoa", "require", "module", "exports",
+	"JSON", "Math", "Date", "String", "Number", "Boolean", "Object", "Array", "Function",
+	"Error", "TypeError", "RangeError", "SyntaxError", "ReferenceError", "RegExp",
+	"ArrayBuffer", "DataView", "Uint8Array", "Int8Array", "Uint16Array", "Int16Array",
+	"Uint32Array", "Int32Array", "Float32Array", "Float64Array",
+	"parseInt", "parseFloat", "isNaN", "isFinite", "chr", "globalThis",
+)
+
+var templateIdentRe = regexp.MustCompile(`[A-Za-z_$][\w$]*`)
+
+func setOf(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+// renameLocals gives the names a script declares shorter ones. A name is
+// renamed the same way everywhere, so shadowing keeps working, but
+// property names and object keys are left alone; shorthand properties are
+// expanded to keep their key.
+func renameLocals(toks []scriptjs.Token) []scriptjs.Token {
+	toks = stripComments(toks)
+	roles := classify(toks)
+
+	used := make(map[string]bool)
+	pinned := make(map[string]bool)
+	count := make(map[string]int)
+	for i, t := range toks {
+		switch t.Kind {
+		case scriptjs.Ident:
+			used[t.Text] = true
+			if roles[i] != roleKey && roles[i] != roleProperty {
+				count[t.Text]++
+			}
+		case scriptjs.Template:
+			// Names inside ${...} are not tokenized, so keep them as they are.
+			for _, name := range templateIdentRe.FindAllString(t.Text, -1) {
+				used[name] = true
+				pinned[name] = true
+			}
+		}
+	}
+
+	declared := declaredNames(toks)
+	var candidates []string
+	for name := range declared {
+		if count[name] > 0 && !pinned[name] && !reserved[name] && !engineGlobals[name] && len(name) > 1 {
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if count[candidates[i]] != count[candidates[j]] {
+			return count[candidates[i]] > count[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	names := make(map[string]string, len(candidates))
+	gen := newNameGen(used)
+	for _, name := range candidates {
+		short := gen.next()
+		if len(short) >= len(name) {
+			break
+		}
+		names[name] = short
+	}
+
+	out := make([]scriptjs.Token, 0, len(toks))
+	for i, t := range toks {
+		short, ok := names[t.Text]
+		if t.Kind != scriptjs.Ident || !ok {
+			out = append(out, t)
+			continue
+		}
+		switch roles[i] {
+		case roleKey, roleProperty:
+			out = append(out, t)
+		case roleShorthand:
+			out = append(out, t,
+				scriptjs.Token{Kind: scriptjs.Punct, Text: ":"},
+				scriptjs.Token{Kind: scriptjs.Ident, Text: short})
+		default:
+			t.Text = short
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// stripComments drops comment tokens, moving their line breaks to the
+// following token.
+func stripComments(toks []scriptjs.Token) []scriptjs.Token {
+	out := make([]scriptjs.Token, 0, len(toks))
+	pending := ""
+	for _, t := range toks {
+		if t.Kind == scriptjs.Comment {
+			pending += t.Space
+			if strings.Contains(t.Text, "\n") {
+				pending += "\n"
+			}
+			continue
+		}
+		t.Space = pending + t.Space
+		pending = ""
+		out = append(out, t)
+	}
+	return out
+}
+
+type identRole int
+
+const (
+	roleName identRole = iota
+	roleProperty
+	roleKey
+	roleShorthand
+)
+
+// classify finds the identifiers that are property names, object keys and
+// shorthand properties rather than references to a name.
+func classify(toks []scriptjs.Token) []identRole {
+	roles := make([]identRole, len(toks))
+	var objects []bool // brace stack: true for object literals
+	for i, t := range toks {
+		if t.Kind == scriptjs.Punct {
+			switch t.Text {
+			case "{":
+				objects = append(objects, i > 0 && opensObject(toks[i-1]))
+			case "}":
+				if len(objects) > 0 {
+					objects = objects[:len(objects)-1]
+				}
+			}
+			continue
+		}
+		if t.Kind != scriptjs.Ident || i == 0 {
+			continue
+		}
+		prev := toks[i-1]
+		if prev.Is(scriptjs.Punct, ".") || prev.Is(scriptjs.Punct, "?.") {
+			roles[i] = roleProperty
+			continue
+		}
+		inObject := len(objects) > 0 && objects[len(objects)-1]
+		if !inObject || !(prev.Is(scriptjs.Punct, "{") || prev.Is(scriptjs.Punct, ",")) || i+1 >= len(toks) {
+			continue
+		}
+		switch next := toks[i+1]; {
+		case next.Is(scriptjs.Punct, ":"), next.Is(scriptjs.Punct, "("):
+			roles[i] = roleKey
+		case next.Is(scriptjs.Punct, ","), next.Is(scriptjs.Punct, "}"):
+			roles[i] = roleShorthand
+		}
+	}
+	return roles
+}
+
+// opensObject reports whether a "{" after prev starts an object literal
+// (or destructuring pattern) rather than a block.
+func opensObject(prev scriptjs.Token) bool {
+	switch prev.Kind {
+	case scriptjs.Punct:
+		switch prev.Text {
+		case ")", "]", "}", "=>", ";":
+			return false
+		}
+		return true
+	case scriptjs.Ident:
+		switch prev.Text {
+		case "return", "let", "const", "var", "typeof", "in", "of", "case", "yield", "throw":
+			return true
+		}
+	}
+	return false
+}
+
+// declaredNames returns the names declared with let, const, var, function
+// and as function, arrow and catch parameters.
+func declaredNames(toks []scriptjs.Token) map[string]bool {
+	names := make(map[string]bool)
+	ident := func(i int) bool {
+		return i >= 0 && i < len(toks) && toks[i].Kind == scriptjs.Ident && !reserved[toks[i].Text]
+	}
+
+	for i, t := range toks {
+		switch {
+		case t.Kind == scriptjs.Ident && (t.Text == "let" || t.Text == "const" || t.Text == "var"):
+			declarators(toks, i+1, names)
+		case t.Is(scriptjs.Ident, "function"):
+			j := i + 1
+			if j < len(toks) && toks[j].Is(scriptjs.Punct, "*") {
+				j++
+			}
+			if ident(j) {
+				names[toks[j].Text] = true
+				j++
+			}
+			if j < len(toks) && toks[j].Is(scriptjs.Punct, "(") {
+				params(toks, j, names)
+			}
+		case t.Is(scriptjs.Ident, "catch"):
+			if i+2 < len(toks) && toks[i+1].Is(scriptjs.Punct, "(") && ident(i+2) {
+				names[toks[i+2].Text] = true
+			}
+		case t.Is(scriptjs.Punct, "=>"):
+			if ident(i - 1) {
+				names[toks[i-1].Text] = true
+			} else if i > 0 && toks[i-1].Is(scriptjs.Punct, ")") {
+				if open := matchingOpen(toks, i-1); open >= 0 {
+					params(toks, open, names)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// declarators collects the names of a let, const or var statement starting
+// at toks[i]: the first name and every name after a top-level comma.
+func declarators(toks []scriptjs.Token, i int, names map[string]bool) {
+	depth := 0
+	expectName := true
+	for ; i < len(toks); i++ {
+		t := toks[i]
+		if depth == 0 && i > 0 && strings.Contains(t.Space, "\n") && !expectName &&
+			!toks[i-1].Is(scriptjs.Punct, ",") && !toks[i-1].Is(scriptjs.Punct, "=") {
+			return
+		}
+		if expectName {
+			if t.Kind == scriptjs.Ident && !reserved[t.Text] {
+				names[t.Text] = true
+			}
+			expectName = false
+		}
+		if t.Kind != scriptjs.Punct {
+			continue
+		}
+		switch t.Text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth < 0 {
+				return
+			}
+		case ";":
+			if depth == 0 {
+				return
+			}
+		case ",":
+			expectName = depth == 0
+		}
+	}
+}
+
+// params collects parameter names of the list opening at toks[open].
+func params(toks []scriptjs.Token, open int, names map[string]bool) {
+	depth := 0
+	for i := open; i < len(toks); i++ {
+		t := toks[i]
+		if t.Kind == scriptjs.Punct {
+			switch t.Text {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth--
+				if depth == 0 {
+					return
+				}
+			}
+			continue
+		}
+		if t.Kind == scriptjs.Ident && depth == 1 && !reserved[t.Text] {
+			prev := toks[i-1]
+			if prev.Is(scriptjs.Punct, "(") || prev.Is(scriptjs.Punct, ",") || prev.Is(scriptjs.Punct, "...") {
+				names[t.Text] = true
+			}
+		}
+	}
+}
+
+// matchingOpen returns the index of the "(" matching the ")" at close.
+func matchingOpen(toks []scriptjs.Token, closeIdx int) int {
+	depth := 0
+	for i := closeIdx; i >= 0; i-- {
+		switch {
+		case toks[i].Is(scriptjs.Punct, ")"):
+			depth++
+		case toks[i].Is(scriptjs.Punct, "("):
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// nameGen yields short names that do not clash with names in use.
+type nameGen struct {
+	used map[string]bool
+	n    int
+}
+
+const (
+	nameFirst = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_$"
+	nameRest  = nameFirst + "0123456789"
+)
+
+func newNameGen(used map[string]bool) *nameGen {
+	return &nameGen{used: used}
+}
+
+func (g *nameGen) next() string {
+	for {
+		name := g.name(g.n)
+		g.n++
+		if !g.used[name] && !reserved[name] && !engineGlobals[name] {
+			return name
+		}
+	}
+}
+
+func (g *nameGen) name(n int) string {
+	b := []byte{nameFirst[n%len(nameFirst)]}
+	n /= len(nameFirst)
+	for n > 0 {
+		n--
+		b = append(b, nameRest[n%len(nameRest)])
+		n /= len(nameRest)
+	}
+	return string(b)
+}
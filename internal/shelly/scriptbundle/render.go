@@ -0,0 +1,113 @@
+package scriptbundle
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptjs"
+)
+
+var blankLineRe = regexp.MustCompile(`\n[ \t\r]*\n`)
+
+// restricted keywords cannot be followed by a line break without changing
+// the meaning of the statement.
+var restricted = map[string]bool{"return": true, "break": true, "continue": true, "throw": true, "yield": true}
+
+// render prints tokens without their comments. By default the layout of
+// the source is kept, with trailing spaces removed and blank lines
+// collapsed. Compact output drops all whitespace the grammar does not need,
+// keeping line breaks where automatic semicolon insertion may depend on
+// them.
+func render(toks []scriptjs.Token, compact bool) string {
+	var b strings.Builder
+	var prev *scriptjs.Token
+	var (
+		newline, blank bool
+		indent         string
+	)
+	for i := range toks {
+		t := &toks[i]
+		if strings.Contains(t.Space, "\n") {
+			newline = true
+			blank = blank || blankLineRe.MatchString(t.Space)
+			indent = t.Space[strings.LastIndexByte(t.Space, '\n')+1:]
+		} else if !newline {
+			indent = t.Space
+		}
+		if t.Kind == scriptjs.Comment {
+			newline = newline || strings.Contains(t.Text, "\n")
+			continue
+		}
+
+		if prev != nil {
+			switch {
+			case compact && newline && !canJoin(prev, t):
+				b.WriteByte('\n')
+			case compact:
+				if needSpace(prev, t) {
+					b.WriteByte(' ')
+				}
+			case newline:
+				b.WriteByte('\n')
+				if blank {
+					b.WriteByte('\n')
+				}
+				b.WriteString(strings.TrimRight(indent, "\r"))
+			case indent != "":
+				b.WriteString(indent)
+			case needSpace(prev, t):
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(t.Text)
+		prev = t
+		newline, blank, indent = false, false, ""
+	}
+	if b.Len() > 0 {
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// canJoin reports whether the line break between prev and cur can be
+// dropped without changing how the script parses.
+func canJoin(prev, cur *scriptjs.Token) bool {
+	if prev.Kind == scriptjs.Ident && restricted[prev.Text] {
+		return false
+	}
+	switch cur.Text {
+	case "++", "--":
+		return false
+	case "}", ")", "]", ",", ";", ".", ":", "?":
+		if cur.Kind == scriptjs.Punct {
+			return true
+		}
+	}
+	if prev.Kind != scriptjs.Punct {
+		return false
+	}
+	switch prev.Text {
+	case ")", "]", "}", "++", "--":
+		return false
+	}
+	return true
+}
+
+// needSpace reports whether two adjacent tokens must be separated to keep
+// them apart.
+func needSpace(prev, cur *scriptjs.Token) bool {
+	last, _ := utf8.DecodeLastRuneInString(prev.Text)
+	first, _ := utf8.DecodeRuneInString(cur.Text)
+	switch {
+	case scriptjs.IsIdentPart(last) && scriptjs.IsIdentPart(first):
+		return true
+	case (last == '+' || last == '-') && first == last:
+		return true
+	case last == '/' && (first == '/' || first == '*'):
+		return true
+	case prev.Kind == scriptjs.Number && first == '.':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,271 @@
+package scriptbundle
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptjs"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	src := "// header\nlet total = 0;   \n\n\n\nfunction add(n) {\n  total += n; // running\n  return total\n}\nlet x = a\n++b\n"
+	toks, err := scriptjs.Lex(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := render(toks, false)
+	want := "let total = 0;\n\nfunction add(n) {\n  total += n;\n  return total\n}\nlet x = a\n++b\n"
+	if got != want {
+		t.Errorf("render() =\n%q\nwant\n%q", got, want)
+	}
+
+	got = render(toks, true)
+	want = "let total=0;function add(n){total+=n;return total}\nlet x=a\n++b\n"
+	if got != want {
+		t.Errorf("render(compact) =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRender_KeepsTokensApart(t *testing.T) {
+	t.Parallel()
+
+	toks, err := scriptjs.Lex("let a = b + +c; let d = 1 .toString(); return typeof x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "let a=b+ +c;let d=1 .toString();return typeof x\n"
+	if got := render(toks, true); got != want {
+		t.Errorf("render(compact) = %q, want %q", got, want)
+	}
+}
+
+func TestRenameLocals(t *testing.T) {
+	t.Parallel()
+
+	src := `let counter = 0;
+function handle(event, extra) {
+  let total = counter + 1;
+  let info = { total, name: event.name };
+  print(` + "`${counter}`" + `, info.name, extra);
+  Shelly.call("Switch.Toggle", { id: 0 });
+}
+let double = (value) => value * 2;
+try { handle({}); } catch (problem) { print(problem); }
+`
+	toks, err := scriptjs.Lex(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := render(renameLocals(toks), true)
+
+	for _, keep := range []string{"let counter=", "${counter}", "Shelly.call", "name:", ".name", "print", "{total:"} {
+		if !strings.Contains(got, keep) {
+			t.Errorf("renamed code should keep %q:\n%s", keep, got)
+		}
+	}
+	for _, gone := range []string{"handle", "event", "extra", "double", "value", "problem", "info", "let total"} {
+		if strings.Contains(got, gone) {
+			t.Errorf("renamed code should not contain %q:\n%s", gone, got)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	src := `class Light {}
+async function load() { await fetch(); }
+let p = new Promise(function () {});
+function* gen() { yield 1; }
+import("./lazy");
+obj.class = 1; // property names are fine
+let {a, b} = obj;
+const [x] = list;
+function pick({id}, [first]) {}
+let o = { get size() { return 1; }, set size(v) {}, get: 1, set(v) {} };
+function ok(a, b = [1], c = {}) { let d = [a]; }
+`
+	toks, err := scriptjs.Lex(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems := check("main.js", toks, nil)
+	rules := make([]string, 0, len(problems))
+	for _, p := range problems {
+		rules = append(rules, p.Rule)
+	}
+	want := []string{
+		RuleClass, RuleAsync, RuleAsync, RulePromise, RuleGenerator, RuleGenerator, RuleModule,
+		RuleDestructuring, RuleDestructuring, RuleDestructuring, RuleAccessor, RuleAccessor,
+	}
+	if strings.Join(rules, ",") != strings.Join(want, ",") {
+		t.Errorf("rules = %v, want %v", rules, want)
+	}
+	if problems[0].String() != "main.js:1:1: classes are not supported (class)" {
+		t.Errorf("String() = %q", problems[0].String())
+	}
+
+	problems = check("main.js", toks, map[string]bool{RuleAsync: true, RuleClass: true, RuleDestructuring: true, RuleAccessor: true})
+	for _, p := range problems {
+		if p.Rule == RuleAsync || p.Rule == RuleClass || p.Rule == RuleDestructuring || p.Rule == RuleAccessor {
+			t.Errorf("allowed rule %q still reported", p.Rule)
+		}
+	}
+}
+
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	config.SetFs(fs)
+	t.Cleanup(func() { config.SetFs(nil) })
+	for name, content := range files {
+		if err := afero.WriteFile(fs, name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestBundle(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"/app/main.js": `// entry
+import { toggle, LIMIT as limit } from "./lib/switch";
+import log from "./log.js";
+import * as sw from "./lib/switch";
+const helpers = require("./lib");
+
+Shelly.addEventHandler(function (event) {
+  log("push " + limit);
+  toggle(0);
+  sw.toggle(1);
+  helpers.noop();
+});
+export default 1;
+`,
+		"/app/lib/switch.js": `import log from "../log";
+export const LIMIT = 5;
+export function toggle(id) {
+  log("toggle " + id);
+  Shelly.call("Switch.Toggle", { id: id });
+}
+`,
+		"/app/log.js": `export default function (msg) { print(msg); }
+`,
+		"/app/lib/index.js": `function noop() {}
+module.exports = { noop: noop };
+`,
+	})
+
+	res, err := Bundle("/app/main.js", Options{})
+	if err != nil {
+		t.Fatalf("Bundle() error = %v", err)
+	}
+
+	files := make([]string, 0, len(res.Modules))
+	for _, m := range res.Modules {
+		files = append(files, m.File)
+	}
+	if got := strings.Join(files, ","); got != "/app/log.js,/app/lib/switch.js,/app/lib/index.js,/app/main.js" {
+		t.Errorf("modules = %s", got)
+	}
+	if res.Size != len(res.Code) {
+		t.Errorf("Size = %d, want %d", res.Size, len(res.Code))
+	}
+	for _, want := range []string{
+		"let __log = (function () {",
+		"module.exports.default = function (msg)",
+		"let log = __log.default;",
+		"module.exports.LIMIT = LIMIT;",
+		"let toggle = __switch.toggle, limit = __switch.LIMIT;",
+		"let sw = __switch;",
+		"const helpers = __index;",
+		"\n1;\n",
+	} {
+		if !strings.Contains(res.Code, want) {
+			t.Errorf("bundle should contain %q:\n%s", want, res.Code)
+		}
+	}
+	for _, gone := range []string{"import", "export ", "require", "// entry"} {
+		if strings.Contains(res.Code, gone) {
+			t.Errorf("bundle should not contain %q:\n%s", gone, res.Code)
+		}
+	}
+	if strings.Count(res.Code, "let __log =") != 1 {
+		t.Error("shared module should be included once")
+	}
+
+	minified, err := Bundle("/app/main.js", Options{Minify: true})
+	if err != nil {
+		t.Fatalf("Bundle(minify) error = %v", err)
+	}
+	if minified.Size >= res.Size {
+		t.Errorf("minified size %d should be below %d", minified.Size, res.Size)
+	}
+	if !strings.Contains(minified.Code, `Shelly.call("Switch.Toggle",{id:`) {
+		t.Errorf("minified bundle lost engine calls:\n%s", minified.Code)
+	}
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestBundle_Errors(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"/app/cycle.js":    `import { b } from "./b";`,
+		"/app/b.js":        `import { a } from "./cycle"; export let b = 1;`,
+		"/app/npm.js":      `let lodash = require("lodash");`,
+		"/app/missing.js":  `import "./nowhere";`,
+		"/app/class.js":    "let ok = 1;\nclass Light {}\n",
+		"/app/reexport.js": `export { b } from "./b";`,
+	})
+
+	tests := []struct {
+		entry string
+		want  string
+	}{
+		{"/app/cycle.js", "circular import: /app/cycle.js -> /app/b.js -> /app/cycle.js"},
+		{"/app/npm.js", `/app/npm.js:1:14: cannot bundle "lodash"`},
+		{"/app/missing.js", `cannot find module "./nowhere"`},
+		{"/app/reexport.js", "re-exports are not supported"},
+		{"/app/none.js", "read module"},
+	}
+	for _, tt := range tests {
+		_, err := Bundle(tt.entry, Options{})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Bundle(%s) error = %v, want %q", tt.entry, err, tt.want)
+		}
+	}
+
+	_, err := Bundle("/app/class.js", Options{})
+	var checkErr *CheckError
+	if !errors.As(err, &checkErr) || len(checkErr.Problems) != 1 || checkErr.Problems[0].Line != 2 {
+		t.Errorf("Bundle(class) error = %v, want one problem on line 2", err)
+	}
+	if _, err := Bundle("/app/class.js", Options{Allow: []string{RuleClass}}); err != nil {
+		t.Errorf("Bundle(class, allowed) error = %v", err)
+	}
+	if _, err := Bundle("/app/class.js", Options{Allow: []string{"nope"}}); err == nil {
+		t.Error("expected error for unknown rule")
+	}
+}
+
+func TestCheckSize(t *testing.T) {
+	t.Parallel()
+
+	if err := CheckSize(100, 100); err != nil {
+		t.Errorf("CheckSize(100, 100) = %v", err)
+	}
+	if err := CheckSize(500, 0); err != nil {
+		t.Errorf("CheckSize(500, 0) = %v", err)
+	}
+	err := CheckSize(150, 100)
+	if err == nil || err.Error() != "bundle is 150 bytes, 50 over the 100 byte limit" {
+		t.Errorf("CheckSize(150, 100) = %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package scriptdev
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/tj-smith47/shelly-go/rpc"
+
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+)
+
+// ErrLogAuth is returned by StreamLog when the device rejects the
+// credentials, or asks for credentials that were not given. Retrying does
+// not help.
+var ErrLogAuth = errors.New("debug log authentication failed")
+
+// defaultUser is the user Gen2+ devices authenticate.
+const defaultUser = "admin"
+
+// LogEntry is one line of the device debug log.
+type LogEntry struct {
+	Time  time.Time
+	Level int
+	Text  string
+}
+
+// logFrame is a debug log message as sent by the device.
+type logFrame struct {
+	TS    float64 `json:"ts"`
+	Level int     `json:"level"`
+	Data  string  `json:"data"`
+}
+
+// firmwareLineRe matches log lines emitted by the firmware itself, which
+// start with their C source location ("shelly_ejs.cpp:123 ...").
+var firmwareLineRe = regexp.MustCompile(`^\S+\.(?:c|cc|cpp|h):\d+\s`)
+
+// IsFirmware reports whether the entry comes from the firmware rather than
+// a script's print() or an interpreter error.
+func (e LogEntry) IsFirmware() bool {
+	return firmwareLineRe.MatchString(e.Text)
+}
+
+// ParseLogEntries decodes a debug log message. Devices send JSON frames;
+// plain text is accepted as is. A message can carry several lines.
+func ParseLogEntries(data []byte) []LogEntry {
+	var frame logFrame
+	entry := LogEntry{Text: string(data)}
+	if err := json.Unmarshal(data, &frame); err == nil && frame.Data != "" {
+		entry.Text = frame.Data
+		entry.Level = frame.Level
+		if frame.TS > 0 {
+			sec := int64(frame.TS)
+			entry.Time = time.Unix(sec, int64((frame.TS-float64(sec))*1e9))
+		}
+	}
+
+	var entries []LogEntry
+	for _, line := range strings.Split(strings.TrimRight(entry.Text, "\r\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		e := entry
+		e.Text = strings.TrimRight(line, "\r")
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// LogURL returns the debug log WebSocket URL of a device at address.
+func LogURL(address string) string {
+	return (&url.URL{Scheme: "ws", Host: address, Path: "/debug/log"}).String()
+}
+
+// StreamLog connects to a device debug log WebSocket and calls fn for every
+// line until ctx is done or the connection drops. The device only sends
+// its log when Sys debug.websocket is enabled. A device with a password
+// answers the handshake with a digest challenge, which is answered with
+// auth.
+func StreamLog(ctx context.Context, wsURL string, auth *model.Auth, fn func(LogEntry)) error {
+	conn, err := dialLog(ctx, wsURL, auth)
+	if err != nil {
+		return err
+	}
+	defer iostreams.CloseWithDebug("closing debug log connection", conn)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Unblocks ReadMessage.
+			iostreams.CloseWithDebug("closing debug log connection", conn)
+		case <-done:
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("debug log: %w", err)
+		}
+		for _, e := range ParseLogEntries(data) {
+			fn(e)
+		}
+	}
+}
+
+// dialLog opens the debug log WebSocket, answering a digest challenge when
+// the device asks for one.
+func dialLog(ctx context.Context, wsURL string, auth *model.Auth) (*websocket.Conn, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+	var header http.Header
+	for attempt := 0; ; attempt++ {
+		conn, resp, err := dialer.DialContext(ctx, wsURL, header)
+		if resp != nil && resp.Body != nil {
+			iostreams.CloseWithDebug("closing debug log handshake body", resp.Body)
+		}
+		if err == nil {
+			return conn, nil
+		}
+		if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			return nil, fmt.Errorf("connect to debug log: %w", err)
+		}
+		if auth == nil || auth.Password == "" {
+			return nil, fmt.Errorf("%w: the device requires a password and none is configured", ErrLogAuth)
+		}
+		if attempt > 0 {
+			return nil, fmt.Errorf("%w: the device rejected the configured credentials", ErrLogAuth)
+		}
+		authz, authErr := digestAuthorization(resp.Header.Get("WWW-Authenticate"), wsURL, auth)
+		if authErr != nil {
+			return nil, fmt.Errorf("%w: %w", ErrLogAuth, authErr)
+		}
+		header = http.Header{"Authorization": []string{authz}}
+	}
+}
+
+// digestAuthorization answers a digest challenge for a GET of wsURL, hashed
+// as for RPC requests.
+func digestAuthorization(challenge, wsURL string, auth *model.Auth) (string, error) {
+	scheme, params, ok := strings.Cut(challenge, " ")
+	if !ok || !strings.EqualFold(scheme, "digest") {
+		return "", fmt.Errorf("unsupported challenge %q", challenge)
+	}
+	fields := make(map[string]string)
+	for _, part := range strings.Split(params, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if found {
+			fields[strings.ToLower(key)] = strings.Trim(value, `"`)
+		}
+	}
+	if fields["realm"] == "" || fields["nonce"] == "" {
+		return "", fmt.Errorf("challenge without realm or nonce")
+	}
+
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return "", err
+	}
+	user := auth.Username
+	if user == "" {
+		user = defaultUser
+	}
+	algorithm := fields["algorithm"]
+	if algorithm == "" {
+		algorithm = rpc.AlgorithmMD5
+	}
+	d, err := rpc.DigestAuth(user, auth.Password, fields["realm"], fields["nonce"], http.MethodGet, u.RequestURI(), algorithm)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`Digest username=%q, realm=%q, nonce=%q, uri=%q, algorithm=%s, qop=auth, nc=%08x, cnonce=%q, response=%q`,
+		d.Username, d.Realm, d.Nonce, u.RequestURI(), d.Algorithm, d.NC, d.CNonce, d.Response), nil
+}
@@ -0,0 +1,116 @@
+// Package scriptdev supports iterating on device scripts: splitting code
+// into upload chunks, watching the source file, streaming the device debug
+// log and mapping script errors back to source lines.
+package scriptdev
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultChunkSize is the number of bytes uploaded per Script.PutCode call.
+// Larger requests can exceed the device's RPC buffer.
+const DefaultChunkSize = 1024
+
+// Chunks splits code into pieces of at most size bytes without splitting a
+// UTF-8 sequence. Empty code yields a single empty chunk, so uploading it
+// still clears the script.
+func Chunks(code string, size int) []string {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	if code == "" {
+		return []string{""}
+	}
+	var chunks []string
+	for len(code) > size {
+		end := size
+		for end > 0 && !utf8.RuneStart(code[end]) {
+			end--
+		}
+		if end == 0 {
+			end = size
+		}
+		chunks = append(chunks, code[:end])
+		code = code[end:]
+	}
+	return append(chunks, code)
+}
+
+// Source is the code of a script as uploaded from a file.
+type Source struct {
+	File  string
+	lines []string
+}
+
+// NewSource returns the source of file with the given code.
+func NewSource(file, code string) *Source {
+	return &Source{File: file, lines: strings.Split(code, "\n")}
+}
+
+// Line returns line n (1-based) of the source, or "" when out of range.
+func (s *Source) Line(n int) string {
+	if n < 1 || n > len(s.lines) {
+		return ""
+	}
+	return strings.TrimRight(s.lines[n-1], "\r")
+}
+
+// Location is a position in a script source.
+type Location struct {
+	File string
+	Line int
+	Col  int
+	Code string
+}
+
+// String formats the location as file:line[:col].
+func (l Location) String() string {
+	s := l.File + ":" + strconv.Itoa(l.Line)
+	if l.Col > 0 {
+		s += ":" + strconv.Itoa(l.Col)
+	}
+	return s
+}
+
+var (
+	lineColRe = regexp.MustCompile(`(?i)\bline (\d+)(?:,? col(?:umn)? (\d+))?`)
+	atCodeRe  = regexp.MustCompile(`(?m)^\s*at\s+(.+?)\s*$`)
+)
+
+// IsError reports whether a log line is an interpreter error.
+func IsError(text string) bool {
+	return strings.Contains(text, "Uncaught") || strings.Contains(text, "Error:")
+}
+
+// Locate finds the source position a device error line refers to. It
+// understands errors ending in "at line 12 col 5" and the interpreter's
+// "at <code>" excerpt lines, which are matched against the source text.
+// Lines that are not errors are never located, so script output starting
+// with "at " is left alone.
+func (s *Source) Locate(text string) (Location, bool) {
+	if !IsError(text) {
+		return Location{}, false
+	}
+	if m := lineColRe.FindStringSubmatch(text); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err == nil && n >= 1 && n <= len(s.lines) {
+			loc := Location{File: s.File, Line: n, Code: s.Line(n)}
+			if col, err := strconv.Atoi(m[2]); err == nil {
+				loc.Col = col
+			}
+			return loc, true
+		}
+	}
+	if m := atCodeRe.FindStringSubmatch(text); m != nil {
+		excerpt := m[1]
+		for i := range s.lines {
+			if col := strings.Index(s.lines[i], excerpt); col >= 0 {
+				return Location{File: s.File, Line: i + 1, Col: col + 1, Code: s.Line(i + 1)}, true
+			}
+		}
+	}
+	return Location{}, false
+}
@@ -0,0 +1,272 @@
+package scriptdev
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+)
+
+func TestChunks(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		code string
+		size int
+		want []string
+	}{
+		{"empty", "", 4, []string{""}},
+		{"fits", "abc", 4, []string{"abc"}},
+		{"split", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"utf8 boundary", "abcé€", 4, []string{"abc", "é", "€"}},
+		{"default size", strings.Repeat("x", DefaultChunkSize+1), 0, []string{strings.Repeat("x", DefaultChunkSize), "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := Chunks(tt.code, tt.size)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Chunks(%q, %d) = %q, want %q", tt.code, tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSource_Locate(t *testing.T) {
+	t.Parallel()
+	src := NewSource("main.js", "let a = 1;\nlet b = foo(a);\nprint(b);\n")
+
+	tests := []struct {
+		text     string
+		want     string
+		wantCode string
+		ok       bool
+	}{
+		{"Uncaught ReferenceError: \"foo\" is not defined at line 2 col 9", "main.js:2:9", "let b = foo(a);", true},
+		{"Uncaught SyntaxError: Got EOF at line 3", "main.js:3", "print(b);", true},
+		{"Uncaught TypeError: not a function\n at let b = foo(a);", "main.js:2:1", "let b = foo(a);", true},
+		{"Uncaught Error: boom\n at foo(a)\n        ^", "main.js:2:9", "let b = foo(a);", true},
+		{"at foo(a)", "", "", false},
+		{"Uncaught Error: at line 99", "", "", false},
+		{"the deadline is line 2", "", "", false},
+		{"hello world", "", "", false},
+	}
+	for _, tt := range tests {
+		loc, ok := src.Locate(tt.text)
+		if ok != tt.ok {
+			t.Errorf("Locate(%q) ok = %v, want %v", tt.text, ok, tt.ok)
+			continue
+		}
+		if ok && (loc.String() != tt.want || loc.Code != tt.wantCode) {
+			t.Errorf("Locate(%q) = %s %q, want %s %q", tt.text, loc, loc.Code, tt.want, tt.wantCode)
+		}
+	}
+}
+
+func TestParseLogEntries(t *testing.T) {
+	t.Parallel()
+
+	entries := ParseLogEntries([]byte(`{"ts":1700000000.5,"level":2,"data":"Uncaught Error: boom\n at  go();\n\n","fd":102}`))
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(entries), entries)
+	}
+	if entries[0].Text != "Uncaught Error: boom" || entries[1].Text != " at  go();" {
+		t.Errorf("entries = %+v", entries)
+	}
+	if entries[0].Level != 2 || entries[0].Time.Unix() != 1700000000 {
+		t.Errorf("entry meta = %+v", entries[0])
+	}
+
+	plain := ParseLogEntries([]byte("hello\n"))
+	if len(plain) != 1 || plain[0].Text != "hello" {
+		t.Errorf("plain = %+v", plain)
+	}
+}
+
+func TestLogEntry_IsFirmware(t *testing.T) {
+	t.Parallel()
+	if !(LogEntry{Text: "shelly_ejs_rpc.cpp:41 Script.PutCode"}).IsFirmware() {
+		t.Error("firmware line not detected")
+	}
+	if (LogEntry{Text: "temperature: 21.5"}).IsFirmware() {
+		t.Error("print output detected as firmware")
+	}
+}
+
+func TestLogURL(t *testing.T) {
+	t.Parallel()
+	if got := LogURL("192.168.1.50"); got != "ws://192.168.1.50/debug/log" {
+		t.Errorf("LogURL() = %q", got)
+	}
+}
+
+func TestStreamLog(t *testing.T) {
+	t.Parallel()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/debug/log" {
+			http.NotFound(w, r)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, msg := range []string{`{"ts":1,"level":2,"data":"one\n"}`, `{"ts":2,"level":2,"data":"two\n"}`} {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	var got []string
+	err := StreamLog(context.Background(), LogURL(strings.TrimPrefix(srv.URL, "http://")), nil, func(e LogEntry) {
+		got = append(got, e.Text)
+	})
+	if err == nil {
+		t.Error("expected an error when the device closes the connection")
+	}
+	if strings.Join(got, ",") != "one,two" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestStreamLog_DigestAuth(t *testing.T) {
+	t.Parallel()
+
+	const realm, nonce, password = "shellypro1-abc", "5f3c", "secret"
+	hash := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields := make(map[string]string)
+		for _, part := range strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Digest "), ",") {
+			if k, v, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+				fields[k] = strings.Trim(v, `"`)
+			}
+		}
+		ha1 := hash("admin:" + realm + ":" + password)
+		ha2 := hash("GET:/debug/log")
+		want := hash(fmt.Sprintf("%s:%s:%s:%s:auth:%s", ha1, nonce, fields["nc"], fields["cnonce"], ha2))
+		if fields["response"] != want {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest qop="auth", realm=%q, nonce=%q, algorithm=SHA-256`, realm, nonce))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"ts":1,"level":2,"data":"hello\n"}`)); err != nil {
+			return
+		}
+	}))
+	t.Cleanup(srv.Close)
+	wsURL := LogURL(strings.TrimPrefix(srv.URL, "http://"))
+
+	tests := []struct {
+		name    string
+		auth    *model.Auth
+		want    string
+		authErr bool
+	}{
+		{"valid credentials", &model.Auth{Password: password}, "hello", false},
+		{"wrong password", &model.Auth{Username: "admin", Password: "wrong"}, "", true},
+		{"no credentials", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got []string
+			err := StreamLog(context.Background(), wsURL, tt.auth, func(e LogEntry) {
+				got = append(got, e.Text)
+			})
+			if errors.Is(err, ErrLogAuth) != tt.authErr {
+				t.Errorf("StreamLog() error = %v, want auth error %t", err, tt.authErr)
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+//nolint:paralleltest // Uses the global config filesystem
+func TestWatch(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	config.SetFs(fs)
+	t.Cleanup(func() { config.SetFs(nil) })
+	if err := afero.WriteFile(fs, "/main.js", []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		mu      sync.Mutex
+		changes []string
+		errs    int
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Watch(ctx, "/main.js", 5*time.Millisecond, func(data []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, string(data))
+		}, func(error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs++
+		})
+	}()
+
+	waitFor := func(cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			mu.Lock()
+			ok := cond()
+			mu.Unlock()
+			if ok {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("timed out: changes=%q errs=%d", changes, errs)
+	}
+
+	waitFor(func() bool { return len(changes) == 1 })
+	if err := fs.Remove("/main.js"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool { return errs == 1 })
+	if err := afero.WriteFile(fs, "/main.js", []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(func() bool { return len(changes) == 2 })
+	cancel()
+	<-done
+
+	if strings.Join(changes, ",") != "v1,v2" || errs != 1 {
+		t.Errorf("changes = %q, errs = %d", changes, errs)
+	}
+}
@@ -0,0 +1,50 @@
+package scriptdev
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// DefaultWatchInterval is how often a watched file is checked for changes.
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// Watch polls file every interval and calls onChange with its content
+// whenever it differs from the last content seen, starting with the
+// current content. Read errors, such as while an editor replaces the file,
+// are passed to onError once and retried. Watch returns when ctx is done.
+func Watch(ctx context.Context, file string, interval time.Duration, onChange func([]byte), onError func(error)) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last []byte
+	seen, failing := false, false
+	for {
+		data, err := afero.ReadFile(config.Fs(), file)
+		switch {
+		case err != nil:
+			if !failing && onError != nil {
+				onError(err)
+			}
+			failing = true
+		case !seen || !bytes.Equal(data, last):
+			last, seen, failing = data, true, false
+			onChange(data)
+		default:
+			failing = false
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package scriptjs
+
+// position is where a node starts in the source.
+type position struct {
+	line, col int
+}
+
+func (p position) pos() position { return p }
+
+type node interface {
+	pos() position
+}
+
+type expr interface {
+	node
+	eval(r *Runtime, s *scope) (Value, error)
+}
+
+type stmt interface {
+	node
+	exec(r *Runtime, s *scope) (flow, Value, error)
+}
+
+// Program is a parsed script.
+type Program struct {
+	name string
+	body []stmt
+	vars []string
+}
+
+// funcNode is a function declaration, expression, arrow function or
+// object method.
+type funcNode struct {
+	position
+	name     string
+	params   []param
+	body     []stmt
+	exprBody expr
+	arrow    bool
+	vars     []string // var declarations to hoist
+}
+
+type param struct {
+	name string
+	def  expr
+	rest bool
+}
+
+// Expressions.
+type (
+	// constExpr is a number, string, boolean or null literal.
+	constExpr struct {
+		position
+		value Value
+	}
+	templateLit struct {
+		position
+		quasis []string
+		exprs  []expr
+	}
+	regexLit struct {
+		position
+		pattern, flags string
+	}
+	ident struct {
+		position
+		name string
+	}
+	thisExpr struct {
+		position
+	}
+	arrayLit struct {
+		position
+		elems []expr // nil for holes
+	}
+	objectLit struct {
+		position
+		props []property
+	}
+	funcExpr struct {
+		position
+		fn *funcNode
+	}
+	spreadExpr struct {
+		position
+		x expr
+	}
+	unaryExpr struct {
+		position
+		op string
+		x  expr
+	}
+	updateExpr struct {
+		position
+		op     string
+		prefix bool
+		target expr
+	}
+	binaryExpr struct {
+		position
+		op   string
+		l, r expr
+	}
+	logicalExpr struct {
+		position
+		op   string
+		l, r expr
+	}
+	assignExpr struct {
+		position
+		op     string
+		target expr
+		value  expr
+	}
+	condExpr struct {
+		position
+		test, then, els expr
+	}
+	callExpr struct {
+		position
+		callee   expr
+		args     []expr
+		optional bool
+	}
+	memberExpr struct {
+		position
+		obj      expr
+		name     string
+		computed expr
+		optional bool
+	}
+	// optionalChain ends a member chain that contains ?. so a nullish
+	// link short-circuits the whole chain.
+	optionalChain struct {
+		position
+		x expr
+	}
+	newExpr struct {
+		position
+		callee expr
+		args   []expr
+	}
+	seqExpr struct {
+		position
+		exprs []expr
+	}
+)
+
+type property struct {
+	key      string
+	computed expr
+	value    expr
+	spread   bool
+}
+
+// Statements.
+type (
+	varDecl struct {
+		position
+		kind  string
+		decls []declarator
+	}
+	funcDecl struct {
+		position
+		fn *funcNode
+	}
+	exprStmt struct {
+		position
+		x expr
+	}
+	blockStmt struct {
+		position
+		body []stmt
+	}
+	emptyStmt struct {
+		position
+	}
+	ifStmt struct {
+		position
+		test      expr
+		then, els stmt
+	}
+	forStmt struct {
+		position
+		init   stmt
+		test   expr
+		update expr
+		body   stmt
+	}
+	forInStmt struct {
+		position
+		kind   string // let, const, var or "" for an existing target
+		name   string
+		target expr
+		obj    expr
+		of     bool
+		body   stmt
+	}
+	whileStmt struct {
+		position
+		test expr
+		body stmt
+		do   bool
+	}
+	returnStmt struct {
+		position
+		x expr
+	}
+	breakStmt struct {
+		position
+		cont bool
+	}
+	throwStmt struct {
+		position
+		x expr
+	}
+	tryStmt struct {
+		position
+		block     *blockStmt
+		param     string
+		handler   *blockStmt
+		finalizer *blockStmt
+	}
+	switchStmt struct {
+		position
+		disc  expr
+		cases []switchCase
+	}
+)
+
+type declarator struct {
+	name string
+	init expr
+}
+
+type switchCase struct {
+	test expr // nil for default
+	body []stmt
+}
@@ -0,0 +1,414 @@
+package scriptjs
+
+import (
+	"encoding/base64"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func (r *Runtime) installBuiltins() {
+	r.Set("undefined", Undefined)
+	r.Set("NaN", math.NaN())
+	r.Set("Infinity", math.Inf(1))
+	r.installErrors()
+
+	r.object = r.objectCtor()
+	r.array = r.arrayCtor()
+	r.Set("Object", r.object)
+	r.Set("Array", r.array)
+	r.Set("Number", r.numberCtor())
+	r.Set("String", r.stringCtor())
+	r.Set("Boolean", r.booleanCtor())
+	r.Set("Date", r.dateCtor())
+	r.Set("RegExp", r.regexpCtor())
+	r.Set("Math", r.mathObject())
+	r.Set("JSON", r.jsonObject())
+
+	for name, fn := range map[string]NativeFunc{
+		"parseInt":   parseInt,
+		"parseFloat": func(_ *Runtime, _ Value, args []Value) (Value, error) { return parseFloat(ToString(arg(args, 0))), nil },
+		"isNaN": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			return math.IsNaN(toNumber(arg(args, 0))), nil
+		},
+		"isFinite": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			f := toNumber(arg(args, 0))
+			return !math.IsNaN(f) && !math.IsInf(f, 0), nil
+		},
+		"btoa": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			return base64.StdEncoding.EncodeToString([]byte(ToString(arg(args, 0)))), nil
+		},
+		"atob": func(r *Runtime, _ Value, args []Value) (Value, error) {
+			data, err := base64.StdEncoding.DecodeString(ToString(arg(args, 0)))
+			if err != nil {
+				return nil, r.throwf("Error", "invalid base64 string")
+			}
+			return string(data), nil
+		},
+	} {
+		r.Set(name, NewFunction(name, fn))
+	}
+}
+
+// setMethods adds built-in functions to an object, in name order.
+func setMethods(o *Object, fns map[string]NativeFunc) {
+	names := make([]string, 0, len(fns))
+	for name := range fns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		o.Set(name, NewFunction(name, fns[name]))
+	}
+}
+
+var errorKinds = []string{"Error", "TypeError", "RangeError", "SyntaxError", "ReferenceError"}
+
+func (r *Runtime) installErrors() {
+	base := NewObject()
+	base.Set("name", "Error")
+	base.Set("message", "")
+	for _, kind := range errorKinds {
+		proto := base
+		if kind != "Error" {
+			proto = NewObject()
+			proto.proto = base
+			proto.Set("name", kind)
+		}
+		r.protos[kind] = proto
+
+		ctor := NewFunction(kind, func(r *Runtime, _ Value, args []Value) (Value, error) {
+			msg := ""
+			if m := arg(args, 0); m != Undefined {
+				msg = ToString(m)
+			}
+			return r.newError(kind, msg), nil
+		})
+		ctor.construct = ctor.native
+		ctor.Set("prototype", proto)
+		r.Set(kind, ctor)
+	}
+}
+
+func (r *Runtime) newError(kind, msg string) *Object {
+	o := NewObject()
+	o.class = "Error"
+	o.proto = r.protos[kind]
+	if o.proto == nil {
+		o.proto = r.protos["Error"]
+	}
+	o.Set("message", msg)
+	return o
+}
+
+// ownEntries returns the own keys and values of an object, array or
+// string.
+func ownEntries(v Value) ([]string, []Value) {
+	var keys []string
+	var values []Value
+	switch v := v.(type) {
+	case *Object:
+		for _, k := range v.keys {
+			keys = append(keys, k)
+			values = append(values, v.props[k])
+		}
+	case *Function:
+		for _, k := range v.keys {
+			keys = append(keys, k)
+			values = append(values, v.props[k])
+		}
+	case *Array:
+		for i, e := range v.elems {
+			keys = append(keys, strconv.Itoa(i))
+			values = append(values, e)
+		}
+	case string:
+		for i, c := range []rune(v) {
+			keys = append(keys, strconv.Itoa(i))
+			values = append(values, string(c))
+		}
+	}
+	return keys, values
+}
+
+func (r *Runtime) objectCtor() *Function {
+	f := NewFunction("Object", func(_ *Runtime, _ Value, args []Value) (Value, error) {
+		if v := arg(args, 0); isObject(v) {
+			return v, nil
+		}
+		return NewObject(), nil
+	})
+	f.construct = f.native
+	setMethods(&f.Object, map[string]NativeFunc{
+		"keys": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			keys, _ := ownEntries(arg(args, 0))
+			out := make([]Value, len(keys))
+			for i, k := range keys {
+				out[i] = k
+			}
+			return NewArray(out...), nil
+		},
+		"values": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			_, values := ownEntries(arg(args, 0))
+			return NewArray(values...), nil
+		},
+		"entries": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			keys, values := ownEntries(arg(args, 0))
+			out := make([]Value, len(keys))
+			for i, k := range keys {
+				out[i] = NewArray(k, values[i])
+			}
+			return NewArray(out...), nil
+		},
+		"fromEntries": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			o := NewObject()
+			if a, ok := arg(args, 0).(*Array); ok {
+				for _, e := range a.elems {
+					if pair, ok := e.(*Array); ok {
+						o.Set(propertyKey(arg(pair.elems, 0)), arg(pair.elems, 1))
+					}
+				}
+			}
+			return o, nil
+		},
+		"assign": func(r *Runtime, _ Value, args []Value) (Value, error) {
+			dst, ok := arg(args, 0).(*Object)
+			if !ok {
+				return nil, r.throwf("TypeError", "Object.assign target must be an object")
+			}
+			for _, src := range args[1:] {
+				copyProps(dst, src)
+			}
+			return dst, nil
+		},
+		"create": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			o := NewObject()
+			if p, ok := arg(args, 0).(*Object); ok {
+				o.proto = p
+			}
+			return o, nil
+		},
+		"freeze": func(_ *Runtime, _ Value, args []Value) (Value, error) { return arg(args, 0), nil },
+	})
+	return f
+}
+
+func (r *Runtime) arrayCtor() *Function {
+	f := NewFunction("Array", func(r *Runtime, _ Value, args []Value) (Value, error) {
+		if n, ok := arg(args, 0).(float64); ok && len(args) == 1 {
+			a := NewArray()
+			return a, r.setLength(a, n)
+		}
+		return NewArray(append([]Value(nil), args...)...), nil
+	})
+	f.construct = f.native
+	setMethods(&f.Object, map[string]NativeFunc{
+		"isArray": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			_, ok := arg(args, 0).(*Array)
+			return ok, nil
+		},
+		"of": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			return NewArray(append([]Value(nil), args...)...), nil
+		},
+		"from": arrayFrom,
+	})
+	return f
+}
+
+func arrayFrom(r *Runtime, _ Value, args []Value) (Value, error) {
+	src := arg(args, 0)
+	var items []Value
+	if o, ok := src.(*Object); ok {
+		length, _ := o.Get("length")
+		for i := range toInteger(length) {
+			v, _ := o.Get(strconv.Itoa(i))
+			if v == nil {
+				v = Undefined
+			}
+			items = append(items, v)
+		}
+	} else {
+		var err error
+		if items, err = r.iterate(src); err != nil {
+			return nil, err
+		}
+	}
+	if len(args) > 1 {
+		fn, err := r.callback(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range items {
+			if items[i], err = r.call(fn, Undefined, []Value{v, float64(i)}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return NewArray(items...), nil
+}
+
+func (r *Runtime) numberCtor() *Function {
+	f := NewFunction("Number", func(_ *Runtime, _ Value, args []Value) (Value, error) {
+		if len(args) == 0 {
+			return 0.0, nil
+		}
+		return toNumber(args[0]), nil
+	})
+	setMethods(&f.Object, map[string]NativeFunc{
+		"isInteger": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			n, ok := arg(args, 0).(float64)
+			return ok && !math.IsInf(n, 0) && n == math.Trunc(n), nil
+		},
+		"isFinite": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			n, ok := arg(args, 0).(float64)
+			return ok && !math.IsInf(n, 0) && !math.IsNaN(n), nil
+		},
+		"isNaN": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			return isNaNValue(arg(args, 0)), nil
+		},
+		"parseInt":   parseInt,
+		"parseFloat": func(_ *Runtime, _ Value, args []Value) (Value, error) { return parseFloat(ToString(arg(args, 0))), nil },
+	})
+	f.Set("MAX_SAFE_INTEGER", float64(1<<53-1))
+	f.Set("MIN_SAFE_INTEGER", -float64(1<<53-1))
+	f.Set("EPSILON", math.Pow(2, -52))
+	f.Set("MAX_VALUE", math.MaxFloat64)
+	f.Set("POSITIVE_INFINITY", math.Inf(1))
+	f.Set("NEGATIVE_INFINITY", math.Inf(-1))
+	f.Set("NaN", math.NaN())
+	return f
+}
+
+func (r *Runtime) stringCtor() *Function {
+	f := NewFunction("String", func(_ *Runtime, _ Value, args []Value) (Value, error) {
+		if len(args) == 0 {
+			return "", nil
+		}
+		return ToString(args[0]), nil
+	})
+	setMethods(&f.Object, map[string]NativeFunc{
+		"fromCharCode": func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			var b strings.Builder
+			for _, a := range args {
+				b.WriteRune(rune(toUint32(a) & 0xFFFF))
+			}
+			return b.String(), nil
+		},
+	})
+	return f
+}
+
+func (r *Runtime) booleanCtor() *Function {
+	return NewFunction("Boolean", func(_ *Runtime, _ Value, args []Value) (Value, error) {
+		return toBool(arg(args, 0)), nil
+	})
+}
+
+func parseInt(_ *Runtime, _ Value, args []Value) (Value, error) {
+	s := strings.TrimSpace(ToString(arg(args, 0)))
+	radix := toInteger(arg(args, 1))
+	sign := 1.0
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+	}
+	if (radix == 0 || radix == 16) && len(s) > 1 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s, radix = s[2:], 16
+	}
+	if radix == 0 {
+		radix = 10
+	}
+	if radix < 2 || radix > 36 {
+		return math.NaN(), nil
+	}
+	n, digits := 0.0, 0
+	for _, c := range strings.ToLower(s) {
+		d := strings.IndexRune("0123456789abcdefghijklmnopqrstuvwxyz"[:radix], c)
+		if d < 0 {
+			break
+		}
+		n = n*float64(radix) + float64(d)
+		digits++
+	}
+	if digits == 0 {
+		return math.NaN(), nil
+	}
+	return sign * n, nil
+}
+
+var floatPrefix = regexp.MustCompile(`^[+-]?(Infinity|(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?)`)
+
+func parseFloat(s string) float64 {
+	m := floatPrefix.FindString(strings.TrimSpace(s))
+	if m == "" {
+		return math.NaN()
+	}
+	return stringToNumber(m)
+}
+
+func (r *Runtime) mathObject() *Object {
+	m := NewObject()
+	unary := map[string]func(float64) float64{
+		"abs": math.Abs, "floor": math.Floor, "ceil": math.Ceil, "trunc": math.Trunc,
+		"sqrt": math.Sqrt, "cbrt": math.Cbrt, "log": math.Log, "log10": math.Log10,
+		"log2": math.Log2, "exp": math.Exp, "sin": math.Sin, "cos": math.Cos,
+		"tan": math.Tan, "asin": math.Asin, "acos": math.Acos, "atan": math.Atan,
+		"round": func(x float64) float64 { return math.Floor(x + 0.5) },
+		"sign": func(x float64) float64 {
+			switch {
+			case x > 0:
+				return 1
+			case x < 0:
+				return -1
+			}
+			return x
+		},
+	}
+	fns := make(map[string]NativeFunc, len(unary)+6)
+	for name, fn := range unary {
+		fns[name] = func(_ *Runtime, _ Value, args []Value) (Value, error) {
+			return fn(toNumber(arg(args, 0))), nil
+		}
+	}
+	fns["pow"] = func(_ *Runtime, _ Value, args []Value) (Value, error) {
+		return math.Pow(toNumber(arg(args, 0)), toNumber(arg(args, 1))), nil
+	}
+	fns["atan2"] = func(_ *Runtime, _ Value, args []Value) (Value, error) {
+		return math.Atan2(toNumber(arg(args, 0)), toNumber(arg(args, 1))), nil
+	}
+	fns["min"] = func(_ *Runtime, _ Value, args []Value) (Value, error) {
+		return extreme(args, math.Inf(1), math.Min), nil
+	}
+	fns["max"] = func(_ *Runtime, _ Value, args []Value) (Value, error) {
+		return extreme(args, math.Inf(-1), math.Max), nil
+	}
+	fns["hypot"] = func(_ *Runtime, _ Value, args []Value) (Value, error) {
+		sum := 0.0
+		for _, a := range args {
+			n := toNumber(a)
+			sum += n * n
+		}
+		return math.Sqrt(sum), nil
+	}
+	fns["random"] = func(r *Runtime, _ Value, _ []Value) (Value, error) { return r.rand.Float64(), nil }
+	setMethods(m, fns)
+
+	m.Set("PI", math.Pi)
+	m.Set("E", math.E)
+	m.Set("LN2", math.Ln2)
+	m.Set("LN10", math.Ln10)
+	m.Set("SQRT2", math.Sqrt2)
+	return m
+}
+
+func extreme(args []Value, start float64, pick func(a, b float64) float64) float64 {
+	out := start
+	for _, a := range args {
+		out = pick(out, toNumber(a))
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+package scriptjs
+
+import (
+	"math"
+	"time"
+)
+
+// Dates use the location of the runtime clock, so a simulated clock in UTC
+// gives the same results on every machine.
+
+func (r *Runtime) newDate(t time.Time, valid bool) *Object {
+	o := NewObject()
+	o.class = "Date"
+	o.proto = r.protos["Date"]
+	if valid {
+		o.data = t
+	}
+	return o
+}
+
+func (r *Runtime) dateCtor() *Function {
+	proto := NewObject()
+	r.protos["Date"] = proto
+
+	getters := map[string]func(t time.Time) float64{
+		"getTime":         func(t time.Time) float64 { return float64(t.UnixMilli()) },
+		"valueOf":         func(t time.Time) float64 { return float64(t.UnixMilli()) },
+		"getFullYear":     func(t time.Time) float64 { return float64(t.Year()) },
+		"getMonth":        func(t time.Time) float64 { return float64(t.Month() - 1) },
+		"getDate":         func(t time.Time) float64 { return float64(t.Day()) },
+		"getDay":          func(t time.Time) float64 { return float64(t.Weekday()) },
+		"getHours":        func(t time.Time) float64 { return float64(t.Hour()) },
+		"getMinutes":      func(t time.Time) float64 { return float64(t.Minute()) },
+		"getSeconds":      func(t time.Time) float64 { return float64(t.Second()) },
+		"getMilliseconds": func(t time.Time) float64 { return float64(t.Nanosecond() / int(time.Millisecond)) },
+		"getTimezoneOffset": func(t time.Time) float64 {
+			_, offset := t.Zone()
+			return float64(-offset / 60)
+		},
+	}
+	fns := make(map[string]NativeFunc, len(getters)+3)
+	for name, get := range getters {
+		fns[name] = func(_ *Runtime, this Value, _ []Value) (Value, error) {
+			t, ok := dateValue(this)
+			if !ok {
+				return math.NaN(), nil
+			}
+			return get(t), nil
+		}
+	}
+	iso := func(r *Runtime, this Value, _ []Value) (Value, error) {
+		t, ok := dateValue(this)
+		if !ok {
+			return nil, r.throwf("RangeError", "Invalid time value")
+		}
+		return t.UTC().Format("2006-01-02T15:04:05.000Z"), nil
+	}
+	fns["toISOString"] = iso
+	fns["toJSON"] = iso
+	fns["toString"] = func(_ *Runtime, this Value, _ []Value) (Value, error) {
+		if _, ok := dateValue(this); !ok {
+			return "Invalid Date", nil
+		}
+		return ToString(this), nil
+	}
+	setMethods(proto, fns)
+
+	ctor := NewFunction("Date", func(r *Runtime, _ Value, _ []Value) (Value, error) {
+		return ToString(r.newDate(r.Now(), true)), nil
+	})
+	ctor.construct = func(r *Runtime, _ Value, args []Value) (Value, error) {
+		t, valid := r.dateArgs(args)
+		return r.newDate(t, valid), nil
+	}
+	ctor.Set("prototype", proto)
+	ctor.Set("now", NewFunction("now", func(r *Runtime, _ Value, _ []Value) (Value, error) {
+		return float64(r.Now().UnixMilli()), nil
+	}))
+	return ctor
+}
+
+func dateValue(v Value) (time.Time, bool) {
+	if o, ok := v.(*Object); ok {
+		t, ok := o.data.(time.Time)
+		return t, ok
+	}
+	return time.Time{}, false
+}
+
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+// dateArgs interprets the arguments of new Date.
+func (r *Runtime) dateArgs(args []Value) (time.Time, bool) {
+	now := r.Now()
+	switch {
+	case len(args) == 0:
+		return now, true
+	case len(args) == 1:
+		if t, ok := dateValue(args[0]); ok {
+			return t, true
+		}
+		if s, ok := args[0].(string); ok {
+			for _, layout := range dateLayouts {
+				if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+					return t, true
+				}
+			}
+			return time.Time{}, false
+		}
+		ms := toNumber(args[0])
+		if math.IsNaN(ms) || math.IsInf(ms, 0) {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(int64(ms)).In(now.Location()), true
+	}
+	parts := [7]int{0, 0, 1, 0, 0, 0, 0}
+	for i := 0; i < len(args) && i < len(parts); i++ {
+		f := toNumber(args[i])
+		if math.IsNaN(f) {
+			return time.Time{}, false
+		}
+		parts[i] = int(f)
+	}
+	return time.Date(parts[0], time.Month(parts[1]+1), parts[2], parts[3], parts[4], parts[5],
+		parts[6]*int(time.Millisecond), now.Location()), true
+}
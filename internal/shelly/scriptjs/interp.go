@@ -0,0 +1,1043 @@
+package scriptjs
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// DefaultMaxSteps bounds the statements and calls one run of a script or
+// callback may take, so endless loops end with ErrStepLimit.
+const DefaultMaxSteps = 10_000_000
+
+// maxDepth bounds the call stack.
+const maxDepth = 200
+
+// ErrStepLimit is returned when a script runs for more than its step
+// limit. Scripts cannot catch it.
+var ErrStepLimit = errors.New("script exceeded its step limit; is there an endless loop?")
+
+// errShortCircuit ends an optional chain at a nullish link.
+var errShortCircuit = errors.New("optional chain short-circuit")
+
+// Exception is a value thrown by a script and not caught.
+type Exception struct {
+	Value Value
+	File  string
+	Line  int
+	Col   int
+}
+
+func (e *Exception) Error() string {
+	return fmt.Sprintf("%s:%d:%d: Uncaught %s", e.File, e.Line, e.Col, ToString(e.Value))
+}
+
+// Runtime runs scripts. It is not safe for concurrent use.
+type Runtime struct {
+	// MaxSteps bounds each run and call from Go; 0 disables the limit.
+	MaxSteps int
+	// Now returns the current time, for Date.
+	Now func() time.Time
+
+	global *scope
+	file   string
+	pos    position
+	steps  int
+	depth  int
+	rand   *rand.Rand
+	protos map[string]*Object
+	array  *Function
+	object *Function
+}
+
+// New returns a runtime with the standard built-ins defined.
+func New() *Runtime {
+	r := &Runtime{
+		MaxSteps: DefaultMaxSteps,
+		Now:      time.Now,
+		global:   newScope(nil, true),
+		// A fixed seed keeps Math.random reproducible between runs.
+		rand:   rand.New(rand.NewSource(1)), //nolint:gosec // Not used for security
+		protos: make(map[string]*Object),
+	}
+	r.global.hasThis = true
+	r.global.this = Undefined
+	r.installBuiltins()
+	return r
+}
+
+// Set defines a global variable.
+func (r *Runtime) Set(name string, v Value) {
+	r.global.declare(name, v, false)
+}
+
+// Get returns a global variable, or Undefined.
+func (r *Runtime) Get(name string) Value {
+	if b := r.global.vars[name]; b != nil {
+		return b.value
+	}
+	return Undefined
+}
+
+// Run parses and runs a script.
+func (r *Runtime) Run(name, src string) error {
+	prog, err := Parse(name, src)
+	if err != nil {
+		return err
+	}
+	return r.RunProgram(prog)
+}
+
+// RunProgram runs a parsed script.
+func (r *Runtime) RunProgram(p *Program) error {
+	r.file = p.name
+	r.steps = 0
+	r.hoist(r.global, p.vars, p.body)
+	_, _, err := r.execList(p.body, r.global)
+	return err
+}
+
+// Call calls a script function, as a handler or callback.
+func (r *Runtime) Call(fn Value, this Value, args ...Value) (Value, error) {
+	f, ok := fn.(*Function)
+	if !ok {
+		return nil, r.throwf("TypeError", "%s is not a function", typeOf(fn))
+	}
+	if r.depth == 0 {
+		r.steps = 0
+	}
+	return r.call(f, this, args)
+}
+
+// Throw returns an exception of an error type (Error, TypeError, ...) for
+// built-in functions to return.
+func (r *Runtime) Throw(kind, format string, args ...any) error {
+	return r.throwf(kind, format, args...)
+}
+
+func (r *Runtime) throwf(kind, format string, args ...any) error {
+	return &Exception{Value: r.newError(kind, fmt.Sprintf(format, args...)), File: r.file, Line: r.pos.line, Col: r.pos.col}
+}
+
+func (r *Runtime) tick() error {
+	r.steps++
+	if r.MaxSteps > 0 && r.steps > r.MaxSteps {
+		return ErrStepLimit
+	}
+	return nil
+}
+
+// Scopes.
+
+type binding struct {
+	value    Value
+	constant bool
+}
+
+type scope struct {
+	vars   map[string]*binding
+	parent *scope
+	// fn marks function and global scopes, which hold var declarations.
+	fn      bool
+	this    Value
+	hasThis bool
+}
+
+func newScope(parent *scope, fn bool) *scope {
+	return &scope{vars: make(map[string]*binding), parent: parent, fn: fn}
+}
+
+func (s *scope) lookup(name string) *binding {
+	for sc := s; sc != nil; sc = sc.parent {
+		if b := sc.vars[name]; b != nil {
+			return b
+		}
+	}
+	return nil
+}
+
+func (s *scope) declare(name string, v Value, constant bool) {
+	s.vars[name] = &binding{value: v, constant: constant}
+}
+
+func (s *scope) thisValue() Value {
+	for sc := s; sc != nil; sc = sc.parent {
+		if sc.hasThis {
+			return sc.this
+		}
+	}
+	return Undefined
+}
+
+// hoist declares var names and function declarations of a body.
+func (r *Runtime) hoist(s *scope, vars []string, body []stmt) {
+	for _, name := range vars {
+		if s.vars[name] == nil {
+			s.declare(name, Undefined, false)
+		}
+	}
+	r.hoistFunctions(s, body)
+}
+
+func (r *Runtime) hoistFunctions(s *scope, body []stmt) {
+	for _, st := range body {
+		if d, ok := st.(*funcDecl); ok {
+			s.declare(d.fn.name, r.closure(d.fn, s), false)
+		}
+	}
+}
+
+func (r *Runtime) closure(fn *funcNode, s *scope) *Function {
+	return &Function{Object: Object{class: "Function"}, name: fn.name, node: fn, scope: s}
+}
+
+// Functions.
+
+func (r *Runtime) call(f *Function, this Value, args []Value) (Value, error) {
+	if f.bound != nil {
+		return r.call(f.bound, f.this, append(f.boundArgs[:len(f.boundArgs):len(f.boundArgs)], args...))
+	}
+	if f.native != nil {
+		return f.native(r, this, args)
+	}
+	if f.node == nil {
+		return nil, r.throwf("TypeError", "Class constructor %s cannot be invoked without 'new'", f.name)
+	}
+	if r.depth >= maxDepth {
+		return nil, r.throwf("RangeError", "Maximum call stack size exceeded")
+	}
+	if err := r.tick(); err != nil {
+		return nil, err
+	}
+	r.depth++
+	defer func() { r.depth-- }()
+
+	fn := f.node
+	sc := newScope(f.scope, true)
+	if !fn.arrow {
+		sc.hasThis = true
+		sc.this = this
+		sc.declare("arguments", NewArray(append([]Value(nil), args...)...), false)
+	}
+	for i, p := range fn.params {
+		if p.rest {
+			rest := []Value{}
+			if i < len(args) {
+				rest = append(rest, args[i:]...)
+			}
+			sc.declare(p.name, NewArray(rest...), false)
+			break
+		}
+		v := arg(args, i)
+		if v == Undefined && p.def != nil {
+			var err error
+			if v, err = p.def.eval(r, sc); err != nil {
+				return nil, err
+			}
+		}
+		sc.declare(p.name, v, false)
+	}
+
+	if fn.exprBody != nil {
+		return fn.exprBody.eval(r, sc)
+	}
+	r.hoist(sc, fn.vars, fn.body)
+	fl, v, err := r.execList(fn.body, sc)
+	if err != nil {
+		return nil, err
+	}
+	if fl == flowReturn {
+		return v, nil
+	}
+	return Undefined, nil
+}
+
+func (r *Runtime) construct(f *Function, args []Value) (Value, error) {
+	if f.construct != nil {
+		return f.construct(r, Undefined, args)
+	}
+	if f.node == nil || f.node.arrow || f.bound != nil {
+		return nil, r.throwf("TypeError", "%s is not a constructor", f.name)
+	}
+	obj := NewObject()
+	obj.proto = r.prototypeOf(f)
+	res, err := r.call(f, obj, args)
+	if err != nil {
+		return nil, err
+	}
+	if isObject(res) {
+		return res, nil
+	}
+	return obj, nil
+}
+
+// prototypeOf returns the prototype property of a function, creating it
+// on first use.
+func (r *Runtime) prototypeOf(f *Function) *Object {
+	if p, ok := f.props["prototype"].(*Object); ok {
+		return p
+	}
+	p := NewObject()
+	f.Set("prototype", p)
+	return p
+}
+
+// Statements.
+
+type flow int
+
+const (
+	flowNormal flow = iota
+	flowReturn
+	flowBreak
+	flowContinue
+)
+
+func (r *Runtime) execList(body []stmt, s *scope) (flow, Value, error) {
+	for _, st := range body {
+		r.pos = st.pos()
+		if err := r.tick(); err != nil {
+			return flowNormal, nil, err
+		}
+		fl, v, err := st.exec(r, s)
+		if err != nil || fl != flowNormal {
+			return fl, v, err
+		}
+	}
+	return flowNormal, nil, nil
+}
+
+func (b *blockStmt) exec(r *Runtime, s *scope) (flow, Value, error) {
+	sc := newScope(s, false)
+	r.hoistFunctions(sc, b.body)
+	return r.execList(b.body, sc)
+}
+
+func (*emptyStmt) exec(*Runtime, *scope) (flow, Value, error) { return flowNormal, nil, nil }
+
+func (*funcDecl) exec(*Runtime, *scope) (flow, Value, error) { return flowNormal, nil, nil }
+
+func (e *exprStmt) exec(r *Runtime, s *scope) (flow, Value, error) {
+	_, err := e.x.eval(r, s)
+	return flowNormal, nil, err
+}
+
+func (d *varDecl) exec(r *Runtime, s *scope) (flow, Value, error) {
+	for _, dec := range d.decls {
+		var v Value = Undefined
+		if dec.init != nil {
+			var err error
+			if v, err = dec.init.eval(r, s); err != nil {
+				return flowNormal, nil, err
+			}
+			if f, ok := v.(*Function); ok && f.name == "" && f.node != nil {
+				f.name = dec.name
+			}
+		}
+		if d.kind != "var" {
+			s.declare(dec.name, v, d.kind == "const")
+			continue
+		}
+		if dec.init == nil {
+			continue
+		}
+		if b := s.lookup(dec.name); b != nil {
+			b.value = v
+		} else {
+			s.declare(dec.name, v, false)
+		}
+	}
+	return flowNormal, nil, nil
+}
+
+func (i *ifStmt) exec(r *Runtime, s *scope) (flow, Value, error) {
+	test, err := i.test.eval(r, s)
+	if err != nil {
+		return flowNormal, nil, err
+	}
+	if toBool(test) {
+		return i.then.exec(r, s)
+	}
+	if i.els != nil {
+		return i.els.exec(r, s)
+	}
+	return flowNormal, nil, nil
+}
+
+// loopBody runs one iteration. done reports whether the loop ends, with
+// the flow to pass on.
+func (r *Runtime) loopBody(body stmt, s *scope) (done bool, fl flow, v Value, err error) {
+	if err := r.tick(); err != nil {
+		return true, flowNormal, nil, err
+	}
+	fl, v, err = body.exec(r, s)
+	switch {
+	case err != nil:
+		return true, flowNormal, nil, err
+	case fl == flowBreak:
+		return true, flowNormal, nil, nil
+	case fl == flowReturn:
+		return true, fl, v, nil
+	}
+	return false, flowNormal, nil, nil
+}
+
+func (w *whileStmt) exec(r *Runtime, s *scope) (flow, Value, error) {
+	for first := true; ; first = false {
+		if !w.do || !first {
+			test, err := w.test.eval(r, s)
+			if err != nil {
+				return flowNormal, nil, err
+			}
+			if !toBool(test) {
+				return flowNormal, nil, nil
+			}
+		}
+		if done, fl, v, err := r.loopBody(w.body, s); done {
+			return fl, v, err
+		}
+	}
+}
+
+func (f *forStmt) exec(r *Runtime, s *scope) (flow, Value, error) {
+	sc := newScope(s, false)
+	if f.init != nil {
+		if _, _, err := f.init.exec(r, sc); err != nil {
+			return flowNormal, nil, err
+		}
+	}
+	// let declarations get a fresh binding per iteration, so closures
+	// created in the body see the value of their own iteration.
+	decl, perIteration := f.init.(*varDecl)
+	perIteration = perIteration && decl.kind != "var"
+
+	for {
+		if f.test != nil {
+			test, err := f.test.eval(r, sc)
+			if err != nil {
+				return flowNormal, nil, err
+			}
+			if !toBool(test) {
+				return flowNormal, nil, nil
+			}
+		}
+		if done, fl, v, err := r.loopBody(f.body, sc); done {
+			return fl, v, err
+		}
+		if perIteration {
+			sc = copyScope(sc)
+		}
+		if f.update != nil {
+			if _, err := f.update.eval(r, sc); err != nil {
+				return flowNormal, nil, err
+			}
+		}
+	}
+}
+
+func copyScope(s *scope) *scope {
+	c := newScope(s.parent, false)
+	for name, b := range s.vars {
+		c.vars[name] = &binding{value: b.value, constant: b.constant}
+	}
+	return c
+}
+
+func (f *forInStmt) exec(r *Runtime, s *scope) (flow, Value, error) {
+	obj, err := f.obj.eval(r, s)
+	if err != nil {
+		return flowNormal, nil, err
+	}
+	var items []Value
+	if f.of {
+		if items, err = r.iterate(obj); err != nil {
+			return flowNormal, nil, err
+		}
+	} else {
+		items = forInKeys(obj)
+	}
+
+	for _, item := range items {
+		sc := newScope(s, false)
+		switch {
+		case f.kind == "let" || f.kind == "const":
+			sc.declare(f.name, item, f.kind == "const")
+		case f.kind == "var":
+			if err := r.assignName(s, f.name, item); err != nil {
+				return flowNormal, nil, err
+			}
+		default:
+			if err := r.assign(f.target, item, s); err != nil {
+				return flowNormal, nil, err
+			}
+		}
+		if done, fl, v, err := r.loopBody(f.body, sc); done {
+			return fl, v, err
+		}
+	}
+	return flowNormal, nil, nil
+}
+
+// iterate returns the values of an iterable for for...of and spread.
+func (r *Runtime) iterate(v Value) ([]Value, error) {
+	switch v := v.(type) {
+	case *Array:
+		return append([]Value(nil), v.elems...), nil
+	case string:
+		items := make([]Value, 0, len(v))
+		for _, c := range v {
+			items = append(items, string(c))
+		}
+		return items, nil
+	}
+	return nil, r.throwf("TypeError", "%s is not iterable", typeOf(v))
+}
+
+func forInKeys(v Value) []Value {
+	var keys []Value
+	switch v := v.(type) {
+	case *Array:
+		for i := range v.elems {
+			keys = append(keys, formatNumber(float64(i)))
+		}
+	case string:
+		for i := range []rune(v) {
+			keys = append(keys, formatNumber(float64(i)))
+		}
+	case *Object:
+		for _, k := range v.keys {
+			keys = append(keys, k)
+		}
+	case *Function:
+		for _, k := range v.keys {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (rs *returnStmt) exec(r *Runtime, s *scope) (flow, Value, error) {
+	if rs.x == nil {
+		return flowReturn, Undefined, nil
+	}
+	v, err := rs.x.eval(r, s)
+	if err != nil {
+		return flowNormal, nil, err
+	}
+	return flowReturn, v, nil
+}
+
+func (b *breakStmt) exec(*Runtime, *scope) (flow, Value, error) {
+	if b.cont {
+		return flowContinue, nil, nil
+	}
+	return flowBreak, nil, nil
+}
+
+func (t *throwStmt) exec(r *Runtime, s *scope) (flow, Value, error) {
+	v, err := t.x.eval(r, s)
+	if err != nil {
+		return flowNormal, nil, err
+	}
+	return flowNormal, nil, &Exception{Value: v, File: r.file, Line: t.line, Col: t.col}
+}
+
+func (t *tryStmt) exec(r *Runtime, s *scope) (flow, Value, error) {
+	fl, v, err := t.block.exec(r, s)
+	var exc *Exception
+	if err != nil && t.handler != nil && errors.As(err, &exc) {
+		sc := newScope(s, false)
+		if t.param != "" {
+			sc.declare(t.param, exc.Value, false)
+		}
+		fl, v, err = t.handler.exec(r, sc)
+	}
+	if t.finalizer != nil {
+		ffl, fv, ferr := t.finalizer.exec(r, s)
+		if ferr != nil || ffl != flowNormal {
+			return ffl, fv, ferr
+		}
+	}
+	return fl, v, err
+}
+
+func (sw *switchStmt) exec(r *Runtime, s *scope) (flow, Value, error) {
+	disc, err := sw.disc.eval(r, s)
+	if err != nil {
+		return flowNormal, nil, err
+	}
+	start := -1
+	for i, c := range sw.cases {
+		if c.test == nil {
+			continue
+		}
+		v, err := c.test.eval(r, s)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		if strictEquals(disc, v) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		for i, c := range sw.cases {
+			if c.test == nil {
+				start = i
+			}
+		}
+	}
+	if start < 0 {
+		return flowNormal, nil, nil
+	}
+
+	sc := newScope(s, false)
+	for _, c := range sw.cases[start:] {
+		r.hoistFunctions(sc, c.body)
+		fl, v, err := r.execList(c.body, sc)
+		switch {
+		case err != nil:
+			return flowNormal, nil, err
+		case fl == flowBreak:
+			return flowNormal, nil, nil
+		case fl != flowNormal:
+			return fl, v, nil
+		}
+	}
+	return flowNormal, nil, nil
+}
+
+// Expressions.
+
+func (c *constExpr) eval(*Runtime, *scope) (Value, error) { return c.value, nil }
+
+func (t *templateLit) eval(r *Runtime, s *scope) (Value, error) {
+	out := t.quasis[0]
+	for i, x := range t.exprs {
+		v, err := x.eval(r, s)
+		if err != nil {
+			return nil, err
+		}
+		out += ToString(toPrimitive(v)) + t.quasis[i+1]
+	}
+	return out, nil
+}
+
+func (re *regexLit) eval(r *Runtime, _ *scope) (Value, error) {
+	r.pos = re.position
+	return r.newRegExp(re.pattern, re.flags)
+}
+
+func (id *ident) eval(r *Runtime, s *scope) (Value, error) {
+	b := s.lookup(id.name)
+	if b == nil {
+		r.pos = id.position
+		return nil, r.throwf("ReferenceError", "%s is not defined", id.name)
+	}
+	return b.value, nil
+}
+
+func (*thisExpr) eval(_ *Runtime, s *scope) (Value, error) { return s.thisValue(), nil }
+
+func (a *arrayLit) eval(r *Runtime, s *scope) (Value, error) {
+	elems, err := r.evalList(a.elems, s)
+	if err != nil {
+		return nil, err
+	}
+	return NewArray(elems...), nil
+}
+
+// evalList evaluates arguments or array elements, expanding spreads.
+func (r *Runtime) evalList(list []expr, s *scope) ([]Value, error) {
+	out := make([]Value, 0, len(list))
+	for _, x := range list {
+		if x == nil {
+			out = append(out, Undefined)
+			continue
+		}
+		sp, spread := x.(*spreadExpr)
+		if spread {
+			x = sp.x
+		}
+		v, err := x.eval(r, s)
+		if err != nil {
+			return nil, err
+		}
+		if !spread {
+			out = append(out, v)
+			continue
+		}
+		items, err := r.iterate(v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+	}
+	return out, nil
+}
+
+func (o *objectLit) eval(r *Runtime, s *scope) (Value, error) {
+	obj := NewObject()
+	for _, p := range o.props {
+		v, err := p.value.eval(r, s)
+		if err != nil {
+			return nil, err
+		}
+		if p.spread {
+			copyProps(obj, v)
+			continue
+		}
+		key := p.key
+		if p.computed != nil {
+			k, err := p.computed.eval(r, s)
+			if err != nil {
+				return nil, err
+			}
+			key = propertyKey(k)
+		}
+		obj.Set(key, v)
+	}
+	return obj, nil
+}
+
+// copyProps copies the own properties of src, as spread and
+// Object.assign do.
+func copyProps(dst *Object, src Value) {
+	switch src := src.(type) {
+	case *Object:
+		for _, k := range src.keys {
+			dst.Set(k, src.props[k])
+		}
+	case *Array:
+		for i, e := range src.elems {
+			dst.Set(formatNumber(float64(i)), e)
+		}
+	case string:
+		for i, c := range []rune(src) {
+			dst.Set(formatNumber(float64(i)), string(c))
+		}
+	}
+}
+
+func (f *funcExpr) eval(r *Runtime, s *scope) (Value, error) {
+	if f.fn.arrow || f.fn.name == "" {
+		return r.closure(f.fn, s), nil
+	}
+	// A named function expression can call itself by name.
+	sc := newScope(s, false)
+	fn := r.closure(f.fn, sc)
+	sc.declare(f.fn.name, fn, false)
+	return fn, nil
+}
+
+func (sp *spreadExpr) eval(r *Runtime, _ *scope) (Value, error) {
+	r.pos = sp.position
+	return nil, r.throwf("SyntaxError", "unexpected spread")
+}
+
+func (u *unaryExpr) eval(r *Runtime, s *scope) (Value, error) {
+	switch u.op {
+	case "typeof":
+		if id, ok := u.x.(*ident); ok && s.lookup(id.name) == nil {
+			return "undefined", nil
+		}
+	case "delete":
+		return r.delete(u.x, s)
+	}
+	v, err := u.x.eval(r, s)
+	if err != nil {
+		return nil, err
+	}
+	switch u.op {
+	case "typeof":
+		return typeOf(v), nil
+	case "!":
+		return !toBool(v), nil
+	case "-":
+		return -toNumber(v), nil
+	case "+":
+		return toNumber(v), nil
+	case "~":
+		return float64(^toInt32(v)), nil
+	}
+	return Undefined, nil // void
+}
+
+func (r *Runtime) delete(x expr, s *scope) (Value, error) {
+	m, ok := x.(*memberExpr)
+	if !ok {
+		return true, nil
+	}
+	obj, key, err := r.memberTarget(m, s)
+	if err != nil {
+		return nil, err
+	}
+	switch o := obj.(type) {
+	case *Object:
+		o.Delete(key)
+	case *Function:
+		o.Delete(key)
+	case *Array:
+		if i, ok := arrayIndex(key); ok && i < len(o.elems) {
+			o.elems[i] = Undefined
+		}
+	}
+	return true, nil
+}
+
+func (u *updateExpr) eval(r *Runtime, s *scope) (Value, error) {
+	old, err := u.target.eval(r, s)
+	if err != nil {
+		return nil, err
+	}
+	n := toNumber(old)
+	next := n + 1
+	if u.op == "--" {
+		next = n - 1
+	}
+	if err := r.assign(u.target, next, s); err != nil {
+		return nil, err
+	}
+	if u.prefix {
+		return next, nil
+	}
+	return n, nil
+}
+
+func (b *binaryExpr) eval(r *Runtime, s *scope) (Value, error) {
+	left, err := b.l.eval(r, s)
+	if err != nil {
+		return nil, err
+	}
+	right, err := b.r.eval(r, s)
+	if err != nil {
+		return nil, err
+	}
+	r.pos = b.position
+	return r.binaryOp(b.op, left, right)
+}
+
+func (l *logicalExpr) eval(r *Runtime, s *scope) (Value, error) {
+	left, err := l.l.eval(r, s)
+	if err != nil {
+		return nil, err
+	}
+	if shortCircuits(l.op, left) {
+		return left, nil
+	}
+	return l.r.eval(r, s)
+}
+
+// shortCircuits reports whether a logical operator returns its left
+// operand without evaluating the right one.
+func shortCircuits(op string, left Value) bool {
+	switch op {
+	case "&&":
+		return !toBool(left)
+	case "||":
+		return toBool(left)
+	}
+	return !isNullish(left)
+}
+
+func (a *assignExpr) eval(r *Runtime, s *scope) (Value, error) {
+	if a.op == "=" {
+		v, err := a.value.eval(r, s)
+		if err != nil {
+			return nil, err
+		}
+		if f, ok := v.(*Function); ok && f.name == "" && f.node != nil {
+			if id, ok := a.target.(*ident); ok {
+				f.name = id.name
+			}
+		}
+		return v, r.assign(a.target, v, s)
+	}
+
+	old, err := a.target.eval(r, s)
+	if err != nil {
+		return nil, err
+	}
+	op := a.op[:len(a.op)-1]
+	var v Value
+	switch op {
+	case "&&", "||", "??":
+		if shortCircuits(op, old) {
+			return old, nil
+		}
+		if v, err = a.value.eval(r, s); err != nil {
+			return nil, err
+		}
+	default:
+		right, err := a.value.eval(r, s)
+		if err != nil {
+			return nil, err
+		}
+		r.pos = a.position
+		if v, err = r.binaryOp(op, old, right); err != nil {
+			return nil, err
+		}
+	}
+	return v, r.assign(a.target, v, s)
+}
+
+func (r *Runtime) assign(target expr, v Value, s *scope) error {
+	switch t := target.(type) {
+	case *ident:
+		r.pos = t.position
+		return r.assignName(s, t.name, v)
+	case *memberExpr:
+		obj, key, err := r.memberTarget(t, s)
+		if err != nil {
+			return err
+		}
+		r.pos = t.position
+		return r.setMember(obj, key, v)
+	}
+	return r.throwf("SyntaxError", "invalid assignment target")
+}
+
+func (r *Runtime) assignName(s *scope, name string, v Value) error {
+	b := s.lookup(name)
+	switch {
+	case b == nil:
+		// Assigning an undeclared name creates a global, as on the device.
+		r.global.declare(name, v, false)
+	case b.constant:
+		return r.throwf("TypeError", "Assignment to constant variable %s", name)
+	default:
+		b.value = v
+	}
+	return nil
+}
+
+func (c *condExpr) eval(r *Runtime, s *scope) (Value, error) {
+	test, err := c.test.eval(r, s)
+	if err != nil {
+		return nil, err
+	}
+	if toBool(test) {
+		return c.then.eval(r, s)
+	}
+	return c.els.eval(r, s)
+}
+
+func (c *callExpr) eval(r *Runtime, s *scope) (Value, error) {
+	var fn, this Value = nil, Undefined
+	var err error
+	if m, ok := c.callee.(*memberExpr); ok {
+		var key string
+		if this, key, err = r.memberTarget(m, s); err != nil {
+			return nil, err
+		}
+		r.pos = m.position
+		fn, err = r.getMember(this, key)
+	} else {
+		fn, err = c.callee.eval(r, s)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.optional && isNullish(fn) {
+		return nil, errShortCircuit
+	}
+
+	args, err := r.evalList(c.args, s)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := fn.(*Function)
+	r.pos = c.position
+	if !ok {
+		return nil, r.throwf("TypeError", "%s is not a function", describe(c.callee))
+	}
+	v, err := r.call(f, this, args)
+	r.pos = c.position
+	return v, err
+}
+
+// describe names an expression in error messages.
+func describe(x expr) string {
+	switch x := x.(type) {
+	case *ident:
+		return x.name
+	case *memberExpr:
+		if x.computed != nil {
+			return describe(x.obj) + "[...]"
+		}
+		return describe(x.obj) + "." + x.name
+	case *thisExpr:
+		return "this"
+	case *callExpr:
+		return describe(x.callee) + "(...)"
+	}
+	return "expression"
+}
+
+// memberTarget evaluates the object and key of a member expression.
+func (r *Runtime) memberTarget(m *memberExpr, s *scope) (Value, string, error) {
+	obj, err := m.obj.eval(r, s)
+	if err != nil {
+		return nil, "", err
+	}
+	if m.optional && isNullish(obj) {
+		return nil, "", errShortCircuit
+	}
+	if m.computed == nil {
+		return obj, m.name, nil
+	}
+	k, err := m.computed.eval(r, s)
+	if err != nil {
+		return nil, "", err
+	}
+	return obj, propertyKey(k), nil
+}
+
+func (m *memberExpr) eval(r *Runtime, s *scope) (Value, error) {
+	obj, key, err := r.memberTarget(m, s)
+	if err != nil {
+		return nil, err
+	}
+	r.pos = m.position
+	if isNullish(obj) {
+		return nil, r.throwf("TypeError", "Cannot read properties of %s (reading '%s' of %s)", ToString(obj), key, describe(m.obj))
+	}
+	return r.getMember(obj, key)
+}
+
+func (o *optionalChain) eval(r *Runtime, s *scope) (Value, error) {
+	v, err := o.x.eval(r, s)
+	if errors.Is(err, errShortCircuit) {
+		return Undefined, nil
+	}
+	return v, err
+}
+
+func (n *newExpr) eval(r *Runtime, s *scope) (Value, error) {
+	callee, err := n.callee.eval(r, s)
+	if err != nil {
+		return nil, err
+	}
+	args, err := r.evalList(n.args, s)
+	if err != nil {
+		return nil, err
+	}
+	r.pos = n.position
+	f, ok := callee.(*Function)
+	if !ok {
+		return nil, r.throwf("TypeError", "%s is not a constructor", describe(n.callee))
+	}
+	return r.construct(f, args)
+}
+
+func (q *seqExpr) eval(r *Runtime, s *scope) (Value, error) {
+	var v Value
+	for _, x := range q.exprs {
+		var err error
+		if v, err = x.eval(r, s); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,237 @@
+package scriptjs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eval runs src and returns the global result, formatted by Inspect.
+func eval(t *testing.T, src string) (string, error) {
+	t.Helper()
+	r := New()
+	r.Now = func() time.Time { return time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC) }
+	if err := r.Run("test.js", src); err != nil {
+		return "", err
+	}
+	return Inspect(r.Get("result")), nil
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"arithmetic", "let result = 1 + 2 * 3 - 4 / 2 % 3 + 2 ** 3;", "13"},
+		{"string concat", `let result = "a" + 1 + 2;`, "a12"},
+		{"number format", "let result = [0.1 + 0.2, 1e21, -0, 10 / 3].join(' ');", "0.30000000000000004 1e+21 0 3.3333333333333335"},
+		{"equality", `let result = [1 == "1", 1 === "1", null == undefined, null === undefined, NaN == NaN];`, "[true,false,true,false,false]"},
+		{"bitwise", "let result = [5 & 3, 5 | 3, 5 ^ 3, ~5, 1 << 4, -16 >> 2, -16 >>> 28];", "[1,7,6,-6,16,-4,15]"},
+		{"logical", `let a = null; let result = [a ?? "d", a || "o", 0 && 1, a?.b?.c];`, `["d","o",0,null]`},
+		{"template", "let n = 2; let result = `n=${n * 2} ${'x'}`;", "n=4 x"},
+		{"hoisting", "let result = f(); function f() { return typeof g + typeof x; } var x = 1;", "undefinedundefined"},
+		{"closures", `
+			function counter() { let n = 0; return () => ++n; }
+			let c = counter(); c(); c();
+			let result = c();`, "3"},
+		{"let per iteration", `
+			let fns = [];
+			for (let i = 0; i < 3; i++) fns.push(() => i);
+			let result = fns.map(f => f());`, "[0,1,2]"},
+		{"loops", `
+			let result = [];
+			for (const k in {a: 1, b: 2}) result.push(k);
+			for (const v of [3, 4]) { if (v === 4) continue; result.push(v); }
+			let i = 0; while (true) { if (++i > 2) break; }
+			do { i++; } while (i < 5);
+			result.push(i);`, `["a","b",3,5]`},
+		{"switch", `
+			function kind(x) {
+				switch (x) {
+				case 1: case 2: return "small";
+				case 3: { let s = "three"; return s; }
+				default: return "other";
+				}
+			}
+			let result = [kind(1), kind(3), kind(9)];`, `["small","three","other"]`},
+		{"try", `
+			let log = [];
+			function f() { try { throw new TypeError("bad"); } catch (e) { log.push(e.name, e.message, String(e)); return 1; } finally { log.push("finally"); } }
+			f();
+			try { null.x; } catch (e) { log.push(e instanceof TypeError); }
+			try { undefinedName; } catch (e) { log.push(e.name); }
+			let result = log;`, `["TypeError","bad","TypeError: bad","finally",true,"ReferenceError"]`},
+		{"objects", `
+			let key = "k";
+			let o = {a: 1, [key]: 2, m() { return this.a; }, ...{z: 3}};
+			delete o.z;
+			o.b = 4;
+			let result = [o.m(), Object.keys(o), "a" in o, o.hasOwnProperty("q")];`, `[1,["a","k","m","b"],true,false]`},
+		{"constructors", `
+			function P(x) { this.x = x; }
+			P.prototype.get = function () { return this.x; };
+			let p = new P(5);
+			let result = [p.get(), p instanceof P, [] instanceof Array, typeof P];`, `[5,true,true,"function"]`},
+		{"spread and rest", `
+			function sum(first, ...rest) { return rest.reduce((a, b) => a + b, first); }
+			let result = sum(...[1, 2], 3, ...[4]);`, "10"},
+		{"defaults", "function f(a, b = a * 2) { return a + b; } let result = f(2);", "6"},
+		{"bind call apply", `
+			function who(greeting) { return greeting + " " + this.name; }
+			let o = {name: "sw"};
+			let result = [who.call(o, "hi"), who.apply(o, ["yo"]), who.bind(o, "hey")()];`, `["hi sw","yo sw","hey sw"]`},
+		{"arrays", `
+			let a = [3, 1, 2];
+			a.sort();
+			let b = a.slice(1).concat([9]);
+			a.splice(1, 1, "x", "y");
+			let result = [a, b, [1, 2, 3].filter(x => x > 1).map(x => x * 10), [1, 2].indexOf(2), [1, [2, 3]].flat(),
+				[5, 1, 10].sort((x, y) => x - y), [1, 2].some(x => x > 1), [1, 2].every(x => x > 1), [4, 5].find(x => x > 4)];`,
+			`[[1,"x","y",3],[2,3,9],[20,30],1,[1,2,3],[1,5,10],true,false,5]`},
+		{"strings", `
+			let s = "  Hello, World  ".trim();
+			let result = [s.toUpperCase(), s.slice(-5), s.indexOf("o"), s.split(", "), s.replace("l", "L"),
+				s.replaceAll("l", "L"), "7".padStart(3, "0"), s.substring(7, 5), s.charAt(1), "ab".repeat(2), s.length];`,
+			`["HELLO, WORLD","World",4,["Hello","World"],"HeLlo, World","HeLLo, WorLd","007",", ","e","abab",12]`},
+		{"regexp", `
+			let m = "power: 12.5W".match(/(\d+(\.\d+)?)W/);
+			let re = /a/g; let count = 0; while (re.exec("banana")) count++;
+			let result = [m[1], /^sw/i.test("Switch"), "a-b_c".replace(/[-_]/g, " "), count, "x1y2".replace(/\d/g, d => d * 2), "a,b;c".split(/[,;]/)];`,
+			`["12.5",true,"a b c",3,"x2y4",["a","b","c"]]`},
+		{"json", `
+			let o = JSON.parse('{"b": 1, "a": [true, null, "x"], "n": {"f": 1.5}}');
+			let result = [JSON.stringify(o), JSON.stringify({u: undefined, f() {}, v: [undefined]}), JSON.stringify([1, {a: 2}], null, 2)];`,
+			`["{\"b\":1,\"a\":[true,null,\"x\"],\"n\":{\"f\":1.5}}","{\"v\":[null]}","[\n  1,\n  {\n    \"a\": 2\n  }\n]"]`},
+		{"numbers", `let result = [(3.14159).toFixed(2), parseInt("42px"), parseInt("ff", 16), parseFloat("3.5e2x"), Number("12"), isNaN("x"), (255).toString(16), Math.max(1, 5, 3), Math.round(2.5)];`,
+			`["3.14",42,255,350,12,true,"ff",5,3]`},
+		{"date", `let d = new Date(); let result = [d.getFullYear(), d.getMonth(), d.getHours(), d.toISOString(), Date.now() === d.getTime()];`,
+			`[2024,4,7,"2024-05-06T07:08:09.000Z",true]`},
+		{"sloppy globals", "function f() { g = 5; } f(); let result = g;", "5"},
+		{"named function expression", "let f = function fact(n) { return n <= 1 ? 1 : n * fact(n - 1); }; let result = f(5);", "120"},
+		{"escapes", `let result = ['\x41\u0042\u{1F600}\t|', 'a\
+b'];`, `["AB😀\t|","ab"]`},
+		{"for of and spread of strings", `
+			let result = [...'hé'];
+			for (const c of 'ab') result.push(c);`, `["h","é","a","b"]`},
+		{"for in keys", `
+			function F() {}
+			F.x = 1;
+			let result = [];
+			for (const k in F) result.push(k);
+			for (const k in 'xy') result.push(k);
+			for (const k in [7]) result.push(k);`, `["x","0","1","0"]`},
+		{"optional chaining", `
+			let o = {a: {b: 1}, f() { return 2; }};
+			let n = null;
+			let result = [o?.a.b, o?.['a']?.b, o.f?.(), n?.(), n?.[0], n?.a.b, o.g?.()];`, "[1,1,2,null,null,null,null]"},
+		{"replace patterns", `let result = '2024-05-06'.replace(/(\d+)-(\d+)-(\d+)/, '$3.$2.$1 ($&) $$');`, "06.05.2024 (2024-05-06) $"},
+		{"sequence and empty statements", ";; let i = 0; let result = (i++, i++, i);;", "2"},
+		{"update and compound", "let o = {n: 1}; o.n += 2; o.n **= 2; let a = [1]; a[0]++; let x; x ??= 7; let result = [o.n, a[0], x];", "[9,2,7]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := eval(t, tt.src)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("result = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"uncaught throw", "let a = 1;\nthrow new Error('boom');", "test.js:2:1: Uncaught Error: boom"},
+		{"not a function", "let o = {};\no.missing();", "Uncaught TypeError: o.missing is not a function"},
+		{"undefined property", "let o;\nlet x = o.a;", "Cannot read properties of undefined"},
+		{"constant", "const c = 1; c = 2;", "Assignment to constant variable c"},
+		{"recursion", "function f() { return f(); } f();", "Maximum call stack size exceeded"},
+		{"syntax", "let = ;", "test.js:1:"},
+		{"for of non-iterable", "for (const x of 5) {}", "Uncaught TypeError: number is not iterable"},
+		{"spread of object", "let a = [...{a: 1}];", "Uncaught TypeError: object is not iterable"},
+		{"optional chain without name", "let o = {};\nlet x = o?.;", "test.js:2:"},
+		{"unclosed optional index", "let o = {};\nlet x = o?.[1;", "test.js:2:"},
+		{"unclosed optional call", "let o = {};\nlet x = o.f?.(1;", "test.js:2:"},
+		{"bad escape", `let s = '\u{zz}';`, "invalid unicode escape"},
+		{"class", "class Lamp {}", "class is not supported"},
+		{"async", "async function f() {}", "async is not supported"},
+		{"destructuring", "let {a} = {a: 1};", "destructuring is not supported"},
+		{"getter", "let o = { get a() { return 1; } };", "getters and setters are not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := eval(t, tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Run() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_StepLimit(t *testing.T) {
+	t.Parallel()
+
+	r := New()
+	r.MaxSteps = 1000
+	err := r.Run("loop.js", "try { while (true) {} } catch (e) {}")
+	if !errors.Is(err, ErrStepLimit) {
+		t.Errorf("Run() error = %v, want ErrStepLimit", err)
+	}
+}
+
+func TestRuntime_Call(t *testing.T) {
+	t.Parallel()
+
+	r := New()
+	r.Set("native", NewFunction("native", func(_ *Runtime, _ Value, args []Value) (Value, error) {
+		return FromGo(map[string]any{"got": ToGo(args[0])}), nil
+	}))
+	if err := r.Run("cb.js", "function handler(ev) { return native(ev.info).got.n + 1; }"); err != nil {
+		t.Fatal(err)
+	}
+	v, err := r.Call(r.Get("handler"), Undefined, FromGo(map[string]any{"info": map[string]any{"n": 41}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 42.0 {
+		t.Errorf("Call() = %v, want 42", v)
+	}
+}
+
+func TestRuntime_Throw(t *testing.T) {
+	t.Parallel()
+
+	r := New()
+	r.Set("check", NewFunction("check", func(r *Runtime, _ Value, args []Value) (Value, error) {
+		return nil, r.Throw("RangeError", "value %s out of range", ToString(args[0]))
+	}))
+	src := `
+		let result;
+		try { check(7); } catch (e) { result = [e.name, e.message, e instanceof RangeError, e instanceof Error]; }`
+	if err := r.Run("throw.js", src); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := Inspect(r.Get("result")), `["RangeError","value 7 out of range",true,true]`; got != want {
+		t.Errorf("result = %s, want %s", got, want)
+	}
+
+	err := r.Run("uncaught.js", "let x = 1;\ncheck(x);")
+	var exc *Exception
+	if !errors.As(err, &exc) || exc.Line != 2 || !strings.Contains(err.Error(), "Uncaught RangeError: value 1 out of range") {
+		t.Errorf("Run() error = %v, want an uncaught RangeError at line 2", err)
+	}
+}
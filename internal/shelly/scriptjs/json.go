@@ -0,0 +1,231 @@
+package scriptjs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+func (r *Runtime) jsonObject() *Object {
+	o := NewObject()
+	setMethods(o, map[string]NativeFunc{
+		"stringify": func(r *Runtime, _ Value, args []Value) (Value, error) {
+			indent := ""
+			switch space := arg(args, 2).(type) {
+			case float64:
+				indent = strings.Repeat(" ", max(0, min(int(space), 10)))
+			case string:
+				indent = space
+			}
+			v, err := stringify(arg(args, 0), indent)
+			if err != nil {
+				return nil, r.throwf("TypeError", "%v", err)
+			}
+			return v, nil
+		},
+		"parse": func(r *Runtime, _ Value, args []Value) (Value, error) {
+			v, err := ParseJSON(ToString(arg(args, 0)))
+			if err != nil {
+				return nil, r.throwf("SyntaxError", "%v", err)
+			}
+			return v, nil
+		},
+	})
+	return o
+}
+
+// stringify encodes a value as JSON. It returns Undefined for values JSON
+// cannot represent, such as functions.
+func stringify(v Value, indent string) (Value, error) {
+	var b strings.Builder
+	ok, err := writeJSON(&b, v, indent, "", nil)
+	if err != nil || !ok {
+		return Undefined, err
+	}
+	return b.String(), nil
+}
+
+func writeJSON(b *strings.Builder, v Value, indent, prefix string, seen []Value) (bool, error) {
+	switch v := v.(type) {
+	case undefinedType, *Function:
+		return false, nil
+	case nil:
+		b.WriteString("null")
+	case bool, string:
+		b.WriteString(quoteJSON(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			b.WriteString("null")
+		} else {
+			b.WriteString(formatNumber(v))
+		}
+	case *Array, *Object:
+		for _, s := range seen {
+			if s == v {
+				return false, errors.New("converting circular structure to JSON")
+			}
+		}
+		return true, writeComposite(b, v, indent, prefix, append(seen, v))
+	}
+	return true, nil
+}
+
+func writeComposite(b *strings.Builder, v Value, indent, prefix string, seen []Value) error {
+	if t, ok := dateValue(v); ok {
+		b.WriteString(quoteJSON(t.UTC().Format("2006-01-02T15:04:05.000Z")))
+		return nil
+	}
+	open, closing := "{", "}"
+	arr, isArray := v.(*Array)
+	if isArray {
+		open, closing = "[", "]"
+	}
+	inner := prefix + indent
+	sep, colon := ",", ":"
+	if indent != "" {
+		sep, colon = ",\n"+inner, ": "
+	}
+
+	b.WriteString(open)
+	n := 0
+	write := func(key string, e Value) error {
+		mark := b.Len()
+		if n > 0 {
+			b.WriteString(sep)
+		} else if indent != "" {
+			b.WriteString("\n" + inner)
+		}
+		if !isArray {
+			b.WriteString(quoteJSON(key) + colon)
+		}
+		ok, err := writeJSON(b, e, indent, inner, seen)
+		switch {
+		case err != nil:
+			return err
+		case !ok && isArray:
+			b.WriteString("null")
+		case !ok:
+			// Skipped members leave no trace.
+			s := b.String()[:mark]
+			b.Reset()
+			b.WriteString(s)
+			return nil
+		}
+		n++
+		return nil
+	}
+
+	if isArray {
+		for _, e := range arr.elems {
+			if err := write("", e); err != nil {
+				return err
+			}
+		}
+	} else if o, ok := v.(*Object); ok {
+		for _, k := range o.keys {
+			if err := write(k, o.props[k]); err != nil {
+				return err
+			}
+		}
+	}
+	if n > 0 && indent != "" {
+		b.WriteString("\n" + prefix)
+	}
+	b.WriteString(closing)
+	return nil
+}
+
+func quoteJSON(v Value) string {
+	s, ok := v.(string)
+	if !ok {
+		return ToString(v)
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range s {
+		switch c {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if c < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, c)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// ParseJSON decodes JSON into script values, keeping the order of object
+// keys.
+func ParseJSON(s string) (Value, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	v, err := decodeJSON(dec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid JSON: unexpected data after value")
+	}
+	return v, nil
+}
+
+func decodeJSON(dec *json.Decoder) (Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return FromGo(tok), nil
+	}
+	if delim == '[' {
+		a := NewArray()
+		for dec.More() {
+			e, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			a.elems = append(a.elems, e)
+		}
+		_, err := dec.Token()
+		return a, err
+	}
+
+	o := NewObject()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected %v", tok)
+		}
+		v, err := decodeJSON(dec)
+		if err != nil {
+			return nil, err
+		}
+		o.Set(key, v)
+	}
+	_, err = dec.Token()
+	return o, err
+}
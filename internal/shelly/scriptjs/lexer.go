@@ -0,0 +1,332 @@
+// Package scriptjs reads and runs device scripts: the JavaScript subset the
+// Shelly script engine supports. It provides the lexer shared by the
+// bundler and an interpreter for running scripts offline.
+//
+// The interpreter implements that subset rather than embedding a general
+// engine such as goja: a script that uses classes, async functions,
+// destructuring or other constructs the device rejects must fail its
+// offline test too, instead of passing locally and failing on upload.
+package scriptjs
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the kind of a token.
+type Kind int
+
+// Token kinds.
+const (
+	Ident Kind = iota
+	Number
+	String
+	Template
+	Regex
+	Punct
+	Comment
+)
+
+// Token is a lexical token of a script. Space holds the whitespace that
+// preceded it in the source.
+type Token struct {
+	Kind  Kind
+	Text  string
+	Space string
+	Line  int
+	Col   int
+}
+
+// Is reports whether the token is of kind with the given text.
+func (t Token) Is(kind Kind, text string) bool {
+	return t.Kind == kind && t.Text == text
+}
+
+// punctuators, longest first.
+var punctuators = []string{
+	">>>=", "...", "===", "!==", "**=", "<<=", ">>=", ">>>", "&&=", "||=", "??=",
+	"=>", "==", "!=", "<=", ">=", "&&", "||", "??", "?.", "++", "--",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<", ">>", "**",
+}
+
+// regexKeywords may be directly followed by a regular expression literal.
+var regexKeywords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true, "new": true,
+	"delete": true, "void": true, "throw": true, "case": true, "do": true, "else": true,
+}
+
+type lexer struct {
+	src       string
+	pos       int
+	line, col int
+	toks      []Token
+}
+
+// Lex splits a script into tokens.
+func Lex(src string) ([]Token, error) {
+	l := &lexer{src: src, line: 1, col: 1}
+	for {
+		start := l.pos
+		for l.pos < len(l.src) && isSpace(l.peek()) {
+			l.advance(utf8.RuneLen(l.peek()))
+		}
+		space := l.src[start:l.pos]
+		if l.pos >= len(l.src) {
+			return l.toks, nil
+		}
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tok.Space = space
+		l.toks = append(l.toks, tok)
+	}
+}
+
+func (l *lexer) peek() rune {
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return r
+}
+
+func (l *lexer) advance(n int) {
+	for _, r := range l.src[l.pos : l.pos+n] {
+		if r == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+	}
+	l.pos += n
+}
+
+func (l *lexer) errorf(line, col int, format string, args ...any) error {
+	return &SyntaxError{Line: line, Col: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) next() (Token, error) {
+	tok := Token{Line: l.line, Col: l.col}
+	start := l.pos
+	rest := l.src[l.pos:]
+	r := l.peek()
+
+	switch {
+	case strings.HasPrefix(rest, "//"):
+		end := strings.IndexByte(rest, '\n')
+		if end < 0 {
+			end = len(rest)
+		}
+		l.advance(end)
+		tok.Kind = Comment
+	case strings.HasPrefix(rest, "/*"):
+		end := strings.Index(rest[2:], "*/")
+		if end < 0 {
+			return tok, l.errorf(tok.Line, tok.Col, "unterminated comment")
+		}
+		l.advance(end + 4)
+		tok.Kind = Comment
+	case r == '"' || r == '\'':
+		if err := l.scanString(byte(r)); err != nil {
+			return tok, err
+		}
+		tok.Kind = String
+	case r == '`':
+		if err := l.scanTemplate(); err != nil {
+			return tok, err
+		}
+		tok.Kind = Template
+	case isDigit(r) || (r == '.' && len(rest) > 1 && isDigit(rune(rest[1]))):
+		l.scanNumber()
+		tok.Kind = Number
+	case IsIdentStart(r):
+		for l.pos < len(l.src) && IsIdentPart(l.peek()) {
+			l.advance(utf8.RuneLen(l.peek()))
+		}
+		tok.Kind = Ident
+	case r == '/' && l.regexAllowed():
+		if err := l.scanRegex(); err != nil {
+			return tok, err
+		}
+		tok.Kind = Regex
+	default:
+		tok.Kind = Punct
+		n := utf8.RuneLen(r)
+		for _, p := range punctuators {
+			if strings.HasPrefix(rest, p) {
+				n = len(p)
+				break
+			}
+		}
+		l.advance(n)
+	}
+	tok.Text = l.src[start:l.pos]
+	return tok, nil
+}
+
+func (l *lexer) scanString(quote byte) error {
+	line, col := l.line, l.col
+	l.advance(1)
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case '\\':
+			l.advance(min(2, len(l.src)-l.pos))
+		case quote:
+			l.advance(1)
+			return nil
+		case '\n':
+			return l.errorf(line, col, "unterminated string")
+		default:
+			l.advance(1)
+		}
+	}
+	return l.errorf(line, col, "unterminated string")
+}
+
+// scanTemplate scans a template literal, including nested ${...}
+// expressions.
+func (l *lexer) scanTemplate() error {
+	line, col := l.line, l.col
+	l.advance(1)
+	for l.pos < len(l.src) {
+		switch {
+		case l.src[l.pos] == '\\':
+			l.advance(min(2, len(l.src)-l.pos))
+		case l.src[l.pos] == '`':
+			l.advance(1)
+			return nil
+		case strings.HasPrefix(l.src[l.pos:], "${"):
+			l.advance(2)
+			if err := l.scanTemplateExpr(); err != nil {
+				return err
+			}
+		default:
+			l.advance(1)
+		}
+	}
+	return l.errorf(line, col, "unterminated template literal")
+}
+
+func (l *lexer) scanTemplateExpr() error {
+	depth := 1
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case '{':
+			depth++
+			l.advance(1)
+		case '}':
+			depth--
+			l.advance(1)
+			if depth == 0 {
+				return nil
+			}
+		case '"', '\'':
+			if err := l.scanString(c); err != nil {
+				return err
+			}
+		case '`':
+			if err := l.scanTemplate(); err != nil {
+				return err
+			}
+		default:
+			l.advance(1)
+		}
+	}
+	return l.errorf(l.line, l.col, "unterminated template expression")
+}
+
+func (l *lexer) scanNumber() {
+	rest := strings.ToLower(l.src[l.pos:])
+	if len(rest) > 1 && rest[0] == '0' && strings.ContainsRune("xob", rune(rest[1])) {
+		l.advance(2)
+		for l.pos < len(l.src) && IsIdentPart(l.peek()) {
+			l.advance(1)
+		}
+		return
+	}
+	digits := func() {
+		for l.pos < len(l.src) && (isDigit(rune(l.src[l.pos])) || l.src[l.pos] == '_') {
+			l.advance(1)
+		}
+	}
+	digits()
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		l.advance(1)
+		digits()
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		l.advance(1)
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.advance(1)
+		}
+		digits()
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == 'n' {
+		l.advance(1)
+	}
+}
+
+func (l *lexer) scanRegex() error {
+	line, col := l.line, l.col
+	l.advance(1)
+	inClass := false
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == '\\':
+			l.advance(min(2, len(l.src)-l.pos))
+		case c == '\n':
+			return l.errorf(line, col, "unterminated regular expression")
+		case c == '[':
+			inClass = true
+			l.advance(1)
+		case c == ']':
+			inClass = false
+			l.advance(1)
+		case c == '/' && !inClass:
+			l.advance(1)
+			for l.pos < len(l.src) && IsIdentPart(l.peek()) {
+				l.advance(1)
+			}
+			return nil
+		default:
+			l.advance(1)
+		}
+	}
+	return l.errorf(line, col, "unterminated regular expression")
+}
+
+// regexAllowed reports whether a "/" at the current position starts a
+// regular expression rather than a division, based on the previous token.
+func (l *lexer) regexAllowed() bool {
+	for i := len(l.toks) - 1; i >= 0; i-- {
+		prev := l.toks[i]
+		switch prev.Kind {
+		case Comment:
+			continue
+		case Ident:
+			return regexKeywords[prev.Text]
+		case Punct:
+			return prev.Text != ")" && prev.Text != "]"
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' || r == '\v' || r == '\uFEFF' || r == '\u00A0'
+}
+
+func isDigit(r rune) bool { return r >= '0' && r <= '9' }
+
+// IsIdentStart reports whether r can start an identifier.
+func IsIdentStart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+// IsIdentPart reports whether r can be part of an identifier.
+func IsIdentPart(r rune) bool {
+	return IsIdentStart(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,65 @@
+package scriptjs
+
+import (
+	"strings"
+	"testing"
+)
+
+func texts(toks []Token) []string {
+	out := make([]string, 0, len(toks))
+	for _, t := range toks {
+		out = append(out, t.Text)
+	}
+	return out
+}
+
+func TestLex(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"punctuators", "a >>>= b === c ?. d", []string{"a", ">>>=", "b", "===", "c", "?.", "d"}},
+		{"strings", `print("a // b", 'c\'d')`, []string{"print", "(", `"a // b"`, ",", `'c\'d'`, ")"}},
+		{"template", "`a ${ {x: `b`}.x } c`;", []string{"`a ${ {x: `b`}.x } c`", ";"}},
+		{"regex", "let r = /a\\/[/]b/g; x = a / b / c", []string{"let", "r", "=", "/a\\/[/]b/g", ";", "x", "=", "a", "/", "b", "/", "c"}},
+		{"numbers", "1.5e-3 0xFF .5 10n", []string{"1.5e-3", "0xFF", ".5", "10n"}},
+		{"comments", "a // one\n/* two */ b", []string{"a", "// one", "/* two */", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			toks, err := Lex(tt.src)
+			if err != nil {
+				t.Fatalf("Lex() error = %v", err)
+			}
+			if got := texts(toks); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Lex() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLex_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"string":   "let a = 1;\nlet b = \"open",
+		"comment":  "/* never closed",
+		"template": "`${a",
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := Lex(src); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+	_, err := Lex("let a = 1;\nlet b = \"open")
+	if err == nil || !strings.Contains(err.Error(), "line 2 col 9") {
+		t.Errorf("error = %v, want position line 2 col 9", err)
+	}
+}
@@ -0,0 +1,578 @@
+package scriptjs
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type (
+	objectMethod   = func(*Runtime, *Object, []Value) (Value, error)
+	arrayMethod    = func(*Runtime, *Array, []Value) (Value, error)
+	stringMethod   = func(*Runtime, string, []Value) (Value, error)
+	numberMethod   = func(*Runtime, float64, []Value) (Value, error)
+	boolMethod     = func(*Runtime, bool, []Value) (Value, error)
+	functionMethod = func(*Runtime, *Function, []Value) (Value, error)
+)
+
+// The method tables are filled in init because array methods call back
+// into the runtime, which refers to the tables.
+var (
+	objectMethods   map[string]objectMethod
+	arrayMethods    map[string]arrayMethod
+	stringMethods   map[string]stringMethod
+	numberMethods   map[string]numberMethod
+	boolMethods     map[string]boolMethod
+	functionMethods map[string]functionMethod
+)
+
+func init() {
+	objectMethods = map[string]objectMethod{
+		"hasOwnProperty": func(_ *Runtime, o *Object, args []Value) (Value, error) {
+			_, ok := o.props[propertyKey(arg(args, 0))]
+			return ok, nil
+		},
+		"toString": func(_ *Runtime, o *Object, _ []Value) (Value, error) { return objectString(o), nil },
+		"valueOf":  func(_ *Runtime, o *Object, _ []Value) (Value, error) { return o, nil },
+	}
+	boolMethods = map[string]boolMethod{
+		"toString": func(_ *Runtime, b bool, _ []Value) (Value, error) { return strconv.FormatBool(b), nil },
+		"valueOf":  func(_ *Runtime, b bool, _ []Value) (Value, error) { return b, nil },
+	}
+	numberMethods = map[string]numberMethod{
+		"toFixed":  numberToFixed,
+		"toString": numberToString,
+		"valueOf":  func(_ *Runtime, f float64, _ []Value) (Value, error) { return f, nil },
+	}
+	functionMethods = map[string]functionMethod{
+		"call": func(r *Runtime, f *Function, args []Value) (Value, error) {
+			var rest []Value
+			if len(args) > 1 {
+				rest = args[1:]
+			}
+			return r.call(f, arg(args, 0), rest)
+		},
+		"apply": func(r *Runtime, f *Function, args []Value) (Value, error) {
+			var list []Value
+			if a, ok := arg(args, 1).(*Array); ok {
+				list = a.elems
+			}
+			return r.call(f, arg(args, 0), list)
+		},
+		"bind": func(_ *Runtime, f *Function, args []Value) (Value, error) {
+			var rest []Value
+			if len(args) > 1 {
+				rest = append(rest, args[1:]...)
+			}
+			return &Function{Object: Object{class: "Function"}, name: "bound " + f.name, bound: f, this: arg(args, 0), boundArgs: rest}, nil
+		},
+		"toString": func(_ *Runtime, f *Function, _ []Value) (Value, error) { return ToString(f), nil },
+	}
+	initStringMethods()
+	initArrayMethods()
+}
+
+func numberToFixed(r *Runtime, f float64, args []Value) (Value, error) {
+	digits := toInteger(arg(args, 0))
+	if digits < 0 || digits > 100 {
+		return nil, r.throwf("RangeError", "toFixed() digits argument must be between 0 and 100")
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) || math.Abs(f) >= 1e21 {
+		return formatNumber(f), nil
+	}
+	return strconv.FormatFloat(f, 'f', digits, 64), nil
+}
+
+func numberToString(r *Runtime, f float64, args []Value) (Value, error) {
+	radix := 10
+	if v := arg(args, 0); v != Undefined {
+		radix = toInteger(v)
+	}
+	if radix < 2 || radix > 36 {
+		return nil, r.throwf("RangeError", "toString() radix must be between 2 and 36")
+	}
+	if radix == 10 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return formatNumber(f), nil
+	}
+	return strconv.FormatInt(int64(f), radix), nil
+}
+
+// relIndex resolves a possibly negative index argument against length,
+// clamped to [0, length].
+func relIndex(v Value, length, def int) int {
+	if v == Undefined {
+		return def
+	}
+	n := toInteger(v)
+	if n < 0 {
+		n += length
+	}
+	return max(0, min(n, length))
+}
+
+// callback returns args[i] as a function.
+func (r *Runtime) callback(args []Value, i int) (*Function, error) {
+	f, ok := arg(args, i).(*Function)
+	if !ok {
+		return nil, r.throwf("TypeError", "%s is not a function", ToString(arg(args, i)))
+	}
+	return f, nil
+}
+
+func initStringMethods() {
+	stringMethods = map[string]stringMethod{
+		"charAt": func(_ *Runtime, s string, args []Value) (Value, error) {
+			runes := []rune(s)
+			if i := toInteger(arg(args, 0)); i >= 0 && i < len(runes) {
+				return string(runes[i]), nil
+			}
+			return "", nil
+		},
+		"charCodeAt": func(_ *Runtime, s string, args []Value) (Value, error) {
+			runes := []rune(s)
+			if i := toInteger(arg(args, 0)); i >= 0 && i < len(runes) {
+				return float64(runes[i]), nil
+			}
+			return math.NaN(), nil
+		},
+		"at": func(_ *Runtime, s string, args []Value) (Value, error) {
+			runes := []rune(s)
+			i := toInteger(arg(args, 0))
+			if i < 0 {
+				i += len(runes)
+			}
+			if i >= 0 && i < len(runes) {
+				return string(runes[i]), nil
+			}
+			return Undefined, nil
+		},
+		"indexOf": func(_ *Runtime, s string, args []Value) (Value, error) {
+			runes := []rune(s)
+			from := relIndex(arg(args, 1), len(runes), 0)
+			i := strings.Index(string(runes[from:]), ToString(arg(args, 0)))
+			if i < 0 {
+				return -1.0, nil
+			}
+			return float64(from + len([]rune(string(runes[from:])[:i]))), nil
+		},
+		"lastIndexOf": func(_ *Runtime, s string, args []Value) (Value, error) {
+			i := strings.LastIndex(s, ToString(arg(args, 0)))
+			if i < 0 {
+				return -1.0, nil
+			}
+			return float64(len([]rune(s[:i]))), nil
+		},
+		"includes": func(_ *Runtime, s string, args []Value) (Value, error) {
+			return strings.Contains(s, ToString(arg(args, 0))), nil
+		},
+		"startsWith": func(_ *Runtime, s string, args []Value) (Value, error) {
+			runes := []rune(s)
+			return strings.HasPrefix(string(runes[relIndex(arg(args, 1), len(runes), 0):]), ToString(arg(args, 0))), nil
+		},
+		"endsWith": func(_ *Runtime, s string, args []Value) (Value, error) {
+			runes := []rune(s)
+			return strings.HasSuffix(string(runes[:relIndex(arg(args, 1), len(runes), len(runes))]), ToString(arg(args, 0))), nil
+		},
+		"slice": func(_ *Runtime, s string, args []Value) (Value, error) {
+			runes := []rune(s)
+			start := relIndex(arg(args, 0), len(runes), 0)
+			end := relIndex(arg(args, 1), len(runes), len(runes))
+			if start >= end {
+				return "", nil
+			}
+			return string(runes[start:end]), nil
+		},
+		"substring": func(_ *Runtime, s string, args []Value) (Value, error) {
+			runes := []rune(s)
+			clamp := func(v Value, def int) int {
+				if v == Undefined {
+					return def
+				}
+				return max(0, min(toInteger(v), len(runes)))
+			}
+			start, end := clamp(arg(args, 0), 0), clamp(arg(args, 1), len(runes))
+			if start > end {
+				start, end = end, start
+			}
+			return string(runes[start:end]), nil
+		},
+		"substr": func(_ *Runtime, s string, args []Value) (Value, error) {
+			runes := []rune(s)
+			start := relIndex(arg(args, 0), len(runes), 0)
+			n := len(runes) - start
+			if v := arg(args, 1); v != Undefined {
+				n = max(0, min(toInteger(v), n))
+			}
+			return string(runes[start : start+n]), nil
+		},
+		"toUpperCase": func(_ *Runtime, s string, _ []Value) (Value, error) { return strings.ToUpper(s), nil },
+		"toLowerCase": func(_ *Runtime, s string, _ []Value) (Value, error) { return strings.ToLower(s), nil },
+		"trim":        func(_ *Runtime, s string, _ []Value) (Value, error) { return strings.TrimSpace(s), nil },
+		"trimStart": func(_ *Runtime, s string, _ []Value) (Value, error) {
+			return strings.TrimLeftFunc(s, unicode.IsSpace), nil
+		},
+		"trimEnd": func(_ *Runtime, s string, _ []Value) (Value, error) {
+			return strings.TrimRightFunc(s, unicode.IsSpace), nil
+		},
+		"padStart": func(_ *Runtime, s string, args []Value) (Value, error) { return pad(s, args, true), nil },
+		"padEnd":   func(_ *Runtime, s string, args []Value) (Value, error) { return pad(s, args, false), nil },
+		"repeat": func(r *Runtime, s string, args []Value) (Value, error) {
+			n := toInteger(arg(args, 0))
+			if n < 0 {
+				return nil, r.throwf("RangeError", "Invalid count value: %d", n)
+			}
+			return strings.Repeat(s, n), nil
+		},
+		"concat": func(_ *Runtime, s string, args []Value) (Value, error) {
+			for _, a := range args {
+				s += ToString(a)
+			}
+			return s, nil
+		},
+		"split":      stringSplit,
+		"replace":    func(r *Runtime, s string, args []Value) (Value, error) { return r.replace(s, args, false) },
+		"replaceAll": func(r *Runtime, s string, args []Value) (Value, error) { return r.replace(s, args, true) },
+		"match":      stringMatch,
+		"search":     stringSearch,
+		"toString":   func(_ *Runtime, s string, _ []Value) (Value, error) { return s, nil },
+		"valueOf":    func(_ *Runtime, s string, _ []Value) (Value, error) { return s, nil },
+	}
+}
+
+func pad(s string, args []Value, start bool) string {
+	width := toInteger(arg(args, 0))
+	fill := " "
+	if v := arg(args, 1); v != Undefined {
+		fill = ToString(v)
+	}
+	n := width - len([]rune(s))
+	if n <= 0 || fill == "" {
+		return s
+	}
+	padding := []rune(strings.Repeat(fill, n/len([]rune(fill))+1))[:n]
+	if start {
+		return string(padding) + s
+	}
+	return s + string(padding)
+}
+
+func stringSplit(r *Runtime, s string, args []Value) (Value, error) {
+	sep := arg(args, 0)
+	limit := -1
+	if v := arg(args, 1); v != Undefined {
+		limit = int(toUint32(v))
+	}
+	var parts []string
+	switch sep := sep.(type) {
+	case undefinedType:
+		parts = []string{s}
+	case *Object:
+		re, err := r.regexpOf(sep)
+		if err != nil {
+			return nil, err
+		}
+		parts = re.re.Split(s, -1)
+	default:
+		str := ToString(sep)
+		if str == "" {
+			for _, c := range s {
+				parts = append(parts, string(c))
+			}
+		} else {
+			parts = strings.Split(s, str)
+		}
+	}
+	if limit >= 0 && limit < len(parts) {
+		parts = parts[:limit]
+	}
+	out := make([]Value, len(parts))
+	for i, p := range parts {
+		out[i] = p
+	}
+	return NewArray(out...), nil
+}
+
+func initArrayMethods() {
+	arrayMethods = map[string]arrayMethod{
+		"push": func(_ *Runtime, a *Array, args []Value) (Value, error) {
+			a.elems = append(a.elems, args...)
+			return float64(len(a.elems)), nil
+		},
+		"pop": func(_ *Runtime, a *Array, _ []Value) (Value, error) {
+			if len(a.elems) == 0 {
+				return Undefined, nil
+			}
+			v := a.elems[len(a.elems)-1]
+			a.elems = a.elems[:len(a.elems)-1]
+			return v, nil
+		},
+		"shift": func(_ *Runtime, a *Array, _ []Value) (Value, error) {
+			if len(a.elems) == 0 {
+				return Undefined, nil
+			}
+			v := a.elems[0]
+			a.elems = append([]Value(nil), a.elems[1:]...)
+			return v, nil
+		},
+		"unshift": func(_ *Runtime, a *Array, args []Value) (Value, error) {
+			a.elems = append(append([]Value(nil), args...), a.elems...)
+			return float64(len(a.elems)), nil
+		},
+		"slice": func(_ *Runtime, a *Array, args []Value) (Value, error) {
+			start := relIndex(arg(args, 0), len(a.elems), 0)
+			end := relIndex(arg(args, 1), len(a.elems), len(a.elems))
+			if start >= end {
+				return NewArray(), nil
+			}
+			return NewArray(append([]Value(nil), a.elems[start:end]...)...), nil
+		},
+		"splice": arraySplice,
+		"concat": func(_ *Runtime, a *Array, args []Value) (Value, error) {
+			out := append([]Value(nil), a.elems...)
+			for _, v := range args {
+				if other, ok := v.(*Array); ok {
+					out = append(out, other.elems...)
+				} else {
+					out = append(out, v)
+				}
+			}
+			return NewArray(out...), nil
+		},
+		"join": func(_ *Runtime, a *Array, args []Value) (Value, error) {
+			sep := ","
+			if v := arg(args, 0); v != Undefined {
+				sep = ToString(v)
+			}
+			parts := make([]string, len(a.elems))
+			for i, e := range a.elems {
+				if !isNullish(e) {
+					parts[i] = ToString(e)
+				}
+			}
+			return strings.Join(parts, sep), nil
+		},
+		"indexOf": func(_ *Runtime, a *Array, args []Value) (Value, error) {
+			for i := relIndex(arg(args, 1), len(a.elems), 0); i < len(a.elems); i++ {
+				if strictEquals(a.elems[i], arg(args, 0)) {
+					return float64(i), nil
+				}
+			}
+			return -1.0, nil
+		},
+		"lastIndexOf": func(_ *Runtime, a *Array, args []Value) (Value, error) {
+			for i := len(a.elems) - 1; i >= 0; i-- {
+				if strictEquals(a.elems[i], arg(args, 0)) {
+					return float64(i), nil
+				}
+			}
+			return -1.0, nil
+		},
+		"includes": func(_ *Runtime, a *Array, args []Value) (Value, error) {
+			want := arg(args, 0)
+			for _, e := range a.elems {
+				if strictEquals(e, want) || isNaNValue(e) && isNaNValue(want) {
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+		"at": func(_ *Runtime, a *Array, args []Value) (Value, error) {
+			i := toInteger(arg(args, 0))
+			if i < 0 {
+				i += len(a.elems)
+			}
+			if i >= 0 && i < len(a.elems) {
+				return a.elems[i], nil
+			}
+			return Undefined, nil
+		},
+		"reverse": func(_ *Runtime, a *Array, _ []Value) (Value, error) {
+			for i, j := 0, len(a.elems)-1; i < j; i, j = i+1, j-1 {
+				a.elems[i], a.elems[j] = a.elems[j], a.elems[i]
+			}
+			return a, nil
+		},
+		"fill": func(_ *Runtime, a *Array, args []Value) (Value, error) {
+			start := relIndex(arg(args, 1), len(a.elems), 0)
+			end := relIndex(arg(args, 2), len(a.elems), len(a.elems))
+			for i := start; i < end; i++ {
+				a.elems[i] = arg(args, 0)
+			}
+			return a, nil
+		},
+		"flat": func(_ *Runtime, a *Array, _ []Value) (Value, error) {
+			var out []Value
+			for _, e := range a.elems {
+				if inner, ok := e.(*Array); ok {
+					out = append(out, inner.elems...)
+				} else {
+					out = append(out, e)
+				}
+			}
+			return NewArray(out...), nil
+		},
+		"toString": func(_ *Runtime, a *Array, _ []Value) (Value, error) { return ToString(a), nil },
+		"sort":     arraySort,
+		"forEach":  iterator(func(Value, Value, []Value) (bool, Value) { return false, nil }, Undefined),
+		"map":      arrayMap,
+		"filter":   arrayFilter,
+		"find": iterator(func(e, res Value, _ []Value) (bool, Value) {
+			return toBool(res), e
+		}, Undefined),
+		"findIndex": arrayFindIndex,
+		"some": iterator(func(_, res Value, _ []Value) (bool, Value) {
+			return toBool(res), true
+		}, false),
+		"every": iterator(func(_, res Value, _ []Value) (bool, Value) {
+			return !toBool(res), false
+		}, true),
+		"reduce": arrayReduce,
+	}
+}
+
+func isNaNValue(v Value) bool {
+	f, ok := v.(float64)
+	return ok && math.IsNaN(f)
+}
+
+// iterator builds a method that calls its callback for each element until
+// stop reports true, returning the value stop gives or def.
+func iterator(stop func(elem, result Value, args []Value) (bool, Value), def Value) arrayMethod {
+	return func(r *Runtime, a *Array, args []Value) (Value, error) {
+		fn, err := r.callback(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(a.elems); i++ {
+			e := a.elems[i]
+			res, err := r.call(fn, arg(args, 1), []Value{e, float64(i), a})
+			if err != nil {
+				return nil, err
+			}
+			if done, v := stop(e, res, args); done {
+				return v, nil
+			}
+		}
+		return def, nil
+	}
+}
+
+func arrayFindIndex(r *Runtime, a *Array, args []Value) (Value, error) {
+	fn, err := r.callback(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range a.elems {
+		res, err := r.call(fn, arg(args, 1), []Value{e, float64(i), a})
+		if err != nil {
+			return nil, err
+		}
+		if toBool(res) {
+			return float64(i), nil
+		}
+	}
+	return -1.0, nil
+}
+
+func arrayMap(r *Runtime, a *Array, args []Value) (Value, error) {
+	fn, err := r.callback(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Value, len(a.elems))
+	for i, e := range a.elems {
+		if out[i], err = r.call(fn, arg(args, 1), []Value{e, float64(i), a}); err != nil {
+			return nil, err
+		}
+	}
+	return NewArray(out...), nil
+}
+
+func arrayFilter(r *Runtime, a *Array, args []Value) (Value, error) {
+	fn, err := r.callback(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	out := []Value{}
+	for i, e := range a.elems {
+		keep, err := r.call(fn, arg(args, 1), []Value{e, float64(i), a})
+		if err != nil {
+			return nil, err
+		}
+		if toBool(keep) {
+			out = append(out, e)
+		}
+	}
+	return NewArray(out...), nil
+}
+
+func arrayReduce(r *Runtime, a *Array, args []Value) (Value, error) {
+	fn, err := r.callback(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	i := 0
+	var acc Value
+	switch {
+	case len(args) > 1:
+		acc = args[1]
+	case len(a.elems) > 0:
+		acc, i = a.elems[0], 1
+	default:
+		return nil, r.throwf("TypeError", "Reduce of empty array with no initial value")
+	}
+	for ; i < len(a.elems); i++ {
+		if acc, err = r.call(fn, Undefined, []Value{acc, a.elems[i], float64(i), a}); err != nil {
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
+func arraySplice(_ *Runtime, a *Array, args []Value) (Value, error) {
+	start := relIndex(arg(args, 0), len(a.elems), 0)
+	count := len(a.elems) - start
+	if len(args) > 1 {
+		count = max(0, min(toInteger(args[1]), count))
+	}
+	var items []Value
+	if len(args) > 2 {
+		items = args[2:]
+	}
+	removed := append([]Value(nil), a.elems[start:start+count]...)
+	rest := append(append([]Value(nil), items...), a.elems[start+count:]...)
+	a.elems = append(a.elems[:start], rest...)
+	return NewArray(removed...), nil
+}
+
+func arraySort(r *Runtime, a *Array, args []Value) (Value, error) {
+	var cmp *Function
+	if v := arg(args, 0); v != Undefined {
+		var err error
+		if cmp, err = r.callback(args, 0); err != nil {
+			return nil, err
+		}
+	}
+	var sortErr error
+	sort.SliceStable(a.elems, func(i, j int) bool {
+		x, y := a.elems[i], a.elems[j]
+		if sortErr != nil || x == Undefined || y == Undefined {
+			return y == Undefined && x != Undefined
+		}
+		if cmp == nil {
+			return ToString(x) < ToString(y)
+		}
+		res, err := r.call(cmp, Undefined, []Value{x, y})
+		if err != nil {
+			sortErr = err
+			return false
+		}
+		return toNumber(res) < 0
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+	return a, nil
+}
@@ -0,0 +1,245 @@
+package scriptjs
+
+import (
+	"math"
+	"unicode/utf8"
+)
+
+var arithmetic = map[string]func(a, b float64) float64{
+	"-": func(a, b float64) float64 { return a - b },
+	"*": func(a, b float64) float64 { return a * b },
+	"/": func(a, b float64) float64 { return a / b },
+	"%": func(a, b float64) float64 {
+		if b == 0 || math.IsInf(a, 0) {
+			return math.NaN()
+		}
+		if math.IsInf(b, 0) {
+			return a
+		}
+		return math.Mod(a, b)
+	},
+	"**": math.Pow,
+}
+
+var bitwise = map[string]func(a, b Value) float64{
+	"&":   func(a, b Value) float64 { return float64(toInt32(a) & toInt32(b)) },
+	"|":   func(a, b Value) float64 { return float64(toInt32(a) | toInt32(b)) },
+	"^":   func(a, b Value) float64 { return float64(toInt32(a) ^ toInt32(b)) },
+	"<<":  func(a, b Value) float64 { return float64(toInt32(a) << (toUint32(b) & 31)) },
+	">>":  func(a, b Value) float64 { return float64(toInt32(a) >> (toUint32(b) & 31)) },
+	">>>": func(a, b Value) float64 { return float64(toUint32(a) >> (toUint32(b) & 31)) },
+}
+
+func (r *Runtime) binaryOp(op string, a, b Value) (Value, error) {
+	switch op {
+	case "+":
+		a, b = toPrimitive(a), toPrimitive(b)
+		_, as := a.(string)
+		_, bs := b.(string)
+		if as || bs {
+			return ToString(a) + ToString(b), nil
+		}
+		return toNumber(a) + toNumber(b), nil
+	case "==":
+		return looseEquals(a, b), nil
+	case "!=":
+		return !looseEquals(a, b), nil
+	case "===":
+		return strictEquals(a, b), nil
+	case "!==":
+		return !strictEquals(a, b), nil
+	case "<", ">", "<=", ">=":
+		return compare(op, a, b), nil
+	case "in":
+		return r.hasProperty(b, propertyKey(a))
+	case "instanceof":
+		return r.instanceOf(a, b)
+	}
+	if fn, ok := arithmetic[op]; ok {
+		return fn(toNumber(a), toNumber(b)), nil
+	}
+	if fn, ok := bitwise[op]; ok {
+		return fn(a, b), nil
+	}
+	return nil, r.throwf("SyntaxError", "unsupported operator %s", op)
+}
+
+func compare(op string, a, b Value) bool {
+	a, b = toPrimitive(a), toPrimitive(b)
+	sa, aok := a.(string)
+	sb, bok := b.(string)
+	var less, equal bool
+	if aok && bok {
+		less, equal = sa < sb, sa == sb
+	} else {
+		na, nb := toNumber(a), toNumber(b)
+		if math.IsNaN(na) || math.IsNaN(nb) {
+			return false
+		}
+		less, equal = na < nb, na == nb
+	}
+	switch op {
+	case "<":
+		return less
+	case "<=":
+		return less || equal
+	case ">":
+		return !less && !equal
+	}
+	return !less
+}
+
+func (r *Runtime) hasProperty(obj Value, key string) (Value, error) {
+	switch o := obj.(type) {
+	case *Object:
+		_, ok := o.lookup(key)
+		return ok, nil
+	case *Function:
+		_, ok := o.props[key]
+		return ok, nil
+	case *Array:
+		i, ok := arrayIndex(key)
+		return key == "length" || ok && i < len(o.elems), nil
+	}
+	return nil, r.throwf("TypeError", "Cannot use 'in' operator to search for '%s' in %s", key, ToString(obj))
+}
+
+func (r *Runtime) instanceOf(v, ctor Value) (Value, error) {
+	f, ok := ctor.(*Function)
+	if !ok {
+		return nil, r.throwf("TypeError", "Right-hand side of 'instanceof' is not callable")
+	}
+	switch f {
+	case r.array:
+		_, ok := v.(*Array)
+		return ok, nil
+	case r.object:
+		return isObject(v), nil
+	}
+	o, ok := v.(*Object)
+	if !ok {
+		return false, nil
+	}
+	proto, ok := f.props["prototype"].(*Object)
+	if !ok {
+		return false, nil
+	}
+	for p := o.proto; p != nil; p = p.proto {
+		if p == proto {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getMember reads a property of any value, including the methods of
+// strings, numbers, arrays and functions.
+func (r *Runtime) getMember(obj Value, key string) (Value, error) {
+	switch o := obj.(type) {
+	case undefinedType, nil:
+		return nil, r.throwf("TypeError", "Cannot read properties of %s (reading '%s')", ToString(obj), key)
+	case *Object:
+		if v, ok := o.lookup(key); ok {
+			return v, nil
+		}
+		return boundMethod(r, objectMethods, key, o), nil
+	case *Array:
+		if i, ok := arrayIndex(key); ok {
+			if i < len(o.elems) {
+				return o.elems[i], nil
+			}
+			return Undefined, nil
+		}
+		if key == "length" {
+			return float64(len(o.elems)), nil
+		}
+		return boundMethod(r, arrayMethods, key, o), nil
+	case string:
+		if i, ok := arrayIndex(key); ok {
+			if runes := []rune(o); i < len(runes) {
+				return string(runes[i]), nil
+			}
+			return Undefined, nil
+		}
+		if key == "length" {
+			return float64(utf8.RuneCountInString(o)), nil
+		}
+		return boundMethod(r, stringMethods, key, o), nil
+	case float64:
+		return boundMethod(r, numberMethods, key, o), nil
+	case bool:
+		return boundMethod(r, boolMethods, key, o), nil
+	case *Function:
+		return r.functionMember(o, key), nil
+	}
+	return Undefined, nil
+}
+
+// boundMethod returns the method key of a built-in type, bound to recv.
+func boundMethod[T any](_ *Runtime, table map[string]func(*Runtime, T, []Value) (Value, error), key string, recv T) Value {
+	fn, ok := table[key]
+	if !ok {
+		return Undefined
+	}
+	return NewFunction(key, func(r *Runtime, _ Value, args []Value) (Value, error) {
+		return fn(r, recv, args)
+	})
+}
+
+func (r *Runtime) functionMember(f *Function, key string) Value {
+	if v, ok := f.props[key]; ok {
+		return v
+	}
+	switch key {
+	case "prototype":
+		if f.node != nil && !f.node.arrow {
+			return r.prototypeOf(f)
+		}
+	case "name":
+		return f.name
+	case "length":
+		if f.node != nil {
+			return float64(len(f.node.params))
+		}
+		return 0.0
+	}
+	return boundMethod(r, functionMethods, key, f)
+}
+
+func (r *Runtime) setMember(obj Value, key string, v Value) error {
+	switch o := obj.(type) {
+	case undefinedType, nil:
+		return r.throwf("TypeError", "Cannot set properties of %s (setting '%s')", ToString(obj), key)
+	case *Object:
+		o.Set(key, v)
+	case *Function:
+		o.Set(key, v)
+	case *Array:
+		if i, ok := arrayIndex(key); ok {
+			for len(o.elems) <= i {
+				o.elems = append(o.elems, Undefined)
+			}
+			o.elems[i] = v
+			return nil
+		}
+		if key == "length" {
+			return r.setLength(o, v)
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) setLength(a *Array, v Value) error {
+	n := toNumber(v)
+	if n < 0 || n != math.Trunc(n) || n > math.MaxInt32 {
+		return r.throwf("RangeError", "Invalid array length")
+	}
+	size := int(n)
+	if size < len(a.elems) {
+		a.elems = a.elems[:size]
+	}
+	for len(a.elems) < size {
+		a.elems = append(a.elems, Undefined)
+	}
+	return nil
+}
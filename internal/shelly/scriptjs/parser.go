@@ -0,0 +1,1320 @@
+package scriptjs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SyntaxError is a script that cannot be parsed.
+type SyntaxError struct {
+	File string
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("line %d col %d: %s", e.Line, e.Col, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Col, e.Msg)
+}
+
+// binaryPrec holds the precedence of binary operators; higher binds
+// tighter.
+var binaryPrec = map[string]int{
+	"??": 1, "||": 2, "&&": 3, "|": 4, "^": 5, "&": 6,
+	"==": 7, "!=": 7, "===": 7, "!==": 7,
+	"<": 8, ">": 8, "<=": 8, ">=": 8, "instanceof": 8, "in": 8,
+	"<<": 9, ">>": 9, ">>>": 9,
+	"+": 10, "-": 10,
+	"*": 11, "/": 11, "%": 11,
+	"**": 12,
+}
+
+var assignOps = setOf("=", "+=", "-=", "*=", "/=", "%=", "**=", "<<=", ">>=", ">>>=", "&=", "|=", "^=", "&&=", "||=", "??=")
+
+// unsupported are keywords of constructs the device engine does not run.
+var unsupported = setOf("class", "import", "export", "async", "await", "yield", "with", "debugger")
+
+func setOf(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+type parser struct {
+	name string
+	toks []Token
+	nl   []bool // a line break precedes the token
+	i    int
+	vars *[]string
+}
+
+// Parse parses a script. name is used in error positions.
+func Parse(name, src string) (*Program, error) {
+	toks, err := Lex(src)
+	if err != nil {
+		var se *SyntaxError
+		if errors.As(err, &se) {
+			se.File = name
+		}
+		return nil, err
+	}
+
+	p := &parser{name: name}
+	nl := false
+	for _, t := range toks {
+		nl = nl || strings.Contains(t.Space, "\n")
+		if t.Kind == Comment {
+			nl = nl || strings.Contains(t.Text, "\n")
+			continue
+		}
+		p.toks = append(p.toks, t)
+		p.nl = append(p.nl, nl)
+		nl = false
+	}
+
+	prog := &Program{name: name}
+	p.vars = &prog.vars
+	for !p.eof() {
+		st, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		prog.body = append(prog.body, st)
+	}
+	return prog, nil
+}
+
+func (p *parser) eof() bool { return p.i >= len(p.toks) }
+
+func (p *parser) peek() Token {
+	return p.peekAt(0)
+}
+
+func (p *parser) peekAt(n int) Token {
+	if p.i+n < len(p.toks) {
+		return p.toks[p.i+n]
+	}
+	if len(p.toks) == 0 {
+		return Token{Kind: Punct, Line: 1, Col: 1}
+	}
+	last := p.toks[len(p.toks)-1]
+	return Token{Kind: Punct, Line: last.Line, Col: last.Col + len(last.Text)}
+}
+
+func (p *parser) next() Token {
+	t := p.peek()
+	p.i++
+	return t
+}
+
+// at reports whether the next token is the punctuator or keyword text.
+func (p *parser) at(text string) bool {
+	t := p.peek()
+	return !p.eof() && (t.Kind == Punct || t.Kind == Ident) && t.Text == text
+}
+
+// atDecl reports whether a let, const or var declaration starts here. A
+// declaration that destructures is included so varDecl can reject it.
+func (p *parser) atDecl() bool {
+	if !p.at("let") && !p.at("const") && !p.at("var") {
+		return false
+	}
+	next := p.peekAt(1)
+	return next.Kind == Ident || next.Is(Punct, "{") || next.Is(Punct, "[")
+}
+
+func (p *parser) accept(text string) bool {
+	if p.at(text) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *parser) newline() bool { return !p.eof() && p.nl[p.i] }
+
+func (p *parser) errorf(t Token, format string, args ...any) error {
+	return &SyntaxError{File: p.name, Line: t.Line, Col: t.Col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	if p.eof() {
+		return p.errorf(t, "unexpected end of script")
+	}
+	return p.errorf(t, "unexpected token %q", t.Text)
+}
+
+// semicolon ends a statement, inserting the semicolon where the grammar
+// allows it.
+func (p *parser) semicolon() error {
+	if p.accept(";") || p.eof() || p.at("}") || p.newline() {
+		return nil
+	}
+	return p.unexpected()
+}
+
+func posOf(t Token) position { return position{t.Line, t.Col} }
+
+func (p *parser) identName() (string, error) {
+	t := p.peek()
+	if t.Kind != Ident || p.eof() {
+		return "", p.unexpected()
+	}
+	if unsupported[t.Text] {
+		return "", p.errorf(t, "%s is not supported", t.Text)
+	}
+	p.i++
+	return t.Text, nil
+}
+
+// Statements.
+
+func (p *parser) statement() (stmt, error) {
+	t := p.peek()
+	pos := posOf(t)
+	switch {
+	case t.Is(Punct, "{"):
+		return p.block()
+	case t.Is(Punct, ";"):
+		p.i++
+		return &emptyStmt{pos}, nil
+	case t.Kind != Ident:
+		return p.expressionStatement()
+	case unsupported[t.Text]:
+		return nil, p.errorf(t, "%s is not supported", t.Text)
+	case p.atDecl():
+		d, err := p.varDecl(false)
+		if err != nil {
+			return nil, err
+		}
+		return d, p.semicolon()
+	case t.Text == "function":
+		p.i++
+		fn, err := p.function(pos, true)
+		if err != nil {
+			return nil, err
+		}
+		return &funcDecl{pos, fn}, nil
+	case p.peekAt(1).Is(Punct, ":"):
+		return nil, p.errorf(t, "labels are not supported")
+	}
+	return p.keywordStatement(t)
+}
+
+func (p *parser) keywordStatement(t Token) (stmt, error) {
+	switch t.Text {
+	case "if":
+		return p.ifStatement()
+	case "for":
+		return p.forStatement()
+	case "while", "do":
+		return p.whileStatement()
+	case "return":
+		return p.returnStatement()
+	case "break", "continue":
+		p.i++
+		if !p.eof() && !p.newline() && p.peek().Kind == Ident {
+			return nil, p.errorf(p.peek(), "labels are not supported")
+		}
+		return &breakStmt{posOf(t), t.Text == "continue"}, p.semicolon()
+	case "throw":
+		p.i++
+		if p.newline() {
+			return nil, p.errorf(t, "line break after throw")
+		}
+		x, err := p.expression(false)
+		if err != nil {
+			return nil, err
+		}
+		return &throwStmt{posOf(t), x}, p.semicolon()
+	case "try":
+		return p.tryStatement()
+	case "switch":
+		return p.switchStatement()
+	}
+	return p.expressionStatement()
+}
+
+func (p *parser) expressionStatement() (stmt, error) {
+	pos := posOf(p.peek())
+	x, err := p.expression(false)
+	if err != nil {
+		return nil, err
+	}
+	return &exprStmt{pos, x}, p.semicolon()
+}
+
+func (p *parser) block() (*blockStmt, error) {
+	pos := posOf(p.peek())
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	b := &blockStmt{position: pos}
+	for !p.at("}") {
+		if p.eof() {
+			return nil, p.unexpected()
+		}
+		st, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		b.body = append(b.body, st)
+	}
+	p.i++
+	return b, nil
+}
+
+// varDecl parses a let, const or var declaration. In for loop heads noIn
+// stops expressions at "in".
+func (p *parser) varDecl(noIn bool) (*varDecl, error) {
+	t := p.next()
+	d := &varDecl{position: posOf(t), kind: t.Text}
+	for {
+		if p.at("{") || p.at("[") {
+			return nil, p.errorf(p.peek(), "destructuring is not supported")
+		}
+		name, err := p.identName()
+		if err != nil {
+			return nil, err
+		}
+		if d.kind == "var" {
+			*p.vars = append(*p.vars, name)
+		}
+		dec := declarator{name: name}
+		if p.accept("=") {
+			if dec.init, err = p.assignment(noIn); err != nil {
+				return nil, err
+			}
+		} else if d.kind == "const" && !p.at("of") && !p.at("in") {
+			return nil, p.errorf(p.peek(), "missing initializer in const declaration")
+		}
+		d.decls = append(d.decls, dec)
+		if !p.accept(",") {
+			return d, nil
+		}
+	}
+}
+
+func (p *parser) parenExpr() (expr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	x, err := p.expression(false)
+	if err != nil {
+		return nil, err
+	}
+	return x, p.expect(")")
+}
+
+func (p *parser) ifStatement() (stmt, error) {
+	s := &ifStmt{position: posOf(p.next())}
+	var err error
+	if s.test, err = p.parenExpr(); err != nil {
+		return nil, err
+	}
+	if s.then, err = p.statement(); err != nil {
+		return nil, err
+	}
+	if p.accept("else") {
+		if s.els, err = p.statement(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) whileStatement() (stmt, error) {
+	t := p.next()
+	s := &whileStmt{position: posOf(t), do: t.Text == "do"}
+	var err error
+	if s.do {
+		if s.body, err = p.statement(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("while"); err != nil {
+			return nil, err
+		}
+		if s.test, err = p.parenExpr(); err != nil {
+			return nil, err
+		}
+		p.accept(";")
+		return s, nil
+	}
+	if s.test, err = p.parenExpr(); err != nil {
+		return nil, err
+	}
+	if s.body, err = p.statement(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (p *parser) forStatement() (stmt, error) {
+	pos := posOf(p.next())
+	if p.at("await") {
+		return nil, p.errorf(p.peek(), "await is not supported")
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var init stmt
+	switch {
+	case p.at(";"):
+	case p.atDecl():
+		d, err := p.varDecl(true)
+		if err != nil {
+			return nil, err
+		}
+		if (p.at("of") || p.at("in")) && len(d.decls) == 1 && d.decls[0].init == nil {
+			return p.forIn(pos, &forInStmt{position: pos, kind: d.kind, name: d.decls[0].name})
+		}
+		init = d
+	default:
+		initPos := posOf(p.peek())
+		x, err := p.expression(true)
+		if err != nil {
+			return nil, err
+		}
+		if p.at("of") || p.at("in") {
+			if !assignable(x) {
+				return nil, p.errorf(p.peek(), "invalid for loop target")
+			}
+			return p.forIn(pos, &forInStmt{position: pos, target: x})
+		}
+		init = &exprStmt{initPos, x}
+	}
+
+	s := &forStmt{position: pos, init: init}
+	var err error
+	if err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	if !p.at(";") {
+		if s.test, err = p.expression(false); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	if !p.at(")") {
+		if s.update, err = p.expression(false); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if s.body, err = p.statement(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (p *parser) forIn(_ position, s *forInStmt) (stmt, error) {
+	s.of = p.next().Text == "of"
+	var err error
+	if s.obj, err = p.expression(false); err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if s.body, err = p.statement(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (p *parser) returnStatement() (stmt, error) {
+	s := &returnStmt{position: posOf(p.next())}
+	if p.eof() || p.at(";") || p.at("}") || p.newline() {
+		return s, p.semicolon()
+	}
+	var err error
+	if s.x, err = p.expression(false); err != nil {
+		return nil, err
+	}
+	return s, p.semicolon()
+}
+
+func (p *parser) tryStatement() (stmt, error) {
+	s := &tryStmt{position: posOf(p.next())}
+	var err error
+	if s.block, err = p.block(); err != nil {
+		return nil, err
+	}
+	if p.accept("catch") {
+		if p.accept("(") {
+			if s.param, err = p.identName(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		if s.handler, err = p.block(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("finally") {
+		if s.finalizer, err = p.block(); err != nil {
+			return nil, err
+		}
+	}
+	if s.handler == nil && s.finalizer == nil {
+		return nil, p.errorf(p.peek(), "missing catch or finally after try")
+	}
+	return s, nil
+}
+
+func (p *parser) switchStatement() (stmt, error) {
+	s := &switchStmt{position: posOf(p.next())}
+	var err error
+	if s.disc, err = p.parenExpr(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.accept("}") {
+		var c switchCase
+		switch {
+		case p.accept("case"):
+			if c.test, err = p.expression(false); err != nil {
+				return nil, err
+			}
+		case p.accept("default"):
+		default:
+			return nil, p.unexpected()
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		for !p.at("case") && !p.at("default") && !p.at("}") {
+			if p.eof() {
+				return nil, p.unexpected()
+			}
+			st, err := p.statement()
+			if err != nil {
+				return nil, err
+			}
+			c.body = append(c.body, st)
+		}
+		s.cases = append(s.cases, c)
+	}
+	return s, nil
+}
+
+// Functions.
+
+// function parses a function after its keyword. Declarations need a name.
+func (p *parser) function(pos position, decl bool) (*funcNode, error) {
+	if p.at("*") {
+		return nil, p.errorf(p.peek(), "generators are not supported")
+	}
+	fn := &funcNode{position: pos}
+	if p.peek().Kind == Ident && !p.eof() {
+		name, err := p.identName()
+		if err != nil {
+			return nil, err
+		}
+		fn.name = name
+	} else if decl {
+		return nil, p.unexpected()
+	}
+	var err error
+	if fn.params, err = p.params(); err != nil {
+		return nil, err
+	}
+	return fn, p.functionBody(fn)
+}
+
+func (p *parser) params() ([]param, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var params []param
+	for !p.accept(")") {
+		if p.at("{") || p.at("[") {
+			return nil, p.errorf(p.peek(), "destructuring is not supported")
+		}
+		var prm param
+		prm.rest = p.accept("...")
+		name, err := p.identName()
+		if err != nil {
+			return nil, err
+		}
+		prm.name = name
+		if p.accept("=") {
+			if prm.def, err = p.assignment(false); err != nil {
+				return nil, err
+			}
+		}
+		params = append(params, prm)
+		if !p.at(")") {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return params, nil
+}
+
+// functionBody parses a block body, collecting its var declarations.
+func (p *parser) functionBody(fn *funcNode) error {
+	outer := p.vars
+	p.vars = &fn.vars
+	defer func() { p.vars = outer }()
+
+	b, err := p.block()
+	if err != nil {
+		return err
+	}
+	fn.body = b.body
+	return nil
+}
+
+// arrowAhead reports whether an arrow function starts at the next token.
+func (p *parser) arrowAhead() bool {
+	t := p.peek()
+	if t.Kind == Ident && p.peekAt(1).Is(Punct, "=>") {
+		return true
+	}
+	if !t.Is(Punct, "(") {
+		return false
+	}
+	depth := 0
+	for j := p.i; j < len(p.toks); j++ {
+		switch p.toks[j].Text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+			if depth == 0 {
+				return j+1 < len(p.toks) && p.toks[j+1].Is(Punct, "=>") && !p.nl[j+1]
+			}
+		}
+	}
+	return false
+}
+
+func (p *parser) arrow() (expr, error) {
+	pos := posOf(p.peek())
+	fn := &funcNode{position: pos, arrow: true}
+	if p.peek().Kind == Ident {
+		name, err := p.identName()
+		if err != nil {
+			return nil, err
+		}
+		fn.params = []param{{name: name}}
+	} else {
+		var err error
+		if fn.params, err = p.params(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("=>"); err != nil {
+		return nil, err
+	}
+	if p.at("{") {
+		if err := p.functionBody(fn); err != nil {
+			return nil, err
+		}
+		return &funcExpr{pos, fn}, nil
+	}
+	body, err := p.assignment(false)
+	if err != nil {
+		return nil, err
+	}
+	fn.exprBody = body
+	return &funcExpr{pos, fn}, nil
+}
+
+// Expressions.
+
+func (p *parser) expression(noIn bool) (expr, error) {
+	pos := posOf(p.peek())
+	x, err := p.assignment(noIn)
+	if err != nil || !p.at(",") {
+		return x, err
+	}
+	seq := &seqExpr{position: pos, exprs: []expr{x}}
+	for p.accept(",") {
+		x, err := p.assignment(noIn)
+		if err != nil {
+			return nil, err
+		}
+		seq.exprs = append(seq.exprs, x)
+	}
+	return seq, nil
+}
+
+func assignable(x expr) bool {
+	switch x.(type) {
+	case *ident, *memberExpr:
+		return true
+	}
+	return false
+}
+
+func (p *parser) assignment(noIn bool) (expr, error) {
+	if p.at("async") {
+		return nil, p.errorf(p.peek(), "async is not supported")
+	}
+	if p.arrowAhead() {
+		return p.arrow()
+	}
+	pos := posOf(p.peek())
+	x, err := p.conditional(noIn)
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.Kind != Punct || !assignOps[t.Text] || p.eof() {
+		return x, nil
+	}
+	if !assignable(x) {
+		return nil, p.errorf(t, "invalid assignment target")
+	}
+	p.i++
+	value, err := p.assignment(noIn)
+	if err != nil {
+		return nil, err
+	}
+	return &assignExpr{position: pos, op: t.Text, target: x, value: value}, nil
+}
+
+func (p *parser) conditional(noIn bool) (expr, error) {
+	pos := posOf(p.peek())
+	test, err := p.binary(1, noIn)
+	if err != nil || !p.accept("?") {
+		return test, err
+	}
+	then, err := p.assignment(false)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	els, err := p.assignment(noIn)
+	if err != nil {
+		return nil, err
+	}
+	return &condExpr{position: pos, test: test, then: then, els: els}, nil
+}
+
+func (p *parser) binary(minPrec int, noIn bool) (expr, error) {
+	pos := posOf(p.peek())
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		prec, ok := binaryPrec[t.Text]
+		if p.eof() || !ok || prec < minPrec || t.Kind == String || (noIn && t.Text == "in") {
+			return left, nil
+		}
+		p.i++
+		next := prec + 1
+		if t.Text == "**" {
+			next = prec
+		}
+		right, err := p.binary(next, noIn)
+		if err != nil {
+			return nil, err
+		}
+		switch t.Text {
+		case "&&", "||", "??":
+			left = &logicalExpr{position: pos, op: t.Text, l: left, r: right}
+		default:
+			left = &binaryExpr{position: pos, op: t.Text, l: left, r: right}
+		}
+	}
+}
+
+func (p *parser) unary() (expr, error) {
+	t := p.peek()
+	pos := posOf(t)
+	switch {
+	case p.eof():
+		return nil, p.unexpected()
+	case t.Kind == Punct && (t.Text == "!" || t.Text == "~" || t.Text == "+" || t.Text == "-"),
+		t.Kind == Ident && (t.Text == "typeof" || t.Text == "void" || t.Text == "delete"):
+		p.i++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{position: pos, op: t.Text, x: x}, nil
+	case t.Is(Punct, "++"), t.Is(Punct, "--"):
+		p.i++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if !assignable(x) {
+			return nil, p.errorf(t, "invalid %s operand", t.Text)
+		}
+		return &updateExpr{position: pos, op: t.Text, prefix: true, target: x}, nil
+	}
+
+	x, err := p.callOrMember()
+	if err != nil {
+		return nil, err
+	}
+	if (p.at("++") || p.at("--")) && !p.newline() {
+		if !assignable(x) {
+			return nil, p.errorf(p.peek(), "invalid %s operand", p.peek().Text)
+		}
+		return &updateExpr{position: pos, op: p.next().Text, target: x}, nil
+	}
+	return x, nil
+}
+
+// callOrMember parses a primary expression followed by member accesses,
+// calls and new.
+func (p *parser) callOrMember() (expr, error) {
+	pos := posOf(p.peek())
+	var x expr
+	var err error
+	if p.at("new") {
+		p.i++
+		if p.at(".") {
+			return nil, p.errorf(p.peek(), "new.target is not supported")
+		}
+		callee, err := p.memberOnly()
+		if err != nil {
+			return nil, err
+		}
+		n := &newExpr{position: pos, callee: callee}
+		if p.at("(") {
+			if n.args, err = p.arguments(); err != nil {
+				return nil, err
+			}
+		}
+		x = n
+	} else if x, err = p.primary(); err != nil {
+		return nil, err
+	}
+	return p.chain(x, true)
+}
+
+// memberOnly parses the callee of new: a primary expression with member
+// accesses but no calls.
+func (p *parser) memberOnly() (expr, error) {
+	if p.at("new") {
+		return p.callOrMember()
+	}
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	return p.chain(x, false)
+}
+
+func (p *parser) chain(x expr, calls bool) (expr, error) {
+	pos := x.pos()
+	optional := false
+	for {
+		t := p.peek()
+		if p.eof() || t.Kind != Punct {
+			break
+		}
+		switch {
+		case t.Text == ".":
+			p.i++
+			name, err := p.propertyName()
+			if err != nil {
+				return nil, err
+			}
+			x = &memberExpr{position: pos, obj: x, name: name}
+		case t.Text == "?.":
+			p.i++
+			optional = true
+			m, err := p.optionalLink(x, pos)
+			if err != nil {
+				return nil, err
+			}
+			x = m
+		case t.Text == "[":
+			p.i++
+			key, err := p.expression(false)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &memberExpr{position: pos, obj: x, computed: key}
+		case t.Text == "(" && calls:
+			args, err := p.arguments()
+			if err != nil {
+				return nil, err
+			}
+			x = &callExpr{position: pos, callee: x, args: args}
+		default:
+			return p.endChain(x, optional), nil
+		}
+	}
+	if p.peek().Kind == Template && !p.eof() {
+		return nil, p.errorf(p.peek(), "tagged templates are not supported")
+	}
+	return p.endChain(x, optional), nil
+}
+
+func (p *parser) endChain(x expr, optional bool) expr {
+	if optional {
+		return &optionalChain{position: x.pos(), x: x}
+	}
+	return x
+}
+
+// optionalLink parses what follows ?.: a name, [key] or a call.
+func (p *parser) optionalLink(x expr, pos position) (expr, error) {
+	switch {
+	case p.at("("):
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		return &callExpr{position: pos, callee: x, args: args, optional: true}, nil
+	case p.accept("["):
+		key, err := p.expression(false)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return &memberExpr{position: pos, obj: x, computed: key, optional: true}, nil
+	}
+	name, err := p.propertyName()
+	if err != nil {
+		return nil, err
+	}
+	return &memberExpr{position: pos, obj: x, name: name, optional: true}, nil
+}
+
+// propertyName parses a name after "."; keywords are allowed.
+func (p *parser) propertyName() (string, error) {
+	t := p.peek()
+	if t.Kind != Ident || p.eof() {
+		return "", p.unexpected()
+	}
+	p.i++
+	return t.Text, nil
+}
+
+func (p *parser) arguments() ([]expr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []expr
+	for !p.accept(")") {
+		arg, err := p.element()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if !p.at(")") {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return args, nil
+}
+
+// element parses an argument or array element, which may be spread.
+func (p *parser) element() (expr, error) {
+	pos := posOf(p.peek())
+	if p.accept("...") {
+		x, err := p.assignment(false)
+		if err != nil {
+			return nil, err
+		}
+		return &spreadExpr{pos, x}, nil
+	}
+	return p.assignment(false)
+}
+
+func (p *parser) primary() (expr, error) {
+	t := p.peek()
+	pos := posOf(t)
+	if p.eof() {
+		return nil, p.unexpected()
+	}
+	switch t.Kind {
+	case Number:
+		p.i++
+		v, err := parseNumber(t.Text)
+		if err != nil {
+			return nil, p.errorf(t, "%v", err)
+		}
+		return &constExpr{pos, v}, nil
+	case String:
+		p.i++
+		s, err := unescape(t.Text[1 : len(t.Text)-1])
+		if err != nil {
+			return nil, p.errorf(t, "%v", err)
+		}
+		return &constExpr{pos, s}, nil
+	case Template:
+		p.i++
+		return p.template(t)
+	case Regex:
+		p.i++
+		end := strings.LastIndexByte(t.Text, '/')
+		return &regexLit{pos, t.Text[1:end], t.Text[end+1:]}, nil
+	case Ident:
+		return p.primaryIdent(t)
+	case Punct:
+		switch t.Text {
+		case "(":
+			return p.parenExpr()
+		case "[":
+			return p.arrayLiteral()
+		case "{":
+			return p.objectLiteral()
+		}
+	}
+	return nil, p.unexpected()
+}
+
+func (p *parser) primaryIdent(t Token) (expr, error) {
+	pos := posOf(t)
+	switch t.Text {
+	case "function":
+		p.i++
+		fn, err := p.function(pos, false)
+		if err != nil {
+			return nil, err
+		}
+		return &funcExpr{pos, fn}, nil
+	case "this":
+		p.i++
+		return &thisExpr{pos}, nil
+	case "null", "true", "false":
+		p.i++
+		return &constExpr{position: pos, value: map[string]Value{"null": nil, "true": true, "false": false}[t.Text]}, nil
+	}
+	name, err := p.identName()
+	if err != nil {
+		return nil, err
+	}
+	return &ident{pos, name}, nil
+}
+
+func (p *parser) arrayLiteral() (expr, error) {
+	a := &arrayLit{position: posOf(p.next())}
+	for !p.accept("]") {
+		if p.at(",") {
+			p.i++
+			a.elems = append(a.elems, nil)
+			continue
+		}
+		el, err := p.element()
+		if err != nil {
+			return nil, err
+		}
+		a.elems = append(a.elems, el)
+		if !p.at("]") {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return a, nil
+}
+
+func (p *parser) objectLiteral() (expr, error) {
+	o := &objectLit{position: posOf(p.next())}
+	for !p.accept("}") {
+		prop, err := p.objectProperty()
+		if err != nil {
+			return nil, err
+		}
+		o.props = append(o.props, prop)
+		if !p.at("}") {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return o, nil
+}
+
+func (p *parser) objectProperty() (property, error) {
+	var prop property
+	t := p.peek()
+	if p.accept("...") {
+		x, err := p.assignment(false)
+		return property{value: x, spread: true}, err
+	}
+
+	switch {
+	case p.accept("["):
+		key, err := p.assignment(false)
+		if err != nil {
+			return prop, err
+		}
+		prop.computed = key
+		if err := p.expect("]"); err != nil {
+			return prop, err
+		}
+	case t.Kind == Ident && (t.Text == "get" || t.Text == "set") && p.peekAt(1).Kind == Ident:
+		return prop, p.errorf(t, "getters and setters are not supported")
+	case t.Kind == Ident:
+		p.i++
+		prop.key = t.Text
+	case t.Kind == String:
+		p.i++
+		key, err := unescape(t.Text[1 : len(t.Text)-1])
+		if err != nil {
+			return prop, p.errorf(t, "%v", err)
+		}
+		prop.key = key
+	case t.Kind == Number:
+		p.i++
+		v, err := parseNumber(t.Text)
+		if err != nil {
+			return prop, p.errorf(t, "%v", err)
+		}
+		prop.key = formatNumber(v)
+	default:
+		return prop, p.unexpected()
+	}
+
+	var err error
+	switch {
+	case p.accept(":"):
+		prop.value, err = p.assignment(false)
+	case p.at("("):
+		fn := &funcNode{position: posOf(t), name: prop.key}
+		if fn.params, err = p.params(); err != nil {
+			return prop, err
+		}
+		err = p.functionBody(fn)
+		prop.value = &funcExpr{fn.position, fn}
+	case t.Kind == Ident && prop.computed == nil && (p.at(",") || p.at("}")):
+		if unsupported[t.Text] {
+			return prop, p.errorf(t, "%s is not supported", t.Text)
+		}
+		prop.value = &ident{posOf(t), t.Text}
+	default:
+		err = p.unexpected()
+	}
+	return prop, err
+}
+
+// template splits a template literal into its strings and expressions.
+func (p *parser) template(t Token) (expr, error) {
+	tl := &templateLit{position: posOf(t)}
+	raw := t.Text[1 : len(t.Text)-1]
+	var quasi strings.Builder
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && i+1 < len(raw):
+			quasi.WriteString(raw[i : i+2])
+			i++
+		case strings.HasPrefix(raw[i:], "${"):
+			end := templateExprEnd(raw, i+2)
+			if end < 0 {
+				return nil, p.errorf(t, "unterminated template expression")
+			}
+			s, err := unescape(quasi.String())
+			if err != nil {
+				return nil, p.errorf(t, "%v", err)
+			}
+			tl.quasis = append(tl.quasis, s)
+			quasi.Reset()
+
+			x, err := p.subExpression(t, raw[i+2:end])
+			if err != nil {
+				return nil, err
+			}
+			tl.exprs = append(tl.exprs, x)
+			i = end
+		default:
+			quasi.WriteByte(raw[i])
+		}
+	}
+	s, err := unescape(quasi.String())
+	if err != nil {
+		return nil, p.errorf(t, "%v", err)
+	}
+	tl.quasis = append(tl.quasis, s)
+	return tl, nil
+}
+
+// subExpression parses an expression embedded in a template literal.
+func (p *parser) subExpression(t Token, src string) (expr, error) {
+	toks, err := Lex(src)
+	if err != nil {
+		return nil, p.errorf(t, "%v", err)
+	}
+	sub := &parser{name: p.name, vars: p.vars}
+	for _, st := range toks {
+		if st.Kind == Comment {
+			continue
+		}
+		// Positions inside a template are reported at the template.
+		st.Line, st.Col = t.Line, t.Col
+		sub.toks = append(sub.toks, st)
+		sub.nl = append(sub.nl, false)
+	}
+	x, err := sub.expression(false)
+	if err != nil {
+		return nil, err
+	}
+	if !sub.eof() {
+		return nil, sub.unexpected()
+	}
+	return x, nil
+}
+
+// templateExprEnd returns the index of the "}" closing the template
+// expression starting at start.
+func templateExprEnd(raw string, start int) int {
+	depth := 1
+	for i := start; i < len(raw); i++ {
+		switch c := raw[i]; c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '"', '\'', '`':
+			for i++; i < len(raw) && raw[i] != c; i++ {
+				if raw[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+	return -1
+}
+
+func parseNumber(text string) (float64, error) {
+	s := strings.ReplaceAll(text, "_", "")
+	if strings.HasSuffix(s, "n") {
+		return 0, errors.New("BigInt is not supported")
+	}
+	if len(s) > 1 && s[0] == '0' {
+		base := 0
+		switch s[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 0 {
+			n, err := strconv.ParseUint(s[2:], base, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid number %s", text)
+			}
+			return float64(n), nil
+		}
+	}
+	// Out of range literals are Infinity or 0, as in JavaScript.
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("invalid number %s", text)
+	}
+	return v, nil
+}
+
+// unescape resolves the escape sequences of a string literal.
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			b.WriteByte(0)
+		case '\n':
+		case 'x', 'u':
+			r, n, err := hexEscape(s[i:])
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+			i += n - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// hexEscape decodes \xHH, \uHHHH or \u{H...} starting at the x or u and
+// returns the rune and the length consumed.
+func hexEscape(s string) (rune, int, error) {
+	digits, n := 0, 0
+	switch {
+	case s[0] == 'x':
+		digits, n = 2, 1
+	case strings.HasPrefix(s, "u{"):
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return 0, 0, errors.New("invalid unicode escape")
+		}
+		v, err := strconv.ParseUint(s[2:end], 16, 32)
+		if err != nil {
+			return 0, 0, errors.New("invalid unicode escape")
+		}
+		return rune(v), end + 1, nil
+	default:
+		digits, n = 4, 1
+	}
+	if len(s) < n+digits {
+		return 0, 0, errors.New("invalid escape sequence")
+	}
+	v, err := strconv.ParseUint(s[n:n+digits], 16, 32)
+	if err != nil {
+		return 0, 0, errors.New("invalid escape sequence")
+	}
+	return rune(v), n + digits, nil
+}
@@ -0,0 +1,74 @@
+package scriptjs
+
+import "testing"
+
+func TestUnescape(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"plain", "hello", "hello", false},
+		{"control", `a\nb\tc\rd\be\ff\vg`, "a\nb\tc\rd\be\ff\vg", false},
+		{"nul", `a\0b`, "a\x00b", false},
+		{"line continuation", "a\\\nb", "ab", false},
+		{"quotes and backslash", `\'\"\\`, `'"\`, false},
+		{"identity escape", `\q\$`, "q$", false},
+		{"trailing backslash", `a\`, `a\`, false},
+		{"hex", `\x41\x7e`, "A~", false},
+		{"unicode", `Aé`, "Aé", false},
+		{"code point", `\u{1F600}!`, "😀!", false},
+		{"short hex", `\x4`, "", true},
+		{"bad hex", `\xZ1`, "", true},
+		{"short unicode", `\u12`, "", true},
+		{"bad code point", `\u{zz}`, "", true},
+		{"open code point", `\u{41`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := unescape(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unescape(%q) error = %v, want error %t", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("unescape(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHexEscape(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    rune
+		n       int
+		wantErr bool
+	}{
+		{"x41rest", 'A', 3, false},
+		{"u00e9rest", 'é', 5, false},
+		{"u{1F600}rest", '😀', 8, false},
+		{"u{41}", 'A', 5, false},
+		{"x4", 0, 0, true},
+		{"u00g0", 0, 0, true},
+		{"u{}", 0, 0, true},
+		{"u{110000000}", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+			got, n, err := hexEscape(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("hexEscape(%q) error = %v, want error %t", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want || n != tt.n {
+				t.Errorf("hexEscape(%q) = %q, %d, want %q, %d", tt.in, got, n, tt.want, tt.n)
+			}
+		})
+	}
+}
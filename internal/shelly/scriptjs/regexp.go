@@ -0,0 +1,275 @@
+package scriptjs
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// regexpData is the internal state of a RegExp object. Patterns run on
+// Go's regexp package, so lookaround and backreferences are not
+// available.
+type regexpData struct {
+	source string
+	flags  string
+	re     *regexp.Regexp
+	global bool
+}
+
+func (r *Runtime) newRegExp(pattern, flags string) (*Object, error) {
+	var prefix string
+	for _, f := range flags {
+		switch f {
+		case 'i', 'm', 's':
+			prefix += string(f)
+		case 'g', 'u', 'y':
+		default:
+			return nil, r.throwf("SyntaxError", "Invalid regular expression flags '%s'", flags)
+		}
+	}
+	expr := translatePattern(pattern)
+	if prefix != "" {
+		expr = "(?" + prefix + ")" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, r.throwf("SyntaxError", "Invalid regular expression: /%s/: %v", pattern, err)
+	}
+	o := NewObject()
+	o.class = "RegExp"
+	o.proto = r.protos["RegExp"]
+	o.data = &regexpData{source: pattern, flags: flags, re: re, global: strings.Contains(flags, "g")}
+	o.Set("lastIndex", 0.0)
+	return o, nil
+}
+
+// translatePattern rewrites the JavaScript escapes Go does not know.
+func translatePattern(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c != '\\' || i+1 >= len(p) {
+			b.WriteByte(c)
+			continue
+		}
+		next := p[i+1]
+		switch {
+		case next == 'u' && i+5 < len(p):
+			b.WriteString(`\x{` + p[i+2:i+6] + `}`)
+			i += 5
+		case next == '/':
+			b.WriteByte('/')
+			i++
+		default:
+			b.WriteByte(c)
+			b.WriteByte(next)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// regexpOf returns the pattern of a RegExp object.
+func (r *Runtime) regexpOf(v Value) (*regexpData, error) {
+	if o, ok := v.(*Object); ok && o != nil {
+		if re, ok := o.data.(*regexpData); ok {
+			return re, nil
+		}
+	}
+	return nil, r.throwf("TypeError", "%s is not a regular expression", ToString(v))
+}
+
+// toRegExp converts a string argument to a pattern matching it literally.
+func (r *Runtime) toRegExp(v Value) (*regexpData, error) {
+	if o, ok := v.(*Object); ok && o.class == "RegExp" {
+		return r.regexpOf(o)
+	}
+	return &regexpData{re: regexp.MustCompile(regexp.QuoteMeta(ToString(v)))}, nil
+}
+
+// matchArray converts submatch indexes to a match array.
+func matchArray(s string, m []int) *Array {
+	out := make([]Value, len(m)/2)
+	for i := range out {
+		if m[2*i] < 0 {
+			out[i] = Undefined
+		} else {
+			out[i] = s[m[2*i]:m[2*i+1]]
+		}
+	}
+	return NewArray(out...)
+}
+
+// exec runs a pattern from its lastIndex when it is global.
+func (r *Runtime) exec(o *Object, s string) (Value, error) {
+	re, err := r.regexpOf(o)
+	if err != nil {
+		return nil, err
+	}
+	start := 0
+	if re.global {
+		last, _ := o.Get("lastIndex")
+		start = toInteger(last)
+		if start > len(s) {
+			o.Set("lastIndex", 0.0)
+			return Null, nil
+		}
+	}
+	m := re.re.FindStringSubmatchIndex(s[start:])
+	if m == nil {
+		o.Set("lastIndex", 0.0)
+		return Null, nil
+	}
+	for i := range m {
+		if m[i] >= 0 {
+			m[i] += start
+		}
+	}
+	if re.global {
+		end := m[1]
+		if end == m[0] {
+			end++
+		}
+		o.Set("lastIndex", float64(end))
+	}
+	return matchArray(s, m), nil
+}
+
+func stringMatch(r *Runtime, s string, args []Value) (Value, error) {
+	re, err := r.toRegExp(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+	if !re.global {
+		m := re.re.FindStringSubmatchIndex(s)
+		if m == nil {
+			return Null, nil
+		}
+		return matchArray(s, m), nil
+	}
+	all := re.re.FindAllString(s, -1)
+	if all == nil {
+		return Null, nil
+	}
+	out := make([]Value, len(all))
+	for i, m := range all {
+		out[i] = m
+	}
+	return NewArray(out...), nil
+}
+
+func stringSearch(r *Runtime, s string, args []Value) (Value, error) {
+	re, err := r.toRegExp(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+	m := re.re.FindStringIndex(s)
+	if m == nil {
+		return -1.0, nil
+	}
+	return float64(utf8.RuneCountInString(s[:m[0]])), nil
+}
+
+// replace implements replace and replaceAll for string and RegExp
+// patterns with string or function replacements.
+func (r *Runtime) replace(s string, args []Value, all bool) (Value, error) {
+	re, err := r.toRegExp(arg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+	n := 1
+	if all || re.global {
+		n = -1
+	}
+	matches := re.re.FindAllStringSubmatchIndex(s, n)
+	fn, isFunc := arg(args, 1).(*Function)
+	repl := ToString(arg(args, 1))
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(s[last:m[0]])
+		if !isFunc {
+			b.WriteString(expandReplacement(repl, s, m))
+		} else {
+			callArgs := append(matchArray(s, m).elems, float64(utf8.RuneCountInString(s[:m[0]])), s)
+			v, err := r.call(fn, Undefined, callArgs)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(ToString(v))
+		}
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+// expandReplacement expands $&, $1-$99 and $$ in a replacement string.
+func expandReplacement(repl, s string, m []int) string {
+	if !strings.Contains(repl, "$") {
+		return repl
+	}
+	var b strings.Builder
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+		if c != '$' || i+1 >= len(repl) {
+			b.WriteByte(c)
+			continue
+		}
+		next := repl[i+1]
+		switch {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '&':
+			b.WriteString(s[m[0]:m[1]])
+			i++
+		case next >= '1' && next <= '9':
+			group := int(next - '0')
+			i++
+			if i+1 < len(repl) && repl[i+1] >= '0' && repl[i+1] <= '9' && group*10+int(repl[i+1]-'0') < len(m)/2 {
+				group = group*10 + int(repl[i+1]-'0')
+				i++
+			}
+			if group < len(m)/2 && m[2*group] >= 0 {
+				b.WriteString(s[m[2*group]:m[2*group+1]])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func (r *Runtime) regexpCtor() *Function {
+	proto := NewObject()
+	r.protos["RegExp"] = proto
+	setMethods(proto, map[string]NativeFunc{
+		"test": func(r *Runtime, this Value, args []Value) (Value, error) {
+			o, _ := this.(*Object)
+			m, err := r.exec(o, ToString(arg(args, 0)))
+			return m != nil, err
+		},
+		"exec": func(r *Runtime, this Value, args []Value) (Value, error) {
+			o, _ := this.(*Object)
+			return r.exec(o, ToString(arg(args, 0)))
+		},
+		"toString": func(_ *Runtime, this Value, _ []Value) (Value, error) { return ToString(this), nil },
+	})
+
+	ctor := NewFunction("RegExp", func(r *Runtime, _ Value, args []Value) (Value, error) {
+		pattern := arg(args, 0)
+		if re, err := r.regexpOf(pattern); err == nil {
+			pattern = re.source
+		}
+		flags := ""
+		if f := arg(args, 1); f != Undefined {
+			flags = ToString(f)
+		}
+		return r.newRegExp(ToString(pattern), flags)
+	})
+	ctor.construct = ctor.native
+	ctor.Set("prototype", proto)
+	return ctor
+}
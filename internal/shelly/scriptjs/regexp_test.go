@@ -0,0 +1,40 @@
+package scriptjs
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestExpandReplacement(t *testing.T) {
+	t.Parallel()
+
+	const s = "abcdefghijk"
+	eleven := regexp.MustCompile(`(a)(b)(c)(d)(e)(f)(g)(h)(i)(j)(k)`)
+	optional := regexp.MustCompile(`(a)(z)?`)
+
+	tests := []struct {
+		name string
+		re   *regexp.Regexp
+		repl string
+		want string
+	}{
+		{"no pattern", optional, "plain", "plain"},
+		{"dollar", optional, "$$1", "$1"},
+		{"match", optional, "[$&]", "[a]"},
+		{"group", optional, "<$1>", "<a>"},
+		{"unmatched group", optional, "<$2>", "<>"},
+		{"two digit group", eleven, "$11", "k"},
+		{"one digit group before a digit", optional, "$10", "a0"},
+		{"other character", optional, "$x", "$x"},
+		{"trailing dollar", optional, "a$", "a$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := tt.re.FindStringSubmatchIndex(s)
+			if got := expandReplacement(tt.repl, s, m); got != tt.want {
+				t.Errorf("expandReplacement(%q) = %q, want %q", tt.repl, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,483 @@
+package scriptjs
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value is a script value: Undefined, nil (null), bool, float64, string,
+// *Object, *Array or *Function.
+type Value = any
+
+type undefinedType struct{}
+
+// Undefined is the undefined value.
+var Undefined = undefinedType{}
+
+// Null is the null value.
+var Null Value
+
+// Object is a script object. Keys keep their insertion order.
+type Object struct {
+	keys  []string
+	props map[string]Value
+	proto *Object
+	class string // Object, Error, Date or RegExp
+	data  any    // internal state of built-in objects
+}
+
+// NewObject returns an empty object.
+func NewObject() *Object {
+	return &Object{props: make(map[string]Value), class: "Object"}
+}
+
+// Get returns an own property of the object.
+func (o *Object) Get(key string) (Value, bool) {
+	v, ok := o.props[key]
+	return v, ok
+}
+
+// Set sets an own property of the object.
+func (o *Object) Set(key string, v Value) {
+	if o.props == nil {
+		o.props = make(map[string]Value)
+	}
+	if _, ok := o.props[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.props[key] = v
+}
+
+// Delete removes an own property of the object.
+func (o *Object) Delete(key string) {
+	if _, ok := o.props[key]; !ok {
+		return
+	}
+	delete(o.props, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the own property names of the object in insertion order.
+func (o *Object) Keys() []string {
+	return append([]string(nil), o.keys...)
+}
+
+// lookup finds a property on the object or its prototype chain.
+func (o *Object) lookup(key string) (Value, bool) {
+	for p := o; p != nil; p = p.proto {
+		if v, ok := p.props[key]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Array is a script array.
+type Array struct {
+	elems []Value
+}
+
+// NewArray returns an array of elems.
+func NewArray(elems ...Value) *Array {
+	return &Array{elems: elems}
+}
+
+// Elems returns the elements of the array.
+func (a *Array) Elems() []Value { return a.elems }
+
+// NativeFunc implements a built-in function.
+type NativeFunc func(r *Runtime, this Value, args []Value) (Value, error)
+
+// Function is a script function or a built-in one.
+type Function struct {
+	Object
+	name   string
+	native NativeFunc
+	// construct creates instances of built-in constructors.
+	construct NativeFunc
+	node      *funcNode
+	scope     *scope
+	// this is the this value of arrow functions and bound functions.
+	this      Value
+	bound     *Function
+	boundArgs []Value
+}
+
+// NewFunction returns a built-in function.
+func NewFunction(name string, fn NativeFunc) *Function {
+	return &Function{Object: Object{class: "Function"}, name: name, native: fn}
+}
+
+// Name returns the name of the function.
+func (f *Function) Name() string { return f.name }
+
+// arg returns args[i] or Undefined.
+func arg(args []Value, i int) Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return Undefined
+}
+
+func isNullish(v Value) bool {
+	return v == nil || v == Undefined
+}
+
+func toBool(v Value) bool {
+	switch v := v.(type) {
+	case undefinedType, nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	}
+	return true
+}
+
+func toNumber(v Value) float64 {
+	switch v := v.(type) {
+	case undefinedType:
+		return math.NaN()
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case float64:
+		return v
+	case string:
+		return stringToNumber(v)
+	case *Array:
+		return stringToNumber(ToString(v))
+	case *Object:
+		if t, ok := v.data.(time.Time); ok {
+			return float64(t.UnixMilli())
+		}
+	}
+	return math.NaN()
+}
+
+func stringToNumber(s string) float64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	if len(s) > 2 && s[0] == '0' && strings.ContainsRune("xXoObB", rune(s[1])) {
+		if n, err := parseNumber(s); err == nil {
+			return n
+		}
+		return math.NaN()
+	}
+	switch s {
+	case "Infinity", "+Infinity":
+		return math.Inf(1)
+	case "-Infinity":
+		return math.Inf(-1)
+	}
+	if strings.ContainsAny(s, "_xXnN") || strings.HasPrefix(s, "+-") {
+		return math.NaN()
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+func toInt32(v Value) int32 {
+	f := toNumber(v)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return int32(uint32(int64(math.Mod(math.Trunc(f), 1<<32))))
+}
+
+func toUint32(v Value) uint32 {
+	return uint32(toInt32(v))
+}
+
+// toInteger converts v to an integer, as used for indexes and counts.
+func toInteger(v Value) int {
+	f := toNumber(v)
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f > math.MaxInt32:
+		return math.MaxInt32
+	case f < math.MinInt32:
+		return math.MinInt32
+	}
+	return int(f)
+}
+
+// formatNumber formats a number the way JavaScript does.
+func formatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0:
+		return "0"
+	}
+	abs := math.Abs(f)
+	if abs >= 1e21 || abs < 1e-6 {
+		s := strconv.FormatFloat(f, 'e', -1, 64)
+		mant, exp, _ := strings.Cut(s, "e")
+		sign := exp[0]
+		exp = strings.TrimLeft(exp[1:], "0")
+		return mant + "e" + string(sign) + exp
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// ToString converts a value to a string the way JavaScript does.
+func ToString(v Value) string {
+	switch v := v.(type) {
+	case undefinedType:
+		return "undefined"
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return formatNumber(v)
+	case string:
+		return v
+	case *Array:
+		parts := make([]string, len(v.elems))
+		for i, e := range v.elems {
+			if !isNullish(e) {
+				parts[i] = ToString(e)
+			}
+		}
+		return strings.Join(parts, ",")
+	case *Function:
+		return "function " + v.name + "() { [native code] }"
+	case *Object:
+		return objectString(v)
+	}
+	return ""
+}
+
+func objectString(o *Object) string {
+	switch o.class {
+	case "Error":
+		name, _ := o.lookup("name")
+		msg, _ := o.lookup("message")
+		if m := ToString(msg); msg != nil && m != "" && msg != Undefined {
+			return ToString(name) + ": " + m
+		}
+		return ToString(name)
+	case "Date":
+		if t, ok := o.data.(time.Time); ok {
+			return t.Format("Mon Jan 02 2006 15:04:05 GMT-0700")
+		}
+	case "RegExp":
+		if re, ok := o.data.(*regexpData); ok {
+			return "/" + re.source + "/" + re.flags
+		}
+	}
+	return "[object Object]"
+}
+
+func typeOf(v Value) string {
+	switch v.(type) {
+	case undefinedType:
+		return "undefined"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *Function:
+		return "function"
+	}
+	return "object"
+}
+
+func strictEquals(a, b Value) bool {
+	if fa, ok := a.(float64); ok {
+		fb, ok := b.(float64)
+		return ok && fa == fb
+	}
+	return a == b
+}
+
+func looseEquals(a, b Value) bool {
+	if isNullish(a) || isNullish(b) {
+		return isNullish(a) && isNullish(b)
+	}
+	if typeOf(a) == typeOf(b) {
+		return strictEquals(a, b)
+	}
+	switch {
+	case isObject(a) && isObject(b):
+		return a == b
+	case isObject(a):
+		return looseEquals(toPrimitive(a), b)
+	case isObject(b):
+		return looseEquals(a, toPrimitive(b))
+	}
+	return toNumber(a) == toNumber(b)
+}
+
+func isObject(v Value) bool {
+	switch v.(type) {
+	case *Object, *Array, *Function:
+		return true
+	}
+	return false
+}
+
+func toPrimitive(v Value) Value {
+	if o, ok := v.(*Object); ok {
+		if t, ok := o.data.(time.Time); ok {
+			return float64(t.UnixMilli())
+		}
+	}
+	if isObject(v) {
+		return ToString(v)
+	}
+	return v
+}
+
+// propertyKey converts a value used as a property name to a string.
+func propertyKey(v Value) string {
+	if f, ok := v.(float64); ok {
+		return formatNumber(f)
+	}
+	return ToString(v)
+}
+
+// arrayIndex returns the index a property key refers to, if any.
+func arrayIndex(key Value) (int, bool) {
+	switch k := key.(type) {
+	case float64:
+		if k >= 0 && k == math.Trunc(k) && k < math.MaxInt32 {
+			return int(k), true
+		}
+	case string:
+		n, err := strconv.Atoi(k)
+		if err == nil && n >= 0 && strconv.Itoa(n) == k {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// FromGo converts a Go value, as decoded from JSON or YAML, to a script
+// value.
+func FromGo(v any) Value {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case bool, string, float64, *Object, *Array, *Function, undefinedType:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case []any:
+		a := &Array{elems: make([]Value, len(v))}
+		for i, e := range v {
+			a.elems[i] = FromGo(e)
+		}
+		return a
+	case map[string]any:
+		o := NewObject()
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			o.Set(k, FromGo(v[k]))
+		}
+		return o
+	}
+	// Anything else goes through JSON.
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Undefined
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return Undefined
+	}
+	return FromGo(decoded)
+}
+
+// ToGo converts a script value to plain Go values: map[string]any,
+// []any, float64, string, bool and nil.
+func ToGo(v Value) any {
+	switch v := v.(type) {
+	case undefinedType, nil:
+		return nil
+	case *Array:
+		out := make([]any, len(v.elems))
+		for i, e := range v.elems {
+			out[i] = ToGo(e)
+		}
+		return out
+	case *Function:
+		return nil
+	case *Object:
+		if v.class == "Date" || v.class == "RegExp" {
+			return ToString(v)
+		}
+		out := make(map[string]any, len(v.keys))
+		for _, k := range v.keys {
+			if e := v.props[k]; e != Undefined {
+				if _, fn := e.(*Function); !fn {
+					out[k] = ToGo(e)
+				}
+			}
+		}
+		return out
+	}
+	return v
+}
+
+// Inspect formats a value for printing: strings as they are and objects
+// as JSON.
+func Inspect(v Value) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case *Array, *Object:
+		if o, ok := v.(*Object); ok && o.class != "Object" {
+			return ToString(o)
+		}
+		s, err := stringify(v, "")
+		if err != nil {
+			return ToString(v)
+		}
+		return ToString(s)
+	}
+	return ToString(v)
+}
@@ -0,0 +1,213 @@
+package scriptsim
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptjs"
+)
+
+// errDie is returned when the script calls die().
+var errDie = errors.New("script called die()")
+
+type native = scriptjs.NativeFunc
+
+func arg(args []scriptjs.Value, i int) scriptjs.Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return scriptjs.Undefined
+}
+
+// object returns an object with the given methods.
+func object(methods map[string]native) *scriptjs.Object {
+	o := scriptjs.NewObject()
+	for _, name := range sortedKeys(methods) {
+		o.Set(name, scriptjs.NewFunction(name, methods[name]))
+	}
+	return o
+}
+
+// install defines the device script API: Shelly, Timer, MQTT, print and
+// console.
+func (s *simulator) install() {
+	s.rt.Set("Shelly", object(map[string]native{
+		"call":                s.call,
+		"addEventHandler":     s.addHandler(&s.eventHandlers),
+		"addStatusHandler":    s.addHandler(&s.statusHandlers),
+		"removeEventHandler":  s.removeHandler(&s.eventHandlers),
+		"removeStatusHandler": s.removeHandler(&s.statusHandlers),
+		"emitEvent":           s.emitEvent,
+		"getComponentStatus":  s.getComponent(s.dev.status),
+		"getComponentConfig":  s.getComponent(s.dev.config),
+		"getDeviceInfo": func(*scriptjs.Runtime, scriptjs.Value, []scriptjs.Value) (scriptjs.Value, error) {
+			return scriptjs.FromGo(s.dev.info), nil
+		},
+		"getCurrentScriptId": func(*scriptjs.Runtime, scriptjs.Value, []scriptjs.Value) (scriptjs.Value, error) {
+			return float64(scriptID), nil
+		},
+		"getUptimeMs": func(*scriptjs.Runtime, scriptjs.Value, []scriptjs.Value) (scriptjs.Value, error) {
+			return float64(s.now.Sub(epoch).Milliseconds()), nil
+		},
+	}))
+	s.rt.Set("Timer", object(map[string]native{
+		"set": s.setTimer,
+		"clear": func(_ *scriptjs.Runtime, _ scriptjs.Value, args []scriptjs.Value) (scriptjs.Value, error) {
+			id, _ := arg(args, 0).(float64)
+			return s.clearTimer(int(id)), nil
+		},
+	}))
+	s.rt.Set("MQTT", object(map[string]native{
+		"isConnected": func(*scriptjs.Runtime, scriptjs.Value, []scriptjs.Value) (scriptjs.Value, error) { return true, nil },
+		"subscribe":   s.subscribe,
+		"unsubscribe": s.unsubscribe,
+		"publish": func(_ *scriptjs.Runtime, _ scriptjs.Value, args []scriptjs.Value) (scriptjs.Value, error) {
+			s.rec.Published = append(s.rec.Published, Message{
+				Topic: scriptjs.ToString(arg(args, 0)), Message: scriptjs.ToString(arg(args, 1)),
+			})
+			return true, nil
+		},
+		"setConnectHandler": func(*scriptjs.Runtime, scriptjs.Value, []scriptjs.Value) (scriptjs.Value, error) {
+			return scriptjs.Undefined, nil
+		},
+		"setDisconnectHandler": func(*scriptjs.Runtime, scriptjs.Value, []scriptjs.Value) (scriptjs.Value, error) {
+			return scriptjs.Undefined, nil
+		},
+	}))
+
+	s.rt.Set("print", scriptjs.NewFunction("print", s.print))
+	s.rt.Set("console", object(map[string]native{"log": s.print}))
+	s.rt.Set("die", scriptjs.NewFunction("die", func(_ *scriptjs.Runtime, _ scriptjs.Value, args []scriptjs.Value) (scriptjs.Value, error) {
+		if msg := arg(args, 0); msg != scriptjs.Undefined {
+			return nil, errors.New("script called die(): " + scriptjs.ToString(msg))
+		}
+		return nil, errDie
+	}))
+}
+
+func (s *simulator) print(_ *scriptjs.Runtime, _ scriptjs.Value, args []scriptjs.Value) (scriptjs.Value, error) {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = scriptjs.Inspect(a)
+	}
+	line := strings.Join(parts, " ")
+	s.rec.Prints = append(s.rec.Prints, line)
+	s.output = append(s.output, line)
+	return scriptjs.Undefined, nil
+}
+
+// call implements Shelly.call(method, params, callback, userdata). The
+// callback runs after the calling code returns, as on the device.
+func (s *simulator) call(r *scriptjs.Runtime, _ scriptjs.Value, args []scriptjs.Value) (scriptjs.Value, error) {
+	method := scriptjs.ToString(arg(args, 0))
+	var params map[string]any
+	switch p := arg(args, 1).(type) {
+	case string:
+		v, err := scriptjs.ParseJSON(p)
+		if err != nil {
+			return nil, r.Throw("Error", "Shelly.call: %v", err)
+		}
+		params, _ = scriptjs.ToGo(v).(map[string]any)
+	default:
+		params, _ = scriptjs.ToGo(p).(map[string]any)
+	}
+	if params == nil {
+		params = map[string]any{}
+	}
+	s.rec.Calls = append(s.rec.Calls, Call{Method: method, Params: params})
+
+	result, rerr := s.dev.call(method, params)
+	if cb, ok := arg(args, 2).(*scriptjs.Function); ok {
+		if rerr != nil {
+			s.later(cb, scriptjs.Undefined, float64(rerr.code), rerr.msg, arg(args, 3))
+		} else {
+			s.later(cb, scriptjs.FromGo(result), 0.0, "", arg(args, 3))
+		}
+	}
+	return scriptjs.Undefined, nil
+}
+
+func (s *simulator) addHandler(list *[]handler) native {
+	return func(r *scriptjs.Runtime, _ scriptjs.Value, args []scriptjs.Value) (scriptjs.Value, error) {
+		fn, ok := arg(args, 0).(*scriptjs.Function)
+		if !ok {
+			return nil, r.Throw("TypeError", "handler must be a function")
+		}
+		h := handler{id: s.id(), fn: fn, ud: arg(args, 1)}
+		*list = append(*list, h)
+		return float64(h.id), nil
+	}
+}
+
+func (s *simulator) removeHandler(list *[]handler) native {
+	return func(_ *scriptjs.Runtime, _ scriptjs.Value, args []scriptjs.Value) (scriptjs.Value, error) {
+		id, _ := arg(args, 0).(float64)
+		for i, h := range *list {
+			if h.id == int(id) {
+				*list = append((*list)[:i], (*list)[i+1:]...)
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+func (s *simulator) emitEvent(_ *scriptjs.Runtime, _ scriptjs.Value, args []scriptjs.Value) (scriptjs.Value, error) {
+	name := scriptjs.ToString(arg(args, 0))
+	s.rec.Events = append(s.rec.Events, name)
+	component := "script:1"
+	s.dispatchEvent(component, map[string]any{
+		"component": component, "id": scriptID, "event": name, "data": scriptjs.ToGo(arg(args, 1)), "ts": s.unix(),
+	})
+	return scriptjs.Undefined, nil
+}
+
+// getComponent implements getComponentStatus and getComponentConfig,
+// which take a key ("switch:0") or a type and id ("switch", 0).
+func (s *simulator) getComponent(from map[string]map[string]any) native {
+	return func(_ *scriptjs.Runtime, _ scriptjs.Value, args []scriptjs.Value) (scriptjs.Value, error) {
+		key := strings.ToLower(scriptjs.ToString(arg(args, 0)))
+		if id := arg(args, 1); id != scriptjs.Undefined {
+			key += ":" + scriptjs.ToString(id)
+		}
+		if v, ok := from[key]; ok {
+			return scriptjs.FromGo(v), nil
+		}
+		return scriptjs.Null, nil
+	}
+}
+
+// setTimer implements Timer.set(period, repeat, callback, userdata).
+func (s *simulator) setTimer(r *scriptjs.Runtime, _ scriptjs.Value, args []scriptjs.Value) (scriptjs.Value, error) {
+	ms, _ := arg(args, 0).(float64)
+	fn, ok := arg(args, 2).(*scriptjs.Function)
+	if !ok {
+		return nil, r.Throw("TypeError", "Timer.set: callback must be a function")
+	}
+	period := max(time.Duration(ms*float64(time.Millisecond)), time.Millisecond)
+	repeat, _ := arg(args, 1).(bool)
+	t := &timer{id: s.id(), due: s.now.Add(period), period: period, repeat: repeat, fn: fn, ud: arg(args, 3)}
+	s.timers = append(s.timers, t)
+	return float64(t.id), nil
+}
+
+func (s *simulator) subscribe(r *scriptjs.Runtime, _ scriptjs.Value, args []scriptjs.Value) (scriptjs.Value, error) {
+	fn, ok := arg(args, 1).(*scriptjs.Function)
+	if !ok {
+		return nil, r.Throw("TypeError", "MQTT.subscribe: callback must be a function")
+	}
+	s.subscriptions = append(s.subscriptions, subscription{topic: scriptjs.ToString(arg(args, 0)), fn: fn, ud: arg(args, 2)})
+	return scriptjs.Undefined, nil
+}
+
+func (s *simulator) unsubscribe(_ *scriptjs.Runtime, _ scriptjs.Value, args []scriptjs.Value) (scriptjs.Value, error) {
+	topic := scriptjs.ToString(arg(args, 0))
+	for i, sub := range s.subscriptions {
+		if sub.topic == topic {
+			s.subscriptions = append(s.subscriptions[:i], s.subscriptions[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
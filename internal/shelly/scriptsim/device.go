@@ -0,0 +1,334 @@
+package scriptsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tj-smith47/shelly-cli/internal/mock"
+)
+
+// rpcError is an error returned to the script's RPC callback.
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func errNoHandler(method string) *rpcError {
+	return &rpcError{code: 404, msg: "No handler for " + method}
+}
+
+func errNotFound(arg string, value any) *rpcError {
+	return &rpcError{code: -105, msg: fmt.Sprintf("Argument '%s', value %s not found!", arg, compact(value))}
+}
+
+// device is the simulated device a script runs on.
+type device struct {
+	info      map[string]any
+	status    map[string]map[string]any
+	config    map[string]map[string]any
+	kvs       map[string]any
+	kvsRev    int
+	responses map[string]Response
+	// changed is called with the fields that changed in a status.
+	changed func(component string, delta map[string]any)
+}
+
+func newDevice(spec *Spec) (*device, error) {
+	d := &device{
+		info: map[string]any{
+			"id": "shellyplus1pm-simulated", "mac": "000000000000", "model": "SNSW-001P16EU",
+			"gen": 2, "ver": "1.4.4", "app": "Plus1PM", "name": "simulated",
+		},
+		status:    make(map[string]map[string]any),
+		config:    make(map[string]map[string]any),
+		kvs:       make(map[string]any),
+		responses: spec.Responses,
+		changed:   func(string, map[string]any) {},
+	}
+
+	if spec.Fixtures != "" {
+		if err := d.loadFixtures(spec.path(spec.Fixtures), spec.Device); err != nil {
+			return nil, err
+		}
+	}
+	for key, st := range spec.State {
+		d.merge(d.status, key, st)
+	}
+	for key, cfg := range spec.Config {
+		d.merge(d.config, key, cfg)
+	}
+	for k, v := range spec.KVS {
+		d.kvs[k] = normalize(v)
+	}
+	return d, nil
+}
+
+func (d *device) loadFixtures(path, name string) error {
+	fixtures, err := mock.LoadFixtures(path)
+	if err != nil {
+		return fmt.Errorf("failed to load fixtures: %w", err)
+	}
+	var dev *mock.DeviceFixture
+	for i := range fixtures.Config.Devices {
+		fd := &fixtures.Config.Devices[i]
+		if name == "" && fd.Generation >= 2 || fd.Name == name {
+			dev = fd
+			break
+		}
+	}
+	if dev == nil {
+		if name == "" {
+			return fmt.Errorf("no Gen2+ device in fixtures %s", path)
+		}
+		return fmt.Errorf("device %q not found in fixtures %s", name, path)
+	}
+
+	mac := strings.ReplaceAll(dev.MAC, ":", "")
+	d.info = map[string]any{
+		"id":    "shelly" + strings.ToLower(strings.ReplaceAll(dev.Model, " ", "")) + "-" + strings.ToLower(mac),
+		"mac":   mac,
+		"model": dev.Model,
+		"gen":   dev.Generation,
+		"ver":   "1.4.4",
+		"app":   dev.Type,
+		"name":  dev.Name,
+	}
+	for key, st := range fixtures.DeviceStates[dev.Name] {
+		if m, ok := normalize(st).(map[string]any); ok {
+			d.merge(d.status, key, m)
+		}
+	}
+	return nil
+}
+
+func (d *device) merge(into map[string]map[string]any, key string, fields map[string]any) {
+	if into[key] == nil {
+		into[key] = make(map[string]any)
+	}
+	for k, v := range fields {
+		into[key][k] = normalize(v)
+	}
+}
+
+// update changes status fields and reports the ones that changed.
+func (d *device) update(key string, fields map[string]any) {
+	if d.status[key] == nil {
+		d.status[key] = make(map[string]any)
+	}
+	delta := make(map[string]any)
+	for k, v := range fields {
+		v = normalize(v)
+		if old, ok := d.status[key][k]; !ok || compact(old) != compact(v) {
+			delta[k] = v
+		}
+		d.status[key][k] = v
+	}
+	if len(delta) == 0 {
+		return
+	}
+	if _, id, ok := strings.Cut(key, ":"); ok {
+		if n, err := strconv.Atoi(id); err == nil {
+			delta["id"] = float64(n)
+		}
+	}
+	d.changed(key, delta)
+}
+
+// componentKey returns the status key a method addresses, e.g. switch:1
+// for Switch.Set with id 1 or sys for Sys.GetStatus.
+func (d *device) componentKey(typ string, params map[string]any) string {
+	typ = strings.ToLower(typ)
+	if id, ok := params["id"]; ok {
+		return typ + ":" + compact(id)
+	}
+	if _, ok := d.status[typ]; ok {
+		return typ
+	}
+	return typ + ":0"
+}
+
+// call handles an RPC call from the script.
+func (d *device) call(method string, params map[string]any) (any, *rpcError) {
+	if r, ok := d.responses[method]; ok {
+		if r.Code != 0 {
+			return nil, &rpcError{code: r.Code, msg: r.Message}
+		}
+		return normalize(r.Result), nil
+	}
+	typ, verb, ok := strings.Cut(method, ".")
+	if !ok {
+		return nil, errNoHandler(method)
+	}
+	verb = strings.ToLower(verb)
+	switch strings.ToLower(typ) {
+	case "shelly":
+		return d.shellyCall(method, verb)
+	case "kvs":
+		return d.kvsCall(method, verb, params)
+	}
+
+	key := d.componentKey(typ, params)
+	switch verb {
+	case "getstatus":
+		if st, ok := d.status[key]; ok {
+			return st, nil
+		}
+		return nil, errNotFound("id", params["id"])
+	case "getconfig":
+		if cfg, ok := d.config[key]; ok {
+			return cfg, nil
+		}
+		return map[string]any{"id": params["id"]}, nil
+	case "set":
+		return d.set(key, params), nil
+	case "toggle":
+		on, _ := d.status[key]["output"].(bool)
+		d.update(key, map[string]any{"output": !on})
+		return map[string]any{"was_on": on}, nil
+	case "open", "close", "stop", "gotoposition":
+		d.cover(key, verb, params)
+		return nil, nil //nolint:nilnil // Cover calls have a null result
+	}
+	return nil, errNoHandler(method)
+}
+
+func (d *device) shellyCall(method, verb string) (any, *rpcError) {
+	switch verb {
+	case "getdeviceinfo":
+		return d.info, nil
+	case "getstatus":
+		out := make(map[string]any, len(d.status))
+		for k, v := range d.status {
+			out[k] = v
+		}
+		return out, nil
+	case "getconfig":
+		out := make(map[string]any, len(d.config))
+		for k, v := range d.config {
+			out[k] = v
+		}
+		return out, nil
+	}
+	return nil, errNoHandler(method)
+}
+
+// set applies Switch.Set, Light.Set and similar calls.
+func (d *device) set(key string, params map[string]any) any {
+	was, _ := d.status[key]["output"].(bool)
+	fields := make(map[string]any)
+	for k, v := range params {
+		switch k {
+		case "id", "toggle_after", "transition_duration":
+		case "on":
+			fields["output"] = v
+		default:
+			fields[k] = v
+		}
+	}
+	d.update(key, fields)
+	if strings.HasPrefix(key, "switch") {
+		return map[string]any{"was_on": was}
+	}
+	return nil
+}
+
+func (d *device) cover(key, verb string, params map[string]any) {
+	switch verb {
+	case "open":
+		d.update(key, map[string]any{"state": "open", "current_pos": 100.0})
+	case "close":
+		d.update(key, map[string]any{"state": "closed", "current_pos": 0.0})
+	case "stop":
+		d.update(key, map[string]any{"state": "stopped"})
+	default:
+		d.update(key, map[string]any{"state": "stopped", "current_pos": normalize(params["pos"])})
+	}
+}
+
+func (d *device) kvsCall(method, verb string, params map[string]any) (any, *rpcError) {
+	key, _ := params["key"].(string)
+	switch verb {
+	case "set":
+		d.kvs[key] = normalize(params["value"])
+		d.kvsRev++
+		return map[string]any{"etag": etag(key, d.kvs[key]), "rev": float64(d.kvsRev)}, nil
+	case "get":
+		v, ok := d.kvs[key]
+		if !ok {
+			return nil, errNotFound("key", key)
+		}
+		return map[string]any{"etag": etag(key, v), "value": v}, nil
+	case "delete":
+		if _, ok := d.kvs[key]; !ok {
+			return nil, errNotFound("key", key)
+		}
+		delete(d.kvs, key)
+		d.kvsRev++
+		return map[string]any{"rev": float64(d.kvsRev)}, nil
+	case "getmany":
+		items := []any{}
+		for _, k := range d.kvsKeys(params) {
+			items = append(items, map[string]any{"key": k, "etag": etag(k, d.kvs[k]), "value": d.kvs[k]})
+		}
+		return map[string]any{"items": items, "offset": 0.0, "total": float64(len(items))}, nil
+	case "list":
+		keys := make(map[string]any)
+		for _, k := range d.kvsKeys(params) {
+			keys[k] = map[string]any{"etag": etag(k, d.kvs[k])}
+		}
+		return map[string]any{"keys": keys, "rev": float64(d.kvsRev)}, nil
+	}
+	return nil, errNoHandler(method)
+}
+
+// kvsKeys returns the sorted keys matching the match param, where * is a
+// wildcard.
+func (d *device) kvsKeys(params map[string]any) []string {
+	pattern := "*"
+	if m, ok := params["match"].(string); ok && m != "" {
+		pattern = m
+	}
+	re := regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$")
+	var keys []string
+	for k := range d.kvs {
+		if re.MatchString(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func etag(key string, v any) string {
+	h := fnv.New32a()
+	h.Write([]byte(key + "=" + compact(v)))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// normalize converts YAML and Go values to what JSON decoding gives:
+// float64 numbers, map[string]any and []any.
+func normalize(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// compact formats a value as single-line JSON.
+func compact(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
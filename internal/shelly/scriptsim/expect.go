@@ -0,0 +1,134 @@
+package scriptsim
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// check compares what the script did against a step's expectations and
+// returns a message for each one that does not hold.
+func check(exp *Expect, rec Record, dev *device) []string {
+	var failures []string
+	failures = append(failures, checkCalls(exp, rec.Calls)...)
+	for _, want := range exp.Prints {
+		if !containsFunc(rec.Prints, func(line string) bool { return strings.Contains(line, want) }) {
+			failures = append(failures, fmt.Sprintf("expected a print containing %q, got %s", want, list(rec.Prints)))
+		}
+	}
+	for _, want := range exp.MQTT {
+		if !containsFunc(rec.Published, func(m Message) bool {
+			return m.Topic == want.Topic && (want.Message == "" || m.Message == want.Message)
+		}) {
+			failures = append(failures, fmt.Sprintf("expected MQTT publish to %s, got %s", describeMessage(want), list(rec.Published)))
+		}
+	}
+	for _, want := range exp.Events {
+		if !containsFunc(rec.Events, func(name string) bool { return name == want }) {
+			failures = append(failures, fmt.Sprintf("expected event %q, got %s", want, list(rec.Events)))
+		}
+	}
+	for _, key := range sortedKeys(exp.State) {
+		if got, ok := dev.status[key]; !ok || !subset(normalize(exp.State[key]), got) {
+			failures = append(failures, fmt.Sprintf("expected %s to match %s, got %s", key, compact(exp.State[key]), compact(got)))
+		}
+	}
+	for _, key := range sortedKeys(exp.KVS) {
+		if got, ok := dev.kvs[key]; !ok || !subset(normalize(exp.KVS[key]), got) {
+			failures = append(failures, fmt.Sprintf("expected KVS %s to be %s, got %s", key, compact(exp.KVS[key]), compact(got)))
+		}
+	}
+	return failures
+}
+
+// checkCalls requires the expected calls to appear in order, allowing
+// other calls in between.
+func checkCalls(exp *Expect, calls []Call) []string {
+	if exp.NoCalls && len(calls) > 0 {
+		return []string{"expected no calls, got " + list(calls)}
+	}
+	next := 0
+	for _, want := range exp.Calls {
+		found := false
+		for next < len(calls) {
+			got := calls[next]
+			next++
+			if strings.EqualFold(got.Method, want.Method) && subset(normalize(want.Params), got.Params) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return []string{fmt.Sprintf("expected call %s, got %s", want, list(calls))}
+		}
+	}
+	return nil
+}
+
+// subset reports whether want matches got, where maps in want only need
+// a subset of the keys in got.
+func subset(want, got any) bool {
+	switch w := want.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range w {
+			gv, ok := g[k]
+			if !ok || !subset(v, gv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			return false
+		}
+		for i := range w {
+			if !subset(w[i], g[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(want, got)
+}
+
+func containsFunc[T any](items []T, match func(T) bool) bool {
+	for _, item := range items {
+		if match(item) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeMessage(m Message) string {
+	if m.Message == "" {
+		return m.Topic
+	}
+	return fmt.Sprintf("%s %q", m.Topic, m.Message)
+}
+
+// list formats what was recorded for a failure message.
+func list[T any](items []T) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		switch v := any(item).(type) {
+		case Message:
+			parts[i] = describeMessage(v)
+		case string:
+			parts[i] = fmt.Sprintf("%q", v)
+		default:
+			parts[i] = fmt.Sprint(v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,301 @@
+package scriptsim
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+const autoOffScript = `
+let presses = 0;
+Shelly.call("KVS.Get", {key: "presses"}, function (res, code) {
+  if (code === 0) presses = res.value;
+});
+
+Shelly.addEventHandler(function (ev) {
+  if (ev.component === "input:0" && ev.info.event === "single_push") {
+    presses++;
+    Shelly.call("Switch.Toggle", {id: 0});
+    Shelly.call("KVS.Set", {key: "presses", value: presses});
+  }
+});
+
+Shelly.addStatusHandler(function (ev) {
+  if (ev.component !== "switch:0") return;
+  if (ev.delta.output === true) {
+    Timer.set(60000, false, function () {
+      Shelly.call("Switch.Set", {id: 0, on: false});
+    });
+  }
+  if (ev.delta.apower > 1000) {
+    print("overload", ev.delta.apower);
+    MQTT.publish("home/alert", JSON.stringify({power: ev.delta.apower}));
+    Shelly.emitEvent("overload", {power: ev.delta.apower});
+  }
+});
+
+MQTT.subscribe("home/+/cmd", function (topic, message) {
+  Shelly.call("Switch.Set", {id: 0, on: message === "on"});
+});
+`
+
+const autoOffSpec = `
+name: auto off
+script: main.js
+state:
+  "switch:0": {output: false, apower: 0}
+kvs:
+  presses: 2
+steps:
+  - name: startup
+    expect:
+      calls:
+        - method: KVS.Get
+  - name: press
+    event: input:0 single_push
+    expect:
+      calls:
+        - method: Switch.Toggle
+          params: {id: 0}
+        - method: KVS.Set
+          params: {key: presses, value: 3}
+      state:
+        "switch:0": {output: true}
+      kvs: {presses: 3}
+  - name: not yet off
+    wait: 59s
+    expect:
+      no_calls: true
+  - name: auto off
+    wait: 1s
+    expect:
+      calls:
+        - method: Switch.Set
+          params: {id: 0, on: false}
+      state:
+        "switch:0": {output: false}
+  - name: overload
+    status: "switch:0"
+    delta: {apower: 1500}
+    expect:
+      prints: [overload 1500]
+      mqtt:
+        - topic: home/alert
+          message: '{"power":1500}'
+      events: [overload]
+  - name: remote command
+    mqtt: {topic: home/kitchen/cmd, message: "on"}
+    expect:
+      calls:
+        - method: Switch.Set
+          params: {on: true}
+`
+
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	config.SetFs(fs)
+	t.Cleanup(func() { config.SetFs(nil) })
+	for name, content := range files {
+		if err := afero.WriteFile(fs, name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func runSpec(t *testing.T, path string) *Result {
+	t.Helper()
+	spec, err := LoadSpec(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Run(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestRun(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"/tests/main.js":            autoOffScript,
+		"/tests/auto_off.test.yaml": autoOffSpec,
+	})
+
+	res := runSpec(t, "/tests/auto_off.test.yaml")
+	if !res.Passed() {
+		t.Fatalf("failures:\n%s", strings.Join(res.Failures, "\n"))
+	}
+	if res.Name != "auto off" {
+		t.Errorf("Name = %q", res.Name)
+	}
+	if len(res.Output) != 1 || res.Output[0] != "overload 1500" {
+		t.Errorf("Output = %q", res.Output)
+	}
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestRun_Failures(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"/tests/main.js": autoOffScript,
+		"/tests/wrong.yaml": `
+script: main.js
+state:
+  "switch:0": {output: true}
+steps:
+  - event: input:0 single_push
+    expect:
+      calls:
+        - method: Switch.Set
+      state:
+        "switch:0": {output: true}
+      prints: [hello]
+`,
+		"/tests/crash.js": `Shelly.addEventHandler(function (ev) { ev.info.missing.field = 1; });`,
+		"/tests/crash.yaml": `
+script: crash.js
+steps:
+  - event: input:0 single_push
+  - name: never reached
+    expect:
+      no_calls: true
+`,
+	})
+
+	res := runSpec(t, "/tests/wrong.yaml")
+	if res.Name != "wrong" {
+		t.Errorf("Name = %q, want the file name", res.Name)
+	}
+	want := []string{
+		"step 1: expected call Switch.Set, got KVS.Get {\"key\":\"presses\"}, Switch.Toggle {\"id\":0}, KVS.Set {\"key\":\"presses\",\"value\":1}",
+		"step 1: expected a print containing \"hello\", got none",
+		"step 1: expected switch:0 to match {\"output\":true}, got {\"output\":false}",
+	}
+	if strings.Join(res.Failures, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Failures =\n%s\nwant\n%s", strings.Join(res.Failures, "\n"), strings.Join(want, "\n"))
+	}
+
+	res = runSpec(t, "/tests/crash.yaml")
+	if len(res.Failures) != 1 || !strings.Contains(res.Failures[0], "step 1: script error: crash.js:1:") ||
+		!strings.Contains(res.Failures[0], "Cannot set properties of undefined") {
+		t.Errorf("Failures = %q", res.Failures)
+	}
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestRun_Fixtures(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"/tests/fixtures.yaml": `
+config:
+  devices:
+    - name: old-relay
+      model: SHSW-1
+      generation: 1
+    - name: kitchen
+      address: 192.168.1.20
+      mac: "AA:BB:CC:DD:EE:FF"
+      model: SNSW-001P16EU
+      type: Plus1PM
+      generation: 2
+device_states:
+  kitchen:
+    "switch:0":
+      output: true
+      apower: 42.5
+`,
+		"/tests/main.js": `
+let info = Shelly.getDeviceInfo();
+let sw = Shelly.getComponentStatus("switch", 0);
+print(info.name, info.mac, sw.apower, Shelly.getComponentStatus("switch:1"));
+Shelly.call("Switch.GetStatus", {id: 1}, function (res, code, msg) { print(code, msg); });
+Shelly.call("HTTP.GET", {url: "http://example.com"}, function (res) { print(res.code, res.body); });
+`,
+		"/tests/main.test.yaml": `
+script: main.js
+fixtures: fixtures.yaml
+responses:
+  HTTP.GET:
+    result: {code: 200, body: ok}
+steps:
+  - expect:
+      prints:
+        - kitchen AABBCCDDEEFF 42.5 null
+        - "-105 Argument 'id', value 1 not found!"
+        - 200 ok
+`,
+	})
+
+	res := runSpec(t, "/tests/main.test.yaml")
+	if !res.Passed() {
+		t.Errorf("failures:\n%s", strings.Join(res.Failures, "\n"))
+	}
+}
+
+//nolint:paralleltest // Uses the global filesystem
+func TestLoadSpec_Invalid(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"/no_script.yaml":   "steps: []\n",
+		"/two_actions.yaml": "script: a.js\nsteps:\n  - event: input:0 single_push\n    wait: 1s\n",
+		"/bad_event.yaml":   "script: a.js\nsteps:\n  - event: single_push\n",
+		"/bad_wait.yaml":    "script: a.js\nsteps:\n  - wait: soon\n",
+	})
+
+	for name, want := range map[string]string{
+		"/no_script.yaml":   "no script given",
+		"/two_actions.yaml": "only one of event, status, mqtt and wait",
+		"/bad_event.yaml":   "invalid event",
+		"/bad_wait.yaml":    "invalid wait",
+		"/missing.yaml":     "failed to read test spec",
+	} {
+		if _, err := LoadSpec(name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadSpec(%s) error = %v, want containing %q", name, err, want)
+		}
+	}
+}
+
+func TestTopicMatches(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter, topic string
+		want          bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/+/c", "a/x/c", true},
+		{"a/+/c", "a/x/y", false},
+		{"a/#", "a/x/y", true},
+		{"a/b", "a/b/c", false},
+		{"a/b/c", "a/b", false},
+	}
+	for _, tt := range tests {
+		if got := topicMatches(tt.filter, tt.topic); got != tt.want {
+			t.Errorf("topicMatches(%q, %q) = %v, want %v", tt.filter, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestSubset(t *testing.T) {
+	t.Parallel()
+
+	got := normalize(map[string]any{"id": 0, "on": true, "tags": []any{"a", "b"}})
+	tests := []struct {
+		want any
+		ok   bool
+	}{
+		{map[string]any{"on": true}, true},
+		{map[string]any{"id": 0}, true},
+		{map[string]any{"tags": []any{"a", "b"}}, true},
+		{map[string]any{"tags": []any{"a"}}, false},
+		{map[string]any{"on": false}, false},
+		{map[string]any{"missing": 1}, false},
+	}
+	for _, tt := range tests {
+		if subset(normalize(tt.want), got) != tt.ok {
+			t.Errorf("subset(%v) = %v, want %v", tt.want, !tt.ok, tt.ok)
+		}
+	}
+}
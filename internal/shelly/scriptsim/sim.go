@@ -0,0 +1,326 @@
+package scriptsim
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptbundle"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptjs"
+)
+
+// scriptID is the id the simulated script runs under.
+const scriptID = 1
+
+// maxTasks bounds the callbacks run for one step, to stop handlers that
+// keep re-triggering each other.
+const maxTasks = 10000
+
+// epoch is the simulated time when the script starts.
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Result is the outcome of a script test.
+type Result struct {
+	Name     string
+	Failures []string
+	// Output is everything the script printed.
+	Output []string
+}
+
+// Passed reports whether every expectation held.
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+func (r *Result) fail(format string, args ...any) {
+	r.Failures = append(r.Failures, fmt.Sprintf(format, args...))
+}
+
+// Record is what the script did between two checks.
+type Record struct {
+	Calls     []Call
+	Prints    []string
+	Published []Message
+	Events    []string
+}
+
+type handler struct {
+	id int
+	fn scriptjs.Value
+	ud scriptjs.Value
+}
+
+type timer struct {
+	id     int
+	due    time.Time
+	period time.Duration
+	repeat bool
+	fn     scriptjs.Value
+	ud     scriptjs.Value
+}
+
+type subscription struct {
+	topic string
+	fn    scriptjs.Value
+	ud    scriptjs.Value
+}
+
+// simulator runs one script against a simulated device.
+type simulator struct {
+	rt     *scriptjs.Runtime
+	dev    *device
+	now    time.Time
+	queue  []func() error
+	timers []*timer
+	nextID int
+
+	eventHandlers  []handler
+	statusHandlers []handler
+	subscriptions  []subscription
+
+	rec    Record
+	output []string
+}
+
+// Run runs a script test. Setup problems such as a missing script are
+// returned as errors; script errors and unmet expectations are failures.
+func Run(spec *Spec) (*Result, error) {
+	bundle, err := scriptbundle.Bundle(spec.path(spec.Script), scriptbundle.Options{})
+	if err != nil {
+		return nil, err
+	}
+	dev, err := newDevice(spec)
+	if err != nil {
+		return nil, err
+	}
+	s := newSimulator(dev)
+	res := &Result{Name: spec.Name}
+	defer func() { res.Output = s.output }()
+
+	if err := s.load(filepath.Base(spec.Script), bundle.Code); err != nil {
+		res.fail("script error: %v", err)
+		return res, nil
+	}
+	for i := range spec.Steps {
+		st := &spec.Steps[i]
+		if err := s.step(st); err != nil {
+			res.fail("%s: script error: %v", st.label(i), err)
+			return res, nil
+		}
+		if st.Expect == nil {
+			continue
+		}
+		for _, f := range check(st.Expect, s.take(), dev) {
+			res.fail("%s: %s", st.label(i), f)
+		}
+	}
+	return res, nil
+}
+
+func newSimulator(dev *device) *simulator {
+	s := &simulator{rt: scriptjs.New(), dev: dev, now: epoch}
+	s.rt.Now = func() time.Time { return s.now }
+	dev.changed = s.statusChanged
+	s.install()
+	return s
+}
+
+// load runs the script's top level and the callbacks it starts.
+func (s *simulator) load(name, code string) error {
+	if err := s.rt.Run(name, code); err != nil {
+		return err
+	}
+	return s.drain()
+}
+
+// take returns and clears the record since the previous check.
+func (s *simulator) take() Record {
+	rec := s.rec
+	s.rec = Record{}
+	return rec
+}
+
+func (s *simulator) step(st *Step) error {
+	switch {
+	case st.Event != "":
+		component, event, err := parseEvent(st.Event)
+		if err != nil {
+			return err
+		}
+		info := map[string]any{"component": component, "id": componentID(component), "event": event, "ts": s.unix()}
+		for k, v := range st.Data {
+			info[k] = v
+		}
+		s.dispatchEvent(component, info)
+	case st.Status != "":
+		s.dev.update(st.Status, st.Delta)
+	case st.MQTT != nil:
+		s.deliver(st.MQTT.Topic, st.MQTT.Message)
+	case st.Wait != "":
+		d, err := time.ParseDuration(st.Wait)
+		if err != nil {
+			return err
+		}
+		return s.advance(d)
+	}
+	return s.drain()
+}
+
+// Event loop.
+
+// later queues a callback to run after the current one.
+func (s *simulator) later(fn scriptjs.Value, args ...scriptjs.Value) {
+	s.queue = append(s.queue, func() error {
+		_, err := s.rt.Call(fn, scriptjs.Undefined, args...)
+		return err
+	})
+}
+
+// drain runs queued callbacks and due timers until none are left.
+func (s *simulator) drain() error {
+	for n := 0; ; n++ {
+		if n > maxTasks {
+			return errors.New("too many callbacks; do handlers keep triggering each other?")
+		}
+		if len(s.queue) > 0 {
+			task := s.queue[0]
+			s.queue = s.queue[1:]
+			if err := task(); err != nil {
+				return err
+			}
+			continue
+		}
+		t := s.nextTimer(s.now)
+		if t == nil {
+			return nil
+		}
+		s.fire(t)
+	}
+}
+
+// advance moves the clock forward, firing timers as they come due.
+func (s *simulator) advance(d time.Duration) error {
+	end := s.now.Add(d)
+	for {
+		if err := s.drain(); err != nil {
+			return err
+		}
+		t := s.nextTimer(end)
+		if t == nil {
+			break
+		}
+		s.now = t.due
+		s.fire(t)
+	}
+	s.now = end
+	return s.drain()
+}
+
+func (s *simulator) nextTimer(limit time.Time) *timer {
+	var next *timer
+	for _, t := range s.timers {
+		if !t.due.After(limit) && (next == nil || t.due.Before(next.due)) {
+			next = t
+		}
+	}
+	return next
+}
+
+func (s *simulator) fire(t *timer) {
+	if t.repeat {
+		t.due = t.due.Add(t.period)
+	} else {
+		s.clearTimer(t.id)
+	}
+	s.later(t.fn, t.ud)
+}
+
+func (s *simulator) clearTimer(id int) bool {
+	for i, t := range s.timers {
+		if t.id == id {
+			s.timers = append(s.timers[:i], s.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *simulator) id() int {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *simulator) unix() float64 {
+	return float64(s.now.UnixMilli()) / 1000
+}
+
+// Notifications.
+
+func (s *simulator) dispatchEvent(component string, info map[string]any) {
+	name, _, _ := strings.Cut(component, ":")
+	ev := scriptjs.FromGo(map[string]any{
+		"component": component, "name": name, "id": componentID(component), "now": s.unix(), "info": info,
+	})
+	for _, h := range s.eventHandlers {
+		s.later(h.fn, ev, h.ud)
+	}
+}
+
+func (s *simulator) statusChanged(component string, delta map[string]any) {
+	name, _, _ := strings.Cut(component, ":")
+	ev := scriptjs.FromGo(map[string]any{
+		"component": component, "name": name, "id": componentID(component), "delta": delta,
+	})
+	for _, h := range s.statusHandlers {
+		s.later(h.fn, ev, h.ud)
+	}
+}
+
+func (s *simulator) deliver(topic, message string) {
+	for _, sub := range s.subscriptions {
+		if topicMatches(sub.topic, topic) {
+			s.later(sub.fn, topic, message, sub.ud)
+		}
+	}
+}
+
+// topicMatches matches an MQTT topic against a filter with + and #
+// wildcards.
+func topicMatches(filter, topic string) bool {
+	f, t := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i, part := range f {
+		if part == "#" {
+			return true
+		}
+		if i >= len(t) || part != "+" && part != t[i] {
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
+
+// componentID returns the numeric id of a key like input:0, or nil.
+func componentID(key string) any {
+	_, id, ok := strings.Cut(key, ":")
+	if !ok {
+		return nil
+	}
+	var n int
+	if _, err := fmt.Sscan(id, &n); err != nil {
+		return id
+	}
+	return n
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,177 @@
+// Package scriptsim runs device scripts offline against a simulated device,
+// so scripts can be unit tested without hardware.
+//
+// A test spec names a script, the device state it starts from (optionally
+// taken from demo-mode fixtures) and a list of steps. Steps fire synthetic
+// input events, status changes, MQTT messages or let simulated time pass,
+// and check the RPC calls, prints, MQTT publishes, emitted events and
+// state the script produced since the previous check.
+package scriptsim
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+// Spec describes a script test.
+type Spec struct {
+	// Name defaults to the spec file name without .test.yaml.
+	Name   string `yaml:"name"`
+	Script string `yaml:"script"`
+	// Fixtures is a demo-mode fixtures file to take the device from.
+	Fixtures string `yaml:"fixtures,omitempty"`
+	// Device names the fixtures device; defaults to the first Gen2+ one.
+	Device string `yaml:"device,omitempty"`
+	// State is component status merged over the fixtures state.
+	State  map[string]map[string]any `yaml:"state,omitempty"`
+	Config map[string]map[string]any `yaml:"config,omitempty"`
+	KVS    map[string]any            `yaml:"kvs,omitempty"`
+	// Responses are canned results for RPC methods, such as HTTP.GET.
+	Responses map[string]Response `yaml:"responses,omitempty"`
+	Steps     []Step              `yaml:"steps"`
+
+	dir string
+}
+
+// Response is a canned RPC result or error.
+type Response struct {
+	Result  any    `yaml:"result,omitempty"`
+	Code    int    `yaml:"code,omitempty"`
+	Message string `yaml:"message,omitempty"`
+}
+
+// Step is one action and the expectations after it.
+type Step struct {
+	Name string `yaml:"name,omitempty"`
+	// Event fires a component event, e.g. "input:0 single_push".
+	Event string `yaml:"event,omitempty"`
+	// Data adds fields to the event info.
+	Data map[string]any `yaml:"data,omitempty"`
+	// Status changes a component's status, e.g. "switch:0" with a delta.
+	Status string         `yaml:"status,omitempty"`
+	Delta  map[string]any `yaml:"delta,omitempty"`
+	// MQTT delivers a message to the script's subscriptions.
+	MQTT *Message `yaml:"mqtt,omitempty"`
+	// Wait advances simulated time, running due timers.
+	Wait   string  `yaml:"wait,omitempty"`
+	Expect *Expect `yaml:"expect,omitempty"`
+}
+
+// Message is an MQTT message.
+type Message struct {
+	Topic   string `yaml:"topic"`
+	Message string `yaml:"message,omitempty"`
+}
+
+// Expect lists what must have happened since the previous step with
+// expectations.
+type Expect struct {
+	// Calls must appear in this order among the RPC calls made.
+	Calls []Call `yaml:"calls,omitempty"`
+	// NoCalls requires that no RPC calls were made.
+	NoCalls bool `yaml:"no_calls,omitempty"`
+	// Prints are substrings of printed lines.
+	Prints []string  `yaml:"prints,omitempty"`
+	MQTT   []Message `yaml:"mqtt,omitempty"`
+	// Events are the names of events emitted with Shelly.emitEvent.
+	Events []string `yaml:"events,omitempty"`
+	// State and KVS are checked against the device as it is now.
+	State map[string]map[string]any `yaml:"state,omitempty"`
+	KVS   map[string]any            `yaml:"kvs,omitempty"`
+}
+
+// Call is an RPC call made by the script. Expected params only need to
+// be a subset of the actual ones.
+type Call struct {
+	Method string         `yaml:"method"`
+	Params map[string]any `yaml:"params,omitempty"`
+}
+
+func (c Call) String() string {
+	if len(c.Params) == 0 {
+		return c.Method
+	}
+	return c.Method + " " + compact(c.Params)
+}
+
+// LoadSpec reads a test spec. The script and fixtures paths are relative
+// to the spec file.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := afero.ReadFile(config.Fs(), path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test spec: %w", err)
+	}
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse test spec %s: %w", path, err)
+	}
+	if spec.Name == "" {
+		spec.Name = strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), ".test")
+	}
+	spec.dir = filepath.Dir(path)
+	if err := spec.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &spec, nil
+}
+
+func (s *Spec) validate() error {
+	if s.Script == "" {
+		return errors.New("no script given")
+	}
+	for i, st := range s.Steps {
+		actions := 0
+		for _, set := range []bool{st.Event != "", st.Status != "", st.MQTT != nil, st.Wait != ""} {
+			if set {
+				actions++
+			}
+		}
+		if actions > 1 {
+			return fmt.Errorf("%s: only one of event, status, mqtt and wait may be set", st.label(i))
+		}
+		if st.Wait != "" {
+			if _, err := time.ParseDuration(st.Wait); err != nil {
+				return fmt.Errorf("%s: invalid wait: %w", st.label(i), err)
+			}
+		}
+		if st.Event != "" {
+			if _, _, err := parseEvent(st.Event); err != nil {
+				return fmt.Errorf("%s: %w", st.label(i), err)
+			}
+		}
+	}
+	return nil
+}
+
+// path resolves a path relative to the spec file.
+func (s *Spec) path(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(s.dir, p)
+}
+
+// label names a step in messages.
+func (st *Step) label(i int) string {
+	if st.Name != "" {
+		return fmt.Sprintf("step %d (%s)", i+1, st.Name)
+	}
+	return fmt.Sprintf("step %d", i+1)
+}
+
+// parseEvent splits "input:0 single_push" into component and event.
+func parseEvent(s string) (component, event string, err error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 || !strings.Contains(fields[0], ":") {
+		return "", "", fmt.Errorf("invalid event %q: want \"<component>:<id> <event>\"", s)
+	}
+	return fields[0], fields[1], nil
+}
@@ -664,6 +664,11 @@ func (s *Service) CheckDevicesForUpdates(ctx context.Context, ios *iostreams.IOS
 	return s.firmwareService.CheckDevicesForUpdates(ctx, ios, devices, staged)
 }
 
+// CheckDevicesForStage delegates to the firmware service.
+func (s *Service) CheckDevicesForStage(ctx context.Context, ios *iostreams.IOStreams, devices []string, staged int, beta bool) []DeviceUpdateStatus {
+	return s.firmwareService.CheckDevicesForStage(ctx, ios, devices, staged, beta)
+}
+
 // UpdateDevices delegates to the firmware service.
 func (s *Service) UpdateDevices(ctx context.Context, ios *iostreams.IOStreams, devices []DeviceUpdateStatus, opts UpdateOpts) []UpdateResult {
 	return s.firmwareService.UpdateDevices(ctx, ios, devices, opts)
@@ -0,0 +1,15 @@
+package shelly
+
+import (
+	"context"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/model"
+)
+
+// SolarAnalysis analyzes the history of a grid meter over [from, to),
+// paired with a PV meter when pv is set: daily import/export,
+// self-consumption, autarky, peak demand and phase imbalance.
+func (s *Service) SolarAnalysis(ctx context.Context, grid string, gridID int, pv string, pvID int, from, to time.Time) (model.SolarReport, error) {
+	return s.Monitoring().SolarAnalysis(ctx, grid, gridID, pv, pvID, from, to)
+}
@@ -0,0 +1,181 @@
+// Package solar analyzes grid and PV meter history: daily import and export,
+// self-consumption, autarky, peak demand and phase imbalance.
+package solar
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/model"
+)
+
+// DemandWindow is the averaging window for peak demand, matching the
+// 15-minute demand interval most utilities bill on.
+const DemandWindow = 15 * time.Minute
+
+// minPhaseLoad is the mean phase load below which imbalance is not sampled;
+// at near-idle loads a few watts skew the ratio.
+const minPhaseLoad = 100.0 // W
+
+// Interval is the energy metered over one history interval.
+type Interval struct {
+	Start  time.Time
+	Period time.Duration
+	Import float64   // Wh drawn from the grid
+	Export float64   // Wh fed to the grid
+	Phases []float64 // Average active power per phase in W; nil for single-phase meters
+}
+
+// Analyze computes the per-day and total analysis of grid meter intervals,
+// with days split in loc. PV intervals are matched to grid intervals by
+// start time; PV production is whichever direction the PV meter measured
+// most energy in, so a reversed clamp needs no configuration. Without PV
+// intervals only the grid-side figures are filled in.
+func Analyze(grid, pv []Interval, loc *time.Location) (days []model.SolarDay, total model.SolarSummary) {
+	production := productionByStart(pv)
+	hasPV := len(pv) > 0
+
+	byDay := make(map[string]*accumulator)
+	all := newAccumulator()
+	for _, iv := range grid {
+		date := iv.Start.In(loc).Format(time.DateOnly)
+		acc, ok := byDay[date]
+		if !ok {
+			acc = newAccumulator()
+			byDay[date] = acc
+		}
+		prod := production[iv.Start.Unix()]
+		acc.add(iv, prod)
+		all.add(iv, prod)
+	}
+
+	days = make([]model.SolarDay, 0, len(byDay))
+	for date, acc := range byDay {
+		days = append(days, model.SolarDay{Date: date, SolarSummary: acc.summary(hasPV)})
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+
+	return days, all.summary(hasPV)
+}
+
+// productionByStart maps each PV interval start to the energy produced.
+func productionByStart(pv []Interval) map[int64]float64 {
+	var imported, exported float64
+	for _, iv := range pv {
+		imported += iv.Import
+		exported += iv.Export
+	}
+	useExport := exported > imported
+
+	production := make(map[int64]float64, len(pv))
+	for _, iv := range pv {
+		if useExport {
+			production[iv.Start.Unix()] += iv.Export
+		} else {
+			production[iv.Start.Unix()] += iv.Import
+		}
+	}
+	return production
+}
+
+// demandWindow is the import metered within one demand window.
+type demandWindow struct {
+	energy float64 // Wh
+	length time.Duration
+}
+
+// accumulator sums intervals into a summary.
+type accumulator struct {
+	imported, exported, produced, selfConsumed float64 // Wh
+	windows                                    map[time.Time]*demandWindow
+	imbalanceSum                               float64
+	imbalanceCount                             int
+}
+
+func newAccumulator() *accumulator {
+	return &accumulator{windows: make(map[time.Time]*demandWindow)}
+}
+
+// add accumulates a grid interval and the PV production over it. Production
+// not exported was consumed on site.
+func (a *accumulator) add(iv Interval, production float64) {
+	a.imported += iv.Import
+	a.exported += iv.Export
+	a.produced += production
+	a.selfConsumed += max(production-iv.Export, 0)
+
+	key := iv.Start.Truncate(DemandWindow)
+	w, ok := a.windows[key]
+	if !ok {
+		w = &demandWindow{}
+		a.windows[key] = w
+	}
+	w.energy += iv.Import
+	w.length = max(w.length, iv.Period, DemandWindow)
+
+	if imbalance, ok := phaseImbalance(iv.Phases); ok {
+		a.imbalanceSum += imbalance
+		a.imbalanceCount++
+	}
+}
+
+// summary converts the sums to kWh and derives the ratios.
+func (a *accumulator) summary(hasPV bool) model.SolarSummary {
+	s := model.SolarSummary{
+		ImportKWh: a.imported / 1000,
+		ExportKWh: a.exported / 1000,
+	}
+
+	for start, w := range a.windows {
+		demand := w.energy / w.length.Hours()
+		if demand > s.PeakDemand || (demand == s.PeakDemand && start.Before(s.PeakDemandAt)) {
+			s.PeakDemand = demand
+			s.PeakDemandAt = start
+		}
+	}
+
+	if a.imbalanceCount > 0 {
+		imbalance := a.imbalanceSum / float64(a.imbalanceCount)
+		s.PhaseImbalance = &imbalance
+	}
+
+	if !hasPV {
+		return s
+	}
+	consumed := a.imported + a.selfConsumed
+	s.ProductionKWh = a.produced / 1000
+	s.SelfConsumedKWh = a.selfConsumed / 1000
+	s.ConsumptionKWh = consumed / 1000
+	if a.produced > 0 {
+		ratio := a.selfConsumed / a.produced * 100
+		s.SelfConsumption = &ratio
+	}
+	if consumed > 0 {
+		autarky := a.selfConsumed / consumed * 100
+		s.Autarky = &autarky
+	}
+	return s
+}
+
+// phaseImbalance returns the largest deviation of a phase load from the
+// mean, as a percentage of the mean (the NEMA definition). ok is false for
+// single-phase readings and near-idle loads.
+func phaseImbalance(phases []float64) (imbalance float64, ok bool) {
+	if len(phases) < 2 {
+		return 0, false
+	}
+	var sum float64
+	for _, p := range phases {
+		sum += math.Abs(p)
+	}
+	mean := sum / float64(len(phases))
+	if mean < minPhaseLoad {
+		return 0, false
+	}
+	var deviation float64
+	for _, p := range phases {
+		deviation = max(deviation, math.Abs(math.Abs(p)-mean))
+	}
+	return deviation / mean * 100, true
+}
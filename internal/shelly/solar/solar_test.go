@@ -0,0 +1,118 @@
+package solar
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestAnalyze_WithPV(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	hour := time.Hour
+	grid := []Interval{
+		{Start: day.Add(2 * hour), Period: hour, Import: 1000},                   // night: all from grid
+		{Start: day.Add(12 * hour), Period: hour, Import: 0, Export: 2000},       // noon: surplus exported
+		{Start: day.AddDate(0, 0, 1).Add(12 * hour), Period: hour, Import: 500},  // cloudy next day
+		{Start: day.AddDate(0, 0, 1).Add(20 * hour), Period: hour, Import: 1500}, // evening
+		{Start: day.AddDate(0, 0, 1).Add(21 * hour), Period: hour, Import: 0},    // idle
+		{Start: day.AddDate(0, 0, 1).Add(23 * hour), Period: hour, Import: 100},  // no PV reading
+	}
+	// A reversed PV clamp reads production as export
+	pv := []Interval{
+		{Start: day.Add(12 * hour), Period: hour, Export: 3000},
+		{Start: day.AddDate(0, 0, 1).Add(12 * hour), Period: hour, Export: 1000, Import: 5},
+	}
+
+	days, total := Analyze(grid, pv, time.UTC)
+	if len(days) != 2 || days[0].Date != "2026-06-01" || days[1].Date != "2026-06-02" {
+		t.Fatalf("days = %+v", days)
+	}
+
+	first := days[0]
+	if !approx(first.ImportKWh, 1) || !approx(first.ExportKWh, 2) || !approx(first.ProductionKWh, 3) {
+		t.Errorf("day 1 balance = %+v", first.SolarSummary)
+	}
+	if !approx(first.SelfConsumedKWh, 1) || !approx(first.ConsumptionKWh, 2) {
+		t.Errorf("day 1 self-consumed = %v, consumption = %v; want 1, 2", first.SelfConsumedKWh, first.ConsumptionKWh)
+	}
+	if first.SelfConsumption == nil || !approx(*first.SelfConsumption, 100.0/3) {
+		t.Errorf("day 1 self-consumption = %v, want 33.3%%", first.SelfConsumption)
+	}
+	if first.Autarky == nil || !approx(*first.Autarky, 50) {
+		t.Errorf("day 1 autarky = %v, want 50%%", first.Autarky)
+	}
+
+	// Totals: produced 4 kWh, exported 2, so 2 self-consumed of 5.1 consumed
+	if !approx(total.ProductionKWh, 4) || !approx(total.SelfConsumedKWh, 2) || !approx(total.ConsumptionKWh, 5.1) {
+		t.Errorf("total = %+v", total)
+	}
+	if total.Autarky == nil || !approx(*total.Autarky, 2/5.1*100) {
+		t.Errorf("total autarky = %v", total.Autarky)
+	}
+	if !approx(total.PeakDemand, 1500) || !total.PeakDemandAt.Equal(day.AddDate(0, 0, 1).Add(20*hour)) {
+		t.Errorf("peak demand = %v at %v, want 1500 W at day 2 20:00", total.PeakDemand, total.PeakDemandAt)
+	}
+}
+
+func TestAnalyze_GridOnly(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	minute := time.Minute
+	var grid []Interval
+	// 15 one-minute intervals at 2 kW, then 15 at 4 kW split unevenly
+	for i := range 30 {
+		iv := Interval{Start: start.Add(time.Duration(i) * minute), Period: minute, Import: 2000.0 / 60}
+		iv.Phases = []float64{700, 700, 600}
+		if i >= 15 {
+			iv.Import = 4000.0 / 60
+			iv.Phases = []float64{2000, 1000, 1000}
+		}
+		grid = append(grid, iv)
+	}
+
+	days, total := Analyze(grid, nil, time.UTC)
+	if len(days) != 1 {
+		t.Fatalf("len(days) = %d, want 1", len(days))
+	}
+	if total.SelfConsumption != nil || total.Autarky != nil || total.ProductionKWh != 0 {
+		t.Errorf("PV figures without a PV meter: %+v", total)
+	}
+	if !approx(total.PeakDemand, 4000) || !total.PeakDemandAt.Equal(start.Add(15*minute)) {
+		t.Errorf("peak demand = %v at %v, want 4000 W at 10:15", total.PeakDemand, total.PeakDemandAt)
+	}
+	// 10% (600 W against a 666.7 W mean) for the first half, 50% for the second
+	if total.PhaseImbalance == nil || !approx(*total.PhaseImbalance, (10+50)/2.0) {
+		t.Errorf("phase imbalance = %v, want 30%%", total.PhaseImbalance)
+	}
+}
+
+func TestPhaseImbalance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		phases []float64
+		want   float64
+		ok     bool
+	}{
+		{"balanced", []float64{1000, 1000, 1000}, 0, true},
+		{"one heavy phase", []float64{2000, 1000, 1000}, 50, true},
+		{"exporting phase", []float64{-1000, 1000, 1000}, 0, true},
+		{"single phase", []float64{1000}, 0, false},
+		{"idle", []float64{50, 10, 0}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := phaseImbalance(tt.phases)
+			if ok != tt.ok || !approx(got, tt.want) {
+				t.Errorf("phaseImbalance(%v) = %v, %v; want %v, %v", tt.phases, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -96,7 +96,11 @@ func (s *Service) PullDeviceConfigs(ctx context.Context, devices []string, syncD
 				return
 			}
 
-			if err := config.SaveSyncConfig(syncDir, d, result.Config); err != nil {
+			err := config.SaveSyncConfig(syncDir, d, result.Config)
+			if err == nil {
+				err = config.SaveSyncBase(syncDir, d, result.Config)
+			}
+			if err != nil {
 				progress(SyncDeviceResult{Device: d, Status: fmt.Sprintf("failed (%v)", err), Err: err})
 				failedCount.Add(1)
 				return
@@ -183,6 +187,9 @@ func (s *Service) PushDeviceConfigs(ctx context.Context, syncDir string, deviceF
 				return
 			}
 
+			if baseErr := config.SaveSyncBase(syncDir, it.deviceName, configData); baseErr != nil {
+				iostreams.DebugErr("saving sync base", baseErr)
+			}
 			progress(SyncDeviceResult{Device: it.deviceName, Status: "pushed"})
 			successCount.Add(1)
 		})
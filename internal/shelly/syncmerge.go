@@ -0,0 +1,269 @@
+package shelly
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/utils"
+)
+
+// SyncPrefer selects which side wins a sync conflict.
+type SyncPrefer string
+
+// Sync conflict resolutions.
+const (
+	SyncPreferNone   SyncPrefer = ""
+	SyncPreferLocal  SyncPrefer = "local"
+	SyncPreferDevice SyncPrefer = "device"
+)
+
+// ParseSyncPrefer parses a --prefer value.
+func ParseSyncPrefer(s string) (SyncPrefer, error) {
+	switch p := SyncPrefer(strings.ToLower(s)); p {
+	case SyncPreferNone, SyncPreferLocal, SyncPreferDevice:
+		return p, nil
+	default:
+		return SyncPreferNone, fmt.Errorf("invalid preference %q (use device or local)", s)
+	}
+}
+
+// syncUnset marks a config key that is absent on one side of a merge.
+type syncUnset struct{}
+
+// IsSyncUnset reports whether a SyncChange value stands for a missing key.
+func IsSyncUnset(v any) bool {
+	_, ok := v.(syncUnset)
+	return ok
+}
+
+// SyncChange is a single config value that differs between the last-synced
+// base, the local sync file and the device. Values missing on a side are
+// reported by IsSyncUnset.
+type SyncChange struct {
+	Path   []string
+	Base   any
+	Local  any
+	Device any
+	// Resolution records which side a conflict was resolved to.
+	Resolution SyncPrefer
+}
+
+// Key returns the dotted config path, e.g. "switch:0.name".
+func (c SyncChange) Key() string {
+	return strings.Join(c.Path, ".")
+}
+
+// SyncMerge is the three-way merge of a device config.
+type SyncMerge struct {
+	Device string
+	// HasBase is false when the device has never been synced, in which case
+	// every difference is a conflict.
+	HasBase bool
+	// ToDevice holds local edits to apply to the device.
+	ToDevice []SyncChange
+	// ToLocal holds device edits to write to the local file.
+	ToLocal []SyncChange
+	// Conflicts holds keys changed differently on both sides.
+	Conflicts []SyncChange
+
+	local  map[string]any
+	device map[string]any
+}
+
+// MergeSyncConfigs computes a three-way merge between the last-synced base,
+// the local sync file and the live device config. Keys changed on one side
+// only are merged automatically; keys changed differently on both sides are
+// conflicts. Keys removed from the local file are restored from the device,
+// since device settings cannot be deleted. A nil base means the device has
+// not been synced before.
+func MergeSyncConfigs(device string, base, local, live map[string]any) *SyncMerge {
+	m := &SyncMerge{Device: device, HasBase: base != nil, local: local, device: live}
+
+	b, l, d := flattenConfig(base), flattenConfig(local), flattenConfig(live)
+	keys := make(map[string][]string)
+	for _, flat := range []map[string]flatValue{b, l, d} {
+		for key, fv := range flat {
+			keys[key] = fv.path
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(keys)) {
+		c := SyncChange{Path: keys[key], Base: b.get(key), Local: l.get(key), Device: d.get(key)}
+		switch {
+		case utils.DeepEqualJSON(c.Local, c.Device):
+			continue
+		case IsSyncUnset(c.Local):
+			m.ToLocal = append(m.ToLocal, c)
+		case m.HasBase && utils.DeepEqualJSON(c.Local, c.Base):
+			m.ToLocal = append(m.ToLocal, c)
+		case m.HasBase && utils.DeepEqualJSON(c.Device, c.Base):
+			m.ToDevice = append(m.ToDevice, c)
+		default:
+			m.Conflicts = append(m.Conflicts, c)
+		}
+	}
+	return m
+}
+
+// Unresolved returns the number of conflicts without a resolution.
+func (m *SyncMerge) Unresolved() int {
+	n := 0
+	for _, c := range m.Conflicts {
+		if c.Resolution == SyncPreferNone {
+			n++
+		}
+	}
+	return n
+}
+
+// ResolveAll resolves every unresolved conflict to the preferred side.
+func (m *SyncMerge) ResolveAll(prefer SyncPrefer) {
+	for i := range m.Conflicts {
+		if m.Conflicts[i].Resolution == SyncPreferNone {
+			m.Conflicts[i].Resolution = prefer
+		}
+	}
+}
+
+// Changed reports whether the merge changes the device or the local file.
+func (m *SyncMerge) Changed() bool {
+	return len(m.ToDevice) > 0 || len(m.ToLocal) > 0 || len(m.Conflicts) > 0
+}
+
+// Result returns the merged config and the top-level components of it that
+// must be sent to the device. Unresolved conflicts keep the device value.
+func (m *SyncMerge) Result() (merged, deviceUpdate map[string]any) {
+	flat := flattenConfig(m.device)
+	set := func(c SyncChange, v any) {
+		key := flatKey(c.Path)
+		if IsSyncUnset(v) {
+			delete(flat, key)
+			return
+		}
+		flat[key] = flatValue{path: c.Path, value: v}
+	}
+	for _, c := range m.ToDevice {
+		set(c, c.Local)
+	}
+	for _, c := range m.Conflicts {
+		if c.Resolution == SyncPreferLocal {
+			set(c, c.Local)
+		}
+	}
+
+	merged = unflattenConfig(flat)
+	deviceUpdate = make(map[string]any)
+	for key, value := range merged {
+		if !utils.DeepEqualJSON(value, m.device[key]) {
+			deviceUpdate[key] = value
+		}
+	}
+	return merged, deviceUpdate
+}
+
+// MergeDeviceConfig fetches a device's config and merges it with the local
+// sync file and the last-synced base. A missing local file merges as empty,
+// which pulls the whole device config.
+func (s *Service) MergeDeviceConfig(ctx context.Context, syncDir, device string) (*SyncMerge, error) {
+	result := s.FetchDeviceConfig(ctx, device)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	local := map[string]any{}
+	if exists, _ := config.SyncConfigExists(syncDir, device); exists {
+		var err error
+		if local, err = config.LoadSyncConfig(syncDir, device+".json"); err != nil {
+			return nil, err
+		}
+	}
+
+	base, _, err := config.LoadSyncBase(syncDir, device)
+	if err != nil {
+		return nil, err
+	}
+	return MergeSyncConfigs(device, base, local, result.Config), nil
+}
+
+// ApplySyncMerge pushes the merged components to the device, then writes the
+// merged config to the local sync file and records it as the new base. Local
+// files are left untouched when the device update fails.
+func (s *Service) ApplySyncMerge(ctx context.Context, syncDir string, m *SyncMerge) error {
+	if n := m.Unresolved(); n > 0 {
+		return fmt.Errorf("%d unresolved conflict(s)", n)
+	}
+
+	merged, update := m.Result()
+	if len(update) > 0 {
+		if err := s.PushDeviceConfig(ctx, m.Device, update); err != nil {
+			return fmt.Errorf("push: %w", err)
+		}
+	}
+	if err := config.SaveSyncConfig(syncDir, m.Device, merged); err != nil {
+		return err
+	}
+	return config.SaveSyncBase(syncDir, m.Device, merged)
+}
+
+// flatValue is a leaf config value and its path.
+type flatValue struct {
+	path  []string
+	value any
+}
+
+type flatConfig map[string]flatValue
+
+func (f flatConfig) get(key string) any {
+	if fv, ok := f[key]; ok {
+		return fv.value
+	}
+	return syncUnset{}
+}
+
+// flatKey joins a path with a separator that cannot appear in config keys.
+func flatKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// flattenConfig maps every leaf of a nested config to its path. Arrays and
+// empty objects are leaves.
+func flattenConfig(cfg map[string]any) flatConfig {
+	flat := make(flatConfig)
+	var walk func(path []string, v any)
+	walk = func(path []string, v any) {
+		if obj, ok := v.(map[string]any); ok && len(obj) > 0 {
+			for key, child := range obj {
+				walk(append(slices.Clone(path), key), child)
+			}
+			return
+		}
+		flat[flatKey(path)] = flatValue{path: path, value: v}
+	}
+	for key, v := range cfg {
+		walk([]string{key}, v)
+	}
+	return flat
+}
+
+// unflattenConfig rebuilds a nested config from its leaves.
+func unflattenConfig(flat flatConfig) map[string]any {
+	cfg := make(map[string]any)
+	for _, key := range slices.Sorted(maps.Keys(flat)) {
+		fv := flat[key]
+		node := cfg
+		for _, part := range fv.path[:len(fv.path)-1] {
+			child, ok := node[part].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				node[part] = child
+			}
+			node = child
+		}
+		node[fv.path[len(fv.path)-1]] = fv.value
+	}
+	return cfg
+}
@@ -0,0 +1,123 @@
+package shelly
+
+import (
+	"testing"
+)
+
+func TestMergeSyncConfigs(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]any{
+		"switch:0": map[string]any{"name": "Lamp", "auto_off": false, "auto_off_delay": 60.0},
+		"sys":      map[string]any{"device": map[string]any{"name": "den"}},
+	}
+	local := map[string]any{
+		"switch:0": map[string]any{"name": "Desk Lamp", "auto_off": true, "auto_off_delay": 60.0},
+		"sys":      map[string]any{"device": map[string]any{"name": "den"}},
+	}
+	device := map[string]any{
+		"switch:0": map[string]any{"name": "Lamp", "auto_off": false, "auto_off_delay": 120.0},
+		"sys":      map[string]any{"device": map[string]any{"name": "study"}},
+	}
+
+	m := MergeSyncConfigs("den", base, local, device)
+	if len(m.ToDevice) != 2 || m.ToDevice[0].Key() != "switch:0.auto_off" || m.ToDevice[1].Key() != "switch:0.name" {
+		t.Errorf("ToDevice = %v", keysOf(m.ToDevice))
+	}
+	if len(m.ToLocal) != 2 || m.ToLocal[0].Key() != "switch:0.auto_off_delay" || m.ToLocal[1].Key() != "sys.device.name" {
+		t.Errorf("ToLocal = %v", keysOf(m.ToLocal))
+	}
+	if len(m.Conflicts) != 0 {
+		t.Errorf("Conflicts = %v, want none", keysOf(m.Conflicts))
+	}
+
+	merged, update := m.Result()
+	sw, _ := merged["switch:0"].(map[string]any)
+	if sw["name"] != "Desk Lamp" || sw["auto_off"] != true || sw["auto_off_delay"] != 120.0 {
+		t.Errorf("merged switch:0 = %v", sw)
+	}
+	if _, ok := update["sys"]; ok || len(update) != 1 {
+		t.Errorf("device update = %v, want only switch:0", update)
+	}
+}
+
+func TestMergeSyncConfigs_Conflicts(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]any{"switch:0": map[string]any{"name": "Lamp"}}
+	local := map[string]any{"switch:0": map[string]any{"name": "Desk"}}
+	device := map[string]any{"switch:0": map[string]any{"name": "Reading"}}
+
+	m := MergeSyncConfigs("den", base, local, device)
+	if len(m.Conflicts) != 1 || m.Unresolved() != 1 {
+		t.Fatalf("Conflicts = %v", keysOf(m.Conflicts))
+	}
+
+	merged, update := m.Result()
+	if got := merged["switch:0"].(map[string]any)["name"]; got != "Reading" || len(update) != 0 {
+		t.Errorf("unresolved merge = %v, update %v; want device value kept", got, update)
+	}
+
+	m.ResolveAll(SyncPreferLocal)
+	if m.Unresolved() != 0 {
+		t.Error("conflict left unresolved")
+	}
+	merged, update = m.Result()
+	if got := merged["switch:0"].(map[string]any)["name"]; got != "Desk" || len(update) != 1 {
+		t.Errorf("local merge = %v, update %v", got, update)
+	}
+}
+
+func TestMergeSyncConfigs_NoBase(t *testing.T) {
+	t.Parallel()
+
+	local := map[string]any{"switch:0": map[string]any{"name": "Desk"}}
+	device := map[string]any{"switch:0": map[string]any{"name": "Lamp", "in_mode": "follow"}}
+
+	m := MergeSyncConfigs("den", nil, local, device)
+	if m.HasBase {
+		t.Error("HasBase = true, want false")
+	}
+	if len(m.Conflicts) != 1 || m.Conflicts[0].Key() != "switch:0.name" {
+		t.Errorf("Conflicts = %v", keysOf(m.Conflicts))
+	}
+	// Keys missing locally are taken from the device without conflict.
+	if len(m.ToLocal) != 1 || !IsSyncUnset(m.ToLocal[0].Local) {
+		t.Errorf("ToLocal = %v", keysOf(m.ToLocal))
+	}
+}
+
+func TestMergeSyncConfigs_Unchanged(t *testing.T) {
+	t.Parallel()
+
+	cfg := map[string]any{"wifi": map[string]any{"ap": map[string]any{"enable": false}}, "ble": map[string]any{}}
+	m := MergeSyncConfigs("den", cfg, cfg, cfg)
+	if m.Changed() {
+		t.Errorf("merge of identical configs changed: %+v", m)
+	}
+	merged, update := m.Result()
+	if len(update) != 0 || len(merged) != 2 {
+		t.Errorf("merged = %v, update = %v", merged, update)
+	}
+}
+
+func TestParseSyncPrefer(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"", "local", "Device"} {
+		if _, err := ParseSyncPrefer(s); err != nil {
+			t.Errorf("ParseSyncPrefer(%q) error: %v", s, err)
+		}
+	}
+	if _, err := ParseSyncPrefer("newest"); err == nil {
+		t.Error("ParseSyncPrefer(newest) succeeded, want error")
+	}
+}
+
+func keysOf(changes []SyncChange) []string {
+	keys := make([]string, 0, len(changes))
+	for _, c := range changes {
+		keys = append(keys, c.Key())
+	}
+	return keys
+}
@@ -151,6 +151,37 @@ func (s *Service) SetSysDiscoverable(ctx context.Context, identifier string, dis
 	})
 }
 
+// GetSysDebugWebSocket reports whether the debug log is sent over WebSocket.
+func (s *Service) GetSysDebugWebSocket(ctx context.Context, identifier string) (bool, error) {
+	var enabled bool
+	err := s.WithConnection(ctx, identifier, func(conn *client.Client) error {
+		sys := components.NewSys(conn.RPCClient())
+		config, err := sys.GetConfig(ctx)
+		if err != nil {
+			return err
+		}
+		if config.Debug != nil && config.Debug.Websocket != nil && config.Debug.Websocket.Enable != nil {
+			enabled = *config.Debug.Websocket.Enable
+		}
+		return nil
+	})
+	return enabled, err
+}
+
+// SetSysDebugWebSocket enables or disables the debug log over WebSocket.
+func (s *Service) SetSysDebugWebSocket(ctx context.Context, identifier string, enable bool) error {
+	return s.WithConnection(ctx, identifier, func(conn *client.Client) error {
+		sys := components.NewSys(conn.RPCClient())
+		return sys.SetConfig(ctx, &components.SysConfig{
+			Debug: &components.SysDebugConfig{
+				Websocket: &components.SysDebugTargetConfig{
+					Enable: &enable,
+				},
+			},
+		})
+	})
+}
+
 func extractDeviceConfig(device *components.SysDeviceConfig, result *SysConfig) {
 	if device == nil {
 		return
@@ -0,0 +1,42 @@
+package shelly
+
+import (
+	"context"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/tariff"
+)
+
+// EnergyTariff compiles the configured tariff; cost_rate applies when no
+// tariff period matches.
+func EnergyTariff(cfg config.EnergyConfig) (*tariff.Tariff, error) {
+	return tariff.New(cfg.Tariff, cfg.CostRate, cfg.Currency)
+}
+
+// EnergyCostToday prices today's history of an EM or EM1 component,
+// including one day's standing charge.
+func (s *Service) EnergyCostToday(ctx context.Context, device, componentType string, id int, t *tariff.Tariff) (model.EnergyCost, error) {
+	now := time.Now()
+	day := t.StartOfDay(now)
+
+	var cost model.EnergyCost
+	var err error
+	if componentType == ComponentTypeEM1 {
+		cost, err = s.Monitoring().EM1EnergyCost(ctx, device, id, t, day, now)
+	} else {
+		cost, err = s.Monitoring().EMEnergyCost(ctx, device, id, t, day, now)
+	}
+	if err != nil {
+		return cost, err
+	}
+	tariff.AddStandingCharge(&cost, t.StandingCharge(day, day.AddDate(0, 0, 1)))
+	return cost, nil
+}
+
+// DeviceEnergyCost prices the EM/EM1 history of a device over [from, to),
+// without the standing charge; metered is false when it has no energy meters.
+func (s *Service) DeviceEnergyCost(ctx context.Context, device string, t *tariff.Tariff, from, to time.Time) (cost model.EnergyCost, metered bool, err error) {
+	return s.Monitoring().DeviceEnergyCost(ctx, device, t, from, to)
+}
@@ -0,0 +1,304 @@
+// Package tariff prices metered energy under time-of-use and tiered
+// electricity tariffs.
+//
+// A tariff is a list of periods matched in order against the start of each
+// metered interval, in the tariff's time zone. A period may restrict months
+// (seasons), days of the week and a time-of-day window, and charges either a
+// flat rate or block rates by the energy imported so far in the calendar
+// month. Intervals matching no period use the fallback rate. Exported energy
+// is credited at the feed-in rate and a standing charge accrues per day.
+package tariff
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+)
+
+// FallbackPeriod names the rate used when no period matches.
+const FallbackPeriod = "standard"
+
+// Interval is energy metered over one period.
+type Interval struct {
+	Start  time.Time
+	Import float64 // Wh drawn from the grid
+	Export float64 // Wh returned to the grid
+}
+
+// Tariff is a compiled tariff.
+type Tariff struct {
+	currency string
+	fallback float64
+	standing float64
+	feedIn   float64
+	loc      *time.Location
+	periods  []period
+}
+
+type period struct {
+	name     string
+	rate     float64
+	tiers    []config.TariffTier
+	months   uint16 // bit per month, 0 = any
+	days     uint8  // bit per weekday, 0 = any
+	from, to int    // minutes since midnight; from == to = all day
+}
+
+var dayNames = map[string][]time.Weekday{
+	"sun":      {time.Sunday},
+	"mon":      {time.Monday},
+	"tue":      {time.Tuesday},
+	"wed":      {time.Wednesday},
+	"thu":      {time.Thursday},
+	"fri":      {time.Friday},
+	"sat":      {time.Saturday},
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekends": {time.Saturday, time.Sunday},
+}
+
+// Flat returns a tariff charging rate per kWh at all times.
+func Flat(rate float64, currency string) *Tariff {
+	return &Tariff{currency: currency, fallback: rate, loc: time.Local}
+}
+
+// New compiles a tariff. fallback is the rate per kWh for intervals that
+// match no period.
+func New(cfg config.TariffConfig, fallback float64, currency string) (*Tariff, error) {
+	t := Flat(fallback, currency)
+	t.standing = cfg.StandingCharge
+	t.feedIn = cfg.FeedIn
+
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("tariff timezone: %w", err)
+		}
+		t.loc = loc
+	}
+
+	for i, pc := range cfg.Periods {
+		p, err := compilePeriod(pc)
+		if err != nil {
+			name := pc.Name
+			if name == "" {
+				name = "#" + strconv.Itoa(i+1)
+			}
+			return nil, fmt.Errorf("tariff period %s: %w", name, err)
+		}
+		t.periods = append(t.periods, p)
+	}
+	return t, nil
+}
+
+func compilePeriod(pc config.TariffPeriod) (period, error) {
+	p := period{name: pc.Name, rate: pc.Rate, tiers: pc.Tiers}
+	if p.name == "" {
+		return p, fmt.Errorf("name is required")
+	}
+
+	for _, m := range pc.Months {
+		if m < 1 || m > 12 {
+			return p, fmt.Errorf("invalid month %d (use 1-12)", m)
+		}
+		p.months |= 1 << m
+	}
+	for _, d := range pc.Days {
+		days, ok := dayNames[strings.ToLower(d)]
+		if !ok {
+			return p, fmt.Errorf("invalid day %q (use mon..sun, weekdays, weekends)", d)
+		}
+		for _, wd := range days {
+			p.days |= 1 << wd
+		}
+	}
+
+	if (pc.From == "") != (pc.To == "") {
+		return p, fmt.Errorf("from and to must be set together")
+	}
+	if pc.From != "" {
+		var err error
+		if p.from, err = parseClock(pc.From); err != nil {
+			return p, err
+		}
+		if p.to, err = parseClock(pc.To); err != nil {
+			return p, err
+		}
+	}
+
+	for i, tier := range pc.Tiers {
+		last := i == len(pc.Tiers)-1
+		switch {
+		case tier.UpToKWh < 0:
+			return p, fmt.Errorf("tier %d: up_to_kwh must not be negative", i+1)
+		case tier.UpToKWh == 0 && !last:
+			return p, fmt.Errorf("tier %d: only the last tier may be unlimited", i+1)
+		case i > 0 && tier.UpToKWh != 0 && tier.UpToKWh <= pc.Tiers[i-1].UpToKWh:
+			return p, fmt.Errorf("tier %d: up_to_kwh must increase", i+1)
+		}
+	}
+	return p, nil
+}
+
+// parseClock parses HH:MM into minutes since midnight; 24:00 is allowed.
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(s, ":")
+	hh, herr := strconv.Atoi(h)
+	mm, merr := strconv.Atoi(m)
+	if !ok || herr != nil || merr != nil || hh < 0 || mm < 0 || mm > 59 || hh*60+mm > 24*60 {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	return hh*60 + mm, nil
+}
+
+// matches reports whether the period applies at local time at.
+func (p *period) matches(at time.Time) bool {
+	if p.months != 0 && p.months&(1<<at.Month()) == 0 {
+		return false
+	}
+	if p.days != 0 && p.days&(1<<at.Weekday()) == 0 {
+		return false
+	}
+	if p.from == p.to {
+		return true
+	}
+	minute := at.Hour()*60 + at.Minute()
+	if p.from < p.to {
+		return minute >= p.from && minute < p.to
+	}
+	return minute >= p.from || minute < p.to
+}
+
+// rateFor returns the period's rate after monthKWh imported this month.
+func (p *period) rateFor(monthKWh float64) float64 {
+	if len(p.tiers) == 0 {
+		return p.rate
+	}
+	for _, tier := range p.tiers {
+		if tier.UpToKWh == 0 || monthKWh < tier.UpToKWh {
+			return tier.Rate
+		}
+	}
+	return p.tiers[len(p.tiers)-1].Rate
+}
+
+// Currency returns the currency symbol costs are expressed in.
+func (t *Tariff) Currency() string {
+	return t.currency
+}
+
+// RateAt returns the import rate in effect at at, after monthKWh imported
+// so far in the calendar month.
+func (t *Tariff) RateAt(at time.Time, monthKWh float64) model.TariffRate {
+	local := at.In(t.loc)
+	for i := range t.periods {
+		if p := &t.periods[i]; p.matches(local) {
+			return model.TariffRate{Period: p.name, Rate: p.rateFor(monthKWh), Currency: t.currency}
+		}
+	}
+	return model.TariffRate{Period: FallbackPeriod, Rate: t.fallback, Currency: t.currency}
+}
+
+// Cost prices intervals, each at the rate in effect at its start. Tiered
+// rates count the month's import from the first interval on, so a range
+// starting mid-month under-counts earlier consumption. The standing charge
+// is not included; see StandingCharge.
+func (t *Tariff) Cost(intervals []Interval) model.EnergyCost {
+	sorted := slices.Clone(intervals)
+	slices.SortStableFunc(sorted, func(a, b Interval) int { return a.Start.Compare(b.Start) })
+
+	cost := model.EnergyCost{Currency: t.currency}
+	periods := make(map[string]*model.PeriodCost)
+	var order []string
+	var month time.Month
+	var year int
+	var monthKWh float64
+
+	for _, iv := range sorted {
+		local := iv.Start.In(t.loc)
+		if local.Year() != year || local.Month() != month {
+			year, month, monthKWh = local.Year(), local.Month(), 0
+		}
+
+		if iv.Import > 0 {
+			kwh := iv.Import / 1000
+			rate := t.RateAt(iv.Start, monthKWh)
+			pc, ok := periods[rate.Period]
+			if !ok {
+				pc = &model.PeriodCost{Name: rate.Period}
+				periods[rate.Period] = pc
+				order = append(order, rate.Period)
+			}
+			pc.KWh += kwh
+			pc.Cost += kwh * rate.Rate
+			cost.ImportKWh += kwh
+			cost.ImportCost += kwh * rate.Rate
+			monthKWh += kwh
+		}
+		if iv.Export > 0 {
+			kwh := iv.Export / 1000
+			cost.ExportKWh += kwh
+			cost.ExportCredit += kwh * t.feedIn
+		}
+	}
+
+	for _, name := range order {
+		cost.Periods = append(cost.Periods, *periods[name])
+	}
+	cost.Total = cost.ImportCost - cost.ExportCredit
+	return cost
+}
+
+// StandingCharge returns the standing charge accrued over [from, to).
+func (t *Tariff) StandingCharge(from, to time.Time) float64 {
+	if t.standing == 0 || !to.After(from) {
+		return 0
+	}
+	return t.standing * to.Sub(from).Hours() / 24
+}
+
+// Bill prices intervals over [from, to), including the standing charge.
+func (t *Tariff) Bill(intervals []Interval, from, to time.Time) model.EnergyCost {
+	cost := t.Cost(intervals)
+	AddStandingCharge(&cost, t.StandingCharge(from, to))
+	return cost
+}
+
+// AddStandingCharge adds a standing charge to cost.
+func AddStandingCharge(cost *model.EnergyCost, charge float64) {
+	cost.StandingCharge += charge
+	cost.Total += charge
+}
+
+// Sum adds up costs, merging their periods by name.
+func Sum(currency string, costs ...model.EnergyCost) model.EnergyCost {
+	total := model.EnergyCost{Currency: currency}
+	for _, c := range costs {
+		total.ImportKWh += c.ImportKWh
+		total.ExportKWh += c.ExportKWh
+		total.ImportCost += c.ImportCost
+		total.ExportCredit += c.ExportCredit
+		total.StandingCharge += c.StandingCharge
+		total.Total += c.Total
+		for _, pc := range c.Periods {
+			i := slices.IndexFunc(total.Periods, func(p model.PeriodCost) bool { return p.Name == pc.Name })
+			if i < 0 {
+				total.Periods = append(total.Periods, model.PeriodCost{Name: pc.Name})
+				i = len(total.Periods) - 1
+			}
+			total.Periods[i].KWh += pc.KWh
+			total.Periods[i].Cost += pc.Cost
+		}
+	}
+	return total
+}
+
+// StartOfDay returns local midnight of at's day in the tariff's time zone.
+func (t *Tariff) StartOfDay(at time.Time) time.Time {
+	y, m, d := at.In(t.loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.loc)
+}
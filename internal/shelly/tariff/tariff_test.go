@@ -0,0 +1,163 @@
+package tariff
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+)
+
+func touConfig() config.TariffConfig {
+	return config.TariffConfig{
+		Timezone:       "UTC",
+		StandingCharge: 0.48,
+		FeedIn:         0.08,
+		Periods: []config.TariffPeriod{
+			{Name: "weekend", Rate: 0.15, Days: []string{"weekends"}},
+			{Name: "peak", Rate: 0.40, Days: []string{"weekdays"}, From: "16:00", To: "21:00"},
+			{Name: "night", Rate: 0.10, From: "23:00", To: "07:00"},
+			{Name: "summer", Months: []int{6, 7, 8}, Tiers: []config.TariffTier{{UpToKWh: 1, Rate: 0.20}, {Rate: 0.30}}},
+		},
+	}
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestRateAt(t *testing.T) {
+	t.Parallel()
+
+	tr, err := New(touConfig(), 0.25, "€")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		at       string
+		monthKWh float64
+		period   string
+		rate     float64
+	}{
+		{"weekday peak", "2026-03-04T17:30:00Z", 0, "peak", 0.40},
+		{"weekday day", "2026-03-04T12:00:00Z", 0, FallbackPeriod, 0.25},
+		{"weekday night after midnight", "2026-03-04T03:00:00Z", 0, "night", 0.10},
+		{"weekday night before midnight", "2026-03-04T23:15:00Z", 0, "night", 0.10},
+		{"peak end exclusive", "2026-03-04T21:00:00Z", 0, FallbackPeriod, 0.25},
+		{"weekend overrides peak", "2026-03-07T17:30:00Z", 0, "weekend", 0.15},
+		{"summer first tier", "2026-07-01T12:00:00Z", 0.5, "summer", 0.20},
+		{"summer second tier", "2026-07-01T12:00:00Z", 1.5, "summer", 0.30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			at, err := time.Parse(time.RFC3339, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := tr.RateAt(at, tt.monthKWh)
+			if got.Period != tt.period || !approx(got.Rate, tt.rate) {
+				t.Errorf("RateAt(%s) = %s %.2f, want %s %.2f", tt.at, got.Period, got.Rate, tt.period, tt.rate)
+			}
+		})
+	}
+}
+
+func TestBill(t *testing.T) {
+	t.Parallel()
+
+	tr, err := New(touConfig(), 0.25, "€")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	day := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC) // Wednesday
+	intervals := []Interval{
+		{Start: day.Add(17 * time.Hour), Import: 2000},              // peak
+		{Start: day.Add(3 * time.Hour), Import: 1000},               // night
+		{Start: day.Add(12 * time.Hour), Import: 500, Export: 3000}, // standard + export
+	}
+	cost := tr.Bill(intervals, day, day.Add(24*time.Hour))
+
+	if !approx(cost.ImportKWh, 3.5) || !approx(cost.ExportKWh, 3) {
+		t.Errorf("kWh = %v import, %v export; want 3.5, 3", cost.ImportKWh, cost.ExportKWh)
+	}
+	wantImport := 2*0.40 + 1*0.10 + 0.5*0.25
+	if !approx(cost.ImportCost, wantImport) {
+		t.Errorf("ImportCost = %v, want %v", cost.ImportCost, wantImport)
+	}
+	if !approx(cost.ExportCredit, 0.24) || !approx(cost.StandingCharge, 0.48) {
+		t.Errorf("ExportCredit = %v, StandingCharge = %v", cost.ExportCredit, cost.StandingCharge)
+	}
+	if want := wantImport - 0.24 + 0.48; !approx(cost.Total, want) {
+		t.Errorf("Total = %v, want %v", cost.Total, want)
+	}
+	// Periods are listed in order of first use
+	if len(cost.Periods) != 3 || cost.Periods[0].Name != "night" || cost.Periods[1].Name != FallbackPeriod {
+		t.Errorf("Periods = %+v", cost.Periods)
+	}
+}
+
+func TestCost_TiersResetMonthly(t *testing.T) {
+	t.Parallel()
+
+	tr, err := New(touConfig(), 0.25, "€")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	noon := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 12, 0, 0, 0, time.UTC) }
+	cost := tr.Cost([]Interval{
+		{Start: noon(6, 1), Import: 1000}, // first tier, month total reaches 1 kWh
+		{Start: noon(6, 2), Import: 1000}, // second tier
+		{Start: noon(7, 1), Import: 1000}, // new month: first tier again
+	})
+	if want := 0.20 + 0.30 + 0.20; !approx(cost.ImportCost, want) {
+		t.Errorf("ImportCost = %v, want %v", cost.ImportCost, want)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		cfg    config.TariffConfig
+		errMsg string
+	}{
+		{"bad zone", config.TariffConfig{Timezone: "Nowhere/City"}, "timezone"},
+		{"no name", config.TariffConfig{Periods: []config.TariffPeriod{{Rate: 1}}}, "name is required"},
+		{"bad month", config.TariffConfig{Periods: []config.TariffPeriod{{Name: "x", Months: []int{13}}}}, "invalid month"},
+		{"bad day", config.TariffConfig{Periods: []config.TariffPeriod{{Name: "x", Days: []string{"funday"}}}}, "invalid day"},
+		{"bad time", config.TariffConfig{Periods: []config.TariffPeriod{{Name: "x", From: "7am", To: "09:00"}}}, "invalid time"},
+		{"half window", config.TariffConfig{Periods: []config.TariffPeriod{{Name: "x", From: "07:00"}}}, "set together"},
+		{"tiers not increasing", config.TariffConfig{Periods: []config.TariffPeriod{{Name: "x", Tiers: []config.TariffTier{{UpToKWh: 5}, {UpToKWh: 3}}}}}, "must increase"},
+		{"unlimited tier not last", config.TariffConfig{Periods: []config.TariffPeriod{{Name: "x", Tiers: []config.TariffTier{{Rate: 1}, {UpToKWh: 3}}}}}, "last tier"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := New(tt.cfg, 0.1, "$")
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("New() error = %v, want containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestSum(t *testing.T) {
+	t.Parallel()
+
+	tr := Flat(0.2, "$")
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := tr.Cost([]Interval{{Start: at, Import: 1000}})
+	b := tr.Cost([]Interval{{Start: at, Import: 2000}})
+	total := Sum("$", a, b)
+	if !approx(total.ImportKWh, 3) || !approx(total.Total, 0.6) {
+		t.Errorf("Sum() = %+v", total)
+	}
+	if len(total.Periods) != 1 || !approx(total.Periods[0].KWh, 3) {
+		t.Errorf("Sum() periods = %+v", total.Periods)
+	}
+}
@@ -8,7 +8,10 @@ import (
 
 	"github.com/tj-smith47/shelly-cli/internal/config"
 	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/output/table"
 	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/alerting"
+	"github.com/tj-smith47/shelly-cli/internal/theme"
 )
 
 // DisplayAlertTriggered displays an alert that was triggered.
@@ -37,6 +40,13 @@ func DisplayAlertTriggered(ios *iostreams.IOStreams, result shelly.AlertCheckRes
 			}
 		}
 
+	case shelly.ActionTypeNotifier:
+		if actionResult.Error != nil {
+			ios.Error("[%s] Notification failed: %v", timestamp, actionResult.Error)
+		} else {
+			ios.Success("[%s] Notification sent for %s", timestamp, result.Name)
+		}
+
 	default:
 		if actionResult.Error != nil {
 			ios.Warning("[%s] %v", timestamp, actionResult.Error)
@@ -61,18 +71,84 @@ func DisplayAlertActionStarting(ios *iostreams.IOStreams, actionType, alertName
 	}
 }
 
-// DisplayAlertResult displays the result of checking an alert.
+// DisplayAlertPending displays when an alert's condition starts its "for" window.
+func DisplayAlertPending(ios *iostreams.IOStreams, result shelly.AlertCheckResult) {
+	timestamp := time.Now().Format("15:04:05")
+	ios.Info("[%s] Pending: %s - %s on %s (value: %s)",
+		timestamp, result.Name, result.Condition, result.Device, result.Value)
+}
+
+// DisplayAlertResult displays the result of checking an alert, executing the
+// alert's action for transitions that notify.
 func DisplayAlertResult(ctx context.Context, ios *iostreams.IOStreams, alert config.Alert, result shelly.AlertCheckResult) {
 	switch result.Action {
-	case shelly.AlertActionTriggered:
+	case shelly.AlertActionTriggered, shelly.AlertActionRepeat:
+		if result.Action == shelly.AlertActionRepeat {
+			ios.Warning("[%s] Still firing: %s", time.Now().Format("15:04:05"), result.Name)
+		}
 		DisplayAlertActionStarting(ios, alert.Action, alert.Name)
-		actionResult := shelly.ExecuteAlertAction(ctx, alert, result.Value)
+		actionResult := shelly.ExecuteAlertTransition(ctx, alert, result.Action.Transition(), result.Value)
 		DisplayAlertTriggered(ios, result, actionResult)
 
 	case shelly.AlertActionCleared:
 		DisplayAlertCleared(ios, result)
+		if result.Action.Notifies(alert) {
+			DisplayAlertActionStarting(ios, alert.Action, alert.Name)
+			actionResult := shelly.ExecuteAlertTransition(ctx, alert, result.Action.Transition(), result.Value)
+			if actionResult.Type != shelly.ActionTypeNotify {
+				DisplayAlertTriggered(ios, result, actionResult)
+			}
+		}
+
+	case shelly.AlertActionPending:
+		DisplayAlertPending(ios, result)
 
 	case shelly.AlertActionNone:
 		iostreams.Debug("alert %s: no state change (value: %s)", result.Name, result.Value)
 	}
 }
+
+// DisplayAlertHistory prints a table of alert lifecycle transitions.
+func DisplayAlertHistory(ios *iostreams.IOStreams, entries []config.AlertHistoryEntry) {
+	builder := table.NewBuilder("Time", "Alert", "Device", "Transition", "Value")
+	for _, e := range entries {
+		builder.AddRow(e.Time.Local().Format("2006-01-02 15:04:05"), e.Alert, e.Device, renderAlertTransition(e.Transition), e.Value)
+	}
+	tbl := builder.WithModeStyle(ios).Build()
+	if err := tbl.PrintTo(ios.Out); err != nil {
+		ios.DebugErr("print alert history table", err)
+	}
+}
+
+func renderAlertTransition(transition string) string {
+	switch transition {
+	case shelly.AlertTransitionFiring, shelly.AlertTransitionRepeat:
+		return theme.StatusError().Render(transition)
+	case shelly.AlertTransitionResolved:
+		return theme.StatusOK().Render(transition)
+	default:
+		return theme.StatusWarn().Render(transition)
+	}
+}
+
+// DisplayAlertEvaluation displays the outcome of evaluating an alert condition
+// against a single status snapshot.
+func DisplayAlertEvaluation(ios *iostreams.IOStreams, cond *alerting.Condition, res alerting.Result) {
+	ios.Printf("  Parsed: %s\n", cond.String())
+	for _, obs := range res.Observations {
+		mark := theme.StatusError().Render("✗")
+		if obs.Matched {
+			mark = theme.StatusOK().Render("✓")
+		}
+		ios.Printf("    %s %s = %s\n", mark, obs.Path, obs.Value)
+	}
+
+	switch {
+	case res.Triggered:
+		ios.Warning("Condition is met")
+	case res.Unknown:
+		ios.Info("Condition is unknown: status values are missing")
+	default:
+		ios.Info("Condition is not met")
+	}
+}
@@ -2,8 +2,13 @@
 package term
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/tj-smith47/shelly-cli/internal/iostreams"
 	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/output/table"
+	"github.com/tj-smith47/shelly-cli/internal/theme"
 )
 
 // DisplayLatencyStats prints latency statistics to the terminal.
@@ -18,3 +23,44 @@ func DisplayLatencyStats(ios *iostreams.IOStreams, stats model.LatencyStats) {
 		ios.Printf("  Errors: %d\n", stats.Errors)
 	}
 }
+
+// DisplayLoadResults prints a table of load profile results.
+func DisplayLoadResults(ios *iostreams.IOStreams, results []model.LoadResult) {
+	builder := table.NewBuilder("Transport", "Method", "Clients", "Requests", "Req/s", "Errors", "P50", "P95", "P99")
+	for _, r := range results {
+		builder.AddRow(
+			r.Transport,
+			r.Method,
+			fmt.Sprintf("%d", r.Concurrency),
+			fmt.Sprintf("%d", r.Requests),
+			fmt.Sprintf("%.1f", r.Throughput),
+			fmt.Sprintf("%.1f%%", r.ErrorRate*100),
+			r.Latency.P50.Round(time.Microsecond*100).String(),
+			r.Latency.P95.Round(time.Microsecond*100).String(),
+			r.Latency.P99.Round(time.Microsecond*100).String(),
+		)
+	}
+	tbl := builder.WithModeStyle(ios).Build()
+	if err := tbl.PrintTo(ios.Out); err != nil {
+		ios.DebugErr("print load results table", err)
+	}
+}
+
+// DisplayBenchmarkRegressions prints metrics that regressed against a
+// baseline.
+func DisplayBenchmarkRegressions(ios *iostreams.IOStreams, regressions []model.BenchmarkRegression) {
+	builder := table.NewBuilder("Profile", "Metric", "Baseline", "Current", "Change")
+	for _, r := range regressions {
+		builder.AddRow(
+			r.Profile,
+			r.Metric,
+			fmt.Sprintf("%.2f", r.Baseline),
+			fmt.Sprintf("%.2f", r.Current),
+			theme.StatusError().Render(fmt.Sprintf("%+.1f%%", r.Change)),
+		)
+	}
+	tbl := builder.WithModeStyle(ios).Build()
+	if err := tbl.PrintTo(ios.Out); err != nil {
+		ios.DebugErr("print regressions table", err)
+	}
+}
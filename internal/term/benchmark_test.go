@@ -91,3 +91,41 @@ func TestDisplayLatencyStats_MicrosecondValues(t *testing.T) {
 		t.Error("expected output")
 	}
 }
+
+func TestDisplayLoadResults(t *testing.T) {
+	t.Parallel()
+
+	ios, out, _ := testIOStreams()
+	DisplayLoadResults(ios, []model.LoadResult{{
+		Transport:   "ws",
+		Method:      "Shelly.GetStatus",
+		Concurrency: 3,
+		Requests:    100,
+		Throughput:  42.5,
+		ErrorRate:   0.02,
+		Latency:     model.LatencyStats{P50: 20 * time.Millisecond},
+	}})
+
+	output := out.String()
+	for _, want := range []string{"ws", "Shelly.GetStatus", "42.5", "2.0%", "20ms"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+}
+
+func TestDisplayBenchmarkRegressions(t *testing.T) {
+	t.Parallel()
+
+	ios, out, _ := testIOStreams()
+	DisplayBenchmarkRegressions(ios, []model.BenchmarkRegression{{
+		Profile: "http Shelly.GetStatus x2", Metric: "p95", Baseline: 40, Current: 60, Change: 50,
+	}})
+
+	output := out.String()
+	for _, want := range []string{"http Shelly.GetStatus x2", "p95", "40.00", "60.00", "+50.0%"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package term
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/output/table"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/theme"
+)
+
+// DisplayDriftStatuses prints a table of assigned drift baselines and the
+// last known drift state of each device.
+func DisplayDriftStatuses(ios *iostreams.IOStreams, statuses []shelly.DriftStatus) {
+	builder := table.NewBuilder("Device", "Baseline", "State", "Since", "Last Check")
+	for _, st := range statuses {
+		state, since, lastCheck := theme.Dim().Render("unchecked"), "-", "-"
+		if rec := st.State; rec != nil {
+			state = renderDriftState(*rec)
+			if !rec.Since.IsZero() {
+				since = rec.Since.Local().Format(time.DateTime)
+			}
+			lastCheck = rec.LastCheck.Local().Format(time.DateTime)
+		}
+		builder.AddRow(st.Device, st.Baseline.String(), state, since, lastCheck)
+	}
+	tbl := builder.WithModeStyle(ios).Build()
+	if err := tbl.PrintTo(ios.Out); err != nil {
+		ios.DebugErr("print drift table", err)
+	}
+}
+
+func renderDriftState(rec config.DriftRecord) string {
+	switch {
+	case rec.Error != "":
+		return theme.StatusWarn().Render("check failed")
+	case rec.Drifted:
+		return theme.StatusError().Render(fmt.Sprintf("drifted (%d)", len(rec.Changes)))
+	default:
+		return theme.StatusOK().Render("in sync")
+	}
+}
+
+// DisplayDriftHistory prints a table of recorded drift events.
+func DisplayDriftHistory(ios *iostreams.IOStreams, events []config.DriftEvent) {
+	builder := table.NewBuilder("Time", "Device", "Event", "Baseline", "Changes")
+	for _, ev := range events {
+		builder.AddRow(ev.Time.Local().Format(time.DateTime), ev.Device, renderDriftEvent(ev.Event), ev.Baseline, fmt.Sprint(len(ev.Changes)))
+	}
+	tbl := builder.WithModeStyle(ios).Build()
+	if err := tbl.PrintTo(ios.Out); err != nil {
+		ios.DebugErr("print drift history table", err)
+	}
+}
+
+func renderDriftEvent(event string) string {
+	if event == config.DriftEventResolved {
+		return theme.StatusOK().Render(event)
+	}
+	return theme.StatusError().Render(event)
+}
+
+// DisplayDriftEvent prints a drift event as it happens.
+func DisplayDriftEvent(ios *iostreams.IOStreams, ev config.DriftEvent) {
+	timestamp := ev.Time.Local().Format("15:04:05")
+	switch ev.Event {
+	case config.DriftEventResolved:
+		ios.Success("[%s] %s matches %s again", timestamp, ev.Device, ev.Baseline)
+		return
+	case config.DriftEventChanged:
+		ios.Warning("[%s] DRIFT CHANGED: %s differs from %s (%d change(s))", timestamp, ev.Device, ev.Baseline, len(ev.Changes))
+	default:
+		ios.Warning("[%s] DRIFT: %s differs from %s (%d change(s))", timestamp, ev.Device, ev.Baseline, len(ev.Changes))
+	}
+	for _, c := range ev.Changes {
+		ios.Printf("    %s\n", c)
+	}
+}
+
+// DisplayDriftResults prints the outcome of one drift check of each device.
+func DisplayDriftResults(ios *iostreams.IOStreams, results []shelly.DriftResult) {
+	for _, res := range results {
+		switch {
+		case res.Err != nil:
+			ios.Printf("  %s %s: %s\n", theme.StatusWarn().Render("!"), res.Device, res.Err)
+		case res.Drifted():
+			ios.Printf("  %s %s: %d change(s) from %s\n", theme.StatusError().Render("✗"), res.Device, len(res.Changes), res.Baseline)
+			for _, c := range res.Changes {
+				ios.Printf("      %s\n", theme.Dim().Render(c))
+			}
+		default:
+			ios.Printf("  %s %s: matches %s\n", theme.StatusOK().Render("✓"), res.Device, res.Baseline)
+		}
+		for _, w := range res.Warnings {
+			ios.Printf("      %s\n", theme.StatusWarn().Render(w))
+		}
+	}
+}
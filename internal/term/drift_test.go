@@ -0,0 +1,46 @@
+package term
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+)
+
+func TestDisplayDriftResults(t *testing.T) {
+	t.Parallel()
+
+	ios, out, _ := testIOStreams()
+	baseline := config.DriftBaseline{Template: "plug"}
+	DisplayDriftResults(ios, []shelly.DriftResult{
+		{Device: "kitchen", Baseline: baseline, Changes: []string{"schedule @sunset missing"}},
+		{Device: "porch", Baseline: baseline},
+		{Device: "garage", Baseline: baseline, Err: errors.New("offline")},
+	})
+
+	output := out.String()
+	for _, want := range []string{"kitchen: 1 change(s) from template:plug", "schedule @sunset missing", "porch: matches", "garage: offline"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestDisplayDriftEvent(t *testing.T) {
+	t.Parallel()
+
+	ios, out, errOut := testIOStreams()
+	now := time.Now()
+	DisplayDriftEvent(ios, config.DriftEvent{Time: now, Device: "kitchen", Baseline: "template:plug", Event: config.DriftEventDetected, Changes: []string{"script boot missing"}})
+	DisplayDriftEvent(ios, config.DriftEvent{Time: now, Device: "kitchen", Baseline: "template:plug", Event: config.DriftEventResolved})
+
+	output := out.String() + errOut.String()
+	for _, want := range []string{"DRIFT: kitchen differs from template:plug", "script boot missing", "kitchen matches template:plug again"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package term
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/output"
+	"github.com/tj-smith47/shelly-cli/internal/output/table"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/energystore"
+	"github.com/tj-smith47/shelly-cli/internal/theme"
+)
+
+// DisplayLocalEnergyHistory shows energy history read from the local store,
+// one section per component.
+func DisplayLocalEnergyHistory(ios *iostreams.IOStreams, series []energystore.SeriesData, startTS, endTS *int64, limit int) {
+	if output.WantsStructured() {
+		if err := output.FormatOutput(ios.Out, series); err != nil {
+			ios.DebugErr("format output", err)
+		}
+		return
+	}
+
+	for i, sr := range series {
+		if i > 0 {
+			ios.Printf("\n")
+		}
+		ios.Printf("Energy History (local) %s %s\n", sr.Device, sr.Component)
+		if startTS != nil {
+			ios.Printf("From: %s\n", time.Unix(*startTS, 0).Format(time.RFC3339))
+		}
+		if endTS != nil {
+			ios.Printf("To:   %s\n", time.Unix(*endTS, 0).Format(time.RFC3339))
+		}
+		ios.Printf("\n")
+
+		if len(sr.Points) == 0 {
+			ios.Warning("No data available for the specified time range")
+			continue
+		}
+
+		ios.Printf("Data points: %d\n\n", len(sr.Points))
+		for n, p := range sr.Points {
+			if limit > 0 && n >= limit {
+				ios.Printf("\n(showing first %d of %d records, use --limit to see more)\n", limit, len(sr.Points))
+				break
+			}
+			voltage := ""
+			if p.Voltage > 0 {
+				voltage = fmt.Sprintf(" | Voltage: %.1fV", p.Voltage)
+			}
+			ios.Printf("[%s] Power: %.2fW (min %.2fW, max %.2fW) | Energy: %.2fWh%s\n",
+				p.Time().Format("2006-01-02 15:04:05"), p.Power, p.PowerMin, p.PowerMax, p.Energy, voltage)
+		}
+
+		shown := len(sr.Points)
+		if limit > 0 {
+			shown = min(shown, limit)
+		}
+		ios.Printf("\n%s: %.2f kWh (avg %.2fW, peak %.2fW)\n",
+			energySummaryLabel(shown, len(sr.Points)), sr.Totals.Energy/1000, sr.Totals.AvgPower, sr.Totals.PeakPower)
+	}
+}
+
+// DisplayEnergyCollectResults prints a table of collected devices.
+func DisplayEnergyCollectResults(ios *iostreams.IOStreams, results []shelly.EnergyCollectResult) {
+	builder := table.NewBuilder("Device", "Components", "Status")
+	for _, r := range results {
+		status := theme.StatusOK().Render("ok")
+		switch {
+		case r.Error != "":
+			status = theme.StatusError().Render(r.Error)
+		case r.Samples == 0:
+			status = theme.Dim().Render("no metering components")
+		}
+		builder.AddRow(r.Device, fmt.Sprint(r.Samples), status)
+	}
+	tbl := builder.WithModeStyle(ios).Build()
+	if err := tbl.PrintTo(ios.Out); err != nil {
+		ios.DebugErr("print energy collect table", err)
+	}
+}
@@ -0,0 +1,48 @@
+package term
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/energystore"
+)
+
+func TestDisplayLocalEnergyHistory(t *testing.T) {
+	t.Parallel()
+
+	ios, out, _ := testIOStreams()
+	points := []energystore.Point{
+		{TS: 1700000000, Power: 50, PowerMin: 40, PowerMax: 60, Voltage: 230, Energy: 50, Count: 60},
+		{TS: 1700003600, Power: 70, PowerMin: 70, PowerMax: 70, Energy: 70, Count: 60},
+	}
+	DisplayLocalEnergyHistory(ios, []energystore.SeriesData{{
+		Series: energystore.Series{Device: "kitchen", Component: "switch:0"},
+		Totals: energystore.Summarize(points),
+		Points: points,
+	}}, nil, nil, 1)
+
+	output := out.String()
+	for _, want := range []string{"kitchen switch:0", "Power: 50.00W (min 40.00W, max 60.00W)", "showing first 1 of 2", "0.12 kWh"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestDisplayEnergyCollectResults(t *testing.T) {
+	t.Parallel()
+
+	ios, out, _ := testIOStreams()
+	DisplayEnergyCollectResults(ios, []shelly.EnergyCollectResult{
+		{Device: "kitchen", Samples: 2},
+		{Device: "porch", Error: "offline"},
+	})
+
+	output := out.String()
+	for _, want := range []string{"kitchen", "offline"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
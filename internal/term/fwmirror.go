@@ -0,0 +1,63 @@
+package term
+
+import (
+	"strings"
+
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/output"
+	"github.com/tj-smith47/shelly-cli/internal/output/table"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/fwmirror"
+	"github.com/tj-smith47/shelly-cli/internal/theme"
+)
+
+// DisplayMirrorResults prints the outcome of a firmware mirror sync.
+func DisplayMirrorResults(ios *iostreams.IOStreams, results []fwmirror.Result) {
+	builder := table.NewBuilder("Model", "Version", "Result", "Size", "SHA-256", "Devices")
+	failed := 0
+	for _, r := range results {
+		result := theme.StatusOK().Render(r.Action)
+		if r.Err != nil {
+			result = theme.StatusError().Render(r.Err.Error())
+			failed++
+		}
+		builder.AddRow(
+			r.Image.Model,
+			r.Image.Version,
+			result,
+			output.FormatSize(r.Image.Size),
+			shortSum(r.Image.SHA256),
+			strings.Join(r.Image.Devices, ", "),
+		)
+	}
+	tbl := builder.WithModeStyle(ios).Build()
+	if err := tbl.PrintTo(ios.Out); err != nil {
+		ios.DebugErr("print mirror results table", err)
+	}
+
+	ios.Println("")
+	if failed > 0 {
+		ios.Warning("Mirrored %d image(s), %d failed", len(results)-failed, failed)
+	} else {
+		ios.Success("Mirrored %d image(s)", len(results))
+	}
+}
+
+// DisplayMirrorImages prints the images of a firmware mirror with their
+// URLs on a mirror served at baseURL.
+func DisplayMirrorImages(ios *iostreams.IOStreams, images []fwmirror.Image, baseURL string) {
+	builder := table.NewBuilder("Model", "Version", "Stage", "URL", "Devices")
+	for _, img := range images {
+		builder.AddRow(img.Model, img.Version, img.Stage, fwmirror.ImageURL(baseURL, img), strings.Join(img.Devices, ", "))
+	}
+	tbl := builder.WithModeStyle(ios).Build()
+	if err := tbl.PrintTo(ios.Out); err != nil {
+		ios.DebugErr("print mirror images table", err)
+	}
+}
+
+func shortSum(sum string) string {
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
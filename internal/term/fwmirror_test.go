@@ -0,0 +1,39 @@
+package term
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/fwmirror"
+)
+
+func TestDisplayMirrorResults(t *testing.T) {
+	t.Parallel()
+	ios, out, errOut := testIOStreams()
+
+	DisplayMirrorResults(ios, []fwmirror.Result{
+		{Image: fwmirror.Image{Model: "SHSW-1", Version: "1.14.0", Size: 1024, SHA256: strings.Repeat("ab", 32), Devices: []string{"garage", "shed"}}, Action: fwmirror.ActionDownloaded},
+		{Image: fwmirror.Image{Model: "S3SW-001X8EU", Version: "1.5.0"}, Err: errors.New("HTTP status 404")},
+	})
+
+	all := out.String() + errOut.String()
+	for _, want := range []string{"SHSW-1", "downloaded", "abababababab", "garage, shed", "HTTP status 404", "Mirrored 1 image(s), 1 failed"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("output missing %q:\n%s", want, all)
+		}
+	}
+}
+
+func TestDisplayMirrorImages(t *testing.T) {
+	t.Parallel()
+	ios, out, _ := testIOStreams()
+
+	DisplayMirrorImages(ios, []fwmirror.Image{
+		{Model: "SHSW-1", Version: "1.14.0", Stage: "stable", File: "SHSW-1/1.14.0/fw.zip", Devices: []string{"garage"}},
+	}, "http://10.0.0.2:8080")
+
+	if !strings.Contains(out.String(), "http://10.0.0.2:8080/SHSW-1/1.14.0/fw.zip") {
+		t.Errorf("output missing image URL:\n%s", out.String())
+	}
+}
@@ -0,0 +1,65 @@
+package term
+
+import (
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/gitops"
+	"github.com/tj-smith47/shelly-cli/internal/theme"
+)
+
+// DisplayGitOpsPlan prints the changes a manifest plan would make, grouped
+// by device, followed by a summary line.
+func DisplayGitOpsPlan(ios *iostreams.IOStreams, plan *gitops.Plan) {
+	for _, dp := range plan.Devices {
+		switch {
+		case dp.Err != nil:
+			ios.Printf("%s %s\n", theme.Bold().Render(dp.Device), theme.StatusError().Render("error: "+dp.Err.Error()))
+			continue
+		case len(dp.Changes) == 0:
+			ios.Printf("%s %s\n", theme.Bold().Render(dp.Device), theme.Dim().Render("up to date"))
+		default:
+			ios.Printf("%s\n", theme.Bold().Render(dp.Device))
+			for _, c := range dp.Changes {
+				ios.Printf("  %s %s %s\n", gitOpsActionSymbol(c.Action), c.Kind, c.Name)
+				for _, d := range c.Details {
+					ios.Printf("      %s\n", theme.Dim().Render(d))
+				}
+			}
+		}
+		for _, w := range dp.Warnings {
+			ios.Printf("  %s %s\n", theme.StatusWarn().Render("!"), w)
+		}
+	}
+
+	ios.Println()
+	create, update, del := plan.Counts()
+	if create+update+del == 0 {
+		ios.Success("No changes. Devices match the manifest.")
+	} else {
+		ios.Info("Plan: %d to create, %d to update, %d to delete.", create, update, del)
+	}
+	if failed := len(plan.Failed()); failed > 0 {
+		ios.Warning("%d device(s) could not be read and were not planned", failed)
+	}
+}
+
+// DisplayGitOpsApplyResult prints the outcome of one applied change.
+func DisplayGitOpsApplyResult(ios *iostreams.IOStreams, r gitops.ApplyResult) {
+	c := r.Change
+	if r.Err != nil {
+		ios.Printf("  %s %s: %s %s %s\n", theme.StatusError().Render("✗"), c.Device, c.Action, c.Kind, c.Name)
+		ios.Printf("      %s\n", theme.StatusError().Render(r.Err.Error()))
+		return
+	}
+	ios.Printf("  %s %s: %s %s %s\n", theme.StatusOK().Render("✓"), c.Device, c.Action, c.Kind, c.Name)
+}
+
+func gitOpsActionSymbol(action string) string {
+	switch action {
+	case gitops.ActionCreate:
+		return theme.StatusOK().Render("+")
+	case gitops.ActionDelete:
+		return theme.StatusError().Render("-")
+	default:
+		return theme.StatusWarn().Render("~")
+	}
+}
@@ -0,0 +1,32 @@
+package term
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/gitops"
+)
+
+func TestDisplayGitOpsPlan(t *testing.T) {
+	t.Parallel()
+
+	ios, out, errOut := testIOStreams()
+	plan := &gitops.Plan{Devices: []gitops.DevicePlan{
+		{Device: "kitchen", Changes: []gitops.Change{
+			{Device: "kitchen", Kind: gitops.KindConfig, Action: gitops.ActionUpdate, Name: "switch:0", Details: []string{`name: "Old" -> "Kitchen"`}},
+			{Device: "kitchen", Kind: gitops.KindScript, Action: gitops.ActionDelete, Name: "stray"},
+		}},
+		{Device: "porch", Warnings: []string{"1 schedule(s) on the device are not in the manifest"}},
+		{Device: "garage", Err: errors.New("offline")},
+	}}
+
+	DisplayGitOpsPlan(ios, plan)
+
+	output := out.String() + errOut.String()
+	for _, want := range []string{"switch:0", `"Kitchen"`, "script stray", "up to date", "1 schedule(s) on the device", "offline", "0 to create, 1 to update, 1 to delete", "1 device(s) could not be read"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
@@ -188,9 +188,19 @@ func DisplayDashboard(ios *iostreams.IOStreams, data model.DashboardData) {
 			data.CostCurrency, *data.EstimatedCost)
 	}
 
+	if data.CostToday != nil {
+		DisplayEnergyCost(ios, "Cost Today", *data.CostToday, data.CurrentRate)
+	} else if data.CurrentRate != nil {
+		ios.Printf("  Rate now:    %s/kWh (%s)\n", formatMoney(data.CurrentRate.Currency, data.CurrentRate.Rate), data.CurrentRate.Period)
+	}
+
 	ios.Printf("\n%s\n", theme.Bold().Render("Device Breakdown"))
 
-	builder := table.NewBuilder("Device", "Status", "Power", "Components")
+	headers := []string{"Device", "Status", "Power", "Components"}
+	if data.CostToday != nil {
+		headers = append(headers, "Cost Today")
+	}
+	builder := table.NewBuilder(headers...)
 	for _, dev := range data.Devices {
 		statusStr := theme.StatusOK().Render("online")
 		if !dev.Online {
@@ -202,7 +212,11 @@ func DisplayDashboard(ios *iostreams.IOStreams, data model.DashboardData) {
 			powerStr = "-"
 		}
 
-		builder.AddRow(dev.Device, statusStr, powerStr, formatComponentSummary(dev.Components))
+		row := []string{dev.Device, statusStr, powerStr, formatComponentSummary(dev.Components)}
+		if data.CostToday != nil {
+			row = append(row, formatOptionalCost(dev.CostToday))
+		}
+		builder.AddRow(row...)
 	}
 
 	tbl := builder.WithModeStyle(ios).Build()
@@ -211,6 +225,14 @@ func DisplayDashboard(ios *iostreams.IOStreams, data model.DashboardData) {
 	}
 }
 
+// formatOptionalCost formats a cost total, or "-" when there is none.
+func formatOptionalCost(cost *model.EnergyCost) string {
+	if cost == nil {
+		return "-"
+	}
+	return formatMoney(cost.Currency, cost.Total)
+}
+
 func formatComponentSummary(components []model.ComponentPower) string {
 	if len(components) == 0 {
 		return "-"
@@ -245,6 +267,9 @@ func DisplayComparison(ios *iostreams.IOStreams, data model.ComparisonData) {
 	ios.Printf("  Total Energy: %s\n", theme.StyledEnergy(data.TotalEnergy*1000))
 	ios.Printf("  Max Device:   %s\n", theme.StyledEnergy(data.MaxEnergy*1000))
 	ios.Printf("  Min Device:   %s\n", theme.StyledEnergy(data.MinEnergy*1000))
+	if data.Cost != nil {
+		DisplayEnergyCost(ios, "Cost", *data.Cost, nil)
+	}
 	ios.Printf("\n")
 
 	// Sort by energy consumption (descending) for display
@@ -252,7 +277,11 @@ func DisplayComparison(ios *iostreams.IOStreams, data model.ComparisonData) {
 
 	ios.Printf("%s\n", theme.Bold().Render("Device Breakdown"))
 
-	builder := table.NewBuilder("Rank", "Device", "Energy", "Avg Power", "Peak Power", "Share", "Status")
+	headers := []string{"Rank", "Device", "Energy", "Avg Power", "Peak Power", "Share"}
+	if data.Cost != nil {
+		headers = append(headers, "Cost")
+	}
+	builder := table.NewBuilder(append(headers, "Status")...)
 	for i, dev := range sorted {
 		rank := fmt.Sprintf("#%d", i+1)
 
@@ -273,7 +302,11 @@ func DisplayComparison(ios *iostreams.IOStreams, data model.ComparisonData) {
 			}
 		}
 
-		builder.AddRow(rank, dev.Device, energyStr, avgStr, peakStr, shareStr, statusStr)
+		row := []string{rank, dev.Device, energyStr, avgStr, peakStr, shareStr}
+		if data.Cost != nil {
+			row = append(row, formatOptionalCost(dev.Cost))
+		}
+		builder.AddRow(append(row, statusStr)...)
 	}
 
 	tbl := builder.WithModeStyle(ios).Build()
@@ -0,0 +1,106 @@
+package term
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/output/table"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rollout"
+	"github.com/tj-smith47/shelly-cli/internal/theme"
+)
+
+// DisplayRolloutPlan prints the waves of a firmware rollout.
+func DisplayRolloutPlan(ios *iostreams.IOStreams, waves []config.RolloutWave) {
+	ios.Println("")
+	ios.Printf("%s\n", theme.Bold().Render("Rollout plan:"))
+	builder := table.NewBuilder("Wave", "Name", "Devices")
+	for i, w := range waves {
+		builder.AddRow(fmt.Sprintf("%d", i+1), w.Name, strings.Join(w.Devices, ", "))
+	}
+	tbl := builder.WithModeStyle(ios).Build()
+	if err := tbl.PrintTo(ios.Out); err != nil {
+		ios.DebugErr("print rollout plan table", err)
+	}
+	ios.Println("")
+}
+
+// DisplayRolloutEvent prints the progress of a running rollout.
+func DisplayRolloutEvent(ios *iostreams.IOStreams, e rollout.Event) {
+	if e.Device == "" {
+		ios.Printf("%s\n", theme.Bold().Render(fmt.Sprintf("Wave %d: %s", e.Wave+1, e.WaveName)))
+		return
+	}
+	switch e.Status {
+	case config.RolloutUpdating:
+		ios.Info("Updating %s...", e.Device)
+	case config.RolloutHealthy:
+		ios.Success("%s is healthy", e.Device)
+	case config.RolloutSkipped:
+		ios.Warning("Skipped %s: %s", e.Device, e.Problem)
+	case config.RolloutRolledBack:
+		ios.Error("%s failed and was rolled back: %s", e.Device, e.Problem)
+	default:
+		ios.Error("%s failed: %s", e.Device, e.Problem)
+	}
+}
+
+// DisplayRolloutState prints the recorded firmware rollout.
+func DisplayRolloutState(ios *iostreams.IOStreams, state *config.RolloutState) {
+	switch {
+	case state.Completed:
+		ios.Success("Rollout completed")
+	case state.Halted:
+		ios.Error("Rollout halted: %s", state.HaltReason)
+	default:
+		ios.Info("Rollout in progress")
+	}
+	ios.Printf("  Started: %s\n", state.StartedAt.Format("2006-01-02 15:04:05"))
+	ios.Printf("  Updated: %s\n", state.UpdatedAt.Format("2006-01-02 15:04:05"))
+	ios.Println("")
+
+	builder := table.NewBuilder("Wave", "Device", "Status", "From", "To", "Problem")
+	for i, w := range state.Waves {
+		for _, name := range w.Devices {
+			d := state.Devices[name]
+			builder.AddRow(
+				fmt.Sprintf("%d %s", i+1, w.Name),
+				name,
+				rolloutStatusStyle(d.Status),
+				d.FromVersion,
+				d.ToVersion,
+				d.Problem,
+			)
+		}
+	}
+	tbl := builder.WithModeStyle(ios).Build()
+	if err := tbl.PrintTo(ios.Out); err != nil {
+		ios.DebugErr("print rollout state table", err)
+	}
+
+	counts := rollout.Counts(state)
+	ios.Println("")
+	ios.Printf("%d healthy, %d failed, %d rolled back, %d skipped, %d pending\n",
+		counts[config.RolloutHealthy], counts[config.RolloutFailed], counts[config.RolloutRolledBack],
+		counts[config.RolloutSkipped], counts[config.RolloutPending]+counts[config.RolloutUpdating])
+	if state.Halted {
+		ios.Info("Fix the failed devices, then run 'shelly firmware rollout resume --retry-failed', or 'shelly firmware rollout discard'.")
+	}
+}
+
+func rolloutStatusStyle(status config.RolloutDeviceStatus) string {
+	s := string(status)
+	switch status {
+	case config.RolloutHealthy:
+		return theme.StatusOK().Render(s)
+	case config.RolloutFailed, config.RolloutRolledBack:
+		return theme.StatusError().Render(s)
+	case config.RolloutSkipped:
+		return theme.StatusWarn().Render(s)
+	case config.RolloutUpdating:
+		return theme.StatusUpdating().Render(s)
+	default:
+		return s
+	}
+}
@@ -0,0 +1,61 @@
+package term
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rollout"
+)
+
+func TestDisplayRolloutPlan(t *testing.T) {
+	t.Parallel()
+	ios, out, _ := testIOStreams()
+
+	DisplayRolloutPlan(ios, []config.RolloutWave{
+		{Name: rollout.CanaryWave, Devices: []string{"office"}},
+		{Name: "model SHSW-1", Devices: []string{"garage", "shed"}},
+	})
+
+	for _, want := range []string{"canary", "office", "model SHSW-1", "garage, shed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDisplayRolloutEvent(t *testing.T) {
+	t.Parallel()
+	ios, out, errOut := testIOStreams()
+
+	DisplayRolloutEvent(ios, rollout.Event{Wave: 0, WaveName: rollout.CanaryWave})
+	DisplayRolloutEvent(ios, rollout.Event{Device: "office", Status: config.RolloutHealthy})
+	DisplayRolloutEvent(ios, rollout.Event{Device: "garage", Status: config.RolloutRolledBack, Problem: "not back online"})
+
+	all := out.String() + errOut.String()
+	for _, want := range []string{"Wave 1: canary", "office is healthy", "garage failed and was rolled back: not back online"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("output missing %q:\n%s", want, all)
+		}
+	}
+}
+
+func TestDisplayRolloutState(t *testing.T) {
+	t.Parallel()
+	ios, out, errOut := testIOStreams()
+
+	DisplayRolloutState(ios, &config.RolloutState{
+		Waves: []config.RolloutWave{{Name: "all", Devices: []string{"office", "garage"}}},
+		Devices: map[string]config.RolloutDevice{
+			"office": {Status: config.RolloutHealthy, FromVersion: "1.4.2", ToVersion: "1.4.4"},
+			"garage": {Status: config.RolloutPending},
+		},
+	})
+
+	all := out.String() + errOut.String()
+	for _, want := range []string{"in progress", "office", "1.4.4", "garage", "1 healthy", "1 pending"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("output missing %q:\n%s", want, all)
+		}
+	}
+}
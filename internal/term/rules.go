@@ -0,0 +1,61 @@
+package term
+
+import (
+	"strings"
+
+	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/output/table"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/rules"
+	"github.com/tj-smith47/shelly-cli/internal/theme"
+)
+
+// DisplayRuleList prints a table of rules.
+func DisplayRuleList(ios *iostreams.IOStreams, list []config.Rule) {
+	builder := table.NewBuilder("Name", "Trigger", "Conditions", "Actions", "Status")
+	for _, rule := range list {
+		actions := make([]string, 0, len(rule.Actions))
+		for _, a := range rule.Actions {
+			actions = append(actions, rules.DescribeAction(a))
+		}
+		status := theme.StatusOK().Render("enabled")
+		if rule.Disabled {
+			status = theme.Dim().Render("disabled")
+		}
+		conditions := "-"
+		if len(rule.Conditions) > 0 {
+			parts := make([]string, 0, len(rule.Conditions))
+			for _, c := range rule.Conditions {
+				parts = append(parts, c.Device+" "+c.Condition)
+			}
+			conditions = strings.Join(parts, "; ")
+		}
+		builder.AddRow(rule.Name, rules.DescribeTrigger(rule.Trigger), conditions, strings.Join(actions, ", "), status)
+	}
+
+	tbl := builder.WithModeStyle(ios).Build()
+	if err := tbl.PrintTo(ios.Out); err != nil {
+		ios.DebugErr("print rules table", err)
+	}
+	ios.Println()
+	ios.Count("rule", len(list))
+}
+
+// DisplayRuleOutcome prints the result of a fired rule.
+func DisplayRuleOutcome(ios *iostreams.IOStreams, out rules.Outcome) {
+	timestamp := out.Time.Format("15:04:05")
+
+	if out.Blocked != "" {
+		ios.Info("[%s] %s: %s, skipped (%s)", timestamp, out.Rule.Name, out.Cause, out.Blocked)
+		return
+	}
+
+	ios.Printf("[%s] %s: %s\n", timestamp, theme.Bold().Render(out.Rule.Name), out.Cause)
+	for _, a := range out.Actions {
+		if a.Err != nil {
+			ios.Printf("  %s %s: %v\n", theme.StatusError().Render("✗"), a.Action, a.Err)
+		} else {
+			ios.Printf("  %s %s\n", theme.StatusOK().Render("✓"), a.Action)
+		}
+	}
+}
@@ -2,11 +2,14 @@ package term
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/tj-smith47/shelly-cli/internal/config"
 	"github.com/tj-smith47/shelly-cli/internal/iostreams"
 	"github.com/tj-smith47/shelly-cli/internal/output"
 	"github.com/tj-smith47/shelly-cli/internal/output/table"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
 	"github.com/tj-smith47/shelly-cli/internal/theme"
 )
 
@@ -27,19 +30,36 @@ func DisplaySceneDetails(ios *iostreams.IOStreams, scene config.Scene) {
 	ios.Info("")
 	ios.Info("Actions (%d):", len(scene.Actions))
 
-	builder := table.NewBuilder("#", "Device", "Method", "Parameters")
+	timed := false
+	for _, action := range scene.Actions {
+		timed = timed || sceneActionTiming(action) != ""
+	}
+
+	headers := []string{"#", "Device", "Method", "Parameters"}
+	if timed {
+		headers = append(headers, "Timing")
+	}
+	builder := table.NewBuilder(headers...)
 
 	for i, action := range scene.Actions {
 		params := "-"
 		if len(action.Params) > 0 {
 			params = output.FormatParamsInline(action.Params)
 		}
-		builder.AddRow(
+		row := []string{
 			theme.Dim().Render(fmt.Sprintf("%d", i+1)),
 			theme.Bold().Render(action.Device),
 			theme.Highlight().Render(action.Method),
 			params,
-		)
+		}
+		if timed {
+			timing := sceneActionTiming(action)
+			if timing == "" {
+				timing = "-"
+			}
+			row = append(row, timing)
+		}
+		builder.AddRow(row...)
 	}
 
 	tbl := builder.WithModeStyle(ios).Build()
@@ -47,3 +67,103 @@ func DisplaySceneDetails(ios *iostreams.IOStreams, scene config.Scene) {
 		ios.DebugErr("print scene actions table", err)
 	}
 }
+
+// DisplayScenePlan prints a scene's actions in execution order, grouped into
+// the stages that run one after another.
+func DisplayScenePlan(ios *iostreams.IOStreams, scene config.Scene) {
+	stages := shelly.PlanScene(scene.Actions)
+	for _, stage := range stages {
+		if len(stages) > 1 {
+			ios.Info("Group %d:", stage.Group)
+		}
+		for _, step := range stage.Steps {
+			action := step.Action
+			params := output.FormatParamsInline(action.Params)
+			if params != "" {
+				params = theme.Dim().Render("{" + params + "}")
+			}
+			timing := ""
+			if t := sceneActionTiming(config.SceneAction{Delay: action.Delay, Transition: action.Transition}); t != "" {
+				timing = theme.Dim().Render("(" + t + ")")
+			}
+			ios.Info("  %d. %s %s %s %s",
+				step.Index+1,
+				theme.Bold().Render(action.Device),
+				theme.Highlight().Render(action.Method),
+				params,
+				timing,
+			)
+		}
+	}
+}
+
+// sceneActionTiming describes an action's group, delay and transition.
+func sceneActionTiming(a config.SceneAction) string {
+	var parts []string
+	if a.Group != 0 {
+		parts = append(parts, fmt.Sprintf("group %d", a.Group))
+	}
+	if a.Delay != "" {
+		parts = append(parts, "delay "+a.Delay)
+	}
+	if a.Transition != "" {
+		parts = append(parts, "transition "+a.Transition)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SceneProgress shows per-action progress while a scene runs.
+type SceneProgress struct {
+	mw  *iostreams.MultiWriter
+	ids []string
+}
+
+// NewSceneProgress adds a pending line for each action, labelled
+// device:method (suffixed with the action number when repeated).
+func NewSceneProgress(ios *iostreams.IOStreams, actions []config.SceneAction) *SceneProgress {
+	p := &SceneProgress{
+		mw:  iostreams.NewMultiWriter(ios.Out, ios.IsStdoutTTY()),
+		ids: make([]string, len(actions)),
+	}
+	seen := make(map[string]bool, len(actions))
+	for i, action := range actions {
+		id := fmt.Sprintf("%s:%s", action.Device, action.Method)
+		if seen[id] {
+			id = fmt.Sprintf("%s#%d", id, i+1)
+		}
+		seen[id] = true
+		p.ids[i] = id
+		p.mw.AddLine(id, "pending")
+	}
+	return p
+}
+
+// Options returns run options that report progress, with the given
+// concurrency and per-action timeout.
+func (p *SceneProgress) Options(concurrent int, timeout time.Duration) shelly.SceneRunOptions {
+	return shelly.SceneRunOptions{
+		Concurrent: concurrent,
+		Timeout:    timeout,
+		OnStart: func(step shelly.SceneStep) {
+			params := output.FormatParamsInline(shelly.SceneActionParams(step.Action))
+			if params != "" {
+				params = theme.Dim().Render("{" + params + "}")
+			}
+			p.mw.UpdateLine(p.ids[step.Index], iostreams.StatusRunning, params)
+		},
+		OnDone: func(step shelly.SceneStep, err error) {
+			if err != nil {
+				p.mw.UpdateLine(p.ids[step.Index], iostreams.StatusError, err.Error())
+			} else {
+				p.mw.UpdateLine(p.ids[step.Index], iostreams.StatusSuccess, "done")
+			}
+		},
+	}
+}
+
+// Finish finalizes the progress display and returns the action counts.
+func (p *SceneProgress) Finish() (succeeded, failed int) {
+	p.mw.Finalize()
+	succeeded, failed, _ = p.mw.Summary()
+	return succeeded, failed
+}
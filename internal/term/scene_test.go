@@ -135,3 +135,32 @@ func TestDisplaySceneDetails(t *testing.T) {
 		}
 	})
 }
+
+func TestDisplayScenePlan(t *testing.T) {
+	t.Parallel()
+
+	ios, out, errOut := testIOStreams()
+	scene := config.Scene{
+		Name: "movie-night",
+		Actions: []config.SceneAction{
+			{Device: "blinds", Method: "Cover.Close", Group: 1},
+			{Device: "lamp", Method: "Light.Set", Params: map[string]any{"brightness": 10}, Transition: "3s"},
+		},
+	}
+
+	DisplayScenePlan(ios, scene)
+	DisplaySceneDetails(ios, scene)
+
+	allOutput := out.String() + errOut.String()
+	for _, want := range []string{"Group 0:", "Group 1:", "transition 3s", "group 1"} {
+		if !strings.Contains(allOutput, want) {
+			t.Errorf("output should contain %q:\n%s", want, allOutput)
+		}
+	}
+	if !strings.Contains(strings.ToLower(allOutput), "timing") {
+		t.Error("details should include a timing column")
+	}
+	if strings.Index(allOutput, "lamp") > strings.Index(allOutput, "blinds") {
+		t.Error("group 0 actions should be listed before group 1")
+	}
+}
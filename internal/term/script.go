@@ -3,12 +3,15 @@ package term
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/tj-smith47/shelly-cli/internal/config"
 	"github.com/tj-smith47/shelly-cli/internal/iostreams"
 	"github.com/tj-smith47/shelly-cli/internal/output"
 	"github.com/tj-smith47/shelly-cli/internal/output/table"
 	"github.com/tj-smith47/shelly-cli/internal/shelly/automation"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptdev"
 	"github.com/tj-smith47/shelly-cli/internal/theme"
 )
 
@@ -147,3 +150,36 @@ func DisplayScriptTemplate(ios *iostreams.IOStreams, tpl config.ScriptTemplate)
 	ios.Println(theme.Dim().Render("─────────────────────────────────────────"))
 	ios.Println(tpl.Code)
 }
+
+// DisplayScriptLogEntry prints a line of a device debug log while
+// developing a script. When the line points into the script source, the
+// source line is printed below it.
+func DisplayScriptLogEntry(ios *iostreams.IOStreams, entry scriptdev.LogEntry, loc *scriptdev.Location) {
+	ts := entry.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	stamp := theme.Dim().Render(ts.Format("15:04:05"))
+	text := entry.Text
+	if loc != nil || scriptdev.IsError(text) {
+		text = theme.StatusError().Render(text)
+	}
+	ios.Printf("%s %s\n", stamp, text)
+	if loc != nil {
+		DisplayScriptSourceLocation(ios, *loc)
+	}
+}
+
+// DisplayScriptSourceLocation prints a source position with its code and a
+// marker under the column.
+func DisplayScriptSourceLocation(ios *iostreams.IOStreams, loc scriptdev.Location) {
+	ios.Printf("  --> %s\n", theme.Highlight().Render(loc.String()))
+	if loc.Code == "" {
+		return
+	}
+	gutter := fmt.Sprintf("%4d | ", loc.Line)
+	ios.Printf("  %s%s\n", theme.Dim().Render(gutter), loc.Code)
+	if loc.Col > 0 {
+		ios.Printf("  %s%s\n", strings.Repeat(" ", len(gutter)+loc.Col-1), theme.StatusError().Render("^"))
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/tj-smith47/shelly-cli/internal/config"
 	"github.com/tj-smith47/shelly-cli/internal/shelly/automation"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/scriptdev"
 )
 
 func TestDisplayScriptEvalResult_Nil(t *testing.T) {
@@ -270,3 +271,19 @@ func TestDisplayScriptTemplate_Minimal(t *testing.T) {
 		t.Error("expected user-defined source")
 	}
 }
+
+func TestDisplayScriptLogEntry(t *testing.T) {
+	t.Parallel()
+
+	ios, out, _ := testIOStreams()
+	DisplayScriptLogEntry(ios, scriptdev.LogEntry{Text: "temperature: 21.5"}, nil)
+	DisplayScriptLogEntry(ios, scriptdev.LogEntry{Text: "Uncaught Error: boom at line 2 col 9"},
+		&scriptdev.Location{File: "main.js", Line: 2, Col: 9, Code: "let b = foo(a);"})
+
+	output := out.String()
+	for _, want := range []string{"temperature: 21.5", "Uncaught Error: boom", "main.js:2:9", "let b = foo(a);", "^"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package term
+
+import (
+	"fmt"
+
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/output"
+	"github.com/tj-smith47/shelly-cli/internal/output/table"
+	"github.com/tj-smith47/shelly-cli/internal/theme"
+)
+
+// DisplaySolarReport prints the self-consumption analysis: a summary of the
+// whole range followed by the per-day breakdown.
+func DisplaySolarReport(ios *iostreams.IOStreams, report model.SolarReport) {
+	hasPV := report.PV != ""
+
+	ios.Printf("%s\n", theme.Bold().Render("Solar Analysis"))
+	ios.Printf("Grid:   %s\n", report.Grid)
+	if hasPV {
+		ios.Printf("PV:     %s\n", report.PV)
+	}
+	ios.Printf("From:   %s\n", report.From.Format("2006-01-02 15:04:05"))
+	ios.Printf("To:     %s\n", report.To.Format("2006-01-02 15:04:05"))
+
+	if len(report.Days) == 0 {
+		ios.Printf("\n")
+		ios.Warning("No history recorded in this range")
+		return
+	}
+
+	total := report.Total
+	ios.Printf("\n%s\n", theme.Bold().Render("Summary"))
+	ios.Printf("  Import:           %s\n", theme.StyledEnergy(total.ImportKWh*1000))
+	ios.Printf("  Export:           %s\n", theme.StyledEnergy(total.ExportKWh*1000))
+	if hasPV {
+		ios.Printf("  Production:       %s\n", theme.StyledEnergy(total.ProductionKWh*1000))
+		ios.Printf("  Consumption:      %s\n", theme.StyledEnergy(total.ConsumptionKWh*1000))
+		ios.Printf("  Self-consumption: %s\n", theme.Highlight().Render(formatOptionalPercent(total.SelfConsumption)))
+		ios.Printf("  Autarky:          %s\n", theme.Highlight().Render(formatOptionalPercent(total.Autarky)))
+	}
+	if total.PeakDemand > 0 {
+		ios.Printf("  Peak demand:      %s at %s\n", output.FormatPower(total.PeakDemand), total.PeakDemandAt.Local().Format("2006-01-02 15:04"))
+	}
+	if total.PhaseImbalance != nil {
+		ios.Printf("  Phase imbalance:  %s\n", formatOptionalPercent(total.PhaseImbalance))
+	}
+
+	ios.Printf("\n%s\n", theme.Bold().Render("Daily Breakdown"))
+	headers := []string{"Date", "Import", "Export"}
+	if hasPV {
+		headers = append(headers, "Production", "Self-use", "Autarky")
+	}
+	headers = append(headers, "Peak Demand")
+	if total.PhaseImbalance != nil {
+		headers = append(headers, "Imbalance")
+	}
+
+	builder := table.NewBuilder(headers...)
+	for _, day := range report.Days {
+		row := []string{day.Date, output.FormatEnergy(day.ImportKWh * 1000), output.FormatEnergy(day.ExportKWh * 1000)}
+		if hasPV {
+			row = append(row,
+				output.FormatEnergy(day.ProductionKWh*1000),
+				formatOptionalPercent(day.SelfConsumption),
+				formatOptionalPercent(day.Autarky),
+			)
+		}
+		row = append(row, output.FormatPower(day.PeakDemand))
+		if total.PhaseImbalance != nil {
+			row = append(row, formatOptionalPercent(day.PhaseImbalance))
+		}
+		builder.AddRow(row...)
+	}
+
+	tbl := builder.WithModeStyle(ios).Build()
+	if err := tbl.PrintTo(ios.Out); err != nil {
+		ios.DebugErr("print solar table", err)
+	}
+}
+
+// formatOptionalPercent formats a percentage, or "-" when it is not known.
+func formatOptionalPercent(pct *float64) string {
+	if pct == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", *pct)
+}
@@ -0,0 +1,63 @@
+package term
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj-smith47/shelly-cli/internal/model"
+)
+
+func TestDisplaySolarReport(t *testing.T) {
+	t.Parallel()
+
+	ios, out, _ := testIOStreams()
+	selfUse, autarky, imbalance := 40.0, 62.5, 12.3
+	summary := model.SolarSummary{
+		ImportKWh:       3,
+		ExportKWh:       6,
+		ProductionKWh:   10,
+		SelfConsumedKWh: 4,
+		ConsumptionKWh:  7,
+		SelfConsumption: &selfUse,
+		Autarky:         &autarky,
+		PeakDemand:      4200,
+		PeakDemandAt:    time.Date(2026, 6, 1, 18, 15, 0, 0, time.Local),
+		PhaseImbalance:  &imbalance,
+	}
+	DisplaySolarReport(ios, model.SolarReport{
+		Grid:  "house",
+		PV:    "inverter",
+		From:  time.Date(2026, 6, 1, 0, 0, 0, 0, time.Local),
+		To:    time.Date(2026, 6, 2, 0, 0, 0, 0, time.Local),
+		Days:  []model.SolarDay{{Date: "2026-06-01", SolarSummary: summary}},
+		Total: summary,
+	})
+
+	got := out.String()
+	for _, want := range []string{"inverter", "Self-consumption", "40.0%", "62.5%", "12.3%", "2026-06-01 18:15", "Daily Breakdown", "Production"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestDisplaySolarReport_GridOnly(t *testing.T) {
+	t.Parallel()
+
+	ios, out, _ := testIOStreams()
+	summary := model.SolarSummary{ImportKWh: 5, PeakDemand: 2000}
+	DisplaySolarReport(ios, model.SolarReport{
+		Grid:  "house",
+		Days:  []model.SolarDay{{Date: "2026-06-01", SolarSummary: summary}},
+		Total: summary,
+	})
+
+	got := out.String()
+	if strings.Contains(got, "Autarky") || strings.Contains(got, "Imbalance") {
+		t.Errorf("grid-only output shows PV or phase columns:\n%s", got)
+	}
+	if !strings.Contains(got, "2026-06-01") {
+		t.Errorf("output missing day row:\n%s", got)
+	}
+}
@@ -1,6 +1,13 @@
 package term
 
-import "github.com/tj-smith47/shelly-cli/internal/iostreams"
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
+	"github.com/tj-smith47/shelly-cli/internal/theme"
+)
 
 // DisplaySyncProgress displays the sync status for a device.
 func DisplaySyncProgress(ios *iostreams.IOStreams, device, status string) {
@@ -41,8 +48,67 @@ func DisplaySyncHeader(ios *iostreams.IOStreams, op string, count int, dryRun bo
 }
 
 func direction(op string) string {
-	if op == "Pulling" {
+	switch op {
+	case "Pulling":
 		return "from"
+	case "Merging":
+		return "with"
 	}
 	return "to"
 }
+
+// DisplaySyncMerge displays the changes a three-way sync merge would make.
+func DisplaySyncMerge(ios *iostreams.IOStreams, m *shelly.SyncMerge) {
+	if !m.Changed() {
+		ios.Printf("  %s: %s\n", m.Device, theme.Dim().Render("in sync"))
+		return
+	}
+
+	ios.Printf("  %s:\n", theme.Bold().Render(m.Device))
+	for _, c := range m.ToDevice {
+		ios.Printf("    %s %s: %s -> %s\n", theme.StatusOK().Render("device <"), c.Key(), FormatSyncValue(c.Device), FormatSyncValue(c.Local))
+	}
+	for _, c := range m.ToLocal {
+		ios.Printf("    %s %s: %s -> %s\n", theme.StatusOK().Render("local  <"), c.Key(), FormatSyncValue(c.Local), FormatSyncValue(c.Device))
+	}
+	for _, c := range m.Conflicts {
+		label := theme.StatusWarn().Render("conflict")
+		if c.Resolution != shelly.SyncPreferNone {
+			label = theme.StatusWarn().Render("use " + string(c.Resolution))
+		}
+		ios.Printf("    %s %s: local %s, device %s\n", label, c.Key(), FormatSyncValue(c.Local), FormatSyncValue(c.Device))
+	}
+}
+
+// DisplayMergeSummary displays the overall merge summary.
+func DisplayMergeSummary(ios *iostreams.IOStreams, synced, unchanged, conflicted, failed int, dryRun bool) {
+	ios.Println()
+	verb := "synced"
+	if dryRun {
+		verb = "would sync"
+	}
+	if conflicted > 0 || failed > 0 {
+		ios.Warning("Completed: %d %s, %d unchanged, %d with unresolved conflicts, %d failed", synced, verb, unchanged, conflicted, failed)
+		if conflicted > 0 {
+			ios.Info("Resolve conflicts with --prefer device or --prefer local")
+		}
+		return
+	}
+	ios.Success("Completed: %d %s, %d unchanged", synced, verb, unchanged)
+}
+
+// FormatSyncValue formats a config value for sync output.
+func FormatSyncValue(v any) string {
+	if shelly.IsSyncUnset(v) {
+		return "(unset)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	const maxLen = 40
+	if s := string(data); len(s) > maxLen {
+		return s[:maxLen-3] + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,47 @@
+package term
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tj-smith47/shelly-cli/internal/iostreams"
+	"github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/theme"
+)
+
+// DisplayEnergyCost prints a cost section: the rate in effect (when given),
+// import and export energy with their cost, and the per-period breakdown.
+func DisplayEnergyCost(ios *iostreams.IOStreams, title string, cost model.EnergyCost, rate *model.TariffRate) {
+	ios.Printf("\n%s\n", theme.Bold().Render(title))
+	if rate != nil {
+		ios.Printf("  Rate now:   %s/kWh (%s)\n", formatMoney(rate.Currency, rate.Rate), rate.Period)
+	}
+	ios.Printf("  Import:     %.3f kWh = %s\n", cost.ImportKWh, formatMoney(cost.Currency, cost.ImportCost))
+	if cost.ExportKWh > 0 {
+		ios.Printf("  Export:     %.3f kWh = -%s\n", cost.ExportKWh, formatMoney(cost.Currency, cost.ExportCredit))
+	}
+	if cost.StandingCharge > 0 {
+		ios.Printf("  Standing:   %s\n", formatMoney(cost.Currency, cost.StandingCharge))
+	}
+	if len(cost.Periods) > 1 {
+		ios.Printf("  By period:  %s\n", formatPeriodCosts(cost))
+	}
+	ios.Printf("  Total:      %s\n", theme.Highlight().Render(formatMoney(cost.Currency, cost.Total)))
+}
+
+// formatMoney formats an amount with its currency symbol.
+func formatMoney(currency string, amount float64) string {
+	if amount < 0 {
+		return fmt.Sprintf("-%s%.2f", currency, -amount)
+	}
+	return fmt.Sprintf("%s%.2f", currency, amount)
+}
+
+// formatPeriodCosts formats the per-period breakdown of a cost on one line.
+func formatPeriodCosts(cost model.EnergyCost) string {
+	parts := make([]string, len(cost.Periods))
+	for i, p := range cost.Periods {
+		parts[i] = fmt.Sprintf("%s %.2f kWh %s", p.Name, p.KWh, formatMoney(cost.Currency, p.Cost))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,58 @@
+package term
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tj-smith47/shelly-cli/internal/model"
+)
+
+func TestDisplayEnergyCost(t *testing.T) {
+	t.Parallel()
+
+	ios, out, _ := testIOStreams()
+	cost := model.EnergyCost{
+		Currency:       "€",
+		ImportKWh:      3.5,
+		ExportKWh:      3,
+		ImportCost:     1.025,
+		ExportCredit:   0.24,
+		StandingCharge: 0.48,
+		Total:          1.265,
+		Periods: []model.PeriodCost{
+			{Name: "peak", KWh: 2, Cost: 0.8},
+			{Name: "night", KWh: 1.5, Cost: 0.225},
+		},
+	}
+	rate := &model.TariffRate{Period: "peak", Rate: 0.4, Currency: "€"}
+	DisplayEnergyCost(ios, "Cost Today", cost, rate)
+
+	got := out.String()
+	for _, want := range []string{"Cost Today", "€0.40/kWh (peak)", "3.500 kWh = €1.02", "-€0.24", "€0.48", "night 1.50 kWh €0.23", "€1.26"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestDisplayComparison_Cost(t *testing.T) {
+	t.Parallel()
+
+	ios, out, _ := testIOStreams()
+	cost := &model.EnergyCost{Currency: "$", ImportKWh: 1.5, ImportCost: 0.3, Total: 0.3}
+	DisplayComparison(ios, model.ComparisonData{
+		Period:      "day",
+		TotalEnergy: 1.5,
+		MaxEnergy:   1.5,
+		Devices: []model.DeviceEnergy{
+			{Device: "kitchen", Energy: 1.5, Online: true, Cost: cost},
+			{Device: "porch", Online: true},
+		},
+		Cost: cost,
+	})
+
+	got := out.String()
+	if !strings.Contains(got, "Cost") || !strings.Contains(got, "$0.30") {
+		t.Errorf("output missing cost:\n%s", got)
+	}
+}
@@ -0,0 +1,117 @@
+// Package mqtttest provides an in-process MQTT broker stand-in for tests.
+package mqtttest
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+)
+
+// Broker is a minimal MQTT broker stand-in. It accepts any number of
+// connections, acknowledges CONNECT, SUBSCRIBE and QoS 1 publishes, and
+// records every CONNECT, PUBLISH and SUBSCRIBE. Messages are not routed to
+// subscribers.
+type Broker struct {
+	// URL is the broker address, e.g. tcp://127.0.0.1:40000.
+	URL string
+	// Connected receives every CONNECT, buffered up to 100.
+	Connected <-chan *packets.ConnectPacket
+	// Published receives every PUBLISH, buffered up to 100.
+	Published <-chan *packets.PublishPacket
+	// Subscribed receives every SUBSCRIBE, buffered up to 100.
+	Subscribed <-chan *packets.SubscribePacket
+
+	connected  chan *packets.ConnectPacket
+	published  chan *packets.PublishPacket
+	subscribed chan *packets.SubscribePacket
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+// NewBroker starts a broker that is stopped when the test ends.
+func NewBroker(t *testing.T) *Broker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	b := &Broker{
+		URL:        "tcp://" + ln.Addr().String(),
+		connected:  make(chan *packets.ConnectPacket, 100),
+		published:  make(chan *packets.PublishPacket, 100),
+		subscribed: make(chan *packets.SubscribePacket, 100),
+	}
+	b.Connected, b.Published, b.Subscribed = b.connected, b.published, b.subscribed
+	t.Cleanup(func() {
+		_ = ln.Close() //nolint:errcheck // test cleanup
+		b.DropConnections()
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			b.mu.Lock()
+			b.conns = append(b.conns, conn)
+			b.mu.Unlock()
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+// DropConnections closes every open client connection, as a broker restart
+// would.
+func (b *Broker) DropConnections() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, conn := range b.conns {
+		_ = conn.Close() //nolint:errcheck // test cleanup
+	}
+	b.conns = nil
+}
+
+func (b *Broker) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }() //nolint:errcheck // test cleanup
+
+	for {
+		pkt, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		var reply packets.ControlPacket
+		switch p := pkt.(type) {
+		case *packets.ConnectPacket:
+			b.connected <- p
+			reply = packets.NewControlPacket(packets.Connack)
+		case *packets.PublishPacket:
+			b.published <- p
+			if p.Qos == 1 {
+				ack, _ := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket) //nolint:errcheck // known type
+				ack.MessageID = p.MessageID
+				reply = ack
+			}
+		case *packets.SubscribePacket:
+			b.subscribed <- p
+			ack, _ := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket) //nolint:errcheck // known type
+			ack.MessageID = p.MessageID
+			ack.ReturnCodes = p.Qoss
+			reply = ack
+		case *packets.PingreqPacket:
+			reply = packets.NewControlPacket(packets.Pingresp)
+		case *packets.DisconnectPacket:
+			return
+		}
+		if reply != nil {
+			if err := reply.Write(conn); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/tj-smith47/shelly-cli/internal/config"
 	"github.com/tj-smith47/shelly-cli/internal/iostreams"
 	devmodel "github.com/tj-smith47/shelly-cli/internal/model"
+	"github.com/tj-smith47/shelly-cli/internal/shelly"
 	"github.com/tj-smith47/shelly-cli/internal/shelly/automation"
 	"github.com/tj-smith47/shelly-cli/internal/theme"
 	"github.com/tj-smith47/shelly-cli/internal/tui/cache"
@@ -240,6 +241,9 @@ func New(ctx context.Context, f *cmdutil.Factory, opts Options) Model {
 
 	// Create energy history sparklines component
 	ehm := energyhistory.New(deviceCache)
+	if mgr, err := f.ConfigManager(); err == nil {
+		ehm = ehm.SetStore(shelly.OpenEnergyStore(mgr))
+	}
 	energyHistoryModel := &ehm
 
 	// Create JSON viewer component
@@ -2866,6 +2870,8 @@ func (m Model) dispatchMonitorDeviceAction(action keys.Action) (Model, tea.Cmd,
 			return m.showMonitorEnergyHistory()
 		case keys.ActionPhaseDetail:
 			return m.showMonitorPhaseDetail()
+		case keys.ActionSolar:
+			return m.showMonitorSolar()
 		default:
 			// Not a power-ranking action; fall through to global actions
 		}
@@ -2992,6 +2998,17 @@ func (m Model) showMonitorPhaseDetail() (Model, tea.Cmd, bool) {
 	return m, cmd, true
 }
 
+// showMonitorSolar opens the solar analysis overlay with the selected device
+// as the grid meter.
+func (m Model) showMonitorSolar() (Model, tea.Cmd, bool) {
+	dev := m.getMonitorSelectedDevice()
+	if dev == nil || !dev.Online {
+		return m, nil, false
+	}
+	cmd := m.viewManager.Update(views.SolarRequestMsg{DeviceName: dev.Name, Address: dev.Address})
+	return m, cmd, true
+}
+
 // openMonitorJSONViewer opens the JSON viewer for the Monitor's selected device.
 func (m Model) openMonitorJSONViewer() (Model, tea.Cmd, bool) {
 	dev := m.getMonitorSelectedDevice()
@@ -8,6 +8,7 @@ import (
 	"charm.land/lipgloss/v2"
 
 	"github.com/tj-smith47/shelly-cli/internal/config"
+	"github.com/tj-smith47/shelly-cli/internal/shelly/alerting"
 	"github.com/tj-smith47/shelly-cli/internal/theme"
 	"github.com/tj-smith47/shelly-cli/internal/tui/components/form"
 	"github.com/tj-smith47/shelly-cli/internal/tui/keyconst"
@@ -454,15 +455,5 @@ func validateCondition(s string) error {
 		return fmt.Errorf("required")
 	}
 
-	// Valid patterns: offline, online, metric>N, metric<N
-	s = strings.ToLower(strings.TrimSpace(s))
-	if s == "offline" || s == "online" {
-		return nil
-	}
-
-	if strings.Contains(s, ">") || strings.Contains(s, "<") {
-		return nil
-	}
-
-	return fmt.Errorf("use: offline, online, power>N, temperature>N")
+	return alerting.Validate(s)
 }
@@ -407,14 +407,13 @@ func (m *Model) TestAlert(name string) tea.Cmd {
 			return AlertActionResultMsg{Action: actionTest, Name: name, Err: fmt.Errorf("service not available")}
 		}
 
-		// Check the alert condition
-		state := &shelly.AlertState{}
-		result := m.svc.CheckAlert(m.ctx, alert, state)
+		// Check the alert condition against the current status
+		result := m.svc.EvaluateAlertCondition(m.ctx, alert)
 
 		// Return result with value
 		return AlertTestResultMsg{
 			Name:      name,
-			Triggered: result.Action == shelly.AlertActionTriggered,
+			Triggered: result.Triggered,
 			Value:     result.Value,
 		}
 	}
@@ -12,6 +12,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 
+	"github.com/tj-smith47/shelly-cli/internal/shelly/energystore"
 	"github.com/tj-smith47/shelly-cli/internal/theme"
 	"github.com/tj-smith47/shelly-cli/internal/tui/cache"
 	"github.com/tj-smith47/shelly-cli/internal/tui/debug"
@@ -84,6 +85,10 @@ type Model struct {
 	focused        bool
 	panelIndex     int // For Shift+N hint
 	loading        bool
+	store          *energystore.Store // Local energy store for longer ranges (nil: live only)
+	rangeIdx       int                // Index into historyRanges
+	stored         []historyEntry     // Entries of the selected stored range
+	storedAt       time.Time          // When stored was last requested
 }
 
 // Styles for the energy history component.
@@ -154,7 +159,14 @@ func (m *Model) Init() tea.Cmd {
 func (m *Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	// Handle navigation messages
 	if navMsg, ok := msg.(messages.NavigationMsg); ok {
-		m.handleNavigation(navMsg)
+		return m.handleNavigation(navMsg)
+	}
+
+	if stored, ok := msg.(storedHistoryMsg); ok {
+		if stored.rangeIdx == m.rangeIdx {
+			m.stored = sortEntries(stored.entries)
+			m.storedAt = time.Now()
+		}
 		return *m, nil
 	}
 
@@ -177,14 +189,24 @@ func (m *Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	switch msg.(type) {
 	case cache.DeviceUpdateMsg, cache.AllDevicesLoadedMsg:
 		m.handleDeviceUpdate()
+		return *m, m.refreshStored()
 	}
 
 	return *m, nil
 }
 
-// handleNavigation handles NavigationMsg for scrolling.
-func (m *Model) handleNavigation(msg messages.NavigationMsg) {
-	m.scroller.HandleNavigation(msg)
+// handleNavigation handles NavigationMsg for scrolling; left/right switch
+// the history range.
+func (m *Model) handleNavigation(msg messages.NavigationMsg) (Model, tea.Cmd) {
+	switch msg.Direction {
+	case messages.NavLeft:
+		return m.CycleRange(-1)
+	case messages.NavRight:
+		return m.CycleRange(1)
+	default:
+		m.scroller.HandleNavigation(msg)
+		return *m, nil
+	}
 }
 
 func (m *Model) handleDeviceUpdate() {
@@ -322,13 +344,20 @@ type historyEntry struct {
 
 // View renders the energy history.
 func (m *Model) View() string {
+	if m.rangeIdx > 0 {
+		return m.renderEntries(m.stored)
+	}
+
 	// Handle special states first
 	if result, handled := m.handleSpecialStates(); handled {
 		return result
 	}
 
-	// Get sorted entries
-	entries := m.getSortedEntries()
+	return m.renderEntries(m.getSortedEntries())
+}
+
+// renderEntries renders sorted entries in the panel.
+func (m *Model) renderEntries(entries []historyEntry) string {
 	if len(entries) == 0 {
 		return m.renderNoData()
 	}
@@ -377,7 +406,11 @@ func (m *Model) getSortedEntries() []historyEntry {
 	}
 	m.mu.RUnlock()
 	debug.TraceUnlock("energyhistory", "RLock", "View")
+	return sortEntries(entries)
+}
 
+// sortEntries sorts entries in place and returns them.
+func sortEntries(entries []historyEntry) []historyEntry {
 	// Sort by power descending, then by key label for stable ordering when power values are equal
 	slices.SortFunc(entries, func(a, b historyEntry) int {
 		if c := cmp.Compare(b.power, a.power); c != 0 {
@@ -428,7 +461,7 @@ func (m *Model) buildPanel(content string, entryCount int) string {
 		SetBadge(legend).
 		SetFocused(m.focused).
 		SetPanelIndex(m.panelIndex).
-		SetFooter("5m·····now")
+		SetFooter(m.Range() + "·····now")
 
 	return r.SetContent(content).Render()
 }
@@ -701,15 +734,19 @@ func (m *Model) renderEmpty() string {
 }
 
 func (m *Model) renderNoData() string {
+	text := "Collecting energy data...\nHistory will appear after a few updates."
+	if m.rangeIdx > 0 {
+		text = "No stored energy data for the last " + m.Range() + ".\nRecord it with: shelly energy collect"
+	}
 	r := rendering.New(m.Width, m.Height).
 		SetTitle("Energy History").
-		SetBadge("5 min").
+		SetBadge(m.Range()).
 		SetFocused(false)
 	centered := lipgloss.NewStyle().
 		Width(m.Width-4).
 		Height(m.Height-2).
 		Align(lipgloss.Center, lipgloss.Center).
-		Render("Collecting energy data...\nHistory will appear after a few updates.")
+		Render(text)
 	return r.SetContent(centered).Render()
 }
 
@@ -1,10 +1,14 @@
 package energyhistory
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/afero"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/energystore"
 )
 
 func TestNew(t *testing.T) {
@@ -283,3 +287,50 @@ func TestSwitchKey_String(t *testing.T) {
 		})
 	}
 }
+
+func TestModel_CycleRange_NoStore(t *testing.T) {
+	t.Parallel()
+	m := New(nil)
+	m, cmd := m.CycleRange(1)
+	if cmd != nil {
+		t.Error("CycleRange() without a store should not load")
+	}
+	if m.Range() != "5m" {
+		t.Errorf("Range() = %q, want 5m", m.Range())
+	}
+}
+
+func TestModel_StoredRange(t *testing.T) {
+	t.Parallel()
+	store := energystore.New(afero.NewMemMapFs(), "energy")
+	power := 120.0
+	now := time.Now()
+	for i := range 3 {
+		at := now.Add(time.Duration(i-3) * time.Minute)
+		if err := store.Append("kitchen", at, []energystore.Sample{{Component: "switch:0", Power: power}}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	m := New(nil)
+	m = m.SetStore(store)
+	m = m.SetSize(80, 20)
+	m, cmd := m.CycleRange(1)
+	if cmd == nil {
+		t.Fatal("CycleRange() should return a load command")
+	}
+	if m.Range() != "24h" {
+		t.Errorf("Range() = %q, want 24h", m.Range())
+	}
+	m, _ = m.Update(cmd())
+
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, "kitchen") || !strings.Contains(view, "24h") {
+		t.Errorf("View() = %q, want stored kitchen entry and 24h footer", view)
+	}
+
+	m, _ = m.CycleRange(-1)
+	if m.Range() != "5m" || m.stored != nil {
+		t.Errorf("Range() = %q, stored = %v; want live range", m.Range(), m.stored)
+	}
+}
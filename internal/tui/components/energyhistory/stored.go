@@ -0,0 +1,122 @@
+package energyhistory
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/tj-smith47/shelly-cli/internal/shelly/energystore"
+	"github.com/tj-smith47/shelly-cli/internal/tui/debug"
+)
+
+// storedRefresh is how often a stored range is re-read from the local store.
+const storedRefresh = time.Minute
+
+// historyRange is a selectable history window. The live range (span 0) is
+// collected in memory; longer ones are read from the local energy store.
+type historyRange struct {
+	label string
+	span  time.Duration
+}
+
+var historyRanges = []historyRange{
+	{label: "5m", span: 0},
+	{label: "24h", span: 24 * time.Hour},
+	{label: "30d", span: 30 * 24 * time.Hour},
+	{label: "1y", span: 365 * 24 * time.Hour},
+}
+
+// storedHistoryMsg carries entries read from the local energy store.
+type storedHistoryMsg struct {
+	rangeIdx int
+	entries  []historyEntry
+}
+
+// SetStore enables the longer history ranges, read from the local energy
+// store filled by "shelly energy collect".
+func (m *Model) SetStore(store *energystore.Store) Model {
+	m.store = store
+	return *m
+}
+
+// Range returns the label of the selected history range.
+func (m *Model) Range() string {
+	return historyRanges[m.rangeIdx].label
+}
+
+// CycleRange selects the next longer (delta > 0) or shorter history range.
+// Ranges beyond the live one need a store.
+func (m *Model) CycleRange(delta int) (Model, tea.Cmd) {
+	if m.store == nil {
+		return *m, nil
+	}
+	idx := max(0, min(len(historyRanges)-1, m.rangeIdx+delta))
+	if idx == m.rangeIdx {
+		return *m, nil
+	}
+	m.rangeIdx = idx
+	m.stored = nil
+	m.storedAt = time.Time{}
+	m.scroller.CursorToStart()
+	return *m, m.loadStored()
+}
+
+// refreshStored returns a command re-reading the selected stored range once
+// it is older than storedRefresh.
+func (m *Model) refreshStored() tea.Cmd {
+	if m.rangeIdx == 0 || m.store == nil || time.Since(m.storedAt) < storedRefresh {
+		return nil
+	}
+	m.storedAt = time.Now()
+	return m.loadStored()
+}
+
+// loadStored reads every stored series over the selected range.
+func (m *Model) loadStored() tea.Cmd {
+	store, idx := m.store, m.rangeIdx
+	return func() tea.Msg {
+		now := time.Now()
+		return storedHistoryMsg{rangeIdx: idx, entries: readStored(store, now.Add(-historyRanges[idx].span), now)}
+	}
+}
+
+// readStored converts the store's series over [from, to) into history entries.
+func readStored(store *energystore.Store, from, to time.Time) []historyEntry {
+	series, err := store.Series("")
+	if err != nil {
+		debug.TraceEvent("energy: read store: %v", err)
+		return nil
+	}
+
+	perDevice := make(map[string]int)
+	for _, sr := range series {
+		perDevice[sr.Device]++
+	}
+
+	var entries []historyEntry
+	for _, sr := range series {
+		points, err := store.Query(sr.Device, sr.Component, energystore.Query{From: from, To: to})
+		if err != nil || len(points) == 0 {
+			continue
+		}
+		history := make([]DataPoint, len(points))
+		for i, p := range points {
+			history[i] = DataPoint{Value: p.Power, Timestamp: p.Time()}
+		}
+		_, id, _ := strings.Cut(sr.Component, ":")
+		switchID, _ := strconv.Atoi(id)
+		entries = append(entries, historyEntry{
+			key: SwitchKey{
+				DeviceName:  sr.Device,
+				SwitchID:    switchID,
+				SwitchName:  sr.Component,
+				SwitchCount: perDevice[sr.Device],
+			},
+			history: history,
+			power:   history[len(history)-1].Value,
+		})
+	}
+	return entries
+}
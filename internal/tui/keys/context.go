@@ -95,6 +95,7 @@ const (
 	ActionSave           // Ctrl+S: save changes
 	ActionHistory        // h: show energy history overlay
 	ActionPhaseDetail    // p: show 3-phase detail overlay
+	ActionSolar          // s: show solar analysis overlay
 )
 
 // KeyBinding represents a key and its description.
@@ -349,6 +350,7 @@ func (m *ContextMap) initDefaults() {
 		"d":                ActionDetail,      // Device detail overlay
 		"h":                ActionHistory,     // Energy history overlay
 		"p":                ActionPhaseDetail, // 3-phase detail overlay
+		"s":                ActionSolar,       // Solar analysis overlay
 		"n":                ActionNew,         // Create new alert
 		"e":                ActionEdit,        // Edit / toggle enable
 		keyconst.KeyEnter:  ActionEnter,
@@ -578,6 +580,7 @@ var actionDescriptions = map[Action]string{
 	ActionSave:           "Save",
 	ActionHistory:        "Energy history",
 	ActionPhaseDetail:    "3-phase detail",
+	ActionSolar:          "Solar analysis",
 }
 
 // contextActionDescriptions overrides action descriptions for specific contexts.
@@ -630,6 +633,7 @@ var contextActionDescriptions = map[Context]map[Action]string{
 		ActionViewJSON:    "Open JSON viewer",
 		ActionHistory:     "Energy history overlay",
 		ActionPhaseDetail: "3-phase detail overlay",
+		ActionSolar:       "Solar analysis overlay",
 		ActionNew:         "Create new alert",
 		ActionEdit:        "Edit / toggle enable",
 	},
@@ -62,6 +62,20 @@ type phaseDetailDataMsg struct {
 	Err        error
 }
 
+// SolarRequestMsg requests opening the solar analysis overlay with the
+// device as the grid meter.
+type SolarRequestMsg struct {
+	DeviceName string
+	Address    string
+}
+
+// solarDataMsg returns the fetched solar analysis.
+type solarDataMsg struct {
+	DeviceName string
+	Report     model.SolarReport
+	Err        error
+}
+
 // overlayBase holds common state for all overlay modals.
 type overlayBase struct {
 	deviceName string
@@ -85,6 +99,12 @@ type phaseDetailOverlay struct {
 	em *model.EMStatus
 }
 
+// solarOverlay holds state for the solar analysis modal.
+type solarOverlay struct {
+	overlayBase
+	report model.SolarReport
+}
+
 // overlayRenderConfig describes how to render an overlay modal.
 type overlayRenderConfig struct {
 	title     string
@@ -158,6 +178,11 @@ type Monitor struct {
 	phaseDetail     *phaseDetailOverlay
 	phaseDetailOpen bool
 
+	// Solar analysis overlay; the PV meter comes from energy.solar
+	solar     *solarOverlay
+	solarOpen bool
+	solarCfg  config.SolarConfig
+
 	// Focus management
 	focusState *focus.State
 	cols       monitorCols
@@ -244,6 +269,7 @@ func NewMonitor(deps MonitorDeps) *Monitor {
 		environment:  environment,
 		alerts:       alertsModel,
 		eventFeed:    eventFeed,
+		solarCfg:     energyCfg.Solar,
 		focusState:   deps.FocusState,
 		layout:       layoutCalc,
 		cols: monitorCols{
@@ -290,7 +316,7 @@ func (m *Monitor) Update(msg tea.Msg) (View, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 	}
 
-	if m.energyHistoryOpen || m.phaseDetailOpen {
+	if m.energyHistoryOpen || m.phaseDetailOpen || m.solarOpen {
 		return m.updateOverlay(msg)
 	}
 
@@ -648,7 +674,7 @@ func (m *Monitor) SetSize(width, height int) View {
 
 // HasActiveModal returns true if any component has a modal overlay visible.
 func (m *Monitor) HasActiveModal() bool {
-	return m.alertFormOpen || m.energyHistoryOpen || m.phaseDetailOpen
+	return m.alertFormOpen || m.energyHistoryOpen || m.phaseDetailOpen || m.solarOpen
 }
 
 // RenderModal returns the active modal content for full-screen rendering.
@@ -662,6 +688,9 @@ func (m *Monitor) RenderModal() string {
 	if m.phaseDetailOpen {
 		return m.renderPhaseDetailOverlay()
 	}
+	if m.solarOpen {
+		return m.renderSolarOverlay()
+	}
 	return ""
 }
 
@@ -786,6 +815,7 @@ func (m *Monitor) updateOverlay(msg tea.Msg) (View, tea.Cmd) {
 		case keyconst.KeyEsc, "q":
 			m.energyHistoryOpen = false
 			m.phaseDetailOpen = false
+			m.solarOpen = false
 			return m, nil
 		}
 	}
@@ -813,13 +843,16 @@ func (m *Monitor) handlePanelSpecificKeys(msg tea.KeyPressMsg) tea.Cmd {
 	return nil
 }
 
-// handleOverlayMessages handles energy history and phase detail request messages.
+// handleOverlayMessages handles energy history, phase detail and solar
+// analysis request messages.
 func (m *Monitor) handleOverlayMessages(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
 	case EnergyHistoryRequestMsg:
 		return m.openEnergyHistory(msg)
 	case PhaseDetailRequestMsg:
 		return m.openPhaseDetail(msg)
+	case SolarRequestMsg:
+		return m.openSolar(msg)
 	}
 	return m.handleOverlayDataMessages(msg)
 }
@@ -843,6 +876,12 @@ func (m *Monitor) handleOverlayDataMessages(msg tea.Msg) tea.Cmd {
 			m.phaseDetail.em = msg.EM
 			m.phaseDetail.err = msg.Err
 		}
+	case solarDataMsg:
+		if m.solar != nil && m.solar.deviceName == msg.DeviceName {
+			m.solar.loading = false
+			m.solar.report = msg.Report
+			m.solar.err = msg.Err
+		}
 	}
 	return nil
 }
@@ -958,6 +997,55 @@ func (m *Monitor) openPhaseDetail(msg PhaseDetailRequestMsg) tea.Cmd {
 	}
 }
 
+// solarOverlayDays is the number of days, including today, the solar
+// analysis overlay covers.
+const solarOverlayDays = 7
+
+// openSolar opens the solar analysis overlay and starts fetching data.
+func (m *Monitor) openSolar(msg SolarRequestMsg) tea.Cmd {
+	m.solar = &solarOverlay{
+		overlayBase: overlayBase{deviceName: msg.DeviceName, loading: true},
+	}
+	m.solarOpen = true
+
+	svc := m.svc
+	ctx := m.ctx
+	deviceName := msg.DeviceName
+	address := msg.Address
+	pv, pvID := m.solarCfg.PV, m.solarCfg.PVID
+	if pv == deviceName {
+		pv = ""
+	}
+
+	return func() tea.Msg {
+		snapshot, err := svc.GetMonitoringSnapshotAuto(ctx, address)
+		if err != nil {
+			return solarDataMsg{DeviceName: deviceName, Err: fmt.Errorf("get snapshot: %w", err)}
+		}
+
+		// The grid meter is the device's first EM or EM1 component
+		var gridID int
+		switch {
+		case len(snapshot.EM) > 0:
+			gridID = snapshot.EM[0].ID
+		case len(snapshot.EM1) > 0:
+			gridID = snapshot.EM1[0].ID
+		default:
+			return solarDataMsg{DeviceName: deviceName, Err: fmt.Errorf("no grid meter — only EM/EM1 devices store history")}
+		}
+
+		now := time.Now()
+		year, month, day := now.Date()
+		from := time.Date(year, month, day-(solarOverlayDays-1), 0, 0, 0, 0, now.Location())
+		report, err := svc.SolarAnalysis(ctx, address, gridID, pv, pvID, from, now)
+		if err != nil {
+			return solarDataMsg{DeviceName: deviceName, Err: err}
+		}
+		report.Grid = deviceName
+		return solarDataMsg{DeviceName: deviceName, Report: report}
+	}
+}
+
 // Sparkline characters for history overlay.
 var overlaySparkChars = []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
 
@@ -1209,6 +1297,88 @@ func scaleFloatData(data []float64, width int) []float64 {
 	return result
 }
 
+// renderSolarOverlay renders the solar analysis modal content.
+func (m *Monitor) renderSolarOverlay() string {
+	var base *overlayBase
+	if m.solar != nil {
+		base = &m.solar.overlayBase
+	}
+	return m.renderOverlayModal(overlayRenderConfig{
+		title: "Solar Analysis",
+		base:  base,
+		contentFn: func(w int) string {
+			return m.renderSolarContent(w)
+		},
+	})
+}
+
+func (m *Monitor) renderSolarContent(w int) string {
+	colors := theme.GetSemanticColors()
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(colors.Highlight)
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(colors.Text)
+	labelStyle := lipgloss.NewStyle().Foreground(colors.Muted)
+	valueStyle := lipgloss.NewStyle().Bold(true).Foreground(colors.Warning)
+
+	report := m.solar.report
+	total := report.Total
+	hasPV := report.PV != ""
+
+	title := "Solar Analysis — " + m.solar.deviceName
+	if hasPV {
+		title += " + " + report.PV
+	}
+	lines := []string{
+		titleStyle.Render(title),
+		labelStyle.Render(fmt.Sprintf("Last %d days", solarOverlayDays)),
+		"",
+		labelStyle.Render("Import:           ") + valueStyle.Render(fmt.Sprintf("%.2f kWh", total.ImportKWh)),
+		labelStyle.Render("Export:           ") + valueStyle.Render(fmt.Sprintf("%.2f kWh", total.ExportKWh)),
+	}
+	if hasPV {
+		lines = append(lines,
+			labelStyle.Render("Production:       ")+valueStyle.Render(fmt.Sprintf("%.2f kWh", total.ProductionKWh)),
+			labelStyle.Render("Self-consumption: ")+valueStyle.Render(formatOptionalFloat(total.SelfConsumption, "%.1f%%")),
+			labelStyle.Render("Autarky:          ")+valueStyle.Render(formatOptionalFloat(total.Autarky, "%.1f%%")),
+		)
+	} else {
+		lines = append(lines, labelStyle.Render("Set energy.solar.pv for self-consumption and autarky"))
+	}
+	lines = append(lines,
+		labelStyle.Render("Peak demand:      ")+valueStyle.Render(formatOverlayPower(total.PeakDemand)),
+	)
+	if total.PhaseImbalance != nil {
+		lines = append(lines,
+			labelStyle.Render("Phase imbalance:  ")+valueStyle.Render(formatOptionalFloat(total.PhaseImbalance, "%.1f%%")),
+		)
+	}
+
+	if len(report.Days) == 0 {
+		return strings.Join(append(lines, "", labelStyle.Render("No history recorded")), "\n")
+	}
+
+	// Daily breakdown, newest first
+	colW := max(10, min(14, (w-16)/4))
+	header := padRight("Date", 12) + padRight("Import", colW) + padRight("Export", colW)
+	if hasPV {
+		header += padRight("Autarky", colW)
+	}
+	header += padRight("Peak", colW)
+	lines = append(lines, "", headerStyle.Render(header), strings.Repeat("─", min(len(header), w-4)))
+	for i := len(report.Days) - 1; i >= 0; i-- {
+		day := report.Days[i]
+		row := padRight(day.Date, 12) +
+			padRight(fmt.Sprintf("%.2f kWh", day.ImportKWh), colW) +
+			padRight(fmt.Sprintf("%.2f kWh", day.ExportKWh), colW)
+		if hasPV {
+			row += padRight(formatOptionalFloat(day.Autarky, "%.1f%%"), colW)
+		}
+		row += padRight(formatOverlayPower(day.PeakDemand), colW)
+		lines = append(lines, row)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 // formatOverlayPower delegates to the shared output.FormatPower formatter.
 func formatOverlayPower(watts float64) string {
 	return output.FormatPower(watts)
@@ -1309,6 +1309,82 @@ func TestMonitor_RenderModal_PhaseDetail(t *testing.T) {
 	}
 }
 
+// --- Solar Analysis Overlay Tests ---
+
+func TestMonitor_SolarRequestMsg_OpensOverlay(t *testing.T) {
+	t.Parallel()
+	m := newTestMonitor()
+	m.SetSize(120, 40)
+
+	cmd := m.handleOverlayMessages(SolarRequestMsg{DeviceName: "house", Address: "192.168.1.30"})
+
+	if !m.solarOpen {
+		t.Error("expected solarOpen to be true after request msg")
+	}
+	if m.solar == nil || m.solar.deviceName != "house" || !m.solar.loading {
+		t.Fatalf("solar overlay = %+v, want loading for house", m.solar)
+	}
+	if cmd == nil {
+		t.Error("expected non-nil command for data fetch")
+	}
+	if !m.HasActiveModal() {
+		t.Error("expected active modal when solar analysis is open")
+	}
+}
+
+func TestMonitor_SolarDataMsg_RendersReport(t *testing.T) {
+	t.Parallel()
+	m := newTestMonitor()
+	m.SetSize(120, 40)
+
+	m.solar = &solarOverlay{overlayBase: overlayBase{deviceName: "house", loading: true}}
+	m.solarOpen = true
+
+	autarky := 55.5
+	summary := model.SolarSummary{ImportKWh: 4, ExportKWh: 2, ProductionKWh: 7, Autarky: &autarky, PeakDemand: 3200}
+	m.handleOverlayDataMessages(solarDataMsg{
+		DeviceName: "house",
+		Report: model.SolarReport{
+			Grid:  "house",
+			PV:    "inverter",
+			Days:  []model.SolarDay{{Date: "2026-06-01", SolarSummary: summary}},
+			Total: summary,
+		},
+	})
+
+	if m.solar.loading {
+		t.Error("expected loading to be false after data msg")
+	}
+	rendered := m.RenderModal()
+	for _, want := range []string{"Solar Analysis", "house + inverter", "55.5%", "2026-06-01", "3.20 kW"} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("rendered overlay missing %q", want)
+		}
+	}
+}
+
+func TestMonitor_RenderSolarOverlay_GridOnly(t *testing.T) {
+	t.Parallel()
+	m := newTestMonitor()
+	m.SetSize(120, 40)
+
+	m.solar = &solarOverlay{
+		overlayBase: overlayBase{deviceName: "house"},
+		report:      model.SolarReport{Grid: "house"},
+	}
+	m.solarOpen = true
+
+	rendered := m.renderSolarOverlay()
+	if !strings.Contains(rendered, "energy.solar.pv") || !strings.Contains(rendered, "No history recorded") {
+		t.Error("expected PV hint and empty-history message")
+	}
+
+	m.updateOverlay(tea.KeyPressMsg{Code: 0, Text: keyconst.KeyEsc})
+	if m.solarOpen {
+		t.Error("expected solar analysis to be closed after Esc")
+	}
+}
+
 // --- Overlay Dismiss Tests ---
 
 func TestMonitor_UpdateOverlay_EscClosesEnergyHistory(t *testing.T) {